@@ -36,15 +36,21 @@ type InternalChatCompletionRequest struct {
 	TaskID            string `json:"task_id,omitempty"`
 	UserID            string `json:"user_id,omitempty"`
 	AdditionalContext string `json:"additional_context,omitempty"`
+	// Stream asks for a text/event-stream response; see ChatCompletionHandler.
+	Stream bool `json:"stream,omitempty"`
 }
 
 // InternalChatCompletionResponse is the response body.
+// Usage is omitted when the inference backend reported no token counts.
 type InternalChatCompletionResponse struct {
-	Content string `json:"content"`
+	Content string             `json:"content"`
+	Usage   *InternalChatUsage `json:"usage,omitempty"`
 }
 
 // ChatCompletionHandler returns an HTTP handler for POST /internal/chat/completion.
 // It uses instructionsContent as system context and calls the configured inference backend (Ollama).
+// When the request sets stream, the response is server-sent events: data: {"content":"<fragment>"} per fragment,
+// data: {"error":"<message>"} on failure, data: {"usage":{...}} once the completion succeeded, and a final data: [DONE].
 func ChatCompletionHandler(instructionsContent string, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -61,12 +67,21 @@ func ChatCompletionHandler(instructionsContent string, logger *slog.Logger) http
 			writeJSON(w, http.StatusBadRequest, InternalChatCompletionResponse{})
 			return
 		}
+		ctx, usage := withUsage(r.Context())
+		if req.Stream {
+			streamChatCompletion(w, r.WithContext(ctx), instructionsContent, &req, usage, logger)
+			return
+		}
 		// Capture a detached root context before calling resolveContent so that
 		// a retry after context-window overflow can use a fresh timeout independent
 		// of the gateway request deadline.
-		detached := context.WithoutCancel(r.Context())
-		content, httpStatus := resolveContent(r.Context(), detached, instructionsContent, &req, logger)
-		writeJSON(w, httpStatus, InternalChatCompletionResponse{Content: content})
+		detached := context.WithoutCancel(ctx)
+		content, httpStatus := resolveContent(ctx, detached, instructionsContent, &req, logger)
+		resp := InternalChatCompletionResponse{Content: content}
+		if httpStatus == http.StatusOK {
+			resp.Usage = usage.result()
+		}
+		writeJSON(w, httpStatus, resp)
 	}
 }
 
@@ -302,6 +317,48 @@ func callInference(ctx context.Context, systemContext string, messages []struct
 	Role    string `json:"role"`
 	Content string `json:"content"`
 }, logger *slog.Logger) (string, error) {
+	req, client, err := newInferenceChatRequest(ctx, systemContext, messages, false, 120*time.Second)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("inference returned %s", resp.Status)
+	}
+	var out struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		Response        string `json:"response"`
+		Error           string `json:"error"`
+		Model           string `json:"model"`
+		PromptEvalCount int64  `json:"prompt_eval_count"`
+		EvalCount       int64  `json:"eval_count"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", err
+	}
+	if out.Error != "" {
+		return "", fmt.Errorf("inference error: %s", out.Error)
+	}
+	addUsage(ctx, out.Model, out.PromptEvalCount, out.EvalCount)
+	content := strings.TrimSpace(out.Message.Content)
+	if content == "" {
+		content = strings.TrimSpace(out.Response)
+	}
+	return content, nil
+}
+
+// newInferenceChatRequest builds the Ollama /api/chat request (system context first, then messages) and the client
+// to send it with. timeout bounds the whole call; zero leaves it to ctx.
+func newInferenceChatRequest(ctx context.Context, systemContext string, messages []struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}, stream bool, timeout time.Duration) (*http.Request, *http.Client, error) {
 	baseURL := os.Getenv("OLLAMA_BASE_URL")
 	if baseURL == "" {
 		baseURL = os.Getenv("INFERENCE_URL")
@@ -326,12 +383,12 @@ func callInference(ctx context.Context, systemContext string, messages []struct
 			"content": m.Content,
 		})
 	}
-	inferenceURL, inferenceClient := resolveInferenceClient(baseURL, 120*time.Second)
+	inferenceURL, inferenceClient := resolveInferenceClient(baseURL, timeout)
 	chatURL := strings.TrimSuffix(inferenceURL, "/") + "/api/chat"
 	body := map[string]interface{}{
 		"model":    model,
 		"messages": chatMessages,
-		"stream":   false,
+		"stream":   stream,
 	}
 	if n := ollamaNumCtxFromEnv(); n > 0 {
 		body["options"] = map[string]interface{}{"num_ctx": n}
@@ -339,34 +396,8 @@ func callInference(ctx context.Context, systemContext string, messages []struct
 	raw, _ := json.Marshal(body)
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, chatURL, bytes.NewReader(raw))
 	if err != nil {
-		return "", err
+		return nil, nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
-	client := inferenceClient
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer func() { _ = resp.Body.Close() }()
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("inference returned %s", resp.Status)
-	}
-	var out struct {
-		Message struct {
-			Content string `json:"content"`
-		} `json:"message"`
-		Response string `json:"response"`
-		Error    string `json:"error"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return "", err
-	}
-	if out.Error != "" {
-		return "", fmt.Errorf("inference error: %s", out.Error)
-	}
-	content := strings.TrimSpace(out.Message.Content)
-	if content == "" {
-		content = strings.TrimSpace(out.Response)
-	}
-	return content, nil
+	return req, inferenceClient, nil
 }
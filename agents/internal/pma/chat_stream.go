@@ -0,0 +1,141 @@
+package pma
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// streamDone is the data payload of the final server-sent event of a streamed completion.
+const streamDone = "[DONE]"
+
+// InternalChatCompletionStreamEvent is the data payload of one server-sent event of a streamed completion.
+// Usage is sent in its own event after the last fragment of a successful completion.
+type InternalChatCompletionStreamEvent struct {
+	Content string             `json:"content,omitempty"`
+	Error   string             `json:"error,omitempty"`
+	Usage   *InternalChatUsage `json:"usage,omitempty"`
+}
+
+// streamChatCompletion writes the completion for req as server-sent events, flushing each fragment as it arrives.
+// Once the stream has started the status is always 200; failures are reported as an error event.
+func streamChatCompletion(w http.ResponseWriter, r *http.Request, instructionsContent string, req *InternalChatCompletionRequest, usage *usageAccumulator, logger *slog.Logger) {
+	rc := http.NewResponseController(w)
+	// Streams outlive the server write timeout; the completion timeouts bound the call instead.
+	_ = rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	send := func(ev InternalChatCompletionStreamEvent) {
+		b, _ := json.Marshal(ev)
+		_, _ = fmt.Fprintf(w, "data: %s\n\n", b)
+		_ = rc.Flush()
+	}
+	detached := context.WithoutCancel(r.Context())
+	content, err := streamCompletionContent(r.Context(), instructionsContent, req, logger, func(delta string) {
+		send(InternalChatCompletionStreamEvent{Content: delta})
+	})
+	if err == nil && strings.TrimSpace(content) == "" {
+		// Nothing was streamed: same context-window fallback as the non-streaming path, sent as one fragment.
+		retryCtx, cancel := context.WithTimeout(detached, pmaLangchainCompletionTimeout)
+		content, err = getCompletionContent(retryCtx, instructionsContent, stripToCurrentMessage(req), logger)
+		cancel()
+		if err == nil && strings.TrimSpace(content) == "" {
+			err = fmt.Errorf("empty completion after retry")
+		}
+		if err == nil {
+			send(InternalChatCompletionStreamEvent{Content: content})
+		}
+	}
+	if err != nil {
+		logger.Error("chat completion stream error", "error", err)
+		send(InternalChatCompletionStreamEvent{Error: "completion failed"})
+	}
+	if u := usage.result(); err == nil && u != nil {
+		send(InternalChatCompletionStreamEvent{Usage: u})
+	}
+	_, _ = fmt.Fprintf(w, "data: %s\n\n", streamDone)
+	_ = rc.Flush()
+}
+
+// streamCompletionContent runs the same inference path as getCompletionContent and calls onDelta with the answer.
+// The direct Ollama path streams token fragments; the langchaingo agent path runs its tool loop to completion
+// and sends the final answer as one fragment.
+func streamCompletionContent(ctx context.Context, instructionsContent string, req *InternalChatCompletionRequest, logger *slog.Logger, onDelta func(string)) (string, error) {
+	model := os.Getenv("INFERENCE_MODEL")
+	if model == "" {
+		model = pmaDefaultModel
+	}
+	if NewMCPClient().BaseURL != "" && isCapableModel(model) {
+		content, err := getCompletionContent(ctx, instructionsContent, req, logger)
+		if err == nil && strings.TrimSpace(content) != "" {
+			onDelta(content)
+		}
+		return content, err
+	}
+	return callInferenceStream(ctx, buildSystemContext(instructionsContent, req), req.Messages, onDelta)
+}
+
+// callInferenceStream calls Ollama /api/chat with stream enabled and passes each message content fragment to onDelta.
+func callInferenceStream(ctx context.Context, systemContext string, messages []struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}, onDelta func(string)) (string, error) {
+	streamCtx, cancel := context.WithTimeout(ctx, pmaLangchainCompletionTimeout)
+	defer cancel()
+	req, client, err := newInferenceChatRequest(streamCtx, systemContext, messages, true, 0)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("inference returned %s", resp.Status)
+	}
+	var out strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var chunk struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+			Error           string `json:"error"`
+			Done            bool   `json:"done"`
+			Model           string `json:"model"`
+			PromptEvalCount int64  `json:"prompt_eval_count"`
+			EvalCount       int64  `json:"eval_count"`
+		}
+		if json.Unmarshal([]byte(line), &chunk) != nil {
+			continue
+		}
+		if chunk.Error != "" {
+			return "", fmt.Errorf("inference error: %s", chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			out.WriteString(chunk.Message.Content)
+			onDelta(chunk.Message.Content)
+		}
+		if chunk.Done {
+			addUsage(ctx, chunk.Model, chunk.PromptEvalCount, chunk.EvalCount)
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
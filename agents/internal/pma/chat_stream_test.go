@@ -0,0 +1,82 @@
+package pma
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func streamEvents(t *testing.T, body string) (events []InternalChatCompletionStreamEvent, done bool) {
+	t.Helper()
+	for _, line := range strings.Split(body, "\n") {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		if data == streamDone {
+			done = true
+			continue
+		}
+		var ev InternalChatCompletionStreamEvent
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			t.Fatalf("decode event %q: %v", data, err)
+		}
+		events = append(events, ev)
+	}
+	return events, done
+}
+
+func TestChatCompletionHandler_StreamDirectInference(t *testing.T) {
+	mockOllama := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["stream"] != true {
+			t.Errorf("stream not requested: %v", body["stream"])
+		}
+		_, _ = w.Write([]byte(`{"message":{"role":"assistant","content":"hel"},"done":false}` + "\n" +
+			`{"message":{"role":"assistant","content":"lo"},"done":false}` + "\n" +
+			`{"model":"qwen3.5:0.8b","message":{"role":"assistant","content":""},"done":true,"prompt_eval_count":9,"eval_count":2}` + "\n"))
+	}))
+	defer mockOllama.Close()
+	t.Setenv("PMA_MCP_GATEWAY_URL", "")
+	t.Setenv("OLLAMA_BASE_URL", mockOllama.URL)
+	t.Setenv("INFERENCE_MODEL", "qwen3.5:0.8b")
+
+	handler := ChatCompletionHandler("sys", slog.Default())
+	req := httptest.NewRequest(http.MethodPost, "/internal/chat/completion",
+		bytes.NewReader([]byte(`{"messages":[{"role":"user","content":"hi"}],"stream":true}`)))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status %d content-type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	events, done := streamEvents(t, rec.Body.String())
+	if !done || len(events) != 3 || events[0].Content != "hel" || events[1].Content != "lo" {
+		t.Fatalf("events = %+v done=%v", events, done)
+	}
+	if u := events[2].Usage; u == nil || u.Model != "qwen3.5:0.8b" || u.PromptTokens != 9 || u.CompletionTokens != 2 {
+		t.Errorf("usage event = %+v", events[2])
+	}
+}
+
+func TestChatCompletionHandler_StreamInferenceError(t *testing.T) {
+	mockOllama := newMockInferenceServer(t, http.StatusInternalServerError, `{}`)
+	defer mockOllama.Close()
+	t.Setenv("PMA_MCP_GATEWAY_URL", "")
+	t.Setenv("OLLAMA_BASE_URL", mockOllama.URL)
+	t.Setenv("INFERENCE_MODEL", "qwen3.5:0.8b")
+
+	handler := ChatCompletionHandler("sys", slog.Default())
+	req := httptest.NewRequest(http.MethodPost, "/internal/chat/completion",
+		bytes.NewReader([]byte(`{"messages":[{"role":"user","content":"hi"}],"stream":true}`)))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	events, done := streamEvents(t, rec.Body.String())
+	if !done || len(events) != 1 || events[0].Error == "" {
+		t.Errorf("events = %+v done=%v", events, done)
+	}
+}
@@ -54,7 +54,7 @@ func TestChatCompletionHandler_BadRequest(t *testing.T) {
 }
 
 func TestChatCompletionHandler_Success(t *testing.T) {
-	mockInference := newMockInferenceServer(t, http.StatusOK, `{"response":"hello"}`)
+	mockInference := newMockInferenceServer(t, http.StatusOK, `{"model":"m","response":"hello","prompt_eval_count":4,"eval_count":3}`)
 	defer mockInference.Close()
 	oldURL := os.Getenv("OLLAMA_BASE_URL")
 	_ = os.Setenv("OLLAMA_BASE_URL", mockInference.URL)
@@ -74,7 +74,8 @@ func TestChatCompletionHandler_Success(t *testing.T) {
 	if rec.Code != http.StatusOK {
 		t.Errorf("got status %d, want 200", rec.Code)
 	}
-	if !bytes.Contains(rec.Body.Bytes(), []byte(`"content":"hello"`)) {
+	if !bytes.Contains(rec.Body.Bytes(), []byte(`"content":"hello"`)) ||
+		!bytes.Contains(rec.Body.Bytes(), []byte(`"usage":{"model":"m","prompt_tokens":4,"completion_tokens":3}`)) {
 		t.Errorf("body = %q", rec.Body.String())
 	}
 }
@@ -132,6 +132,8 @@ func runCompletionWithLangchain(ctx context.Context, fullPrompt string, mcpClien
 		}
 	}
 
+	llm = usageRecordingModel{Model: llm, model: model}
+
 	if logger != nil {
 		logger.Debug("using langchain functions agent path", "model", model)
 	}
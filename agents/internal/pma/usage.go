@@ -0,0 +1,88 @@
+package pma
+
+import (
+	"context"
+	"sync"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// InternalChatUsage is the model token usage of one completion, summed over every inference call it made
+// (agent iterations and retries included). The orchestrator records it against the chat's user and project.
+type InternalChatUsage struct {
+	Model            string `json:"model"`
+	PromptTokens     int64  `json:"prompt_tokens"`
+	CompletionTokens int64  `json:"completion_tokens"`
+}
+
+type usageKey struct{}
+
+// usageAccumulator collects the token counts of the inference calls made for one request.
+type usageAccumulator struct {
+	mu    sync.Mutex
+	usage InternalChatUsage
+}
+
+// withUsage returns a context that accumulates the usage of inference calls made with it.
+func withUsage(ctx context.Context) (context.Context, *usageAccumulator) {
+	acc := &usageAccumulator{}
+	return context.WithValue(ctx, usageKey{}, acc), acc
+}
+
+// addUsage adds one inference call's token counts to the accumulator in ctx, if any.
+func addUsage(ctx context.Context, model string, prompt, completion int64) {
+	acc, ok := ctx.Value(usageKey{}).(*usageAccumulator)
+	if !ok || (prompt <= 0 && completion <= 0) {
+		return
+	}
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	if acc.usage.Model == "" {
+		acc.usage.Model = model
+	}
+	acc.usage.PromptTokens += prompt
+	acc.usage.CompletionTokens += completion
+}
+
+// result returns the accumulated usage, or nil when no call reported token counts.
+func (a *usageAccumulator) result() *InternalChatUsage {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.usage.PromptTokens == 0 && a.usage.CompletionTokens == 0 {
+		return nil
+	}
+	u := a.usage
+	return &u
+}
+
+// usageRecordingModel wraps the langchaingo model so each agent step's token counts
+// (ContentChoice.GenerationInfo PromptTokens / CompletionTokens) reach the request's accumulator.
+type usageRecordingModel struct {
+	llms.Model
+	model string
+}
+
+func (m usageRecordingModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	resp, err := m.Model.GenerateContent(ctx, messages, options...)
+	if err == nil && resp != nil {
+		for _, c := range resp.Choices {
+			if c == nil {
+				continue
+			}
+			addUsage(ctx, m.model, generationInfoInt(c.GenerationInfo, "PromptTokens"), generationInfoInt(c.GenerationInfo, "CompletionTokens"))
+		}
+	}
+	return resp, err
+}
+
+func generationInfoInt(info map[string]any, key string) int64 {
+	switch v := info[key].(type) {
+	case int:
+		return int64(v)
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
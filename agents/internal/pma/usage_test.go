@@ -0,0 +1,40 @@
+package pma
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+type usageInfoLLM struct{ mockLLM }
+
+func (m *usageInfoLLM) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{
+		Content:        "ok",
+		GenerationInfo: map[string]any{"PromptTokens": 7, "CompletionTokens": 3},
+	}}}, nil
+}
+
+func TestUsageRecordingModel(t *testing.T) {
+	ctx, acc := withUsage(context.Background())
+	llm := usageRecordingModel{Model: &usageInfoLLM{}, model: "qwen3.5:9b"}
+	for range 2 {
+		if _, err := llm.GenerateContent(ctx, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := acc.result()
+	if got == nil || *got != (InternalChatUsage{Model: "qwen3.5:9b", PromptTokens: 14, CompletionTokens: 6}) {
+		t.Errorf("usage = %+v", got)
+	}
+
+	// No accumulator on the context, or no counts reported: nothing recorded.
+	if _, err := llm.GenerateContent(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	_, empty := withUsage(context.Background())
+	if empty.result() != nil {
+		t.Error("empty accumulator should return nil")
+	}
+}
@@ -306,16 +306,16 @@ func (s *cynorkState) mockGatewayMux() *http.ServeMux {
 			},
 		})
 	})
-	// Stub endpoints for creds, prefs, settings, nodes, skills, audit
-	mux.HandleFunc("GET /v1/creds", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("GET /v1/credentials", func(w http.ResponseWriter, r *http.Request) {
 		if r.Header.Get("Authorization") == "" {
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("[]"))
+		_, _ = w.Write([]byte(`{"credentials":[]}`))
 	})
+	// Stub endpoints for prefs, settings, nodes, skills, audit
 	mux.HandleFunc("GET /v1/nodes", func(w http.ResponseWriter, r *http.Request) {
 		if r.Header.Get("Authorization") == "" {
 			w.WriteHeader(http.StatusUnauthorized)
@@ -332,7 +332,7 @@ func (s *cynorkState) mockGatewayMux() *http.ServeMux {
 		}
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("[]"))
+		_, _ = w.Write([]byte(`{"events":[]}`))
 	})
 	mux.HandleFunc("POST /v1/prefs", func(w http.ResponseWriter, r *http.Request) {
 		if r.Header.Get("Authorization") == "" {
@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+
+	"github.com/cypher0n3/cynodeai/cynork/internal/exit"
+	"github.com/cypher0n3/cynodeai/cynork/internal/gateway"
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+)
+
+var (
+	adminUserEmail         string
+	adminUserPasswordStdin bool
+	adminUserPasswordFile  string
+	adminUserMustChange    bool
+	adminUserDeleteYes     bool
+)
+
+var adminCmd = &cobra.Command{
+	Use:   "admin",
+	Short: "Administration commands (requires users.manage)",
+}
+
+var adminUsersCmd = &cobra.Command{
+	Use:   "users",
+	Short: "Manage local user accounts",
+}
+
+var adminUsersListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List users, service accounts included",
+	Args:  cobra.NoArgs,
+	RunE:  runAdminUsersList,
+}
+
+var adminUsersCreateCmd = &cobra.Command{
+	Use:   "create <handle>",
+	Short: "Create a user with a password",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runAdminUsersCreate,
+}
+
+var adminUsersDisableCmd = &cobra.Command{
+	Use:   "disable <user>",
+	Short: "Disable a user and revoke their sessions",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runAdminUsersDisable,
+}
+
+var adminUsersEnableCmd = &cobra.Command{
+	Use:   "enable <user>",
+	Short: "Enable a disabled user",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runAdminUsersEnable,
+}
+
+var adminUsersDeleteCmd = &cobra.Command{
+	Use:   "delete <user>",
+	Short: "Delete a user",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runAdminUsersDelete,
+}
+
+var adminUsersResetPasswordCmd = &cobra.Command{
+	Use:   "reset-password <user>",
+	Short: "Set a new password and revoke the user's sessions",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runAdminUsersResetPassword,
+}
+
+func init() {
+	rootCmd.AddCommand(adminCmd)
+	adminCmd.AddCommand(adminUsersCmd)
+	adminUsersCmd.AddCommand(adminUsersListCmd, adminUsersCreateCmd, adminUsersDisableCmd, adminUsersEnableCmd,
+		adminUsersDeleteCmd, adminUsersResetPasswordCmd)
+	adminUsersCreateCmd.Flags().StringVar(&adminUserEmail, "email", "", "email address")
+	for _, c := range []*cobra.Command{adminUsersCreateCmd, adminUsersResetPasswordCmd} {
+		c.Flags().BoolVar(&adminUserPasswordStdin, "password-stdin", false, "read the password from stdin")
+		c.Flags().StringVar(&adminUserPasswordFile, "password-file", "", "read the password from a file")
+		c.Flags().BoolVar(&adminUserMustChange, "must-change-password", true, "require a new password at next login")
+	}
+	adminUsersDeleteCmd.Flags().BoolVarP(&adminUserDeleteYes, "yes", "y", false, "skip confirmation")
+}
+
+func runAdminUsersList(_ *cobra.Command, _ []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	resp, err := client.ListUsers()
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(resp)
+		return nil
+	}
+	fmt.Println("user_id\thandle\temail\tactive\tservice_account")
+	for i := range resp.Users {
+		u := &resp.Users[i]
+		fmt.Printf("%s\t%s\t%s\t%t\t%t\n", u.ID, u.Handle, derefOrDash(u.Email), u.IsActive, u.IsServiceAccount)
+	}
+	return nil
+}
+
+func runAdminUsersCreate(_ *cobra.Command, args []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	password, err := readAdminUserPassword()
+	if err != nil {
+		return err
+	}
+	req := &userapi.CreateUserRequest{Handle: args[0], Password: password, MustChangePassword: adminUserMustChange}
+	if adminUserEmail != "" {
+		req.Email = &adminUserEmail
+	}
+	resp, err := client.CreateUser(req)
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	printAdminUser(resp)
+	return nil
+}
+
+func runAdminUsersDisable(_ *cobra.Command, args []string) error {
+	return setAdminUserActive(args[0], (*gateway.Client).DisableUser)
+}
+
+func runAdminUsersEnable(_ *cobra.Command, args []string) error {
+	return setAdminUserActive(args[0], (*gateway.Client).EnableUser)
+}
+
+func setAdminUserActive(user string, call func(*gateway.Client, string) (*userapi.UserResponse, error)) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	id, err := resolveUserID(client, user)
+	if err != nil {
+		return err
+	}
+	resp, err := call(client, id)
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	printAdminUser(resp)
+	return nil
+}
+
+func runAdminUsersDelete(_ *cobra.Command, args []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	id, err := resolveUserID(client, args[0])
+	if err != nil {
+		return err
+	}
+	if !adminUserDeleteYes {
+		fmt.Fprintf(os.Stderr, "Delete user %s? [y/N] ", args[0])
+		var ch string
+		if _, err := fmt.Scanln(&ch); err != nil || (ch != "y" && ch != "Y") {
+			return nil
+		}
+	}
+	if err := client.DeleteUser(id); err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(map[string]any{"user_id": id, "deleted": true})
+		return nil
+	}
+	fmt.Printf("user_id=%s deleted=true\n", id)
+	return nil
+}
+
+func runAdminUsersResetPassword(_ *cobra.Command, args []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	id, err := resolveUserID(client, args[0])
+	if err != nil {
+		return err
+	}
+	password, err := readAdminUserPassword()
+	if err != nil {
+		return err
+	}
+	if err := client.ResetPassword(id, &userapi.ResetPasswordRequest{Password: password, MustChangePassword: adminUserMustChange}); err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(map[string]any{"user_id": id, "password_reset": true, "must_change_password": adminUserMustChange})
+		return nil
+	}
+	fmt.Printf("user_id=%s password_reset=true must_change_password=%t\n", id, adminUserMustChange)
+	return nil
+}
+
+// resolveUserID accepts a user id or handle and returns the id, looking handles up in the user list.
+func resolveUserID(client *gateway.Client, user string) (string, error) {
+	if _, err := uuid.Parse(user); err == nil {
+		return user, nil
+	}
+	resp, err := client.ListUsers()
+	if err != nil {
+		return "", exitFromGatewayErr(err)
+	}
+	for i := range resp.Users {
+		if resp.Users[i].Handle == user {
+			return resp.Users[i].ID, nil
+		}
+	}
+	return "", exit.NotFound(fmt.Errorf("user %q not found", user))
+}
+
+func printAdminUser(u *userapi.UserResponse) {
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(u)
+		return
+	}
+	fmt.Printf("user_id=%s handle=%s active=%t\n", u.ID, u.Handle, u.IsActive)
+}
+
+// readAdminUserPassword reads the password from exactly one of --password-stdin, --password-file, or a no-echo
+// prompt. It is never printed.
+func readAdminUserPassword() (string, error) {
+	if adminUserPasswordStdin && adminUserPasswordFile != "" {
+		return "", exit.Usage(fmt.Errorf("use only one of --password-stdin and --password-file"))
+	}
+	var password string
+	switch {
+	case adminUserPasswordStdin:
+		p, err := readPasswordFromStdin()
+		if err != nil {
+			return "", fmt.Errorf("read password: %w", err)
+		}
+		password = p
+	case adminUserPasswordFile != "":
+		raw, err := os.ReadFile(adminUserPasswordFile)
+		if err != nil {
+			return "", exit.Usage(fmt.Errorf("read password file: %w", err))
+		}
+		password = trimOneNewline(string(raw))
+	default:
+		p, err := readPassword("Password: ")
+		if err != nil {
+			return "", fmt.Errorf("read password: %w", err)
+		}
+		password = p
+	}
+	if password == "" {
+		return "", exit.Usage(fmt.Errorf("password is empty"))
+	}
+	return password, nil
+}
@@ -1,26 +1,160 @@
-//nolint:dupl // stub command structure shared with creds, nodes
 package cmd
 
 import (
+	"fmt"
+	"os"
+
+	"github.com/cypher0n3/cynodeai/cynork/internal/exit"
+	"github.com/cypher0n3/cynodeai/cynork/internal/gateway"
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
 	"github.com/spf13/cobra"
 )
 
+var (
+	auditFilter       gateway.AuditEventFilter
+	auditExportFormat string
+	auditExportOut    string
+)
+
 var auditCmd = &cobra.Command{
 	Use:   "audit",
-	Short: "Audit logs (stub until orchestrator supports)",
+	Short: "Search and export the audit log (admin)",
 }
 
 var auditListCmd = &cobra.Command{
 	Use:   "list",
-	Short: "List audit events",
+	Short: "List audit events, newest first",
+	Args:  cobra.NoArgs,
 	RunE:  runAuditList,
 }
 
+var auditGetCmd = &cobra.Command{
+	Use:   "get <event_id>",
+	Short: "Show an audit event",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runAuditGet,
+}
+
+var auditExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export every matching audit event as JSONL or CSV",
+	Args:  cobra.NoArgs,
+	RunE:  runAuditExport,
+}
+
 func init() {
 	rootCmd.AddCommand(auditCmd)
-	auditCmd.AddCommand(auditListCmd)
+	auditCmd.AddCommand(auditListCmd, auditGetCmd, auditExportCmd)
+	for _, c := range []*cobra.Command{auditListCmd, auditExportCmd} {
+		f := c.Flags()
+		f.StringVar(&auditFilter.Since, "since", "", "only events at or after this time (RFC 3339)")
+		f.StringVar(&auditFilter.Until, "until", "", "only events before this time (RFC 3339)")
+		f.StringVar(&auditFilter.ActorID, "actor-id", "", "filter by acting user id")
+		f.StringVar(&auditFilter.TaskID, "task-id", "", "filter by task id")
+		f.StringVar(&auditFilter.ProjectID, "project-id", "", "filter by project id")
+		f.StringVar(&auditFilter.Source, "source", "", "auth, mcp_tool_call, access_control, chat, or preference")
+		f.StringVar(&auditFilter.Action, "action", "", "event type (e.g. auth.login_failure; auth.* matches a prefix)")
+		f.StringVar(&auditFilter.ResourceType, "resource-type", "", "filter by resource type")
+		f.StringVar(&auditFilter.Decision, "decision", "", "allow or deny")
+	}
+	auditListCmd.Flags().IntVar(&auditFilter.Limit, "limit", 50, "page size (1-200)")
+	auditListCmd.Flags().StringVar(&auditFilter.Cursor, "cursor", "", "pagination cursor (next_cursor of the previous page)")
+	auditExportCmd.Flags().StringVar(&auditExportFormat, "format", userapi.AuditFormatJSONL, "jsonl or csv")
+	auditExportCmd.Flags().StringVar(&auditExportOut, "out", "", "file to write the export to (default: stdout)")
 }
 
 func runAuditList(_ *cobra.Command, _ []string) error {
-	return runStubList("/v1/audit")
+	if auditFilter.Limit < 1 || auditFilter.Limit > 200 {
+		return exit.Usage(fmt.Errorf("--limit must be between 1 and 200"))
+	}
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	resp, err := client.ListAuditEvents(auditFilter)
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(resp)
+		return nil
+	}
+	fmt.Println("event_id\tts\tactor_id\taction\tresource_type\tresource_id\tdecision")
+	for i := range resp.Events {
+		e := &resp.Events[i]
+		actorID := "-"
+		if e.ActorID != nil {
+			actorID = *e.ActorID
+		}
+		fmt.Printf("%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.EventID, e.Timestamp, actorID, e.Action, e.ResourceType, e.ResourceID, e.Decision)
+	}
+	if resp.NextCursor != "" {
+		fmt.Fprintf(os.Stderr, "next_cursor=%s\n", resp.NextCursor)
+	}
+	return nil
+}
+
+func runAuditGet(_ *cobra.Command, args []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	e, err := client.GetAuditEvent(args[0])
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(e)
+		return nil
+	}
+	fmt.Printf("event_id=%s\nts=%s\nsource=%s\n", e.EventID, e.Timestamp, e.Source)
+	for _, kv := range []struct {
+		key string
+		val *string
+	}{{"actor_id", e.ActorID}, {"actor_handle", e.ActorHandle}, {"task_id", e.TaskID}, {"project_id", e.ProjectID}} {
+		if kv.val != nil {
+			fmt.Printf("%s=%s\n", kv.key, *kv.val)
+		}
+	}
+	fmt.Printf("action=%s\nresource_type=%s\nresource_id=%s\ndecision=%s\n", e.Action, e.ResourceType, e.ResourceID, e.Decision)
+	if e.Details != nil {
+		fmt.Printf("details=%q\n", *e.Details)
+	}
+	return nil
+}
+
+// runAuditExport streams the export to --out, or to stdout when --out is empty. A failed export removes --out.
+func runAuditExport(_ *cobra.Command, _ []string) error {
+	if auditExportFormat != userapi.AuditFormatJSONL && auditExportFormat != userapi.AuditFormatCSV {
+		return exit.Usage(fmt.Errorf("--format must be jsonl or csv"))
+	}
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	if auditExportOut == "" {
+		if err := client.ExportAuditEvents(auditFilter, auditExportFormat, os.Stdout); err != nil {
+			return exitFromGatewayErr(err)
+		}
+		return nil
+	}
+	f, err := os.Create(auditExportOut)
+	if err != nil {
+		return exit.Internal(err)
+	}
+	err = client.ExportAuditEvents(auditFilter, auditExportFormat, f)
+	if cerr := f.Close(); err == nil && cerr != nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(auditExportOut)
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(map[string]any{"saved": true, "path": auditExportOut})
+		return nil
+	}
+	fmt.Printf("saved=true path=%s\n", auditExportOut)
+	return nil
 }
@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/cypher0n3/cynodeai/cynork/internal/config"
 	"github.com/cypher0n3/cynodeai/cynork/internal/exit"
@@ -18,18 +19,24 @@ import (
 var (
 	authLoginHandle        string
 	authLoginPasswordStdin bool
+	authLoginSSO           bool
 )
 
+// ssoPollSleep waits between device sign-in polls; tests replace it.
+var ssoPollSleep = time.Sleep
+
 // authCmd represents the auth command group.
 var authCmd = &cobra.Command{
 	Use:   "auth",
-	Short: "Authentication (login, logout, whoami)",
+	Short: "Authentication (login, logout, whoami, sessions)",
 }
 
 var authLoginCmd = &cobra.Command{
 	Use:   "login",
 	Short: "Log in and store token",
-	RunE:  runAuthLogin,
+	Long: "Logs in with a handle and password, or with --sso through the gateway's identity provider: cynork " +
+		"prints a URL and code to enter in a browser and waits until sign-in completes.",
+	RunE: runAuthLogin,
 }
 
 var authLogoutCmd = &cobra.Command{
@@ -57,16 +64,24 @@ func init() {
 	authLoginCmd.Flags().StringVarP(&authLoginHandle, "user", "u", "", "login user handle")
 	authLoginCmd.Flags().StringVar(&authLoginHandle, "handle", "", "login handle (alias of --user)")
 	authLoginCmd.Flags().BoolVar(&authLoginPasswordStdin, "password-stdin", false, "read password from stdin")
+	authLoginCmd.Flags().BoolVar(&authLoginSSO, "sso", false, "sign in with the identity provider (device code flow)")
 }
 
 func runAuthLogin(_ *cobra.Command, _ []string) error {
+	if authLoginSSO {
+		return runAuthLoginSSO()
+	}
 	handle, password, err := gatherLoginCredentials()
 	if err != nil {
 		return err
 	}
 	client := gateway.NewClient(cfg.GatewayURL)
-	resp, err := client.Login(userapi.LoginRequest{Handle: handle, Password: password})
-	if err != nil {
+	resp, err := client.Login(userapi.LoginRequest{Handle: handle, Password: password, ClientName: gateway.ClientName})
+	if gateway.IsPasswordChangeRequired(err) {
+		if resp, err = loginWithNewPassword(client, handle, password); err != nil {
+			return err
+		}
+	} else if err != nil {
 		return exitFromGatewayErr(err)
 	}
 	cfg.Token = resp.AccessToken
@@ -74,14 +89,109 @@ func runAuthLogin(_ *cobra.Command, _ []string) error {
 	if err := saveConfig(); err != nil {
 		return err
 	}
+	printLoggedIn(handle)
+	return nil
+}
+
+// loginWithNewPassword logs in an account that must change its password, asking for the new one.
+// With --password-stdin there is no terminal to ask on, so the user is told to log in interactively.
+func loginWithNewPassword(client *gateway.Client, handle, password string) (*userapi.LoginResponse, error) {
+	if authLoginPasswordStdin {
+		return nil, exit.Auth(fmt.Errorf("password change required: run 'cynork auth login' interactively to set a new password"))
+	}
+	newPassword, err := promptNewPassword()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Login(userapi.LoginRequest{
+		Handle: handle, Password: password, NewPassword: newPassword, ClientName: gateway.ClientName,
+	})
+	if err != nil {
+		return nil, exitFromGatewayErr(err)
+	}
+	return resp, nil
+}
+
+// promptNewPassword asks for a new password twice; a variable so tests can replace the terminal prompt.
+var promptNewPassword = func() (string, error) {
+	_, _ = fmt.Fprintln(os.Stderr, "Your password must be changed before you can continue.")
+	newPassword, err := readPassword("New password: ")
+	if err != nil {
+		return "", err
+	}
+	confirm, err := readPassword("Confirm new password: ")
+	if err != nil {
+		return "", err
+	}
+	if newPassword != confirm {
+		return "", exit.Usage(fmt.Errorf("passwords do not match"))
+	}
+	return newPassword, nil
+}
+
+func printLoggedIn(handle string) {
 	if outputFmt == outputFormatJSON {
 		_ = jsonOutputEncoder().Encode(map[string]any{"logged_in": true, "user": handle})
 	} else {
 		fmt.Printf("logged_in=true user=%s\n", handle)
 	}
+}
+
+// runAuthLoginSSO signs in through the gateway's device authorization endpoints: it shows the verification URL
+// and user code on stderr, then polls until the user completes sign-in, the code expires, or it is denied.
+func runAuthLoginSSO() error {
+	if authLoginHandle != "" || authLoginPasswordStdin {
+		return exit.Usage(fmt.Errorf("--sso cannot be combined with --user or --password-stdin"))
+	}
+	client := gateway.NewClient(cfg.GatewayURL)
+	da, err := client.StartSSODevice()
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "To sign in, open %s and enter code %s\n", da.VerificationURI, da.UserCode)
+	if da.VerificationURIComplete != "" {
+		_, _ = fmt.Fprintf(os.Stderr, "or open %s\n", da.VerificationURIComplete)
+	}
+	resp, err := pollSSODevice(client, da)
+	if err != nil {
+		return err
+	}
+	cfg.Token = resp.AccessToken
+	cfg.RefreshToken = resp.RefreshToken
+	if err := saveConfig(); err != nil {
+		return err
+	}
+	client.SetToken(resp.AccessToken)
+	user, err := client.GetMe()
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	printLoggedIn(user.Handle)
 	return nil
 }
 
+func pollSSODevice(client *gateway.Client, da *userapi.SSODeviceAuthorizationResponse) (*userapi.LoginResponse, error) {
+	interval := time.Duration(da.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	deadline := time.Now().Add(time.Duration(da.ExpiresIn) * time.Second)
+	for da.ExpiresIn <= 0 || time.Now().Before(deadline) {
+		ssoPollSleep(interval)
+		resp, status, err := client.PollSSODevice(da.DeviceCode)
+		if err != nil {
+			return nil, exitFromGatewayErr(err)
+		}
+		if resp != nil {
+			return resp, nil
+		}
+		if status == userapi.SSODeviceStatusSlowDown {
+			interval += 5 * time.Second
+		}
+	}
+	return nil, exit.Auth(fmt.Errorf("sign-in code expired: run 'cynork auth login --sso' again"))
+}
+
 func gatherLoginCredentials() (handle, password string, err error) {
 	handle = authLoginHandle
 	if authLoginPasswordStdin && strings.TrimSpace(handle) == "" {
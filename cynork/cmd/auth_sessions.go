@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var authSessionsRevokeYes bool
+
+var authSessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "List and revoke your signed-in sessions",
+}
+
+var authSessionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List active sessions with their client, user agent, and IP address",
+	Args:  cobra.NoArgs,
+	RunE:  runAuthSessionsList,
+}
+
+var authSessionsRevokeCmd = &cobra.Command{
+	Use:   "revoke <session_id>",
+	Short: "Sign out a session",
+	Long: "Revokes a session: its refresh token is invalidated and its access tokens are rejected at once. " +
+		"Revoking the current session signs this cynork out; run 'cynork auth login' again.",
+	Args: cobra.ExactArgs(1),
+	RunE: runAuthSessionsRevoke,
+}
+
+func init() {
+	authCmd.AddCommand(authSessionsCmd)
+	authSessionsCmd.AddCommand(authSessionsListCmd, authSessionsRevokeCmd)
+	authSessionsRevokeCmd.Flags().BoolVarP(&authSessionsRevokeYes, "yes", "y", false, "skip confirmation")
+}
+
+func runAuthSessionsList(_ *cobra.Command, _ []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	resp, err := client.ListSessions()
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(resp)
+		return nil
+	}
+	fmt.Println("session_id\tclient_name\tuser_agent\tip_address\tcurrent\tcreated_at\tlast_used_at\texpires_at")
+	for i := range resp.Sessions {
+		s := &resp.Sessions[i]
+		fmt.Printf("%s\t%s\t%s\t%s\t%t\t%s\t%s\t%s\n", s.ID, derefOrDash(s.ClientName), derefOrDash(s.UserAgent),
+			derefOrDash(s.IPAddress), s.Current, s.CreatedAt, derefOrDash(s.LastUsedAt), s.ExpiresAt)
+	}
+	return nil
+}
+
+func runAuthSessionsRevoke(_ *cobra.Command, args []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	id := args[0]
+	if !authSessionsRevokeYes {
+		fmt.Fprintf(os.Stderr, "Revoke session %s? [y/N] ", id)
+		var ch string
+		if _, err := fmt.Scanln(&ch); err != nil || (ch != "y" && ch != "Y") {
+			return nil
+		}
+	}
+	if err := client.RevokeSession(id); err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(map[string]any{"session_id": id, "revoked": true})
+		return nil
+	}
+	fmt.Printf("session_id=%s revoked=true\n", id)
+	return nil
+}
@@ -21,6 +21,7 @@ var chatPlain bool
 var chatMessage string
 var chatProjectID string // --project-id flag (initial project for session)
 var chatThreadNew bool   // --thread-new flag: create a fresh thread before sending/opening session
+var chatNoStream bool    // --no-stream flag: wait for the whole response and render it
 
 // chatSessionModel and chatSessionProjectID are the in-session model and project (set via /model, /project).
 var chatSessionModel string
@@ -29,7 +30,7 @@ var chatSessionProjectID string
 var chatCmd = &cobra.Command{
 	Use:          "chat",
 	Short:        "Interactive chat with the Project Manager (POST /v1/chat/completions)",
-	Long:         "Reads lines from stdin; /exit or /quit or EOF exits. Each message is sent via POST /v1/chat/completions (OpenAI format). Use --message for one-shot (send one message and print response). No token yields exit 3. Use --plain for raw output (no Markdown rendering).",
+	Long:         "Reads lines from stdin; /exit or /quit or EOF exits. Each message is sent via POST /v1/chat/completions (OpenAI format). Use --message for one-shot (send one message and print response). No token yields exit 3. Use --plain for raw output (no Markdown rendering). Responses are streamed token by token as raw text; use --no-stream to wait for the whole response and render it as Markdown.",
 	RunE:         runChat,
 	SilenceUsage: true,
 }
@@ -40,6 +41,7 @@ func init() {
 	chatCmd.Flags().StringVarP(&chatMessage, "message", "m", "", "send one message and print response (non-interactive)")
 	chatCmd.Flags().StringVar(&chatProjectID, "project-id", "", "project to associate with chat session (sent as OpenAI-Project header)")
 	chatCmd.Flags().BoolVar(&chatThreadNew, "thread-new", false, "start a new conversation thread before sending the first message")
+	chatCmd.Flags().BoolVar(&chatNoStream, "no-stream", false, "wait for the complete response and render it as Markdown instead of printing tokens as they arrive")
 }
 
 // formatChatResponseFn is the implementation of formatChatResponse; tests may replace it to trigger error path.
@@ -225,13 +227,27 @@ func startNewThread(client *gateway.Client) error {
 	return nil
 }
 
-// sendAndPrintChat sends the line to the gateway and prints the response (formatted or raw).
+// sendAndPrintChat sends the line to the gateway and prints the response: streamed tokens as they arrive, or,
+// with --no-stream (or a gateway that does not stream), the whole response formatted or raw.
 func sendAndPrintChat(client *gateway.Client, line string) error {
-	resp, err := client.ChatWithOptions(line, chatSessionModel, chatSessionProjectID)
+	var resp *gateway.ChatResponse
+	var err error
+	if chatNoStream {
+		resp, err = client.ChatWithOptions(line, chatSessionModel, chatSessionProjectID)
+	} else {
+		printed := false
+		resp, err = client.ChatStream(line, chatSessionModel, chatSessionProjectID, func(delta string) {
+			printed = true
+			fmt.Print(delta)
+		})
+		if printed && (err != nil || !strings.HasSuffix(resp.Response, "\n")) {
+			fmt.Println()
+		}
+	}
 	if err != nil {
 		return exitFromGatewayErr(err)
 	}
-	if resp.Response == "" {
+	if resp.Streamed || resp.Response == "" {
 		return nil
 	}
 	out, err := formatChatResponseFn(resp.Response, chatPlain, noColor)
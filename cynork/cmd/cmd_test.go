@@ -22,6 +22,7 @@ import (
 	"github.com/cypher0n3/cynodeai/cynork/internal/exit"
 	"github.com/cypher0n3/cynodeai/cynork/internal/gateway"
 	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/spf13/cobra"
 )
 
 const testUser = "alice"
@@ -306,6 +307,59 @@ func TestRunAuthLogin_JSONOutput(t *testing.T) {
 	}
 }
 
+func TestRunAuthLogin_SSO(t *testing.T) {
+	polls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/auth/sso/device", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(userapi.SSODeviceAuthorizationResponse{
+			DeviceCode: "dc", UserCode: "ABCD-EFGH", VerificationURI: "https://idp.example/activate", ExpiresIn: 60, Interval: 1,
+		})
+	})
+	mux.HandleFunc("POST /v1/auth/sso/device/token", func(w http.ResponseWriter, r *http.Request) {
+		var req userapi.SSODeviceTokenRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if polls++; polls == 1 || req.DeviceCode != "dc" {
+			w.WriteHeader(http.StatusAccepted)
+			_ = json.NewEncoder(w).Encode(userapi.SSODevicePendingResponse{Status: userapi.SSODeviceStatusPending})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(userapi.LoginResponse{AccessToken: "sso-tok", RefreshToken: "sso-refresh", TokenType: "Bearer"})
+	})
+	mux.HandleFunc("GET /v1/users/me", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sso-tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(userapi.UserResponse{ID: "u1", Handle: testUser})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	configPath = filepath.Join(t.TempDir(), "config.yaml")
+	cfg = &config.Config{GatewayURL: server.URL}
+	authLoginSSO = true
+	var slept time.Duration
+	ssoPollSleep = func(d time.Duration) { slept += d }
+	defer func() {
+		configPath, cfg, authLoginSSO, ssoPollSleep = "", nil, false, time.Sleep
+	}()
+	out := captureStdout(t, func() {
+		if err := runAuthLogin(nil, nil); err != nil {
+			t.Errorf("runAuthLogin --sso: %v", err)
+		}
+	})
+	if !strings.Contains(out, "user=alice") || cfg.Token != "sso-tok" || cfg.RefreshToken != "sso-refresh" {
+		t.Errorf("output %q, token %q", out, cfg.Token)
+	}
+	if polls != 2 || slept != 2*time.Second {
+		t.Errorf("polls = %d, slept = %v", polls, slept)
+	}
+	authLoginHandle = testUser
+	defer func() { authLoginHandle = "" }()
+	if err := runAuthLogin(nil, nil); exit.CodeOf(err) != exit.CodeUsage {
+		t.Errorf("--sso with --user: %v", err)
+	}
+}
+
 func TestRunAuthRefresh_NoRefreshToken(t *testing.T) {
 	cfg = &config.Config{GatewayURL: "http://localhost"}
 	defer func() { cfg = nil }()
@@ -387,6 +441,37 @@ func TestRunModelsList_JSONOutput(t *testing.T) {
 	}
 }
 
+func TestRunUsage_NoToken(t *testing.T) {
+	cfg = &config.Config{}
+	defer func() { cfg = nil }()
+	if err := runUsage(nil, nil); exit.CodeOf(err) != exit.CodeAuth {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestRunUsage_OK(t *testing.T) {
+	reset := "2026-02-01T00:00:00Z"
+	server := mockJSONServer(t, http.StatusOK, userapi.UsageResponse{
+		Since: "2026-01-01T00:00:00Z", Until: "2026-01-15T00:00:00Z",
+		Totals:  userapi.UsageTotals{PromptTokens: 10, CompletionTokens: 32, TotalTokens: 42},
+		ByModel: []userapi.ModelUsage{{Model: "qwen3:8b", UsageTotals: userapi.UsageTotals{PromptTokens: 10, CompletionTokens: 32, TotalTokens: 42}}},
+		Quotas:  []userapi.QuotaStatus{{Scope: "user", Kind: "monthly_tokens", Limit: 1000, Used: 42, ResetsAt: &reset}},
+	})
+	defer server.Close()
+	cfg = &config.Config{GatewayURL: server.URL, Token: "tok"}
+	defer func() { cfg = nil }()
+	out := captureStdout(t, func() {
+		if err := runUsage(nil, nil); err != nil {
+			t.Errorf("runUsage: %v", err)
+		}
+	})
+	for _, want := range []string{"total_tokens=42", "model=qwen3:8b", "quota scope=user kind=monthly_tokens used=42 limit=1000 resets_at=" + reset} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
 func TestRunChat_WithMessageFlag(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -1234,6 +1319,35 @@ func TestRunTaskLogs_OK(t *testing.T) {
 	}
 }
 
+func TestRunTaskLogs_Follow(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("follow") != "true" {
+			t.Errorf("follow not requested: %s", r.URL)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("event: log\ndata: {\"job_id\":\"j1\",\"stream\":\"stdout\",\"line\":\"building\"}\n\n" +
+			"event: end\ndata: {\"task_id\":\"tid\",\"status\":\"completed\"}\n\n"))
+	}))
+	defer server.Close()
+	cfg = &config.Config{GatewayURL: server.URL, Token: "tok"}
+	taskLogsFollow = true
+	defer func() { cfg = nil; taskLogsFollow = false }()
+	out := captureStdout(t, func() {
+		if err := runTaskLogs(nil, []string{"tid"}); err != nil {
+			t.Errorf("runTaskLogs: %v", err)
+		}
+	})
+	if out != "building\n" {
+		t.Errorf("stdout = %q", out)
+	}
+
+	taskLogsStream = "both"
+	defer func() { taskLogsStream = "all" }()
+	if err := runTaskLogs(nil, []string{"tid"}); err == nil {
+		t.Error("expected usage error for invalid --stream")
+	}
+}
+
 func TestParseArgs(t *testing.T) {
 	tests := []struct {
 		line string
@@ -1313,7 +1427,7 @@ func TestRunCredsList_OK(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("[]"))
+		_, _ = w.Write([]byte(`{"credentials":[]}`))
 	}))
 	defer server.Close()
 	cfg = &config.Config{GatewayURL: server.URL, Token: "tok"}
@@ -1338,16 +1452,33 @@ func TestRunNodesList_OK(t *testing.T) {
 }
 
 func TestRunAuditList_OK(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("[]"))
+		_, _ = w.Write([]byte(`{"events":[{"event_id":"e1","ts":"2026-03-01T12:00:00Z","source":"auth",` +
+			`"action":"auth.login_failure","resource_type":"user","resource_id":"u1","decision":"deny"}],"next_cursor":"50"}`))
 	}))
 	defer server.Close()
 	cfg = &config.Config{GatewayURL: server.URL, Token: "tok"}
-	defer func() { cfg = nil }()
-	if err := runAuditList(nil, nil); err != nil {
-		t.Errorf("runAuditList: %v", err)
+	defer func() { cfg, auditFilter.Decision = nil, "" }()
+	auditFilter.Decision = "deny"
+	out := captureStdout(t, func() {
+		if err := runAuditList(nil, nil); err != nil {
+			t.Errorf("runAuditList: %v", err)
+		}
+	})
+	if !strings.Contains(query, "decision=deny") || !strings.Contains(query, "limit=50") {
+		t.Errorf("query %q", query)
+	}
+	if !strings.HasPrefix(out, "event_id\tts\tactor_id\taction\tresource_type\tresource_id\tdecision\n") ||
+		!strings.Contains(out, "e1\t2026-03-01T12:00:00Z\t-\tauth.login_failure\tuser\tu1\tdeny") {
+		t.Errorf("output %q", out)
+	}
+	auditFilter.Limit = 500
+	defer func() { auditFilter.Limit = 50 }()
+	if err := runAuditList(nil, nil); exit.CodeOf(err) != exit.CodeUsage {
+		t.Errorf("limit 500: %v", err)
 	}
 }
 
@@ -1645,7 +1776,7 @@ func strPtr(s string) *string { return &s }
 func TestRunStubList_NoToken(t *testing.T) {
 	cfg = &config.Config{}
 	defer func() { cfg = nil }()
-	if err := runStubList("/v1/audit"); err == nil {
+	if err := runStubList("/v1/nodes"); err == nil {
 		t.Fatal("expected auth error")
 	}
 }
@@ -1657,7 +1788,7 @@ func TestRunStubList_OK(t *testing.T) {
 	defer server.Close()
 	cfg = &config.Config{GatewayURL: server.URL, Token: "tok"}
 	defer func() { cfg = nil }()
-	if err := runStubList("/v1/audit"); err != nil {
+	if err := runStubList("/v1/nodes"); err != nil {
 		t.Errorf("runStubList: %v", err)
 	}
 }
@@ -1669,7 +1800,7 @@ func TestRunStubList_EmptyBody(t *testing.T) {
 	defer server.Close()
 	cfg = &config.Config{GatewayURL: server.URL, Token: "tok"}
 	defer func() { cfg = nil }()
-	if err := runStubList("/v1/audit"); err != nil {
+	if err := runStubList("/v1/nodes"); err != nil {
 		t.Errorf("runStubList: %v", err)
 	}
 }
@@ -1681,7 +1812,7 @@ func TestRunStubList_GatewayError(t *testing.T) {
 	defer server.Close()
 	cfg = &config.Config{GatewayURL: server.URL, Token: "tok"}
 	defer func() { cfg = nil }()
-	if err := runStubList("/v1/audit"); err == nil {
+	if err := runStubList("/v1/nodes"); err == nil {
 		t.Fatal("expected gateway error")
 	}
 }
@@ -1730,15 +1861,53 @@ func TestRunStubSet_OK(t *testing.T) {
 	}
 }
 
-func TestRunAuditList(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-		_, _ = w.Write([]byte("[]"))
+func TestRunAuditGetAndExport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/audit/e1":
+			_, _ = w.Write([]byte(`{"event_id":"e1","ts":"2026-03-01T12:00:00Z","source":"chat","action":"chat.completion",` +
+				`"resource_type":"chat","resource_id":"","decision":"allow","details":"success"}`))
+		case r.URL.Path == "/v1/audit" && r.URL.Query().Get("format") == "jsonl":
+			_, _ = w.Write([]byte(`{"event_id":"e1"}` + "\n"))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
 	}))
 	defer server.Close()
 	cfg = &config.Config{GatewayURL: server.URL, Token: "tok"}
-	defer func() { cfg = nil }()
-	if err := runAuditList(nil, nil); err != nil {
-		t.Errorf("runAuditList: %v", err)
+	defer func() { cfg, auditExportOut, auditExportFormat = nil, "", "jsonl" }()
+
+	out := captureStdout(t, func() {
+		if err := runAuditGet(nil, []string{"e1"}); err != nil {
+			t.Errorf("runAuditGet: %v", err)
+		}
+	})
+	if !strings.Contains(out, "event_id=e1\n") || !strings.Contains(out, "details=\"success\"") || strings.Contains(out, "actor_id=") {
+		t.Errorf("get output %q", out)
+	}
+	if err := runAuditGet(nil, []string{"e2"}); exit.CodeOf(err) != exit.CodeAuth {
+		t.Errorf("get forbidden: %v", err)
+	}
+
+	auditExportOut = filepath.Join(t.TempDir(), "audit.jsonl")
+	out = captureStdout(t, func() {
+		if err := runAuditExport(nil, nil); err != nil {
+			t.Errorf("runAuditExport: %v", err)
+		}
+	})
+	if data, _ := os.ReadFile(auditExportOut); string(data) != `{"event_id":"e1"}`+"\n" || !strings.Contains(out, "saved=true") {
+		t.Errorf("export file %q output %q", data, out)
+	}
+	auditExportFormat = "csv"
+	if err := runAuditExport(nil, nil); exit.CodeOf(err) != exit.CodeAuth {
+		t.Errorf("export forbidden: %v", err)
+	}
+	if _, err := os.Stat(auditExportOut); !os.IsNotExist(err) {
+		t.Errorf("failed export left %s behind", auditExportOut)
+	}
+	auditExportFormat = "xml"
+	if err := runAuditExport(nil, nil); exit.CodeOf(err) != exit.CodeUsage {
+		t.Errorf("bad format: %v", err)
 	}
 }
 
@@ -1801,14 +1970,333 @@ func TestRunSkillsUpdate_NoAuth(t *testing.T) {
 }
 
 func TestRunCredsList(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-		_, _ = w.Write([]byte("[]"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/credentials" || r.URL.Query().Get("provider") != "openai" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		_, _ = w.Write([]byte(`{"credentials":[{"id":"c1","provider":"openai","credential_name":"default","owner_type":"user","owner_id":"u1","is_active":true}]}`))
 	}))
 	defer server.Close()
 	cfg = &config.Config{GatewayURL: server.URL, Token: "tok"}
 	defer func() { cfg = nil }()
-	if err := runCredsList(nil, nil); err != nil {
-		t.Errorf("runCredsList: %v", err)
+	credsProvider = "openai"
+	defer func() { credsProvider = "" }()
+	out := captureStdout(t, func() {
+		if err := runCredsList(nil, nil); err != nil {
+			t.Errorf("runCredsList: %v", err)
+		}
+	})
+	if !strings.HasPrefix(out, "credential_id\tprovider\tname\t") || !strings.Contains(out, "c1\topenai\tdefault\tuser\tu1\ttrue") {
+		t.Errorf("output %q", out)
+	}
+}
+
+func TestRunCredsCreateRotateDisable(t *testing.T) {
+	var created userapi.CreateCredentialRequest
+	var rotated userapi.RotateCredentialRequest
+	var disabled bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/credentials":
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+		case "/v1/credentials/c1/rotate":
+			_ = json.NewDecoder(r.Body).Decode(&rotated)
+		case "/v1/credentials/c1/disable":
+			disabled = true
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(userapi.CredentialResponse{ID: "c1", Provider: "openai", IsActive: !disabled})
+	}))
+	defer server.Close()
+	cfg = &config.Config{GatewayURL: server.URL, Token: "tok"}
+	defer func() {
+		cfg = nil
+		credsProvider, credsName, credsSecretFile, credsDisableYes = "", "", "", false
+	}()
+
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	_ = os.WriteFile(secretFile, []byte("sk-test\n"), 0o600)
+	credsProvider, credsName, credsSecretFile = "openai", "default", secretFile
+	out := captureStdout(t, func() {
+		if err := runCredsCreate(nil, nil); err != nil {
+			t.Errorf("create: %v", err)
+		}
+	})
+	if created.Secret != "sk-test" || created.Provider != "openai" || created.CredentialName != "default" {
+		t.Errorf("create request %+v", created)
+	}
+	if strings.Contains(out, "sk-test") || !strings.Contains(out, "credential_id=c1") {
+		t.Errorf("create output %q", out)
+	}
+
+	_ = os.WriteFile(secretFile, []byte("sk-new"), 0o600)
+	out = captureStdout(t, func() {
+		if err := runCredsRotate(nil, []string{"c1"}); err != nil {
+			t.Errorf("rotate: %v", err)
+		}
+	})
+	if rotated.Secret != "sk-new" || !strings.Contains(out, "credential_id=c1 rotated=true") {
+		t.Errorf("rotate request %+v output %q", rotated, out)
+	}
+
+	credsDisableYes = true
+	out = captureStdout(t, func() {
+		if err := runCredsDisable(nil, []string{"c1"}); err != nil {
+			t.Errorf("disable: %v", err)
+		}
+	})
+	if !disabled || !strings.Contains(out, "credential_id=c1 disabled=true") {
+		t.Errorf("disabled=%t output %q", disabled, out)
+	}
+	if err := runCredsGet(nil, []string{"missing"}); exit.CodeOf(err) != exit.CodeNotFound {
+		t.Errorf("get missing: %v", err)
+	}
+}
+
+func TestReadCredentialSecret_RejectsTwoSources(t *testing.T) {
+	credsSecretStdin, credsSecretFile = true, "x"
+	defer func() { credsSecretStdin, credsSecretFile = false, "" }()
+	if _, err := readCredentialSecret(); exit.CodeOf(err) != exit.CodeUsage {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestRunTokenCreateListRevoke(t *testing.T) {
+	var created userapi.CreateAccessTokenRequest
+	var revoked bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/users/me/tokens":
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":"t1","token_prefix":"cyn_pat_abcd","scopes":["tasks:write"],"token":"cyn_pat_secret"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/users/me/tokens":
+			_, _ = w.Write([]byte(`{"tokens":[{"id":"t1","name":"ci","token_prefix":"cyn_pat_abcd","scopes":["tasks:write","chat"],"is_active":true,"created_at":"2026-01-01T00:00:00Z"}]}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/v1/users/me/tokens/t1":
+			revoked = true
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	cfg = &config.Config{GatewayURL: server.URL, Token: "tok"}
+	defer func() {
+		cfg = nil
+		tokenName, tokenScopes, tokenExpiresIn, tokenRevokeYes = "", nil, "", false
+	}()
+
+	tokenName, tokenScopes, tokenExpiresIn = "ci", []string{"tasks:write"}, "30d"
+	out := captureStdout(t, func() {
+		if err := runTokenCreate(nil, nil); err != nil {
+			t.Errorf("create: %v", err)
+		}
+	})
+	if created.Name != "ci" || len(created.Scopes) != 1 || created.ExpiresAt == nil {
+		t.Errorf("create request %+v", created)
+	}
+	if !strings.Contains(out, "token_id=t1") || !strings.Contains(out, "cyn_pat_secret") {
+		t.Errorf("create output %q", out)
+	}
+
+	out = captureStdout(t, func() {
+		if err := runTokenList(nil, nil); err != nil {
+			t.Errorf("list: %v", err)
+		}
+	})
+	if !strings.Contains(out, "t1\tci\tcyn_pat_abcd\ttasks:write,chat\ttrue\t-\t-\t") {
+		t.Errorf("list output %q", out)
+	}
+
+	tokenRevokeYes = true
+	out = captureStdout(t, func() {
+		if err := runTokenRevoke(nil, []string{"t1"}); err != nil {
+			t.Errorf("revoke: %v", err)
+		}
+	})
+	if !revoked || !strings.Contains(out, "token_id=t1 revoked=true") {
+		t.Errorf("revoked=%t output %q", revoked, out)
+	}
+	if err := runTokenRevoke(nil, []string{"missing"}); exit.CodeOf(err) != exit.CodeNotFound {
+		t.Errorf("revoke missing: %v", err)
+	}
+
+	tokenExpiresIn = "soon"
+	if err := runTokenCreate(nil, nil); exit.CodeOf(err) != exit.CodeUsage {
+		t.Errorf("bad --expires-in: %v", err)
+	}
+}
+
+func TestRunAuthSessions(t *testing.T) {
+	var revoked bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/users/me/sessions":
+			_, _ = w.Write([]byte(`{"sessions":[{"id":"s1","client_name":"cynork","ip_address":"10.0.0.5","current":true,` +
+				`"created_at":"2026-01-01T00:00:00Z","expires_at":"2026-01-08T00:00:00Z"}]}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/v1/users/me/sessions/s1":
+			revoked = true
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"detail":"Session not found"}`))
+		}
+	}))
+	defer server.Close()
+	cfg = &config.Config{GatewayURL: server.URL, Token: "tok"}
+	defer func() {
+		cfg = nil
+		authSessionsRevokeYes = false
+	}()
+
+	out := captureStdout(t, func() {
+		if err := runAuthSessionsList(nil, nil); err != nil {
+			t.Errorf("list: %v", err)
+		}
+	})
+	if !strings.Contains(out, "s1\tcynork\t-\t10.0.0.5\ttrue\t2026-01-01T00:00:00Z\t-\t2026-01-08T00:00:00Z") {
+		t.Errorf("list output %q", out)
+	}
+
+	authSessionsRevokeYes = true
+	out = captureStdout(t, func() {
+		if err := runAuthSessionsRevoke(nil, []string{"s1"}); err != nil {
+			t.Errorf("revoke: %v", err)
+		}
+	})
+	if !revoked || !strings.Contains(out, "session_id=s1 revoked=true") {
+		t.Errorf("revoked=%t output %q", revoked, out)
+	}
+	if err := runAuthSessionsRevoke(nil, []string{"missing"}); exit.CodeOf(err) != exit.CodeNotFound {
+		t.Errorf("revoke missing: %v", err)
+	}
+}
+
+func TestParseTokenLifetime(t *testing.T) {
+	for in, want := range map[string]time.Duration{"90d": 90 * 24 * time.Hour, "12h": 12 * time.Hour} {
+		if got, err := parseTokenLifetime(in); err != nil || got != want {
+			t.Errorf("%s: %v, %v", in, got, err)
+		}
+	}
+	for _, in := range []string{"0d", "-1h", "xd", ""} {
+		if _, err := parseTokenLifetime(in); err == nil {
+			t.Errorf("%q accepted", in)
+		}
+	}
+}
+
+func TestRunPolicyRulesCreateUpdateDelete(t *testing.T) {
+	var created, updated userapi.PolicyRuleRequest
+	var deleted bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/policy/rules":
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/policy/rules/r1":
+			_ = json.NewDecoder(r.Body).Decode(&updated)
+		case r.Method == http.MethodDelete && r.URL.Path == "/v1/policy/rules/r1":
+			deleted = true
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case r.Method != http.MethodGet || r.URL.Path != "/v1/policy/rules/r1":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(userapi.PolicyRuleResponse{
+			ID: "r1", SubjectType: "system", Action: "api.call", ResourceType: "api.provider_operation",
+			ResourcePattern: "openai/*", Effect: "allow", Priority: 5, Conditions: json.RawMessage(`{"task_types":["sba"]}`),
+		})
+	}))
+	defer server.Close()
+	cfg = &config.Config{GatewayURL: server.URL, Token: "tok"}
+	defer func() {
+		cfg = nil
+		policySubjectType, policyAction, policyResourceType, policyPattern, policyEffect = "", "", "", "", ""
+		policyConditions, policyDeleteYes = "", false
+	}()
+
+	policySubjectType, policyAction, policyResourceType, policyPattern, policyEffect = "system", "api.call", "api.provider_operation", "openai/*", "allow"
+	policyConditions = `{"task_types":["sba"]}`
+	out := captureStdout(t, func() {
+		if err := runPolicyRulesCreate(nil, nil); err != nil {
+			t.Errorf("create: %v", err)
+		}
+	})
+	if created.SubjectID != nil || created.ResourcePattern != "openai/*" || string(created.Conditions) != policyConditions || !strings.Contains(out, "rule_id=r1") {
+		t.Errorf("create request %+v output %q", created, out)
+	}
+
+	// Only changed flags are applied; the rest of the rule is sent back unchanged.
+	cmd := &cobra.Command{}
+	cmd.Flags().StringVar(&policyEffect, "effect", "", "")
+	_ = cmd.Flags().Set("effect", "deny")
+	captureStdout(t, func() {
+		if err := runPolicyRulesUpdate(cmd, []string{"r1"}); err != nil {
+			t.Errorf("update: %v", err)
+		}
+	})
+	if updated.Effect != "deny" || updated.Priority != 5 || updated.ResourcePattern != "openai/*" || len(updated.Conditions) == 0 {
+		t.Errorf("update request %+v", updated)
+	}
+
+	policyDeleteYes = true
+	out = captureStdout(t, func() {
+		if err := runPolicyRulesDelete(nil, []string{"r1"}); err != nil {
+			t.Errorf("delete: %v", err)
+		}
+	})
+	if !deleted || !strings.Contains(out, "rule_id=r1 deleted=true") {
+		t.Errorf("deleted=%t output %q", deleted, out)
+	}
+	if err := runPolicyRulesGet(nil, []string{"missing"}); exit.CodeOf(err) != exit.CodeNotFound {
+		t.Errorf("get missing: %v", err)
+	}
+	policyConditions = "[1]"
+	if err := runPolicyRulesCreate(nil, nil); exit.CodeOf(err) != exit.CodeUsage {
+		t.Errorf("bad conditions: %v", err)
+	}
+}
+
+func TestRunPolicyEvaluate(t *testing.T) {
+	var got userapi.PolicyEvaluateRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_ = json.NewEncoder(w).Encode(userapi.PolicyEvaluateResponse{
+			Reason: "denied by policy", ResourceType: "api.provider_operation",
+			Rule:         &userapi.PolicyRuleResponse{ID: "r9", Effect: "deny"},
+			MatchedRules: []userapi.PolicyRuleResponse{{ID: "r9", SubjectType: "group", Effect: "deny", Priority: 10}},
+			Principal:    userapi.PolicyPrincipal{GroupIDs: []string{"g1"}, RoleNames: []string{}},
+		})
+	}))
+	defer server.Close()
+	cfg = &config.Config{GatewayURL: server.URL, Token: "tok"}
+	defer func() {
+		cfg = nil
+		policyAction, policyEvalResource, policyEvalGroup, policyEvalRole, policyEvalAt = "", "", "", "", ""
+	}()
+	policyAction, policyEvalResource, policyEvalGroup, policyEvalAt = "api.call", "openai/chat", "g1", "2026-03-04T10:00:00Z"
+	out := captureStdout(t, func() {
+		if err := runPolicyEvaluate(nil, nil); err != nil {
+			t.Errorf("evaluate: %v", err)
+		}
+	})
+	if got.SubjectType != "group" || got.SubjectID != "g1" || got.Context == nil || *got.Context.Time != policyEvalAt {
+		t.Errorf("request %+v", got)
+	}
+	if !strings.Contains(out, "allowed=false resource_type=api.provider_operation rule_id=r9") || !strings.Contains(out, "r9\tgroup\t-") {
+		t.Errorf("output %q", out)
+	}
+	policyEvalRole = "member"
+	if err := runPolicyEvaluate(nil, nil); exit.CodeOf(err) != exit.CodeUsage {
+		t.Errorf("two subjects: %v", err)
 	}
 }
 
@@ -2330,7 +2818,7 @@ func TestRunTaskArtifactsList(t *testing.T) {
 		if strings.HasSuffix(r.URL.Path, "/artifacts") && r.Method == http.MethodGet {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusOK)
-			_, _ = w.Write([]byte(`[{"artifact_id":"a1","name":"out.txt"}]`))
+			_, _ = w.Write([]byte(`{"task_id":"task-1","artifacts":[{"path":"out.txt","size_bytes":3,"has_content":true}]}`))
 			return
 		}
 		w.WriteHeader(http.StatusNotFound)
@@ -2343,6 +2831,66 @@ func TestRunTaskArtifactsList(t *testing.T) {
 	}
 }
 
+func TestRunTaskArtifactsGetAndPut(t *testing.T) {
+	stored := map[string][]byte{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := strings.TrimPrefix(r.URL.Path, "/v1/tasks/t1/artifacts/")
+		switch r.Method {
+		case http.MethodPut:
+			stored[p], _ = io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(userapi.ArtifactResponse{Path: p, HasContent: true})
+		case http.MethodGet:
+			b, ok := stored[p]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(b)
+		}
+	}))
+	defer server.Close()
+	cfg = &config.Config{GatewayURL: server.URL, Token: "tok"}
+	defer func() { cfg = nil; taskArtifactOut = ""; taskArtifactForce = false }()
+
+	dir := t.TempDir()
+	local := filepath.Join(dir, "in.txt")
+	_ = os.WriteFile(local, []byte("payload"), 0o600)
+	out := captureStdout(t, func() {
+		if err := runTaskArtifactsPut(nil, []string{"t1", "out/in.txt", local}); err != nil {
+			t.Errorf("put: %v", err)
+		}
+	})
+	if string(stored["out/in.txt"]) != "payload" || !strings.Contains(out, "saved=true path=out/in.txt size_bytes=7") {
+		t.Fatalf("stored=%q out=%q", stored["out/in.txt"], out)
+	}
+
+	taskArtifactOut = filepath.Join(dir, "nested", "copy.txt")
+	out = captureStdout(t, func() {
+		if err := runTaskArtifactsGet(nil, []string{"t1", "out/in.txt"}); err != nil {
+			t.Errorf("get: %v", err)
+		}
+	})
+	if b, _ := os.ReadFile(taskArtifactOut); string(b) != "payload" || !strings.Contains(out, "saved=true") {
+		t.Errorf("downloaded %q, out %q", b, out)
+	}
+	if err := runTaskArtifactsGet(nil, []string{"t1", "out/in.txt"}); exit.CodeOf(err) != exit.CodeConflict {
+		t.Errorf("existing --out without --force: %v", err)
+	}
+	taskArtifactForce = true
+	taskArtifactOut = filepath.Join(dir, "missing.txt")
+	if err := runTaskArtifactsGet(nil, []string{"t1", "nope"}); exit.CodeOf(err) != exit.CodeNotFound {
+		t.Errorf("missing artifact: %v", err)
+	}
+	if _, err := os.Stat(taskArtifactOut); !os.IsNotExist(err) {
+		t.Errorf("failed download left %s behind", taskArtifactOut)
+	}
+	if err := runTaskArtifactsPut(nil, []string{"t1", "x", filepath.Join(dir, "absent")}); exit.CodeOf(err) != exit.CodeUsage {
+		t.Errorf("missing local file: %v", err)
+	}
+}
+
 func TestRunSlashCommand_StatusWhoami(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/healthz" {
@@ -2520,7 +3068,7 @@ func stubSlashServeTasks(w http.ResponseWriter, r *http.Request) bool {
 	}
 	if r.URL.Path == "/v1/tasks/t1/artifacts" && r.Method == http.MethodGet {
 		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("[]"))
+		_, _ = w.Write([]byte(`{"task_id":"t1","artifacts":[]}`))
 		return true
 	}
 	return false
@@ -2889,6 +3437,36 @@ func TestSendAndPrintChat_FormatErrorFallback(t *testing.T) {
 	}
 }
 
+func TestSendAndPrintChat_Streamed(t *testing.T) {
+	var gotStream bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req userapi.ChatCompletionsRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		gotStream = req.Stream
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(`data: {"choices":[{"index":0,"delta":{"content":"stre"}}]}` + "\n\n" +
+			`data: {"choices":[{"index":0,"delta":{"content":"amed"}}]}` + "\n\n" + "data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+	client := gateway.NewClient(server.URL)
+	client.SetToken("tok")
+	out := captureStdout(t, func() {
+		if err := sendAndPrintChat(client, "hi"); err != nil {
+			t.Errorf("sendAndPrintChat streamed: %v", err)
+		}
+	})
+	if !gotStream || out != "streamed\n" {
+		t.Errorf("stream=%v output=%q", gotStream, out)
+	}
+
+	chatNoStream = true
+	defer func() { chatNoStream = false }()
+	_ = captureStdout(t, func() { _ = sendAndPrintChat(client, "hi") })
+	if gotStream {
+		t.Error("--no-stream still requested a stream")
+	}
+}
+
 func TestRunSlashCommand_SendAndPrintUsesSessionModel(t *testing.T) {
 	var gotModel, gotProject string
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -3006,3 +3584,239 @@ func TestRunSlashThread_NilClient(t *testing.T) {
 		t.Fatalf("nil client should print message and return nil, got: %v", err)
 	}
 }
+
+func TestRunEgressLimits(t *testing.T) {
+	var created, updated userapi.EgressLimitRequest
+	var deleted bool
+	scope := "p1"
+	limit := userapi.EgressLimitResponse{
+		ID: "l1", Provider: "openai", ScopeType: "project", ScopeID: &scope, RequestsPerMinute: 60,
+		MonthlyBudgetUSD: 25, CostPerCallUSD: 0.002,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/egress/limits":
+			_ = json.NewEncoder(w).Encode(userapi.ListEgressLimitsResponse{Limits: []userapi.EgressLimitResponse{limit}})
+			return
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/egress/limits":
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/egress/limits/l1":
+			_ = json.NewDecoder(r.Body).Decode(&updated)
+		case r.Method == http.MethodDelete && r.URL.Path == "/v1/egress/limits/l1":
+			deleted = true
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case r.Method != http.MethodGet || r.URL.Path != "/v1/egress/limits/l1":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(limit)
+	}))
+	defer server.Close()
+	cfg = &config.Config{GatewayURL: server.URL, Token: "tok"}
+	defer func() {
+		cfg = nil
+		egressProvider, egressScopeType, egressScopeID, egressRPM, egressMaxConcurrent = "", "", "", 0, 0
+		egressDeleteYes = false
+	}()
+
+	out := captureStdout(t, func() {
+		if err := runEgressLimitsList(nil, nil); err != nil {
+			t.Errorf("list: %v", err)
+		}
+	})
+	if !strings.Contains(out, "l1\topenai\t*\tproject\tp1\t60\t0\t25\t0.002") {
+		t.Errorf("list output %q", out)
+	}
+
+	egressProvider, egressScopeType, egressRPM = "github", "user", 30
+	out = captureStdout(t, func() {
+		if err := runEgressLimitsCreate(nil, nil); err != nil {
+			t.Errorf("create: %v", err)
+		}
+	})
+	if created.Provider != "github" || created.ScopeID != nil || created.RequestsPerMinute != 30 || !strings.Contains(out, "limit_id=l1") {
+		t.Errorf("create request %+v output %q", created, out)
+	}
+
+	// Only changed flags are applied; switching to system scope drops the scope id.
+	cmd := &cobra.Command{}
+	cmd.Flags().StringVar(&egressScopeType, "scope-type", "", "")
+	cmd.Flags().IntVar(&egressMaxConcurrent, "max-concurrent", 0, "")
+	_ = cmd.Flags().Set("scope-type", "system")
+	_ = cmd.Flags().Set("max-concurrent", "4")
+	captureStdout(t, func() {
+		if err := runEgressLimitsUpdate(cmd, []string{"l1"}); err != nil {
+			t.Errorf("update: %v", err)
+		}
+	})
+	if updated.ScopeType != "system" || updated.ScopeID != nil || updated.MaxConcurrent != 4 || updated.RequestsPerMinute != 60 ||
+		updated.CostPerCallUSD != 0.002 {
+		t.Errorf("update request %+v", updated)
+	}
+
+	egressDeleteYes = true
+	out = captureStdout(t, func() {
+		if err := runEgressLimitsDelete(nil, []string{"l1"}); err != nil {
+			t.Errorf("delete: %v", err)
+		}
+	})
+	if !deleted || !strings.Contains(out, "limit_id=l1 deleted=true") {
+		t.Errorf("deleted=%t output %q", deleted, out)
+	}
+	if err := runEgressLimitsGet(nil, []string{"missing"}); exit.CodeOf(err) != exit.CodeNotFound {
+		t.Errorf("get missing: %v", err)
+	}
+}
+
+func TestRunAuthLogin_PasswordChangeRequired(t *testing.T) {
+	var newPassword, clientName string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req userapi.LoginRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		if req.NewPassword == "" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"type":"urn:cynodeai:error:password_change_required","status":403,"detail":"Password change required"}`))
+			return
+		}
+		newPassword = req.NewPassword
+		clientName = req.ClientName
+		_, _ = w.Write([]byte(`{"access_token":"tok","token_type":"Bearer"}`))
+	}))
+	defer server.Close()
+	oldPrompt := promptNewPassword
+	promptNewPassword = func() (string, error) { return "n3w-password", nil }
+	defer func() { promptNewPassword = oldPrompt }()
+
+	if err := runAuthLoginAgainst(t, server.URL, false); err != nil {
+		t.Fatalf("runAuthLogin: %v", err)
+	}
+	if newPassword != "n3w-password" || clientName != "cynork" {
+		t.Errorf("new password %q, client name %q", newPassword, clientName)
+	}
+	if err := runAuthLoginAgainst(t, server.URL, true); exit.CodeOf(err) != exit.CodeAuth {
+		t.Errorf("--password-stdin: %v", err)
+	}
+}
+
+// runAuthLoginAgainst logs in as "u" with password "p" piped on stdin and resets the globals afterwards.
+func runAuthLoginAgainst(t *testing.T, gatewayURL string, passwordStdin bool) error {
+	t.Helper()
+	configPath = filepath.Join(t.TempDir(), "config.yaml")
+	cfg = &config.Config{GatewayURL: gatewayURL}
+	authLoginHandle = "u"
+	authLoginPasswordStdin = passwordStdin
+	oldStdin := os.Stdin
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		os.Stdin = oldStdin
+		configPath = ""
+		cfg = nil
+		authLoginHandle = ""
+		authLoginPasswordStdin = false
+	}()
+	os.Stdin = r
+	_, _ = w.WriteString("p\n")
+	_ = w.Close()
+	var runErr error
+	captureStdout(t, func() { runErr = runAuthLogin(nil, nil) })
+	return runErr
+}
+
+func TestRunAdminUsers(t *testing.T) {
+	const aliceID = "7d7f6c2e-3b1a-4c55-9e0f-0a1b2c3d4e5f"
+	var created userapi.CreateUserRequest
+	var reset userapi.ResetPasswordRequest
+	var deleted, disabled bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/users":
+			_, _ = w.Write([]byte(`{"users":[{"id":"` + aliceID + `","handle":"alice","email":"a@example.com","is_active":true}]}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/users":
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":"u2","handle":"bob","is_active":true}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/users/"+aliceID+"/disable":
+			disabled = true
+			_, _ = w.Write([]byte(`{"id":"` + aliceID + `","handle":"alice","is_active":false}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/users/"+aliceID+"/reset_password":
+			_ = json.NewDecoder(r.Body).Decode(&reset)
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodDelete && r.URL.Path == "/v1/users/"+aliceID:
+			deleted = true
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	cfg = &config.Config{GatewayURL: server.URL, Token: "tok"}
+	passwordFile := filepath.Join(t.TempDir(), "pw")
+	if err := os.WriteFile(passwordFile, []byte("s3cret-password\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		cfg = nil
+		adminUserEmail, adminUserPasswordFile, adminUserMustChange, adminUserDeleteYes = "", "", true, false
+	}()
+
+	out := captureStdout(t, func() {
+		if err := runAdminUsersList(nil, nil); err != nil {
+			t.Errorf("list: %v", err)
+		}
+	})
+	if !strings.Contains(out, aliceID+"\talice\ta@example.com\ttrue\tfalse") {
+		t.Errorf("list output %q", out)
+	}
+
+	adminUserEmail, adminUserPasswordFile, adminUserMustChange = "b@example.com", passwordFile, true
+	out = captureStdout(t, func() {
+		if err := runAdminUsersCreate(nil, []string{"bob"}); err != nil {
+			t.Errorf("create: %v", err)
+		}
+	})
+	if created.Handle != "bob" || created.Password != "s3cret-password" || !created.MustChangePassword ||
+		created.Email == nil || strings.Contains(out, "s3cret") {
+		t.Errorf("create request %+v output %q", created, out)
+	}
+
+	// Handles resolve to ids through the user list.
+	out = captureStdout(t, func() {
+		if err := runAdminUsersDisable(nil, []string{"alice"}); err != nil {
+			t.Errorf("disable: %v", err)
+		}
+	})
+	if !disabled || !strings.Contains(out, "active=false") {
+		t.Errorf("disabled=%t output %q", disabled, out)
+	}
+	if err := runAdminUsersEnable(nil, []string{"nobody"}); exit.CodeOf(err) != exit.CodeNotFound {
+		t.Errorf("enable unknown handle: %v", err)
+	}
+
+	adminUserMustChange = false
+	captureStdout(t, func() {
+		if err := runAdminUsersResetPassword(nil, []string{aliceID}); err != nil {
+			t.Errorf("reset-password: %v", err)
+		}
+	})
+	if reset.Password != "s3cret-password" || reset.MustChangePassword {
+		t.Errorf("reset request %+v", reset)
+	}
+
+	adminUserDeleteYes = true
+	out = captureStdout(t, func() {
+		if err := runAdminUsersDelete(nil, []string{"alice"}); err != nil {
+			t.Errorf("delete: %v", err)
+		}
+	})
+	if !deleted || !strings.Contains(out, "deleted=true") {
+		t.Errorf("deleted=%t output %q", deleted, out)
+	}
+}
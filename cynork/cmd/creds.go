@@ -1,26 +1,293 @@
-//nolint:dupl // stub command structure shared with audit, nodes
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cypher0n3/cynodeai/cynork/internal/exit"
+	"github.com/cypher0n3/cynodeai/cynork/internal/gateway"
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
 	"github.com/spf13/cobra"
 )
 
+var (
+	credsProvider    string
+	credsOwnerType   string
+	credsOwnerID     string
+	credsName        string
+	credsType        string
+	credsExpiresAt   string
+	credsSecretStdin bool
+	credsSecretFile  string
+	credsDisableYes  bool
+)
+
 var credsCmd = &cobra.Command{
 	Use:   "creds",
-	Short: "Credential management (stub until orchestrator supports)",
+	Short: "API Egress credential management (secrets are write-only)",
 }
 
 var credsListCmd = &cobra.Command{
 	Use:   "list",
-	Short: "List credentials metadata",
+	Short: "List credential metadata",
+	Args:  cobra.NoArgs,
 	RunE:  runCredsList,
 }
 
+var credsGetCmd = &cobra.Command{
+	Use:   "get <credential_id>",
+	Short: "Show credential metadata",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCredsGet,
+}
+
+var credsCreateCmd = &cobra.Command{
+	Use:     "create",
+	Aliases: []string{"add"},
+	Short:   "Store a new credential",
+	Args:    cobra.NoArgs,
+	RunE:    runCredsCreate,
+}
+
+var credsRotateCmd = &cobra.Command{
+	Use:   "rotate <credential_id>",
+	Short: "Replace a credential's secret",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCredsRotate,
+}
+
+var credsDisableCmd = &cobra.Command{
+	Use:     "disable <credential_id>",
+	Aliases: []string{"revoke"},
+	Short:   "Disable a credential so it can no longer be used",
+	Args:    cobra.ExactArgs(1),
+	RunE:    runCredsDisable,
+}
+
+var credsRekeyCmd = &cobra.Command{
+	Use:   "rekey",
+	Short: "Re-wrap all credentials under the current master key (admin)",
+	Args:  cobra.NoArgs,
+	RunE:  runCredsRekey,
+}
+
 func init() {
 	rootCmd.AddCommand(credsCmd)
-	credsCmd.AddCommand(credsListCmd)
+	credsCmd.AddCommand(credsListCmd, credsGetCmd, credsCreateCmd, credsRotateCmd, credsDisableCmd, credsRekeyCmd)
+	credsListCmd.Flags().StringVar(&credsProvider, "provider", "", "filter by provider")
+	credsListCmd.Flags().StringVar(&credsOwnerType, "owner-type", "", "filter by owner type (user or group)")
+	credsListCmd.Flags().StringVar(&credsOwnerID, "owner-id", "", "filter by owner id")
+	credsCreateCmd.Flags().StringVar(&credsProvider, "provider", "", "provider (e.g. openai, github)")
+	credsCreateCmd.Flags().StringVar(&credsName, "name", "", "credential name, unique per owner and provider")
+	credsCreateCmd.Flags().StringVar(&credsType, "type", "", "credential type (default api_key)")
+	credsCreateCmd.Flags().StringVar(&credsOwnerType, "owner-type", "", "owner type: user or group (default user)")
+	credsCreateCmd.Flags().StringVar(&credsOwnerID, "owner-id", "", "owner id (default: the authenticated user)")
+	credsCreateCmd.Flags().StringVar(&credsExpiresAt, "expires-at", "", "expiry, RFC 3339")
+	_ = credsCreateCmd.MarkFlagRequired("provider")
+	_ = credsCreateCmd.MarkFlagRequired("name")
+	credsRotateCmd.Flags().StringVar(&credsExpiresAt, "expires-at", "", "new expiry, RFC 3339")
+	for _, c := range []*cobra.Command{credsCreateCmd, credsRotateCmd} {
+		c.Flags().BoolVar(&credsSecretStdin, "secret-from-stdin", false, "read the secret from stdin")
+		c.Flags().StringVar(&credsSecretFile, "secret-file", "", "read the secret from a file")
+	}
+	credsDisableCmd.Flags().BoolVarP(&credsDisableYes, "yes", "y", false, "skip confirmation")
+}
+
+func credsClient() (*gateway.Client, error) {
+	if cfg.Token == "" {
+		return nil, exit.Auth(fmt.Errorf("not logged in: run 'cynork auth login'"))
+	}
+	client := gateway.NewClient(cfg.GatewayURL)
+	client.SetToken(cfg.Token)
+	return client, nil
 }
 
 func runCredsList(_ *cobra.Command, _ []string) error {
-	return runStubList("/v1/creds")
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	resp, err := client.ListCredentials(gateway.CredentialFilter{
+		Provider: credsProvider, OwnerType: credsOwnerType, OwnerID: credsOwnerID,
+	})
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(resp)
+		return nil
+	}
+	fmt.Println("credential_id\tprovider\tname\towner_type\towner_id\tactive\tcreated_at\tupdated_at")
+	for i := range resp.Credentials {
+		c := &resp.Credentials[i]
+		fmt.Printf("%s\t%s\t%s\t%s\t%s\t%t\t%s\t%s\n",
+			c.ID, c.Provider, c.CredentialName, c.OwnerType, c.OwnerID, c.IsActive, c.CreatedAt, c.UpdatedAt)
+	}
+	return nil
+}
+
+func runCredsGet(_ *cobra.Command, args []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	resp, err := client.GetCredential(args[0])
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	printCredential(resp)
+	return nil
+}
+
+func runCredsCreate(_ *cobra.Command, _ []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	secret, err := readCredentialSecret()
+	if err != nil {
+		return err
+	}
+	req := &userapi.CreateCredentialRequest{
+		Provider:       credsProvider,
+		CredentialName: credsName,
+		CredentialType: credsType,
+		OwnerType:      credsOwnerType,
+		OwnerID:        credsOwnerID,
+		Secret:         secret,
+	}
+	if credsExpiresAt != "" {
+		req.ExpiresAt = &credsExpiresAt
+	}
+	resp, err := client.CreateCredential(req)
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(map[string]any{"credential_id": resp.ID})
+		return nil
+	}
+	fmt.Printf("credential_id=%s\n", resp.ID)
+	return nil
+}
+
+func runCredsRotate(_ *cobra.Command, args []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	secret, err := readCredentialSecret()
+	if err != nil {
+		return err
+	}
+	req := &userapi.RotateCredentialRequest{Secret: secret}
+	if credsExpiresAt != "" {
+		req.ExpiresAt = &credsExpiresAt
+	}
+	resp, err := client.RotateCredential(args[0], req)
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(map[string]any{"credential_id": resp.ID, "rotated": true})
+		return nil
+	}
+	fmt.Printf("credential_id=%s rotated=true\n", resp.ID)
+	return nil
+}
+
+func runCredsDisable(_ *cobra.Command, args []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	id := args[0]
+	if !credsDisableYes {
+		fmt.Fprintf(os.Stderr, "Disable credential %s? [y/N] ", id)
+		var ch string
+		if _, err := fmt.Scanln(&ch); err != nil || (ch != "y" && ch != "Y") {
+			return nil
+		}
+	}
+	resp, err := client.DisableCredential(id)
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(map[string]any{"credential_id": resp.ID, "disabled": true})
+		return nil
+	}
+	fmt.Printf("credential_id=%s disabled=true\n", resp.ID)
+	return nil
+}
+
+func runCredsRekey(_ *cobra.Command, _ []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	resp, err := client.RekeyCredentials()
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(resp)
+		return nil
+	}
+	fmt.Printf("credential_kid=%s rewrapped=%d failed=%d\n", resp.KeyID, resp.Rewrapped, resp.Failed)
+	return nil
+}
+
+func printCredential(c *userapi.CredentialResponse) {
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(c)
+		return
+	}
+	line := fmt.Sprintf("credential_id=%s provider=%s name=%s type=%s owner_type=%s owner_id=%s active=%t",
+		c.ID, c.Provider, c.CredentialName, c.CredentialType, c.OwnerType, c.OwnerID, c.IsActive)
+	if c.ExpiresAt != nil {
+		line += " expires_at=" + *c.ExpiresAt
+	}
+	fmt.Println(line)
+}
+
+// readCredentialSecret reads the secret from exactly one of --secret-from-stdin, --secret-file, or a no-echo
+// prompt. One trailing newline is trimmed; the secret is never printed.
+func readCredentialSecret() (string, error) {
+	if credsSecretStdin && credsSecretFile != "" {
+		return "", exit.Usage(fmt.Errorf("use only one of --secret-from-stdin and --secret-file"))
+	}
+	var secret string
+	switch {
+	case credsSecretStdin:
+		s, err := readPasswordFromStdin()
+		if err != nil {
+			return "", fmt.Errorf("read secret: %w", err)
+		}
+		secret = s
+	case credsSecretFile != "":
+		raw, err := os.ReadFile(credsSecretFile)
+		if err != nil {
+			return "", exit.Usage(fmt.Errorf("read secret file: %w", err))
+		}
+		secret = trimOneNewline(string(raw))
+	default:
+		s, err := readPassword("Secret: ")
+		if err != nil {
+			return "", fmt.Errorf("read secret: %w", err)
+		}
+		secret = s
+	}
+	if secret == "" {
+		return "", exit.Usage(fmt.Errorf("secret is empty"))
+	}
+	return secret, nil
+}
+
+func trimOneNewline(s string) string {
+	if strings.HasSuffix(s, "\r\n") {
+		return strings.TrimSuffix(s, "\r\n")
+	}
+	return strings.TrimSuffix(s, "\n")
 }
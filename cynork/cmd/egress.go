@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/spf13/cobra"
+)
+
+var (
+	egressProvider      string
+	egressOperation     string
+	egressScopeType     string
+	egressScopeID       string
+	egressRPM           int
+	egressMaxConcurrent int
+	egressBudgetUSD     float64
+	egressCostUSD       float64
+	egressDeleteYes     bool
+)
+
+var egressCmd = &cobra.Command{
+	Use:   "egress",
+	Short: "API Egress administration",
+}
+
+var egressLimitsCmd = &cobra.Command{
+	Use:   "limits",
+	Short: "Manage API Egress rate limits, concurrency caps, and monthly budgets",
+}
+
+var egressLimitsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List egress limits",
+	Args:  cobra.NoArgs,
+	RunE:  runEgressLimitsList,
+}
+
+var egressLimitsGetCmd = &cobra.Command{
+	Use:   "get <limit_id>",
+	Short: "Show an egress limit",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runEgressLimitsGet,
+}
+
+var egressLimitsCreateCmd = &cobra.Command{
+	Use:     "create",
+	Aliases: []string{"add"},
+	Short:   "Create an egress limit",
+	Args:    cobra.NoArgs,
+	RunE:    runEgressLimitsCreate,
+}
+
+var egressLimitsUpdateCmd = &cobra.Command{
+	Use:   "update <limit_id>",
+	Short: "Change an egress limit (unset flags keep their current value)",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runEgressLimitsUpdate,
+}
+
+var egressLimitsDeleteCmd = &cobra.Command{
+	Use:     "delete <limit_id>",
+	Aliases: []string{"rm"},
+	Short:   "Delete an egress limit",
+	Args:    cobra.ExactArgs(1),
+	RunE:    runEgressLimitsDelete,
+}
+
+func init() {
+	rootCmd.AddCommand(egressCmd)
+	egressCmd.AddCommand(egressLimitsCmd)
+	egressLimitsCmd.AddCommand(egressLimitsListCmd, egressLimitsGetCmd, egressLimitsCreateCmd, egressLimitsUpdateCmd, egressLimitsDeleteCmd)
+	egressLimitsListCmd.Flags().StringVar(&egressProvider, "provider", "", "filter by provider")
+	for _, c := range []*cobra.Command{egressLimitsCreateCmd, egressLimitsUpdateCmd} {
+		f := c.Flags()
+		f.StringVar(&egressProvider, "provider", "", "provider (e.g. openai, github)")
+		f.StringVar(&egressOperation, "operation", "", "operation (empty: every operation of the provider)")
+		f.StringVar(&egressScopeType, "scope-type", "", "system, user, or project")
+		f.StringVar(&egressScopeID, "scope-id", "", "user or project id (empty: each user or project separately)")
+		f.IntVar(&egressRPM, "rpm", 0, "requests per minute (0: unlimited)")
+		f.IntVar(&egressMaxConcurrent, "max-concurrent", 0, "concurrent calls (0: unlimited)")
+		f.Float64Var(&egressBudgetUSD, "monthly-budget-usd", 0, "monthly estimated spend in USD (0: unlimited)")
+		f.Float64Var(&egressCostUSD, "cost-per-call-usd", 0, "estimated cost charged per call in USD")
+	}
+	_ = egressLimitsCreateCmd.MarkFlagRequired("provider")
+	_ = egressLimitsCreateCmd.MarkFlagRequired("scope-type")
+	egressLimitsDeleteCmd.Flags().BoolVarP(&egressDeleteYes, "yes", "y", false, "skip confirmation")
+}
+
+func runEgressLimitsList(_ *cobra.Command, _ []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	resp, err := client.ListEgressLimits(egressProvider)
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(resp)
+		return nil
+	}
+	fmt.Println("limit_id\tprovider\toperation\tscope_type\tscope_id\trpm\tmax_concurrent\tmonthly_budget_usd\tcost_per_call_usd")
+	for i := range resp.Limits {
+		l := &resp.Limits[i]
+		operation, scopeID := "*", "-"
+		if l.Operation != "" {
+			operation = l.Operation
+		}
+		if l.ScopeID != nil {
+			scopeID = *l.ScopeID
+		}
+		fmt.Printf("%s\t%s\t%s\t%s\t%s\t%d\t%d\t%g\t%g\n", l.ID, l.Provider, operation, l.ScopeType, scopeID,
+			l.RequestsPerMinute, l.MaxConcurrent, l.MonthlyBudgetUSD, l.CostPerCallUSD)
+	}
+	return nil
+}
+
+func runEgressLimitsGet(_ *cobra.Command, args []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	resp, err := client.GetEgressLimit(args[0])
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	printEgressLimit(resp)
+	return nil
+}
+
+func runEgressLimitsCreate(_ *cobra.Command, _ []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	req := &userapi.EgressLimitRequest{
+		Provider: egressProvider, Operation: egressOperation, ScopeType: egressScopeType,
+		RequestsPerMinute: egressRPM, MaxConcurrent: egressMaxConcurrent, MonthlyBudgetUSD: egressBudgetUSD, CostPerCallUSD: egressCostUSD,
+	}
+	if egressScopeID != "" {
+		req.ScopeID = &egressScopeID
+	}
+	resp, err := client.CreateEgressLimit(req)
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(map[string]any{"limit_id": resp.ID})
+		return nil
+	}
+	fmt.Printf("limit_id=%s\n", resp.ID)
+	return nil
+}
+
+// runEgressLimitsUpdate loads the limit, applies the flags that were set, and sends the whole limit back.
+func runEgressLimitsUpdate(cmd *cobra.Command, args []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	cur, err := client.GetEgressLimit(args[0])
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	req := &userapi.EgressLimitRequest{
+		Provider: cur.Provider, Operation: cur.Operation, ScopeType: cur.ScopeType, ScopeID: cur.ScopeID,
+		RequestsPerMinute: cur.RequestsPerMinute, MaxConcurrent: cur.MaxConcurrent,
+		MonthlyBudgetUSD: cur.MonthlyBudgetUSD, CostPerCallUSD: cur.CostPerCallUSD,
+	}
+	flags := cmd.Flags()
+	if flags.Changed("provider") {
+		req.Provider = egressProvider
+	}
+	if flags.Changed("operation") {
+		req.Operation = egressOperation
+	}
+	if flags.Changed("scope-type") {
+		req.ScopeType = egressScopeType
+		if egressScopeType == userapi.EgressLimitScopeSystem {
+			req.ScopeID = nil
+		}
+	}
+	if flags.Changed("scope-id") {
+		req.ScopeID = &egressScopeID
+	}
+	if flags.Changed("rpm") {
+		req.RequestsPerMinute = egressRPM
+	}
+	if flags.Changed("max-concurrent") {
+		req.MaxConcurrent = egressMaxConcurrent
+	}
+	if flags.Changed("monthly-budget-usd") {
+		req.MonthlyBudgetUSD = egressBudgetUSD
+	}
+	if flags.Changed("cost-per-call-usd") {
+		req.CostPerCallUSD = egressCostUSD
+	}
+	resp, err := client.UpdateEgressLimit(args[0], req)
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	printEgressLimit(resp)
+	return nil
+}
+
+func runEgressLimitsDelete(_ *cobra.Command, args []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	id := args[0]
+	if !egressDeleteYes {
+		fmt.Fprintf(os.Stderr, "Delete egress limit %s? [y/N] ", id)
+		var ch string
+		if _, err := fmt.Scanln(&ch); err != nil || (ch != "y" && ch != "Y") {
+			return nil
+		}
+	}
+	if err := client.DeleteEgressLimit(id); err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(map[string]any{"limit_id": id, "deleted": true})
+		return nil
+	}
+	fmt.Printf("limit_id=%s deleted=true\n", id)
+	return nil
+}
+
+func printEgressLimit(l *userapi.EgressLimitResponse) {
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(l)
+		return
+	}
+	line := fmt.Sprintf("limit_id=%s provider=%s", l.ID, l.Provider)
+	if l.Operation != "" {
+		line += " operation=" + l.Operation
+	}
+	line += " scope_type=" + l.ScopeType
+	if l.ScopeID != nil {
+		line += " scope_id=" + *l.ScopeID
+	}
+	fmt.Printf("%s rpm=%d max_concurrent=%d monthly_budget_usd=%g cost_per_call_usd=%g\n",
+		line, l.RequestsPerMinute, l.MaxConcurrent, l.MonthlyBudgetUSD, l.CostPerCallUSD)
+}
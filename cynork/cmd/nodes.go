@@ -1,4 +1,4 @@
-//nolint:dupl // stub command structure shared with audit, creds
+//nolint:dupl // stub command structure shared with audit
 package cmd
 
 import (
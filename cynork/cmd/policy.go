@@ -0,0 +1,376 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cypher0n3/cynodeai/cynork/internal/exit"
+	"github.com/cypher0n3/cynodeai/cynork/internal/gateway"
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/spf13/cobra"
+)
+
+var (
+	policySubjectType  string
+	policySubjectID    string
+	policyAction       string
+	policyResourceType string
+	policyPattern      string
+	policyEffect       string
+	policyPriority     int
+	policyConditions   string
+	policyDeleteYes    bool
+
+	policyEvalUser         string
+	policyEvalGroup        string
+	policyEvalRole         string
+	policyEvalSystem       bool
+	policyEvalResource     string
+	policyEvalProject      string
+	policyEvalTaskType     string
+	policyEvalRequestBytes int64
+	policyEvalAt           string
+)
+
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Access control rules and policy dry-runs",
+}
+
+var policyRulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Manage access control rules",
+}
+
+var policyRulesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List access control rules",
+	Args:  cobra.NoArgs,
+	RunE:  runPolicyRulesList,
+}
+
+var policyRulesGetCmd = &cobra.Command{
+	Use:   "get <rule_id>",
+	Short: "Show an access control rule",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runPolicyRulesGet,
+}
+
+var policyRulesCreateCmd = &cobra.Command{
+	Use:     "create",
+	Aliases: []string{"add"},
+	Short:   "Create an access control rule",
+	Args:    cobra.NoArgs,
+	RunE:    runPolicyRulesCreate,
+}
+
+var policyRulesUpdateCmd = &cobra.Command{
+	Use:   "update <rule_id>",
+	Short: "Change an access control rule (unset flags keep their current value)",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runPolicyRulesUpdate,
+}
+
+var policyRulesDeleteCmd = &cobra.Command{
+	Use:     "delete <rule_id>",
+	Aliases: []string{"rm"},
+	Short:   "Delete an access control rule",
+	Args:    cobra.ExactArgs(1),
+	RunE:    runPolicyRulesDelete,
+}
+
+var policyEvaluateCmd = &cobra.Command{
+	Use:   "evaluate",
+	Short: "Show whether a request would be allowed and which rules matched (dry-run)",
+	Args:  cobra.NoArgs,
+	RunE:  runPolicyEvaluate,
+}
+
+func init() {
+	rootCmd.AddCommand(policyCmd)
+	policyCmd.AddCommand(policyRulesCmd, policyEvaluateCmd)
+	policyRulesCmd.AddCommand(policyRulesListCmd, policyRulesGetCmd, policyRulesCreateCmd, policyRulesUpdateCmd, policyRulesDeleteCmd)
+	policyRulesListCmd.Flags().StringVar(&policyAction, "action", "", "filter by action (e.g. api.call)")
+	policyRulesListCmd.Flags().StringVar(&policyResourceType, "resource-type", "", "filter by resource type")
+	policyRulesListCmd.Flags().StringVar(&policySubjectType, "subject-type", "", "filter by subject type")
+	policyRulesListCmd.Flags().StringVar(&policySubjectID, "subject-id", "", "filter by subject id")
+	for _, c := range []*cobra.Command{policyRulesCreateCmd, policyRulesUpdateCmd} {
+		c.Flags().StringVar(&policySubjectType, "subject-type", "", "system, user, group, or role")
+		c.Flags().StringVar(&policySubjectID, "subject-id", "", "subject id (empty for system rules)")
+		c.Flags().StringVar(&policyAction, "action", "", "action (e.g. api.call, mcp.tool.invoke, task.create)")
+		c.Flags().StringVar(&policyResourceType, "resource-type", "", "resource type (e.g. api.provider_operation)")
+		c.Flags().StringVar(&policyPattern, "pattern", "", "resource pattern (exact, glob, or prefix ending in **)")
+		c.Flags().StringVar(&policyEffect, "effect", "", "allow or deny")
+		c.Flags().IntVar(&policyPriority, "priority", 0, "priority; higher wins, deny wins ties")
+		c.Flags().StringVar(&policyConditions, "conditions", "", "conditions JSON object; \"\" clears on update")
+	}
+	for _, name := range []string{"subject-type", "action", "resource-type", "pattern", "effect"} {
+		_ = policyRulesCreateCmd.MarkFlagRequired(name)
+	}
+	policyRulesDeleteCmd.Flags().BoolVarP(&policyDeleteYes, "yes", "y", false, "skip confirmation")
+	f := policyEvaluateCmd.Flags()
+	f.StringVar(&policyEvalUser, "user", "", "evaluate as this user handle (default: yourself)")
+	f.StringVar(&policyEvalGroup, "group", "", "evaluate as this group id")
+	f.StringVar(&policyEvalRole, "role", "", "evaluate as this role name")
+	f.BoolVar(&policyEvalSystem, "system", false, "evaluate with system rules only (no user context)")
+	f.StringVar(&policyAction, "action", "", "action (e.g. api.call)")
+	f.StringVar(&policyResourceType, "resource-type", "", "resource type (default from the action)")
+	f.StringVar(&policyEvalResource, "resource", "", "resource (e.g. openai/chat_completions)")
+	f.StringVar(&policyEvalProject, "project", "", "project id checked by project_ids conditions")
+	f.StringVar(&policyEvalTaskType, "task-type", "", "task type checked by task_types conditions")
+	f.Int64Var(&policyEvalRequestBytes, "request-bytes", 0, "request size checked by max_request_bytes conditions")
+	f.StringVar(&policyEvalAt, "at", "", "evaluate at this time, RFC 3339 (default: now)")
+	_ = policyEvaluateCmd.MarkFlagRequired("action")
+	_ = policyEvaluateCmd.MarkFlagRequired("resource")
+}
+
+func runPolicyRulesList(_ *cobra.Command, _ []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	resp, err := client.ListPolicyRules(gateway.PolicyRuleFilter{
+		Action: policyAction, ResourceType: policyResourceType, SubjectType: policySubjectType, SubjectID: policySubjectID,
+	})
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(resp)
+		return nil
+	}
+	printPolicyRuleTable(resp.Rules)
+	return nil
+}
+
+func runPolicyRulesGet(_ *cobra.Command, args []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	resp, err := client.GetPolicyRule(args[0])
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	printPolicyRule(resp)
+	return nil
+}
+
+func runPolicyRulesCreate(_ *cobra.Command, _ []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	req := &userapi.PolicyRuleRequest{
+		SubjectType: policySubjectType, Action: policyAction, ResourceType: policyResourceType,
+		ResourcePattern: policyPattern, Effect: policyEffect, Priority: policyPriority,
+	}
+	if policySubjectID != "" {
+		req.SubjectID = &policySubjectID
+	}
+	if req.Conditions, err = policyConditionsJSON(policyConditions); err != nil {
+		return err
+	}
+	resp, err := client.CreatePolicyRule(req)
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(map[string]any{"rule_id": resp.ID})
+		return nil
+	}
+	fmt.Printf("rule_id=%s\n", resp.ID)
+	return nil
+}
+
+// runPolicyRulesUpdate loads the rule, applies the flags that were set, and sends the whole rule back.
+func runPolicyRulesUpdate(cmd *cobra.Command, args []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	cur, err := client.GetPolicyRule(args[0])
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	req := &userapi.PolicyRuleRequest{
+		SubjectType: cur.SubjectType, SubjectID: cur.SubjectID, Action: cur.Action, ResourceType: cur.ResourceType,
+		ResourcePattern: cur.ResourcePattern, Effect: cur.Effect, Priority: cur.Priority, Conditions: cur.Conditions,
+	}
+	flags := cmd.Flags()
+	if flags.Changed("subject-type") {
+		req.SubjectType = policySubjectType
+		if policySubjectType == userapi.PolicySubjectSystem {
+			req.SubjectID = nil
+		}
+	}
+	if flags.Changed("subject-id") {
+		req.SubjectID = &policySubjectID
+	}
+	if flags.Changed("action") {
+		req.Action = policyAction
+	}
+	if flags.Changed("resource-type") {
+		req.ResourceType = policyResourceType
+	}
+	if flags.Changed("pattern") {
+		req.ResourcePattern = policyPattern
+	}
+	if flags.Changed("effect") {
+		req.Effect = policyEffect
+	}
+	if flags.Changed("priority") {
+		req.Priority = policyPriority
+	}
+	if flags.Changed("conditions") {
+		if req.Conditions, err = policyConditionsJSON(policyConditions); err != nil {
+			return err
+		}
+	}
+	resp, err := client.UpdatePolicyRule(args[0], req)
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	printPolicyRule(resp)
+	return nil
+}
+
+func runPolicyRulesDelete(_ *cobra.Command, args []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	id := args[0]
+	if !policyDeleteYes {
+		fmt.Fprintf(os.Stderr, "Delete policy rule %s? [y/N] ", id)
+		var ch string
+		if _, err := fmt.Scanln(&ch); err != nil || (ch != "y" && ch != "Y") {
+			return nil
+		}
+	}
+	if err := client.DeletePolicyRule(id); err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(map[string]any{"rule_id": id, "deleted": true})
+		return nil
+	}
+	fmt.Printf("rule_id=%s deleted=true\n", id)
+	return nil
+}
+
+func runPolicyEvaluate(_ *cobra.Command, _ []string) error {
+	req, err := policyEvaluateRequest()
+	if err != nil {
+		return err
+	}
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	resp, err := client.EvaluatePolicy(req)
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(resp)
+		return nil
+	}
+	line := fmt.Sprintf("allowed=%t resource_type=%s", resp.Allowed, resp.ResourceType)
+	if resp.Rule != nil {
+		line += " rule_id=" + resp.Rule.ID
+	}
+	if resp.Reason != "" {
+		line += fmt.Sprintf(" reason=%q", resp.Reason)
+	}
+	fmt.Println(line)
+	p := resp.Principal
+	if p.UserID != nil {
+		fmt.Printf("user_id=%s ", *p.UserID)
+	}
+	fmt.Printf("groups=%s roles=%s\n", strings.Join(p.GroupIDs, ","), strings.Join(p.RoleNames, ","))
+	if len(resp.MatchedRules) > 0 {
+		printPolicyRuleTable(resp.MatchedRules)
+	}
+	return nil
+}
+
+// policyEvaluateRequest builds the dry-run request from flags; at most one subject flag may be set.
+func policyEvaluateRequest() (*userapi.PolicyEvaluateRequest, error) {
+	req := &userapi.PolicyEvaluateRequest{Action: policyAction, ResourceType: policyResourceType, Resource: policyEvalResource}
+	subjects := 0
+	if policyEvalUser != "" {
+		req.SubjectType, req.Handle = userapi.PolicySubjectUser, policyEvalUser
+		subjects++
+	}
+	if policyEvalGroup != "" {
+		req.SubjectType, req.SubjectID = userapi.PolicySubjectGroup, policyEvalGroup
+		subjects++
+	}
+	if policyEvalRole != "" {
+		req.SubjectType, req.Role = userapi.PolicySubjectRole, policyEvalRole
+		subjects++
+	}
+	if policyEvalSystem {
+		req.SubjectType = userapi.PolicySubjectSystem
+		subjects++
+	}
+	if subjects > 1 {
+		return nil, exit.Usage(fmt.Errorf("use only one of --user, --group, --role, and --system"))
+	}
+	if policyEvalProject != "" || policyEvalTaskType != "" || policyEvalRequestBytes != 0 || policyEvalAt != "" {
+		req.Context = &userapi.PolicyEvaluateContext{TaskType: policyEvalTaskType, RequestBytes: policyEvalRequestBytes}
+		if policyEvalProject != "" {
+			req.Context.ProjectID = &policyEvalProject
+		}
+		if policyEvalAt != "" {
+			req.Context.Time = &policyEvalAt
+		}
+	}
+	return req, nil
+}
+
+// policyConditionsJSON validates --conditions as a JSON object; empty clears conditions.
+func policyConditionsJSON(s string) (json.RawMessage, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(s), &obj); err != nil {
+		return nil, exit.Usage(fmt.Errorf("--conditions must be a JSON object: %w", err))
+	}
+	return json.RawMessage(s), nil
+}
+
+func printPolicyRuleTable(rules []userapi.PolicyRuleResponse) {
+	fmt.Println("rule_id\tsubject_type\tsubject_id\taction\tresource_type\tpattern\teffect\tpriority")
+	for i := range rules {
+		r := &rules[i]
+		subjectID := "-"
+		if r.SubjectID != nil {
+			subjectID = *r.SubjectID
+		}
+		fmt.Printf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d\n",
+			r.ID, r.SubjectType, subjectID, r.Action, r.ResourceType, r.ResourcePattern, r.Effect, r.Priority)
+	}
+}
+
+func printPolicyRule(r *userapi.PolicyRuleResponse) {
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(r)
+		return
+	}
+	line := fmt.Sprintf("rule_id=%s subject_type=%s", r.ID, r.SubjectType)
+	if r.SubjectID != nil {
+		line += " subject_id=" + *r.SubjectID
+	}
+	line += fmt.Sprintf(" action=%s resource_type=%s pattern=%s effect=%s priority=%d",
+		r.Action, r.ResourceType, r.ResourcePattern, r.Effect, r.Priority)
+	if len(r.Conditions) > 0 {
+		line += " conditions=" + string(r.Conditions)
+	}
+	fmt.Println(line)
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/url"
 	"os"
 	"os/signal"
 	"strings"
@@ -40,6 +39,8 @@ var taskListCursor string
 var taskCancelYes bool
 var taskResultWait bool
 var taskResultWaitInterval time.Duration
+var taskLogsStream string
+var taskLogsFollow bool
 
 // taskCmd represents the task command group.
 var taskCmd = &cobra.Command{
@@ -95,18 +96,6 @@ var taskWatchCmd = &cobra.Command{
 	RunE:  runTaskWatch,
 }
 
-var taskArtifactsCmd = &cobra.Command{
-	Use:   "artifacts",
-	Short: "Task artifacts (list, get)",
-}
-
-var taskArtifactsListCmd = &cobra.Command{
-	Use:   "list [task-id]",
-	Short: "List artifacts for a task",
-	Args:  cobra.ExactArgs(1),
-	RunE:  runTaskArtifactsList,
-}
-
 var terminalTaskStatuses = map[string]bool{
 	"completed":  true,
 	"failed":     true,
@@ -117,7 +106,8 @@ var terminalTaskStatuses = map[string]bool{
 func init() {
 	rootCmd.AddCommand(taskCmd)
 	taskCmd.AddCommand(taskCreateCmd, taskListCmd, taskGetCmd, taskResultCmd, taskCancelCmd, taskLogsCmd, taskWatchCmd, taskArtifactsCmd)
-	taskArtifactsCmd.AddCommand(taskArtifactsListCmd)
+	taskLogsCmd.Flags().StringVar(&taskLogsStream, "stream", "all", "log stream: stdout, stderr, or all")
+	taskLogsCmd.Flags().BoolVarP(&taskLogsFollow, "follow", "f", false, "stream new log lines until the task finishes")
 	taskWatchCmd.Flags().DurationVarP(&taskWatchInterval, "interval", "n", 2*time.Second, "poll interval")
 	taskWatchCmd.Flags().BoolVar(&taskWatchNoClear, "no-clear", false, "do not clear screen between polls")
 	taskCreateCmd.Flags().StringVarP(&taskCreateTask, "task", "t", "", "task text (inline)")
@@ -470,9 +460,17 @@ func runTaskLogs(_ *cobra.Command, args []string) error {
 	if cfg.Token == "" {
 		return exit.Auth(fmt.Errorf("not logged in: run 'cynork auth login'"))
 	}
+	switch taskLogsStream {
+	case "stdout", "stderr", "all":
+	default:
+		return exit.Usage(fmt.Errorf("--stream must be stdout, stderr, or all"))
+	}
 	client := gateway.NewClient(cfg.GatewayURL)
 	client.SetToken(cfg.Token)
-	logs, err := client.GetTaskLogs(args[0], "")
+	if taskLogsFollow {
+		return followTaskLogs(client, args[0])
+	}
+	logs, err := client.GetTaskLogs(args[0], taskLogsStream)
 	if err != nil {
 		return exitFromGatewayErr(err)
 	}
@@ -489,6 +487,28 @@ func runTaskLogs(_ *cobra.Command, args []string) error {
 	return nil
 }
 
+// followTaskLogs prints log lines as the gateway streams them: raw lines (stderr lines to stderr) in table
+// mode, one JSON object per event in JSON mode.
+func followTaskLogs(client *gateway.Client, taskID string) error {
+	end, err := client.FollowTaskLogs(taskID, taskLogsStream, func(e userapi.TaskLogEvent) {
+		switch {
+		case outputFmt == outputFormatJSON:
+			_ = json.NewEncoder(os.Stdout).Encode(e)
+		case e.Stream == "stderr":
+			fmt.Fprintln(os.Stderr, e.Line)
+		default:
+			fmt.Println(e.Line)
+		}
+	})
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = json.NewEncoder(os.Stdout).Encode(end)
+	}
+	return nil
+}
+
 func printTaskResult(result *userapi.TaskResultResponse) {
 	if outputFmt == outputFormatJSON {
 		printTaskResultJSON(result)
@@ -542,24 +562,6 @@ func parseTaskJobResultStderr(raw string) (string, bool) {
 	return "", false
 }
 
-func runTaskArtifactsList(_ *cobra.Command, args []string) error {
-	if cfg.Token == "" {
-		return exit.Auth(fmt.Errorf("not logged in: run 'cynork auth login'"))
-	}
-	client := gateway.NewClient(cfg.GatewayURL)
-	client.SetToken(cfg.Token)
-	path := "/v1/tasks/" + url.PathEscape(args[0]) + "/artifacts"
-	body, err := client.GetBytes(path)
-	if err != nil {
-		return exitFromGatewayErr(err)
-	}
-	if len(body) == 0 {
-		body = []byte("[]")
-	}
-	printJSONOrRaw(body)
-	return nil
-}
-
 func runTaskWatch(_ *cobra.Command, args []string) error {
 	if cfg.Token == "" {
 		return exit.Auth(fmt.Errorf("not logged in: run 'cynork auth login'"))
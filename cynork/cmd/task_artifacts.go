@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+
+	"github.com/cypher0n3/cynodeai/cynork/internal/exit"
+	"github.com/cypher0n3/cynodeai/cynork/internal/gateway"
+	"github.com/spf13/cobra"
+)
+
+var (
+	taskArtifactOut         string
+	taskArtifactForce       bool
+	taskArtifactContentType string
+)
+
+var taskArtifactsCmd = &cobra.Command{
+	Use:   "artifacts",
+	Short: "Task artifacts (list, get, put)",
+}
+
+var taskArtifactsListCmd = &cobra.Command{
+	Use:   "list [task-id]",
+	Short: "List artifacts for a task",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runTaskArtifactsList,
+}
+
+var taskArtifactsGetCmd = &cobra.Command{
+	Use:   "get [task-id] [path]",
+	Short: "Download an artifact to --out, verifying its SHA-256",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runTaskArtifactsGet,
+}
+
+var taskArtifactsPutCmd = &cobra.Command{
+	Use:   "put [task-id] [path] [local-file]",
+	Short: "Upload a local file as a task artifact",
+	Args:  cobra.ExactArgs(3),
+	RunE:  runTaskArtifactsPut,
+}
+
+func init() {
+	taskArtifactsCmd.AddCommand(taskArtifactsListCmd, taskArtifactsGetCmd, taskArtifactsPutCmd)
+	taskArtifactsGetCmd.Flags().StringVar(&taskArtifactOut, "out", "", "file to write the artifact to (required)")
+	taskArtifactsGetCmd.Flags().BoolVar(&taskArtifactForce, "force", false, "overwrite --out if it exists")
+	_ = taskArtifactsGetCmd.MarkFlagRequired("out")
+	taskArtifactsPutCmd.Flags().StringVar(&taskArtifactContentType, "content-type", "", "content type (default: from the file extension)")
+}
+
+func newTaskArtifactsClient() (*gateway.Client, error) {
+	if cfg.Token == "" {
+		return nil, exit.Auth(fmt.Errorf("not logged in: run 'cynork auth login'"))
+	}
+	client := gateway.NewClient(cfg.GatewayURL)
+	client.SetToken(cfg.Token)
+	return client, nil
+}
+
+func runTaskArtifactsList(_ *cobra.Command, args []string) error {
+	client, err := newTaskArtifactsClient()
+	if err != nil {
+		return err
+	}
+	resp, err := client.ListArtifacts(args[0])
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(resp)
+		return nil
+	}
+	fmt.Println("path\tcontent_type\tsize_bytes\tchecksum_sha256")
+	for _, a := range resp.Artifacts {
+		ct, size, sum := "", "", ""
+		if a.ContentType != nil {
+			ct = *a.ContentType
+		}
+		if a.SizeBytes != nil {
+			size = fmt.Sprint(*a.SizeBytes)
+		}
+		if a.ChecksumSHA256 != nil {
+			sum = *a.ChecksumSHA256
+		}
+		fmt.Printf("%s\t%s\t%s\t%s\n", a.Path, ct, size, sum)
+	}
+	return nil
+}
+
+func runTaskArtifactsGet(_ *cobra.Command, args []string) error {
+	client, err := newTaskArtifactsClient()
+	if err != nil {
+		return err
+	}
+	if !taskArtifactForce {
+		if _, err := os.Stat(taskArtifactOut); err == nil {
+			return exit.Conflict(fmt.Errorf("%s exists: use --force to overwrite", taskArtifactOut))
+		}
+	}
+	dir := filepath.Dir(taskArtifactOut)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return exit.Internal(err)
+	}
+	// Download next to the target and rename only once the checksum has been verified.
+	tmp, err := os.CreateTemp(dir, ".cynork-artifact-*")
+	if err != nil {
+		return exit.Internal(err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	_, err = client.GetArtifact(args[0], args[1], tmp)
+	if cerr := tmp.Close(); err == nil && cerr != nil {
+		return exit.Internal(cerr)
+	}
+	if errors.Is(err, gateway.ErrArtifactChecksum) {
+		return exit.Validation(err)
+	}
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if err := os.Rename(tmp.Name(), taskArtifactOut); err != nil {
+		return exit.Internal(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(map[string]any{"saved": true, "path": taskArtifactOut})
+		return nil
+	}
+	fmt.Printf("saved=true path=%s\n", taskArtifactOut)
+	return nil
+}
+
+func runTaskArtifactsPut(_ *cobra.Command, args []string) error {
+	client, err := newTaskArtifactsClient()
+	if err != nil {
+		return err
+	}
+	local := args[2]
+	sum, size, err := fileChecksum(local)
+	if err != nil {
+		return exit.Usage(err)
+	}
+	f, err := os.Open(local)
+	if err != nil {
+		return exit.Usage(err)
+	}
+	defer func() { _ = f.Close() }()
+	contentType := taskArtifactContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(local))
+	}
+	resp, err := client.PutArtifact(args[0], args[1], contentType, f, size, sum)
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(resp)
+		return nil
+	}
+	fmt.Printf("saved=true path=%s size_bytes=%d checksum_sha256=%s\n", resp.Path, size, sum)
+	return nil
+}
+
+// fileChecksum returns the hex SHA-256 and size of a local file.
+func fileChecksum(name string) (sum string, size int64, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	if size, err = io.Copy(h, f); err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
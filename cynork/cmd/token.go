@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cypher0n3/cynodeai/cynork/internal/exit"
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tokenName           string
+	tokenScopes         []string
+	tokenExpiresIn      string
+	tokenServiceAccount string
+	tokenRevokeYes      bool
+)
+
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Personal access tokens and service accounts for automation",
+}
+
+var tokenCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a scoped access token (printed once)",
+	Args:  cobra.NoArgs,
+	RunE:  runTokenCreate,
+}
+
+var tokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List access tokens (metadata only)",
+	Args:  cobra.NoArgs,
+	RunE:  runTokenList,
+}
+
+var tokenRevokeCmd = &cobra.Command{
+	Use:   "revoke <token_id>",
+	Short: "Revoke an access token",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runTokenRevoke,
+}
+
+var tokenServiceAccountCmd = &cobra.Command{
+	Use:   "service-account",
+	Short: "Manage service accounts (admin)",
+}
+
+var tokenServiceAccountCreateCmd = &cobra.Command{
+	Use:   "create <handle>",
+	Short: "Create a service account",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runTokenServiceAccountCreate,
+}
+
+var tokenServiceAccountListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List service accounts",
+	Args:  cobra.NoArgs,
+	RunE:  runTokenServiceAccountList,
+}
+
+func init() {
+	rootCmd.AddCommand(tokenCmd)
+	tokenCmd.AddCommand(tokenCreateCmd, tokenListCmd, tokenRevokeCmd, tokenServiceAccountCmd)
+	tokenServiceAccountCmd.AddCommand(tokenServiceAccountCreateCmd, tokenServiceAccountListCmd)
+	tokenCreateCmd.Flags().StringVar(&tokenName, "name", "", "token name, e.g. the automation that uses it")
+	tokenCreateCmd.Flags().StringArrayVar(&tokenScopes, "scope", nil,
+		"scope to grant: all, read-only, tasks:read, tasks:write, chat, skills:write (repeatable)")
+	tokenCreateCmd.Flags().StringVar(&tokenExpiresIn, "expires-in", "", "lifetime, e.g. 90d or 12h (default: no expiry)")
+	_ = tokenCreateCmd.MarkFlagRequired("name")
+	_ = tokenCreateCmd.MarkFlagRequired("scope")
+	for _, c := range []*cobra.Command{tokenCreateCmd, tokenListCmd, tokenRevokeCmd} {
+		c.Flags().StringVar(&tokenServiceAccount, "service-account", "", "act on this service account's tokens (admin)")
+	}
+	tokenRevokeCmd.Flags().BoolVarP(&tokenRevokeYes, "yes", "y", false, "skip confirmation")
+}
+
+func runTokenCreate(_ *cobra.Command, _ []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	req := &userapi.CreateAccessTokenRequest{Name: tokenName, Scopes: tokenScopes}
+	if tokenExpiresIn != "" {
+		d, err := parseTokenLifetime(tokenExpiresIn)
+		if err != nil {
+			return exit.Usage(err)
+		}
+		at := time.Now().Add(d).UTC().Format(time.RFC3339)
+		req.ExpiresAt = &at
+	}
+	resp, err := client.CreateAccessToken(tokenServiceAccount, req)
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(resp)
+		return nil
+	}
+	fmt.Printf("token_id=%s\n", resp.ID)
+	fmt.Println(resp.Token)
+	fmt.Fprintln(os.Stderr, "Store this token now; it will not be shown again.")
+	return nil
+}
+
+func runTokenList(_ *cobra.Command, _ []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	resp, err := client.ListAccessTokens(tokenServiceAccount)
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(resp)
+		return nil
+	}
+	fmt.Println("token_id\tname\tprefix\tscopes\tactive\texpires_at\tlast_used_at\tcreated_at")
+	for i := range resp.Tokens {
+		t := &resp.Tokens[i]
+		fmt.Printf("%s\t%s\t%s\t%s\t%t\t%s\t%s\t%s\n", t.ID, t.Name, t.TokenPrefix, strings.Join(t.Scopes, ","),
+			t.IsActive, derefOrDash(t.ExpiresAt), derefOrDash(t.LastUsedAt), t.CreatedAt)
+	}
+	return nil
+}
+
+func runTokenRevoke(_ *cobra.Command, args []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	id := args[0]
+	if !tokenRevokeYes {
+		fmt.Fprintf(os.Stderr, "Revoke token %s? [y/N] ", id)
+		var ch string
+		if _, err := fmt.Scanln(&ch); err != nil || (ch != "y" && ch != "Y") {
+			return nil
+		}
+	}
+	if err := client.RevokeAccessToken(tokenServiceAccount, id); err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(map[string]any{"token_id": id, "revoked": true})
+		return nil
+	}
+	fmt.Printf("token_id=%s revoked=true\n", id)
+	return nil
+}
+
+func runTokenServiceAccountCreate(_ *cobra.Command, args []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	resp, err := client.CreateServiceAccount(args[0])
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(resp)
+		return nil
+	}
+	fmt.Printf("id=%s handle=%s\n", resp.ID, resp.Handle)
+	return nil
+}
+
+func runTokenServiceAccountList(_ *cobra.Command, _ []string) error {
+	client, err := credsClient()
+	if err != nil {
+		return err
+	}
+	resp, err := client.ListServiceAccounts()
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(resp)
+		return nil
+	}
+	fmt.Println("id\thandle\tactive")
+	for i := range resp.ServiceAccounts {
+		u := &resp.ServiceAccounts[i]
+		fmt.Printf("%s\t%s\t%t\n", u.ID, u.Handle, u.IsActive)
+	}
+	return nil
+}
+
+func derefOrDash(s *string) string {
+	if s == nil {
+		return "-"
+	}
+	return *s
+}
+
+// parseTokenLifetime accepts a Go duration or a whole number of days such as "90d".
+func parseTokenLifetime(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid --expires-in %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid --expires-in %q", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cypher0n3/cynodeai/cynork/internal/exit"
+	"github.com/cypher0n3/cynodeai/cynork/internal/gateway"
+	"github.com/spf13/cobra"
+)
+
+var (
+	usageSince     string
+	usageUntil     string
+	usageProjectID string
+)
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Show token usage and quota status (GET /v1/usage)",
+	Args:  cobra.NoArgs,
+	RunE:  runUsage,
+}
+
+func init() {
+	rootCmd.AddCommand(usageCmd)
+	usageCmd.Flags().StringVar(&usageSince, "since", "", "start of the window, RFC 3339 (default: start of the current UTC month)")
+	usageCmd.Flags().StringVar(&usageUntil, "until", "", "end of the window, RFC 3339 (default: now)")
+	usageCmd.Flags().StringVar(&usageProjectID, "project-id", "", "limit usage and quotas to one project")
+}
+
+func runUsage(_ *cobra.Command, _ []string) error {
+	if cfg.Token == "" {
+		return exit.Auth(fmt.Errorf("not logged in: run 'cynork auth login'"))
+	}
+	client := gateway.NewClient(cfg.GatewayURL)
+	client.SetToken(cfg.Token)
+	resp, err := client.GetUsage(usageSince, usageUntil, usageProjectID)
+	if err != nil {
+		return exitFromGatewayErr(err)
+	}
+	if outputFmt == outputFormatJSON {
+		_ = jsonOutputEncoder().Encode(resp)
+		return nil
+	}
+	fmt.Printf("since=%s until=%s prompt_tokens=%d completion_tokens=%d total_tokens=%d\n",
+		resp.Since, resp.Until, resp.Totals.PromptTokens, resp.Totals.CompletionTokens, resp.Totals.TotalTokens)
+	for _, m := range resp.ByModel {
+		fmt.Printf("model=%s prompt_tokens=%d completion_tokens=%d total_tokens=%d\n",
+			m.Model, m.PromptTokens, m.CompletionTokens, m.TotalTokens)
+	}
+	for _, q := range resp.Quotas {
+		line := fmt.Sprintf("quota scope=%s kind=%s used=%d limit=%d", q.Scope, q.Kind, q.Used, q.Limit)
+		if q.ResetsAt != nil {
+			line += " resets_at=" + *q.ResetsAt
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package gateway
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+)
+
+// accessTokensPath returns the token collection of the caller (serviceAccountID empty) or of a service account.
+func accessTokensPath(serviceAccountID string) string {
+	if serviceAccountID == "" {
+		return "/v1/users/me/tokens"
+	}
+	return "/v1/service-accounts/" + url.PathEscape(serviceAccountID) + "/tokens"
+}
+
+// ListAccessTokens calls GET /v1/users/me/tokens, or GET /v1/service-accounts/{id}/tokens (admin) when
+// serviceAccountID is set. Only metadata is returned.
+func (c *Client) ListAccessTokens(serviceAccountID string) (*userapi.ListAccessTokensResponse, error) {
+	var out userapi.ListAccessTokensResponse
+	if err := c.doGetJSON(accessTokensPath(serviceAccountID), &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateAccessToken calls POST /v1/users/me/tokens, or POST /v1/service-accounts/{id}/tokens (admin) when
+// serviceAccountID is set. The response is the only time the token is returned.
+func (c *Client) CreateAccessToken(serviceAccountID string, req *userapi.CreateAccessTokenRequest) (*userapi.CreateAccessTokenResponse, error) {
+	var out userapi.CreateAccessTokenResponse
+	if err := c.doPostJSON(accessTokensPath(serviceAccountID), req, http.StatusCreated, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RevokeAccessToken calls DELETE on a token of the caller or of a service account (admin).
+func (c *Client) RevokeAccessToken(serviceAccountID, tokenID string) error {
+	_, err := c.DeleteBytes(accessTokensPath(serviceAccountID) + "/" + url.PathEscape(tokenID))
+	return err
+}
+
+// CreateServiceAccount calls POST /v1/service-accounts (admin).
+func (c *Client) CreateServiceAccount(handle string) (*userapi.UserResponse, error) {
+	var out userapi.UserResponse
+	req := userapi.CreateServiceAccountRequest{Handle: handle}
+	if err := c.doPostJSON("/v1/service-accounts", &req, http.StatusCreated, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListServiceAccounts calls GET /v1/service-accounts (admin).
+func (c *Client) ListServiceAccounts() (*userapi.ListServiceAccountsResponse, error) {
+	var out userapi.ListServiceAccountsResponse
+	if err := c.doGetJSON("/v1/service-accounts", &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,89 @@
+package gateway
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+)
+
+// ErrArtifactChecksum is returned by GetArtifact when the downloaded content does not match X-Checksum-SHA256.
+var ErrArtifactChecksum = errors.New("artifact checksum mismatch")
+
+// artifactPath returns /v1/tasks/{id}/artifacts/{path} with each path segment escaped.
+func artifactPath(taskID, artifact string) string {
+	segments := strings.Split(strings.Trim(artifact, "/"), "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return "/v1/tasks/" + url.PathEscape(taskID) + "/artifacts/" + strings.Join(segments, "/")
+}
+
+// ListArtifacts calls GET /v1/tasks/{id}/artifacts (requires auth).
+func (c *Client) ListArtifacts(taskID string) (*userapi.ListArtifactsResponse, error) {
+	var out userapi.ListArtifactsResponse
+	if err := c.doGetJSON("/v1/tasks/"+url.PathEscape(taskID)+"/artifacts", &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetArtifact calls GET /v1/tasks/{id}/artifacts/{path} and copies the content to w. The content is
+// hashed as it is copied and checked against the X-Checksum-SHA256 response header; on mismatch
+// ErrArtifactChecksum is returned after w has been written, so callers writing files should discard them.
+func (c *Client) GetArtifact(taskID, artifact string, w io.Writer) (checksum string, err error) {
+	resp, err := c.doRequest(http.MethodGet, artifactPath(taskID, artifact), nil, nil)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", c.parseError(resp)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, h), resp.Body); err != nil {
+		return "", fmt.Errorf("read artifact: %w", err)
+	}
+	checksum = hex.EncodeToString(h.Sum(nil))
+	if want := resp.Header.Get(userapi.HeaderChecksumSHA256); want != "" && !strings.EqualFold(want, checksum) {
+		return checksum, fmt.Errorf("%w: got %s, gateway reported %s", ErrArtifactChecksum, checksum, want)
+	}
+	return checksum, nil
+}
+
+// PutArtifact calls PUT /v1/tasks/{id}/artifacts/{path} with body as the content. checksum (hex SHA-256)
+// is sent in X-Checksum-SHA256 so the gateway rejects corrupted uploads; contentType may be empty.
+func (c *Client) PutArtifact(taskID, artifact, contentType string, body io.Reader, size int64, checksum string) (*userapi.ArtifactResponse, error) {
+	req, err := c.newRequest(http.MethodPut, artifactPath(taskID, artifact), nil, body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	req.Header.Set("Content-Type", contentType)
+	if checksum != "" {
+		req.Header.Set(userapi.HeaderChecksumSHA256, checksum)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, c.parseError(resp)
+	}
+	var out userapi.ArtifactResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode artifact response: %w", err)
+	}
+	return &out, nil
+}
@@ -0,0 +1,89 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+)
+
+// AuditEventFilter holds query params for GET /v1/audit; empty fields are not sent.
+type AuditEventFilter struct {
+	Since        string // RFC 3339, inclusive
+	Until        string // RFC 3339, exclusive
+	ActorID      string
+	TaskID       string
+	ProjectID    string
+	Source       string // auth, mcp_tool_call, access_control, chat, preference
+	Action       string // event type; a trailing "*" matches by prefix
+	ResourceType string
+	Decision     string // allow or deny
+	Limit        int    // default 50, max 200; ignored by exports
+	Cursor       string
+}
+
+func (f *AuditEventFilter) query() url.Values {
+	q := url.Values{}
+	for k, v := range map[string]string{
+		"since": f.Since, "until": f.Until, "actor_id": f.ActorID, "task_id": f.TaskID, "project_id": f.ProjectID,
+		"source": f.Source, "action": f.Action, "resource_type": f.ResourceType, "decision": f.Decision, "cursor": f.Cursor,
+	} {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	if f.Limit > 0 {
+		q.Set("limit", strconv.Itoa(f.Limit))
+	}
+	return q
+}
+
+// ListAuditEvents calls GET /v1/audit (requires audit.read).
+func (c *Client) ListAuditEvents(f AuditEventFilter) (*userapi.ListAuditEventsResponse, error) {
+	resp, err := c.doRequest(http.MethodGet, "/v1/audit", f.query(), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+	var out userapi.ListAuditEventsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode audit events response: %w", err)
+	}
+	return &out, nil
+}
+
+// GetAuditEvent calls GET /v1/audit/{id} (requires audit.read).
+func (c *Client) GetAuditEvent(id string) (*userapi.AuditEventResponse, error) {
+	var out userapi.AuditEventResponse
+	if err := c.doGetJSON("/v1/audit/"+url.PathEscape(id), &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ExportAuditEvents calls GET /v1/audit with format (userapi.AuditFormatJSONL or AuditFormatCSV) and copies every
+// matching event to w as the gateway streams it (requires audit.read).
+func (c *Client) ExportAuditEvents(f AuditEventFilter, format string, w io.Writer) error {
+	q := f.query()
+	q.Del("limit")
+	q.Set("format", format)
+	resp, err := c.doRequest(http.MethodGet, "/v1/audit", q, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return c.parseError(resp)
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("read audit export: %w", err)
+	}
+	return nil
+}
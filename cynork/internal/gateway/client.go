@@ -3,8 +3,10 @@
 package gateway
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -54,6 +56,49 @@ func (c *Client) Refresh(refreshToken string) (*userapi.LoginResponse, error) {
 	return &out, nil
 }
 
+// StartSSODevice calls POST /v1/auth/sso/device to begin a single sign-on device authorization (no auth header).
+func (c *Client) StartSSODevice() (*userapi.SSODeviceAuthorizationResponse, error) {
+	var out userapi.SSODeviceAuthorizationResponse
+	if err := c.doPostJSONNoAuth("/v1/auth/sso/device", struct{}{}, http.StatusOK, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PollSSODevice calls POST /v1/auth/sso/device/token once. It returns the tokens when sign-in is complete, or
+// nil and the pending status (authorization_pending or slow_down) while the user has not finished.
+func (c *Client) PollSSODevice(deviceCode string) (*userapi.LoginResponse, string, error) {
+	body, err := json.Marshal(userapi.SSODeviceTokenRequest{DeviceCode: deviceCode, ClientName: ClientName})
+	if err != nil {
+		return nil, "", fmt.Errorf("marshal request: %w", err)
+	}
+	req, err := c.newRequest(http.MethodPost, "/v1/auth/sso/device/token", nil, bytes.NewReader(body))
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Del("Authorization")
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	switch resp.StatusCode {
+	case http.StatusAccepted:
+		var pending userapi.SSODevicePendingResponse
+		if err := json.NewDecoder(resp.Body).Decode(&pending); err != nil {
+			return nil, "", fmt.Errorf("decode response: %w", err)
+		}
+		return nil, pending.Status, nil
+	case http.StatusOK:
+		var out userapi.LoginResponse
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			return nil, "", fmt.Errorf("decode response: %w", err)
+		}
+		return &out, "", nil
+	}
+	return nil, "", c.parseError(resp)
+}
+
 // GetMe calls GET /v1/users/me (requires auth).
 func (c *Client) GetMe() (*userapi.UserResponse, error) {
 	resp, err := c.doRequest(http.MethodGet, "/v1/users/me", nil, nil)
@@ -213,9 +258,62 @@ func (c *Client) GetTaskLogs(taskID, stream string) (*userapi.TaskLogsResponse,
 	return &out, nil
 }
 
+// FollowTaskLogs calls GET /v1/tasks/{id}/logs?follow=true and calls onLine for each log event until the
+// gateway sends the end event, which is returned. stream is stdout, stderr, all, or empty (all).
+func (c *Client) FollowTaskLogs(taskID, stream string, onLine func(userapi.TaskLogEvent)) (*userapi.TaskLogEnd, error) {
+	query := url.Values{"follow": {"true"}}
+	if stream != "" {
+		query.Set("stream", stream)
+	}
+	resp, err := c.doRequest(http.MethodGet, "/v1/tasks/"+url.PathEscape(taskID)+"/logs", query, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+	var event string
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name, ok := strings.CutPrefix(line, "event: "); ok {
+			event = name
+			continue
+		}
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		switch event {
+		case userapi.TaskLogEventLog:
+			var e userapi.TaskLogEvent
+			if err := json.Unmarshal([]byte(data), &e); err != nil {
+				return nil, fmt.Errorf("decode task log event: %w", err)
+			}
+			if onLine != nil {
+				onLine(e)
+			}
+		case userapi.TaskLogEventEnd:
+			var end userapi.TaskLogEnd
+			if err := json.Unmarshal([]byte(data), &end); err != nil {
+				return nil, fmt.Errorf("decode task log end: %w", err)
+			}
+			return &end, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read task log stream: %w", err)
+	}
+	return nil, fmt.Errorf("task log stream ended before the task finished")
+}
+
 // ChatResponse is the parsed chat result for callers (content from choices[0].message.content).
 type ChatResponse struct {
 	Response string
+	// Streamed is true when the response arrived as server-sent events (see ChatStream).
+	Streamed bool
 }
 
 // ListModelsResponse is the OpenAI-format response from GET /v1/models.
@@ -248,9 +346,72 @@ func (c *Client) Chat(message string) (*ChatResponse, error) {
 // ChatWithOptions is like Chat but allows session model and OpenAI-Project header.
 // If model is non-empty it is sent in the request body; if projectID is non-empty it is sent as OpenAI-Project header.
 func (c *Client) ChatWithOptions(message, model, projectID string) (*ChatResponse, error) {
+	resp, err := c.postChat(message, model, projectID, false)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	return decodeChatResponse(resp.Body)
+}
+
+// ChatStream is like ChatWithOptions but requests a streamed completion and calls onDelta with each content
+// fragment as it arrives (OpenAI chat.completion.chunk server-sent events). Streamed is set on the result when
+// the gateway streamed; a gateway that answers with a plain completion is decoded as ChatWithOptions does,
+// without calling onDelta.
+func (c *Client) ChatStream(message, model, projectID string, onDelta func(string)) (*ChatResponse, error) {
+	resp, err := c.postChat(message, model, projectID, true)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return decodeChatResponse(resp.Body)
+	}
+	var content strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if data == userapi.ChatCompletionsStreamDone {
+			return &ChatResponse{Response: content.String(), Streamed: true}, nil
+		}
+		var event struct {
+			userapi.ChatCompletionsChunk
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return nil, fmt.Errorf("decode chat stream: %w", err)
+		}
+		if event.Error != nil {
+			return nil, fmt.Errorf("chat stream: %s", event.Error.Message)
+		}
+		for _, choice := range event.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			content.WriteString(choice.Delta.Content)
+			if onDelta != nil {
+				onDelta(choice.Delta.Content)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read chat stream: %w", err)
+	}
+	return nil, fmt.Errorf("chat stream ended before completion")
+}
+
+// postChat sends POST /v1/chat/completions with one user message; returns the response when the status is 200.
+func (c *Client) postChat(message, model, projectID string, stream bool) (*http.Response, error) {
 	req := userapi.ChatCompletionsRequest{
 		Model:    model,
 		Messages: []userapi.ChatMessage{{Role: "user", Content: message}},
+		Stream:   stream,
 	}
 	body, err := json.Marshal(req)
 	if err != nil {
@@ -269,6 +430,9 @@ func (c *Client) ChatWithOptions(message, model, projectID string) (*ChatRespons
 		return nil, err
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
 	if c.Token != "" {
 		httpReq.Header.Set("Authorization", "Bearer "+c.Token)
 	}
@@ -279,12 +443,16 @@ func (c *Client) ChatWithOptions(message, model, projectID string) (*ChatRespons
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
 	if resp.StatusCode != http.StatusOK {
+		defer func() { _ = resp.Body.Close() }()
 		return nil, c.parseError(resp)
 	}
+	return resp, nil
+}
+
+func decodeChatResponse(body io.Reader) (*ChatResponse, error) {
 	var out userapi.ChatCompletionsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+	if err := json.NewDecoder(body).Decode(&out); err != nil {
 		return nil, fmt.Errorf("decode chat response: %w", err)
 	}
 	content := ""
@@ -342,6 +510,34 @@ func (c *Client) CreateTask(req *userapi.CreateTaskRequest) (*userapi.TaskRespon
 	return &out, nil
 }
 
+// GetUsage calls GET /v1/usage (requires auth). since and until are RFC 3339 and optional
+// (the gateway defaults to the current month); projectID scopes usage and quotas to one project.
+func (c *Client) GetUsage(since, until, projectID string) (*userapi.UsageResponse, error) {
+	q := url.Values{}
+	if since != "" {
+		q.Set("since", since)
+	}
+	if until != "" {
+		q.Set("until", until)
+	}
+	if projectID != "" {
+		q.Set("project_id", projectID)
+	}
+	resp, err := c.doRequest(http.MethodGet, "/v1/usage", q, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+	var out userapi.UsageResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode usage response: %w", err)
+	}
+	return &out, nil
+}
+
 // GetTaskResult calls GET /v1/tasks/{id}/result (requires auth).
 //
 //nolint:dupl // same pattern as CancelTask by design
@@ -423,6 +619,15 @@ func (c *Client) DeleteBytes(path string) ([]byte, error) {
 }
 
 func (c *Client) doRequest(method, path string, query url.Values, body io.Reader) (*http.Response, error) {
+	req, err := c.newRequest(method, path, query, body)
+	if err != nil {
+		return nil, err
+	}
+	return c.HTTPClient.Do(req)
+}
+
+// newRequest builds an authenticated request; bodies default to Content-Type application/json.
+func (c *Client) newRequest(method, path string, query url.Values, body io.Reader) (*http.Request, error) {
 	base, err := url.Parse(c.BaseURL)
 	if err != nil {
 		return nil, fmt.Errorf("invalid base URL: %w", err)
@@ -442,7 +647,7 @@ func (c *Client) doRequest(method, path string, query url.Values, body io.Reader
 	if c.Token != "" {
 		req.Header.Set("Authorization", "Bearer "+c.Token)
 	}
-	return c.HTTPClient.Do(req)
+	return req, nil
 }
 
 func (c *Client) doPostJSON(path string, reqBody any, wantStatus int, out any) error {
@@ -516,7 +721,9 @@ func (c *Client) doGetJSON(path string, out any) error {
 // HTTPError carries the HTTP status for exit-code mapping (401->3, 404->4, etc.).
 type HTTPError struct {
 	Status int
-	Err    error
+	// Type is the problem details type URI when the gateway sent one (e.g. problem.TypePasswordChangeRequired).
+	Type string
+	Err  error
 }
 
 func (e *HTTPError) Error() string {
@@ -540,5 +747,11 @@ func (c *Client) parseError(resp *http.Response) error {
 	} else {
 		msg = resp.Status
 	}
-	return &HTTPError{Status: resp.StatusCode, Err: fmt.Errorf("%s", msg)}
+	return &HTTPError{Status: resp.StatusCode, Type: p.Type, Err: fmt.Errorf("%s", msg)}
+}
+
+// IsPasswordChangeRequired reports whether err is the login error for an account that must set a new password.
+func IsPasswordChangeRequired(err error) bool {
+	var he *HTTPError
+	return errors.As(err, &he) && he.Type == problem.TypePasswordChangeRequired
 }
@@ -1,8 +1,10 @@
 package gateway
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -518,6 +520,59 @@ func TestClient_Chat_Success(t *testing.T) {
 	}
 }
 
+func TestClient_ChatStream(t *testing.T) {
+	body := `data: {"object":"chat.completion.chunk","choices":[{"index":0,"delta":{"role":"assistant"}}]}` + "\n\n" +
+		`data: {"object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"Hel"}}]}` + "\n\n" +
+		`data: {"object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}]}` + "\n\n" +
+		"data: [DONE]\n\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.Header.Get("OpenAI-Project"), "broken") {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte(`data: {"error":{"message":"Completion failed"}}` + "\n\ndata: [DONE]\n\n"))
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+	client := NewClient(server.URL)
+	var deltas []string
+	resp, err := client.ChatStream("hi", "", "", func(d string) { deltas = append(deltas, d) })
+	if err != nil || resp.Response != "Hello" || !resp.Streamed || len(deltas) != 2 {
+		t.Fatalf("ChatStream: %+v %v deltas=%v", resp, err, deltas)
+	}
+	if _, err := client.ChatStream("hi", "", "broken", nil); err == nil || !strings.Contains(err.Error(), "Completion failed") {
+		t.Errorf("error event: %v", err)
+	}
+}
+
+func TestClient_FollowTaskLogs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/tasks/t1/logs" || r.URL.Query().Get("follow") != "true" || r.URL.Query().Get("stream") != "stderr" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(": following task t1\n\n" +
+			"event: log\ndata: {\"job_id\":\"j1\",\"stream\":\"stderr\",\"line\":\"oops\"}\n\n" +
+			"event: end\ndata: {\"task_id\":\"t1\",\"status\":\"failed\"}\n\n"))
+	}))
+	defer server.Close()
+	client := NewClient(server.URL)
+	var lines []userapi.TaskLogEvent
+	end, err := client.FollowTaskLogs("t1", "stderr", func(e userapi.TaskLogEvent) { lines = append(lines, e) })
+	if err != nil || end.Status != "failed" || len(lines) != 1 || lines[0].Line != "oops" {
+		t.Fatalf("FollowTaskLogs: end=%+v err=%v lines=%+v", end, err, lines)
+	}
+
+	truncated := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("event: log\ndata: {\"line\":\"x\"}\n\n"))
+	}))
+	defer truncated.Close()
+	if _, err := NewClient(truncated.URL).FollowTaskLogs("t1", "", nil); err == nil {
+		t.Error("expected error when the stream ends without an end event")
+	}
+}
+
 func TestClient_ChatWithOptions_ModelAndProject(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/v1/chat/completions" || r.Method != http.MethodPost {
@@ -1077,3 +1132,398 @@ func TestClient_NewChatThread_InvalidBaseURL(t *testing.T) {
 		t.Fatal("expected error on invalid base URL")
 	}
 }
+
+func TestClient_GetUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/v1/usage" || q.Get("since") != "2026-01-01T00:00:00Z" || q.Get("project_id") != "p1" || q.Has("until") {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		jsonHandler(http.StatusOK, userapi.UsageResponse{UserID: "u1", Totals: userapi.UsageTotals{TotalTokens: 42}})(w, r)
+	}))
+	defer server.Close()
+	client := NewClient(server.URL)
+	client.SetToken("tok")
+	resp, err := client.GetUsage("2026-01-01T00:00:00Z", "", "p1")
+	if err != nil {
+		t.Fatalf("GetUsage: %v", err)
+	}
+	if resp.Totals.TotalTokens != 42 {
+		t.Errorf("totals = %+v", resp.Totals)
+	}
+
+	errServer := httptest.NewServer(rawHandler(http.StatusTooManyRequests, `{"detail":"quota exceeded"}`))
+	defer errServer.Close()
+	_, err = NewClient(errServer.URL).GetUsage("", "", "")
+	var he *HTTPError
+	if !errors.As(err, &he) || he.Status != http.StatusTooManyRequests {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestClient_Artifacts(t *testing.T) {
+	const content = "hello"
+	const sum = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.EscapedPath() == "/v1/tasks/t1/artifacts/out/my%20file.txt":
+			b, _ := io.ReadAll(r.Body)
+			if string(b) != content || r.Header.Get(userapi.HeaderChecksumSHA256) != sum || r.Header.Get("Content-Type") != "text/plain" {
+				t.Errorf("put body=%q headers=%v", b, r.Header)
+			}
+			jsonHandler(http.StatusCreated, userapi.ArtifactResponse{Path: "out/my file.txt", HasContent: true})(w, r)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/tasks/t1/artifacts/good.txt":
+			w.Header().Set(userapi.HeaderChecksumSHA256, sum)
+			_, _ = w.Write([]byte(content))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/tasks/t1/artifacts/bad.txt":
+			w.Header().Set(userapi.HeaderChecksumSHA256, sum)
+			_, _ = w.Write([]byte("tampered"))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/tasks/t1/artifacts":
+			jsonHandler(http.StatusOK, userapi.ListArtifactsResponse{TaskID: "t1", Artifacts: []userapi.ArtifactResponse{{Path: "good.txt"}}})(w, r)
+		default:
+			rawHandler(http.StatusNotFound, `{"detail":"Artifact not found"}`)(w, r)
+		}
+	}))
+	defer server.Close()
+	client := NewClient(server.URL)
+	client.SetToken("tok")
+
+	art, err := client.PutArtifact("t1", "out/my file.txt", "text/plain", strings.NewReader(content), int64(len(content)), sum)
+	if err != nil || art.Path != "out/my file.txt" {
+		t.Fatalf("PutArtifact: %+v, %v", art, err)
+	}
+	var buf bytes.Buffer
+	if got, err := client.GetArtifact("t1", "good.txt", &buf); err != nil || got != sum || buf.String() != content {
+		t.Errorf("GetArtifact: %q %q %v", got, buf.String(), err)
+	}
+	if _, err := client.GetArtifact("t1", "bad.txt", io.Discard); !errors.Is(err, ErrArtifactChecksum) {
+		t.Errorf("GetArtifact tampered: %v", err)
+	}
+	var he *HTTPError
+	if _, err := client.GetArtifact("t1", "missing.txt", io.Discard); !errors.As(err, &he) || he.Status != http.StatusNotFound {
+		t.Errorf("GetArtifact missing: %v", err)
+	}
+	list, err := client.ListArtifacts("t1")
+	if err != nil || len(list.Artifacts) != 1 {
+		t.Errorf("ListArtifacts: %+v, %v", list, err)
+	}
+}
+
+func TestClient_Credentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/credentials":
+			if r.URL.Query().Get("owner_type") != "user" || r.URL.Query().Has("provider") {
+				t.Errorf("list query %s", r.URL.RawQuery)
+			}
+			jsonHandler(http.StatusOK, userapi.ListCredentialsResponse{Credentials: []userapi.CredentialResponse{{ID: "c1"}}})(w, r)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/credentials":
+			var req userapi.CreateCredentialRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if req.Secret != "s3cret" {
+				t.Errorf("create secret %q", req.Secret)
+			}
+			jsonHandler(http.StatusCreated, userapi.CredentialResponse{ID: "c1", Provider: req.Provider})(w, r)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/credentials/c1/rotate":
+			jsonHandler(http.StatusOK, userapi.CredentialResponse{ID: "c1", IsActive: true})(w, r)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/credentials/c1/disable":
+			jsonHandler(http.StatusOK, userapi.CredentialResponse{ID: "c1"})(w, r)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/credentials/rekey":
+			jsonHandler(http.StatusOK, userapi.RekeyCredentialsResponse{KeyID: "k2", Rewrapped: 3})(w, r)
+		default:
+			rawHandler(http.StatusNotFound, `{"detail":"Credential not found"}`)(w, r)
+		}
+	}))
+	defer server.Close()
+	client := NewClient(server.URL)
+	client.SetToken("tok")
+
+	if list, err := client.ListCredentials(CredentialFilter{OwnerType: "user"}); err != nil || len(list.Credentials) != 1 {
+		t.Errorf("ListCredentials: %+v, %v", list, err)
+	}
+	if c, err := client.CreateCredential(&userapi.CreateCredentialRequest{Provider: "openai", CredentialName: "n", Secret: "s3cret"}); err != nil || c.Provider != "openai" {
+		t.Errorf("CreateCredential: %+v, %v", c, err)
+	}
+	if c, err := client.RotateCredential("c1", &userapi.RotateCredentialRequest{Secret: "x"}); err != nil || !c.IsActive {
+		t.Errorf("RotateCredential: %+v, %v", c, err)
+	}
+	if c, err := client.DisableCredential("c1"); err != nil || c.IsActive {
+		t.Errorf("DisableCredential: %+v, %v", c, err)
+	}
+	if r, err := client.RekeyCredentials(); err != nil || r.Rewrapped != 3 {
+		t.Errorf("RekeyCredentials: %+v, %v", r, err)
+	}
+	var he *HTTPError
+	if _, err := client.GetCredential("nope"); !errors.As(err, &he) || he.Status != http.StatusNotFound {
+		t.Errorf("GetCredential: %v", err)
+	}
+}
+
+func TestClient_AccessTokens(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/users/me/tokens":
+			jsonHandler(http.StatusOK, userapi.ListAccessTokensResponse{Tokens: []userapi.AccessTokenResponse{{ID: "t1"}}})(w, r)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/service-accounts/sa1/tokens":
+			var req userapi.CreateAccessTokenRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			resp := userapi.CreateAccessTokenResponse{Token: "cyn_pat_x"}
+			resp.Scopes = req.Scopes
+			jsonHandler(http.StatusCreated, resp)(w, r)
+		case r.Method == http.MethodDelete && r.URL.Path == "/v1/users/me/tokens/t1":
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/service-accounts":
+			jsonHandler(http.StatusCreated, userapi.UserResponse{ID: "sa1", Handle: "ci-bot", IsServiceAccount: true})(w, r)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/service-accounts":
+			jsonHandler(http.StatusOK, userapi.ListServiceAccountsResponse{ServiceAccounts: []userapi.UserResponse{{ID: "sa1"}}})(w, r)
+		default:
+			rawHandler(http.StatusNotFound, `{"detail":"Access token not found"}`)(w, r)
+		}
+	}))
+	defer server.Close()
+	client := NewClient(server.URL)
+	client.SetToken("tok")
+
+	if list, err := client.ListAccessTokens(""); err != nil || len(list.Tokens) != 1 {
+		t.Errorf("ListAccessTokens: %+v, %v", list, err)
+	}
+	created, err := client.CreateAccessToken("sa1", &userapi.CreateAccessTokenRequest{Name: "ci", Scopes: []string{"chat"}})
+	if err != nil || created.Token != "cyn_pat_x" || len(created.Scopes) != 1 {
+		t.Errorf("CreateAccessToken: %+v, %v", created, err)
+	}
+	if err := client.RevokeAccessToken("", "t1"); err != nil {
+		t.Errorf("RevokeAccessToken: %v", err)
+	}
+	var he *HTTPError
+	if err := client.RevokeAccessToken("sa1", "t1"); !errors.As(err, &he) || he.Status != http.StatusNotFound {
+		t.Errorf("RevokeAccessToken (service account): %v", err)
+	}
+	if sa, err := client.CreateServiceAccount("ci-bot"); err != nil || !sa.IsServiceAccount {
+		t.Errorf("CreateServiceAccount: %+v, %v", sa, err)
+	}
+	if list, err := client.ListServiceAccounts(); err != nil || len(list.ServiceAccounts) != 1 {
+		t.Errorf("ListServiceAccounts: %+v, %v", list, err)
+	}
+}
+
+func TestClient_Sessions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/users/me/sessions":
+			jsonHandler(http.StatusOK, userapi.ListSessionsResponse{Sessions: []userapi.SessionResponse{{ID: "s1", Current: true}}})(w, r)
+		case r.Method == http.MethodDelete && r.URL.Path == "/v1/users/me/sessions/s1":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			rawHandler(http.StatusNotFound, `{"detail":"Session not found"}`)(w, r)
+		}
+	}))
+	defer server.Close()
+	client := NewClient(server.URL)
+	client.SetToken("tok")
+
+	if list, err := client.ListSessions(); err != nil || len(list.Sessions) != 1 || !list.Sessions[0].Current {
+		t.Errorf("ListSessions: %+v, %v", list, err)
+	}
+	if err := client.RevokeSession("s1"); err != nil {
+		t.Errorf("RevokeSession: %v", err)
+	}
+	var he *HTTPError
+	if err := client.RevokeSession("s2"); !errors.As(err, &he) || he.Status != http.StatusNotFound {
+		t.Errorf("RevokeSession (missing): %v", err)
+	}
+}
+
+func TestClient_Policy(t *testing.T) {
+	rule := userapi.PolicyRuleResponse{ID: "r1", SubjectType: "system", Effect: "allow"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/policy/rules":
+			if r.URL.Query().Get("action") != "api.call" || r.URL.Query().Has("subject_id") {
+				t.Errorf("list query %s", r.URL.RawQuery)
+			}
+			jsonHandler(http.StatusOK, userapi.ListPolicyRulesResponse{Rules: []userapi.PolicyRuleResponse{rule}})(w, r)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/policy/rules":
+			jsonHandler(http.StatusCreated, rule)(w, r)
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/policy/rules/r1":
+			var req userapi.PolicyRuleRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			jsonHandler(http.StatusOK, userapi.PolicyRuleResponse{ID: "r1", Effect: req.Effect})(w, r)
+		case r.Method == http.MethodDelete && r.URL.Path == "/v1/policy/rules/r1":
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/policy/evaluate":
+			jsonHandler(http.StatusOK, userapi.PolicyEvaluateResponse{Allowed: true, Rule: &rule, MatchedRules: []userapi.PolicyRuleResponse{rule}})(w, r)
+		default:
+			rawHandler(http.StatusNotFound, `{"detail":"Policy rule not found"}`)(w, r)
+		}
+	}))
+	defer server.Close()
+	client := NewClient(server.URL)
+	client.SetToken("tok")
+
+	if list, err := client.ListPolicyRules(PolicyRuleFilter{Action: "api.call"}); err != nil || len(list.Rules) != 1 {
+		t.Errorf("ListPolicyRules: %+v, %v", list, err)
+	}
+	if r, err := client.CreatePolicyRule(&userapi.PolicyRuleRequest{SubjectType: "system"}); err != nil || r.ID != "r1" {
+		t.Errorf("CreatePolicyRule: %+v, %v", r, err)
+	}
+	if r, err := client.UpdatePolicyRule("r1", &userapi.PolicyRuleRequest{Effect: "deny"}); err != nil || r.Effect != "deny" {
+		t.Errorf("UpdatePolicyRule: %+v, %v", r, err)
+	}
+	if err := client.DeletePolicyRule("r1"); err != nil {
+		t.Errorf("DeletePolicyRule: %v", err)
+	}
+	if d, err := client.EvaluatePolicy(&userapi.PolicyEvaluateRequest{Action: "api.call", Resource: "openai/chat"}); err != nil || !d.Allowed || len(d.MatchedRules) != 1 {
+		t.Errorf("EvaluatePolicy: %+v, %v", d, err)
+	}
+	var he *HTTPError
+	if _, err := client.GetPolicyRule("nope"); !errors.As(err, &he) || he.Status != http.StatusNotFound {
+		t.Errorf("GetPolicyRule: %v", err)
+	}
+}
+
+func TestClient_Audit(t *testing.T) {
+	event := userapi.AuditEventResponse{EventID: "e1", Source: "auth", Action: "auth.login_failure", Decision: "deny"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case r.URL.Path == "/v1/audit" && q.Get("format") == "csv":
+			if q.Has("limit") || q.Get("decision") != "deny" {
+				t.Errorf("export query %s", r.URL.RawQuery)
+			}
+			rawHandler(http.StatusOK, "event_id,ts\ne1,2026-03-01T12:00:00Z\n")(w, r)
+		case r.URL.Path == "/v1/audit":
+			if q.Get("limit") != "10" || q.Get("action") != "auth.*" || q.Has("task_id") {
+				t.Errorf("list query %s", r.URL.RawQuery)
+			}
+			jsonHandler(http.StatusOK, userapi.ListAuditEventsResponse{Events: []userapi.AuditEventResponse{event}, NextCursor: "10"})(w, r)
+		case r.URL.Path == "/v1/audit/e1":
+			jsonHandler(http.StatusOK, event)(w, r)
+		default:
+			rawHandler(http.StatusForbidden, `{"detail":"Forbidden"}`)(w, r)
+		}
+	}))
+	defer server.Close()
+	client := NewClient(server.URL)
+	client.SetToken("tok")
+
+	if list, err := client.ListAuditEvents(AuditEventFilter{Action: "auth.*", Limit: 10}); err != nil || len(list.Events) != 1 || list.NextCursor != "10" {
+		t.Errorf("ListAuditEvents: %+v, %v", list, err)
+	}
+	if e, err := client.GetAuditEvent("e1"); err != nil || e.Action != "auth.login_failure" {
+		t.Errorf("GetAuditEvent: %+v, %v", e, err)
+	}
+	var buf bytes.Buffer
+	if err := client.ExportAuditEvents(AuditEventFilter{Decision: "deny", Limit: 50}, userapi.AuditFormatCSV, &buf); err != nil ||
+		!strings.HasPrefix(buf.String(), "event_id,ts\n") {
+		t.Errorf("ExportAuditEvents: %q, %v", buf.String(), err)
+	}
+	var he *HTTPError
+	if _, err := client.GetAuditEvent("nope"); !errors.As(err, &he) || he.Status != http.StatusForbidden {
+		t.Errorf("GetAuditEvent(forbidden): %v", err)
+	}
+}
+
+func TestClient_EgressLimits(t *testing.T) {
+	limit := userapi.EgressLimitResponse{ID: "l1", Provider: "openai", ScopeType: "system", RequestsPerMinute: 60}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/egress/limits":
+			if r.URL.Query().Get("provider") != "openai" {
+				t.Errorf("list query %s", r.URL.RawQuery)
+			}
+			jsonHandler(http.StatusOK, userapi.ListEgressLimitsResponse{Limits: []userapi.EgressLimitResponse{limit}})(w, r)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/egress/limits/l1":
+			jsonHandler(http.StatusOK, limit)(w, r)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/egress/limits":
+			jsonHandler(http.StatusCreated, limit)(w, r)
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/egress/limits/l1":
+			var req userapi.EgressLimitRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			jsonHandler(http.StatusOK, userapi.EgressLimitResponse{ID: "l1", MaxConcurrent: req.MaxConcurrent})(w, r)
+		case r.Method == http.MethodDelete && r.URL.Path == "/v1/egress/limits/l1":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			rawHandler(http.StatusNotFound, `{"detail":"Egress limit not found"}`)(w, r)
+		}
+	}))
+	defer server.Close()
+	client := NewClient(server.URL)
+	client.SetToken("tok")
+
+	if list, err := client.ListEgressLimits("openai"); err != nil || len(list.Limits) != 1 {
+		t.Errorf("ListEgressLimits: %+v, %v", list, err)
+	}
+	if l, err := client.GetEgressLimit("l1"); err != nil || l.RequestsPerMinute != 60 {
+		t.Errorf("GetEgressLimit: %+v, %v", l, err)
+	}
+	if l, err := client.CreateEgressLimit(&userapi.EgressLimitRequest{Provider: "openai", ScopeType: "system"}); err != nil || l.ID != "l1" {
+		t.Errorf("CreateEgressLimit: %+v, %v", l, err)
+	}
+	if l, err := client.UpdateEgressLimit("l1", &userapi.EgressLimitRequest{MaxConcurrent: 3}); err != nil || l.MaxConcurrent != 3 {
+		t.Errorf("UpdateEgressLimit: %+v, %v", l, err)
+	}
+	if err := client.DeleteEgressLimit("l1"); err != nil {
+		t.Errorf("DeleteEgressLimit: %v", err)
+	}
+	var he *HTTPError
+	if _, err := client.GetEgressLimit("nope"); !errors.As(err, &he) || he.Status != http.StatusNotFound {
+		t.Errorf("GetEgressLimit: %v", err)
+	}
+}
+
+func TestClient_Users(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/users":
+			jsonHandler(http.StatusOK, userapi.ListUsersResponse{Users: []userapi.UserResponse{{ID: "u1"}}})(w, r)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/users":
+			var req userapi.CreateUserRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			jsonHandler(http.StatusCreated, userapi.UserResponse{ID: "u2", Handle: req.Handle, IsActive: true})(w, r)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/users/u1/disable":
+			jsonHandler(http.StatusOK, userapi.UserResponse{ID: "u1"})(w, r)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/users/u1/enable":
+			jsonHandler(http.StatusOK, userapi.UserResponse{ID: "u1", IsActive: true})(w, r)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/users/u1/reset_password",
+			r.Method == http.MethodDelete && r.URL.Path == "/v1/users/u1":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			rawHandler(http.StatusNotFound, `{"detail":"User not found"}`)(w, r)
+		}
+	}))
+	defer server.Close()
+	client := NewClient(server.URL)
+	client.SetToken("tok")
+
+	if list, err := client.ListUsers(); err != nil || len(list.Users) != 1 {
+		t.Errorf("ListUsers: %+v, %v", list, err)
+	}
+	if u, err := client.CreateUser(&userapi.CreateUserRequest{Handle: "bob", Password: "pw"}); err != nil || u.Handle != "bob" {
+		t.Errorf("CreateUser: %+v, %v", u, err)
+	}
+	if u, err := client.DisableUser("u1"); err != nil || u.IsActive {
+		t.Errorf("DisableUser: %+v, %v", u, err)
+	}
+	if u, err := client.EnableUser("u1"); err != nil || !u.IsActive {
+		t.Errorf("EnableUser: %+v, %v", u, err)
+	}
+	if err := client.ResetPassword("u1", &userapi.ResetPasswordRequest{Password: "pw"}); err != nil {
+		t.Errorf("ResetPassword: %v", err)
+	}
+	if err := client.DeleteUser("u1"); err != nil {
+		t.Errorf("DeleteUser: %v", err)
+	}
+	if err := client.DeleteUser("u2"); err == nil || IsPasswordChangeRequired(err) {
+		t.Errorf("DeleteUser missing: %v", err)
+	}
+}
+
+func TestIsPasswordChangeRequired(t *testing.T) {
+	server := httptest.NewServer(rawHandler(http.StatusForbidden,
+		`{"type":"urn:cynodeai:error:password_change_required","status":403,"detail":"Password change required"}`))
+	defer server.Close()
+	_, err := NewClient(server.URL).Login(userapi.LoginRequest{Handle: "u", Password: "p"})
+	if !IsPasswordChangeRequired(err) {
+		t.Errorf("expected password change required, got %v", err)
+	}
+}
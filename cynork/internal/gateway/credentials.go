@@ -0,0 +1,89 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+)
+
+// CredentialFilter narrows ListCredentials; empty fields are not sent.
+type CredentialFilter struct {
+	Provider  string
+	OwnerType string
+	OwnerID   string
+}
+
+// ListCredentials calls GET /v1/credentials (requires auth). Non-admin callers only see their own credentials.
+func (c *Client) ListCredentials(f CredentialFilter) (*userapi.ListCredentialsResponse, error) {
+	q := url.Values{}
+	if f.Provider != "" {
+		q.Set("provider", f.Provider)
+	}
+	if f.OwnerType != "" {
+		q.Set("owner_type", f.OwnerType)
+	}
+	if f.OwnerID != "" {
+		q.Set("owner_id", f.OwnerID)
+	}
+	resp, err := c.doRequest(http.MethodGet, "/v1/credentials", q, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+	var out userapi.ListCredentialsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode credentials response: %w", err)
+	}
+	return &out, nil
+}
+
+// GetCredential calls GET /v1/credentials/{id} (requires auth).
+func (c *Client) GetCredential(id string) (*userapi.CredentialResponse, error) {
+	var out userapi.CredentialResponse
+	if err := c.doGetJSON("/v1/credentials/"+url.PathEscape(id), &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateCredential calls POST /v1/credentials (requires auth). The response carries metadata only.
+func (c *Client) CreateCredential(req *userapi.CreateCredentialRequest) (*userapi.CredentialResponse, error) {
+	var out userapi.CredentialResponse
+	if err := c.doPostJSON("/v1/credentials", req, http.StatusCreated, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RotateCredential calls POST /v1/credentials/{id}/rotate (requires auth).
+func (c *Client) RotateCredential(id string, req *userapi.RotateCredentialRequest) (*userapi.CredentialResponse, error) {
+	var out userapi.CredentialResponse
+	if err := c.doPostJSON("/v1/credentials/"+url.PathEscape(id)+"/rotate", req, http.StatusOK, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DisableCredential calls POST /v1/credentials/{id}/disable (requires auth).
+func (c *Client) DisableCredential(id string) (*userapi.CredentialResponse, error) {
+	var out userapi.CredentialResponse
+	if err := c.doPostJSON("/v1/credentials/"+url.PathEscape(id)+"/disable", struct{}{}, http.StatusOK, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RekeyCredentials calls POST /v1/credentials/rekey (admin only).
+func (c *Client) RekeyCredentials() (*userapi.RekeyCredentialsResponse, error) {
+	var out userapi.RekeyCredentialsResponse
+	if err := c.doPostJSON("/v1/credentials/rekey", struct{}{}, http.StatusOK, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,72 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+)
+
+// ListEgressLimits calls GET /v1/egress/limits, optionally for one provider (requires policy.read).
+func (c *Client) ListEgressLimits(provider string) (*userapi.ListEgressLimitsResponse, error) {
+	q := url.Values{}
+	if provider != "" {
+		q.Set("provider", provider)
+	}
+	resp, err := c.doRequest(http.MethodGet, "/v1/egress/limits", q, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+	var out userapi.ListEgressLimitsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode egress limits response: %w", err)
+	}
+	return &out, nil
+}
+
+// GetEgressLimit calls GET /v1/egress/limits/{id} (requires policy.read).
+func (c *Client) GetEgressLimit(id string) (*userapi.EgressLimitResponse, error) {
+	var out userapi.EgressLimitResponse
+	if err := c.doGetJSON("/v1/egress/limits/"+url.PathEscape(id), &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateEgressLimit calls POST /v1/egress/limits (requires policy.manage).
+func (c *Client) CreateEgressLimit(req *userapi.EgressLimitRequest) (*userapi.EgressLimitResponse, error) {
+	var out userapi.EgressLimitResponse
+	if err := c.doPostJSON("/v1/egress/limits", req, http.StatusCreated, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateEgressLimit calls PUT /v1/egress/limits/{id} (requires policy.manage); req replaces the whole limit.
+func (c *Client) UpdateEgressLimit(id string, req *userapi.EgressLimitRequest) (*userapi.EgressLimitResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	raw, err := c.PutBytes("/v1/egress/limits/"+url.PathEscape(id), body)
+	if err != nil {
+		return nil, err
+	}
+	var out userapi.EgressLimitResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return &out, nil
+}
+
+// DeleteEgressLimit calls DELETE /v1/egress/limits/{id} (requires policy.manage).
+func (c *Client) DeleteEgressLimit(id string) error {
+	_, err := c.DeleteBytes("/v1/egress/limits/" + url.PathEscape(id))
+	return err
+}
@@ -0,0 +1,93 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+)
+
+// PolicyRuleFilter narrows ListPolicyRules; empty fields are not sent.
+type PolicyRuleFilter struct {
+	Action       string
+	ResourceType string
+	SubjectType  string
+	SubjectID    string
+}
+
+// ListPolicyRules calls GET /v1/policy/rules (requires policy.read).
+func (c *Client) ListPolicyRules(f PolicyRuleFilter) (*userapi.ListPolicyRulesResponse, error) {
+	q := url.Values{}
+	for k, v := range map[string]string{
+		"action": f.Action, "resource_type": f.ResourceType, "subject_type": f.SubjectType, "subject_id": f.SubjectID,
+	} {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	resp, err := c.doRequest(http.MethodGet, "/v1/policy/rules", q, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+	var out userapi.ListPolicyRulesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode policy rules response: %w", err)
+	}
+	return &out, nil
+}
+
+// GetPolicyRule calls GET /v1/policy/rules/{id} (requires policy.read).
+func (c *Client) GetPolicyRule(id string) (*userapi.PolicyRuleResponse, error) {
+	var out userapi.PolicyRuleResponse
+	if err := c.doGetJSON("/v1/policy/rules/"+url.PathEscape(id), &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreatePolicyRule calls POST /v1/policy/rules (requires policy.manage).
+func (c *Client) CreatePolicyRule(req *userapi.PolicyRuleRequest) (*userapi.PolicyRuleResponse, error) {
+	var out userapi.PolicyRuleResponse
+	if err := c.doPostJSON("/v1/policy/rules", req, http.StatusCreated, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdatePolicyRule calls PUT /v1/policy/rules/{id} (requires policy.manage); req replaces the whole rule.
+func (c *Client) UpdatePolicyRule(id string, req *userapi.PolicyRuleRequest) (*userapi.PolicyRuleResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	raw, err := c.PutBytes("/v1/policy/rules/"+url.PathEscape(id), body)
+	if err != nil {
+		return nil, err
+	}
+	var out userapi.PolicyRuleResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return &out, nil
+}
+
+// DeletePolicyRule calls DELETE /v1/policy/rules/{id} (requires policy.manage).
+func (c *Client) DeletePolicyRule(id string) error {
+	_, err := c.DeleteBytes("/v1/policy/rules/" + url.PathEscape(id))
+	return err
+}
+
+// EvaluatePolicy calls POST /v1/policy/evaluate (requires policy.read). It is a dry-run: nothing is audited.
+func (c *Client) EvaluatePolicy(req *userapi.PolicyEvaluateRequest) (*userapi.PolicyEvaluateResponse, error) {
+	var out userapi.PolicyEvaluateResponse
+	if err := c.doPostJSON("/v1/policy/evaluate", req, http.StatusOK, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
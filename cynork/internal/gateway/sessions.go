@@ -0,0 +1,25 @@
+package gateway
+
+import (
+	"net/url"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+)
+
+// ClientName identifies cynork in the sessions it signs in, so users can tell them apart in a session listing.
+const ClientName = "cynork"
+
+// ListSessions calls GET /v1/users/me/sessions; the session of the caller's token is marked current.
+func (c *Client) ListSessions() (*userapi.ListSessionsResponse, error) {
+	var out userapi.ListSessionsResponse
+	if err := c.doGetJSON("/v1/users/me/sessions", &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RevokeSession calls DELETE /v1/users/me/sessions/{id}. The session's refresh and access tokens stop working at once.
+func (c *Client) RevokeSession(sessionID string) error {
+	_, err := c.DeleteBytes("/v1/users/me/sessions/" + url.PathEscape(sessionID))
+	return err
+}
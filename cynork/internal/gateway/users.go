@@ -0,0 +1,62 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+)
+
+// ListUsers calls GET /v1/users (requires users.manage).
+func (c *Client) ListUsers() (*userapi.ListUsersResponse, error) {
+	var out userapi.ListUsersResponse
+	if err := c.doGetJSON("/v1/users", &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateUser calls POST /v1/users (requires users.manage).
+func (c *Client) CreateUser(req *userapi.CreateUserRequest) (*userapi.UserResponse, error) {
+	var out userapi.UserResponse
+	if err := c.doPostJSON("/v1/users", req, http.StatusCreated, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DisableUser calls POST /v1/users/{id}/disable (requires users.manage).
+func (c *Client) DisableUser(id string) (*userapi.UserResponse, error) {
+	var out userapi.UserResponse
+	if err := c.doPostJSON("/v1/users/"+url.PathEscape(id)+"/disable", struct{}{}, http.StatusOK, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// EnableUser calls POST /v1/users/{id}/enable (requires users.manage).
+func (c *Client) EnableUser(id string) (*userapi.UserResponse, error) {
+	var out userapi.UserResponse
+	if err := c.doPostJSON("/v1/users/"+url.PathEscape(id)+"/enable", struct{}{}, http.StatusOK, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteUser calls DELETE /v1/users/{id} (requires users.manage).
+func (c *Client) DeleteUser(id string) error {
+	_, err := c.DeleteBytes("/v1/users/" + url.PathEscape(id))
+	return err
+}
+
+// ResetPassword calls POST /v1/users/{id}/reset_password (requires users.manage).
+func (c *Client) ResetPassword(id string, req *userapi.ResetPasswordRequest) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+	_, err = c.PostBytes("/v1/users/"+url.PathEscape(id)+"/reset_password", body)
+	return err
+}
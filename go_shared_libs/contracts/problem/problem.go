@@ -16,8 +16,12 @@ const (
 	TypeAuthentication = "urn:cynodeai:error:authentication"
 	TypeAuthorization  = "urn:cynodeai:error:authorization"
 	TypeNotFound       = "urn:cynodeai:error:not_found"
+	TypeConflict       = "urn:cynodeai:error:conflict"
 	TypeRateLimit      = "urn:cynodeai:error:rate_limit"
+	TypeQuotaExceeded  = "urn:cynodeai:error:quota_exceeded"
 	TypeInternal       = "urn:cynodeai:error:internal"
+	// TypePasswordChangeRequired is returned by login when the account must set a new password first.
+	TypePasswordChangeRequired = "urn:cynodeai:error:password_change_required"
 )
 
 // Validate returns an error if Details has an invalid status (non-HTTP error range).
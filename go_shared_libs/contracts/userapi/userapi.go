@@ -3,6 +3,8 @@
 // See docs/tech_specs/user_api_gateway.md and REQ-CLIENT-0004 (CLI/Web Console parity).
 package userapi
 
+import "encoding/json"
+
 // API-facing task/job status constants (returned in REST responses; used by CLI/Web Console).
 // Canonical spelling is American "canceled".
 const (
@@ -12,14 +14,20 @@ const (
 	StatusFailed     = "failed"
 	StatusCanceled   = "canceled"
 	StatusSuperseded = "superseded"
+	// StatusDeadLettered is a job whose retryable failures exhausted its retry policy (admin job endpoints).
+	StatusDeadLettered = "dead_lettered"
 )
 
 // --- Auth ---
 
-// LoginRequest is the body for POST /v1/auth/login.
+// LoginRequest is the body for POST /v1/auth/login. NewPassword, when set, replaces the password once it is
+// verified; it is required when the account must change its password (403 password_change_required).
+// ClientName labels the new session in GET /v1/users/me/sessions (e.g. "cynork").
 type LoginRequest struct {
-	Handle   string `json:"handle"`
-	Password string `json:"password"`
+	Handle      string `json:"handle"`
+	Password    string `json:"password"`
+	NewPassword string `json:"new_password,omitempty"`
+	ClientName  string `json:"client_name,omitempty"`
 }
 
 // LoginResponse is the body returned by POST /v1/auth/login and POST /v1/auth/refresh.
@@ -35,6 +43,36 @@ type RefreshRequest struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
+// SSODeviceAuthorizationResponse is the body returned by POST /v1/auth/sso/device: the identity provider's
+// device authorization, which the client shows to the user before polling POST /v1/auth/sso/device/token.
+type SSODeviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// SSODeviceTokenRequest is the body for POST /v1/auth/sso/device/token. ClientName is as in LoginRequest.
+type SSODeviceTokenRequest struct {
+	DeviceCode string `json:"device_code"`
+	ClientName string `json:"client_name,omitempty"`
+}
+
+// Statuses in SSODevicePendingResponse.
+const (
+	SSODeviceStatusPending  = "authorization_pending"
+	SSODeviceStatusSlowDown = "slow_down"
+)
+
+// SSODevicePendingResponse is returned with 202 Accepted by POST /v1/auth/sso/device/token while the user has not
+// finished signing in. Clients poll again after the interval from the device authorization, adding 5 seconds
+// to it on slow_down. On success the endpoint returns LoginResponse.
+type SSODevicePendingResponse struct {
+	Status string `json:"status"`
+}
+
 // LogoutRequest is the body for POST /v1/auth/logout.
 type LogoutRequest struct {
 	RefreshToken string `json:"refresh_token"`
@@ -42,10 +80,94 @@ type LogoutRequest struct {
 
 // UserResponse is the body returned by GET /v1/users/me.
 type UserResponse struct {
-	ID       string  `json:"id"`
-	Handle   string  `json:"handle"`
-	Email    *string `json:"email,omitempty"`
-	IsActive bool    `json:"is_active"`
+	ID               string  `json:"id"`
+	Handle           string  `json:"handle"`
+	Email            *string `json:"email,omitempty"`
+	IsActive         bool    `json:"is_active"`
+	IsServiceAccount bool    `json:"is_service_account,omitempty"`
+}
+
+// SessionResponse is one signed-in session (refresh session) of the caller. Current marks the session of the
+// access token making the request; IPAddress and UserAgent are as last seen at login or refresh.
+type SessionResponse struct {
+	ID         string  `json:"id"`
+	ClientName *string `json:"client_name,omitempty"`
+	UserAgent  *string `json:"user_agent,omitempty"`
+	IPAddress  *string `json:"ip_address,omitempty"`
+	Current    bool    `json:"current"`
+	CreatedAt  string  `json:"created_at"`
+	LastUsedAt *string `json:"last_used_at,omitempty"`
+	ExpiresAt  string  `json:"expires_at"`
+}
+
+// ListSessionsResponse is the body returned by GET /v1/users/me/sessions.
+type ListSessionsResponse struct {
+	Sessions []SessionResponse `json:"sessions"`
+}
+
+// --- User administration (users.manage) ---
+
+// CreateUserRequest is the body of POST /v1/users. MustChangePassword forces a password change at first login.
+type CreateUserRequest struct {
+	Handle             string  `json:"handle"`
+	Email              *string `json:"email,omitempty"`
+	Password           string  `json:"password"`
+	MustChangePassword bool    `json:"must_change_password,omitempty"`
+}
+
+// ListUsersResponse is the body of GET /v1/users.
+type ListUsersResponse struct {
+	Users []UserResponse `json:"users"`
+}
+
+// ResetPasswordRequest is the body of POST /v1/users/{id}/reset_password.
+type ResetPasswordRequest struct {
+	Password           string `json:"password"`
+	MustChangePassword bool   `json:"must_change_password,omitempty"`
+}
+
+// --- Personal access tokens and service accounts ---
+
+// AccessTokenResponse is personal access token metadata. The token itself is returned only by create.
+type AccessTokenResponse struct {
+	ID          string   `json:"id"`
+	UserID      string   `json:"user_id"`
+	Name        string   `json:"name"`
+	TokenPrefix string   `json:"token_prefix"`
+	Scopes      []string `json:"scopes"`
+	IsActive    bool     `json:"is_active"`
+	ExpiresAt   *string  `json:"expires_at,omitempty"`
+	LastUsedAt  *string  `json:"last_used_at,omitempty"`
+	CreatedAt   string   `json:"created_at"`
+}
+
+// CreateAccessTokenRequest is the body of POST /v1/users/me/tokens and POST /v1/service-accounts/{id}/tokens.
+// Scopes is required (e.g. tasks:write, chat, read-only); ExpiresAt is RFC 3339 and omitted for no expiry.
+type CreateAccessTokenRequest struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	ExpiresAt *string  `json:"expires_at,omitempty"`
+}
+
+// CreateAccessTokenResponse is returned with 201 by token create: the metadata and the token, shown only once.
+type CreateAccessTokenResponse struct {
+	AccessTokenResponse
+	Token string `json:"token"`
+}
+
+// ListAccessTokensResponse is the body of GET /v1/users/me/tokens and GET /v1/service-accounts/{id}/tokens.
+type ListAccessTokensResponse struct {
+	Tokens []AccessTokenResponse `json:"tokens"`
+}
+
+// CreateServiceAccountRequest is the body of POST /v1/service-accounts.
+type CreateServiceAccountRequest struct {
+	Handle string `json:"handle"`
+}
+
+// ListServiceAccountsResponse is the body of GET /v1/service-accounts.
+type ListServiceAccountsResponse struct {
+	ServiceAccounts []UserResponse `json:"service_accounts"`
 }
 
 // --- Tasks ---
@@ -62,6 +184,9 @@ type CreateTaskRequest struct {
 	TaskName *string `json:"task_name,omitempty"`
 	// Attachments are optional path strings (CLI) or identifiers for file uploads; acceptance path per REQ-ORCHES-0127.
 	Attachments []string `json:"attachments,omitempty"`
+	// PlanID adds the task to a project plan; DependsOn lists task ids in the same plan that must complete first.
+	PlanID    *string  `json:"plan_id,omitempty"`
+	DependsOn []string `json:"depends_on,omitempty"`
 }
 
 // TaskResponse is the task in create/get/list responses (CLI spec: task_id, status, optional task_name).
@@ -93,6 +218,46 @@ type ListTasksResponse struct {
 	NextCursor string         `json:"next_cursor,omitempty"`
 }
 
+// --- Project plans ---
+
+// CreatePlanRequest is the body of POST /v1/projects/{project_id}/plans.
+type CreatePlanRequest struct {
+	PlanName *string `json:"plan_name,omitempty"`
+}
+
+// PlanResponse is a project plan in create responses.
+type PlanResponse struct {
+	PlanID    string  `json:"plan_id"`
+	ProjectID string  `json:"project_id"`
+	PlanName  *string `json:"plan_name,omitempty"`
+	State     string  `json:"state"`
+	Archived  bool    `json:"archived"`
+	CreatedAt string  `json:"created_at"`
+	UpdatedAt string  `json:"updated_at"`
+}
+
+// PlanStatusResponse is the body of GET /v1/plans/{id}/status: the plan's task graph in dependency order.
+// Status is the execution status derived from the tasks (pending, running, completed, failed, canceled);
+// Counts is the number of tasks per task status.
+type PlanStatusResponse struct {
+	PlanResponse
+	Status string         `json:"status"`
+	Counts map[string]int `json:"counts"`
+	Tasks  []PlanTaskNode `json:"tasks"`
+}
+
+// PlanTaskNode is one task in a plan status graph.
+// Runnable is true for a pending task whose dependencies are all completed; BlockedBy lists the ones that are not.
+type PlanTaskNode struct {
+	TaskID     string   `json:"task_id"`
+	TaskName   *string  `json:"task_name,omitempty"`
+	Status     string   `json:"status"`
+	DependsOn  []string `json:"depends_on"`
+	Dependents []string `json:"dependents"`
+	Runnable   bool     `json:"runnable"`
+	BlockedBy  []string `json:"blocked_by,omitempty"`
+}
+
 // CancelTaskResponse is the body of POST /v1/tasks/{id}/cancel.
 type CancelTaskResponse struct {
 	TaskID   string `json:"task_id"`
@@ -106,15 +271,41 @@ type TaskResultResponse struct {
 	Jobs   []JobResponse `json:"jobs"`
 }
 
-// JobResponse is one job in a task result.
+// JobResponse is one job in a task result or job list.
+// TaskID and Attempts are set by the admin job endpoints.
 type JobResponse struct {
 	ID        string  `json:"id"`
+	TaskID    string  `json:"task_id,omitempty"`
 	Status    string  `json:"status"`
+	Attempts  int     `json:"attempts,omitempty"`
 	Result    *string `json:"result,omitempty"`
 	StartedAt *string `json:"started_at,omitempty"`
 	EndedAt   *string `json:"ended_at,omitempty"`
 }
 
+// ListJobsResponse is the body of GET /v1/jobs/dead-lettered.
+type ListJobsResponse struct {
+	Jobs       []JobResponse `json:"jobs"`
+	NextOffset *int          `json:"next_offset,omitempty"`
+}
+
+// JobAttemptResponse is one dispatch attempt of a job.
+type JobAttemptResponse struct {
+	Attempt      int     `json:"attempt"`
+	NodeID       *string `json:"node_id,omitempty"`
+	Status       string  `json:"status"`
+	FailureClass *string `json:"failure_class,omitempty"`
+	Result       *string `json:"result,omitempty"`
+	StartedAt    *string `json:"started_at,omitempty"`
+	EndedAt      string  `json:"ended_at"`
+}
+
+// ListJobAttemptsResponse is the body of GET /v1/jobs/{id}/attempts.
+type ListJobAttemptsResponse struct {
+	JobID    string               `json:"job_id"`
+	Attempts []JobAttemptResponse `json:"attempts"`
+}
+
 // TaskLogsResponse is the body of GET /v1/tasks/{id}/logs.
 type TaskLogsResponse struct {
 	TaskID string `json:"task_id"`
@@ -122,6 +313,394 @@ type TaskLogsResponse struct {
 	Stderr string `json:"stderr"`
 }
 
+// Task log follow (GET /v1/tasks/{id}/logs?follow=true) server-sent event names.
+const (
+	TaskLogEventLog = "log"
+	TaskLogEventEnd = "end"
+)
+
+// TaskLogEvent is the data of one "log" event: a line of a job's container output.
+type TaskLogEvent struct {
+	JobID      string `json:"job_id"`
+	Stream     string `json:"stream"` // stdout or stderr
+	Line       string `json:"line"`
+	OccurredAt string `json:"occurred_at,omitempty"`
+}
+
+// TaskLogEnd is the data of the final "end" event, sent once the task reaches a terminal status.
+type TaskLogEnd struct {
+	TaskID string `json:"task_id"`
+	Status string `json:"status"`
+}
+
+// --- Artifacts ---
+
+// HeaderChecksumSHA256 carries the hex SHA-256 of artifact content: sent by clients on upload to have the
+// gateway verify it, and returned on download so clients can verify what they received.
+const HeaderChecksumSHA256 = "X-Checksum-SHA256"
+
+// ArtifactResponse describes one task artifact. HasContent is false for path-only references
+// (e.g. create-task attachments) that have no content in the artifact store.
+type ArtifactResponse struct {
+	Path           string  `json:"path"`
+	SizeBytes      *int64  `json:"size_bytes,omitempty"`
+	ContentType    *string `json:"content_type,omitempty"`
+	ChecksumSHA256 *string `json:"checksum_sha256,omitempty"`
+	HasContent     bool    `json:"has_content"`
+	CreatedAt      string  `json:"created_at"`
+	UpdatedAt      string  `json:"updated_at"`
+}
+
+// ListArtifactsResponse is the body of GET /v1/tasks/{id}/artifacts.
+type ListArtifactsResponse struct {
+	TaskID    string             `json:"task_id"`
+	Artifacts []ArtifactResponse `json:"artifacts"`
+}
+
+// --- Usage ---
+
+// UsageTotals is a token count summary.
+type UsageTotals struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+}
+
+// ModelUsage is the token usage of one model.
+type ModelUsage struct {
+	Model string `json:"model"`
+	UsageTotals
+}
+
+// QuotaStatus is one configured quota and how much of it is used.
+// Scope is "user" or "project"; Kind is "daily_tokens", "monthly_tokens" or "concurrent_jobs".
+type QuotaStatus struct {
+	Scope    string  `json:"scope"`
+	Kind     string  `json:"kind"`
+	Limit    int64   `json:"limit"`
+	Used     int64   `json:"used"`
+	ResetsAt *string `json:"resets_at,omitempty"`
+}
+
+// UsageResponse is the body of GET /v1/usage.
+type UsageResponse struct {
+	UserID    string        `json:"user_id"`
+	ProjectID *string       `json:"project_id,omitempty"`
+	Since     string        `json:"since"`
+	Until     string        `json:"until"`
+	Totals    UsageTotals   `json:"totals"`
+	ByModel   []ModelUsage  `json:"by_model"`
+	Quotas    []QuotaStatus `json:"quotas"`
+}
+
+// --- API Egress credentials ---
+
+// Credential owner types.
+const (
+	CredentialOwnerUser  = "user"
+	CredentialOwnerGroup = "group"
+)
+
+// CredentialResponse is credential metadata; the secret is never returned.
+type CredentialResponse struct {
+	ID             string  `json:"id"`
+	OwnerType      string  `json:"owner_type"`
+	OwnerID        string  `json:"owner_id"`
+	Provider       string  `json:"provider"`
+	CredentialName string  `json:"credential_name"`
+	CredentialType string  `json:"credential_type"`
+	KeyID          *string `json:"credential_kid,omitempty"`
+	IsActive       bool    `json:"is_active"`
+	ExpiresAt      *string `json:"expires_at,omitempty"`
+	CreatedAt      string  `json:"created_at"`
+	UpdatedAt      string  `json:"updated_at"`
+	UpdatedBy      *string `json:"updated_by,omitempty"`
+}
+
+// ListCredentialsResponse is the body of GET /v1/credentials.
+type ListCredentialsResponse struct {
+	Credentials []CredentialResponse `json:"credentials"`
+}
+
+// CreateCredentialRequest is the body of POST /v1/credentials. OwnerType defaults to user and OwnerID to the
+// caller; Secret is write-only. ExpiresAt is RFC 3339.
+type CreateCredentialRequest struct {
+	Provider       string  `json:"provider"`
+	CredentialName string  `json:"credential_name"`
+	CredentialType string  `json:"credential_type,omitempty"`
+	OwnerType      string  `json:"owner_type,omitempty"`
+	OwnerID        string  `json:"owner_id,omitempty"`
+	Secret         string  `json:"secret"`
+	ExpiresAt      *string `json:"expires_at,omitempty"`
+}
+
+// RotateCredentialRequest is the body of POST /v1/credentials/{id}/rotate.
+// ExpiresAt, when set, replaces the expiry (RFC 3339).
+type RotateCredentialRequest struct {
+	Secret    string  `json:"secret"`
+	ExpiresAt *string `json:"expires_at,omitempty"`
+}
+
+// RekeyCredentialsResponse is the body of POST /v1/credentials/rekey: rows re-wrapped under the current master
+// key, and rows that could not be (e.g. their key id is not configured).
+type RekeyCredentialsResponse struct {
+	KeyID     string `json:"credential_kid"`
+	Rewrapped int    `json:"rewrapped"`
+	Failed    int    `json:"failed"`
+}
+
+// --- API Egress limits ---
+
+// Egress limit scope types.
+const (
+	EgressLimitScopeSystem  = "system"
+	EgressLimitScopeUser    = "user"
+	EgressLimitScopeProject = "project"
+)
+
+// EgressLimitResponse is an api_egress_limits row. Operation "" applies to every operation of the provider; a
+// user or project limit without ScopeID applies to each user or project separately. Zero limits are unlimited.
+type EgressLimitResponse struct {
+	ID                string  `json:"id"`
+	Provider          string  `json:"provider"`
+	Operation         string  `json:"operation,omitempty"`
+	ScopeType         string  `json:"scope_type"`
+	ScopeID           *string `json:"scope_id,omitempty"`
+	RequestsPerMinute int     `json:"requests_per_minute"`
+	MaxConcurrent     int     `json:"max_concurrent"`
+	MonthlyBudgetUSD  float64 `json:"monthly_budget_usd"`
+	CostPerCallUSD    float64 `json:"cost_per_call_usd"`
+	CreatedAt         string  `json:"created_at"`
+	UpdatedAt         string  `json:"updated_at"`
+	UpdatedBy         *string `json:"updated_by,omitempty"`
+}
+
+// ListEgressLimitsResponse is the body of GET /v1/egress/limits.
+type ListEgressLimitsResponse struct {
+	Limits []EgressLimitResponse `json:"limits"`
+}
+
+// EgressLimitRequest is the body of POST /v1/egress/limits and PUT /v1/egress/limits/{id} (which replaces the
+// whole limit). ScopeID must be empty for system limits. At least one limit must be set.
+type EgressLimitRequest struct {
+	Provider          string  `json:"provider"`
+	Operation         string  `json:"operation,omitempty"`
+	ScopeType         string  `json:"scope_type"`
+	ScopeID           *string `json:"scope_id,omitempty"`
+	RequestsPerMinute int     `json:"requests_per_minute,omitempty"`
+	MaxConcurrent     int     `json:"max_concurrent,omitempty"`
+	MonthlyBudgetUSD  float64 `json:"monthly_budget_usd,omitempty"`
+	CostPerCallUSD    float64 `json:"cost_per_call_usd,omitempty"`
+}
+
+// --- Groups and RBAC ---
+
+// Role binding subject and scope types.
+const (
+	RoleBindingSubjectUser  = "user"
+	RoleBindingSubjectGroup = "group"
+	RoleBindingScopeSystem  = "system"
+	RoleBindingScopeProject = "project"
+)
+
+// GroupResponse is a group.
+type GroupResponse struct {
+	ID          string  `json:"id"`
+	Slug        string  `json:"slug"`
+	DisplayName string  `json:"display_name"`
+	IsActive    bool    `json:"is_active"`
+	ManagedBy   *string `json:"managed_by,omitempty"`
+	CreatedAt   string  `json:"created_at"`
+	UpdatedAt   string  `json:"updated_at"`
+}
+
+// ListGroupsResponse is the body of GET /v1/groups.
+type ListGroupsResponse struct {
+	Groups []GroupResponse `json:"groups"`
+}
+
+// CreateGroupRequest is the body of POST /v1/groups. DisplayName defaults to Slug.
+type CreateGroupRequest struct {
+	Slug        string `json:"slug"`
+	DisplayName string `json:"display_name,omitempty"`
+}
+
+// GroupMemberResponse is one active group membership.
+type GroupMemberResponse struct {
+	UserID    string `json:"user_id"`
+	Handle    string `json:"handle,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+// ListGroupMembersResponse is the body of GET /v1/groups/{id}/members.
+type ListGroupMembersResponse struct {
+	Members []GroupMemberResponse `json:"members"`
+}
+
+// AddGroupMemberRequest is the body of POST /v1/groups/{id}/members; set exactly one of UserID or Handle.
+type AddGroupMemberRequest struct {
+	UserID string `json:"user_id,omitempty"`
+	Handle string `json:"handle,omitempty"`
+}
+
+// RoleResponse is a role and the permissions it grants.
+type RoleResponse struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description *string  `json:"description,omitempty"`
+	Permissions []string `json:"permissions"`
+}
+
+// ListRolesResponse is the body of GET /v1/roles.
+type ListRolesResponse struct {
+	Roles []RoleResponse `json:"roles"`
+}
+
+// RoleBindingResponse is an active role binding.
+type RoleBindingResponse struct {
+	ID          string  `json:"id"`
+	SubjectType string  `json:"subject_type"`
+	SubjectID   string  `json:"subject_id"`
+	RoleID      string  `json:"role_id"`
+	Role        string  `json:"role"`
+	ScopeType   string  `json:"scope_type"`
+	ScopeID     *string `json:"scope_id,omitempty"`
+	CreatedAt   string  `json:"created_at"`
+	UpdatedBy   *string `json:"updated_by,omitempty"`
+}
+
+// ListRoleBindingsResponse is the body of GET /v1/role-bindings.
+type ListRoleBindingsResponse struct {
+	RoleBindings []RoleBindingResponse `json:"role_bindings"`
+}
+
+// CreateRoleBindingRequest is the body of POST /v1/role-bindings. Role is a role name; ScopeType defaults to
+// system, and ScopeID (a project id) is required for project scope.
+type CreateRoleBindingRequest struct {
+	SubjectType string  `json:"subject_type"`
+	SubjectID   string  `json:"subject_id"`
+	Role        string  `json:"role"`
+	ScopeType   string  `json:"scope_type,omitempty"`
+	ScopeID     *string `json:"scope_id,omitempty"`
+}
+
+// --- Access control policy ---
+
+// Policy rule subject types and effects.
+const (
+	PolicySubjectSystem = "system"
+	PolicySubjectUser   = "user"
+	PolicySubjectGroup  = "group"
+	PolicySubjectRole   = "role"
+	PolicyEffectAllow   = "allow"
+	PolicyEffectDeny    = "deny"
+)
+
+// PolicyRuleResponse is an access_control_rules row.
+type PolicyRuleResponse struct {
+	ID              string          `json:"id"`
+	SubjectType     string          `json:"subject_type"`
+	SubjectID       *string         `json:"subject_id,omitempty"`
+	Action          string          `json:"action"`
+	ResourceType    string          `json:"resource_type"`
+	ResourcePattern string          `json:"resource_pattern"`
+	Effect          string          `json:"effect"`
+	Priority        int             `json:"priority"`
+	Conditions      json.RawMessage `json:"conditions,omitempty"`
+	CreatedAt       string          `json:"created_at"`
+	UpdatedAt       string          `json:"updated_at"`
+	UpdatedBy       *string         `json:"updated_by,omitempty"`
+}
+
+// ListPolicyRulesResponse is the body of GET /v1/policy/rules.
+type ListPolicyRulesResponse struct {
+	Rules []PolicyRuleResponse `json:"rules"`
+}
+
+// PolicyRuleRequest is the body of POST /v1/policy/rules and PUT /v1/policy/rules/{id} (which replaces the whole
+// rule). SubjectID must be empty for system rules and set otherwise; Conditions is a JSON object.
+type PolicyRuleRequest struct {
+	SubjectType     string          `json:"subject_type"`
+	SubjectID       *string         `json:"subject_id,omitempty"`
+	Action          string          `json:"action"`
+	ResourceType    string          `json:"resource_type"`
+	ResourcePattern string          `json:"resource_pattern"`
+	Effect          string          `json:"effect"`
+	Priority        int             `json:"priority"`
+	Conditions      json.RawMessage `json:"conditions,omitempty"`
+}
+
+// PolicyEvaluateRequest is the body of POST /v1/policy/evaluate. SubjectType defaults to the caller (user). A user
+// subject is named by SubjectID or Handle and evaluated with its groups and roles; group and role subjects are
+// named by SubjectID (a role may also be named by Role). ResourceType defaults from Action for built-in actions.
+type PolicyEvaluateRequest struct {
+	SubjectType  string                 `json:"subject_type,omitempty"`
+	SubjectID    string                 `json:"subject_id,omitempty"`
+	Handle       string                 `json:"handle,omitempty"`
+	Role         string                 `json:"role,omitempty"`
+	Action       string                 `json:"action"`
+	ResourceType string                 `json:"resource_type,omitempty"`
+	Resource     string                 `json:"resource"`
+	Context      *PolicyEvaluateContext `json:"context,omitempty"`
+}
+
+// PolicyEvaluateContext is the request metadata checked by rule conditions. Time is RFC 3339 and defaults to now.
+type PolicyEvaluateContext struct {
+	ProjectID    *string `json:"project_id,omitempty"`
+	TaskType     string  `json:"task_type,omitempty"`
+	RequestBytes int64   `json:"request_bytes,omitempty"`
+	Time         *string `json:"time,omitempty"`
+}
+
+// PolicyPrincipal is who a dry-run was evaluated as.
+type PolicyPrincipal struct {
+	UserID    *string  `json:"user_id,omitempty"`
+	GroupIDs  []string `json:"group_ids"`
+	RoleNames []string `json:"role_names"`
+}
+
+// PolicyEvaluateResponse is the body of POST /v1/policy/evaluate. Rule is the deciding rule (absent on default
+// deny); MatchedRules lists every rule that matched, highest priority first.
+type PolicyEvaluateResponse struct {
+	Allowed      bool                 `json:"allowed"`
+	Reason       string               `json:"reason,omitempty"`
+	ResourceType string               `json:"resource_type"`
+	Rule         *PolicyRuleResponse  `json:"rule,omitempty"`
+	MatchedRules []PolicyRuleResponse `json:"matched_rules"`
+	Principal    PolicyPrincipal      `json:"principal"`
+}
+
+// --- Audit ---
+
+// Audit export formats for GET /v1/audit?format=.
+const (
+	AuditFormatJSONL = "jsonl"
+	AuditFormatCSV   = "csv"
+)
+
+// AuditEventResponse is one event of the unified audit log. Source names the audit table it came from
+// (auth, mcp_tool_call, access_control, chat, preference); Decision is allow or deny.
+type AuditEventResponse struct {
+	EventID      string  `json:"event_id"`
+	Timestamp    string  `json:"ts"`
+	Source       string  `json:"source"`
+	ActorID      *string `json:"actor_id,omitempty"`
+	ActorHandle  *string `json:"actor_handle,omitempty"`
+	TaskID       *string `json:"task_id,omitempty"`
+	ProjectID    *string `json:"project_id,omitempty"`
+	Action       string  `json:"action"`
+	ResourceType string  `json:"resource_type"`
+	ResourceID   string  `json:"resource_id"`
+	Decision     string  `json:"decision"`
+	Details      *string `json:"details,omitempty"`
+}
+
+// ListAuditEventsResponse is the body of GET /v1/audit, newest first. NextCursor is empty on the last page.
+type ListAuditEventsResponse struct {
+	Events     []AuditEventResponse `json:"events"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
+
 // --- Chat (OpenAI-compatible) ---
 
 // ChatMessage is one message in the OpenAI messages array.
@@ -134,6 +713,8 @@ type ChatMessage struct {
 type ChatCompletionsRequest struct {
 	Model    string        `json:"model,omitempty"`
 	Messages []ChatMessage `json:"messages"`
+	// Stream requests server-sent events: chat.completion.chunk objects followed by data: [DONE].
+	Stream bool `json:"stream,omitempty"`
 }
 
 // ChatCompletionsChoice is one choice in the chat completions response.
@@ -154,3 +735,28 @@ type ChatCompletionsResponse struct {
 	Model   string                  `json:"model"`
 	Choices []ChatCompletionsChoice `json:"choices"`
 }
+
+// ChatCompletionsStreamDone is the data payload of the final server-sent event of a streamed completion.
+const ChatCompletionsStreamDone = "[DONE]"
+
+// ChatCompletionsDelta is the incremental message content of a streamed chunk.
+type ChatCompletionsDelta struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+// ChatCompletionsChunkChoice is one choice in a streamed chunk; FinishReason is set on the last content chunk.
+type ChatCompletionsChunkChoice struct {
+	Index        int                  `json:"index"`
+	Delta        ChatCompletionsDelta `json:"delta"`
+	FinishReason *string              `json:"finish_reason"`
+}
+
+// ChatCompletionsChunk is one server-sent event of a streamed POST /v1/chat/completions (object chat.completion.chunk).
+type ChatCompletionsChunk struct {
+	ID      string                       `json:"id"`
+	Object  string                       `json:"object"`
+	Created int64                        `json:"created"`
+	Model   string                       `json:"model"`
+	Choices []ChatCompletionsChunkChoice `json:"choices"`
+}
@@ -8,12 +8,25 @@ import (
 )
 
 // Job status constants.
+// Accepted and InProgress are non-terminal states reported by the async job endpoints.
 const (
-	StatusCompleted = "completed"
-	StatusFailed    = "failed"
-	StatusTimeout   = "timeout"
+	StatusAccepted   = "accepted"
+	StatusInProgress = "in_progress"
+	StatusCompleted  = "completed"
+	StatusFailed     = "failed"
+	StatusTimeout    = "timeout"
+	StatusCanceled   = "canceled"
 )
 
+// IsTerminalStatus reports whether status is a final job state.
+func IsTerminalStatus(status string) bool {
+	switch status {
+	case StatusCompleted, StatusFailed, StatusTimeout, StatusCanceled:
+		return true
+	}
+	return false
+}
+
 // DefaultImage is used when no image is specified.
 const DefaultImage = "alpine:latest"
 
@@ -24,8 +37,71 @@ type RunJobRequest struct {
 	TaskID  string      `json:"task_id"`
 	JobID   string      `json:"job_id"`
 	Sandbox SandboxSpec `json:"sandbox"`
+	// Lease is set for async submissions; the node heartbeats to HeartbeatURL while the job runs.
+	Lease *LeaseSpec `json:"lease,omitempty"`
+	// Artifacts is set when the orchestrator accepts job outputs; the node uploads them after the run.
+	Artifacts *ArtifactUploadSpec `json:"artifacts,omitempty"`
 }
 
+// ArtifactUploadSpec tells the node where to upload job output files. Each file is sent as
+// PUT {UploadURL}/{path} with the Worker API bearer token and an X-Checksum-SHA256 header.
+// See docs/tech_specs/worker_api.md#job-artifacts.
+type ArtifactUploadSpec struct {
+	UploadURL string `json:"upload_url"`
+}
+
+// HeaderChecksumSHA256 carries the hex SHA-256 of an uploaded artifact (same header as the User API Gateway).
+const HeaderChecksumSHA256 = "X-Checksum-SHA256"
+
+// LeaseSpec tells the node how to keep the orchestrator's job lease alive.
+// See docs/tech_specs/worker_api.md#run-job-asynchronous.
+type LeaseSpec struct {
+	LeaseID                  string `json:"lease_id"`
+	HeartbeatURL             string `json:"heartbeat_url"`
+	HeartbeatIntervalSeconds int    `json:"heartbeat_interval_seconds"`
+}
+
+// SubmitJobResponse is the 202 body for POST /v1/worker/jobs:submit (the job handle).
+type SubmitJobResponse struct {
+	Version   int    `json:"version"`
+	TaskID    string `json:"task_id"`
+	JobID     string `json:"job_id"`
+	Status    string `json:"status"`
+	StatusURL string `json:"status_url"`
+	ResultURL string `json:"result_url"`
+}
+
+// JobStatusResponse is the body for GET /v1/worker/jobs/{job_id}.
+type JobStatusResponse struct {
+	Version   int    `json:"version"`
+	TaskID    string `json:"task_id"`
+	JobID     string `json:"job_id"`
+	Status    string `json:"status"`
+	StartedAt string `json:"started_at,omitempty"`
+	EndedAt   string `json:"ended_at,omitempty"`
+}
+
+// HeartbeatRequest is sent by the node to the orchestrator while an async job runs.
+type HeartbeatRequest struct {
+	Version int    `json:"version"`
+	JobID   string `json:"job_id"`
+	LeaseID string `json:"lease_id"`
+	Status  string `json:"status"`
+}
+
+// HeartbeatResponse returns the extended lease expiry.
+type HeartbeatResponse struct {
+	LeaseExpiresAt string `json:"lease_expires_at"`
+}
+
+// Sandbox network policies. None and restricted deny all network access; egress-proxy allows outbound
+// HTTP(S) only through the Web Egress Proxy (web_egress_proxy.md). Unknown values are treated as none.
+const (
+	NetworkPolicyNone        = "none"
+	NetworkPolicyRestricted  = "restricted"
+	NetworkPolicyEgressProxy = "egress-proxy"
+)
+
 // SandboxSpec defines sandbox execution parameters.
 type SandboxSpec struct {
 	Image          string            `json:"image"`
@@ -33,6 +109,9 @@ type SandboxSpec struct {
 	Env            map[string]string `json:"env,omitempty"`
 	TimeoutSeconds int               `json:"timeout_seconds,omitempty"`
 	NetworkPolicy  string            `json:"network_policy,omitempty"`
+	// EgressProxyToken is the job's credential for the Web Egress Proxy, set by the orchestrator when
+	// NetworkPolicy is NetworkPolicyEgressProxy. It is valid only while the job runs.
+	EgressProxyToken string `json:"egress_proxy_token,omitempty"`
 	// UseInference when true asks the node to run the job in a pod with an inference proxy
 	// so the sandbox can call http://localhost:11434 for node-local Ollama (worker_node.md Option A).
 	UseInference bool `json:"use_inference,omitempty"`
@@ -77,6 +156,15 @@ type RunJobResponse struct {
 	Artifacts          []json.RawMessage `json:"artifacts,omitempty"`
 	// RunDiagnostics is set for container runs (e.g. SBA) to aid troubleshooting when a job fails.
 	RunDiagnostics *RunDiagnostics `json:"run_diagnostics,omitempty"`
+	// Usage is the model token usage recorded by the job's inference proxy (pod-inference jobs only).
+	Usage []TokenUsage `json:"usage,omitempty"`
+}
+
+// TokenUsage is the token consumption of one model, as counted by the worker inference proxy.
+type TokenUsage struct {
+	Model            string `json:"model"`
+	PromptTokens     int64  `json:"prompt_tokens"`
+	CompletionTokens int64  `json:"completion_tokens"`
 }
 
 // TruncatedInfo indicates if output was truncated.
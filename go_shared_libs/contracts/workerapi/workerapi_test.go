@@ -66,3 +66,35 @@ func TestRunJobRequestResponseJSON(t *testing.T) {
 		t.Errorf("round-trip mismatch: got %+v", decoded)
 	}
 }
+
+func TestIsTerminalStatus(t *testing.T) {
+	for _, s := range []string{StatusCompleted, StatusFailed, StatusTimeout, StatusCanceled} {
+		if !IsTerminalStatus(s) {
+			t.Errorf("IsTerminalStatus(%q) = false", s)
+		}
+	}
+	for _, s := range []string{StatusAccepted, StatusInProgress, ""} {
+		if IsTerminalStatus(s) {
+			t.Errorf("IsTerminalStatus(%q) = true", s)
+		}
+	}
+}
+
+func TestRunJobRequestLeaseJSON(t *testing.T) {
+	req := RunJobRequest{
+		Version: 1, TaskID: "t", JobID: "j",
+		Sandbox: SandboxSpec{Command: []string{"true"}},
+		Lease:   &LeaseSpec{LeaseID: "l", HeartbeatURL: "http://cp/v1/jobs/j/heartbeat", HeartbeatIntervalSeconds: 20},
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var decoded RunJobRequest
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if decoded.Lease == nil || *decoded.Lease != *req.Lease {
+		t.Errorf("lease round-trip: got %+v", decoded.Lease)
+	}
+}
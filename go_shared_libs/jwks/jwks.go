@@ -0,0 +1,149 @@
+// Package jwks encodes and decodes JSON Web Keys (RFC 7517), caches a remote key set by key id, and verifies
+// CyNodeAI tokens signed with EdDSA (Ed25519) or ES256 using only public keys, so services that check tokens
+// never hold signing material. It has no dependencies outside the standard library so worker nodes can use it.
+package jwks
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// JWK is one JSON Web Key; only the members needed for RSA, EC, and Ed25519 signature keys.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// Set is a JWK Set document, as served at /.well-known/jwks.json.
+type Set struct {
+	Keys []JWK `json:"keys"`
+}
+
+var curves = map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+
+// ecAlgs maps an EC curve to its JWS algorithm (RFC 7518 section 3.4).
+var ecAlgs = map[string]string{"P-256": "ES256", "P-384": "ES384", "P-521": "ES512"}
+
+// FromPublicKey returns the signature JWK for an Ed25519 or ECDSA public key, with alg set and kid set to
+// its RFC 7638 thumbprint, so every service that loads the same key derives the same kid.
+func FromPublicKey(pub crypto.PublicKey) (JWK, error) {
+	var j JWK
+	switch k := pub.(type) {
+	case ed25519.PublicKey:
+		j = JWK{Kty: "OKP", Crv: "Ed25519", Alg: "EdDSA", X: base64.RawURLEncoding.EncodeToString(k)}
+	case *ecdsa.PublicKey:
+		point, err := k.Bytes()
+		if err != nil {
+			return JWK{}, err
+		}
+		crv := k.Curve.Params().Name
+		if ecAlgs[crv] == "" {
+			return JWK{}, fmt.Errorf("jwk: unsupported curve %q", crv)
+		}
+		size := (len(point) - 1) / 2
+		j = JWK{
+			Kty: "EC", Crv: crv, Alg: ecAlgs[crv],
+			X: base64.RawURLEncoding.EncodeToString(point[1 : 1+size]),
+			Y: base64.RawURLEncoding.EncodeToString(point[1+size:]),
+		}
+	default:
+		return JWK{}, fmt.Errorf("jwk: unsupported public key type %T", pub)
+	}
+	j.Use = "sig"
+	kid, err := j.Thumbprint()
+	if err != nil {
+		return JWK{}, err
+	}
+	j.Kid = kid
+	return j, nil
+}
+
+// Thumbprint returns the base64url SHA-256 JWK thumbprint (RFC 7638).
+func (j *JWK) Thumbprint() (string, error) {
+	// The required members, in lexicographic order; json.Marshal sorts map keys.
+	var members map[string]string
+	switch j.Kty {
+	case "OKP":
+		members = map[string]string{"crv": j.Crv, "kty": j.Kty, "x": j.X}
+	case "EC":
+		members = map[string]string{"crv": j.Crv, "kty": j.Kty, "x": j.X, "y": j.Y}
+	case "RSA":
+		members = map[string]string{"e": j.E, "kty": j.Kty, "n": j.N}
+	default:
+		return "", fmt.Errorf("jwk: unsupported key type %q", j.Kty)
+	}
+	b, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// PublicKey decodes an RSA, EC (P-256, P-384, P-521), or Ed25519 public key.
+func (j *JWK) PublicKey() (crypto.PublicKey, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := decodeBigInt(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(j.E)
+		if err != nil || !e.IsInt64() {
+			return nil, errors.New("jwk: bad RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		return j.ecdsaKey()
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if j.Crv != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("jwk: bad Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("jwk: unsupported key type %q", j.Kty)
+}
+
+func (j *JWK) ecdsaKey() (*ecdsa.PublicKey, error) {
+	curve, ok := curves[j.Crv]
+	if !ok {
+		return nil, fmt.Errorf("jwk: unsupported curve %q", j.Crv)
+	}
+	size := (curve.Params().BitSize + 7) / 8
+	x, errX := base64.RawURLEncoding.DecodeString(j.X)
+	y, errY := base64.RawURLEncoding.DecodeString(j.Y)
+	if errX != nil || errY != nil || len(x) != size || len(y) != size {
+		return nil, errors.New("jwk: bad EC point")
+	}
+	point := append(append([]byte{4}, x...), y...)
+	key, err := ecdsa.ParseUncompressedPublicKey(curve, point)
+	if err != nil {
+		return nil, fmt.Errorf("jwk: bad EC point: %w", err)
+	}
+	return key, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("jwk: bad base64url integer")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,206 @@
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testIssuer serves a JWKS for the public halves of its keys and signs tokens with them.
+type testIssuer struct {
+	mu   sync.Mutex
+	keys map[string]crypto.Signer
+	srv  *httptest.Server
+}
+
+func newTestIssuer(t *testing.T, signers ...crypto.Signer) *testIssuer {
+	t.Helper()
+	iss := &testIssuer{keys: map[string]crypto.Signer{}}
+	for _, s := range signers {
+		iss.add(t, s)
+	}
+	iss.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		iss.mu.Lock()
+		defer iss.mu.Unlock()
+		var set Set
+		for _, s := range iss.keys {
+			j, _ := FromPublicKey(s.Public())
+			set.Keys = append(set.Keys, j)
+		}
+		_ = json.NewEncoder(w).Encode(set)
+	}))
+	t.Cleanup(iss.srv.Close)
+	return iss
+}
+
+func (i *testIssuer) add(t *testing.T, s crypto.Signer) string {
+	t.Helper()
+	j, err := FromPublicKey(s.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.keys[j.Kid] = s
+	return j.Kid
+}
+
+func (i *testIssuer) sign(t *testing.T, kid, alg string, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	i.mu.Lock()
+	s := i.keys[kid]
+	i.mu.Unlock()
+	var sig []byte
+	switch k := s.(type) {
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(k, []byte(input))
+	case *ecdsa.PrivateKey:
+		sum := sha256.Sum256([]byte(input))
+		r, ss, err := ecdsa.Sign(rand.Reader, k, sum[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = append(r.FillBytes(make([]byte, 32)), ss.FillBytes(make([]byte, 32))...)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestThumbprint_RFC7638Example(t *testing.T) {
+	j := JWK{
+		Kty: "RSA", E: "AQAB",
+		N: "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMs" +
+			"tn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n" +
+			"91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+	}
+	kid, err := j.Thumbprint()
+	if err != nil || kid != "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs" {
+		t.Errorf("thumbprint = %q, %v", kid, err)
+	}
+}
+
+func TestFromPublicKey_RoundTrip(t *testing.T) {
+	_, ed, _ := ed25519.GenerateKey(rand.Reader)
+	ec, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	for _, pub := range []crypto.PublicKey{ed.Public(), ec.Public()} {
+		j, err := FromPublicKey(pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if j.Use != "sig" || j.Kid == "" || (j.Alg != "EdDSA" && j.Alg != "ES256") {
+			t.Errorf("jwk = %+v", j)
+		}
+		back, err := j.PublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !back.(interface{ Equal(crypto.PublicKey) bool }).Equal(pub) {
+			t.Errorf("%s key did not round-trip", j.Kty)
+		}
+	}
+	if _, err := FromPublicKey("not a key"); err == nil {
+		t.Error("FromPublicKey accepted a string")
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	_, ed, _ := ed25519.GenerateKey(rand.Reader)
+	ec, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	iss := newTestIssuer(t, ed, ec)
+	edKid, _ := FromPublicKey(ed.Public())
+	ecKid, _ := FromPublicKey(ec.Public())
+	v := &Verifier{Keys: NewRemoteKeySet(iss.srv.URL, nil), Issuer: "cynodeai", Audience: "node-1"}
+	now := time.Now().Unix()
+	good := map[string]any{"iss": "cynodeai", "aud": []string{"node-1"}, "exp": now + 60, "sub": "u1"}
+	with := func(k string, val any) map[string]any {
+		c := map[string]any{}
+		for key, v := range good {
+			c[key] = v
+		}
+		if val == nil {
+			delete(c, k)
+		} else {
+			c[k] = val
+		}
+		return c
+	}
+	for _, tc := range []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"eddsa", iss.sign(t, edKid.Kid, "EdDSA", good), true},
+		{"es256", iss.sign(t, ecKid.Kid, "ES256", with("aud", "node-1")), true},
+		{"expired", iss.sign(t, edKid.Kid, "EdDSA", with("exp", now-120)), false},
+		{"no exp", iss.sign(t, edKid.Kid, "EdDSA", with("exp", nil)), false},
+		{"not yet valid", iss.sign(t, edKid.Kid, "EdDSA", with("nbf", now+120)), false},
+		{"wrong issuer", iss.sign(t, edKid.Kid, "EdDSA", with("iss", "other")), false},
+		{"wrong audience", iss.sign(t, edKid.Kid, "EdDSA", with("aud", "node-2")), false},
+		{"alg does not match key", iss.sign(t, ecKid.Kid, "EdDSA", good), false},
+		{"hmac alg", iss.sign(t, edKid.Kid, "HS256", good), false},
+		{"unknown kid", iss.sign(t, "missing", "EdDSA", good), false},
+		{"malformed", "a.b", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var claims struct {
+				Sub string `json:"sub"`
+			}
+			err := v.Verify(context.Background(), tc.token, &claims)
+			if tc.ok != (err == nil) {
+				t.Fatalf("Verify err = %v, want ok=%v", err, tc.ok)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("err = %v, want ErrInvalidToken", err)
+			}
+			if tc.ok && claims.Sub != "u1" {
+				t.Errorf("sub = %q", claims.Sub)
+			}
+		})
+	}
+	parts := strings.Split(iss.sign(t, edKid.Kid, "EdDSA", good), ".")
+	parts[1] = base64.RawURLEncoding.EncodeToString([]byte(`{"iss":"cynodeai","aud":"node-1","exp":9999999999,"sub":"admin"}`))
+	if err := v.Verify(context.Background(), strings.Join(parts, "."), &struct{}{}); err == nil {
+		t.Error("tampered payload accepted")
+	}
+}
+
+func TestKeySet_PicksUpRotatedKeys(t *testing.T) {
+	_, oldKey, _ := ed25519.GenerateKey(rand.Reader)
+	iss := newTestIssuer(t, oldKey)
+	keys := NewRemoteKeySet(iss.srv.URL, nil)
+	v := &Verifier{Keys: keys}
+	exp := map[string]any{"exp": time.Now().Add(time.Minute).Unix()}
+	oldJWK, _ := FromPublicKey(oldKey.Public())
+	if err := v.Verify(context.Background(), iss.sign(t, oldJWK.Kid, "EdDSA", exp), &struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, newKey, _ := ed25519.GenerateKey(rand.Reader)
+	newKid := iss.add(t, newKey)
+	rotated := iss.sign(t, newKid, "EdDSA", exp)
+	if err := v.Verify(context.Background(), rotated, &struct{}{}); err == nil {
+		t.Fatal("unknown kid accepted before the refresh interval elapsed")
+	}
+	keys.MinRefresh = 0
+	if err := v.Verify(context.Background(), rotated, &struct{}{}); err != nil {
+		t.Fatalf("rotated key: %v", err)
+	}
+	if err := v.Verify(context.Background(), iss.sign(t, oldJWK.Kid, "EdDSA", exp), &struct{}{}); err != nil {
+		t.Errorf("previous key during overlap: %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultMinRefresh bounds how often an unknown kid makes a KeySet refetch its JWKS, so tokens with made-up
+// key ids cannot turn a verifier into a request amplifier against the issuer.
+const DefaultMinRefresh = 30 * time.Second
+
+// maxSetBytes bounds a fetched JWKS document.
+const maxSetBytes = 1 << 20
+
+// FetchFunc GETs uri and decodes the JSON body into out.
+type FetchFunc func(ctx context.Context, uri string, out any) error
+
+// KeySet caches an issuer's signing keys by kid and refetches them when a token names a key it has not seen,
+// which is how key rotation is picked up: the issuer publishes the new key before signing with it.
+type KeySet struct {
+	uri   string
+	fetch FetchFunc
+	// MinRefresh is the minimum time between refetches; DefaultMinRefresh unless changed before first use.
+	MinRefresh time.Duration
+
+	mu      sync.Mutex
+	keys    map[string]crypto.PublicKey
+	fetched time.Time
+}
+
+// NewKeySet returns a KeySet for the JWKS at uri; nothing is fetched until the first lookup.
+func NewKeySet(uri string, fetch FetchFunc) *KeySet {
+	return &KeySet{uri: uri, fetch: fetch, MinRefresh: DefaultMinRefresh}
+}
+
+// NewRemoteKeySet returns a KeySet that fetches uri with client (http.DefaultClient when nil).
+func NewRemoteKeySet(uri string, client *http.Client) *KeySet {
+	return NewKeySet(uri, HTTPFetch(client))
+}
+
+// HTTPFetch returns a FetchFunc that GETs with client (http.DefaultClient when nil) and treats any non-2xx
+// status as an error.
+func HTTPFetch(client *http.Client) FetchFunc {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context, uri string, out any) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, http.NoBody)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("%s: %s", req.URL.Redacted(), resp.Status)
+		}
+		return json.NewDecoder(io.LimitReader(resp.Body, maxSetBytes)).Decode(out)
+	}
+}
+
+// Key returns the public key for kid. An empty kid matches only when the set holds exactly one key.
+func (k *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if key, ok := k.lookup(kid); ok {
+		return key, nil
+	}
+	if !k.fetched.IsZero() && time.Since(k.fetched) < k.MinRefresh {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if err := k.refresh(ctx); err != nil {
+		return nil, err
+	}
+	if key, ok := k.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (k *KeySet) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(k.keys) == 1 {
+		for _, key := range k.keys {
+			return key, true
+		}
+	}
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+func (k *KeySet) refresh(ctx context.Context) error {
+	var doc Set
+	if err := k.fetch(ctx, k.uri, &doc); err != nil {
+		return fmt.Errorf("fetch jwks: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(doc.Keys))
+	for i := range doc.Keys {
+		j := &doc.Keys[i]
+		if j.Use != "" && j.Use != "sig" {
+			continue
+		}
+		if key, err := j.PublicKey(); err == nil {
+			keys[j.Kid] = key
+		}
+	}
+	k.keys, k.fetched = keys, time.Now()
+	return nil
+}
@@ -0,0 +1,167 @@
+package jwks
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is returned, wrapped, for any token that fails verification.
+var ErrInvalidToken = errors.New("invalid token")
+
+// DefaultLeeway is the clock skew allowed on exp and nbf.
+const DefaultLeeway = 30 * time.Second
+
+// Verifier checks compact JWS tokens signed with EdDSA (Ed25519) or ES256 against a KeySet. Other algorithms,
+// including HMAC and "none", are rejected, so a verifier can never be tricked into treating a public key as a
+// shared secret.
+type Verifier struct {
+	Keys *KeySet
+	// Issuer, when set, must equal the iss claim.
+	Issuer string
+	// Audience, when set, must be one of the aud claim values.
+	Audience string
+	// Leeway is the clock skew allowed on exp and nbf; DefaultLeeway when zero.
+	Leeway time.Duration
+	// Now returns the current time; time.Now when nil.
+	Now func() time.Time
+}
+
+// registered holds the registered claims the verifier checks.
+type registered struct {
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *float64 `json:"exp"`
+	NotBefore *float64 `json:"nbf"`
+}
+
+// audience decodes aud as either a string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var one string
+	if err := json.Unmarshal(b, &one); err == nil {
+		*a = audience{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+// Verify checks the signature and the exp (required), nbf, iss, and aud claims of token, then decodes its
+// payload into claims. Every failure wraps ErrInvalidToken.
+func (v *Verifier) Verify(ctx context.Context, token string, claims any) error {
+	payload, err := v.verifySignature(ctx, token)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	var reg registered
+	if err := json.Unmarshal(payload, &reg); err != nil {
+		return fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	if err := v.checkRegistered(&reg); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	return nil
+}
+
+// verifySignature checks the JWS signature and returns the decoded payload.
+func (v *Verifier) verifySignature(ctx context.Context, token string) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("header: %w", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed signature")
+	}
+	key, err := v.Keys.Key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	switch header.Alg {
+	case "EdDSA":
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok || !ed25519.Verify(pub, signed, sig) {
+			return nil, errors.New("bad signature")
+		}
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || pub.Curve != elliptic.P256() || len(sig) != 64 {
+			return nil, errors.New("bad signature")
+		}
+		sum := sha256.Sum256(signed)
+		r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(pub, sum[:], r, s) {
+			return nil, errors.New("bad signature")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported alg %q", header.Alg)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.New("malformed payload")
+	}
+	return payload, nil
+}
+
+func (v *Verifier) checkRegistered(reg *registered) error {
+	now := time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+	leeway := v.Leeway
+	if leeway == 0 {
+		leeway = DefaultLeeway
+	}
+	switch {
+	case reg.ExpiresAt == nil:
+		return errors.New("missing exp")
+	case now.Add(-leeway).After(unixTime(*reg.ExpiresAt)):
+		return errors.New("token expired")
+	case reg.NotBefore != nil && now.Add(leeway).Before(unixTime(*reg.NotBefore)):
+		return errors.New("token not yet valid")
+	case v.Issuer != "" && reg.Issuer != v.Issuer:
+		return fmt.Errorf("unexpected issuer %q", reg.Issuer)
+	case v.Audience != "" && !slices.Contains(reg.Audience, v.Audience):
+		return errors.New("unexpected audience")
+	}
+	return nil
+}
+
+func decodeSegment(seg string, out any) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+func unixTime(sec float64) time.Time {
+	return time.Unix(0, int64(sec*float64(time.Second)))
+}
@@ -0,0 +1,74 @@
+package redact
+
+import (
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Defaults for the built-in high-entropy detector: random base64 or alphanumeric tokens of 32 characters or
+// more score above 4.3 bits per character, while identifiers, paths, and prose stay below it.
+const (
+	DefaultEntropyMinLen = 32
+	DefaultEntropyBits   = 4.3
+)
+
+var (
+	entropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/_-]+=*`)
+	// hexLike covers commit ids, digests, and UUIDs, which are identifiers rather than secrets.
+	hexLike = regexp.MustCompile(`^[0-9a-fA-F-]+$`)
+)
+
+// HighEntropy returns a Detector for base64- or token-like strings of at least minLen characters that mix
+// upper case, lower case, and digits and whose Shannon entropy is at least bits per character. Hexadecimal
+// strings are ignored.
+func HighEntropy(minLen int, bits float64) Detector {
+	return &entropyDetector{minLen: minLen, bits: bits}
+}
+
+type entropyDetector struct {
+	minLen int
+	bits   float64
+}
+
+func (d *entropyDetector) Kind() string { return KindHighEntropy }
+
+func (d *entropyDetector) Redact(s string) (string, bool) {
+	hit := false
+	out := entropyCandidate.ReplaceAllStringFunc(s, func(tok string) string {
+		if !d.secretLike(tok) {
+			return tok
+		}
+		hit = true
+		return Placeholder
+	})
+	return out, hit
+}
+
+func (d *entropyDetector) secretLike(tok string) bool {
+	if len(tok) < d.minLen || isPlaceholder(tok) || hexLike.MatchString(tok) {
+		return false
+	}
+	if !strings.ContainsAny(tok, "0123456789") || !strings.ContainsAny(tok, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") ||
+		!strings.ContainsAny(tok, "abcdefghijklmnopqrstuvwxyz") {
+		return false
+	}
+	return shannon(tok) >= d.bits
+}
+
+// shannon returns the Shannon entropy of s in bits per byte.
+func shannon(s string) float64 {
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+	n := float64(len(s))
+	var h float64
+	for _, c := range counts {
+		if c > 0 {
+			p := float64(c) / n
+			h -= p * math.Log2(p)
+		}
+	}
+	return h
+}
@@ -0,0 +1,60 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Value redacts every string in v, a value decoded by encoding/json (maps, slices, and scalars), and returns
+// the redacted copy and the kinds found, in detector order. Object keys are kept as they are.
+func (r *Redactor) Value(v interface{}) (interface{}, []string) {
+	hits := make([]bool, len(r.detectors))
+	v = r.value(v, hits)
+	return v, r.kinds(hits)
+}
+
+func (r *Redactor) value(v interface{}, hits []bool) interface{} {
+	switch t := v.(type) {
+	case string:
+		return r.redact(t, hits)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for key, item := range t {
+			out[key] = r.value(item, hits)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, item := range t {
+			out[i] = r.value(item, hits)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// JSON redacts the strings inside a JSON document and returns the re-encoded document, or s unchanged when
+// nothing was found. Text that is not valid JSON is redacted as a plain string.
+func (r *Redactor) JSON(s string) (string, []string) {
+	dec := json.NewDecoder(bytes.NewReader([]byte(s)))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return r.String(s)
+	}
+	redacted, found := r.Value(v)
+	if len(found) == 0 {
+		return s, nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(redacted); err != nil {
+		return r.String(s)
+	}
+	return string(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))), found
+}
+
+// JSON redacts the JSON document s with Default.
+func JSON(s string) (string, []string) { return Default.JSON(s) }
@@ -30,6 +30,7 @@ import (
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/handlers"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/middleware"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/rbac"
 )
 
 type ctxKey int
@@ -174,7 +175,7 @@ func InitializeOrchestratorSuite(sc *godog.ScenarioContext, state *testState) {
 		}
 		taskHandler := handlers.NewTaskHandler(db, nil, inferenceURL, inferenceModel)
 		nodeHandler := handlers.NewNodeHandler(db, jwtManager, cfg.NodeRegistrationPSK, cfg.OrchestratorPublicURL, cfg.WorkerAPIBearerToken, cfg.WorkerAPITargetURL, cfg.WorkerInternalAgentToken, nil)
-		authMiddleware := middleware.NewAuthMiddleware(jwtManager, nil)
+		authMiddleware := middleware.NewAuthMiddleware(jwtManager, db, nil)
 
 		mux := http.NewServeMux()
 		mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
@@ -196,16 +197,16 @@ func InitializeOrchestratorSuite(sc *godog.ScenarioContext, state *testState) {
 		mux.HandleFunc("POST /v1/auth/refresh", authHandler.Refresh)
 		mux.Handle("POST /v1/auth/logout", authMiddleware.RequireUserAuth(http.HandlerFunc(authHandler.Logout)))
 		mux.Handle("GET /v1/users/me", authMiddleware.RequireUserAuth(http.HandlerFunc(userHandler.GetMe)))
-		mux.Handle("POST /v1/users/{id}/revoke_sessions", authMiddleware.RequireAdminAuth(http.HandlerFunc(userHandler.RevokeSessions)))
-		mux.Handle("POST /v1/tasks", authMiddleware.RequireUserAuth(http.HandlerFunc(taskHandler.CreateTask)))
-		mux.Handle("GET /v1/tasks", authMiddleware.RequireUserAuth(http.HandlerFunc(taskHandler.ListTasks)))
-		mux.Handle("GET /v1/tasks/{id}", authMiddleware.RequireUserAuth(http.HandlerFunc(taskHandler.GetTask)))
-		mux.Handle("GET /v1/tasks/{id}/result", authMiddleware.RequireUserAuth(http.HandlerFunc(taskHandler.GetTaskResult)))
-		mux.Handle("POST /v1/tasks/{id}/cancel", authMiddleware.RequireUserAuth(http.HandlerFunc(taskHandler.CancelTask)))
-		mux.Handle("GET /v1/tasks/{id}/logs", authMiddleware.RequireUserAuth(http.HandlerFunc(taskHandler.GetTaskLogs)))
+		mux.Handle("POST /v1/users/{id}/revoke_sessions", authMiddleware.RequirePermission(rbac.PermUsersManage, http.HandlerFunc(userHandler.RevokeSessions)))
+		mux.Handle("POST /v1/tasks", authMiddleware.RequirePermission(rbac.PermTasksWrite, http.HandlerFunc(taskHandler.CreateTask)))
+		mux.Handle("GET /v1/tasks", authMiddleware.RequirePermission(rbac.PermTasksRead, http.HandlerFunc(taskHandler.ListTasks)))
+		mux.Handle("GET /v1/tasks/{id}", authMiddleware.RequirePermission(rbac.PermTasksRead, http.HandlerFunc(taskHandler.GetTask)))
+		mux.Handle("GET /v1/tasks/{id}/result", authMiddleware.RequirePermission(rbac.PermTasksRead, http.HandlerFunc(taskHandler.GetTaskResult)))
+		mux.Handle("POST /v1/tasks/{id}/cancel", authMiddleware.RequirePermission(rbac.PermTasksWrite, http.HandlerFunc(taskHandler.CancelTask)))
+		mux.Handle("GET /v1/tasks/{id}/logs", authMiddleware.RequirePermission(rbac.PermTasksRead, http.HandlerFunc(taskHandler.GetTaskLogs)))
 		openAIChatHandler := handlers.NewOpenAIChatHandler(db, slog.Default(), inferenceURL, inferenceModel, bddGetEnv("WORKER_API_BEARER_TOKEN", ""))
-		mux.Handle("GET /v1/models", authMiddleware.RequireUserAuth(http.HandlerFunc(openAIChatHandler.ListModels)))
-		mux.Handle("POST /v1/chat/completions", authMiddleware.RequireUserAuth(http.HandlerFunc(openAIChatHandler.ChatCompletions)))
+		mux.Handle("GET /v1/models", authMiddleware.RequirePermission(rbac.PermChatUse, http.HandlerFunc(openAIChatHandler.ListModels)))
+		mux.Handle("POST /v1/chat/completions", authMiddleware.RequirePermission(rbac.PermChatUse, http.HandlerFunc(openAIChatHandler.ChatCompletions)))
 		mux.HandleFunc("POST /v1/nodes/register", nodeHandler.Register)
 		mux.Handle("GET /v1/nodes/config", authMiddleware.RequireNodeAuth(http.HandlerFunc(nodeHandler.GetConfig)))
 		mux.Handle("POST /v1/nodes/config", authMiddleware.RequireNodeAuth(http.HandlerFunc(nodeHandler.ConfigAck)))
@@ -305,8 +306,13 @@ func RegisterOrchestratorSteps(sc *godog.ScenarioContext, state *testState) {
 		if err != nil {
 			return err
 		}
-		_, err = st.db.CreatePasswordCredential(ctx, user.ID, hash, "argon2id")
-		return err
+		if _, err = st.db.CreatePasswordCredential(ctx, user.ID, hash, "argon2id"); err != nil {
+			return err
+		}
+		return st.db.CreateRoleBinding(ctx, &models.RoleBinding{
+			SubjectType: database.SubjectTypeUser, SubjectID: user.ID,
+			RoleID: database.BuiltinRoles()[0].ID, ScopeType: database.ScopeTypeSystem,
+		})
 	})
 
 	// Auth
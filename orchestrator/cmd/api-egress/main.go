@@ -1,5 +1,6 @@
 // Package main provides the API egress server.
-// REQ-APIEGR-0001, REQ-APIEGR-0110--0113, REQ-APIEGR-0119: access control and audit when API_EGRESS_DSN is set.
+// REQ-APIEGR-0001, REQ-APIEGR-0110--0113, REQ-APIEGR-0119: access control, rate limits, and audit when
+// API_EGRESS_DSN is set; allowed calls are performed by the provider adapters in internal/apiegress with the caller's decrypted credential.
 package main
 
 import (
@@ -15,9 +16,13 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/apiegress"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/auth"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/credcrypt"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/middleware"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/policy"
 )
 
 func main() {
@@ -45,6 +50,14 @@ func run(ctx context.Context, logger *slog.Logger) error {
 		_, _ = w.Write([]byte("ok"))
 	})
 	bearer := getEnv("API_EGRESS_BEARER_TOKEN", "")
+	var verifier *auth.RemoteVerifier
+	if jwksURL := getEnv("API_EGRESS_JWKS_URL", ""); jwksURL != "" {
+		if bearer != "" {
+			logger.Warn("API_EGRESS_JWKS_URL is set; API_EGRESS_BEARER_TOKEN is ignored")
+			bearer = ""
+		}
+		verifier = auth.NewRemoteVerifier(jwksURL)
+	}
 	allowlist := getEnv("API_EGRESS_ALLOWED", "openai,github")
 	dsn := getEnv("API_EGRESS_DSN", "")
 	if dsn != "" {
@@ -56,9 +69,19 @@ func run(ctx context.Context, logger *slog.Logger) error {
 			_ = db.Close()
 			return err
 		}
-		mux.Handle("POST /v1/call", newCallHandlerWithStore(logger, bearer, allowlist, db))
+		keys, err := credcrypt.FromEnv()
+		switch {
+		case errors.Is(err, credcrypt.ErrKeyNotConfigured):
+			logger.Warn("credential master key not set; outbound calls disabled", "env", credcrypt.EnvMasterKeyB64)
+		case err != nil:
+			_ = db.Close()
+			return err
+		}
+		h := newCallHandlerWithStore(logger, bearer, allowlist, db)
+		h.keys = keys
+		mux.Handle("POST /v1/call", requireSignedToken(verifier, db, h))
 	} else {
-		mux.Handle("POST /v1/call", newCallHandler(logger, bearer, allowlist))
+		mux.Handle("POST /v1/call", requireSignedToken(verifier, nil, newCallHandler(logger, bearer, allowlist)))
 	}
 
 	handler := middleware.Logging(logger)(mux)
@@ -67,7 +90,7 @@ func run(ctx context.Context, logger *slog.Logger) error {
 		Handler:           handler,
 		ReadHeaderTimeout: 30 * time.Second,
 		ReadTimeout:       30 * time.Second,
-		WriteTimeout:      30 * time.Second,
+		WriteTimeout:      callTimeout() + 15*time.Second,
 		IdleTimeout:       120 * time.Second,
 		MaxHeaderBytes:    1 << 20,
 	}
@@ -103,6 +126,37 @@ func shutdownTimeout() time.Duration {
 	return defaultSec * time.Second
 }
 
+// callTimeout returns the outbound provider call timeout from env or default. Used by run and providersFromEnv.
+func callTimeout() time.Duration {
+	const defaultSec = 60
+	if s := os.Getenv("API_EGRESS_CALL_TIMEOUT_SEC"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 {
+			return time.Duration(n) * time.Second
+		}
+	}
+	return defaultSec * time.Second
+}
+
+// providersFromEnv returns the provider adapters with base URLs from env (overridable for stand-ins and
+// OpenAI-compatible or GitHub Enterprise endpoints).
+func providersFromEnv() *apiegress.Registry {
+	return apiegress.NewRegistry(&http.Client{Timeout: callTimeout()},
+		apiegress.NewOpenAI(getEnv("API_EGRESS_OPENAI_BASE_URL", apiegress.DefaultOpenAIBaseURL)),
+		apiegress.NewGitHub(getEnv("API_EGRESS_GITHUB_BASE_URL", apiegress.DefaultGitHubBaseURL)),
+	)
+}
+
+// requireSignedToken wraps h so callers must present a user access or node token signed by the orchestrator
+// (API_EGRESS_JWKS_URL); the service verifies it against the JWKS and holds no signing key. Access tokens of
+// revoked sessions are refused through denyList, which is nil only without a database, when no call is made.
+// h is returned as is when v is nil.
+func requireSignedToken(v *auth.RemoteVerifier, denyList middleware.SessionDenyList, h http.Handler) http.Handler {
+	if v == nil {
+		return h
+	}
+	return middleware.RequireSignedToken(v, denyList, auth.TokenTypeAccess, auth.TokenTypeNode)(h)
+}
+
 func getEnv(key, def string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
@@ -118,20 +172,28 @@ type callRequest struct {
 	TaskID    string          `json:"task_id,omitempty"`
 }
 
-// callHandler implements POST /v1/call with authz and audit; returns 501 for unimplemented operations.
+// maxCallBodyBytes bounds the POST /v1/call body; each operation applies its own smaller params limit.
+const maxCallBodyBytes = 1 << 20
+
+// callHandler implements POST /v1/call with authz and audit, then performs the call through the provider
+// adapters. Refusals (auth, policy, unknown operation, missing configuration) are problem+json; once a call is
+// attempted the body is the normalized apiegress.Response.
 type callHandler struct {
-	logger  *slog.Logger
-	token   string
-	allowed map[string]bool
-	store   database.Store
+	logger    *slog.Logger
+	token     string
+	allowed   map[string]bool
+	store     database.Store
+	providers *apiegress.Registry
+	// keys decrypts api_credentials; nil disables outbound calls (503).
+	keys *credcrypt.Keyring
 }
 
 func newCallHandler(logger *slog.Logger, bearerToken, allowlist string) *callHandler {
-	return &callHandler{logger: logger, token: bearerToken, allowed: parseAllowlist(allowlist), store: nil}
+	return &callHandler{logger: logger, token: bearerToken, allowed: parseAllowlist(allowlist), store: nil, providers: providersFromEnv()}
 }
 
 func newCallHandlerWithStore(logger *slog.Logger, bearerToken, allowlist string, store database.Store) *callHandler {
-	return &callHandler{logger: logger, token: bearerToken, allowed: parseAllowlist(allowlist), store: store}
+	return &callHandler{logger: logger, token: bearerToken, allowed: parseAllowlist(allowlist), store: store, providers: providersFromEnv()}
 }
 
 func parseAllowlist(allowlist string) map[string]bool {
@@ -158,7 +220,12 @@ func (h *callHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	var req callRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxCallBodyBytes)).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.writeProblem(w, http.StatusRequestEntityTooLarge, "Payload Too Large", "request body too large")
+			return
+		}
 		h.writeProblem(w, http.StatusBadRequest, "Bad Request", "invalid JSON")
 		return
 	}
@@ -166,21 +233,7 @@ func (h *callHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	operation := strings.TrimSpace(strings.ToLower(req.Operation))
 
 	if h.store != nil {
-		subjectID, decision, reason := h.evaluateWithStore(r.Context(), &req, provider, operation)
-		var taskIDPtr *uuid.UUID
-		if req.TaskID != "" {
-			if tid, err := uuid.Parse(req.TaskID); err == nil {
-				taskIDPtr = &tid
-			}
-		}
-		h.auditLog(r.Context(), subjectID, decision, reason, provider, operation, taskIDPtr)
-		if decision == decisionDeny {
-			h.logger.Info("api_egress_audit", "task_id", req.TaskID, "provider", provider, "operation", operation, "decision", decision, "reason", reason)
-			h.writeProblem(w, http.StatusForbidden, "Forbidden", reason)
-			return
-		}
-		h.logger.Info("api_egress_audit", "task_id", req.TaskID, "provider", provider, "operation", operation, "decision", "allow")
-		h.writeProblem(w, http.StatusNotImplemented, "Not Implemented", "operation not implemented")
+		h.serveWithStore(w, r, &req, provider, operation)
 		return
 	}
 
@@ -190,7 +243,116 @@ func (h *callHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	h.logger.Info("api_egress_audit", "task_id", req.TaskID, "provider", req.Provider, "operation", req.Operation, "allowed", true)
-	h.writeProblem(w, http.StatusNotImplemented, "Not Implemented", "operation not implemented")
+	if !h.lookup(w, provider, operation) {
+		return
+	}
+	// Without a database there are no credentials to inject.
+	h.writeProblem(w, http.StatusServiceUnavailable, "Service Unavailable", "credential store not configured")
+}
+
+// serveWithStore enforces policy, credentials, and egress limits for the task's user, audits the decision, then
+// performs the call. Limit denials are 429 with Retry-After; other denials are 403.
+func (h *callHandler) serveWithStore(w http.ResponseWriter, r *http.Request, req *callRequest, provider, operation string) {
+	ctx := r.Context()
+	task, decision, reason := h.evaluateWithStore(ctx, req, provider, operation)
+	var (
+		lease    *uuid.UUID
+		exceeded *database.ApiEgressLimitExceededError
+	)
+	if decision == decisionAllow {
+		lease, exceeded, reason = h.acquireQuota(ctx, task, provider, operation)
+		if reason != "" {
+			decision = decisionDeny
+		}
+	}
+	var subjectID, taskIDPtr *uuid.UUID
+	if task != nil {
+		subjectID = task.CreatedBy
+	}
+	if req.TaskID != "" {
+		if tid, err := uuid.Parse(req.TaskID); err == nil {
+			taskIDPtr = &tid
+		}
+	}
+	h.auditLog(ctx, subjectID, decision, reason, provider, operation, taskIDPtr)
+	if decision == decisionDeny {
+		h.logger.Info("api_egress_audit", "task_id", req.TaskID, "provider", provider, "operation", operation, "decision", decision, "reason", reason)
+		if exceeded != nil {
+			w.Header().Set("Retry-After", strconv.Itoa(int(exceeded.RetryAfter(time.Now()).Seconds())))
+			h.writeProblem(w, http.StatusTooManyRequests, "Too Many Requests", reason)
+			return
+		}
+		h.writeProblem(w, http.StatusForbidden, "Forbidden", reason)
+		return
+	}
+	h.logger.Info("api_egress_audit", "task_id", req.TaskID, "provider", provider, "operation", operation, "decision", "allow")
+	if lease != nil {
+		defer h.releaseQuota(context.WithoutCancel(ctx), *lease)
+	}
+	h.perform(w, r, req, *subjectID, provider, operation)
+}
+
+// lookup writes a 501 problem and returns false when no adapter implements provider/operation.
+func (h *callHandler) lookup(w http.ResponseWriter, provider, operation string) bool {
+	if _, err := h.providers.Lookup(provider, operation); err != nil {
+		e := apiegress.AsError(err)
+		h.writeProblem(w, e.HTTPStatus, "Not Implemented", e.Message)
+		return false
+	}
+	return true
+}
+
+// perform resolves and decrypts the subject's credential for provider, calls the adapter, and writes the
+// normalized response. The plaintext credential is erased before returning and never logged.
+func (h *callHandler) perform(w http.ResponseWriter, r *http.Request, req *callRequest, subjectID uuid.UUID, provider, operation string) {
+	if !h.lookup(w, provider, operation) {
+		return
+	}
+	if h.keys == nil {
+		h.writeProblem(w, http.StatusServiceUnavailable, "Service Unavailable", "credential decryption not configured")
+		return
+	}
+	ctx := r.Context()
+	cred, err := h.store.GetActiveApiCredentialForUserAndProvider(ctx, subjectID, provider)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			h.writeProblem(w, http.StatusForbidden, "Forbidden", "no active credential for provider")
+			return
+		}
+		h.logger.Error("load api credential", "error", err, "provider", provider)
+		h.writeProblem(w, http.StatusInternalServerError, "Internal Server Error", "failed to load credential")
+		return
+	}
+	var kid string
+	if cred.CredentialKID != nil {
+		kid = *cred.CredentialKID
+	}
+	secret, err := h.keys.Open(cred.ID, kid, cred.CredentialCiphertext)
+	if err != nil {
+		h.logger.Error("decrypt api credential", "error", err, "credential_id", cred.ID, "provider", provider)
+		h.writeProblem(w, http.StatusInternalServerError, "Internal Server Error", "failed to decrypt credential")
+		return
+	}
+	start := time.Now()
+	result, err := h.providers.Call(ctx, provider, operation, req.Params, secret)
+	credcrypt.Zero(secret)
+	duration := time.Since(start)
+	if err != nil {
+		e := apiegress.AsError(err)
+		h.logger.Info("api_egress_call", "task_id", req.TaskID, "provider", provider, "operation", operation,
+			"credential_id", cred.ID, "status", "error", "code", e.Code, "upstream_status", e.UpstreamStatus, "duration_ms", duration.Milliseconds())
+		h.writeResponse(w, e.HTTPStatus, &apiegress.Response{Status: "error", Error: e})
+		return
+	}
+	h.logger.Info("api_egress_call", "task_id", req.TaskID, "provider", provider, "operation", operation,
+		"credential_id", cred.ID, "status", "success", "duration_ms", duration.Milliseconds())
+	h.writeResponse(w, http.StatusOK, &apiegress.Response{Status: "success", Result: result})
+}
+
+func (h *callHandler) writeResponse(w http.ResponseWriter, status int, resp *apiegress.Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
 }
 
 func (h *callHandler) writeProblem(w http.ResponseWriter, status int, title, detail string) {
@@ -206,30 +368,64 @@ func (h *callHandler) writeProblem(w http.ResponseWriter, status int, title, det
 const decisionDeny = "deny"
 const decisionAllow = "allow"
 
-// evaluateWithStore resolves subject from task_id, checks policy and credential; returns subjectID (may be nil on deny), decision, reason.
-func (h *callHandler) evaluateWithStore(ctx context.Context, req *callRequest, provider, operation string) (subjectID *uuid.UUID, decision, reason string) {
-	subjectID, reason = h.resolveSubjectFromTask(ctx, req)
-	if subjectID == nil {
+// evaluateWithStore resolves the task from task_id, checks policy and credential; returns the task (nil when it
+// cannot be resolved), decision, reason.
+func (h *callHandler) evaluateWithStore(ctx context.Context, req *callRequest, provider, operation string) (task *models.Task, decision, reason string) {
+	task, reason = h.resolveSubjectFromTask(ctx, req)
+	if task == nil {
 		return nil, decisionDeny, reason
 	}
-	rules, err := h.store.ListAccessControlRulesForApiCall(ctx, "user", subjectID, database.ActionApiCall, database.ResourceTypeProviderOperation)
-	if err != nil {
-		return subjectID, decisionDeny, "failed to load policy"
-	}
-	if reason := h.evaluatePolicy(rules, provider, operation); reason != "" {
-		return subjectID, decisionDeny, reason
+	if reason := h.evaluatePolicy(ctx, task, req, provider, operation); reason != "" {
+		return task, decisionDeny, reason
 	}
-	hasCred, err := h.store.HasActiveApiCredentialForUserAndProvider(ctx, *subjectID, provider)
+	hasCred, err := h.store.HasActiveApiCredentialForUserAndProvider(ctx, *task.CreatedBy, provider)
 	if err != nil {
-		return subjectID, decisionDeny, "failed to check credential"
+		return task, decisionDeny, "failed to check credential"
 	}
 	if !hasCred {
-		return subjectID, decisionDeny, "no active credential for provider"
+		return task, decisionDeny, "no active credential for provider"
+	}
+	return task, decisionAllow, ""
+}
+
+// quotaLeaseGrace is how long past the call timeout a concurrency slot outlives a replica that died mid-call.
+const quotaLeaseGrace = 15 * time.Second
+
+// acquireQuota charges the call to the api_egress_limits that apply to the task's user and project. Returns the
+// lease to release when the call ends (nil when no concurrency slot is held), or a deny reason: the exceeded limit,
+// or a failure to check, which denies the call rather than letting it through unthrottled.
+func (h *callHandler) acquireQuota(ctx context.Context, task *models.Task, provider, operation string) (*uuid.UUID, *database.ApiEgressLimitExceededError, string) {
+	c := &database.ApiEgressCall{
+		Provider:       provider,
+		Operation:      operation,
+		UserID:         *task.CreatedBy,
+		ProjectID:      task.ProjectID,
+		LeaseID:        uuid.New(),
+		LeaseExpiresAt: time.Now().UTC().Add(callTimeout() + quotaLeaseGrace),
+	}
+	held, err := h.store.AcquireApiEgressQuota(ctx, c)
+	var exceeded *database.ApiEgressLimitExceededError
+	switch {
+	case errors.As(err, &exceeded):
+		return nil, exceeded, exceeded.Error()
+	case err != nil:
+		h.logger.Error("acquire api egress quota", "error", err, "provider", provider)
+		return nil, nil, "failed to check egress limits"
+	case held:
+		return &c.LeaseID, nil, ""
+	}
+	return nil, nil, ""
+}
+
+// releaseQuota frees the concurrency slots of a finished call; a slot that fails to release expires on its own.
+func (h *callHandler) releaseQuota(ctx context.Context, leaseID uuid.UUID) {
+	if err := h.store.ReleaseApiEgressQuota(ctx, leaseID); err != nil {
+		h.logger.Warn("release api egress quota", "error", err, "lease_id", leaseID)
 	}
-	return subjectID, decisionAllow, ""
 }
 
-func (h *callHandler) resolveSubjectFromTask(ctx context.Context, req *callRequest) (subjectID *uuid.UUID, reason string) {
+// resolveSubjectFromTask returns the task named by task_id when it has a creating user, or a deny reason.
+func (h *callHandler) resolveSubjectFromTask(ctx context.Context, req *callRequest) (task *models.Task, reason string) {
 	if strings.TrimSpace(req.TaskID) == "" {
 		return nil, "task_id required"
 	}
@@ -237,7 +433,7 @@ func (h *callHandler) resolveSubjectFromTask(ctx context.Context, req *callReque
 	if err != nil {
 		return nil, "invalid task_id"
 	}
-	task, err := h.store.GetTaskByID(ctx, taskID)
+	task, err = h.store.GetTaskByID(ctx, taskID)
 	if err != nil {
 		if errors.Is(err, database.ErrNotFound) {
 			return nil, "task not found"
@@ -247,30 +443,38 @@ func (h *callHandler) resolveSubjectFromTask(ctx context.Context, req *callReque
 	if task.CreatedBy == nil {
 		return nil, "task has no user context"
 	}
-	return task.CreatedBy, ""
+	return task, ""
 }
 
-func (h *callHandler) evaluatePolicy(rules []*models.AccessControlRule, provider, operation string) string {
-	resource := provider + "/" + operation
-	var allowMatch, denyMatch bool
-	for _, rule := range rules {
-		if rule.ResourcePattern != resource {
-			continue
-		}
-		switch rule.Effect {
-		case decisionDeny:
-			denyMatch = true
-		case decisionAllow:
-			allowMatch = true
-		}
+// evaluatePolicy decides api.call on provider/operation for the task's user; returns a deny reason or "".
+func (h *callHandler) evaluatePolicy(ctx context.Context, task *models.Task, req *callRequest, provider, operation string) string {
+	engine := policy.New(h.store)
+	principal, err := engine.PrincipalForUser(ctx, *task.CreatedBy)
+	if err != nil {
+		return "failed to load policy"
 	}
-	if denyMatch {
-		return "policy denies provider/operation"
+	pctx := policy.Context{ProjectID: task.ProjectID, RequestBytes: int64(len(req.Params))}
+	if task.TaskType != nil {
+		pctx.TaskType = *task.TaskType
 	}
-	if !allowMatch {
+	d, err := engine.Decide(ctx, &policy.Request{
+		Principal:    principal,
+		Action:       database.ActionApiCall,
+		ResourceType: database.ResourceTypeProviderOperation,
+		Resource:     provider + "/" + operation,
+		Context:      pctx,
+	})
+	switch {
+	case err != nil:
+		h.logger.Error("load access control rules", "error", err)
+		return "failed to load policy"
+	case d.Allowed:
+		return ""
+	case d.Rule != nil:
+		return "policy denies provider/operation"
+	default:
 		return "provider/operation not allowed by policy"
 	}
-	return ""
 }
 
 func (h *callHandler) auditLog(ctx context.Context, subjectID *uuid.UUID, decision, reason, provider, operation string, taskID *uuid.UUID) {
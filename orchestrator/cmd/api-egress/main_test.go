@@ -9,11 +9,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/apiegress"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/credcrypt"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
@@ -192,7 +195,7 @@ func TestCallHandler_WithStore_AllowReturns501(t *testing.T) {
 	task := &models.Task{ID: uuid.New(), CreatedBy: &user.ID, Status: "running"}
 	mock.AddTask(task)
 	mock.AccessControlRules = []*models.AccessControlRule{
-		{Effect: "allow", ResourcePattern: "openai/chat", Action: database.ActionApiCall, ResourceType: database.ResourceTypeProviderOperation},
+		{SubjectType: database.SubjectTypeUser, SubjectID: &user.ID, Effect: "allow", ResourcePattern: "openai/chat", Action: database.ActionApiCall, ResourceType: database.ResourceTypeProviderOperation},
 	}
 	mock.HasActiveApiCredential = true
 	h := newCallHandlerWithStore(slog.Default(), "secret", "openai,github", mock)
@@ -266,7 +269,7 @@ func TestCallHandler_WithStore_PolicyOrCredentialDeny_403(t *testing.T) {
 			mock := testutil.NewMockDB()
 			task := setupMockWithUserAndTask(mock, "ae-user-"+name)
 			mock.AccessControlRules = []*models.AccessControlRule{
-				{Effect: tc.effect, ResourcePattern: "openai/chat", Action: database.ActionApiCall, ResourceType: database.ResourceTypeProviderOperation},
+				{SubjectType: database.SubjectTypeUser, SubjectID: task.CreatedBy, Effect: tc.effect, ResourcePattern: "openai/chat", Action: database.ActionApiCall, ResourceType: database.ResourceTypeProviderOperation},
 			}
 			mock.HasActiveApiCredential = tc.hasCred
 			code, detail := callWithStoreAndAssert403(t, mock, task.ID.String())
@@ -334,6 +337,211 @@ func TestCallHandler_WithStore_NoUserContext_403(t *testing.T) {
 	}
 }
 
+func TestCallHandler_NoStore_KnownOperation_503(t *testing.T) {
+	h := newCallHandler(slog.Default(), "secret", "openai,github")
+	body := map[string]string{"provider": "openai", "operation": "chat_completions", "task_id": "t3"}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/v1/call", bytes.NewReader(mustJSON(body)))
+	r.Header.Set("Authorization", "Bearer secret")
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("no store: got %d", w.Code)
+	}
+}
+
+func TestCallHandler_BodyTooLarge_413(t *testing.T) {
+	h := newCallHandler(slog.Default(), "", "openai")
+	big := `{"provider":"openai","params":"` + strings.Repeat("x", maxCallBodyBytes) + `"}`
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/call", strings.NewReader(big)))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("large body: got %d", w.Code)
+	}
+}
+
+// setupProviderCall returns a handler whose OpenAI adapter points at upstream and whose store holds an allow rule
+// and an encrypted credential "sk-user" for the task creator.
+func setupProviderCall(t *testing.T, upstream http.HandlerFunc) (*callHandler, *testutil.MockDB, *models.Task) {
+	t.Helper()
+	srv := httptest.NewServer(upstream)
+	t.Cleanup(srv.Close)
+	mock := testutil.NewMockDB()
+	task := setupMockWithUserAndTask(mock, "ae-call-"+uuid.NewString())
+	mock.AccessControlRules = []*models.AccessControlRule{
+		{SubjectType: database.SubjectTypeUser, SubjectID: task.CreatedBy, Effect: "allow", ResourcePattern: "openai/chat_completions", Action: database.ActionApiCall, ResourceType: database.ResourceTypeProviderOperation},
+	}
+	mock.HasActiveApiCredential = true
+	keys, err := credcrypt.New("k1", []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cred := &models.ApiCredential{ID: uuid.New(), OwnerType: "user", OwnerID: *task.CreatedBy, Provider: "openai", IsActive: true}
+	ct, kid, err := keys.Seal(cred.ID, []byte("sk-user"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cred.CredentialCiphertext, cred.CredentialKID = ct, &kid
+	mock.ApiCredentials = []*models.ApiCredential{cred}
+	h := newCallHandlerWithStore(slog.Default(), "secret", "openai,github", mock)
+	h.providers = apiegress.NewRegistry(srv.Client(), apiegress.NewOpenAI(srv.URL))
+	h.keys = keys
+	return h, mock, task
+}
+
+func postChatCall(h *callHandler, taskID uuid.UUID) *httptest.ResponseRecorder {
+	body := map[string]interface{}{
+		"provider": "openai", "operation": "chat_completions", "task_id": taskID.String(),
+		"params": map[string]interface{}{"model": "m", "messages": []map[string]string{{"role": "user", "content": "hi"}}},
+	}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/v1/call", bytes.NewReader(mustJSON(body)))
+	r.Header.Set("Authorization", "Bearer secret")
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestCallHandler_WithStore_PerformsCall(t *testing.T) {
+	h, _, task := setupProviderCall(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sk-user" {
+			t.Errorf("credential not injected: %q", r.Header.Get("Authorization"))
+		}
+		_, _ = w.Write([]byte(`{"id":"c","model":"m","choices":[{"message":{"role":"assistant","content":"hello"},"finish_reason":"stop"}]}`))
+	})
+	w := postChatCall(h, task.ID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	var resp apiegress.Response
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != "success" || !strings.Contains(string(resp.Result), `"content":"hello"`) {
+		t.Errorf("response = %+v", resp)
+	}
+	if strings.Contains(string(resp.Result), "sk-user") {
+		t.Error("credential leaked into response")
+	}
+}
+
+func TestCallHandler_WithStore_UpstreamErrorNormalized(t *testing.T) {
+	h, _, task := setupProviderCall(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":{"message":"rate limited"}}`))
+	})
+	w := postChatCall(h, task.ID)
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("status %d", w.Code)
+	}
+	var resp apiegress.Response
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Status != "error" || resp.Error == nil || resp.Error.Code != apiegress.CodeUpstreamError ||
+		resp.Error.UpstreamStatus != http.StatusTooManyRequests || resp.Error.Message != "rate limited" {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestCallHandler_WithStore_CredentialFailures(t *testing.T) {
+	upstream := func(http.ResponseWriter, *http.Request) { t.Error("provider must not be called") }
+
+	h, _, task := setupProviderCall(t, upstream)
+	h.keys = nil
+	if w := postChatCall(h, task.ID); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("no keyring: got %d", w.Code)
+	}
+
+	h, mock, task := setupProviderCall(t, upstream)
+	mock.ApiCredentials[0].CredentialCiphertext = []byte("tampered ciphertext")
+	if w := postChatCall(h, task.ID); w.Code != http.StatusInternalServerError {
+		t.Errorf("bad ciphertext: got %d", w.Code)
+	}
+
+	h, mock, task = setupProviderCall(t, upstream)
+	mock.ApiCredentials = nil
+	if w := postChatCall(h, task.ID); w.Code != http.StatusForbidden {
+		t.Errorf("credential gone: got %d", w.Code)
+	}
+}
+
+const okChatCompletion = `{"id":"c","model":"m","choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`
+
+func TestCallHandler_WithStore_RateLimit429(t *testing.T) {
+	calls := 0
+	h, mock, task := setupProviderCall(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		_, _ = w.Write([]byte(okChatCompletion))
+	})
+	mock.ApiEgressLimits = []*models.ApiEgressLimit{
+		{ID: uuid.New(), Provider: "openai", ScopeType: database.ApiEgressScopeUser, RequestsPerMinute: 1},
+	}
+	if w := postChatCall(h, task.ID); w.Code != http.StatusOK {
+		t.Fatalf("first call: got %d", w.Code)
+	}
+	w := postChatCall(h, task.ID)
+	if w.Code != http.StatusTooManyRequests || calls != 1 {
+		t.Fatalf("second call: got %d after %d upstream calls", w.Code, calls)
+	}
+	if ra := w.Header().Get("Retry-After"); ra == "" || ra == "0" {
+		t.Errorf("Retry-After = %q", ra)
+	}
+	last := mock.AccessControlAuditLogs[len(mock.AccessControlAuditLogs)-1]
+	if last.Decision != decisionDeny || last.Reason == nil || !strings.HasPrefix(*last.Reason, "rate limit exceeded") {
+		t.Errorf("audit = %+v", last)
+	}
+}
+
+func TestCallHandler_WithStore_ConcurrencyAndBudget(t *testing.T) {
+	h, mock, task := setupProviderCall(t, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(okChatCompletion))
+	})
+	limit := &models.ApiEgressLimit{ID: uuid.New(), Provider: "openai", Operation: "chat_completions",
+		ScopeType: database.ApiEgressScopeSystem, MaxConcurrent: 1}
+	mock.ApiEgressLimits = []*models.ApiEgressLimit{limit}
+	for i := 0; i < 2; i++ { // the slot is released after each call
+		if w := postChatCall(h, task.ID); w.Code != http.StatusOK {
+			t.Fatalf("call %d: got %d", i, w.Code)
+		}
+	}
+	if len(mock.ApiEgressInflight) != 0 {
+		t.Errorf("slots not released: %d", len(mock.ApiEgressInflight))
+	}
+	mock.ApiEgressInflight = append(mock.ApiEgressInflight, &models.ApiEgressInflight{
+		LeaseID: uuid.New(), LimitID: limit.ID, ExpiresAt: time.Now().Add(time.Minute),
+	})
+	w := postChatCall(h, task.ID)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "2" {
+		t.Errorf("concurrency: got %d Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	mock.ApiEgressInflight = nil
+	limit.MaxConcurrent, limit.MonthlyBudgetMicroUSD, limit.CostPerCallMicroUSD = 0, 5000, 2000
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		if w := postChatCall(h, task.ID); w.Code != want {
+			t.Errorf("budget call %d: got %d, want %d", i, w.Code, want)
+		}
+	}
+	last := mock.AccessControlAuditLogs[len(mock.AccessControlAuditLogs)-1]
+	if last.Reason == nil || !strings.HasPrefix(*last.Reason, "monthly budget exceeded") {
+		t.Errorf("audit = %+v", last)
+	}
+}
+
+func TestCallHandler_WithStore_LimitOtherProviderOrUserIgnored(t *testing.T) {
+	h, mock, task := setupProviderCall(t, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(okChatCompletion))
+	})
+	other := uuid.New()
+	mock.ApiEgressLimits = []*models.ApiEgressLimit{
+		{ID: uuid.New(), Provider: "github", ScopeType: database.ApiEgressScopeSystem, RequestsPerMinute: 1},
+		{ID: uuid.New(), Provider: "openai", ScopeType: database.ApiEgressScopeUser, ScopeID: &other, RequestsPerMinute: 1},
+		{ID: uuid.New(), Provider: "openai", ScopeType: database.ApiEgressScopeProject, RequestsPerMinute: 1},
+	}
+	for i := 0; i < 3; i++ {
+		if w := postChatCall(h, task.ID); w.Code != http.StatusOK {
+			t.Fatalf("call %d: got %d", i, w.Code)
+		}
+	}
+}
+
 func mustJSON(v interface{}) []byte {
 	b, err := json.Marshal(v)
 	if err != nil {
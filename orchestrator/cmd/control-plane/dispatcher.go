@@ -6,56 +6,162 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/auth"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/dispatcher"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
 )
 
 type dispatcherConfig struct {
 	Enabled      bool
 	PollInterval time.Duration
 	HTTPTimeout  time.Duration
+	// Workers is the number of concurrent dispatch loops; each claims jobs independently (SKIP LOCKED),
+	// so a long-running job only occupies one worker.
+	Workers int
+	// Async submits jobs with jobs:submit instead of holding jobs:run open; results are collected by polling.
+	Async bool
+	// LeaseTTL is how far each worker heartbeat extends an async job's lease; expired leases are requeued.
+	LeaseTTL time.Duration
+	// HeartbeatBaseURL is the control-plane URL nodes post heartbeats to.
+	HeartbeatBaseURL string
+	// WebEgressTokenSecret signs Web Egress Proxy credentials for egress-proxy jobs (WEB_EGRESS_TOKEN_SECRET).
+	WebEgressTokenSecret string
+	// SignedWorkerTokens sends each job's Worker API call a short-lived orchestrator-signed token instead of the
+	// node's static bearer token (WORKER_API_SIGNED_TOKENS). Enable it once every worker-api verifies tokens
+	// against the control-plane JWKS (WORKER_API_JWKS_URL).
+	SignedWorkerTokens bool
+	// workerTokens signs those tokens; set by startDispatcher.
+	workerTokens *auth.JWTManager
+	// ArtifactUploads tells nodes to upload job outputs to HeartbeatBaseURL (set when an artifact store is configured).
+	ArtifactUploads bool
 }
 
 func loadDispatcherConfig() dispatcherConfig {
+	workers := getIntEnv("DISPATCH_WORKERS", 4)
+	if workers < 1 {
+		workers = 1
+	}
 	return dispatcherConfig{
 		Enabled:      getEnv("DISPATCHER_ENABLED", "true") == "true",
 		PollInterval: getDurationEnv("DISPATCH_POLL_INTERVAL", 1*time.Second),
 		// Worker /v1/worker/jobs:run is synchronous and may run close to SBA max_runtime_seconds.
-		HTTPTimeout: getDurationEnv("DISPATCH_HTTP_TIMEOUT", 300*time.Second),
+		HTTPTimeout:          getDurationEnv("DISPATCH_HTTP_TIMEOUT", 300*time.Second),
+		Workers:              workers,
+		Async:                getEnv("DISPATCH_ASYNC", "false") == "true",
+		LeaseTTL:             getDurationEnv("DISPATCH_LEASE_TTL", 60*time.Second),
+		HeartbeatBaseURL:     getEnv("ORCHESTRATOR_PUBLIC_URL", "http://localhost:12082"),
+		WebEgressTokenSecret: os.Getenv("WEB_EGRESS_TOKEN_SECRET"),
+		SignedWorkerTokens:   getEnv("WORKER_API_SIGNED_TOKENS", "false") == "true",
 	}
 }
 
-func startDispatcher(ctx context.Context, db database.Store, logger *slog.Logger) {
+// startDispatcher runs the dispatch loops until ctx is canceled. artifactUploads enables job artifact upload URLs;
+// jwt signs Worker API tokens when SignedWorkerTokens is enabled.
+func startDispatcher(ctx context.Context, db database.Store, logger *slog.Logger, artifactUploads bool, jwt *auth.JWTManager) {
 	cfg := loadDispatcherConfig()
+	cfg.ArtifactUploads = artifactUploads
+	if cfg.SignedWorkerTokens {
+		cfg.workerTokens = jwt
+	}
 	if !cfg.Enabled {
 		logger.Info("dispatcher disabled")
 		return
 	}
 
 	client := &http.Client{Timeout: cfg.HTTPTimeout}
+	logger.Info("dispatcher started", "poll_interval", cfg.PollInterval.String(), "workers", cfg.Workers, "async", cfg.Async)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		runLeaseMaintenance(ctx, db, client, cfg, logger)
+	}()
+	for i := 0; i < cfg.Workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			runDispatchWorker(ctx, db, client, cfg, logger.With("dispatch_worker", worker))
+		}(i)
+	}
+	wg.Wait()
+	logger.Info("dispatcher stopping", "reason", ctx.Err())
+}
+
+// runDispatchWorker claims and dispatches jobs until the queue is empty, then waits for the next tick.
+func runDispatchWorker(ctx context.Context, db database.Store, client *http.Client, cfg dispatcherConfig, logger *slog.Logger) {
 	ticker := time.NewTicker(cfg.PollInterval)
 	defer ticker.Stop()
 
-	logger.Info("dispatcher started", "poll_interval", cfg.PollInterval.String())
-
 	for {
 		select {
 		case <-ctx.Done():
-			logger.Info("dispatcher stopping", "reason", ctx.Err())
 			return
 		case <-ticker.C:
-			if err := dispatcher.RunOnce(ctx, db, client, cfg.HTTPTimeout, logger); err != nil {
-				if errors.Is(err, database.ErrNotFound) {
+			for ctx.Err() == nil {
+				err := dispatchOnce(ctx, db, client, cfg, logger)
+				if err == nil {
 					continue
 				}
-				logger.Error("dispatch iteration failed", "error", err)
+				if !errors.Is(err, database.ErrNotFound) {
+					logger.Error("dispatch iteration failed", "error", err)
+				}
+				break
+			}
+		}
+	}
+}
+
+func dispatchOnce(ctx context.Context, db database.Store, client *http.Client, cfg dispatcherConfig, logger *slog.Logger) error {
+	opts := dispatcher.Options{WebEgressTokenSecret: []byte(cfg.WebEgressTokenSecret)}
+	if cfg.Async {
+		opts.Async = &dispatcher.AsyncConfig{HeartbeatBaseURL: cfg.HeartbeatBaseURL, LeaseTTL: cfg.LeaseTTL}
+	}
+	if cfg.ArtifactUploads {
+		opts.ArtifactBaseURL = cfg.HeartbeatBaseURL
+	}
+	if cfg.workerTokens != nil {
+		opts.WorkerToken = func(node *models.Node) (string, error) { return cfg.workerTokens.GenerateWorkerToken(node.NodeSlug) }
+	}
+	return dispatcher.RunOnceWithOptions(ctx, db, client, cfg.HTTPTimeout, opts, logger)
+}
+
+// runLeaseMaintenance requeues jobs with expired leases and, in async mode, collects finished job results.
+func runLeaseMaintenance(ctx context.Context, db database.Store, client *http.Client, cfg dispatcherConfig, logger *slog.Logger) {
+	ticker := time.NewTicker(cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := dispatcher.ReclaimExpiredLeases(ctx, db, logger); err != nil {
+				logger.Error("reclaim expired job leases failed", "error", err)
+			}
+			if cfg.Async {
+				if err := dispatcher.CollectAsyncResults(ctx, db, client, logger); err != nil {
+					logger.Error("collect async job results failed", "error", err)
+				}
 			}
 		}
 	}
 }
 
+func getIntEnv(key string, def int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return def
+}
+
 func getDurationEnv(key string, def time.Duration) time.Duration {
 	if v := os.Getenv(key); v != "" {
 		if d, err := time.ParseDuration(v); err == nil {
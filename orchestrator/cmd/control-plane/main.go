@@ -6,6 +6,7 @@ import (
 	"context"
 	"errors"
 	"flag"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
@@ -15,11 +16,15 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/artifacts"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/auth"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/config"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/handlers"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/middleware"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/nodetelemetry"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/pmasubprocess"
 	readinesscheck "github.com/cypher0n3/cynodeai/orchestrator/internal/readiness"
@@ -145,26 +150,45 @@ func run(ctx context.Context, store database.Store, cfg *config.OrchestratorConf
 		return err
 	}
 
-	jwtManager := auth.NewJWTManager(
-		cfg.JWTSecret,
+	keyring, err := auth.LoadKeyring(cfg.JWTSigningKeyFiles, cfg.JWTSecret)
+	if err != nil {
+		return err
+	}
+	if len(cfg.JWTSigningKeyFiles) == 0 {
+		logger.Warn("JWT_SIGNING_KEY_FILES not set; deriving the JWT signing key from JWT_SECRET")
+	}
+	jwtManager := auth.NewJWTManagerWithKeyring(
+		keyring,
 		cfg.JWTAccessDuration,
 		cfg.JWTRefreshDuration,
 		cfg.JWTNodeDuration,
 	)
 
 	nodeHandler := handlers.NewNodeHandler(store, jwtManager, cfg.NodeRegistrationPSK, cfg.OrchestratorPublicURL, cfg.WorkerAPIBearerToken, cfg.WorkerAPITargetURL, cfg.WorkerInternalAgentToken, logger)
-	authMiddleware := middleware.NewAuthMiddleware(jwtManager, logger)
+	authMiddleware := middleware.NewAuthMiddleware(jwtManager, store, logger)
 	workflowHandler := handlers.NewWorkflowHandler(store, logger)
 	workflowAuth := middleware.RequireWorkflowRunnerAuth(cfg.WorkflowRunnerBearerToken)
+	jobLeaseHandler := handlers.NewJobLeaseHandler(store, loadDispatcherConfig().LeaseTTL, logger)
+	artifactStore, err := artifacts.New(cfg)
+	if err != nil {
+		logger.Warn("artifact store unavailable; job artifact uploads disabled", "error", err)
+	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /healthz", healthzHandler)
 	mux.HandleFunc("GET /readyz", readyzHandler(store, cfg, logger))
+	mux.HandleFunc("GET /.well-known/jwks.json", handlers.JWKS(keyring))
 
 	mux.HandleFunc("POST /v1/nodes/register", nodeHandler.Register)
 	mux.Handle("GET /v1/nodes/config", authMiddleware.RequireNodeAuth(http.HandlerFunc(nodeHandler.GetConfig)))
 	mux.Handle("POST /v1/nodes/config", authMiddleware.RequireNodeAuth(http.HandlerFunc(nodeHandler.ConfigAck)))
 	mux.Handle("POST /v1/nodes/capability", authMiddleware.RequireNodeAuth(http.HandlerFunc(nodeHandler.ReportCapability)))
+	// Worker heartbeats for async jobs; authenticated by the assigned node's Worker API bearer token.
+	mux.HandleFunc("POST /v1/jobs/{id}/heartbeat", jobLeaseHandler.Heartbeat)
+	if artifactStore != nil {
+		jobArtifactHandler := handlers.NewJobArtifactHandler(store, artifactStore, int64(cfg.ArtifactMaxSizeMB)*1024*1024, logger)
+		mux.HandleFunc("PUT /v1/jobs/{id}/artifacts/{path...}", jobArtifactHandler.PutJobArtifact)
+	}
 
 	mux.Handle("POST /v1/workflow/start", workflowAuth(http.HandlerFunc(workflowHandler.Start)))
 	mux.Handle("POST /v1/workflow/resume", workflowAuth(http.HandlerFunc(workflowHandler.Resume)))
@@ -184,7 +208,7 @@ func run(ctx context.Context, store database.Store, cfg *config.OrchestratorConf
 		MaxHeaderBytes:    cfg.MaxHeaderBytes,
 	}
 
-	go startDispatcher(ctx, store, logger)
+	go startDispatcher(ctx, store, logger, artifactStore != nil, jwtManager)
 
 	// REQ-ORCHES-0150: start PMA only when first inference path is available (worker ready and inference-capable, or API Egress key for PMA).
 	var pmaCmdMu sync.Mutex
@@ -398,30 +422,75 @@ func getEnv(key, def string) string {
 }
 
 func bootstrapAdminUser(ctx context.Context, db database.Store, password string, logger *slog.Logger) error {
-	_, err := db.GetUserByHandle(ctx, "admin")
-	if err == nil {
+	user, err := db.GetUserByHandle(ctx, "admin")
+	switch {
+	case err == nil:
+		if err := checkLocalAdminUser(ctx, db, user); err != nil {
+			return err
+		}
 		logger.Info("admin user already exists")
-		return nil
+	case !errors.Is(err, database.ErrNotFound):
+		return err
+	default:
+		if user, err = createAdminUser(ctx, db, password); err != nil {
+			return err
+		}
+		logger.Info("admin user created", "handle", "admin")
 	}
-	if !errors.Is(err, database.ErrNotFound) {
+	return ensureAdminRoleBinding(ctx, db, user.ID)
+}
+
+// checkLocalAdminUser refuses an existing "admin" user that is not the local bootstrap account: one provisioned by
+// an identity provider, or one without a password credential, must not be granted the admin role.
+func checkLocalAdminUser(ctx context.Context, db database.Store, user *models.User) error {
+	if user.ExternalSource != nil {
+		return fmt.Errorf("admin user is linked to external source %q; refusing to bootstrap it", *user.ExternalSource)
+	}
+	if _, err := db.GetPasswordCredentialByUserID(ctx, user.ID); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return errors.New("admin user has no password credential; refusing to bootstrap it")
+		}
 		return err
 	}
+	return nil
+}
 
+func createAdminUser(ctx context.Context, db database.Store, password string) (*models.User, error) {
 	user, err := db.CreateUser(ctx, "admin", nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	passwordHash, err := auth.HashPassword(password, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	_, err = db.CreatePasswordCredential(ctx, user.ID, passwordHash, "argon2id")
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return user, nil
+}
 
-	logger.Info("admin user created", "handle", "admin")
+// ensureAdminRoleBinding binds the built-in admin role to the bootstrap admin at system scope (rbac_and_groups.md)
+// so a new deployment has an administrator. It only binds a user that has never had a binding, so an admin role
+// that was later disabled stays disabled across restarts.
+func ensureAdminRoleBinding(ctx context.Context, db database.Store, userID uuid.UUID) error {
+	bound, err := db.HasAnyRoleBinding(ctx, database.AccessSubject{Type: database.SubjectTypeUser, ID: userID})
+	if err != nil || bound {
+		return err
+	}
+	updatedBy := "bootstrap"
+	b := &models.RoleBinding{
+		SubjectType: database.SubjectTypeUser,
+		SubjectID:   userID,
+		RoleID:      database.BuiltinRoles()[0].ID,
+		ScopeType:   database.ScopeTypeSystem,
+		UpdatedBy:   &updatedBy,
+	}
+	if err := db.CreateRoleBinding(ctx, b); err != nil && !errors.Is(err, database.ErrExists) {
+		return err
+	}
 	return nil
 }
@@ -28,6 +28,12 @@ import (
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
 )
 
+func TestMain(m *testing.M) {
+	// run() refuses to start with the placeholder JWT_SECRET default when no key files are configured.
+	_ = os.Setenv("JWT_SECRET", "test-jwt-secret")
+	os.Exit(m.Run())
+}
+
 const testJobPayload = `{"command":["echo","hi"]}`
 
 const testWorkerAPIURL = "http://localhost:9190"
@@ -67,6 +73,52 @@ func TestLoadDispatcherConfig(t *testing.T) {
 	}
 }
 
+func TestLoadDispatcherConfig_Workers(t *testing.T) {
+	_ = os.Unsetenv("DISPATCH_WORKERS")
+	defer func() { _ = os.Unsetenv("DISPATCH_WORKERS") }()
+	if w := loadDispatcherConfig().Workers; w != 4 {
+		t.Errorf("default Workers: %d", w)
+	}
+	_ = os.Setenv("DISPATCH_WORKERS", "8")
+	if w := loadDispatcherConfig().Workers; w != 8 {
+		t.Errorf("DISPATCH_WORKERS=8: %d", w)
+	}
+	_ = os.Setenv("DISPATCH_WORKERS", "0")
+	if w := loadDispatcherConfig().Workers; w != 1 {
+		t.Errorf("DISPATCH_WORKERS=0 should clamp to 1: %d", w)
+	}
+}
+
+func TestLoadDispatcherConfig_Async(t *testing.T) {
+	for _, k := range []string{"DISPATCH_ASYNC", "DISPATCH_LEASE_TTL", "ORCHESTRATOR_PUBLIC_URL"} {
+		_ = os.Unsetenv(k)
+		defer func(k string) { _ = os.Unsetenv(k) }(k)
+	}
+	cfg := loadDispatcherConfig()
+	if cfg.Async || cfg.LeaseTTL != time.Minute || cfg.HeartbeatBaseURL != "http://localhost:12082" {
+		t.Errorf("defaults: async=%v ttl=%v url=%q", cfg.Async, cfg.LeaseTTL, cfg.HeartbeatBaseURL)
+	}
+	_ = os.Setenv("DISPATCH_ASYNC", "true")
+	_ = os.Setenv("DISPATCH_LEASE_TTL", "90s")
+	_ = os.Setenv("ORCHESTRATOR_PUBLIC_URL", "http://control-plane:12082")
+	cfg = loadDispatcherConfig()
+	if !cfg.Async || cfg.LeaseTTL != 90*time.Second || cfg.HeartbeatBaseURL != "http://control-plane:12082" {
+		t.Errorf("overrides: async=%v ttl=%v url=%q", cfg.Async, cfg.LeaseTTL, cfg.HeartbeatBaseURL)
+	}
+}
+
+func TestGetIntEnv(t *testing.T) {
+	_ = os.Setenv("TEST_DISPATCH_INT", "bad")
+	defer func() { _ = os.Unsetenv("TEST_DISPATCH_INT") }()
+	if getIntEnv("TEST_DISPATCH_INT", 3) != 3 {
+		t.Error("invalid int should return default")
+	}
+	_ = os.Setenv("TEST_DISPATCH_INT", "5")
+	if getIntEnv("TEST_DISPATCH_INT", 3) != 5 {
+		t.Error("parse 5")
+	}
+}
+
 func TestGetDurationEnv(t *testing.T) {
 	_ = os.Unsetenv("TEST_DISPATCH_DURATION")
 	if getDurationEnv("TEST_DISPATCH_DURATION", 10*time.Second) != 10*time.Second {
@@ -360,7 +412,7 @@ func TestStartDispatcher_Disabled(t *testing.T) {
 	defer cancel()
 	mock := testutil.NewMockDB()
 	logger := slog.Default()
-	startDispatcher(ctx, mock, logger)
+	startDispatcher(ctx, mock, logger, false, nil)
 }
 
 func TestStartDispatcher_NoToken(t *testing.T) {
@@ -375,7 +427,7 @@ func TestStartDispatcher_NoToken(t *testing.T) {
 	mock := testutil.NewMockDB()
 	logger := slog.Default()
 	// Dispatcher no longer exits early when token unset (uses per-node token); run in goroutine and cancel.
-	go startDispatcher(ctx, mock, logger)
+	go startDispatcher(ctx, mock, logger, false, nil)
 	<-time.After(25 * time.Millisecond)
 	cancel()
 	<-time.After(10 * time.Millisecond)
@@ -393,7 +445,7 @@ func TestStartDispatcher_EnabledOneTick(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	mock := testutil.NewMockDB()
 	logger := slog.Default()
-	go startDispatcher(ctx, mock, logger)
+	go startDispatcher(ctx, mock, logger, false, nil)
 	// Allow one tick (dispatchOnce returns ErrNotFound when queue empty)
 	<-time.After(20 * time.Millisecond)
 	cancel()
@@ -460,7 +512,7 @@ func TestStartDispatcher_DispatchOnceReturnsError(t *testing.T) {
 	mock := &listDispatchableNodesErrorStore{MockDB: base}
 	ctx, cancel := context.WithCancel(context.Background())
 	logger := slog.Default()
-	go startDispatcher(ctx, mock, logger)
+	go startDispatcher(ctx, mock, logger, false, nil)
 	<-time.After(25 * time.Millisecond)
 	cancel()
 	<-time.After(10 * time.Millisecond)
@@ -469,12 +521,55 @@ func TestStartDispatcher_DispatchOnceReturnsError(t *testing.T) {
 func TestBootstrapAdminUser_AlreadyExists(t *testing.T) {
 	mock := testutil.NewMockDB()
 	ctx := context.Background()
-	_, _ = mock.CreateUser(ctx, "admin", nil)
+	admin, _ := mock.CreateUser(ctx, "admin", nil)
+	_, _ = mock.CreatePasswordCredential(ctx, admin.ID, []byte("hash"), "argon2id")
 	logger := slog.Default()
 	err := bootstrapAdminUser(ctx, mock, "password", logger)
 	if err != nil {
 		t.Fatalf("bootstrapAdminUser: %v", err)
 	}
+	// An existing admin without a binding (pre-RBAC deployment) gets the admin role; rerunning keeps one binding.
+	if err := bootstrapAdminUser(ctx, mock, "password", logger); err != nil {
+		t.Fatalf("bootstrapAdminUser rerun: %v", err)
+	}
+	if len(mock.RoleBindings) != 1 || mock.RoleBindings[0].RoleID != database.BuiltinRoles()[0].ID {
+		t.Errorf("role bindings = %+v", mock.RoleBindings)
+	}
+}
+
+func TestBootstrapAdminUser_RefusesNonLocalAdmin(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.Default()
+	sso := testutil.NewMockDB()
+	_, _ = sso.CreateExternalUser(ctx, "admin", nil, "https://idp.example.com", "sub-1")
+	noPassword := testutil.NewMockDB()
+	_, _ = noPassword.CreateUser(ctx, "admin", nil)
+	for name, mock := range map[string]*testutil.MockDB{"external": sso, "no password": noPassword} {
+		if err := bootstrapAdminUser(ctx, mock, "adminpass", logger); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+		if len(mock.RoleBindings) != 0 {
+			t.Errorf("%s: role bindings = %+v", name, mock.RoleBindings)
+		}
+	}
+}
+
+func TestBootstrapAdminUser_KeepsDisabledBinding(t *testing.T) {
+	mock := testutil.NewMockDB()
+	ctx := context.Background()
+	logger := slog.Default()
+	if err := bootstrapAdminUser(ctx, mock, "adminpass", logger); err != nil {
+		t.Fatalf("bootstrapAdminUser: %v", err)
+	}
+	if _, err := mock.DisableRoleBinding(ctx, mock.RoleBindings[0].ID, "admin"); err != nil {
+		t.Fatalf("DisableRoleBinding: %v", err)
+	}
+	if err := bootstrapAdminUser(ctx, mock, "adminpass", logger); err != nil {
+		t.Fatalf("bootstrapAdminUser rerun: %v", err)
+	}
+	if len(mock.RoleBindings) != 1 || mock.RoleBindings[0].IsActive {
+		t.Errorf("disabled admin binding re-granted: %+v", mock.RoleBindings)
+	}
 }
 
 func TestBootstrapAdminUser_Create(t *testing.T) {
@@ -1333,12 +1428,12 @@ func TestRunMain_RunFails(t *testing.T) {
 	}
 }
 
-// completeJobErrorStore fails CompleteJob so dispatchOnce returns error after worker succeeds.
+// completeJobErrorStore fails CompleteLeasedJob so dispatchOnce returns error after worker succeeds.
 type completeJobErrorStore struct {
 	*testutil.MockDB
 }
 
-func (m *completeJobErrorStore) CompleteJob(_ context.Context, _ uuid.UUID, _, _ string) error {
+func (m *completeJobErrorStore) CompleteLeasedJob(_ context.Context, _, _ uuid.UUID, _, _ string) error {
 	return errors.New("complete job error")
 }
 
@@ -1377,7 +1472,7 @@ type assignJobErrorStore struct {
 	*testutil.MockDB
 }
 
-func (m *assignJobErrorStore) AssignJobToNode(_ context.Context, _, _ uuid.UUID) error {
+func (m *assignJobErrorStore) AssignJobToNode(_ context.Context, _, _, _ uuid.UUID, _ string) error {
 	return errors.New("assign job error")
 }
 
@@ -0,0 +1,437 @@
+// Git egress tools (git_egress_mcp.md): git.clone, git.fetch, git.push_branch, and git.open_pr run remote Git
+// operations for a task with its user's stored provider token. Repository content moves to and from the job
+// workspace as base64 git bundles in the tool arguments and results.
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/apiegress"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/credcrypt"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/gitegress"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/handlers"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/policy"
+)
+
+// gitEgress runs the git.* tools; while nil (no credential master key configured) they answer 503.
+var gitEgress *gitTools
+
+// gitCodeNotImplemented is the error code for operations the repo's provider does not support.
+const gitCodeNotImplemented = "not_implemented"
+
+// gitToolActions maps each git tool to the access control action evaluated on the repo.
+var gitToolActions = map[string]string{
+	"git.clone":       database.ActionGitClone,
+	"git.fetch":       database.ActionGitFetch,
+	"git.push_branch": database.ActionGitPush,
+	"git.open_pr":     database.ActionGitPRCreate,
+}
+
+// gitTools holds what the git tools need besides the store: the git runner, the credential keyring, and the
+// HTTP client and optional API root override used to open pull requests.
+type gitTools struct {
+	client       *gitegress.Client
+	keys         *credcrypt.Keyring
+	http         *http.Client
+	githubAPIURL string
+	timeout      time.Duration
+}
+
+// gitToolsFromEnv configures the git tools from GIT_EGRESS_* and the credential master key. It returns nil
+// (tools disabled) when the master key is not set.
+func gitToolsFromEnv(logger *slog.Logger) (*gitTools, error) {
+	keys, err := credcrypt.FromEnv()
+	switch {
+	case errors.Is(err, credcrypt.ErrKeyNotConfigured):
+		logger.Warn("credential master key not set; git tools disabled", "env", credcrypt.EnvMasterKeyB64)
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	timeout := gitEgressTimeout()
+	return &gitTools{
+		client: &gitegress.Client{
+			AllowHTTP:      getEnv("GIT_EGRESS_ALLOW_HTTP", "") == "true",
+			MaxBundleBytes: int64(getEnvInt("GIT_EGRESS_MAX_BUNDLE_BYTES", gitegress.DefaultMaxBundleBytes)),
+		},
+		keys:         keys,
+		http:         &http.Client{Timeout: timeout},
+		githubAPIURL: getEnv("GIT_EGRESS_GITHUB_API_URL", ""),
+		timeout:      timeout,
+	}, nil
+}
+
+// gitEgressTimeout bounds one git tool call (GIT_EGRESS_TIMEOUT_SEC, default 120s); the server's write timeout
+// allows for it.
+func gitEgressTimeout() time.Duration {
+	return time.Duration(getEnvInt("GIT_EGRESS_TIMEOUT_SEC", 120)) * time.Second
+}
+
+func getEnvInt(key string, def int) int {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil && n > 0 {
+		return n
+	}
+	return def
+}
+
+// gitCall is an authorized git tool call: the target repo and the decrypted token, erased by done.
+type gitCall struct {
+	repo  gitegress.Repo
+	token []byte
+}
+
+func (c *gitCall) done() { credcrypt.Zero(c.token) }
+
+// gitResult is what a git operation returns: the tool result and audit attributes (branch, base_ref,
+// changeset_hash, result) for the git_egress_audit log line.
+type gitResult struct {
+	out   map[string]interface{}
+	attrs []any
+}
+
+type gitOperation func(ctx context.Context, g *gitTools, call *gitCall, args map[string]interface{}) (*gitResult, error)
+
+func handleGitClone(ctx context.Context, store database.Store, args map[string]interface{}, rec *models.McpToolCallAuditLog) (code int, body []byte, auditRec *models.McpToolCallAuditLog) {
+	return runGitTool(ctx, store, "git.clone", args, rec, gitFetch(false))
+}
+
+func handleGitFetch(ctx context.Context, store database.Store, args map[string]interface{}, rec *models.McpToolCallAuditLog) (code int, body []byte, auditRec *models.McpToolCallAuditLog) {
+	return runGitTool(ctx, store, "git.fetch", args, rec, gitFetch(true))
+}
+
+func handleGitPushBranch(ctx context.Context, store database.Store, args map[string]interface{}, rec *models.McpToolCallAuditLog) (code int, body []byte, auditRec *models.McpToolCallAuditLog) {
+	return runGitTool(ctx, store, "git.push_branch", args, rec, gitPushBranch)
+}
+
+func handleGitOpenPR(ctx context.Context, store database.Store, args map[string]interface{}, rec *models.McpToolCallAuditLog) (code int, body []byte, auditRec *models.McpToolCallAuditLog) {
+	return runGitTool(ctx, store, "git.open_pr", args, rec, gitOpenPR)
+}
+
+// runGitTool authorizes a git tool call for the task's user (repo policy, project allowlist, credential), runs op
+// with the decrypted token, and logs a git_egress_audit line alongside the tool call audit record.
+func runGitTool(ctx context.Context, store database.Store, tool string, args map[string]interface{}, rec *models.McpToolCallAuditLog, op gitOperation) (code int, body []byte, auditRec *models.McpToolCallAuditLog) {
+	auditRec = rec
+	rec.TaskID = uuidArg(args, "task_id")
+	g := gitEgress
+	if g == nil {
+		rec.Decision, rec.Status, rec.ErrorType = auditDecisionAllow, auditStatusError, strPtr("not_configured")
+		return http.StatusServiceUnavailable, []byte(`{"error":"git egress not configured"}`), auditRec
+	}
+	call, code, body := authorizeGitCall(ctx, store, g, tool, args, rec)
+	if call == nil {
+		slog.Info("git_egress_audit", "tool", tool, "task_id", rec.TaskID, "provider", strArg(args, "provider"),
+			"repo", strArg(args, "repo"), "decision", rec.Decision, "error_type", rec.ErrorType)
+		return code, body, auditRec
+	}
+	defer call.done()
+	opCtx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+	res, err := op(opCtx, g, call, args)
+	attrs := []any{"tool", tool, "task_id", rec.TaskID, "provider", call.repo.Provider, "repo", call.repo.Resource(), "decision", auditDecisionAllow}
+	if res != nil {
+		attrs = append(attrs, res.attrs...)
+	}
+	if err != nil {
+		code, body = gitErrorResponse(err, rec)
+		slog.Info("git_egress_audit", append(attrs, "status", auditStatusError, "error_type", *rec.ErrorType)...)
+		return code, body, auditRec
+	}
+	slog.Info("git_egress_audit", append(attrs, "status", auditStatusSuccess)...)
+	rec.Decision, rec.Status, rec.ErrorType = auditDecisionAllow, auditStatusSuccess, nil
+	res.out["provider"], res.out["repo"] = call.repo.Provider, call.repo.Identifier
+	body, _ = json.Marshal(res.out)
+	return http.StatusOK, body, auditRec
+}
+
+// authorizeGitCall resolves the task and repo, binds the caller to the task (see gitCallerBound), checks access
+// (see checkGitAccess), and decrypts the task user's provider credential. On failure it returns a nil call with the audit record and response already set.
+func authorizeGitCall(ctx context.Context, store database.Store, g *gitTools, tool string, args map[string]interface{}, rec *models.McpToolCallAuditLog) (call *gitCall, code int, body []byte) {
+	repo, err := gitegress.NormalizeRepo(strArg(args, "provider"), strArg(args, "repo"), strArg(args, "base_url"))
+	if err != nil || rec.TaskID == nil {
+		return gitDenied(rec, http.StatusBadRequest, "invalid_arguments", "task_id and a valid provider and repo required")
+	}
+	task, err := store.GetTaskByID(ctx, *rec.TaskID)
+	if err != nil {
+		return gitFailed(rec, err)
+	}
+	if task.CreatedBy == nil {
+		return gitDenied(rec, http.StatusForbidden, "credential_unavailable", "task has no owner")
+	}
+	bound, err := gitCallerBound(ctx, store, task)
+	if err != nil {
+		return gitFailed(rec, err)
+	}
+	if !bound {
+		return gitDenied(rec, http.StatusForbidden, "caller_not_allowed", "caller is neither the task's user nor the node running its job")
+	}
+	errType, msg, err := checkGitAccess(ctx, store, task, gitToolActions[tool], repo)
+	if err != nil {
+		return gitFailed(rec, err)
+	}
+	if errType != "" {
+		return gitDenied(rec, http.StatusForbidden, errType, msg)
+	}
+	token, err := openGitCredential(ctx, store, g, *task.CreatedBy, repo.Provider)
+	if errors.Is(err, database.ErrNotFound) {
+		return gitDenied(rec, http.StatusForbidden, "credential_unavailable", "no active credential for provider")
+	}
+	if err != nil {
+		return gitFailed(rec, err)
+	}
+	return &gitCall{repo: repo, token: token}, 0, nil
+}
+
+// gitCallerBound reports whether the authenticated caller may use the task's credential: the caller must be the
+// user who created the task or the node assigned to one of its running jobs.
+func gitCallerBound(ctx context.Context, store database.Store, task *models.Task) (bool, error) {
+	if userID := handlers.GetUserIDFromContext(ctx); userID != nil {
+		return *userID == *task.CreatedBy, nil
+	}
+	nodeID := handlers.GetNodeIDFromContext(ctx)
+	if nodeID == nil {
+		return false, nil
+	}
+	jobs, err := store.GetJobsByTaskID(ctx, task.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, j := range jobs {
+		if j.Status == models.JobStatusRunning && j.NodeID != nil && *j.NodeID == *nodeID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func gitDenied(rec *models.McpToolCallAuditLog, status int, errType, msg string) (call *gitCall, code int, body []byte) {
+	rec.Decision, rec.Status, rec.ErrorType = auditDecisionDeny, auditStatusError, strPtr(errType)
+	body, _ = json.Marshal(map[string]string{"error": msg})
+	return nil, status, body
+}
+
+func gitFailed(rec *models.McpToolCallAuditLog, err error) (call *gitCall, code int, body []byte) {
+	code, body = writePreferenceErrToAudit(err, rec)
+	return nil, code, body
+}
+
+// checkGitAccess requires an allow rule for action on the repo and the repo's presence in the task project's
+// project_git_repos (REQ-APIEGR-0127); tasks without a project are denied. It returns the audit error type and
+// message of a denial, or "".
+func checkGitAccess(ctx context.Context, store database.Store, task *models.Task, action string, repo gitegress.Repo) (errType, msg string, err error) {
+	if task.ProjectID == nil {
+		return "repo_not_allowed", "git tools require a task in a project", nil
+	}
+	d, err := decideGitAction(ctx, store, task, action, repo)
+	if err != nil {
+		return "", "", err
+	}
+	if !d.Allowed {
+		return "policy_denied", d.Reason, nil
+	}
+	ok, err := projectHasRepo(ctx, store, *task.ProjectID, repo)
+	if err != nil || ok {
+		return "", "", err
+	}
+	return "repo_not_allowed", "repo is not associated with the task's project", nil
+}
+
+// decideGitAction evaluates action on the repo (resource host/identifier) for the task's user. There is no seeded
+// rule, so Git egress is denied until granted.
+func decideGitAction(ctx context.Context, store database.Store, task *models.Task, action string, repo gitegress.Repo) (policy.Decision, error) {
+	engine := policy.New(store)
+	principal, err := engine.PrincipalForUser(ctx, *task.CreatedBy)
+	if err != nil {
+		return policy.Decision{}, err
+	}
+	req := &policy.Request{
+		Principal:    principal,
+		Action:       action,
+		ResourceType: database.ResourceTypeGitRepo,
+		Resource:     repo.Resource(),
+		Context:      policy.Context{ProjectID: task.ProjectID},
+	}
+	if task.TaskType != nil {
+		req.Context.TaskType = *task.TaskType
+	}
+	return engine.Decide(ctx, req)
+}
+
+// projectHasRepo reports whether repo is one of the project's project_git_repos (REQ-APIEGR-0127).
+func projectHasRepo(ctx context.Context, store database.Store, projectID uuid.UUID, repo gitegress.Repo) (bool, error) {
+	repos, err := store.ListProjectGitRepos(ctx, projectID)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range repos {
+		var base string
+		if r.BaseURL != nil {
+			base = *r.BaseURL
+		}
+		if repo.Matches(r.Provider, r.RepoIdentifier, base) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// openGitCredential decrypts userID's active credential for provider (user-owned first, then group-owned).
+func openGitCredential(ctx context.Context, store database.Store, g *gitTools, userID uuid.UUID, provider string) ([]byte, error) {
+	cred, err := store.GetActiveApiCredentialForUserAndProvider(ctx, userID, provider)
+	if err != nil {
+		return nil, err
+	}
+	var kid string
+	if cred.CredentialKID != nil {
+		kid = *cred.CredentialKID
+	}
+	return g.keys.Open(cred.ID, kid, cred.CredentialCiphertext)
+}
+
+// gitErrorResponse maps a git or provider failure to a status and {"error","code"} body and fills the audit record.
+func gitErrorResponse(err error, rec *models.McpToolCallAuditLog) (code int, body []byte) {
+	var msg, errType string
+	code = http.StatusBadGateway
+	var apiErr *apiegress.Error
+	if errors.As(err, &apiErr) {
+		msg, errType, code = apiErr.Message, apiErr.Code, apiErr.HTTPStatus
+	} else {
+		e := gitegress.AsError(err)
+		msg, errType = e.Message, e.Code
+		switch e.Code {
+		case gitegress.CodeInvalidArguments, gitegress.CodeInvalidBundle:
+			code = http.StatusBadRequest
+		case gitegress.CodeBundleTooLarge:
+			code = http.StatusRequestEntityTooLarge
+		case gitegress.CodeNotFound:
+			code = http.StatusNotFound
+		case gitegress.CodePushRejected:
+			code = http.StatusConflict
+		case gitCodeNotImplemented:
+			code = http.StatusNotImplemented
+		}
+	}
+	rec.Decision, rec.Status, rec.ErrorType = auditDecisionAllow, auditStatusError, strPtr(errType)
+	if code == http.StatusBadRequest {
+		rec.Decision = auditDecisionDeny
+	}
+	body, _ = json.Marshal(map[string]string{"error": msg, "code": errType})
+	return code, body
+}
+
+// gitFetch returns git.clone (withHave false) or git.fetch: export branch ref (default branch when empty) as a
+// bundle. git.fetch accepts have, the commit ids the workspace already holds, and omits the bundle when up to date.
+func gitFetch(withHave bool) gitOperation {
+	return func(ctx context.Context, g *gitTools, call *gitCall, args map[string]interface{}) (*gitResult, error) {
+		var have []string
+		if withHave {
+			var ok bool
+			if have, ok = strSliceArg(args, "have"); !ok {
+				return nil, &gitegress.Error{Code: gitegress.CodeInvalidArguments, Message: "have must be an array of commit ids"}
+			}
+		}
+		b, err := g.client.Fetch(ctx, call.repo, call.token, strArg(args, "ref"), have)
+		if err != nil {
+			return &gitResult{attrs: []any{"branch", strArg(args, "ref")}}, err
+		}
+		out := map[string]interface{}{"branch": b.Branch, "commit": b.Commit}
+		if withHave {
+			out["up_to_date"] = b.UpToDate
+		}
+		if !b.UpToDate {
+			out["bundle_base64"] = base64.StdEncoding.EncodeToString(b.Data)
+			out["bundle_sha256"] = b.SHA256
+			out["size_bytes"] = len(b.Data)
+		}
+		return &gitResult{out: out, attrs: []any{"branch", b.Branch, "changeset_hash", b.SHA256, "result", b.Commit}}, nil
+	}
+}
+
+// gitPushBranch imports branch from bundle_base64 (which may build on base_ref) and pushes it without force.
+func gitPushBranch(ctx context.Context, g *gitTools, call *gitCall, args map[string]interface{}) (*gitResult, error) {
+	branch, baseRef := strArg(args, "branch"), strArg(args, "base_ref")
+	bundle, err := base64.StdEncoding.DecodeString(strArg(args, "bundle_base64"))
+	if branch == "" || len(bundle) == 0 || err != nil {
+		return nil, &gitegress.Error{Code: gitegress.CodeInvalidArguments, Message: "branch and bundle_base64 required"}
+	}
+	sum := gitegress.BundleSHA256(bundle)
+	res := &gitResult{attrs: []any{"branch", branch, "base_ref", baseRef, "changeset_hash", sum}}
+	commit, err := g.client.PushBranch(ctx, call.repo, call.token, bundle, branch, baseRef)
+	if err != nil {
+		return res, err
+	}
+	res.out = map[string]interface{}{"branch": branch, "commit": commit, "bundle_sha256": sum}
+	res.attrs = append(res.attrs, "result", commit)
+	return res, nil
+}
+
+// gitOpenPR opens a pull request from head into base (the repo's default branch when empty). GitHub only.
+func gitOpenPR(ctx context.Context, g *gitTools, call *gitCall, args map[string]interface{}) (*gitResult, error) {
+	head, base, title := strArg(args, "head"), strArg(args, "base"), strArg(args, "title")
+	res := &gitResult{attrs: []any{"branch", head, "base_ref", base}}
+	if call.repo.Provider != gitegress.ProviderGitHub {
+		return res, &gitegress.Error{Code: gitCodeNotImplemented, Message: "git.open_pr supports github only"}
+	}
+	if head == "" || title == "" {
+		return res, &gitegress.Error{Code: gitegress.CodeInvalidArguments, Message: "head and title required"}
+	}
+	if base == "" {
+		var err error
+		if base, err = g.client.DefaultBranch(ctx, call.repo, call.token); err != nil {
+			return res, err
+		}
+	}
+	owner, name, _ := strings.Cut(call.repo.Identifier, "/")
+	draft, _ := args["draft"].(bool)
+	params, _ := json.Marshal(map[string]interface{}{
+		"owner": owner, "repo": name, "title": title, "head": head, "base": base, "body": strArg(args, "body"), "draft": draft,
+	})
+	apiURL := g.githubAPIURL
+	if apiURL == "" {
+		apiURL = call.repo.GitHubAPIURL()
+	}
+	reg := apiegress.NewRegistry(g.http, apiegress.NewGitHub(apiURL))
+	pr, err := reg.Call(ctx, apiegress.ProviderGitHub, "create_pull_request", params, call.token)
+	if err != nil {
+		return res, err
+	}
+	var created struct {
+		HTMLURL string `json:"html_url"`
+	}
+	_ = json.Unmarshal(pr, &created)
+	res.out = map[string]interface{}{"head": head, "base": base, "pull_request": pr}
+	res.attrs = append(res.attrs, "result", created.HTMLURL)
+	return res, nil
+}
+
+// strSliceArg returns args[key] as strings; ok is false when it is present but not an array of strings.
+func strSliceArg(args map[string]interface{}, key string) (out []string, ok bool) {
+	v, present := args[key]
+	if !present || v == nil {
+		return nil, true
+	}
+	items, isArray := v.([]interface{})
+	if !isArray {
+		return nil, false
+	}
+	for _, item := range items {
+		s, isString := item.(string)
+		if !isString {
+			return nil, false
+		}
+		out = append(out, s)
+	}
+	return out, true
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/credcrypt"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/gitegress"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/handlers"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
+)
+
+const gitTestToken = "ghp_gateway"
+
+// gitEnv is a task whose user holds a GitHub token for octo/hello on a stand-in Git host, the repo in the task's
+// project, and a stub GitHub API that records the pull request it is asked to open.
+type gitEnv struct {
+	mock    *testutil.MockDB
+	srv     *testutil.GitServer
+	initial string
+	taskID  uuid.UUID
+	userID  uuid.UUID
+	pull    map[string]interface{}
+	// caller sets the authenticated caller on a tool call's context; the task's user by default.
+	caller func(context.Context) context.Context
+}
+
+func newGitEnv(t *testing.T) *gitEnv {
+	t.Helper()
+	e := &gitEnv{srv: testutil.NewGitServer(t, gitTestToken), mock: testutil.NewMockDB()}
+	e.initial = e.srv.CreateRepo(t, "octo/hello")
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/repos/octo/hello/pulls" || r.Header.Get("Authorization") != "Bearer "+gitTestToken {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &e.pull)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"number":7,"title":"Fix","state":"open","html_url":"https://github.example/octo/hello/pull/7"}`))
+	}))
+	t.Cleanup(api.Close)
+	keys, err := credcrypt.New("k1", bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gitEgress = &gitTools{client: &gitegress.Client{AllowHTTP: true}, keys: keys, http: api.Client(), githubAPIURL: api.URL, timeout: time.Minute}
+	t.Cleanup(func() { gitEgress = nil })
+
+	ctx := context.Background()
+	user, _ := e.mock.CreateUser(ctx, "dev", nil)
+	e.userID = user.ID
+	e.caller = func(ctx context.Context) context.Context { return handlers.SetUserContext(ctx, user.ID, user.Handle) }
+	projectID := uuid.New()
+	task, _ := e.mock.CreateTask(ctx, &user.ID, "fix it", nil, &projectID)
+	e.taskID = task.ID
+	base := e.srv.URL
+	e.mock.ProjectGitRepos = append(e.mock.ProjectGitRepos, &models.ProjectGitRepo{
+		ID: uuid.New(), ProjectID: projectID, Provider: "github", RepoIdentifier: "octo/hello", BaseURL: &base,
+	})
+	cred := &models.ApiCredential{ID: uuid.New(), OwnerType: database.SubjectTypeUser, OwnerID: user.ID, Provider: "github", IsActive: true}
+	cred.CredentialCiphertext, _, err = keys.Seal(cred.ID, []byte(gitTestToken))
+	if err != nil {
+		t.Fatal(err)
+	}
+	kid := "k1"
+	cred.CredentialKID = &kid
+	e.mock.ApiCredentials = append(e.mock.ApiCredentials, cred)
+	for _, action := range gitToolActions {
+		e.allow(action, "*/octo/hello")
+	}
+	return e
+}
+
+func (e *gitEnv) allow(action, pattern string) {
+	e.mock.AccessControlRules = append(e.mock.AccessControlRules, &models.AccessControlRule{
+		ID: uuid.New(), SubjectType: database.SubjectTypeUser, SubjectID: &e.userID, Action: action,
+		ResourceType: database.ResourceTypeGitRepo, ResourcePattern: pattern, Effect: "allow", Priority: 10,
+	})
+}
+
+// call invokes tool for the task on octo/hello and decodes the result.
+func (e *gitEnv) call(t *testing.T, tool string, args map[string]interface{}) (int, map[string]interface{}) {
+	t.Helper()
+	all := map[string]interface{}{"task_id": e.taskID.String(), "provider": "github", "repo": "octo/hello", "base_url": e.srv.URL}
+	for k, v := range args {
+		all[k] = v
+	}
+	body, _ := json.Marshal(map[string]interface{}{"tool_name": tool, "arguments": all})
+	req := httptest.NewRequest(http.MethodPost, "/v1/mcp/tools/call", bytes.NewReader(body))
+	req = req.WithContext(e.caller(req.Context()))
+	rec := httptest.NewRecorder()
+	toolCallHandler(e.mock, slog.Default())(rec, req)
+	var out map[string]interface{}
+	_ = json.Unmarshal(rec.Body.Bytes(), &out)
+	return rec.Code, out
+}
+
+// lastAudit returns the most recent tool call audit record.
+func (e *gitEnv) lastAudit(t *testing.T) *models.McpToolCallAuditLog {
+	t.Helper()
+	if len(e.mock.McpToolCallAuditLogs) == 0 {
+		t.Fatal("no audit record written")
+	}
+	return e.mock.McpToolCallAuditLogs[len(e.mock.McpToolCallAuditLogs)-1]
+}
+
+func TestGitTools_CloneFetchPushOpenPR(t *testing.T) {
+	e := newGitEnv(t)
+	code, out := e.call(t, "git.clone", nil)
+	if code != http.StatusOK || out["commit"] != e.initial || out["branch"] != "main" {
+		t.Fatalf("git.clone: %d %v", code, out)
+	}
+	if a := e.lastAudit(t); a.ToolName != "git.clone" || a.Decision != auditDecisionAllow || a.Status != auditStatusSuccess || *a.TaskID != e.taskID {
+		t.Errorf("clone audit = %+v", a)
+	}
+	bundle, _ := base64.StdEncoding.DecodeString(out["bundle_base64"].(string))
+
+	code, out = e.call(t, "git.fetch", map[string]interface{}{"ref": "main", "have": []string{e.initial}})
+	if code != http.StatusOK || out["up_to_date"] != true || out["bundle_base64"] != nil {
+		t.Fatalf("git.fetch: %d %v", code, out)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "in.bundle"), bundle, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	work := filepath.Join(dir, "work")
+	testutil.Git(t, dir, "clone", "--quiet", "--branch", "main", "in.bundle", work)
+	testutil.Git(t, work, "checkout", "--quiet", "-b", "fix")
+	if err := os.WriteFile(filepath.Join(work, "fix.txt"), []byte("fix\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	testutil.Git(t, work, "add", "fix.txt")
+	testutil.Git(t, work, "commit", "--quiet", "-m", "fix")
+	testutil.Git(t, work, "bundle", "create", "--quiet", "../out.bundle", "fix", "^"+e.initial)
+	outBundle, _ := os.ReadFile(filepath.Join(dir, "out.bundle"))
+
+	code, out = e.call(t, "git.push_branch", map[string]interface{}{
+		"branch": "fix", "base_ref": "main", "bundle_base64": base64.StdEncoding.EncodeToString(outBundle),
+	})
+	if want := testutil.Git(t, work, "rev-parse", "HEAD"); code != http.StatusOK || out["commit"] != want {
+		t.Fatalf("git.push_branch: %d %v, want commit %s", code, out, want)
+	}
+
+	code, out = e.call(t, "git.open_pr", map[string]interface{}{"head": "fix", "title": "Fix"})
+	if code != http.StatusOK || out["base"] != "main" || e.pull["head"] != "fix" || e.pull["base"] != "main" {
+		t.Fatalf("git.open_pr: %d %v, request %v", code, out, e.pull)
+	}
+	if pr, _ := out["pull_request"].(map[string]interface{}); pr["number"] != float64(7) {
+		t.Errorf("pull_request = %v", out["pull_request"])
+	}
+	if n := len(e.mock.McpToolCallAuditLogs); n != 4 {
+		t.Errorf("audit records = %d, want 4", n)
+	}
+}
+
+func TestGitTools_Denied(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		setup    func(e *gitEnv)
+		args     map[string]interface{}
+		wantCode int
+		wantErr  string
+	}{
+		{"no policy rule", func(e *gitEnv) { e.mock.AccessControlRules = nil }, nil, http.StatusForbidden, "policy_denied"},
+		{"no project", func(e *gitEnv) { e.mock.Tasks[e.taskID].ProjectID = nil }, nil, http.StatusForbidden, "repo_not_allowed"},
+		{"other repo", func(e *gitEnv) { e.allow(database.ActionGitClone, "*") }, map[string]interface{}{"repo": "octo/other"}, http.StatusForbidden, "repo_not_allowed"},
+		{"no credential", func(e *gitEnv) { e.mock.ApiCredentials = nil }, nil, http.StatusForbidden, "credential_unavailable"},
+		{"other user", func(e *gitEnv) { e.caller = asUser(uuid.New()) }, nil, http.StatusForbidden, "caller_not_allowed"},
+		{"unassigned node", func(e *gitEnv) { e.caller = asNode(uuid.New()) }, nil, http.StatusForbidden, "caller_not_allowed"},
+		{"no caller", func(e *gitEnv) { e.caller = func(ctx context.Context) context.Context { return ctx } }, nil, http.StatusForbidden, "caller_not_allowed"},
+		{"bad repo", nil, map[string]interface{}{"repo": "octo"}, http.StatusBadRequest, "invalid_arguments"},
+		{"not configured", func(*gitEnv) { gitEgress = nil }, nil, http.StatusServiceUnavailable, "not_configured"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			e := newGitEnv(t)
+			if tc.setup != nil {
+				tc.setup(e)
+			}
+			if code, out := e.call(t, "git.clone", tc.args); code != tc.wantCode {
+				t.Errorf("code = %d (%v), want %d", code, out, tc.wantCode)
+			}
+			if a := e.lastAudit(t); a.ErrorType == nil || *a.ErrorType != tc.wantErr {
+				t.Errorf("audit = %+v, want error_type %s", a, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestGitTools_NodeRunningTaskJob(t *testing.T) {
+	e := newGitEnv(t)
+	ctx := context.Background()
+	nodeID := uuid.New()
+	job, _ := e.mock.CreateJob(ctx, e.taskID, "{}")
+	leaseID := uuid.New()
+	job.LeaseID = &leaseID
+	_ = e.mock.AssignJobToNode(ctx, job.ID, leaseID, nodeID, "test")
+	e.caller = asNode(nodeID)
+	if code, out := e.call(t, "git.clone", nil); code != http.StatusOK {
+		t.Errorf("running job: %d %v", code, out)
+	}
+	_ = e.mock.UpdateJobStatus(ctx, job.ID, models.JobStatusCompleted)
+	if code, out := e.call(t, "git.clone", nil); code != http.StatusForbidden {
+		t.Errorf("completed job: %d %v", code, out)
+	}
+}
+
+func asUser(id uuid.UUID) func(context.Context) context.Context {
+	return func(ctx context.Context) context.Context { return handlers.SetUserContext(ctx, id, "someone") }
+}
+
+func asNode(id uuid.UUID) func(context.Context) context.Context {
+	return func(ctx context.Context) context.Context { return handlers.SetNodeContext(ctx, id, "node-1") }
+}
+
+func TestToolCallHandler_BodyTooLarge(t *testing.T) {
+	e := newGitEnv(t)
+	gitEgress.client.MaxBundleBytes = 3
+	big := strings.Repeat("A", int(maxToolCallBytes())+1)
+	body := `{"tool_name":"git.push_branch","arguments":{"bundle_base64":"` + big + `"}}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/mcp/tools/call", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	toolCallHandler(e.mock, slog.Default())(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want 413", rec.Code)
+	}
+}
+
+func TestGitTools_GitErrors(t *testing.T) {
+	e := newGitEnv(t)
+	code, out := e.call(t, "git.push_branch", map[string]interface{}{"branch": "fix", "bundle_base64": "bm90IGEgYnVuZGxl"})
+	if code != http.StatusBadRequest || out["code"] != gitegress.CodeInvalidBundle {
+		t.Errorf("bad bundle: %d %v", code, out)
+	}
+	e.srv.Token = "rotated"
+	code, out = e.call(t, "git.fetch", map[string]interface{}{"ref": "main"})
+	if code != http.StatusBadGateway || out["code"] != gitegress.CodeAuthFailed {
+		t.Errorf("bad token: %d %v", code, out)
+	}
+	if a := e.lastAudit(t); a.Decision != auditDecisionAllow || a.Status != auditStatusError {
+		t.Errorf("audit = %+v", a)
+	}
+}
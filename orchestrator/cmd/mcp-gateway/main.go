@@ -4,19 +4,27 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/cypher0n3/cynodeai/go_shared_libs/redact"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/auth"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/gitegress"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/middleware"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/policy"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/skillscan"
 )
 
@@ -48,6 +56,10 @@ var testDatabaseOpen func(ctx context.Context, dsn string) (database.Store, erro
 // run sets up and runs the server until ctx is canceled. Used by main and tests.
 // When DATABASE_URL is set (or testStore/testDatabaseOpen is set in tests), tool-call handler writes audit records.
 func run(ctx context.Context, logger *slog.Logger) error {
+	jwksURL := getEnv("MCP_GATEWAY_JWKS_URL", "")
+	if jwksURL == "" {
+		return errors.New("MCP_GATEWAY_JWKS_URL is required: tool calls must carry an orchestrator-signed token")
+	}
 	var store database.Store
 	switch {
 	case testStore != nil:
@@ -76,12 +88,20 @@ func run(ctx context.Context, logger *slog.Logger) error {
 		}
 	}
 
+	if store != nil && gitEgress == nil {
+		g, err := gitToolsFromEnv(logger)
+		if err != nil {
+			return err
+		}
+		gitEgress = g
+	}
+
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("ok"))
 	})
-	mux.HandleFunc("POST /v1/mcp/tools/call", toolCallHandler(store, logger))
+	mux.Handle("POST /v1/mcp/tools/call", toolCallRoute(store, jwksURL, logger))
 
 	handler := middleware.Logging(logger)(mux)
 	srv := &http.Server{
@@ -89,7 +109,7 @@ func run(ctx context.Context, logger *slog.Logger) error {
 		Handler:           handler,
 		ReadHeaderTimeout: 30 * time.Second,
 		ReadTimeout:       30 * time.Second,
-		WriteTimeout:      30 * time.Second,
+		WriteTimeout:      gitEgressTimeout() + 15*time.Second,
 		IdleTimeout:       120 * time.Second,
 		MaxHeaderBytes:    1 << 20,
 	}
@@ -114,6 +134,15 @@ func run(ctx context.Context, logger *slog.Logger) error {
 	return srv.Shutdown(shutdownCtx)
 }
 
+// toolCallRoute returns the tool call handler. Callers must present a user access or node token signed by the
+// orchestrator; the gateway verifies it against the JWKS at jwksURL and holds no signing key. Access tokens of
+// revoked sessions are refused through the store's deny-list; without a store every tool call answers 503.
+func toolCallRoute(store database.Store, jwksURL string, logger *slog.Logger) http.Handler {
+	logger.Info("mcp-gateway requires orchestrator-signed tokens", "jwks_url", jwksURL)
+	verifier := auth.NewRemoteVerifier(jwksURL)
+	return middleware.RequireSignedToken(verifier, store, auth.TokenTypeAccess, auth.TokenTypeNode)(toolCallHandler(store, logger))
+}
+
 // shutdownTimeout returns server shutdown timeout from env or default. Used by run and tests.
 func shutdownTimeout() time.Duration {
 	const defaultSec = 10
@@ -154,6 +183,10 @@ var requiredScopedIds = map[string]struct{ TaskID, RunID, JobID bool }{
 	"skills.get":              {TaskID: true},
 	"skills.update":           {TaskID: true},
 	"skills.delete":           {TaskID: true},
+	"git.clone":               {TaskID: true},
+	"git.fetch":               {TaskID: true},
+	"git.push_branch":         {TaskID: true},
+	"git.open_pr":             {TaskID: true},
 }
 
 // validateRequiredScopedIds returns an error message if required scoped ids are missing or invalid for the tool.
@@ -185,6 +218,7 @@ func writeDenyAuditAndRespond(ctx context.Context, w http.ResponseWriter, store
 		RunID:     uuidArg(args, "run_id"),
 		JobID:     uuidArg(args, "job_id"),
 	}
+	recordAuditArguments(rec, args)
 	if err := store.CreateMcpToolCallAuditLog(ctx, rec); err != nil {
 		logger.Error("create mcp tool call audit log", "error", err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -196,10 +230,141 @@ func writeDenyAuditAndRespond(ctx context.Context, w http.ResponseWriter, store
 	return true
 }
 
-// routeAndWriteAudit runs the tool, fills audit scoped ids and duration, writes audit, and sends the response.
-func routeAndWriteAudit(ctx context.Context, w http.ResponseWriter, store database.Store, logger *slog.Logger, toolName string, args map[string]interface{}, start time.Time) {
+// checkToolPolicy evaluates mcp.tool.invoke on toolName for the user behind task_id (system rules only when the
+// call has no task). On deny it writes a deny audit record and a 403. Returns the evaluated request (for auditing
+// the principal) and true when the call may proceed.
+func checkToolPolicy(ctx context.Context, w http.ResponseWriter, store database.Store, logger *slog.Logger, toolName string, args map[string]interface{}, size int64) (*policy.Request, bool) {
+	engine := policy.New(store)
+	req := &policy.Request{
+		Action:       database.ActionMcpToolInvoke,
+		ResourceType: database.ResourceTypeMcpTool,
+		Resource:     toolName,
+		Context:      policy.Context{RequestBytes: size},
+	}
+	if taskID := uuidArg(args, "task_id"); taskID != nil {
+		if task, err := store.GetTaskByID(ctx, *taskID); err == nil {
+			req.Context.ProjectID = task.ProjectID
+			if task.TaskType != nil {
+				req.Context.TaskType = *task.TaskType
+			}
+			if task.CreatedBy != nil {
+				principal, err := engine.PrincipalForUser(ctx, *task.CreatedBy)
+				if err != nil {
+					logger.Error("resolve policy principal", "error", err)
+					w.WriteHeader(http.StatusInternalServerError)
+					return nil, false
+				}
+				req.Principal = principal
+			}
+		}
+	}
+	d, err := engine.Decide(ctx, req)
+	if err != nil {
+		logger.Error("evaluate mcp tool policy", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, false
+	}
+	if d.Allowed {
+		return req, true
+	}
+	rec := &models.McpToolCallAuditLog{
+		ToolName:  toolName,
+		Decision:  auditDecisionDeny,
+		Status:    auditStatusError,
+		ErrorType: strPtr("policy_denied"),
+		TaskID:    uuidArg(args, "task_id"),
+		RunID:     uuidArg(args, "run_id"),
+		JobID:     uuidArg(args, "job_id"),
+	}
+	auditPrincipal(rec, req)
+	recordAuditArguments(rec, args)
+	if err := store.CreateMcpToolCallAuditLog(ctx, rec); err != nil {
+		logger.Error("create mcp tool call audit log", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	_, _ = w.Write([]byte(`{"error":"` + d.Reason + `"}`))
+	return nil, false
+}
+
+// auditPrincipal records the acting user, groups, roles, and project of an evaluated policy request.
+func auditPrincipal(rec *models.McpToolCallAuditLog, req *policy.Request) {
+	if req == nil {
+		return
+	}
+	p := req.Principal
+	rec.UserID = p.UserID
+	if rec.ProjectID == nil {
+		rec.ProjectID = req.Context.ProjectID
+	}
+	if p.UserID == nil {
+		return
+	}
+	groupIDs := p.GroupIDs
+	if groupIDs == nil {
+		groupIDs = []uuid.UUID{}
+	}
+	roleNames := p.RoleNames
+	if roleNames == nil {
+		roleNames = []string{}
+	}
+	g, _ := json.Marshal(groupIDs)
+	r, _ := json.Marshal(roleNames)
+	rec.GroupIDs, rec.RoleNames = strPtr(string(g)), strPtr(string(r))
+}
+
+// maxAuditArgBytes bounds each string argument kept in the audit record; longer values (such as git bundles)
+// are replaced by a note of their size.
+const maxAuditArgBytes = 4096
+
+// recordAuditArguments stores args on rec with secrets redacted (redact.Default) and long strings omitted, and
+// lists the redaction kinds found (mcp_tool_call_auditing.md Payload storage).
+func recordAuditArguments(rec *models.McpToolCallAuditLog, args map[string]interface{}) {
+	if len(args) == 0 {
+		return
+	}
+	v, kinds := redact.Default.Value(omitLongStrings(args))
+	b, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	rec.Arguments = strPtr(string(b))
+	if len(kinds) > 0 {
+		k, _ := json.Marshal(kinds)
+		rec.RedactionKinds = strPtr(string(k))
+	}
+}
+
+func omitLongStrings(v interface{}) interface{} {
+	switch t := v.(type) {
+	case string:
+		if len(t) > maxAuditArgBytes {
+			return fmt.Sprintf("[omitted %d bytes]", len(t))
+		}
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, item := range t {
+			out[k] = omitLongStrings(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, item := range t {
+			out[i] = omitLongStrings(item)
+		}
+		return out
+	}
+	return v
+}
+
+// routeAndWriteAudit runs the tool, fills audit scoped ids, principal, and duration, writes audit, and sends the
+// response.
+func routeAndWriteAudit(ctx context.Context, w http.ResponseWriter, store database.Store, logger *slog.Logger, toolName string, args map[string]interface{}, req *policy.Request, start time.Time) {
 	code, body, rec := routeToolCall(ctx, store, toolName, args)
 	rec.ToolName = toolName
+	auditPrincipal(rec, req)
 	if rec.TaskID == nil {
 		rec.TaskID = uuidArg(args, "task_id")
 	}
@@ -213,6 +378,7 @@ func routeAndWriteAudit(ctx context.Context, w http.ResponseWriter, store databa
 		ms := int(time.Since(start).Milliseconds())
 		rec.DurationMs = &ms
 	}
+	recordAuditArguments(rec, args)
 	if err := store.CreateMcpToolCallAuditLog(ctx, rec); err != nil {
 		logger.Error("create mcp tool call audit log", "error", err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -223,8 +389,21 @@ func routeAndWriteAudit(ctx context.Context, w http.ResponseWriter, store databa
 	_, _ = w.Write(body)
 }
 
+// toolCallOverheadBytes is the room a tool call body has beyond its largest argument, a base64 git bundle.
+const toolCallOverheadBytes = 1 << 20
+
+// maxToolCallBytes bounds a tool call body: a base64 git bundle at the bundle limit plus the other arguments.
+func maxToolCallBytes() int64 {
+	limit := int64(gitegress.DefaultMaxBundleBytes)
+	if g := gitEgress; g != nil {
+		limit = g.client.BundleLimit()
+	}
+	return int64(base64.StdEncoding.EncodedLen(int(limit))) + toolCallOverheadBytes
+}
+
 // toolCallHandler writes an audit record for every tool call (P2-02) and routes db.preference.* tools (P2-03).
 // P2-01: enforces required scoped ids (task_id/run_id/job_id) per tool before routing; rejects with 400 when missing.
+// Access control (mcp.tool.invoke) is evaluated after scoped ids; denied calls get 403.
 func toolCallHandler(store database.Store, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -236,8 +415,18 @@ func toolCallHandler(store database.Store, logger *slog.Logger) http.HandlerFunc
 			_, _ = w.Write([]byte("database not configured"))
 			return
 		}
+		raw, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxToolCallBytes()))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				return
+			}
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
 		var req toolCallRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if err := json.Unmarshal(raw, &req); err != nil {
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
@@ -253,7 +442,11 @@ func toolCallHandler(store database.Store, logger *slog.Logger) http.HandlerFunc
 			writeDenyAuditAndRespond(r.Context(), w, store, logger, toolName, args, errMsg)
 			return
 		}
-		routeAndWriteAudit(r.Context(), w, store, logger, toolName, args, time.Now())
+		policyReq, ok := checkToolPolicy(r.Context(), w, store, logger, toolName, args, int64(len(raw)))
+		if !ok {
+			return
+		}
+		routeAndWriteAudit(r.Context(), w, store, logger, toolName, args, policyReq, time.Now())
 	}
 }
 
@@ -296,6 +489,14 @@ func routeToolCall(ctx context.Context, store database.Store, toolName string, a
 		code, body, rec = handleSkillsUpdate(ctx, store, args, rec)
 	case "skills.delete":
 		code, body, rec = handleSkillsDelete(ctx, store, args, rec)
+	case "git.clone":
+		code, body, rec = handleGitClone(ctx, store, args, rec)
+	case "git.fetch":
+		code, body, rec = handleGitFetch(ctx, store, args, rec)
+	case "git.push_branch":
+		code, body, rec = handleGitPushBranch(ctx, store, args, rec)
+	case "git.open_pr":
+		code, body, rec = handleGitOpenPR(ctx, store, args, rec)
 	default:
 		code = http.StatusNotImplemented
 		body = []byte(`{"error":"tool routing not implemented"}`)
@@ -752,7 +953,14 @@ func handleSkillsCreate(ctx context.Context, store database.Store, args map[stri
 	if scope == "" {
 		scope = "user"
 	}
+	groupID, code, body := skillGroupArg(ctx, store, args, scope, *userID, rec)
+	if code != 0 {
+		return code, body, auditRec
+	}
 	skill, err := store.CreateSkill(ctx, name, content, scope, userID, false)
+	if err == nil && groupID != nil {
+		skill, err = store.SetSkillGroup(ctx, skill.ID, groupID)
+	}
 	if err != nil {
 		rec.Decision = auditDecisionAllow
 		rec.Status = auditStatusError
@@ -850,7 +1058,7 @@ func handleSkillsGet(ctx context.Context, store database.Store, args map[string]
 		code, body := writePreferenceErrToAudit(err, rec)
 		return code, body, auditRec
 	}
-	if !skill.IsSystem && (skill.OwnerID == nil || *skill.OwnerID != *userID) {
+	if !skillVisibleTo(ctx, store, skill, *userID) {
 		rec.Decision = auditDecisionAllow
 		rec.Status = auditStatusError
 		rec.ErrorType = strPtr("not_found")
@@ -899,12 +1107,18 @@ func handleSkillsUpdate(ctx context.Context, store database.Store, args map[stri
 		code, body := writePreferenceErrToAudit(err, rec)
 		return code, body, auditRec
 	}
-	if !skill.IsSystem && (skill.OwnerID == nil || *skill.OwnerID != *userID) {
+	if !skillVisibleTo(ctx, store, skill, *userID) {
 		rec.Decision = auditDecisionAllow
 		rec.Status = auditStatusError
 		rec.ErrorType = strPtr("not_found")
 		return http.StatusNotFound, []byte(`{"error":"not found"}`), auditRec
 	}
+	if !skill.IsSystem && (skill.OwnerID == nil || *skill.OwnerID != *userID) {
+		rec.Decision = auditDecisionDeny
+		rec.Status = auditStatusError
+		rec.ErrorType = strPtr("forbidden")
+		return http.StatusForbidden, []byte(`{"error":"only the owner may change this skill"}`), auditRec
+	}
 	var name, content, scope *string
 	if v := strArg(args, "name"); v != "" {
 		name = &v
@@ -915,10 +1129,18 @@ func handleSkillsUpdate(ctx context.Context, store database.Store, args map[stri
 		}
 		content = &v
 	}
+	var groupID *uuid.UUID
 	if v := strArg(args, "scope"); v != "" {
 		scope = &v
+		var code int
+		if groupID, code, body = skillGroupArg(ctx, store, args, v, *userID, rec); code != 0 {
+			return code, body, auditRec
+		}
 	}
 	updated, err := store.UpdateSkill(ctx, skillID, name, content, scope)
+	if err == nil && scope != nil {
+		updated, err = store.SetSkillGroup(ctx, skillID, groupID)
+	}
 	if err != nil {
 		code, body := writePreferenceErrToAudit(err, rec)
 		return code, body, auditRec
@@ -966,12 +1188,18 @@ func handleSkillsDelete(ctx context.Context, store database.Store, args map[stri
 		code, body := writePreferenceErrToAudit(err, rec)
 		return code, body, auditRec
 	}
-	if !skill.IsSystem && (skill.OwnerID == nil || *skill.OwnerID != *userID) {
+	if !skillVisibleTo(ctx, store, skill, *userID) {
 		rec.Decision = auditDecisionAllow
 		rec.Status = auditStatusError
 		rec.ErrorType = strPtr("not_found")
 		return http.StatusNotFound, []byte(`{"error":"not found"}`), auditRec
 	}
+	if !skill.IsSystem && (skill.OwnerID == nil || *skill.OwnerID != *userID) {
+		rec.Decision = auditDecisionDeny
+		rec.Status = auditStatusError
+		rec.ErrorType = strPtr("forbidden")
+		return http.StatusForbidden, []byte(`{"error":"only the owner may change this skill"}`), auditRec
+	}
 	if err := store.DeleteSkill(ctx, skillID); err != nil {
 		code, body := writePreferenceErrToAudit(err, rec)
 		return code, body, auditRec
@@ -984,6 +1212,42 @@ func handleSkillsDelete(ctx context.Context, store database.Store, args map[stri
 
 func strPtr(s string) *string { return &s }
 
+// skillVisibleTo reports whether userID may read skill: system, own, or shared with one of the user's groups. Fails closed when group membership cannot be loaded.
+func skillVisibleTo(ctx context.Context, store database.Store, skill *models.Skill, userID uuid.UUID) bool {
+	groupIDs, err := store.ListGroupIDsForUser(ctx, userID)
+	if err != nil {
+		groupIDs = nil
+	}
+	return database.SkillVisibleTo(skill, userID, groupIDs)
+}
+
+// skillGroupArg validates the group_id argument for scope: required for scope "group", where the task owner must be
+// a member, and rejected otherwise. A non-zero code means the audit record and response are already set.
+func skillGroupArg(ctx context.Context, store database.Store, args map[string]interface{}, scope string, userID uuid.UUID, rec *models.McpToolCallAuditLog) (groupID *uuid.UUID, code int, body []byte) {
+	groupID = uuidArg(args, "group_id")
+	if scope != "group" {
+		if strArg(args, "group_id") != "" {
+			rec.Decision, rec.Status, rec.ErrorType = auditDecisionDeny, auditStatusError, strPtr("invalid_arguments")
+			return nil, http.StatusBadRequest, []byte(`{"error":"group_id is only valid with scope group"}`)
+		}
+		return nil, 0, nil
+	}
+	if groupID == nil {
+		rec.Decision, rec.Status, rec.ErrorType = auditDecisionDeny, auditStatusError, strPtr("invalid_arguments")
+		return nil, http.StatusBadRequest, []byte(`{"error":"group_id required for scope group"}`)
+	}
+	groupIDs, err := store.ListGroupIDsForUser(ctx, userID)
+	if err != nil {
+		rec.Decision, rec.Status, rec.ErrorType = auditDecisionAllow, auditStatusError, strPtr("internal_error")
+		return nil, http.StatusInternalServerError, []byte(`{"error":"internal error"}`)
+	}
+	if !slices.Contains(groupIDs, *groupID) {
+		rec.Decision, rec.Status, rec.ErrorType = auditDecisionDeny, auditStatusError, strPtr("forbidden")
+		return nil, http.StatusForbidden, []byte(`{"error":"not a member of the group"}`)
+	}
+	return groupID, 0, nil
+}
+
 func skillsPolicyViolationResponse(rec *models.McpToolCallAuditLog, m *skillscan.Match, auditRec *models.McpToolCallAuditLog) (code int, body []byte, outRec *models.McpToolCallAuditLog) {
 	rec.Decision = auditDecisionAllow
 	rec.Status = auditStatusError
@@ -9,12 +9,15 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/auth"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/handlers"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
 )
@@ -1147,6 +1150,11 @@ func TestRun_WithTestDatabaseOpen(t *testing.T) {
 func TestRun_WithTestStore(t *testing.T) {
 	testStore = testutil.NewMockDB()
 	defer func() { testStore = nil }()
+	jwt := auth.NewJWTManager("test-secret", time.Minute, time.Hour, time.Hour)
+	jwksSrv := httptest.NewServer(handlers.JWKS(jwt.Keyring()))
+	defer jwksSrv.Close()
+	t.Setenv("MCP_GATEWAY_JWKS_URL", jwksSrv.URL)
+	nodeToken, _, _ := jwt.GenerateNodeToken(uuid.New(), "node-1")
 
 	oldAddr := os.Getenv("LISTEN_ADDR")
 	_ = os.Setenv("LISTEN_ADDR", "127.0.0.1:19083")
@@ -1164,7 +1172,10 @@ func TestRun_WithTestStore(t *testing.T) {
 
 	time.Sleep(50 * time.Millisecond)
 	// Use a non-routed tool so gateway returns 501.
-	resp, err := http.Post("http://127.0.0.1:19083/v1/mcp/tools/call", "application/json", bytes.NewReader([]byte(`{"tool_name":"other.tool"}`)))
+	req, _ := http.NewRequest(http.MethodPost, "http://127.0.0.1:19083/v1/mcp/tools/call", strings.NewReader(`{"tool_name":"other.tool"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+nodeToken)
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		cancel()
 		<-done
@@ -1180,3 +1191,134 @@ func TestRun_WithTestStore(t *testing.T) {
 		t.Errorf("run: %v", err)
 	}
 }
+
+func TestToolCallHandler_PolicyDeny(t *testing.T) {
+	mock := testutil.NewMockDB()
+	userID := uuid.New()
+	task, _ := mock.CreateTask(context.Background(), &userID, "p", nil)
+	mock.AccessControlRules = append(mock.AccessControlRules, &models.AccessControlRule{
+		ID: uuid.New(), SubjectType: database.SubjectTypeUser, SubjectID: &userID, Action: database.ActionMcpToolInvoke,
+		ResourceType: database.ResourceTypeMcpTool, ResourcePattern: "db.*", Effect: "deny", Priority: 10,
+	})
+	body := `{"tool_name":"db.task.get","arguments":{"task_id":"` + task.ID.String() + `"}}`
+	code, resp := callToolHandlerWithStoreAndBody(t, mock, body)
+	if code != http.StatusForbidden || !bytes.Contains(resp, []byte("denied by policy")) {
+		t.Errorf("user deny rule: %d %s", code, resp)
+	}
+	otherTask, _ := mock.CreateTask(context.Background(), nil, "p", nil)
+	body = `{"tool_name":"db.task.get","arguments":{"task_id":"` + otherTask.ID.String() + `"}}`
+	if code, resp := callToolHandlerWithStoreAndBody(t, mock, body); code != http.StatusOK {
+		t.Errorf("system allow rule for other task: %d %s", code, resp)
+	}
+	mock.AccessControlRules = nil
+	if code, _ := callToolHandlerWithStoreAndBody(t, mock, body); code != http.StatusForbidden {
+		t.Errorf("no rules (default deny): %d", code)
+	}
+}
+
+// auditCaptureStore records MCP audit rows written through the mock.
+type auditCaptureStore struct {
+	*testutil.MockDB
+	audits []*models.McpToolCallAuditLog
+}
+
+func (s *auditCaptureStore) CreateMcpToolCallAuditLog(_ context.Context, rec *models.McpToolCallAuditLog) error {
+	s.audits = append(s.audits, rec)
+	return nil
+}
+
+func TestToolCallHandler_AuditRecordsGroupsAndRoles(t *testing.T) {
+	store := &auditCaptureStore{MockDB: testutil.NewMockDB()}
+	ctx := context.Background()
+	user, _ := store.CreateUser(ctx, "u", nil)
+	store.AddUser(user)
+	group := &models.Group{Slug: "eng", DisplayName: "eng", IsActive: true}
+	_ = store.CreateGroup(ctx, group)
+	_, _ = store.AddGroupMember(ctx, group.ID, user.ID, "admin")
+	task, _ := store.CreateTask(ctx, &user.ID, "p", nil)
+	body := `{"tool_name":"skills.create","arguments":{"task_id":"` + task.ID.String() + `","content":"# Team","scope":"group","group_id":"` + group.ID.String() + `"}}`
+	if code, resp := callToolHandlerWithStoreAndBody(t, store, body); code != http.StatusOK {
+		t.Fatalf("got status %d body %s", code, resp)
+	}
+	if len(store.audits) != 1 {
+		t.Fatalf("audits = %d", len(store.audits))
+	}
+	rec := store.audits[0]
+	if rec.UserID == nil || *rec.UserID != user.ID || rec.GroupIDs == nil || *rec.GroupIDs != `["`+group.ID.String()+`"]` ||
+		rec.RoleNames == nil || *rec.RoleNames != `["member"]` {
+		t.Errorf("audit = %+v", rec)
+	}
+
+	// A group skill is visible to other members but the creator must belong to the group.
+	outsider, _ := store.CreateUser(ctx, "o", nil)
+	store.AddUser(outsider)
+	otherTask, _ := store.CreateTask(ctx, &outsider.ID, "p", nil)
+	body = `{"tool_name":"skills.create","arguments":{"task_id":"` + otherTask.ID.String() + `","content":"# x","scope":"group","group_id":"` + group.ID.String() + `"}}`
+	callToolHandlerWithStore(t, store, body, http.StatusForbidden)
+}
+
+func TestToolCallHandler_AuditArgumentsRedacted(t *testing.T) {
+	mock := testutil.NewMockDB()
+	long := strings.Repeat("a", maxAuditArgBytes+1)
+	body := `{"tool_name":"other.tool","arguments":{"note":"password=hunter2","blob":"` + long + `","n":3}}`
+	if code, _ := callToolHandlerWithStoreAndBody(t, mock, body); code != http.StatusNotImplemented {
+		t.Fatalf("code = %d", code)
+	}
+	if len(mock.McpToolCallAuditLogs) != 1 {
+		t.Fatalf("audit records = %d", len(mock.McpToolCallAuditLogs))
+	}
+	rec := mock.McpToolCallAuditLogs[0]
+	want := `{"blob":"[omitted 4097 bytes]","n":3,"note":"password: SECRET_REDACTED"}`
+	if rec.Arguments == nil || *rec.Arguments != want || rec.RedactionKinds == nil || *rec.RedactionKinds != `["api_key"]` {
+		t.Errorf("arguments = %v, kinds = %v", rec.Arguments, rec.RedactionKinds)
+	}
+}
+
+func TestRun_RequiresJWKSURL(t *testing.T) {
+	t.Setenv("MCP_GATEWAY_JWKS_URL", "")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := run(ctx, slog.Default()); err == nil || !strings.Contains(err.Error(), "MCP_GATEWAY_JWKS_URL") {
+		t.Errorf("run without JWKS URL: %v", err)
+	}
+}
+
+func TestToolCallRoute_RequiresSignedToken(t *testing.T) {
+	jwt := auth.NewJWTManager("test-secret", time.Minute, time.Hour, time.Hour)
+	jwksSrv := httptest.NewServer(handlers.JWKS(jwt.Keyring()))
+	defer jwksSrv.Close()
+	mock := testutil.NewMockDB()
+	route := toolCallRoute(mock, jwksSrv.URL, slog.Default())
+
+	call := func(token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/mcp/tools/call", strings.NewReader(`{"tool_name":"unknown.tool"}`))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		route.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := call(""); code != http.StatusUnauthorized {
+		t.Errorf("no token: %d", code)
+	}
+	node, _, _ := jwt.GenerateNodeToken(uuid.New(), "node-1")
+	if code := call(node); code == http.StatusUnauthorized {
+		t.Errorf("node token rejected")
+	}
+
+	// An access token of a revoked session is refused before it expires.
+	ctx := context.Background()
+	user, _ := mock.CreateUser(ctx, "alice", nil)
+	session := &models.RefreshSession{UserID: user.ID, RefreshTokenHash: []byte("h"), ExpiresAt: time.Now().Add(time.Hour)}
+	_ = mock.CreateRefreshSession(ctx, session)
+	access, accessExpiresAt, _ := jwt.GenerateSessionAccessToken(user.ID, "alice", session.ID)
+	session.AccessExpiresAt = &accessExpiresAt
+	if code := call(access); code == http.StatusUnauthorized {
+		t.Errorf("access token of active session rejected")
+	}
+	_ = mock.InvalidateRefreshSession(ctx, session.ID)
+	if code := call(access); code != http.StatusUnauthorized {
+		t.Errorf("access token of revoked session: %d", code)
+	}
+}
@@ -123,6 +123,8 @@ type tcResult struct {
 }
 
 func TestMain(m *testing.M) {
+	// run() refuses to start without a JWKS URL; nothing is fetched from it until a token is presented.
+	_ = os.Setenv("MCP_GATEWAY_JWKS_URL", "http://127.0.0.1:1/.well-known/jwks.json")
 	if os.Getenv(mcpGatewayDBEnv) != "" {
 		os.Exit(m.Run())
 		return
@@ -5,19 +5,26 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/artifacts"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/auth"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/config"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/credcrypt"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/handlers"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/middleware"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/oidc"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/rbac"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/readiness"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/usage"
 )
 
 func main() {
@@ -51,25 +58,68 @@ func runMainWithStore(ctx context.Context, cfg *config.OrchestratorConfig, store
 
 // run sets up handlers and runs the server until ctx is canceled. Used by main and tests.
 func run(ctx context.Context, cfg *config.OrchestratorConfig, store database.Store, logger *slog.Logger) error {
-	jwtManager := auth.NewJWTManager(
-		cfg.JWTSecret,
+	keyring, err := auth.LoadKeyring(cfg.JWTSigningKeyFiles, cfg.JWTSecret)
+	if err != nil {
+		return err
+	}
+	if len(cfg.JWTSigningKeyFiles) == 0 {
+		logger.Warn("JWT_SIGNING_KEY_FILES not set; deriving the JWT signing key from JWT_SECRET")
+	}
+	jwtManager := auth.NewJWTManagerWithKeyring(
+		keyring,
 		cfg.JWTAccessDuration,
 		cfg.JWTRefreshDuration,
 		cfg.JWTNodeDuration,
 	)
 	rateLimiter := auth.NewRateLimiter(cfg.RateLimitPerMinute, time.Minute)
+	// Permission checks reuse resolved access briefly; membership and role binding writes through store drop it.
+	accessCache := rbac.NewCache(store, rbac.DefaultCacheTTL)
+	store = accessCache.InvalidatingStore(store)
 
 	authHandler := handlers.NewAuthHandler(store, jwtManager, rateLimiter, logger)
+	sso, err := ssoFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	if sso != nil {
+		authHandler.SetSSO(sso)
+		logger.Info("single sign-on enabled", "issuer", cfg.OIDCIssuerURL)
+	}
 	userHandler := handlers.NewUserHandler(store, logger)
 	taskHandler := handlers.NewTaskHandler(store, logger, cfg.InferenceURL, cfg.InferenceModel)
 	openAIChatHandler := handlers.NewOpenAIChatHandler(store, logger, cfg.InferenceURL, cfg.InferenceModel, cfg.WorkerAPIBearerToken)
 	skillsHandler := handlers.NewSkillsHandler(store, logger)
+	jobHandler := handlers.NewJobHandler(store, logger)
+	planHandler := handlers.NewPlanHandler(store, logger)
+	groupHandler := handlers.NewGroupHandler(store, logger)
+	policyHandler := handlers.NewPolicyHandler(store, logger)
+	egressLimitHandler := handlers.NewEgressLimitHandler(store, logger)
+	auditHandler := handlers.NewAuditHandler(store, logger)
+	quotas := usage.NewEnforcer(store, usage.LimitsFromConfig(cfg))
+	taskHandler.SetQuotaEnforcer(quotas)
+	openAIChatHandler.SetQuotaEnforcer(quotas)
+	usageHandler := handlers.NewUsageHandler(store, logger, quotas)
+	if artifactStore, err := artifacts.New(cfg); err != nil {
+		logger.Warn("artifact store unavailable; artifact content endpoints return 503", "error", err)
+	} else {
+		taskHandler.SetArtifactStore(artifactStore, int64(cfg.ArtifactMaxSizeMB)*1024*1024)
+	}
+	credentialKeys, err := credcrypt.FromEnv()
+	switch {
+	case errors.Is(err, credcrypt.ErrKeyNotConfigured):
+		logger.Warn("credential master key not set; credential create, rotate, and rekey return 503", "env", credcrypt.EnvMasterKeyB64)
+	case err != nil:
+		return err
+	}
+	credentialHandler := handlers.NewCredentialHandler(store, credentialKeys, logger)
+	tokenHandler := handlers.NewAccessTokenHandler(store, logger)
 
 	if err := store.EnsureDefaultSkill(ctx, defaultSkillContent); err != nil {
 		logger.Warn("ensure default skill", "error", err)
 	}
 
-	authMiddleware := middleware.NewAuthMiddleware(jwtManager, logger)
+	authMiddleware := middleware.NewAuthMiddleware(jwtManager, store, logger)
+	authMiddleware.SetAccessCache(accessCache)
 
 	mux := http.NewServeMux()
 	plainTextOK := func(body string) http.HandlerFunc {
@@ -81,29 +131,91 @@ func run(ctx context.Context, cfg *config.OrchestratorConfig, store database.Sto
 	}
 	mux.HandleFunc("GET /healthz", plainTextOK("ok"))
 	mux.HandleFunc("GET /readyz", gatewayReadyzHandler(store, cfg, logger))
+	mux.HandleFunc("GET /.well-known/jwks.json", handlers.JWKS(keyring))
 
 	maxBodyBytes := int64(cfg.MaxRequestBodyMB) * 1024 * 1024
 
 	mux.HandleFunc("POST /v1/auth/login", limitBody(maxBodyBytes, authHandler.Login))
 	mux.HandleFunc("POST /v1/auth/refresh", limitBody(maxBodyBytes, authHandler.Refresh))
+	mux.HandleFunc("GET /v1/auth/sso/login", authHandler.SSOLogin)
+	mux.HandleFunc("GET /v1/auth/sso/callback", authHandler.SSOCallback)
+	mux.HandleFunc("POST /v1/auth/sso/device", authHandler.SSODeviceStart)
+	mux.HandleFunc("POST /v1/auth/sso/device/token", limitBody(maxBodyBytes, authHandler.SSODeviceToken))
 
 	mux.Handle("POST /v1/auth/logout", authMiddleware.RequireUserAuth(http.HandlerFunc(limitBody(maxBodyBytes, authHandler.Logout))))
 	mux.Handle("GET /v1/users/me", authMiddleware.RequireUserAuth(http.HandlerFunc(userHandler.GetMe)))
-	mux.Handle("POST /v1/users/{id}/revoke_sessions", authMiddleware.RequireAdminAuth(http.HandlerFunc(userHandler.RevokeSessions)))
-	mux.Handle("POST /v1/tasks", authMiddleware.RequireUserAuth(http.HandlerFunc(limitBody(maxBodyBytes, taskHandler.CreateTask))))
-	mux.Handle("GET /v1/tasks", authMiddleware.RequireUserAuth(http.HandlerFunc(taskHandler.ListTasks)))
-	mux.Handle("GET /v1/tasks/{id}", authMiddleware.RequireUserAuth(http.HandlerFunc(taskHandler.GetTask)))
-	mux.Handle("GET /v1/tasks/{id}/result", authMiddleware.RequireUserAuth(http.HandlerFunc(taskHandler.GetTaskResult)))
-	mux.Handle("POST /v1/tasks/{id}/cancel", authMiddleware.RequireUserAuth(http.HandlerFunc(taskHandler.CancelTask)))
-	mux.Handle("GET /v1/tasks/{id}/logs", authMiddleware.RequireUserAuth(http.HandlerFunc(taskHandler.GetTaskLogs)))
-	mux.Handle("GET /v1/models", authMiddleware.RequireUserAuth(http.HandlerFunc(openAIChatHandler.ListModels)))
-	mux.Handle("POST /v1/chat/completions", authMiddleware.RequireUserAuth(http.HandlerFunc(limitBody(maxBodyBytes, openAIChatHandler.ChatCompletions))))
-	mux.Handle("POST /v1/chat/threads", authMiddleware.RequireUserAuth(http.HandlerFunc(openAIChatHandler.NewThread)))
-	mux.Handle("GET /v1/skills", authMiddleware.RequireUserAuth(http.HandlerFunc(skillsHandler.List)))
-	mux.Handle("GET /v1/skills/{id}", authMiddleware.RequireUserAuth(http.HandlerFunc(skillsHandler.Get)))
-	mux.Handle("POST /v1/skills/load", authMiddleware.RequireUserAuth(http.HandlerFunc(limitBody(maxBodyBytes, skillsHandler.Load))))
-	mux.Handle("PUT /v1/skills/{id}", authMiddleware.RequireUserAuth(http.HandlerFunc(limitBody(maxBodyBytes, skillsHandler.Update))))
-	mux.Handle("DELETE /v1/skills/{id}", authMiddleware.RequireUserAuth(http.HandlerFunc(skillsHandler.Delete)))
+	mux.Handle("POST /v1/users/{id}/revoke_sessions", authMiddleware.RequirePermission(rbac.PermUsersManage, http.HandlerFunc(userHandler.RevokeSessions)))
+	mux.Handle("GET /v1/users", authMiddleware.RequirePermission(rbac.PermUsersManage, http.HandlerFunc(userHandler.ListUsers)))
+	mux.Handle("POST /v1/users", authMiddleware.RequirePermission(rbac.PermUsersManage, http.HandlerFunc(limitBody(maxBodyBytes, userHandler.CreateUser))))
+	mux.Handle("DELETE /v1/users/{id}", authMiddleware.RequirePermission(rbac.PermUsersManage, http.HandlerFunc(userHandler.DeleteUser)))
+	mux.Handle("POST /v1/users/{id}/disable", authMiddleware.RequirePermission(rbac.PermUsersManage, http.HandlerFunc(userHandler.DisableUser)))
+	mux.Handle("POST /v1/users/{id}/enable", authMiddleware.RequirePermission(rbac.PermUsersManage, http.HandlerFunc(userHandler.EnableUser)))
+	mux.Handle("POST /v1/users/{id}/reset_password", authMiddleware.RequirePermission(rbac.PermUsersManage, http.HandlerFunc(limitBody(maxBodyBytes, userHandler.ResetPassword))))
+	mux.Handle("GET /v1/users/me/sessions", authMiddleware.RequireUserAuth(http.HandlerFunc(userHandler.ListMySessions)))
+	mux.Handle("DELETE /v1/users/me/sessions/{session_id}", authMiddleware.RequireUserAuth(http.HandlerFunc(userHandler.RevokeMySession)))
+	mux.Handle("GET /v1/users/me/tokens", authMiddleware.RequireUserAuth(http.HandlerFunc(tokenHandler.ListMyTokens)))
+	mux.Handle("POST /v1/users/me/tokens", authMiddleware.RequireUserAuth(http.HandlerFunc(limitBody(maxBodyBytes, tokenHandler.CreateMyToken))))
+	mux.Handle("DELETE /v1/users/me/tokens/{token_id}", authMiddleware.RequireUserAuth(http.HandlerFunc(tokenHandler.RevokeMyToken)))
+	mux.Handle("GET /v1/service-accounts", authMiddleware.RequirePermission(rbac.PermUsersManage, http.HandlerFunc(tokenHandler.ListServiceAccounts)))
+	mux.Handle("POST /v1/service-accounts", authMiddleware.RequirePermission(rbac.PermUsersManage, http.HandlerFunc(limitBody(maxBodyBytes, tokenHandler.CreateServiceAccount))))
+	mux.Handle("GET /v1/service-accounts/{id}/tokens", authMiddleware.RequirePermission(rbac.PermUsersManage, http.HandlerFunc(tokenHandler.ListServiceAccountTokens)))
+	mux.Handle("POST /v1/service-accounts/{id}/tokens", authMiddleware.RequirePermission(rbac.PermUsersManage, http.HandlerFunc(limitBody(maxBodyBytes, tokenHandler.CreateServiceAccountToken))))
+	mux.Handle("DELETE /v1/service-accounts/{id}/tokens/{token_id}", authMiddleware.RequirePermission(rbac.PermUsersManage, http.HandlerFunc(tokenHandler.RevokeServiceAccountToken)))
+	mux.Handle("POST /v1/tasks", authMiddleware.RequirePermission(rbac.PermTasksWrite, http.HandlerFunc(limitBody(maxBodyBytes, taskHandler.CreateTask))))
+	mux.Handle("GET /v1/tasks", authMiddleware.RequirePermission(rbac.PermTasksRead, http.HandlerFunc(taskHandler.ListTasks)))
+	mux.Handle("GET /v1/tasks/{id}", authMiddleware.RequirePermission(rbac.PermTasksRead, http.HandlerFunc(taskHandler.GetTask)))
+	mux.Handle("GET /v1/tasks/{id}/result", authMiddleware.RequirePermission(rbac.PermTasksRead, http.HandlerFunc(taskHandler.GetTaskResult)))
+	mux.Handle("POST /v1/tasks/{id}/cancel", authMiddleware.RequirePermission(rbac.PermTasksWrite, http.HandlerFunc(taskHandler.CancelTask)))
+	mux.Handle("GET /v1/tasks/{id}/logs", authMiddleware.RequirePermission(rbac.PermTasksRead, http.HandlerFunc(taskHandler.GetTaskLogs)))
+	mux.Handle("GET /v1/tasks/{id}/artifacts", authMiddleware.RequirePermission(rbac.PermTasksRead, http.HandlerFunc(taskHandler.ListTaskArtifacts)))
+	mux.Handle("GET /v1/tasks/{id}/artifacts/{path...}", authMiddleware.RequirePermission(rbac.PermTasksRead, http.HandlerFunc(taskHandler.GetTaskArtifact)))
+	// Artifact uploads are bounded by ARTIFACT_MAX_SIZE_MB rather than MAX_REQUEST_BODY_MB.
+	mux.Handle("PUT /v1/tasks/{id}/artifacts/{path...}", authMiddleware.RequirePermission(rbac.PermTasksWrite, http.HandlerFunc(taskHandler.PutTaskArtifact)))
+	mux.Handle("POST /v1/projects/{project_id}/plans", authMiddleware.RequirePermission(rbac.PermTasksWrite, http.HandlerFunc(limitBody(maxBodyBytes, planHandler.CreatePlan))))
+	mux.Handle("GET /v1/plans/{id}/status", authMiddleware.RequirePermission(rbac.PermTasksRead, http.HandlerFunc(planHandler.GetPlanStatus)))
+	mux.Handle("GET /v1/jobs/dead-lettered", authMiddleware.RequirePermission(rbac.PermJobsRead, http.HandlerFunc(jobHandler.ListDeadLettered)))
+	mux.Handle("GET /v1/jobs/{id}/attempts", authMiddleware.RequirePermission(rbac.PermJobsRead, http.HandlerFunc(jobHandler.ListAttempts)))
+	mux.Handle("POST /v1/jobs/{id}/requeue", authMiddleware.RequirePermission(rbac.PermJobsManage, http.HandlerFunc(jobHandler.Requeue)))
+	mux.Handle("GET /v1/models", authMiddleware.RequirePermission(rbac.PermChatUse, http.HandlerFunc(openAIChatHandler.ListModels)))
+	mux.Handle("POST /v1/chat/completions", authMiddleware.RequirePermission(rbac.PermChatUse, http.HandlerFunc(limitBody(maxBodyBytes, openAIChatHandler.ChatCompletions))))
+	mux.Handle("POST /v1/chat/threads", authMiddleware.RequirePermission(rbac.PermChatUse, http.HandlerFunc(openAIChatHandler.NewThread)))
+	mux.Handle("GET /v1/credentials", authMiddleware.RequirePermission(rbac.PermCredentialsManage, http.HandlerFunc(credentialHandler.ListCredentials)))
+	mux.Handle("POST /v1/credentials", authMiddleware.RequirePermission(rbac.PermCredentialsManage, http.HandlerFunc(limitBody(maxBodyBytes, credentialHandler.CreateCredential))))
+	mux.Handle("POST /v1/credentials/rekey", authMiddleware.RequirePermission(rbac.PermCredentialsAdmin, http.HandlerFunc(credentialHandler.Rekey)))
+	mux.Handle("GET /v1/credentials/{id}", authMiddleware.RequirePermission(rbac.PermCredentialsManage, http.HandlerFunc(credentialHandler.GetCredential)))
+	mux.Handle("POST /v1/credentials/{id}/rotate", authMiddleware.RequirePermission(rbac.PermCredentialsManage, http.HandlerFunc(limitBody(maxBodyBytes, credentialHandler.RotateCredential))))
+	mux.Handle("POST /v1/credentials/{id}/disable", authMiddleware.RequirePermission(rbac.PermCredentialsManage, http.HandlerFunc(credentialHandler.DisableCredential)))
+	mux.Handle("GET /v1/usage", authMiddleware.RequirePermission(rbac.PermUsageRead, http.HandlerFunc(usageHandler.GetUsage)))
+	mux.Handle("GET /v1/skills", authMiddleware.RequirePermission(rbac.PermSkillsRead, http.HandlerFunc(skillsHandler.List)))
+	mux.Handle("GET /v1/skills/{id}", authMiddleware.RequirePermission(rbac.PermSkillsRead, http.HandlerFunc(skillsHandler.Get)))
+	mux.Handle("POST /v1/skills/load", authMiddleware.RequirePermission(rbac.PermSkillsWrite, http.HandlerFunc(limitBody(maxBodyBytes, skillsHandler.Load))))
+	mux.Handle("PUT /v1/skills/{id}", authMiddleware.RequirePermission(rbac.PermSkillsWrite, http.HandlerFunc(limitBody(maxBodyBytes, skillsHandler.Update))))
+	mux.Handle("DELETE /v1/skills/{id}", authMiddleware.RequirePermission(rbac.PermSkillsWrite, http.HandlerFunc(skillsHandler.Delete)))
+	mux.Handle("GET /v1/groups", authMiddleware.RequirePermission(rbac.PermGroupsRead, http.HandlerFunc(groupHandler.ListGroups)))
+	mux.Handle("POST /v1/groups", authMiddleware.RequirePermission(rbac.PermGroupsManage, http.HandlerFunc(limitBody(maxBodyBytes, groupHandler.CreateGroup))))
+	mux.Handle("GET /v1/groups/{id}", authMiddleware.RequirePermission(rbac.PermGroupsRead, http.HandlerFunc(groupHandler.GetGroup)))
+	mux.Handle("GET /v1/groups/{id}/members", authMiddleware.RequirePermission(rbac.PermGroupsRead, http.HandlerFunc(groupHandler.ListMembers)))
+	mux.Handle("POST /v1/groups/{id}/members", authMiddleware.RequirePermission(rbac.PermGroupsManage, http.HandlerFunc(limitBody(maxBodyBytes, groupHandler.AddMember))))
+	mux.Handle("DELETE /v1/groups/{id}/members/{user_id}", authMiddleware.RequirePermission(rbac.PermGroupsManage, http.HandlerFunc(groupHandler.RemoveMember)))
+	mux.Handle("GET /v1/roles", authMiddleware.RequirePermission(rbac.PermGroupsRead, http.HandlerFunc(groupHandler.ListRoles)))
+	mux.Handle("GET /v1/role-bindings", authMiddleware.RequirePermission(rbac.PermGroupsManage, http.HandlerFunc(groupHandler.ListRoleBindings)))
+	mux.Handle("POST /v1/role-bindings", authMiddleware.RequirePermission(rbac.PermGroupsManage, http.HandlerFunc(limitBody(maxBodyBytes, groupHandler.CreateRoleBinding))))
+	mux.Handle("DELETE /v1/role-bindings/{id}", authMiddleware.RequirePermission(rbac.PermGroupsManage, http.HandlerFunc(groupHandler.DisableRoleBinding)))
+	mux.Handle("GET /v1/policy/rules", authMiddleware.RequirePermission(rbac.PermPolicyRead, http.HandlerFunc(policyHandler.ListRules)))
+	mux.Handle("POST /v1/policy/rules", authMiddleware.RequirePermission(rbac.PermPolicyManage, http.HandlerFunc(limitBody(maxBodyBytes, policyHandler.CreateRule))))
+	mux.Handle("GET /v1/policy/rules/{id}", authMiddleware.RequirePermission(rbac.PermPolicyRead, http.HandlerFunc(policyHandler.GetRule)))
+	mux.Handle("PUT /v1/policy/rules/{id}", authMiddleware.RequirePermission(rbac.PermPolicyManage, http.HandlerFunc(limitBody(maxBodyBytes, policyHandler.UpdateRule))))
+	mux.Handle("DELETE /v1/policy/rules/{id}", authMiddleware.RequirePermission(rbac.PermPolicyManage, http.HandlerFunc(policyHandler.DeleteRule)))
+	mux.Handle("POST /v1/policy/evaluate", authMiddleware.RequirePermission(rbac.PermPolicyRead, http.HandlerFunc(limitBody(maxBodyBytes, policyHandler.Evaluate))))
+	mux.Handle("GET /v1/egress/limits", authMiddleware.RequirePermission(rbac.PermPolicyRead, http.HandlerFunc(egressLimitHandler.List)))
+	mux.Handle("POST /v1/egress/limits", authMiddleware.RequirePermission(rbac.PermPolicyManage, http.HandlerFunc(limitBody(maxBodyBytes, egressLimitHandler.Create))))
+	mux.Handle("GET /v1/egress/limits/{id}", authMiddleware.RequirePermission(rbac.PermPolicyRead, http.HandlerFunc(egressLimitHandler.Get)))
+	mux.Handle("PUT /v1/egress/limits/{id}", authMiddleware.RequirePermission(rbac.PermPolicyManage, http.HandlerFunc(limitBody(maxBodyBytes, egressLimitHandler.Update))))
+	mux.Handle("DELETE /v1/egress/limits/{id}", authMiddleware.RequirePermission(rbac.PermPolicyManage, http.HandlerFunc(egressLimitHandler.Delete)))
+
+	// Unified audit log search and export (admin only)
+	mux.Handle("GET /v1/audit", authMiddleware.RequirePermission(rbac.PermAuditRead, http.HandlerFunc(auditHandler.List)))
+	mux.Handle("GET /v1/audit/{id}", authMiddleware.RequirePermission(rbac.PermAuditRead, http.HandlerFunc(auditHandler.Get)))
 
 	handler := middleware.Recovery(logger)(middleware.Logging(logger)(mux))
 
@@ -153,6 +265,37 @@ const defaultSkillContent = `# CyNodeAI interaction
 
 Use MCP tools and the User API Gateway as documented. Follow task and project context. See docs/requirements and docs/tech_specs for authoritative behavior.`
 
+// ssoFromConfig returns the SSO settings for the auth handler, or nil when OIDC_ISSUER_URL or OIDC_CLIENT_ID is
+// unset.
+func ssoFromConfig(cfg *config.OrchestratorConfig) (*handlers.SSOConfig, error) {
+	if cfg.OIDCIssuerURL == "" || cfg.OIDCClientID == "" {
+		return nil, nil
+	}
+	provider, err := oidc.New(oidc.Config{
+		IssuerURL:    cfg.OIDCIssuerURL,
+		ClientID:     cfg.OIDCClientID,
+		ClientSecret: cfg.OIDCClientSecret,
+		RedirectURL:  cfg.OIDCRedirectURL,
+		Scopes:       strings.Fields(cfg.OIDCScopes),
+		GroupsClaim:  cfg.OIDCGroupsClaim,
+	})
+	if err != nil {
+		return nil, err
+	}
+	groupMap := make(map[string]string)
+	for _, pair := range strings.Split(cfg.OIDCGroupMap, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		idpGroup, slug, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(idpGroup) == "" || strings.TrimSpace(slug) == "" {
+			return nil, fmt.Errorf("OIDC_GROUP_MAP: %q is not idp-group=group-slug", pair)
+		}
+		groupMap[strings.TrimSpace(idpGroup)] = strings.TrimSpace(slug)
+	}
+	return &handlers.SSOConfig{Provider: provider, AutoProvision: cfg.OIDCAutoProvision, GroupMap: groupMap}, nil
+}
+
 func getEnv(key, def string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
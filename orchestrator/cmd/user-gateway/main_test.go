@@ -11,11 +11,18 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/auth"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/config"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
 )
 
+func TestMain(m *testing.M) {
+	// run() refuses to start with the placeholder JWT_SECRET default when no key files are configured.
+	_ = os.Setenv("JWT_SECRET", "test-jwt-secret")
+	os.Exit(m.Run())
+}
+
 // readyzErrorStore implements database.Store by delegating to MockDB but returning an error from ListDispatchableNodes.
 type readyzErrorStore struct {
 	*testutil.MockDB
@@ -59,6 +66,23 @@ func TestLimitBody(t *testing.T) {
 	}
 }
 
+func TestSSOFromConfig(t *testing.T) {
+	cfg := &config.OrchestratorConfig{}
+	if sso, err := ssoFromConfig(cfg); sso != nil || err != nil {
+		t.Fatalf("unconfigured: %v, %v", sso, err)
+	}
+	cfg.OIDCIssuerURL, cfg.OIDCClientID, cfg.OIDCAutoProvision = "https://idp.example", "cynodeai", true
+	cfg.OIDCGroupMap = " Engineering = eng ,ops=ops-team,"
+	sso, err := ssoFromConfig(cfg)
+	if err != nil || !sso.AutoProvision || len(sso.GroupMap) != 2 || sso.GroupMap["Engineering"] != "eng" {
+		t.Fatalf("configured: %+v, %v", sso, err)
+	}
+	cfg.OIDCGroupMap = "no-slug"
+	if _, err := ssoFromConfig(cfg); err == nil {
+		t.Error("malformed OIDC_GROUP_MAP accepted")
+	}
+}
+
 func TestGetEnv_USER_GATEWAY_LISTEN_ADDR(t *testing.T) {
 	// run() uses getEnv("USER_GATEWAY_LISTEN_ADDR", getEnv("LISTEN_ADDR", ":8080"))
 	_ = os.Unsetenv("USER_GATEWAY_LISTEN_ADDR")
@@ -90,6 +114,14 @@ func TestRun_CanceledContext(t *testing.T) {
 	}
 }
 
+func TestRun_RefusesPlaceholderJWTSecret(t *testing.T) {
+	cfg := config.LoadOrchestratorConfig()
+	cfg.JWTSecret = "change-me-in-production"
+	if err := run(context.Background(), cfg, testutil.NewMockDB(), slog.Default()); !errors.Is(err, auth.ErrInsecureJWTSecret) {
+		t.Errorf("run with placeholder secret: %v", err)
+	}
+}
+
 func TestRun_StartAndShutdown(t *testing.T) {
 	oldAddr := os.Getenv("LISTEN_ADDR")
 	_ = os.Setenv("LISTEN_ADDR", ":18080")
@@ -0,0 +1,218 @@
+// Package main provides the Web Egress Proxy: the allowlisting HTTP(S) forward proxy sandboxes with network
+// policy egress-proxy reach through their node. Each request carries the job's proxy credentials; the job's
+// task decides the allowlist through web.egress policy rules, and every decision is written to
+// access_control_audit_log. REQ-WEBPRX-0100--0103, REQ-WEBPRX-0106; see docs/tech_specs/web_egress_proxy.md.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/policy"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/webegress"
+)
+
+func main() {
+	os.Exit(runMain(context.Background()))
+}
+
+// runMain sets up logger and runs the server. Returns 0 on success, 1 on failure. Used by main and tests.
+func runMain(ctx context.Context) int {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	}))
+	slog.SetDefault(logger)
+	if err := run(ctx, logger); err != nil {
+		logger.Error("run failed", "error", err)
+		return 1
+	}
+	return 0
+}
+
+// run sets up and runs the proxy until ctx is canceled. WEB_EGRESS_DSN and WEB_EGRESS_TOKEN_SECRET (the
+// control-plane's secret for job proxy credentials) are required: without them no request can be attributed.
+func run(ctx context.Context, logger *slog.Logger) error {
+	dsn := getEnv("WEB_EGRESS_DSN", "")
+	secret := getEnv("WEB_EGRESS_TOKEN_SECRET", "")
+	if dsn == "" || secret == "" {
+		return errors.New("WEB_EGRESS_DSN and WEB_EGRESS_TOKEN_SECRET must be set")
+	}
+	db, err := database.Open(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+	if err := db.RunSchema(ctx, logger); err != nil {
+		return err
+	}
+	auth := newStoreAuthorizer(db, []byte(secret), logger)
+	proxy := webegress.New(auth, auth.audit)
+	proxy.DialTimeout = time.Duration(getEnvInt("WEB_EGRESS_DIAL_TIMEOUT_SEC", 10)) * time.Second
+	// No read or write timeouts: CONNECT tunnels live as long as the sandbox's download.
+	srv := &http.Server{
+		Addr:              getEnv("LISTEN_ADDR", ":8085"),
+		Handler:           newHandler(proxy),
+		ReadHeaderTimeout: 30 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		MaxHeaderBytes:    1 << 20,
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Info("starting web-egress", "addr", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("server error", "error", err)
+			serverErr <- err
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+	case err := <-serverErr:
+		return err
+	}
+	logger.Info("shutting down web-egress")
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+// newHandler sends proxy requests (CONNECT and absolute-form) to proxy and serves /healthz itself.
+func newHandler(proxy http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodConnect || r.URL.IsAbs() {
+			proxy.ServeHTTP(w, r)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func getEnv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func getEnvInt(key string, def int) int {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil && n > 0 {
+		return n
+	}
+	return def
+}
+
+// storeAuthorizer resolves proxy credentials to a running job and its task, and decides destinations with the
+// task user's web.egress rules.
+type storeAuthorizer struct {
+	store  database.Store
+	secret []byte
+	logger *slog.Logger
+}
+
+func newStoreAuthorizer(store database.Store, secret []byte, logger *slog.Logger) *storeAuthorizer {
+	return &storeAuthorizer{store: store, secret: secret, logger: logger}
+}
+
+// Authenticate accepts jobID and the token the control-plane issued for it while the job is running.
+func (a *storeAuthorizer) Authenticate(ctx context.Context, jobID, token string) (*webegress.Caller, error) {
+	invalid := webegress.Deny(http.StatusProxyAuthRequired, webegress.ReasonInvalidCredentials, "invalid job credentials")
+	id, err := uuid.Parse(jobID)
+	if err != nil || !webegress.VerifyJobToken(a.secret, id.String(), token) {
+		return nil, invalid
+	}
+	job, err := a.store.GetJobByID(ctx, id)
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		return nil, invalid
+	case err != nil:
+		return nil, err
+	case job.Status != models.JobStatusRunning:
+		return nil, webegress.Deny(http.StatusForbidden, webegress.ReasonJobNotRunning, "job "+id.String()+" is not running")
+	}
+	task, err := a.store.GetTaskByID(ctx, job.TaskID)
+	if err != nil {
+		return nil, err
+	}
+	c := &webegress.Caller{JobID: job.ID, TaskID: task.ID, UserID: task.CreatedBy, ProjectID: task.ProjectID}
+	if task.TaskType != nil {
+		c.TaskType = *task.TaskType
+	}
+	return c, nil
+}
+
+// Authorize decides web.egress on host:port for the task's user; tasks without a user get system rules only.
+func (a *storeAuthorizer) Authorize(ctx context.Context, c *webegress.Caller, dest webegress.Destination) error {
+	engine := policy.New(a.store)
+	var principal policy.Principal
+	if c.UserID != nil {
+		p, err := engine.PrincipalForUser(ctx, *c.UserID)
+		if err != nil {
+			return err
+		}
+		principal = p
+	}
+	resource := dest.Resource()
+	d, err := engine.Decide(ctx, &policy.Request{
+		Principal:    principal,
+		Action:       database.ActionWebEgress,
+		ResourceType: database.ResourceTypeWebDestination,
+		Resource:     resource,
+		Context:      policy.Context{ProjectID: c.ProjectID, TaskType: c.TaskType},
+	})
+	switch {
+	case err != nil:
+		a.logger.Error("load access control rules", "error", err)
+		return err
+	case d.Allowed:
+		return nil
+	case d.Rule != nil:
+		return webegress.Deny(http.StatusForbidden, webegress.ReasonPolicyDenied, "policy denies web egress to "+resource)
+	default:
+		return webegress.Deny(http.StatusForbidden, webegress.ReasonNotAllowlisted, resource+" is not on the egress allowlist")
+	}
+}
+
+// audit writes one access_control_audit_log row per request; job, scheme, and byte counts go in reason.
+func (a *storeAuthorizer) audit(ctx context.Context, rec *webegress.AuditRecord) {
+	var resource string
+	if rec.Dest.Host != "" {
+		resource = rec.Dest.Resource()
+	}
+	reason := fmt.Sprintf("reason_code=%s scheme=%s bytes_sent=%d bytes_received=%d",
+		rec.Code, rec.Dest.Scheme, rec.BytesSent, rec.BytesReceived)
+	entry := &models.AccessControlAuditLog{
+		SubjectType:  database.SubjectTypeUser,
+		Action:       database.ActionWebEgress,
+		ResourceType: database.ResourceTypeWebDestination,
+		Resource:     resource,
+		Decision:     rec.Decision,
+		Reason:       &reason,
+	}
+	var jobID string
+	if rec.Caller != nil {
+		entry.SubjectID, entry.TaskID = rec.Caller.UserID, &rec.Caller.TaskID
+		jobID = rec.Caller.JobID.String()
+		reason = "job_id=" + jobID + " " + reason
+	}
+	a.logger.Info("web_egress_audit", "job_id", jobID, "destination", resource, "scheme", rec.Dest.Scheme,
+		"decision", rec.Decision, "reason_code", rec.Code, "bytes_sent", rec.BytesSent, "bytes_received", rec.BytesReceived)
+	if err := a.store.CreateAccessControlAuditLog(ctx, entry); err != nil {
+		a.logger.Warn("access_control_audit_log failed", "error", err)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/webegress"
+)
+
+var testSecret = []byte("test-secret")
+
+func TestRun_RequiresDSNAndSecret(t *testing.T) {
+	t.Setenv("WEB_EGRESS_DSN", "")
+	t.Setenv("WEB_EGRESS_TOKEN_SECRET", "")
+	if err := run(context.Background(), slog.Default()); err == nil {
+		t.Error("run without WEB_EGRESS_DSN: expected error")
+	}
+	if code := runMain(context.Background()); code != 1 {
+		t.Errorf("runMain: got %d", code)
+	}
+}
+
+func TestHealthz(t *testing.T) {
+	srv := httptest.NewServer(newHandler(http.NotFoundHandler()))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("healthz: got %d", resp.StatusCode)
+	}
+}
+
+// webEgressFixture serves the proxy over mock, resolving every host to loopback. audited receives a value after
+// each audit row is written.
+func webEgressFixture(t *testing.T, mock *testutil.MockDB) (proxyURL *url.URL, audited chan struct{}) {
+	t.Helper()
+	auth := newStoreAuthorizer(mock, testSecret, slog.Default())
+	audited = make(chan struct{}, 8)
+	proxy := webegress.New(auth, func(ctx context.Context, rec *webegress.AuditRecord) {
+		auth.audit(ctx, rec)
+		audited <- struct{}{}
+	})
+	proxy.LookupIP = func(context.Context, string) ([]net.IP, error) { return []net.IP{net.ParseIP("127.0.0.1")}, nil }
+	proxy.PermitIP = func(net.IP) bool { return true }
+	srv := httptest.NewServer(newHandler(proxy))
+	t.Cleanup(srv.Close)
+	proxyURL, _ = url.Parse(srv.URL)
+	return proxyURL, audited
+}
+
+func getViaProxy(t *testing.T, proxyURL *url.URL, jobID, token, target string) *http.Response {
+	t.Helper()
+	u := *proxyURL
+	u.User = url.UserPassword(jobID, token)
+	c := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(&u)}, Timeout: 10 * time.Second}
+	resp, err := c.Get(target)
+	if err != nil {
+		t.Fatalf("GET %s: %v", target, err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	return resp
+}
+
+// lastAudit waits for the audit row the proxy writes after the response is sent and returns it.
+func lastAudit(t *testing.T, mock *testutil.MockDB, audited chan struct{}) *models.AccessControlAuditLog {
+	t.Helper()
+	select {
+	case <-audited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("audit row not written")
+	}
+	return mock.AccessControlAuditLogs[len(mock.AccessControlAuditLogs)-1]
+}
+
+func TestWebEgress_PolicyAllowlist(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("module"))
+	}))
+	defer upstream.Close()
+	port := upstream.URL[strings.LastIndex(upstream.URL, ":")+1:]
+
+	mock := testutil.NewMockDB()
+	user := &models.User{ID: uuid.New(), Handle: "web-user", IsActive: true}
+	mock.AddUser(user)
+	task := &models.Task{ID: uuid.New(), CreatedBy: &user.ID, Status: models.TaskStatusRunning}
+	mock.AddTask(task)
+	job := &models.Job{ID: uuid.New(), TaskID: task.ID, Status: models.JobStatusRunning}
+	mock.AddJob(job)
+	mock.AccessControlRules = []*models.AccessControlRule{{
+		SubjectType: database.SubjectTypeUser, SubjectID: &user.ID, Effect: "allow",
+		Action: database.ActionWebEgress, ResourceType: database.ResourceTypeWebDestination, ResourcePattern: "proxy.golang.test:*",
+	}}
+	proxyURL, audited := webEgressFixture(t, mock)
+	token := webegress.JobToken(testSecret, job.ID.String())
+
+	if resp := getViaProxy(t, proxyURL, job.ID.String(), token, "http://proxy.golang.test:"+port+"/mod"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("allowlisted host: got %d", resp.StatusCode)
+	}
+	rec := lastAudit(t, mock, audited)
+	if rec.Decision != "allow" || rec.TaskID == nil || *rec.TaskID != task.ID || rec.SubjectID == nil || *rec.SubjectID != user.ID ||
+		rec.Resource != "proxy.golang.test:"+port || rec.Reason == nil || !strings.Contains(*rec.Reason, "bytes_received=6") {
+		t.Errorf("allow audit = %+v reason=%v", rec, rec.Reason)
+	}
+
+	resp := getViaProxy(t, proxyURL, job.ID.String(), token, "http://exfil.test:"+port+"/")
+	if resp.StatusCode != http.StatusForbidden || resp.Header.Get(webegress.HeaderDenyReason) != webegress.ReasonNotAllowlisted {
+		t.Errorf("other host: got %d %q", resp.StatusCode, resp.Header.Get(webegress.HeaderDenyReason))
+	}
+	if rec := lastAudit(t, mock, audited); rec.Decision != "deny" || !strings.Contains(*rec.Reason, "reason_code=not_allowlisted") {
+		t.Errorf("deny audit = %+v", rec)
+	}
+
+	if resp := getViaProxy(t, proxyURL, job.ID.String(), "forged", "http://proxy.golang.test:"+port+"/"); resp.StatusCode != http.StatusProxyAuthRequired {
+		t.Errorf("forged token: got %d", resp.StatusCode)
+	}
+	lastAudit(t, mock, audited)
+
+	job.Status = models.JobStatusCompleted
+	resp = getViaProxy(t, proxyURL, job.ID.String(), token, "http://proxy.golang.test:"+port+"/")
+	if resp.StatusCode != http.StatusForbidden || resp.Header.Get(webegress.HeaderDenyReason) != webegress.ReasonJobNotRunning {
+		t.Errorf("finished job: got %d %q", resp.StatusCode, resp.Header.Get(webegress.HeaderDenyReason))
+	}
+}
@@ -0,0 +1,265 @@
+// Package apiegress implements the API Egress provider adapters. An adapter maps a named operation and its JSON
+// params onto one provider HTTP call, injects the caller's decrypted credential server-side, enforces the
+// operation's request and response size limits, and returns a normalized result. Adapters take their base URL
+// as configuration so they can be exercised against a local HTTP stand-in.
+// See docs/tech_specs/api_egress_server.md (Provider Adapters).
+package apiegress
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Error codes in the normalized error object.
+const (
+	CodeUnknownProvider     = "unknown_provider"
+	CodeUnknownOperation    = "unknown_operation"
+	CodeInvalidParams       = "invalid_params"
+	CodeRequestTooLarge     = "request_too_large"
+	CodeResponseTooLarge    = "response_too_large"
+	CodeUpstreamError       = "upstream_error"
+	CodeUpstreamUnavailable = "upstream_unavailable"
+)
+
+// maxUpstreamMessage bounds the provider error message copied into a normalized error.
+const maxUpstreamMessage = 512
+
+// Limits bounds one operation: MaxRequestBytes applies to the caller's params, MaxResponseBytes to the
+// provider response body.
+type Limits struct {
+	MaxRequestBytes  int64
+	MaxResponseBytes int64
+}
+
+// Error is a normalized call failure. HTTPStatus is the status API Egress returns to its caller;
+// UpstreamStatus is the provider's status when the provider answered.
+type Error struct {
+	HTTPStatus     int    `json:"-"`
+	Code           string `json:"code"`
+	Message        string `json:"message"`
+	UpstreamStatus int    `json:"upstream_status,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// Response is the normalized body of POST /v1/call: status is success or error.
+type Response struct {
+	Status string          `json:"status"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *Error          `json:"error,omitempty"`
+}
+
+// Adapter performs operations against one provider.
+type Adapter interface {
+	// Provider returns the provider name used in calls, policy, and api_credentials.provider.
+	Provider() string
+	// Operations returns the supported operation names, sorted.
+	Operations() []string
+	// Limits returns the size limits of op; ok is false for unsupported operations.
+	Limits(op string) (limits Limits, ok bool)
+	// Call performs op with params, authenticating with secret. It must not retain or log secret.
+	// Failures are returned as *Error.
+	Call(ctx context.Context, client *http.Client, op string, params json.RawMessage, secret []byte) (json.RawMessage, error)
+}
+
+// Registry holds the configured adapters and the HTTP client they share.
+type Registry struct {
+	client   *http.Client
+	adapters map[string]Adapter
+}
+
+// NewRegistry returns a registry of adapters keyed by provider name.
+func NewRegistry(client *http.Client, adapters ...Adapter) *Registry {
+	r := &Registry{client: client, adapters: make(map[string]Adapter, len(adapters))}
+	for _, a := range adapters {
+		r.adapters[a.Provider()] = a
+	}
+	return r
+}
+
+// Lookup returns the adapter for provider after checking it supports op; failures are *Error.
+func (r *Registry) Lookup(provider, op string) (Adapter, error) {
+	a, ok := r.adapters[provider]
+	if !ok {
+		return nil, &Error{HTTPStatus: http.StatusNotImplemented, Code: CodeUnknownProvider, Message: "provider not implemented"}
+	}
+	if _, ok := a.Limits(op); !ok {
+		return nil, &Error{HTTPStatus: http.StatusNotImplemented, Code: CodeUnknownOperation,
+			Message: fmt.Sprintf("operation not implemented (supported: %s)", strings.Join(a.Operations(), ", "))}
+	}
+	return a, nil
+}
+
+// Call performs provider/op through its adapter after enforcing the request size limit.
+func (r *Registry) Call(ctx context.Context, provider, op string, params json.RawMessage, secret []byte) (json.RawMessage, error) {
+	a, err := r.Lookup(provider, op)
+	if err != nil {
+		return nil, err
+	}
+	limits, _ := a.Limits(op)
+	if limits.MaxRequestBytes > 0 && int64(len(params)) > limits.MaxRequestBytes {
+		return nil, &Error{HTTPStatus: http.StatusRequestEntityTooLarge, Code: CodeRequestTooLarge,
+			Message: fmt.Sprintf("params exceed %d bytes", limits.MaxRequestBytes)}
+	}
+	return a.Call(ctx, r.client, op, params, secret)
+}
+
+// operation is one REST call of an httpAdapter.
+type operation struct {
+	limits Limits
+	// build validates params and returns the method, path (relative to the base URL), and JSON body (nil for none).
+	build func(params json.RawMessage) (method, path string, body any, err error)
+	// normalize maps a 2xx provider body onto the normalized result.
+	normalize func(body []byte) (json.RawMessage, error)
+}
+
+// httpAdapter is an Adapter for JSON-over-HTTP providers.
+type httpAdapter struct {
+	provider string
+	baseURL  string
+	ops      map[string]*operation
+	// authorize sets the credential and any provider-specific headers on req.
+	authorize func(req *http.Request, secret []byte)
+	// upstreamMessage extracts the error message from a non-2xx provider body.
+	upstreamMessage func(body []byte) string
+}
+
+func (a *httpAdapter) Provider() string { return a.provider }
+
+func (a *httpAdapter) Operations() []string {
+	names := make([]string, 0, len(a.ops))
+	for name := range a.ops {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (a *httpAdapter) Limits(op string) (Limits, bool) {
+	o, ok := a.ops[op]
+	if !ok {
+		return Limits{}, false
+	}
+	return o.limits, true
+}
+
+func (a *httpAdapter) Call(ctx context.Context, client *http.Client, op string, params json.RawMessage, secret []byte) (json.RawMessage, error) {
+	o, ok := a.ops[op]
+	if !ok {
+		return nil, &Error{HTTPStatus: http.StatusNotImplemented, Code: CodeUnknownOperation, Message: "operation not implemented"}
+	}
+	if len(bytes.TrimSpace(params)) == 0 {
+		params = json.RawMessage("{}")
+	}
+	method, path, body, err := o.build(params)
+	if err != nil {
+		return nil, &Error{HTTPStatus: http.StatusBadRequest, Code: CodeInvalidParams, Message: err.Error()}
+	}
+	var reqBody io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return nil, &Error{HTTPStatus: http.StatusBadRequest, Code: CodeInvalidParams, Message: err.Error()}
+		}
+		reqBody = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(a.baseURL, "/")+path, reqBody)
+	if err != nil {
+		return nil, &Error{HTTPStatus: http.StatusInternalServerError, Code: CodeUpstreamUnavailable, Message: "build provider request"}
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	a.authorize(req, secret)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, &Error{HTTPStatus: http.StatusBadGateway, Code: CodeUpstreamUnavailable, Message: "provider request failed"}
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, err := readLimited(resp.Body, o.limits.MaxResponseBytes)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &Error{HTTPStatus: http.StatusBadGateway, Code: CodeUpstreamError, UpstreamStatus: resp.StatusCode,
+			Message: truncate(a.upstreamMessage(respBody), maxUpstreamMessage)}
+	}
+	result, err := o.normalize(respBody)
+	if err != nil {
+		return nil, &Error{HTTPStatus: http.StatusBadGateway, Code: CodeUpstreamError, UpstreamStatus: resp.StatusCode,
+			Message: "unexpected provider response"}
+	}
+	return result, nil
+}
+
+// readLimited reads r, failing with CodeResponseTooLarge when it holds more than maxBytes (0 means no limit).
+func readLimited(r io.Reader, maxBytes int64) ([]byte, error) {
+	if maxBytes > 0 {
+		r = io.LimitReader(r, maxBytes+1)
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, &Error{HTTPStatus: http.StatusBadGateway, Code: CodeUpstreamUnavailable, Message: "read provider response"}
+	}
+	if maxBytes > 0 && int64(len(body)) > maxBytes {
+		return nil, &Error{HTTPStatus: http.StatusBadGateway, Code: CodeResponseTooLarge,
+			Message: fmt.Sprintf("provider response exceeds %d bytes", maxBytes)}
+	}
+	return body, nil
+}
+
+// bearer returns an authorize func that sends secret as a bearer token plus the given fixed headers.
+func bearer(headers map[string]string) func(req *http.Request, secret []byte) {
+	return func(req *http.Request, secret []byte) {
+		req.Header.Set("Authorization", "Bearer "+string(secret))
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+	}
+}
+
+// decodeParams strictly decodes params into v.
+func decodeParams(params json.RawMessage, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(params))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid params: %w", err)
+	}
+	return nil
+}
+
+// reshape decodes a provider body into v and re-encodes it, keeping only the fields v declares.
+func reshape[T any](body []byte) (json.RawMessage, error) {
+	var v T
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+func truncate(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+// AsError returns err as a normalized *Error, mapping unexpected errors to a 502.
+func AsError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return &Error{HTTPStatus: http.StatusBadGateway, Code: CodeUpstreamUnavailable, Message: "provider call failed"}
+}
@@ -0,0 +1,190 @@
+package apiegress
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// standIn serves fn on a local HTTP server and returns a registry with both adapters pointing at it.
+func standIn(t *testing.T, fn http.HandlerFunc) *Registry {
+	t.Helper()
+	srv := httptest.NewServer(fn)
+	t.Cleanup(srv.Close)
+	return NewRegistry(srv.Client(), NewOpenAI(srv.URL+"/v1"), NewGitHub(srv.URL))
+}
+
+func wantError(t *testing.T, err error, code string, httpStatus int) *Error {
+	t.Helper()
+	var e *Error
+	if !errors.As(err, &e) || e.Code != code || e.HTTPStatus != httpStatus {
+		t.Fatalf("err = %#v, want %s/%d", err, code, httpStatus)
+	}
+	return e
+}
+
+func TestOpenAI_ChatCompletions(t *testing.T) {
+	reg := standIn(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer sk-test" {
+			t.Errorf("Authorization = %q", got)
+		}
+		var body openAIChatParams
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body.Model != "gpt-test" || len(body.Messages) != 1 {
+			t.Errorf("body = %+v", body)
+		}
+		_, _ = io.WriteString(w, `{"id":"c1","model":"gpt-test","choices":[{"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4},"system_fingerprint":"x"}`)
+	})
+	res, err := reg.Call(context.Background(), ProviderOpenAI, "chat_completions",
+		json.RawMessage(`{"model":"gpt-test","messages":[{"role":"user","content":"hello"}]}`), []byte("sk-test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got openAIChatResult
+	if err := json.Unmarshal(res, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Message.Content != "hi" || got.FinishReason != "stop" || got.Usage.TotalTokens != 4 {
+		t.Errorf("result = %s", res)
+	}
+	if strings.Contains(string(res), "system_fingerprint") {
+		t.Errorf("result not normalized: %s", res)
+	}
+}
+
+func TestOpenAI_Embeddings(t *testing.T) {
+	reg := standIn(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		_, _ = io.WriteString(w, `{"object":"list","model":"emb","data":[{"object":"embedding","index":0,"embedding":[0.5,-1]}],"usage":{"prompt_tokens":2,"total_tokens":2}}`)
+	})
+	res, err := reg.Call(context.Background(), ProviderOpenAI, "embeddings", json.RawMessage(`{"model":"emb","input":["a"]}`), []byte("k"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"model":"emb","data":[{"index":0,"embedding":[0.5,-1]}],"usage":{"prompt_tokens":2,"total_tokens":2}}`; string(res) != want {
+		t.Errorf("result = %s", res)
+	}
+	_, err = reg.Call(context.Background(), ProviderOpenAI, "embeddings", json.RawMessage(`{"model":"emb","input":[]}`), []byte("k"))
+	wantError(t, err, CodeInvalidParams, http.StatusBadRequest)
+}
+
+func TestOpenAI_UpstreamError(t *testing.T) {
+	reg := standIn(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = io.WriteString(w, `{"error":{"message":"Incorrect API key provided"}}`)
+	})
+	_, err := reg.Call(context.Background(), ProviderOpenAI, "chat_completions",
+		json.RawMessage(`{"model":"m","messages":[{"role":"user","content":"x"}]}`), []byte("bad"))
+	e := wantError(t, err, CodeUpstreamError, http.StatusBadGateway)
+	if e.UpstreamStatus != http.StatusUnauthorized || e.Message != "Incorrect API key provided" {
+		t.Errorf("error = %+v", e)
+	}
+}
+
+func TestGitHub_Operations(t *testing.T) {
+	reg := standIn(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer ghp_x" || r.Header.Get("X-GitHub-Api-Version") != githubAPIVersion {
+			t.Errorf("headers = %v", r.Header)
+		}
+		switch r.Method + " " + r.URL.RequestURI() {
+		case "GET /repos/octo/hello":
+			_, _ = io.WriteString(w, `{"full_name":"octo/hello","default_branch":"main","owner":{"login":"octo"}}`)
+		case "GET /repos/octo/hello/issues?per_page=10&state=open":
+			_, _ = io.WriteString(w, `[{"number":1,"title":"bug","state":"open","user":{"login":"a","id":9}}]`)
+		case "POST /repos/octo/hello/issues":
+			b, _ := io.ReadAll(r.Body)
+			if string(b) != `{"body":"details","title":"new"}` {
+				t.Errorf("create body = %s", b)
+			}
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, `{"number":2,"title":"new","state":"open"}`)
+		case "POST /repos/octo/hello/issues/2/comments":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, `{"id":7,"body":"ok"}`)
+		case "POST /repos/octo/hello/pulls":
+			b, _ := io.ReadAll(r.Body)
+			if string(b) != `{"base":"main","draft":true,"head":"fix","title":"Fix"}` {
+				t.Errorf("pull body = %s", b)
+			}
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, `{"number":3,"title":"Fix","state":"open","draft":true,"head":{"ref":"fix","sha":"abc"},"base":{"ref":"main"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"message":"Not Found"}`)
+		}
+	})
+	ctx := context.Background()
+	secret := []byte("ghp_x")
+	cases := []struct {
+		op, params, want string
+	}{
+		{"get_repo", `{"owner":"octo","repo":"hello"}`, `{"full_name":"octo/hello","description":"","default_branch":"main","private":false,"archived":false,"html_url":""}`},
+		{"list_issues", `{"owner":"octo","repo":"hello","state":"open","per_page":10}`, `[{"number":1,"title":"bug","body":"","state":"open","user":{"login":"a"},"html_url":"","created_at":"","updated_at":""}]`},
+		{"create_issue", `{"owner":"octo","repo":"hello","title":"new","body":"details"}`, `{"number":2,"title":"new","body":"","state":"open","user":{"login":""},"html_url":"","created_at":"","updated_at":""}`},
+		{"create_issue_comment", `{"owner":"octo","repo":"hello","number":2,"body":"ok"}`, `{"id":7,"body":"ok","user":{"login":""},"html_url":"","created_at":""}`},
+		{"create_pull_request", `{"owner":"octo","repo":"hello","title":"Fix","head":"fix","base":"main","draft":true}`, `{"number":3,"title":"Fix","state":"open","draft":true,"user":{"login":""},"html_url":"","head":{"ref":"fix","sha":"abc"},"base":{"ref":"main","sha":""}}`},
+	}
+	for _, tc := range cases {
+		res, err := reg.Call(ctx, ProviderGitHub, tc.op, json.RawMessage(tc.params), secret)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.op, err)
+		}
+		if string(res) != tc.want {
+			t.Errorf("%s = %s", tc.op, res)
+		}
+	}
+	_, err := reg.Call(ctx, ProviderGitHub, "get_issue", json.RawMessage(`{"owner":"octo","repo":"hello","number":99}`), secret)
+	if e := wantError(t, err, CodeUpstreamError, http.StatusBadGateway); e.UpstreamStatus != http.StatusNotFound || e.Message != "Not Found" {
+		t.Errorf("error = %+v", e)
+	}
+}
+
+func TestGitHub_InvalidParams(t *testing.T) {
+	reg := standIn(t, func(http.ResponseWriter, *http.Request) { t.Error("provider must not be called") })
+	for _, params := range []string{
+		`{"owner":"../x","repo":"hello"}`,
+		`{"owner":"octo","repo":".."}`,
+		`{"owner":"octo","repo":"hello","extra":1}`,
+		`{"owner":"octo","repo":"hello","state":"bogus"}`,
+	} {
+		_, err := reg.Call(context.Background(), ProviderGitHub, "list_issues", json.RawMessage(params), []byte("k"))
+		wantError(t, err, CodeInvalidParams, http.StatusBadRequest)
+	}
+}
+
+func TestRegistry_Limits(t *testing.T) {
+	reg := standIn(t, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"full_name":"`+strings.Repeat("x", 2<<20)+`"}`)
+	})
+	ctx := context.Background()
+	_, err := reg.Call(ctx, ProviderGitHub, "get_repo", json.RawMessage(`{"owner":"o","repo":"r"}`), []byte("k"))
+	wantError(t, err, CodeResponseTooLarge, http.StatusBadGateway)
+
+	big := json.RawMessage(`{"owner":"o","repo":"r","title":"` + strings.Repeat("x", 64<<10) + `"}`)
+	_, err = reg.Call(ctx, ProviderGitHub, "create_issue", big, []byte("k"))
+	wantError(t, err, CodeRequestTooLarge, http.StatusRequestEntityTooLarge)
+}
+
+func TestRegistry_Lookup(t *testing.T) {
+	reg := NewRegistry(http.DefaultClient, NewOpenAI(DefaultOpenAIBaseURL))
+	_, err := reg.Lookup("slack", "post_message")
+	wantError(t, err, CodeUnknownProvider, http.StatusNotImplemented)
+	_, err = reg.Lookup(ProviderOpenAI, "images")
+	e := wantError(t, err, CodeUnknownOperation, http.StatusNotImplemented)
+	if !strings.Contains(e.Message, "chat_completions, embeddings") {
+		t.Errorf("message = %q", e.Message)
+	}
+	if AsError(errors.New("boom")).HTTPStatus != http.StatusBadGateway {
+		t.Error("AsError should map unknown errors to 502")
+	}
+}
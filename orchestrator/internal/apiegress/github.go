@@ -0,0 +1,252 @@
+package apiegress
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+)
+
+// ProviderGitHub is the provider name of the GitHub REST adapter.
+const ProviderGitHub = "github"
+
+// DefaultGitHubBaseURL is the API root used when API_EGRESS_GITHUB_BASE_URL is unset.
+const DefaultGitHubBaseURL = "https://api.github.com"
+
+// githubAPIVersion is sent as X-GitHub-Api-Version.
+const githubAPIVersion = "2022-11-28"
+
+// githubName matches GitHub owner and repository names.
+var githubName = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,100}$`)
+
+// NewGitHub returns the adapter for the GitHub REST API rooted at baseURL (e.g. https://api.github.com or a
+// GitHub Enterprise /api/v3 root). Operations: get_repo, list_issues, get_issue, create_issue, create_issue_comment,
+// create_pull_request.
+func NewGitHub(baseURL string) Adapter {
+	small := Limits{MaxRequestBytes: 64 << 10, MaxResponseBytes: 1 << 20}
+	return &httpAdapter{
+		provider: ProviderGitHub,
+		baseURL:  baseURL,
+		ops: map[string]*operation{
+			"get_repo":             {limits: small, build: buildGitHubGetRepo, normalize: reshape[githubRepo]},
+			"list_issues":          {limits: Limits{MaxRequestBytes: 4 << 10, MaxResponseBytes: 4 << 20}, build: buildGitHubListIssues, normalize: reshape[[]githubIssue]},
+			"get_issue":            {limits: small, build: buildGitHubGetIssue, normalize: reshape[githubIssue]},
+			"create_issue":         {limits: small, build: buildGitHubCreateIssue, normalize: reshape[githubIssue]},
+			"create_issue_comment": {limits: small, build: buildGitHubCreateComment, normalize: reshape[githubComment]},
+			"create_pull_request":  {limits: small, build: buildGitHubCreatePull, normalize: reshape[githubPull]},
+		},
+		authorize: bearer(map[string]string{
+			"Accept":               "application/vnd.github+json",
+			"X-GitHub-Api-Version": githubAPIVersion,
+		}),
+		upstreamMessage: githubErrorMessage,
+	}
+}
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+type githubRepo struct {
+	FullName      string `json:"full_name"`
+	Description   string `json:"description"`
+	DefaultBranch string `json:"default_branch"`
+	Private       bool   `json:"private"`
+	Archived      bool   `json:"archived"`
+	HTMLURL       string `json:"html_url"`
+}
+
+type githubIssue struct {
+	Number    int        `json:"number"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	State     string     `json:"state"`
+	User      githubUser `json:"user"`
+	HTMLURL   string     `json:"html_url"`
+	CreatedAt string     `json:"created_at"`
+	UpdatedAt string     `json:"updated_at"`
+}
+
+type githubComment struct {
+	ID        int64      `json:"id"`
+	Body      string     `json:"body"`
+	User      githubUser `json:"user"`
+	HTMLURL   string     `json:"html_url"`
+	CreatedAt string     `json:"created_at"`
+}
+
+type githubPull struct {
+	Number  int        `json:"number"`
+	Title   string     `json:"title"`
+	State   string     `json:"state"`
+	Draft   bool       `json:"draft"`
+	User    githubUser `json:"user"`
+	HTMLURL string     `json:"html_url"`
+	Head    githubRef  `json:"head"`
+	Base    githubRef  `json:"base"`
+}
+
+type githubRef struct {
+	Ref string `json:"ref"`
+	SHA string `json:"sha"`
+}
+
+type githubRepoParams struct {
+	Owner string `json:"owner"`
+	Repo  string `json:"repo"`
+}
+
+// repoPath validates owner and repo and returns /repos/{owner}/{repo}.
+func (p *githubRepoParams) repoPath() (string, error) {
+	if !githubName.MatchString(p.Owner) || !githubName.MatchString(p.Repo) || p.Repo == "." || p.Repo == ".." {
+		return "", errors.New("owner and repo are required and must be valid GitHub names")
+	}
+	return "/repos/" + url.PathEscape(p.Owner) + "/" + url.PathEscape(p.Repo), nil
+}
+
+func buildGitHubGetRepo(params json.RawMessage) (method, path string, body any, err error) {
+	var p githubRepoParams
+	if err := decodeParams(params, &p); err != nil {
+		return "", "", nil, err
+	}
+	path, err = p.repoPath()
+	return http.MethodGet, path, nil, err
+}
+
+func buildGitHubListIssues(params json.RawMessage) (method, path string, body any, err error) {
+	var p struct {
+		githubRepoParams
+		State   string `json:"state,omitempty"`
+		PerPage int    `json:"per_page,omitempty"`
+		Page    int    `json:"page,omitempty"`
+	}
+	if err := decodeParams(params, &p); err != nil {
+		return "", "", nil, err
+	}
+	if path, err = p.repoPath(); err != nil {
+		return "", "", nil, err
+	}
+	q := url.Values{}
+	switch p.State {
+	case "":
+	case "open", "closed", "all":
+		q.Set("state", p.State)
+	default:
+		return "", "", nil, errors.New("state must be open, closed, or all")
+	}
+	if p.PerPage < 0 || p.PerPage > 100 || p.Page < 0 {
+		return "", "", nil, errors.New("per_page must be 1-100 and page positive")
+	}
+	if p.PerPage > 0 {
+		q.Set("per_page", strconv.Itoa(p.PerPage))
+	}
+	if p.Page > 0 {
+		q.Set("page", strconv.Itoa(p.Page))
+	}
+	path += "/issues"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	return http.MethodGet, path, nil, nil
+}
+
+func buildGitHubGetIssue(params json.RawMessage) (method, path string, body any, err error) {
+	var p struct {
+		githubRepoParams
+		Number int `json:"number"`
+	}
+	if err := decodeParams(params, &p); err != nil {
+		return "", "", nil, err
+	}
+	if path, err = p.repoPath(); err != nil {
+		return "", "", nil, err
+	}
+	if p.Number <= 0 {
+		return "", "", nil, errors.New("number is required")
+	}
+	return http.MethodGet, path + "/issues/" + strconv.Itoa(p.Number), nil, nil
+}
+
+func buildGitHubCreateIssue(params json.RawMessage) (method, path string, body any, err error) {
+	var p struct {
+		githubRepoParams
+		Title  string   `json:"title"`
+		Body   string   `json:"body,omitempty"`
+		Labels []string `json:"labels,omitempty"`
+	}
+	if err := decodeParams(params, &p); err != nil {
+		return "", "", nil, err
+	}
+	if path, err = p.repoPath(); err != nil {
+		return "", "", nil, err
+	}
+	if p.Title == "" {
+		return "", "", nil, errors.New("title is required")
+	}
+	issue := map[string]any{"title": p.Title}
+	if p.Body != "" {
+		issue["body"] = p.Body
+	}
+	if len(p.Labels) > 0 {
+		issue["labels"] = p.Labels
+	}
+	return http.MethodPost, path + "/issues", issue, nil
+}
+
+func buildGitHubCreateComment(params json.RawMessage) (method, path string, body any, err error) {
+	var p struct {
+		githubRepoParams
+		Number int    `json:"number"`
+		Body   string `json:"body"`
+	}
+	if err := decodeParams(params, &p); err != nil {
+		return "", "", nil, err
+	}
+	if path, err = p.repoPath(); err != nil {
+		return "", "", nil, err
+	}
+	if p.Number <= 0 || p.Body == "" {
+		return "", "", nil, errors.New("number and body are required")
+	}
+	return http.MethodPost, path + "/issues/" + strconv.Itoa(p.Number) + "/comments", map[string]string{"body": p.Body}, nil
+}
+
+func buildGitHubCreatePull(params json.RawMessage) (method, path string, body any, err error) {
+	var p struct {
+		githubRepoParams
+		Title string `json:"title"`
+		Head  string `json:"head"`
+		Base  string `json:"base"`
+		Body  string `json:"body,omitempty"`
+		Draft bool   `json:"draft,omitempty"`
+	}
+	if err := decodeParams(params, &p); err != nil {
+		return "", "", nil, err
+	}
+	if path, err = p.repoPath(); err != nil {
+		return "", "", nil, err
+	}
+	if p.Title == "" || p.Head == "" || p.Base == "" {
+		return "", "", nil, errors.New("title, head, and base are required")
+	}
+	pull := map[string]any{"title": p.Title, "head": p.Head, "base": p.Base}
+	if p.Body != "" {
+		pull["body"] = p.Body
+	}
+	if p.Draft {
+		pull["draft"] = true
+	}
+	return http.MethodPost, path + "/pulls", pull, nil
+}
+
+func githubErrorMessage(body []byte) string {
+	var e struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &e) == nil && e.Message != "" {
+		return e.Message
+	}
+	return string(body)
+}
@@ -0,0 +1,146 @@
+package apiegress
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ProviderOpenAI is the provider name of the OpenAI-compatible adapter.
+const ProviderOpenAI = "openai"
+
+// DefaultOpenAIBaseURL is the API root used when API_EGRESS_OPENAI_BASE_URL is unset.
+const DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// NewOpenAI returns the adapter for OpenAI-compatible APIs rooted at baseURL (e.g. https://api.openai.com/v1).
+// Operations: chat_completions (POST /chat/completions, non-streaming) and embeddings (POST /embeddings).
+func NewOpenAI(baseURL string) Adapter {
+	return &httpAdapter{
+		provider: ProviderOpenAI,
+		baseURL:  baseURL,
+		ops: map[string]*operation{
+			"chat_completions": {
+				limits:    Limits{MaxRequestBytes: 256 << 10, MaxResponseBytes: 1 << 20},
+				build:     buildOpenAIChat,
+				normalize: normalizeOpenAIChat,
+			},
+			"embeddings": {
+				limits:    Limits{MaxRequestBytes: 256 << 10, MaxResponseBytes: 8 << 20},
+				build:     buildOpenAIEmbeddings,
+				normalize: reshape[openAIEmbeddingsResult],
+			},
+		},
+		authorize:       bearer(nil),
+		upstreamMessage: openAIErrorMessage,
+	}
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIChatParams struct {
+	Model       string          `json:"model"`
+	Messages    []openAIMessage `json:"messages"`
+	Temperature *float64        `json:"temperature,omitempty"`
+	MaxTokens   *int            `json:"max_tokens,omitempty"`
+}
+
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens,omitempty"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// openAIChatResult is the normalized chat_completions result: the first choice only.
+type openAIChatResult struct {
+	ID           string        `json:"id"`
+	Model        string        `json:"model"`
+	Message      openAIMessage `json:"message"`
+	FinishReason string        `json:"finish_reason"`
+	Usage        openAIUsage   `json:"usage"`
+}
+
+func buildOpenAIChat(params json.RawMessage) (method, path string, body any, err error) {
+	var p openAIChatParams
+	if err := decodeParams(params, &p); err != nil {
+		return "", "", nil, err
+	}
+	if strings.TrimSpace(p.Model) == "" {
+		return "", "", nil, errors.New("model is required")
+	}
+	if len(p.Messages) == 0 {
+		return "", "", nil, errors.New("messages is required")
+	}
+	return http.MethodPost, "/chat/completions", p, nil
+}
+
+func normalizeOpenAIChat(body []byte) (json.RawMessage, error) {
+	var raw struct {
+		ID      string `json:"id"`
+		Model   string `json:"model"`
+		Choices []struct {
+			Message      openAIMessage `json:"message"`
+			FinishReason string        `json:"finish_reason"`
+		} `json:"choices"`
+		Usage openAIUsage `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	if len(raw.Choices) == 0 {
+		return nil, errors.New("no choices")
+	}
+	return json.Marshal(openAIChatResult{
+		ID:           raw.ID,
+		Model:        raw.Model,
+		Message:      raw.Choices[0].Message,
+		FinishReason: raw.Choices[0].FinishReason,
+		Usage:        raw.Usage,
+	})
+}
+
+type openAIEmbeddingsParams struct {
+	Model string          `json:"model"`
+	Input json.RawMessage `json:"input"`
+}
+
+// openAIEmbeddingsResult is the normalized embeddings result, one vector per input in order.
+type openAIEmbeddingsResult struct {
+	Model string `json:"model"`
+	Data  []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+	Usage openAIUsage `json:"usage"`
+}
+
+func buildOpenAIEmbeddings(params json.RawMessage) (method, path string, body any, err error) {
+	var p openAIEmbeddingsParams
+	if err := decodeParams(params, &p); err != nil {
+		return "", "", nil, err
+	}
+	if strings.TrimSpace(p.Model) == "" {
+		return "", "", nil, errors.New("model is required")
+	}
+	var one string
+	var many []string
+	if json.Unmarshal(p.Input, &one) != nil && (json.Unmarshal(p.Input, &many) != nil || len(many) == 0) {
+		return "", "", nil, errors.New("input must be a string or a non-empty array of strings")
+	}
+	return http.MethodPost, "/embeddings", p, nil
+}
+
+func openAIErrorMessage(body []byte) string {
+	var e struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &e) == nil && e.Error.Message != "" {
+		return e.Error.Message
+	}
+	return string(body)
+}
@@ -0,0 +1,180 @@
+package artifacts
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/config"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
+)
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestCleanPath(t *testing.T) {
+	for in, want := range map[string]string{
+		"out.txt":         "out.txt",
+		"dir/./out.txt":   "dir/out.txt",
+		"dir//sub/a.json": "dir/sub/a.json",
+	} {
+		got, err := CleanPath(in)
+		if err != nil || got != want {
+			t.Errorf("CleanPath(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", " ", "/etc/passwd", "../x", "a/../../x", "a/..", ".", "a\\b", "a\x00b", strings.Repeat("a", maxPathLen+1)} {
+		if _, err := CleanPath(in); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("CleanPath(%q) err = %v, want ErrInvalidPath", in, err)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	s, err := New(&config.OrchestratorConfig{ArtifactStore: "fs", ArtifactFSRoot: t.TempDir()})
+	if err != nil || s.Backend() != BackendFS {
+		t.Fatalf("fs: %v, %v", s, err)
+	}
+	s, err = New(&config.OrchestratorConfig{ArtifactStore: "S3", ArtifactS3Endpoint: "http://minio:9000", ArtifactS3Bucket: "b"})
+	if err != nil || s.Backend() != BackendS3 {
+		t.Fatalf("s3: %v, %v", s, err)
+	}
+	if _, err := New(&config.OrchestratorConfig{ArtifactStore: "s3"}); err == nil {
+		t.Error("s3 without endpoint: want error")
+	}
+	if _, err := New(&config.OrchestratorConfig{ArtifactStore: "gcs"}); err == nil {
+		t.Error("unknown backend: want error")
+	}
+}
+
+func TestFSStore_RoundTrip(t *testing.T) {
+	root := t.TempDir()
+	s, err := NewFSStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	key := Key(uuid.New(), "dir/out.txt")
+	if err := s.Put(ctx, key, strings.NewReader("hello"), 5); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := s.Put(ctx, key, strings.NewReader("short"), 9); err == nil {
+		t.Error("Put with wrong size: want error")
+	}
+	rc, err := s.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	b, _ := io.ReadAll(rc)
+	_ = rc.Close()
+	if string(b) != "hello" {
+		t.Errorf("content = %q", b)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(filepath.Join(root, filepath.FromSlash(key))))
+	if len(entries) != 1 {
+		t.Errorf("leftover temp files: %v", entries)
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		t.Errorf("Delete missing: %v", err)
+	}
+	if _, err := s.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after delete: %v", err)
+	}
+	if _, err := s.Get(ctx, "../escape"); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("Get escape: %v", err)
+	}
+}
+
+func TestSaveAndOpen(t *testing.T) {
+	db := testutil.NewMockDB()
+	s, _ := NewFSStore(t.TempDir())
+	ctx := context.Background()
+	taskID := uuid.New()
+
+	art, created, err := Save(ctx, db, s, &Upload{TaskID: taskID, Path: "out.txt", ContentType: "text/plain",
+		Body: strings.NewReader("hello"), ChecksumSHA256: strings.ToUpper(sha256Hex("hello"))})
+	if err != nil || !created {
+		t.Fatalf("Save: %v, created=%v", err, created)
+	}
+	if art.StorageRef != "fs:tasks/"+taskID.String()+"/out.txt" || *art.SizeBytes != 5 || *art.ChecksumSHA256 != sha256Hex("hello") {
+		t.Errorf("artifact = %+v", art)
+	}
+	art, created, err = Save(ctx, db, s, &Upload{TaskID: taskID, Path: "out.txt", Body: strings.NewReader("bye")})
+	if err != nil || created {
+		t.Fatalf("Save replace: %v, created=%v", err, created)
+	}
+	if len(db.TaskArtifacts) != 1 {
+		t.Fatalf("rows = %d", len(db.TaskArtifacts))
+	}
+
+	f, err := Open(ctx, s, art)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, f)
+	_ = f.Close()
+	if buf.String() != "bye" {
+		t.Errorf("content = %q", buf.String())
+	}
+}
+
+func TestSave_Rejects(t *testing.T) {
+	db := testutil.NewMockDB()
+	s, _ := NewFSStore(t.TempDir())
+	ctx := context.Background()
+	_, _, err := Save(ctx, db, s, &Upload{TaskID: uuid.New(), Path: "a", Body: strings.NewReader("hello"), ChecksumSHA256: sha256Hex("other")})
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("mismatch err = %v", err)
+	}
+	_, _, err = Save(ctx, db, s, &Upload{TaskID: uuid.New(), Path: "a", Body: strings.NewReader("hello"), MaxBytes: 4})
+	if !errors.Is(err, ErrTooLarge) {
+		t.Errorf("too large err = %v", err)
+	}
+	if len(db.TaskArtifacts) != 0 {
+		t.Errorf("rows = %d", len(db.TaskArtifacts))
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	db := testutil.NewMockDB()
+	s, _ := NewFSStore(t.TempDir())
+	ctx := context.Background()
+	art, _, err := Save(ctx, db, s, &Upload{TaskID: uuid.New(), Path: "a", Body: strings.NewReader("hello")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bad := *art
+	wrong := sha256Hex("tampered")
+	bad.ChecksumSHA256 = &wrong
+	if _, err := Open(ctx, s, &bad); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("tampered: %v", err)
+	}
+	ref := *art
+	ref.StorageRef = ""
+	if _, err := Open(ctx, s, &ref); !errors.Is(err, ErrNoContent) {
+		t.Errorf("path-only ref: %v", err)
+	}
+	ref.StorageRef = "s3:" + Key(art.TaskID, "a")
+	if _, err := Open(ctx, s, &ref); !errors.Is(err, ErrNoContent) {
+		t.Errorf("other backend: %v", err)
+	}
+	_ = s.Delete(ctx, Key(art.TaskID, "a"))
+	if _, err := Open(ctx, s, art); !errors.Is(err, ErrNoContent) {
+		t.Errorf("deleted object: %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package artifacts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FSStore keeps artifacts as files under a root directory (ARTIFACT_FS_ROOT).
+type FSStore struct {
+	root string
+}
+
+// NewFSStore returns a filesystem store rooted at root, creating the directory if needed.
+func NewFSStore(root string) (*FSStore, error) {
+	if strings.TrimSpace(root) == "" {
+		return nil, errors.New("artifact fs root is required")
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("artifact fs root: %w", err)
+	}
+	if err := os.MkdirAll(abs, 0o750); err != nil {
+		return nil, fmt.Errorf("create artifact fs root: %w", err)
+	}
+	return &FSStore{root: abs}, nil
+}
+
+// Backend implements Store.
+func (s *FSStore) Backend() string { return BackendFS }
+
+func (s *FSStore) file(key string) (string, error) {
+	p := filepath.Join(s.root, filepath.FromSlash(key))
+	if !strings.HasPrefix(p, s.root+string(filepath.Separator)) {
+		return "", ErrInvalidPath
+	}
+	return p, nil
+}
+
+// Put implements Store. The file is written to a temporary name and renamed, so readers never see a partial object.
+func (s *FSStore) Put(_ context.Context, key string, r io.Reader, size int64) error {
+	p, err := s.file(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return fmt.Errorf("create artifact dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("create artifact temp file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	n, err := io.Copy(tmp, r)
+	if err == nil && size >= 0 && n != size {
+		err = fmt.Errorf("wrote %d bytes, want %d", n, size)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("write artifact: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return fmt.Errorf("store artifact: %w", err)
+	}
+	return nil
+}
+
+// Get implements Store.
+func (s *FSStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	p, err := s.file(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Delete implements Store.
+func (s *FSStore) Delete(_ context.Context, key string) error {
+	p, err := s.file(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package artifacts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+var (
+	// ErrTooLarge is returned when an upload exceeds Upload.MaxBytes.
+	ErrTooLarge = errors.New("artifact too large")
+	// ErrChecksumMismatch is returned when content does not match the expected or recorded SHA-256.
+	ErrChecksumMismatch = errors.New("artifact checksum mismatch")
+	// ErrNoContent is returned by Open for artifacts whose storage_ref does not point into the store
+	// (path-only attachment references or content written by another backend).
+	ErrNoContent = errors.New("artifact has no stored content")
+)
+
+// Upload describes artifact content to save for a task.
+type Upload struct {
+	TaskID      uuid.UUID
+	RunID       *uuid.UUID
+	Path        string // already cleaned with CleanPath
+	ContentType string
+	Body        io.Reader
+	// MaxBytes bounds the body; 0 means no limit.
+	MaxBytes int64
+	// ChecksumSHA256 is the hex digest the client expects; empty skips verification.
+	ChecksumSHA256 string
+}
+
+// Save spools u.Body to a temporary file while hashing it, verifies size and checksum, writes it to the store,
+// and upserts the task_artifacts row. created reports whether the row is new.
+func Save(ctx context.Context, db database.Store, s Store, u *Upload) (art *models.TaskArtifact, created bool, err error) {
+	spool, size, sum, err := spoolAndHash(u.Body, u.MaxBytes)
+	if err != nil {
+		return nil, false, err
+	}
+	defer func() { _ = spool.Close() }()
+	if u.ChecksumSHA256 != "" && !strings.EqualFold(strings.TrimSpace(u.ChecksumSHA256), sum) {
+		return nil, false, ErrChecksumMismatch
+	}
+	key := Key(u.TaskID, u.Path)
+	if err := s.Put(ctx, key, spool, size); err != nil {
+		return nil, false, fmt.Errorf("put artifact: %w", err)
+	}
+	existing, err := db.GetArtifactByTaskIDAndPath(ctx, u.TaskID, u.Path)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		return nil, false, err
+	}
+	art = &models.TaskArtifact{
+		TaskID:         u.TaskID,
+		RunID:          u.RunID,
+		Path:           u.Path,
+		StorageRef:     StorageRef(s, key),
+		SizeBytes:      &size,
+		ChecksumSHA256: &sum,
+	}
+	if u.ContentType != "" {
+		ct := u.ContentType
+		art.ContentType = &ct
+	}
+	if err := db.UpsertTaskArtifact(ctx, art); err != nil {
+		return nil, false, err
+	}
+	return art, existing == nil, nil
+}
+
+// Open returns the stored content of art after verifying it against the recorded checksum. The content is
+// spooled to a temporary file that is removed on Close, so callers can seek (e.g. http.ServeContent).
+func Open(ctx context.Context, s Store, art *models.TaskArtifact) (io.ReadSeekCloser, error) {
+	key, ok := keyFromRef(s, art.StorageRef)
+	if !ok {
+		return nil, ErrNoContent
+	}
+	rc, err := s.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrNoContent
+		}
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+	spool, _, sum, err := spoolAndHash(rc, 0)
+	if err != nil {
+		return nil, err
+	}
+	if art.ChecksumSHA256 != nil && *art.ChecksumSHA256 != "" && !strings.EqualFold(*art.ChecksumSHA256, sum) {
+		_ = spool.Close()
+		return nil, ErrChecksumMismatch
+	}
+	return spool, nil
+}
+
+// tempFile is an *os.File that removes itself on Close.
+type tempFile struct {
+	*os.File
+}
+
+func (f tempFile) Close() error {
+	err := f.File.Close()
+	_ = os.Remove(f.Name())
+	return err
+}
+
+// spoolAndHash copies r to a temporary file (rewound to the start) and returns its size and hex SHA-256.
+// It returns ErrTooLarge when maxBytes > 0 and r holds more than maxBytes.
+func spoolAndHash(r io.Reader, maxBytes int64) (tempFile, int64, string, error) {
+	f, err := os.CreateTemp("", "cynodeai-artifact-*")
+	if err != nil {
+		return tempFile{}, 0, "", fmt.Errorf("create artifact spool: %w", err)
+	}
+	tf := tempFile{f}
+	h := sha256.New()
+	src := r
+	if maxBytes > 0 {
+		src = io.LimitReader(r, maxBytes+1)
+	}
+	n, err := io.Copy(io.MultiWriter(f, h), src)
+	if err == nil && maxBytes > 0 && n > maxBytes {
+		err = ErrTooLarge
+	}
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		_ = tf.Close()
+		return tempFile{}, 0, "", err
+	}
+	return tf, n, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HasContent reports whether art's storage_ref points at content in s.
+func HasContent(s Store, art *models.TaskArtifact) bool {
+	_, ok := keyFromRef(s, art.StorageRef)
+	return ok
+}
@@ -0,0 +1,99 @@
+// Package artifacts stores task artifact content in a pluggable backend (local filesystem or S3-compatible
+// object storage) and keeps task_artifacts rows in step with it.
+// See docs/tech_specs/orchestrator.md (Artifact Storage) and postgres_schema.md (Task Artifacts).
+package artifacts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/config"
+)
+
+// Backend names (ARTIFACT_STORE) and the storage_ref scheme each one writes.
+const (
+	BackendFS = "fs"
+	BackendS3 = "s3"
+)
+
+// maxPathLen bounds an artifact path (task_artifacts.path).
+const maxPathLen = 1024
+
+var (
+	// ErrNotFound is returned when an object does not exist in the store.
+	ErrNotFound = errors.New("artifact not found")
+	// ErrInvalidPath is returned for artifact paths that are empty, absolute, or escape the task (e.g. "..").
+	ErrInvalidPath = errors.New("invalid artifact path")
+)
+
+// Store is an artifact content backend. Keys are slash-separated and already validated.
+type Store interface {
+	// Put writes size bytes from r under key, replacing any existing object.
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Get opens the object under key; the caller closes it. Returns ErrNotFound when it does not exist.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object under key; deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// Backend returns the backend name recorded in storage_ref (BackendFS or BackendS3).
+	Backend() string
+}
+
+// New returns the store selected by ARTIFACT_STORE.
+func New(cfg *config.OrchestratorConfig) (Store, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.ArtifactStore)) {
+	case "", BackendFS:
+		return NewFSStore(cfg.ArtifactFSRoot)
+	case BackendS3:
+		return NewS3Store(S3Config{
+			Endpoint:        cfg.ArtifactS3Endpoint,
+			Bucket:          cfg.ArtifactS3Bucket,
+			Region:          cfg.ArtifactS3Region,
+			AccessKeyID:     cfg.ArtifactS3AccessKeyID,
+			SecretAccessKey: cfg.ArtifactS3SecretAccessKey,
+		})
+	default:
+		return nil, fmt.Errorf("unknown ARTIFACT_STORE %q (want fs or s3)", cfg.ArtifactStore)
+	}
+}
+
+// CleanPath validates a client-supplied artifact path and returns its canonical form
+// (slash-separated, relative, no "." or ".." segments).
+func CleanPath(p string) (string, error) {
+	p = strings.TrimSpace(p)
+	if p == "" || len(p) > maxPathLen || strings.HasPrefix(p, "/") || strings.ContainsAny(p, "\\\x00") {
+		return "", ErrInvalidPath
+	}
+	for _, seg := range strings.Split(p, "/") {
+		if seg == ".." {
+			return "", ErrInvalidPath
+		}
+	}
+	clean := path.Clean(p)
+	if clean == "." {
+		return "", ErrInvalidPath
+	}
+	return clean, nil
+}
+
+// Key returns the object key for a task artifact path (already cleaned).
+func Key(taskID uuid.UUID, cleanPath string) string {
+	return "tasks/" + taskID.String() + "/" + cleanPath
+}
+
+// StorageRef returns the task_artifacts.storage_ref for key in s, e.g. "fs:tasks/<id>/out.txt".
+func StorageRef(s Store, key string) string {
+	return s.Backend() + ":" + key
+}
+
+// keyFromRef returns the key of a storage_ref written by s. ok is false for empty refs (path-only attachment
+// references) and refs written by another backend.
+func keyFromRef(s Store, ref string) (key string, ok bool) {
+	key, ok = strings.CutPrefix(ref, s.Backend()+":")
+	return key, ok && key != ""
+}
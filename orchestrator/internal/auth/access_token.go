@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// PersonalAccessTokenPrefix starts every personal access token, so the gateway can tell one from a JWT and
+// secret scanners can find leaked tokens.
+const PersonalAccessTokenPrefix = "cyn_pat_"
+
+// personalAccessTokenBytes is the entropy in a personal access token.
+const personalAccessTokenBytes = 32
+
+// personalAccessTokenDisplayLen is how much of a token is kept in clear (prefix included) to identify it in listings.
+const personalAccessTokenDisplayLen = len(PersonalAccessTokenPrefix) + 4
+
+// GeneratePersonalAccessToken returns a new random personal access token and its display prefix.
+// Store only HashToken(token); the token itself is shown to the caller once.
+func GeneratePersonalAccessToken() (token, displayPrefix string, err error) {
+	b := make([]byte, personalAccessTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("generate personal access token: %w", err)
+	}
+	token = PersonalAccessTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return token, token[:personalAccessTokenDisplayLen], nil
+}
+
+// IsPersonalAccessToken reports whether a bearer token is a personal access token rather than a JWT.
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
@@ -17,54 +17,86 @@ const (
 	TokenTypeAccess  TokenType = "access"
 	TokenTypeRefresh TokenType = "refresh"
 	TokenTypeNode    TokenType = "node"
+	TokenTypeWorker  TokenType = "worker"
 )
 
-// Claims represents JWT claims.
+// workerTokenDuration is the lifetime of tokens signed for Worker API calls. Workers check them when a request
+// arrives, so the lifetime does not limit how long a job may run.
+const workerTokenDuration = 5 * time.Minute
+
+// Claims represents JWT claims. SessionID (sid) is the refresh session an access token was issued for.
 type Claims struct {
 	jwt.RegisteredClaims
 	TokenType TokenType `json:"token_type"`
 	UserID    string    `json:"user_id,omitempty"`
 	Handle    string    `json:"handle,omitempty"`
+	SessionID string    `json:"sid,omitempty"`
 	NodeID    string    `json:"node_id,omitempty"`
 	NodeSlug  string    `json:"node_slug,omitempty"`
 }
 
-// JWTManager handles JWT operations.
+// Issuer is the iss claim of every token the orchestrator signs.
+const Issuer = "cynodeai"
+
+// JWTManager handles JWT operations. Tokens are signed with the keyring's signing key (EdDSA or ES256) and
+// carry its kid; services without signing material verify them against the JWKS (see Keyring.JWKS).
 type JWTManager struct {
-	secret          []byte
+	keys            *Keyring
 	accessDuration  time.Duration
 	refreshDuration time.Duration
 	nodeDuration    time.Duration
 }
 
-// NewJWTManager creates a new JWT manager.
+// NewJWTManager creates a JWT manager whose keyring is derived from secret (see KeyringFromSecret).
 func NewJWTManager(secret string, accessDuration, refreshDuration, nodeDuration time.Duration) *JWTManager {
+	return NewJWTManagerWithKeyring(KeyringFromSecret(secret), accessDuration, refreshDuration, nodeDuration)
+}
+
+// NewJWTManagerWithKeyring creates a JWT manager that signs and verifies with keys.
+func NewJWTManagerWithKeyring(keys *Keyring, accessDuration, refreshDuration, nodeDuration time.Duration) *JWTManager {
 	return &JWTManager{
-		secret:          []byte(secret),
+		keys:            keys,
 		accessDuration:  accessDuration,
 		refreshDuration: refreshDuration,
 		nodeDuration:    nodeDuration,
 	}
 }
 
-// GenerateAccessToken generates an access token for a user.
+// Keyring returns the keys tokens are signed and verified with.
+func (m *JWTManager) Keyring() *Keyring {
+	return m.keys
+}
+
+// GenerateAccessToken generates an access token for a user that belongs to no session, so it cannot be
+// revoked before it expires. Logins use GenerateSessionAccessToken.
 func (m *JWTManager) GenerateAccessToken(userID uuid.UUID, handle string) (string, error) {
+	token, _, err := m.GenerateSessionAccessToken(userID, handle, uuid.Nil)
+	return token, err
+}
+
+// GenerateSessionAccessToken generates an access token for a user's refresh session and returns its expiry.
+// The session id is the sid claim, which the gateway checks against the access token deny-list.
+func (m *JWTManager) GenerateSessionAccessToken(userID uuid.UUID, handle string, sessionID uuid.UUID) (string, time.Time, error) {
 	now := time.Now()
+	expiresAt := now.Add(m.accessDuration)
 	claims := &Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    "cynodeai",
+			Issuer:    Issuer,
 			Subject:   userID.String(),
 			IssuedAt:  jwt.NewNumericDate(now),
-			ExpiresAt: jwt.NewNumericDate(now.Add(m.accessDuration)),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			ID:        uuid.New().String(),
 		},
 		TokenType: TokenTypeAccess,
 		UserID:    userID.String(),
 		Handle:    handle,
 	}
+	if sessionID != uuid.Nil {
+		claims.SessionID = sessionID.String()
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(m.secret)
+	tokenStr, err := m.keys.Sign(claims)
+	return tokenStr, expiresAt, err
 }
 
 // GenerateRefreshToken generates a refresh token for a user.
@@ -73,7 +105,7 @@ func (m *JWTManager) GenerateRefreshToken(userID uuid.UUID) (string, time.Time,
 	expiresAt := now.Add(m.refreshDuration)
 	claims := &Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    "cynodeai",
+			Issuer:    Issuer,
 			Subject:   userID.String(),
 			IssuedAt:  jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
@@ -83,8 +115,7 @@ func (m *JWTManager) GenerateRefreshToken(userID uuid.UUID) (string, time.Time,
 		UserID:    userID.String(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenStr, err := token.SignedString(m.secret)
+	tokenStr, err := m.keys.Sign(claims)
 	return tokenStr, expiresAt, err
 }
 
@@ -94,7 +125,7 @@ func (m *JWTManager) GenerateNodeToken(nodeID uuid.UUID, nodeSlug string) (strin
 	expiresAt := now.Add(m.nodeDuration)
 	claims := &Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    "cynodeai",
+			Issuer:    Issuer,
 			Subject:   nodeID.String(),
 			IssuedAt:  jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
@@ -105,22 +136,39 @@ func (m *JWTManager) GenerateNodeToken(nodeID uuid.UUID, nodeSlug string) (strin
 		NodeSlug:  nodeSlug,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenStr, err := token.SignedString(m.secret)
+	tokenStr, err := m.keys.Sign(claims)
 	return tokenStr, expiresAt, err
 }
 
+// GenerateWorkerToken generates a short-lived token for one Worker API call to nodeSlug. Its audience is the
+// node slug, so a worker that receives it cannot replay it against another node.
+func (m *JWTManager) GenerateWorkerToken(nodeSlug string) (string, error) {
+	now := time.Now()
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    Issuer,
+			Subject:   nodeSlug,
+			Audience:  jwt.ClaimStrings{nodeSlug},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(workerTokenDuration)),
+			ID:        uuid.New().String(),
+		},
+		TokenType: TokenTypeWorker,
+		NodeSlug:  nodeSlug,
+	}
+	return m.keys.Sign(claims)
+}
+
 // ErrInvalidToken is returned when token validation fails.
 var ErrInvalidToken = errors.New("invalid token")
 
-// ValidateToken validates a JWT and returns claims.
+// validMethods are the only algorithms ValidateToken accepts; HMAC and "none" are always rejected.
+var validMethods = []string{jwt.SigningMethodEdDSA.Alg(), jwt.SigningMethodES256.Alg()}
+
+// ValidateToken validates a JWT against the keyring and returns claims.
 func (m *JWTManager) ValidateToken(tokenStr string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, ErrInvalidToken
-		}
-		return m.secret, nil
-	})
+	parser := jwt.NewParser(jwt.WithValidMethods(validMethods), jwt.WithIssuer(Issuer), jwt.WithExpirationRequired())
+	token, err := parser.ParseWithClaims(tokenStr, &Claims{}, m.keys.verificationKey)
 	if err != nil {
 		return nil, ErrInvalidToken
 	}
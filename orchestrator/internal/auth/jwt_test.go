@@ -160,3 +160,28 @@ func TestJWTManager_ValidateAccessToken_WithRefreshToken(t *testing.T) {
 		t.Errorf("ValidateAccessToken(refresh) error = %v, want %v", err, ErrInvalidToken)
 	}
 }
+
+func TestJWTManager_GenerateSessionAccessToken(t *testing.T) {
+	mgr := NewJWTManager("test-secret", 15*time.Minute, 7*24*time.Hour, 24*time.Hour)
+	userID, sessionID := uuid.New(), uuid.New()
+
+	token, expiresAt, err := mgr.GenerateSessionAccessToken(userID, "testuser", sessionID)
+	if err != nil {
+		t.Fatalf("GenerateSessionAccessToken() error = %v", err)
+	}
+	claims, err := mgr.ValidateAccessToken(token)
+	if err != nil {
+		t.Fatalf("ValidateAccessToken() error = %v", err)
+	}
+	if claims.SessionID != sessionID.String() {
+		t.Errorf("claims.SessionID = %q, want %q", claims.SessionID, sessionID)
+	}
+	if claims.ExpiresAt.After(expiresAt) {
+		t.Errorf("token expires at %v, after the returned expiry %v", claims.ExpiresAt, expiresAt)
+	}
+
+	token, _ = mgr.GenerateAccessToken(userID, "testuser")
+	if claims, _ := mgr.ValidateAccessToken(token); claims == nil || claims.SessionID != "" {
+		t.Errorf("sessionless token claims = %+v", claims)
+	}
+}
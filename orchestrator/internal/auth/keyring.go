@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/jwks"
+)
+
+// Keyring holds the asymmetric keys JWTs are signed and verified with. The first key signs; every key
+// verifies, so during a rotation the outgoing key keeps validating the tokens it signed until they expire,
+// and an incoming key can be published in the JWKS before any replica signs with it.
+// Each key's kid is its RFC 7638 thumbprint, so replicas loading the same files agree on key ids.
+type Keyring struct {
+	signer crypto.Signer
+	method jwt.SigningMethod
+	kid    string
+	keys   map[string]crypto.PublicKey
+	set    jwks.Set
+}
+
+// NewKeyring returns a keyring that signs with signer (Ed25519 or ECDSA P-256) and also accepts tokens signed
+// by the private keys matching verifyOnly.
+func NewKeyring(signer crypto.Signer, verifyOnly ...crypto.PublicKey) (*Keyring, error) {
+	method, err := signingMethodFor(signer)
+	if err != nil {
+		return nil, err
+	}
+	k := &Keyring{signer: signer, method: method, keys: map[string]crypto.PublicKey{}}
+	for i, pub := range append([]crypto.PublicKey{signer.Public()}, verifyOnly...) {
+		if i > 0 {
+			if _, err := signingMethodFor(pub); err != nil {
+				return nil, err
+			}
+		}
+		j, err := jwks.FromPublicKey(pub)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			k.kid = j.Kid
+		}
+		if _, dup := k.keys[j.Kid]; dup {
+			continue
+		}
+		k.keys[j.Kid] = pub
+		k.set.Keys = append(k.set.Keys, j)
+	}
+	return k, nil
+}
+
+// KeyringFromSecret derives an Ed25519 signing key from secret. It lets deployments that only set
+// JWT_SECRET keep working, with every replica deriving the same key, but the JWKS then depends on a shared
+// secret: production deployments should configure key files (see LoadKeyring).
+func KeyringFromSecret(secret string) *Keyring {
+	seed := sha256.Sum256([]byte("cynodeai-jwt-ed25519:" + secret))
+	k, err := NewKeyring(ed25519.NewKeyFromSeed(seed[:]))
+	if err != nil {
+		panic(err) // an Ed25519 key is always supported
+	}
+	return k
+}
+
+// placeholderJWTSecrets are JWT_SECRET defaults shipped in config and compose files. A key derived from one of
+// them is known to anyone who has read the repository, so LoadKeyring refuses them.
+var placeholderJWTSecrets = []string{"change-me-in-production", "dev-jwt-secret-change-in-production"}
+
+// ErrInsecureJWTSecret is returned by LoadKeyring when no key files are configured and the fallback secret is
+// empty or a shipped placeholder.
+var ErrInsecureJWTSecret = errors.New("JWT_SIGNING_KEY_FILES is not set and JWT_SECRET is empty or a placeholder default; configure signing key files or a unique JWT_SECRET")
+
+// LoadKeyring loads PEM key files: the first must hold a PKCS#8 Ed25519 or ECDSA P-256 private key and signs;
+// later files hold private or PKIX public keys that only verify (keys being rotated in or out).
+// Without files the keyring is derived from fallbackSecret (see KeyringFromSecret); ErrInsecureJWTSecret
+// when that secret is empty or a placeholder.
+func LoadKeyring(files []string, fallbackSecret string) (*Keyring, error) {
+	if len(files) == 0 {
+		secret := strings.TrimSpace(fallbackSecret)
+		if secret == "" || slices.Contains(placeholderJWTSecrets, secret) {
+			return nil, ErrInsecureJWTSecret
+		}
+		return KeyringFromSecret(fallbackSecret), nil
+	}
+	var signer crypto.Signer
+	var verifyOnly []crypto.PublicKey
+	for i, path := range files {
+		key, err := readPEMKey(path)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			s, ok := key.(crypto.Signer)
+			if !ok {
+				return nil, fmt.Errorf("jwt signing key %s: first key file must hold a private key", path)
+			}
+			signer = s
+			continue
+		}
+		if s, ok := key.(crypto.Signer); ok {
+			key = s.Public()
+		}
+		verifyOnly = append(verifyOnly, key)
+	}
+	k, err := NewKeyring(signer, verifyOnly...)
+	if err != nil {
+		return nil, fmt.Errorf("jwt signing keys: %w", err)
+	}
+	return k, nil
+}
+
+// readPEMKey returns the private (PKCS#8) or public (PKIX) key in the first PEM block of path.
+func readPEMKey(path string) (any, error) {
+	b, err := os.ReadFile(strings.TrimSpace(path))
+	if err != nil {
+		return nil, fmt.Errorf("jwt signing key: %w", err)
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("jwt signing key %s: no PEM block", path)
+	}
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("jwt signing key %s: %w", path, err)
+		}
+		return key, nil
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("jwt signing key %s: %w", path, err)
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("jwt signing key %s: unsupported PEM block %q (want PRIVATE KEY or PUBLIC KEY)", path, block.Type)
+}
+
+// signingMethodFor maps a private or public key to its JWS algorithm: EdDSA for Ed25519, ES256 for P-256.
+func signingMethodFor(key any) (jwt.SigningMethod, error) {
+	if s, ok := key.(crypto.Signer); ok {
+		key = s.Public()
+	}
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	case *ecdsa.PublicKey:
+		if k.Curve == elliptic.P256() {
+			return jwt.SigningMethodES256, nil
+		}
+	}
+	return nil, errors.New("unsupported jwt key: want Ed25519 or ECDSA P-256")
+}
+
+// KeyID returns the kid of the signing key.
+func (k *Keyring) KeyID() string { return k.kid }
+
+// JWKS returns the public keys as a JWK Set, signing key first.
+func (k *Keyring) JWKS() jwks.Set { return k.set }
+
+// Sign signs claims with the signing key, naming it in the kid header.
+func (k *Keyring) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.method, claims)
+	token.Header["kid"] = k.kid
+	return token.SignedString(k.signer)
+}
+
+// verificationKey returns the public key for a token's kid header, rejecting tokens without one.
+func (k *Keyring) verificationKey(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	return key, nil
+}
@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func writePrivateKey(t *testing.T, name string, key crypto.Signer) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writePEM(t, name, "PRIVATE KEY", der)
+}
+
+func writePublicKey(t *testing.T, name string, key crypto.PublicKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writePEM(t, name, "PUBLIC KEY", der)
+}
+
+func TestLoadKeyring_OverlappingRotation(t *testing.T) {
+	_, oldKey, _ := ed25519.GenerateKey(rand.Reader)
+	newKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	oldFile := writePrivateKey(t, "old.pem", oldKey)
+	newFile := writePrivateKey(t, "new.pem", newKey)
+	userID := uuid.New()
+
+	// Step 1: the new key is published (verify-only) while the old key still signs.
+	before, err := LoadKeyring([]string{oldFile, writePublicKey(t, "new.pub", newKey.Public())}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(before.JWKS().Keys) != 2 {
+		t.Fatalf("jwks = %+v", before.JWKS())
+	}
+	oldToken, _ := NewJWTManagerWithKeyring(before, time.Minute, time.Hour, time.Hour).GenerateAccessToken(userID, "u")
+
+	// Step 2: the new key signs; the old key only verifies until its tokens expire.
+	after, err := LoadKeyring([]string{newFile, oldFile}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr := NewJWTManagerWithKeyring(after, time.Minute, time.Hour, time.Hour)
+	if _, err := mgr.ValidateAccessToken(oldToken); err != nil {
+		t.Errorf("token signed by the outgoing key: %v", err)
+	}
+	newToken, _ := mgr.GenerateAccessToken(userID, "u")
+	parsed, _, _ := jwt.NewParser().ParseUnverified(newToken, &Claims{})
+	if parsed.Header["alg"] != "ES256" || parsed.Header["kid"] != after.KeyID() || after.KeyID() == before.KeyID() {
+		t.Errorf("header = %v, signing kid = %s", parsed.Header, after.KeyID())
+	}
+
+	// Step 3: once the old key is dropped its tokens stop validating.
+	final, _ := LoadKeyring([]string{newFile}, "")
+	if _, err := NewJWTManagerWithKeyring(final, time.Minute, time.Hour, time.Hour).ValidateAccessToken(oldToken); err == nil {
+		t.Error("token signed by a retired key accepted")
+	}
+}
+
+func TestLoadKeyring_Errors(t *testing.T) {
+	_, ed, _ := ed25519.GenerateKey(rand.Reader)
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	for name, files := range map[string][]string{
+		"public key first":  {writePublicKey(t, "ed.pub", ed.Public())},
+		"unsupported curve": {writePrivateKey(t, "p384.pem", p384)},
+		"not pem":           {writePEM(t, "cert.pem", "CERTIFICATE", []byte("x"))},
+		"missing file":      {filepath.Join(t.TempDir(), "absent.pem")},
+	} {
+		if _, err := LoadKeyring(files, ""); err == nil {
+			t.Errorf("%s: LoadKeyring succeeded", name)
+		}
+	}
+}
+
+func TestLoadKeyring_RefusesPlaceholderSecret(t *testing.T) {
+	for _, secret := range []string{"", "  ", "change-me-in-production", "dev-jwt-secret-change-in-production"} {
+		if _, err := LoadKeyring(nil, secret); !errors.Is(err, ErrInsecureJWTSecret) {
+			t.Errorf("secret %q: err = %v", secret, err)
+		}
+	}
+	if _, err := LoadKeyring(nil, "a-unique-deployment-secret"); err != nil {
+		t.Errorf("unique secret: %v", err)
+	}
+}
+
+func TestKeyringFromSecret(t *testing.T) {
+	a, b, c := KeyringFromSecret("s1"), KeyringFromSecret("s1"), KeyringFromSecret("s2")
+	if a.KeyID() != b.KeyID() || a.KeyID() == c.KeyID() {
+		t.Errorf("kids = %s %s %s; replicas sharing a secret must derive the same key", a.KeyID(), b.KeyID(), c.KeyID())
+	}
+	if a.JWKS().Keys[0].Alg != "EdDSA" {
+		t.Errorf("jwks = %+v", a.JWKS())
+	}
+}
+
+func TestJWTManager_RejectsHMACAndMissingKid(t *testing.T) {
+	mgr := NewJWTManager("test-secret", time.Minute, time.Hour, time.Hour)
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Issuer: Issuer, ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))},
+		TokenType:        TokenTypeAccess,
+	}
+	hs := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	hs.Header["kid"] = mgr.Keyring().KeyID()
+	signed, _ := hs.SignedString([]byte("test-secret"))
+	if _, err := mgr.ValidateToken(signed); err == nil {
+		t.Error("HS256 token accepted")
+	}
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	noKid, _ := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims).SignedString(key)
+	if _, err := mgr.ValidateToken(noKid); err == nil {
+		t.Error("token without kid accepted")
+	}
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/jwks"
+)
+
+// jwksFetchTimeout bounds one JWKS fetch by a RemoteVerifier.
+const jwksFetchTimeout = 10 * time.Second
+
+// RemoteVerifier validates orchestrator-signed tokens with the public keys served at the orchestrator's
+// /.well-known/jwks.json, for services (MCP gateway, API egress) that must check tokens but never sign them.
+// Keys the orchestrator rotates in are picked up on the first token that names them.
+type RemoteVerifier struct {
+	v *jwks.Verifier
+}
+
+// NewRemoteVerifier returns a verifier for tokens signed by the keys published at jwksURL.
+func NewRemoteVerifier(jwksURL string) *RemoteVerifier {
+	keys := jwks.NewRemoteKeySet(jwksURL, &http.Client{Timeout: jwksFetchTimeout})
+	return &RemoteVerifier{v: &jwks.Verifier{Keys: keys, Issuer: Issuer}}
+}
+
+// Validate verifies tokenStr and returns its claims when its token type is one of types.
+func (r *RemoteVerifier) Validate(ctx context.Context, tokenStr string, types ...TokenType) (*Claims, error) {
+	var claims Claims
+	if err := r.v.Verify(ctx, tokenStr, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if !slices.Contains(types, claims.TokenType) {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
@@ -0,0 +1,281 @@
+// Package credcrypt envelope-encrypts api_credentials secrets. Each secret is sealed with its own random data key
+// (AES-256-GCM); the data key is wrapped with a master key that is not stored in PostgreSQL, and credential_kid
+// names that master key. Both layers are authenticated with the credential ID so a row's ciphertext cannot be
+// replayed onto another row. Rotating the master key only re-wraps data keys (Rewrap); secrets are never decrypted.
+// See docs/tech_specs/api_egress_server.md (Credential Storage).
+package credcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Environment variables that configure the master keys.
+const (
+	// EnvMasterKeyB64 is the current master key (base64, 32 bytes); new and rotated secrets are wrapped with it.
+	EnvMasterKeyB64 = "API_EGRESS_CREDENTIAL_KEY_B64"
+	// EnvMasterKeyKID is the key id of the current master key.
+	EnvMasterKeyKID = "API_EGRESS_CREDENTIAL_KID"
+	// EnvPreviousKeys lists retired master keys still needed to read rows not yet re-wrapped, as
+	// comma-separated kid:base64 pairs.
+	EnvPreviousKeys = "API_EGRESS_CREDENTIAL_PREVIOUS_KEYS"
+)
+
+// DefaultKID is the key identifier used when API_EGRESS_CREDENTIAL_KID is unset.
+const DefaultKID = "k1"
+
+const keyLenBytes = 32
+
+// Ciphertext layout: wrapped data key (nonce || sealed key || tag), then nonce || sealed secret || tag.
+const (
+	nonceLen      = 12
+	tagLen        = 16
+	wrappedKeyLen = nonceLen + keyLenBytes + tagLen
+)
+
+var (
+	// ErrKeyNotConfigured is returned by FromEnv when no master key is set.
+	ErrKeyNotConfigured = errors.New("credential master key not configured")
+	// ErrKeyInvalid is returned for master keys that are not base64 or not 32 bytes.
+	ErrKeyInvalid = errors.New("invalid credential master key")
+	// ErrUnknownKID is returned when a credential was wrapped with a key this keyring does not hold.
+	ErrUnknownKID = errors.New("unknown credential key id")
+	// ErrDecrypt is returned when ciphertext is malformed or fails authentication.
+	ErrDecrypt = errors.New("credential decryption failed")
+)
+
+// Keyring holds the master keys by key id; Seal wraps with the current key, Open and Rewrap unwrap with the key
+// named by credential_kid.
+type Keyring struct {
+	current string
+	keys    map[string][]byte
+}
+
+// New returns a keyring whose current key is key under kid. key must be 32 bytes.
+func New(kid string, key []byte) (*Keyring, error) {
+	k := &Keyring{current: kid, keys: map[string][]byte{}}
+	if err := k.Add(kid, key); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Add registers a retired master key so rows wrapped with it can still be read and re-wrapped.
+func (k *Keyring) Add(kid string, key []byte) error {
+	if strings.TrimSpace(kid) == "" || len(key) != keyLenBytes {
+		return ErrKeyInvalid
+	}
+	if _, dup := k.keys[kid]; dup {
+		return fmt.Errorf("%w: duplicate key id %q", ErrKeyInvalid, kid)
+	}
+	c := make([]byte, keyLenBytes)
+	copy(c, key)
+	k.keys[kid] = c
+	return nil
+}
+
+// FromEnv builds a keyring from API_EGRESS_CREDENTIAL_KEY_B64, API_EGRESS_CREDENTIAL_KID, and
+// API_EGRESS_CREDENTIAL_PREVIOUS_KEYS. Returns ErrKeyNotConfigured when the current key is unset.
+func FromEnv() (*Keyring, error) {
+	raw := strings.TrimSpace(os.Getenv(EnvMasterKeyB64))
+	if raw == "" {
+		return nil, ErrKeyNotConfigured
+	}
+	kid := strings.TrimSpace(os.Getenv(EnvMasterKeyKID))
+	if kid == "" {
+		kid = DefaultKID
+	}
+	var kr *Keyring
+	var err error
+	runWithSecret(func() {
+		var key []byte
+		if key, err = decodeKey(raw); err != nil {
+			return
+		}
+		defer zeroBytes(key)
+		if kr, err = New(kid, key); err != nil {
+			return
+		}
+		for _, pair := range strings.Split(os.Getenv(EnvPreviousKeys), ",") {
+			pair = strings.TrimSpace(pair)
+			if pair == "" {
+				continue
+			}
+			prevKID, prevRaw, ok := strings.Cut(pair, ":")
+			if !ok {
+				err = fmt.Errorf("%w: %s entries must be kid:base64", ErrKeyInvalid, EnvPreviousKeys)
+				return
+			}
+			var prev []byte
+			if prev, err = decodeKey(prevRaw); err != nil {
+				return
+			}
+			err = kr.Add(strings.TrimSpace(prevKID), prev)
+			zeroBytes(prev)
+			if err != nil {
+				return
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return kr, nil
+}
+
+func decodeKey(rawB64 string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(rawB64))
+	if err != nil {
+		return nil, fmt.Errorf("%w: base64 decode", ErrKeyInvalid)
+	}
+	if len(key) != keyLenBytes {
+		zeroBytes(key)
+		return nil, fmt.Errorf("%w: expected 32 bytes", ErrKeyInvalid)
+	}
+	return key, nil
+}
+
+// CurrentKID returns the key id Seal and Rewrap write into credential_kid.
+func (k *Keyring) CurrentKID() string {
+	return k.current
+}
+
+// Seal encrypts plaintext for the credential row id under a fresh data key wrapped with the current master key.
+// It returns the ciphertext and the key id to store in credential_kid.
+func (k *Keyring) Seal(credentialID uuid.UUID, plaintext []byte) (ciphertext []byte, kid string, err error) {
+	runWithSecret(func() {
+		dek := make([]byte, keyLenBytes)
+		defer zeroBytes(dek)
+		if _, err = io.ReadFull(rand.Reader, dek); err != nil {
+			err = fmt.Errorf("generate data key: %w", err)
+			return
+		}
+		var wrapped, sealed []byte
+		if wrapped, err = seal(k.keys[k.current], dek, credentialID); err != nil {
+			return
+		}
+		if sealed, err = seal(dek, plaintext, credentialID); err != nil {
+			return
+		}
+		ciphertext = append(wrapped, sealed...)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return ciphertext, k.current, nil
+}
+
+// Open decrypts ciphertext sealed for credentialID with its data key wrapped under kid (empty kid means the
+// current key). The caller must erase the returned plaintext with Zero once it is no longer needed.
+func (k *Keyring) Open(credentialID uuid.UUID, kid string, ciphertext []byte) ([]byte, error) {
+	var plaintext []byte
+	var err error
+	runWithSecret(func() {
+		var dek []byte
+		if dek, err = k.unwrap(credentialID, kid, ciphertext); err != nil {
+			return
+		}
+		defer zeroBytes(dek)
+		plaintext, err = open(dek, ciphertext[wrappedKeyLen:], credentialID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plaintext, nil
+}
+
+// Rewrap re-wraps the data key of ciphertext (wrapped under kid) with the current master key and returns the new
+// ciphertext and key id. The secret itself is not decrypted; its sealed bytes are carried over unchanged.
+func (k *Keyring) Rewrap(credentialID uuid.UUID, kid string, ciphertext []byte) (newCiphertext []byte, newKID string, err error) {
+	runWithSecret(func() {
+		var dek, wrapped []byte
+		if dek, err = k.unwrap(credentialID, kid, ciphertext); err != nil {
+			return
+		}
+		defer zeroBytes(dek)
+		if wrapped, err = seal(k.keys[k.current], dek, credentialID); err != nil {
+			return
+		}
+		newCiphertext = append(wrapped, ciphertext[wrappedKeyLen:]...)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return newCiphertext, k.current, nil
+}
+
+// unwrap returns the data key of ciphertext, wrapped under kid.
+func (k *Keyring) unwrap(credentialID uuid.UUID, kid string, ciphertext []byte) ([]byte, error) {
+	if kid == "" {
+		kid = k.current
+	}
+	kek, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKID, kid)
+	}
+	if len(ciphertext) < wrappedKeyLen+nonceLen+tagLen {
+		return nil, ErrDecrypt
+	}
+	return open(kek, ciphertext[:wrappedKeyLen], credentialID)
+}
+
+// Zero overwrites b; use it to erase decrypted credential plaintext (best-effort when runtime/secret is unavailable).
+func Zero(b []byte) {
+	zeroBytes(b)
+}
+
+// seal returns nonce || AES-256-GCM(key, plaintext) authenticated with credentialID.
+func seal(key, plaintext []byte, credentialID uuid.UUID) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, nonceLen, nonceLen+len(plaintext)+tagLen)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("read nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, plaintext, credentialID[:]), nil
+}
+
+// open reverses seal.
+func open(key, data []byte, credentialID uuid.UUID) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < nonceLen+tagLen {
+		return nil, ErrDecrypt
+	}
+	plaintext, err := gcm.Open(nil, data[:nonceLen], data[nonceLen:], credentialID[:])
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create gcm: %w", err)
+	}
+	return gcm, nil
+}
+
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,114 @@
+package credcrypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestSealOpen_RoundTrip(t *testing.T) {
+	kr, err := New("k1", testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := uuid.New()
+	ct, kid, err := kr.Seal(id, []byte("sk-secret"))
+	if err != nil || kid != "k1" {
+		t.Fatalf("seal: kid=%q err=%v", kid, err)
+	}
+	if bytes.Contains(ct, []byte("sk-secret")) {
+		t.Fatal("ciphertext contains plaintext")
+	}
+	pt, err := kr.Open(id, kid, ct)
+	if err != nil || string(pt) != "sk-secret" {
+		t.Fatalf("open = %q, %v", pt, err)
+	}
+	Zero(pt)
+	if !bytes.Equal(pt, make([]byte, len(pt))) {
+		t.Error("Zero did not erase plaintext")
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	kr, _ := New("k1", testKey)
+	id := uuid.New()
+	ct, _, _ := kr.Seal(id, []byte("token"))
+	if _, err := kr.Open(uuid.New(), "k1", ct); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("other credential id: err = %v", err)
+	}
+	if _, err := kr.Open(id, "k2", ct); !errors.Is(err, ErrUnknownKID) {
+		t.Errorf("unknown kid: err = %v", err)
+	}
+	if _, err := kr.Open(id, "", []byte("short")); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("short ciphertext: err = %v", err)
+	}
+}
+
+func TestRewrap_RotatesMasterKey(t *testing.T) {
+	oldKey := []byte("fedcba9876543210fedcba9876543210")
+	old, _ := New("k1", oldKey)
+	id := uuid.New()
+	ct, kid, _ := old.Seal(id, []byte("ghp_token"))
+
+	kr, _ := New("k2", testKey)
+	if err := kr.Add("k1", oldKey); err != nil {
+		t.Fatal(err)
+	}
+	newCT, newKID, err := kr.Rewrap(id, kid, ct)
+	if err != nil || newKID != "k2" {
+		t.Fatalf("rewrap: kid=%q err=%v", newKID, err)
+	}
+	if !bytes.Equal(newCT[wrappedKeyLen:], ct[wrappedKeyLen:]) {
+		t.Error("rewrap must keep the sealed secret unchanged")
+	}
+	current, _ := New("k2", testKey)
+	pt, err := current.Open(id, newKID, newCT)
+	if err != nil || string(pt) != "ghp_token" {
+		t.Fatalf("open after rewrap = %q, %v", pt, err)
+	}
+	if _, err := current.Open(id, "k1", ct); !errors.Is(err, ErrUnknownKID) {
+		t.Errorf("retired key dropped: err = %v", err)
+	}
+	if err := kr.Add("k1", oldKey); !errors.Is(err, ErrKeyInvalid) {
+		t.Errorf("duplicate kid: err = %v", err)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv(EnvMasterKeyB64, "")
+	if _, err := FromEnv(); !errors.Is(err, ErrKeyNotConfigured) {
+		t.Errorf("unset: err = %v", err)
+	}
+	t.Setenv(EnvMasterKeyB64, "not base64!")
+	if _, err := FromEnv(); !errors.Is(err, ErrKeyInvalid) {
+		t.Errorf("bad base64: err = %v", err)
+	}
+	t.Setenv(EnvMasterKeyB64, base64.StdEncoding.EncodeToString([]byte("short")))
+	if _, err := FromEnv(); !errors.Is(err, ErrKeyInvalid) {
+		t.Errorf("short key: err = %v", err)
+	}
+	t.Setenv(EnvMasterKeyB64, base64.StdEncoding.EncodeToString(testKey))
+	kr, err := FromEnv()
+	if err != nil || kr.CurrentKID() != DefaultKID {
+		t.Fatalf("default kid: %v, %v", kr, err)
+	}
+	t.Setenv(EnvMasterKeyKID, "2026-10")
+	kr, err = FromEnv()
+	if err != nil || kr.CurrentKID() != "2026-10" {
+		t.Fatalf("kid: %v, %v", kr, err)
+	}
+	prev := base64.StdEncoding.EncodeToString([]byte("fedcba9876543210fedcba9876543210"))
+	t.Setenv(EnvPreviousKeys, "k1:"+prev)
+	if kr, err = FromEnv(); err != nil || len(kr.keys) != 2 {
+		t.Fatalf("previous keys: %v, %v", kr, err)
+	}
+	t.Setenv(EnvPreviousKeys, prev)
+	if _, err := FromEnv(); !errors.Is(err, ErrKeyInvalid) {
+		t.Errorf("previous key without kid: err = %v", err)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build goexperiment.runtimesecret
+
+package credcrypt
+
+import "runtime/secret"
+
+// runWithSecret runs f with runtime/secret protection so that temporaries holding the master key or
+// decrypted credential plaintext are erased before return (REQ-STANDS-0133).
+func runWithSecret(f func()) {
+	secret.Do(f)
+}
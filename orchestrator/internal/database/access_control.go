@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
 )
@@ -14,44 +16,225 @@ import (
 const ActionApiCall = "api.call"
 const ResourceTypeProviderOperation = "api.provider_operation"
 
-// ListAccessControlRulesForApiCall returns rules for the given subject and action/resource type, ordered by priority desc.
-// Used by API Egress to evaluate provider+operation allow/deny. Caller matches resource_pattern to provider/operation.
-func (db *DB) ListAccessControlRulesForApiCall(ctx context.Context, subjectType string, subjectID *uuid.UUID, action, resourceType string) ([]*models.AccessControlRule, error) {
-	q := db.db.WithContext(ctx).Where("action = ? AND resource_type = ?", action, resourceType).
-		Where("subject_type = ?", subjectType)
-	if subjectID == nil {
-		q = q.Where("subject_id IS NULL")
-	} else {
-		q = q.Where("subject_id = ?", *subjectID)
+// ActionWebEgress and ResourceTypeWebDestination are used by the Web Egress Proxy; the resource is host:port.
+const ActionWebEgress = "web.egress"
+const ResourceTypeWebDestination = "web.destination"
+
+// Git egress actions, one per MCP git tool, on ResourceTypeGitRepo; the resource is host/repo_identifier
+// (e.g. github.com/octo/hello).
+const (
+	ActionGitClone      = "git.clone"
+	ActionGitFetch      = "git.fetch"
+	ActionGitPush       = "git.push"
+	ActionGitPRCreate   = "git.pr.create"
+	ResourceTypeGitRepo = "git.repo"
+)
+
+// ActionMcpToolInvoke and ResourceTypeMcpTool are used by the MCP gateway; the resource is the tool name.
+const ActionMcpToolInvoke = "mcp.tool.invoke"
+const ResourceTypeMcpTool = "mcp.tool"
+
+// ActionTaskCreate and ResourceTypeProject are used by the User API Gateway; the resource is projects/<uuid>.
+const ActionTaskCreate = "task.create"
+const ResourceTypeProject = "project"
+
+// Access control subject types. System rules (subject_id NULL) apply to every subject.
+const (
+	SubjectTypeSystem = "system"
+	SubjectTypeUser   = "user"
+	SubjectTypeGroup  = "group"
+	SubjectTypeRole   = "role"
+)
+
+// AccessSubject is one subject a principal acts as (the user, a group it belongs to, or a role bound to it).
+type AccessSubject struct {
+	Type string
+	ID   uuid.UUID
+}
+
+// ListAccessControlRulesForSubjects returns rules for action and resourceType that apply to any of subjects or to
+// the system subject, ordered by priority desc. Resource and condition matching is done by internal/policy.
+func (db *DB) ListAccessControlRulesForSubjects(ctx context.Context, subjects []AccessSubject, action, resourceType string) ([]*models.AccessControlRule, error) {
+	match := db.db.Where("subject_type = ? AND subject_id IS NULL", SubjectTypeSystem)
+	for _, s := range subjects {
+		match = match.Or("subject_type = ? AND subject_id = ?", s.Type, s.ID)
 	}
 	var rules []*models.AccessControlRule
-	if err := q.Order("priority DESC").Find(&rules).Error; err != nil {
+	err := db.db.WithContext(ctx).
+		Where("action = ? AND resource_type = ?", action, resourceType).
+		Where(match).
+		Order("priority DESC, id").Find(&rules).Error
+	if err != nil {
 		return nil, wrapErr(err, "list access control rules")
 	}
 	return rules, nil
 }
 
+// AccessControlRuleFilter narrows ListAccessControlRules; zero fields match everything.
+type AccessControlRuleFilter struct {
+	Action       string
+	ResourceType string
+	SubjectType  string
+	SubjectID    *uuid.UUID
+}
+
+// ListAccessControlRules returns rules matching f, ordered by action, resource type, then priority desc.
+func (db *DB) ListAccessControlRules(ctx context.Context, f AccessControlRuleFilter) ([]*models.AccessControlRule, error) {
+	q := db.db.WithContext(ctx).Model(&models.AccessControlRule{})
+	if f.Action != "" {
+		q = q.Where("action = ?", f.Action)
+	}
+	if f.ResourceType != "" {
+		q = q.Where("resource_type = ?", f.ResourceType)
+	}
+	if f.SubjectType != "" {
+		q = q.Where("subject_type = ?", f.SubjectType)
+	}
+	if f.SubjectID != nil {
+		q = q.Where("subject_id = ?", *f.SubjectID)
+	}
+	var rules []*models.AccessControlRule
+	if err := q.Order("action, resource_type, priority DESC, id").Find(&rules).Error; err != nil {
+		return nil, wrapErr(err, "list access control rules")
+	}
+	return rules, nil
+}
+
+// GetAccessControlRuleByID returns a rule by id, or ErrNotFound.
+func (db *DB) GetAccessControlRuleByID(ctx context.Context, id uuid.UUID) (*models.AccessControlRule, error) {
+	return getByID[models.AccessControlRule](db, ctx, id, "get access control rule by id")
+}
+
+// CreateAccessControlRule inserts r, assigning an id and timestamps.
+func (db *DB) CreateAccessControlRule(ctx context.Context, r *models.AccessControlRule) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	r.CreatedAt, r.UpdatedAt = now, now
+	return db.createRecord(ctx, r, "create access control rule")
+}
+
+// UpdateAccessControlRule replaces every editable field of the rule with r.ID and sets updated_at. Returns
+// ErrNotFound when the rule does not exist.
+func (db *DB) UpdateAccessControlRule(ctx context.Context, r *models.AccessControlRule) error {
+	r.UpdatedAt = time.Now().UTC()
+	res := db.db.WithContext(ctx).Model(&models.AccessControlRule{}).Where("id = ?", r.ID).
+		Select("subject_type", "subject_id", "action", "resource_type", "resource_pattern", "effect", "priority",
+			"conditions", "updated_at", "updated_by").
+		Updates(r)
+	if res.Error != nil {
+		return wrapErr(res.Error, "update access control rule")
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteAccessControlRule deletes a rule. Returns ErrNotFound when it does not exist, or ErrConflict for a default
+// rule, which RunSchema would seed again.
+func (db *DB) DeleteAccessControlRule(ctx context.Context, id uuid.UUID) error {
+	if IsDefaultAccessControlRule(id) {
+		return ErrConflict
+	}
+	res := db.db.WithContext(ctx).Where("id = ?", id).Delete(&models.AccessControlRule{})
+	if res.Error != nil {
+		return wrapErr(res.Error, "delete access control rule")
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DefaultAccessControlRules are the bootstrap rules seeded by RunSchema. They keep MCP tool calls and task creation
+// open under the default-deny engine; operators narrow them with higher-priority deny rules.
+func DefaultAccessControlRules() []*models.AccessControlRule {
+	bootstrap := "bootstrap"
+	rule := func(id, action, resourceType string) *models.AccessControlRule {
+		return &models.AccessControlRule{
+			ID:              uuid.MustParse(id),
+			SubjectType:     SubjectTypeSystem,
+			Action:          action,
+			ResourceType:    resourceType,
+			ResourcePattern: "*",
+			Effect:          "allow",
+			UpdatedBy:       &bootstrap,
+		}
+	}
+	return []*models.AccessControlRule{
+		rule("00000000-0000-4000-8000-00000000ac01", ActionMcpToolInvoke, ResourceTypeMcpTool),
+		rule("00000000-0000-4000-8000-00000000ac02", ActionTaskCreate, ResourceTypeProject),
+	}
+}
+
+// IsDefaultAccessControlRule reports whether id is one of DefaultAccessControlRules.
+func IsDefaultAccessControlRule(id uuid.UUID) bool {
+	for _, r := range DefaultAccessControlRules() {
+		if r.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// seedDefaultAccessControlRules inserts DefaultAccessControlRules that are missing (by id). Existing rows,
+// including ones an operator edited, are left alone.
+func (db *DB) seedDefaultAccessControlRules(ctx context.Context) error {
+	now := time.Now().UTC()
+	for _, r := range DefaultAccessControlRules() {
+		r.CreatedAt, r.UpdatedAt = now, now
+		err := db.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(r).Error
+		if err != nil {
+			return wrapErr(err, "seed access control rule")
+		}
+	}
+	return nil
+}
+
 // CreateAccessControlAuditLog writes one audit record. REQ-APIEGR-0119.
 func (db *DB) CreateAccessControlAuditLog(ctx context.Context, rec *models.AccessControlAuditLog) error {
 	ensureAuditIDAndTime(&rec.ID, &rec.CreatedAt)
 	return db.createRecord(ctx, rec, "create access control audit log")
 }
 
-// HasActiveApiCredentialForUserAndProvider returns true if the user has at least one active credential for the provider.
+// HasActiveApiCredentialForUserAndProvider returns true if the user, or one of the user's active groups, has at
+// least one active credential for the provider.
 // REQ-APIEGR-0113: credential must be authorized and active (expires_at not past).
 func (db *DB) HasActiveApiCredentialForUserAndProvider(ctx context.Context, userID uuid.UUID, provider string) (bool, error) {
 	var n int64
-	now := time.Now().UTC()
-	err := db.db.WithContext(ctx).Model(&models.ApiCredential{}).
-		Where("owner_type = ? AND owner_id = ? AND provider = ? AND is_active = ?", "user", userID, provider, true).
-		Where("expires_at IS NULL OR expires_at > ?", now).
-		Count(&n).Error
+	err := db.activeCredentialsForUserQuery(ctx, userID, provider).Count(&n).Error
 	if err != nil {
 		return false, wrapErr(err, "has active api credential")
 	}
 	return n > 0, nil
 }
 
+// GetActiveApiCredentialForUserAndProvider returns the active, unexpired credential for the provider that API Egress
+// injects into the outbound call, or ErrNotFound. User-owned credentials win over credentials of the user's groups;
+// within each, the most recently updated wins.
+func (db *DB) GetActiveApiCredentialForUserAndProvider(ctx context.Context, userID uuid.UUID, provider string) (*models.ApiCredential, error) {
+	var cred models.ApiCredential
+	err := db.activeCredentialsForUserQuery(ctx, userID, provider).
+		Order("CASE WHEN owner_type = 'user' THEN 0 ELSE 1 END, updated_at DESC").
+		First(&cred).Error
+	if err != nil {
+		return nil, wrapErr(err, "get active api credential")
+	}
+	return &cred, nil
+}
+
+// activeCredentialsForUserQuery selects active, unexpired credentials for provider owned by userID or by one of
+// the user's active groups.
+func (db *DB) activeCredentialsForUserQuery(ctx context.Context, userID uuid.UUID, provider string) *gorm.DB {
+	return db.db.WithContext(ctx).Model(&models.ApiCredential{}).
+		Where("(owner_type = ? AND owner_id = ?) OR (owner_type = ? AND owner_id IN (?))",
+			SubjectTypeUser, userID, SubjectTypeGroup, db.activeGroupIDsQuery(ctx, userID)).
+		Where("provider = ? AND is_active = ?", provider, true).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now().UTC())
+}
+
 // HasAnyActiveApiCredential returns true if at least one active (non-expired) API credential exists.
 // Used by control-plane for inference-path readiness: external provider keys count as an inference path (REQ-ORCHES-0150, orchestrator_bootstrap.md).
 func (db *DB) HasAnyActiveApiCredential(ctx context.Context) (bool, error) {
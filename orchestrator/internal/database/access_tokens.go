@@ -0,0 +1,93 @@
+// Package database: personal access tokens and service accounts for automation.
+// See docs/tech_specs/local_user_accounts.md (Personal Access Tokens) and postgres_schema.md.
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+// PersonalAccessTokenTouchInterval bounds how often last_used_at is written for a token in use, so a busy
+// automation client does not write the row on every request.
+const PersonalAccessTokenTouchInterval = time.Minute
+
+// CreateServiceAccount creates an active service-account user. Returns ErrExists when the handle is taken.
+func (db *DB) CreateServiceAccount(ctx context.Context, handle string) (*models.User, error) {
+	var n int64
+	if err := db.db.WithContext(ctx).Model(&models.User{}).Where("handle = ?", handle).Count(&n).Error; err != nil {
+		return nil, wrapErr(err, "check user handle")
+	}
+	if n > 0 {
+		return nil, ErrExists
+	}
+	now := time.Now().UTC()
+	user := &models.User{
+		ID:               uuid.New(),
+		Handle:           handle,
+		IsActive:         true,
+		IsServiceAccount: true,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	return createReturning(db, ctx, user, "create service account")
+}
+
+// ListServiceAccounts returns all service-account users ordered by handle.
+func (db *DB) ListServiceAccounts(ctx context.Context) ([]*models.User, error) {
+	var out []*models.User
+	err := db.db.WithContext(ctx).Where("is_service_account = ?", true).Order("handle").Find(&out).Error
+	return out, wrapErr(err, "list service accounts")
+}
+
+// CreatePersonalAccessToken inserts t; ID and timestamps are set when zero.
+func (db *DB) CreatePersonalAccessToken(ctx context.Context, t *models.PersonalAccessToken) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	t.CreatedAt, t.UpdatedAt = now, now
+	return db.createRecord(ctx, t, "create personal access token")
+}
+
+// GetActivePersonalAccessToken returns the unrevoked, unexpired token with the given hash.
+func (db *DB) GetActivePersonalAccessToken(ctx context.Context, tokenHash []byte) (*models.PersonalAccessToken, error) {
+	var t models.PersonalAccessToken
+	err := db.db.WithContext(ctx).
+		Where("token_hash = ? AND is_active = ? AND (expires_at IS NULL OR expires_at > ?)", tokenHash, true, time.Now().UTC()).
+		First(&t).Error
+	if err != nil {
+		return nil, wrapErr(err, "get personal access token")
+	}
+	return &t, nil
+}
+
+// GetPersonalAccessTokenByID returns a token by id, revoked or not.
+func (db *DB) GetPersonalAccessTokenByID(ctx context.Context, id uuid.UUID) (*models.PersonalAccessToken, error) {
+	return getByID[models.PersonalAccessToken](db, ctx, id, "get personal access token by id")
+}
+
+// ListPersonalAccessTokens returns userID's tokens, newest first, including revoked and expired ones.
+func (db *DB) ListPersonalAccessTokens(ctx context.Context, userID uuid.UUID) ([]*models.PersonalAccessToken, error) {
+	var out []*models.PersonalAccessToken
+	err := db.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&out).Error
+	return out, wrapErr(err, "list personal access tokens")
+}
+
+// RevokePersonalAccessToken deactivates a token; the row is kept for audit.
+func (db *DB) RevokePersonalAccessToken(ctx context.Context, id uuid.UUID) error {
+	return db.updateWhere(ctx, &models.PersonalAccessToken{}, "id", id,
+		map[string]interface{}{"is_active": false}, "revoke personal access token")
+}
+
+// TouchPersonalAccessToken records that a token was used now, at most once per PersonalAccessTokenTouchInterval.
+func (db *DB) TouchPersonalAccessToken(ctx context.Context, id uuid.UUID) error {
+	now := time.Now().UTC()
+	err := db.db.WithContext(ctx).Model(&models.PersonalAccessToken{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, now.Add(-PersonalAccessTokenTouchInterval)).
+		Update("last_used_at", now).Error
+	return wrapErr(err, "touch personal access token")
+}
@@ -0,0 +1,138 @@
+// Package database: API egress credential lifecycle (create, rotate, disable, master-key re-wrap).
+// See docs/tech_specs/api_egress_server.md (Admin API) and postgres_schema.md (API Credentials Table).
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+// ApiCredentialFilter narrows ListApiCredentials; zero fields match everything. Owners, when non-empty, limits
+// results to credentials owned by one of the given user or group subjects.
+type ApiCredentialFilter struct {
+	OwnerType string
+	OwnerID   *uuid.UUID
+	Provider  string
+	Owners    []AccessSubject
+}
+
+// CreateApiCredential inserts cred. Returns ErrExists when (owner_type, owner_id, provider, credential_name) is taken.
+func (db *DB) CreateApiCredential(ctx context.Context, cred *models.ApiCredential) error {
+	var n int64
+	err := db.db.WithContext(ctx).Model(&models.ApiCredential{}).
+		Where("owner_type = ? AND owner_id = ? AND provider = ? AND credential_name = ?", cred.OwnerType, cred.OwnerID, cred.Provider, cred.CredentialName).
+		Count(&n).Error
+	if err != nil {
+		return wrapErr(err, "check api credential")
+	}
+	if n > 0 {
+		return ErrExists
+	}
+	if cred.ID == uuid.Nil {
+		cred.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	cred.CreatedAt, cred.UpdatedAt = now, now
+	return db.createRecord(ctx, cred, "create api credential")
+}
+
+// GetApiCredentialByID returns the credential or ErrNotFound.
+func (db *DB) GetApiCredentialByID(ctx context.Context, id uuid.UUID) (*models.ApiCredential, error) {
+	return getByID[models.ApiCredential](db, ctx, id, "get api credential")
+}
+
+// ListApiCredentials returns credentials matching f ordered by provider and name.
+func (db *DB) ListApiCredentials(ctx context.Context, f ApiCredentialFilter) ([]*models.ApiCredential, error) {
+	q := db.db.WithContext(ctx).Model(&models.ApiCredential{})
+	if f.OwnerType != "" {
+		q = q.Where("owner_type = ?", f.OwnerType)
+	}
+	if f.OwnerID != nil {
+		q = q.Where("owner_id = ?", *f.OwnerID)
+	}
+	if f.Provider != "" {
+		q = q.Where("provider = ?", f.Provider)
+	}
+	if len(f.Owners) > 0 {
+		match := db.db.Where("owner_type = ? AND owner_id = ?", f.Owners[0].Type, f.Owners[0].ID)
+		for _, o := range f.Owners[1:] {
+			match = match.Or("owner_type = ? AND owner_id = ?", o.Type, o.ID)
+		}
+		q = q.Where(match)
+	}
+	var out []*models.ApiCredential
+	if err := q.Order("provider, credential_name, id").Find(&out).Error; err != nil {
+		return nil, wrapErr(err, "list api credentials")
+	}
+	return out, nil
+}
+
+// RotateApiCredential replaces the secret of an active credential (ciphertext and key id) and, when expiresAt is
+// non-nil, its expiry. Returns ErrNotFound, or ErrConflict when the credential has been disabled.
+func (db *DB) RotateApiCredential(ctx context.Context, id uuid.UUID, ciphertext []byte, kid string, expiresAt *time.Time, updatedBy string) (*models.ApiCredential, error) {
+	updates := map[string]interface{}{
+		"credential_ciphertext": ciphertext,
+		"credential_kid":        kid,
+		"updated_by":            updatedBy,
+		"updated_at":            time.Now().UTC(),
+	}
+	if expiresAt != nil {
+		updates["expires_at"] = *expiresAt
+	}
+	res := db.db.WithContext(ctx).Model(&models.ApiCredential{}).Where("id = ? AND is_active = ?", id, true).Updates(updates)
+	if res.Error != nil {
+		return nil, wrapErr(res.Error, "rotate api credential")
+	}
+	if res.RowsAffected == 0 {
+		if _, err := db.GetApiCredentialByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrConflict
+	}
+	return db.GetApiCredentialByID(ctx, id)
+}
+
+// DisableApiCredential marks the credential inactive so API Egress no longer selects it. Disabling is idempotent.
+func (db *DB) DisableApiCredential(ctx context.Context, id uuid.UUID, updatedBy string) (*models.ApiCredential, error) {
+	if _, err := db.GetApiCredentialByID(ctx, id); err != nil {
+		return nil, err
+	}
+	err := db.updateWhere(ctx, &models.ApiCredential{}, "id", id,
+		map[string]interface{}{"is_active": false, "updated_by": updatedBy}, "disable api credential")
+	if err != nil {
+		return nil, err
+	}
+	return db.GetApiCredentialByID(ctx, id)
+}
+
+// ListApiCredentialsNotUnderKID returns up to limit credentials whose data key is wrapped with a master key other
+// than kid (rows with no credential_kid are treated as current).
+func (db *DB) ListApiCredentialsNotUnderKID(ctx context.Context, kid string, limit int) ([]*models.ApiCredential, error) {
+	var out []*models.ApiCredential
+	err := db.db.WithContext(ctx).
+		Where("credential_kid IS NOT NULL AND credential_kid <> ?", kid).
+		Order("id").Limit(limit).Find(&out).Error
+	if err != nil {
+		return nil, wrapErr(err, "list api credentials for rewrap")
+	}
+	return out, nil
+}
+
+// RewrapApiCredential stores ciphertext re-wrapped under newKID when the row is still wrapped under oldKID.
+// updated_at is left alone (API Egress selects by it). Returns ErrConflict when the row changed concurrently.
+func (db *DB) RewrapApiCredential(ctx context.Context, id uuid.UUID, oldKID string, ciphertext []byte, newKID string) error {
+	res := db.db.WithContext(ctx).Model(&models.ApiCredential{}).
+		Where("id = ? AND credential_kid = ?", id, oldKID).
+		UpdateColumns(map[string]interface{}{"credential_ciphertext": ciphertext, "credential_kid": newKID})
+	if res.Error != nil {
+		return wrapErr(res.Error, "rewrap api credential")
+	}
+	if res.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
@@ -0,0 +1,330 @@
+// Package database: API Egress rate limits, concurrency caps, and monthly spend budgets (api_egress_server.md).
+// Counters live in Postgres so every api-egress replica enforces the same limits.
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+// API Egress limit scope types (models.ApiEgressLimit ScopeType).
+const (
+	ApiEgressScopeSystem  = "system"
+	ApiEgressScopeUser    = "user"
+	ApiEgressScopeProject = "project"
+)
+
+// Kinds of API Egress limit a call can exceed (ApiEgressLimitExceededError Kind).
+const (
+	ApiEgressLimitKindRate        = "requests_per_minute"
+	ApiEgressLimitKindConcurrency = "concurrent_calls"
+	ApiEgressLimitKindBudget      = "monthly_budget"
+)
+
+// API Egress usage periods (models.ApiEgressUsage Period).
+const (
+	ApiEgressPeriodMinute = "minute"
+	ApiEgressPeriodMonth  = "month"
+)
+
+// apiEgressConcurrencyRetryAfter is the Retry-After suggested when a concurrency cap is reached; there is no
+// fixed reset, a slot frees when a call in progress ends.
+const apiEgressConcurrencyRetryAfter = 2 * time.Second
+
+// apiEgressMinuteRetention is how long per-minute counters are kept before they are pruned.
+const apiEgressMinuteRetention = time.Hour
+
+// ApiEgressCall identifies one outbound call for AcquireApiEgressQuota. LeaseID names the concurrency slots the
+// call holds until ReleaseApiEgressQuota; slots of a caller that never releases expire at LeaseExpiresAt.
+type ApiEgressCall struct {
+	Provider       string
+	Operation      string
+	UserID         uuid.UUID
+	ProjectID      *uuid.UUID
+	LeaseID        uuid.UUID
+	LeaseExpiresAt time.Time
+}
+
+// ApiEgressBucketUsage is what one limit bucket has used at a point in time.
+type ApiEgressBucketUsage struct {
+	MinuteCalls        int64
+	Inflight           int64
+	MonthSpendMicroUSD int64
+}
+
+// ApiEgressLimitExceededError is returned by AcquireApiEgressQuota when a call would exceed a limit.
+// ResetAt is nil for the concurrency cap.
+type ApiEgressLimitExceededError struct {
+	LimitID uuid.UUID
+	Kind    string
+	Limit   int64
+	Used    int64
+	ResetAt *time.Time
+}
+
+func (e *ApiEgressLimitExceededError) Error() string {
+	switch e.Kind {
+	case ApiEgressLimitKindRate:
+		return fmt.Sprintf("rate limit exceeded: %d of %d requests per minute (limit %s)", e.Used, e.Limit, e.LimitID)
+	case ApiEgressLimitKindConcurrency:
+		return fmt.Sprintf("concurrency limit exceeded: %d of %d concurrent calls (limit %s)", e.Used, e.Limit, e.LimitID)
+	default:
+		return fmt.Sprintf("monthly budget exceeded: $%.2f of $%.2f estimated spend (limit %s)",
+			float64(e.Used)/1e6, float64(e.Limit)/1e6, e.LimitID)
+	}
+}
+
+// RetryAfter returns how long a client should wait before retrying, at least one second.
+func (e *ApiEgressLimitExceededError) RetryAfter(now time.Time) time.Duration {
+	if e.ResetAt == nil {
+		return apiEgressConcurrencyRetryAfter
+	}
+	if d := e.ResetAt.Sub(now).Truncate(time.Second); d >= time.Second {
+		return d
+	}
+	return time.Second
+}
+
+// ApiEgressPeriodStarts returns the UTC minute and month that now falls in.
+func ApiEgressPeriodStarts(now time.Time) (minute, month time.Time) {
+	now = now.UTC()
+	return now.Truncate(time.Minute), time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// ApiEgressLimitApplies reports whether l throttles c.
+func ApiEgressLimitApplies(l *models.ApiEgressLimit, c *ApiEgressCall) bool {
+	if l.Provider != c.Provider || (l.Operation != "" && l.Operation != c.Operation) {
+		return false
+	}
+	switch l.ScopeType {
+	case ApiEgressScopeSystem:
+		return true
+	case ApiEgressScopeUser:
+		return l.ScopeID == nil || *l.ScopeID == c.UserID
+	case ApiEgressScopeProject:
+		return c.ProjectID != nil && (l.ScopeID == nil || *l.ScopeID == *c.ProjectID)
+	}
+	return false
+}
+
+// ApiEgressBucket returns the counter bucket of l that c is charged to: "" when the limit is shared, else the
+// user or project id for limits that apply to each user or project separately.
+func ApiEgressBucket(l *models.ApiEgressLimit, c *ApiEgressCall) string {
+	if l.ScopeID != nil {
+		return ""
+	}
+	switch l.ScopeType {
+	case ApiEgressScopeUser:
+		return c.UserID.String()
+	case ApiEgressScopeProject:
+		if c.ProjectID != nil {
+			return c.ProjectID.String()
+		}
+	}
+	return ""
+}
+
+// CheckApiEgressLimit returns the first limit of l that one more call would exceed given u, or nil.
+func CheckApiEgressLimit(l *models.ApiEgressLimit, u *ApiEgressBucketUsage, now time.Time) *ApiEgressLimitExceededError {
+	minute, month := ApiEgressPeriodStarts(now)
+	if l.RequestsPerMinute > 0 && u.MinuteCalls >= int64(l.RequestsPerMinute) {
+		reset := minute.Add(time.Minute)
+		return &ApiEgressLimitExceededError{LimitID: l.ID, Kind: ApiEgressLimitKindRate,
+			Limit: int64(l.RequestsPerMinute), Used: u.MinuteCalls, ResetAt: &reset}
+	}
+	if l.MaxConcurrent > 0 && u.Inflight >= int64(l.MaxConcurrent) {
+		return &ApiEgressLimitExceededError{LimitID: l.ID, Kind: ApiEgressLimitKindConcurrency,
+			Limit: int64(l.MaxConcurrent), Used: u.Inflight}
+	}
+	if l.MonthlyBudgetMicroUSD > 0 && u.MonthSpendMicroUSD+l.CostPerCallMicroUSD > l.MonthlyBudgetMicroUSD {
+		reset := month.AddDate(0, 1, 0)
+		return &ApiEgressLimitExceededError{LimitID: l.ID, Kind: ApiEgressLimitKindBudget,
+			Limit: l.MonthlyBudgetMicroUSD, Used: u.MonthSpendMicroUSD, ResetAt: &reset}
+	}
+	return nil
+}
+
+// AcquireApiEgressQuota checks every limit that applies to c and, when all pass, charges the call to them: one
+// request in the current minute, CostPerCallMicroUSD in the current month, and a concurrency slot under c.LeaseID.
+// The matching limit rows are locked for the transaction so concurrent replicas cannot both take the last unit.
+// Returns *ApiEgressLimitExceededError when a limit is reached; held reports whether a slot must be released.
+func (db *DB) AcquireApiEgressQuota(ctx context.Context, c *ApiEgressCall) (held bool, err error) {
+	now := time.Now().UTC()
+	minute, month := ApiEgressPeriodStarts(now)
+	err = db.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var limits []*models.ApiEgressLimit
+		q := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("provider = ? AND (operation = '' OR operation = ?)", c.Provider, c.Operation)
+		scopes := "scope_type = ? OR (scope_type = ? AND (scope_id IS NULL OR scope_id = ?))"
+		args := []interface{}{ApiEgressScopeSystem, ApiEgressScopeUser, c.UserID}
+		if c.ProjectID != nil {
+			scopes += " OR (scope_type = ? AND (scope_id IS NULL OR scope_id = ?))"
+			args = append(args, ApiEgressScopeProject, *c.ProjectID)
+		}
+		if err := q.Where(scopes, args...).Order("id").Find(&limits).Error; err != nil {
+			return err
+		}
+		if len(limits) == 0 {
+			return nil
+		}
+		ids := make([]uuid.UUID, 0, len(limits))
+		for _, l := range limits {
+			ids = append(ids, l.ID)
+			u, err := apiEgressBucketUsage(tx, l.ID, ApiEgressBucket(l, c), minute, month, now)
+			if err != nil {
+				return err
+			}
+			if exceeded := CheckApiEgressLimit(l, u, now); exceeded != nil {
+				return exceeded
+			}
+		}
+		for _, l := range limits {
+			bucket := ApiEgressBucket(l, c)
+			if l.RequestsPerMinute > 0 {
+				if err := addApiEgressUsage(tx, l.ID, bucket, ApiEgressPeriodMinute, minute, 0); err != nil {
+					return err
+				}
+			}
+			if err := addApiEgressUsage(tx, l.ID, bucket, ApiEgressPeriodMonth, month, l.CostPerCallMicroUSD); err != nil {
+				return err
+			}
+			if l.MaxConcurrent > 0 {
+				slot := &models.ApiEgressInflight{LeaseID: c.LeaseID, LimitID: l.ID, Bucket: bucket, ExpiresAt: c.LeaseExpiresAt}
+				if err := tx.Create(slot).Error; err != nil {
+					return err
+				}
+				held = true
+			}
+		}
+		if err := tx.Where("limit_id IN ? AND period = ? AND period_start < ?", ids, ApiEgressPeriodMinute,
+			minute.Add(-apiEgressMinuteRetention)).Delete(&models.ApiEgressUsage{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("limit_id IN ? AND expires_at <= ?", ids, now).Delete(&models.ApiEgressInflight{}).Error
+	})
+	var exceeded *ApiEgressLimitExceededError
+	if errors.As(err, &exceeded) {
+		return false, exceeded
+	}
+	if err != nil {
+		return false, wrapErr(err, "acquire api egress quota")
+	}
+	return held, nil
+}
+
+// apiEgressBucketUsage loads the current minute calls, live concurrency slots, and month spend of one bucket.
+func apiEgressBucketUsage(tx *gorm.DB, limitID uuid.UUID, bucket string, minute, month, now time.Time) (*ApiEgressBucketUsage, error) {
+	var u ApiEgressBucketUsage
+	err := tx.Model(&models.ApiEgressUsage{}).Select("COALESCE(SUM(calls), 0)").
+		Where("limit_id = ? AND bucket = ? AND period = ? AND period_start = ?", limitID, bucket, ApiEgressPeriodMinute, minute).
+		Scan(&u.MinuteCalls).Error
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Model(&models.ApiEgressUsage{}).Select("COALESCE(SUM(spend_micro_usd), 0)").
+		Where("limit_id = ? AND bucket = ? AND period = ? AND period_start = ?", limitID, bucket, ApiEgressPeriodMonth, month).
+		Scan(&u.MonthSpendMicroUSD).Error
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Model(&models.ApiEgressInflight{}).
+		Where("limit_id = ? AND bucket = ? AND expires_at > ?", limitID, bucket, now).
+		Count(&u.Inflight).Error
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// addApiEgressUsage adds one call and spend to a bucket's counter for the period starting at start.
+func addApiEgressUsage(tx *gorm.DB, limitID uuid.UUID, bucket, period string, start time.Time, spend int64) error {
+	row := &models.ApiEgressUsage{LimitID: limitID, Bucket: bucket, Period: period, PeriodStart: start, Calls: 1, SpendMicroUSD: spend}
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "limit_id"}, {Name: "bucket"}, {Name: "period"}, {Name: "period_start"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"calls":           gorm.Expr("api_egress_usage.calls + 1"),
+			"spend_micro_usd": gorm.Expr("api_egress_usage.spend_micro_usd + ?", spend),
+		}),
+	}).Create(row).Error
+}
+
+// ReleaseApiEgressQuota frees the concurrency slots held under leaseID. No-op when there are none.
+func (db *DB) ReleaseApiEgressQuota(ctx context.Context, leaseID uuid.UUID) error {
+	err := db.db.WithContext(ctx).Where("lease_id = ?", leaseID).Delete(&models.ApiEgressInflight{}).Error
+	return wrapErr(err, "release api egress quota")
+}
+
+// ListApiEgressLimits returns limits, optionally for one provider, ordered by provider, operation, and scope.
+func (db *DB) ListApiEgressLimits(ctx context.Context, provider string) ([]*models.ApiEgressLimit, error) {
+	q := db.db.WithContext(ctx).Model(&models.ApiEgressLimit{})
+	if provider != "" {
+		q = q.Where("provider = ?", provider)
+	}
+	var limits []*models.ApiEgressLimit
+	if err := q.Order("provider, operation, scope_type, created_at").Find(&limits).Error; err != nil {
+		return nil, wrapErr(err, "list api egress limits")
+	}
+	return limits, nil
+}
+
+// GetApiEgressLimitByID returns a limit by id, or ErrNotFound.
+func (db *DB) GetApiEgressLimitByID(ctx context.Context, id uuid.UUID) (*models.ApiEgressLimit, error) {
+	return getByID[models.ApiEgressLimit](db, ctx, id, "get api egress limit by id")
+}
+
+// CreateApiEgressLimit inserts l, assigning an id and timestamps.
+func (db *DB) CreateApiEgressLimit(ctx context.Context, l *models.ApiEgressLimit) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	l.CreatedAt, l.UpdatedAt = now, now
+	return db.createRecord(ctx, l, "create api egress limit")
+}
+
+// UpdateApiEgressLimit replaces every editable field of the limit with l.ID and sets updated_at. Counters are kept,
+// so a lowered limit applies to usage already recorded in the current minute and month. Returns ErrNotFound when
+// the limit does not exist.
+func (db *DB) UpdateApiEgressLimit(ctx context.Context, l *models.ApiEgressLimit) error {
+	l.UpdatedAt = time.Now().UTC()
+	res := db.db.WithContext(ctx).Model(&models.ApiEgressLimit{}).Where("id = ?", l.ID).
+		Select("provider", "operation", "scope_type", "scope_id", "requests_per_minute", "max_concurrent",
+			"monthly_budget_micro_usd", "cost_per_call_micro_usd", "updated_at", "updated_by").
+		Updates(l)
+	if res.Error != nil {
+		return wrapErr(res.Error, "update api egress limit")
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteApiEgressLimit deletes a limit with its counters and slots. Returns ErrNotFound when it does not exist.
+func (db *DB) DeleteApiEgressLimit(ctx context.Context, id uuid.UUID) error {
+	err := db.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ?", id).Delete(&models.ApiEgressLimit{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		if err := tx.Where("limit_id = ?", id).Delete(&models.ApiEgressUsage{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("limit_id = ?", id).Delete(&models.ApiEgressInflight{}).Error
+	})
+	if errors.Is(err, ErrNotFound) {
+		return ErrNotFound
+	}
+	return wrapErr(err, "delete api egress limit")
+}
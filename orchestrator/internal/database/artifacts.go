@@ -4,9 +4,11 @@ package database
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
 )
@@ -48,3 +50,44 @@ func (db *DB) ListArtifactPathsByTaskID(ctx context.Context, taskID uuid.UUID) (
 	}
 	return paths, nil
 }
+
+// ListTaskArtifacts returns the task's artifacts ordered by path.
+func (db *DB) ListTaskArtifacts(ctx context.Context, taskID uuid.UUID) ([]*models.TaskArtifact, error) {
+	var out []*models.TaskArtifact
+	err := db.db.WithContext(ctx).Where("task_id = ?", taskID).Order("path").Find(&out).Error
+	if err != nil {
+		return nil, wrapErr(err, "list task artifacts")
+	}
+	return out, nil
+}
+
+// UpsertTaskArtifact inserts the artifact or, when (task_id, path) exists, replaces its storage ref, size,
+// content type, and checksum. art is updated to the stored row.
+func (db *DB) UpsertTaskArtifact(ctx context.Context, art *models.TaskArtifact) error {
+	now := time.Now().UTC()
+	art.UpdatedAt = now
+	var existing models.TaskArtifact
+	err := db.db.WithContext(ctx).Where("task_id = ? AND path = ?", art.TaskID, art.Path).First(&existing).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if art.ID == uuid.Nil {
+				art.ID = uuid.New()
+			}
+			art.CreatedAt = now
+			return wrapErr(db.db.WithContext(ctx).Create(art).Error, "upsert task artifact create")
+		}
+		return wrapErr(err, "upsert task artifact get")
+	}
+	err = db.db.WithContext(ctx).Model(&existing).Updates(map[string]interface{}{
+		"storage_ref":     art.StorageRef,
+		"size_bytes":      art.SizeBytes,
+		"content_type":    art.ContentType,
+		"checksum_sha256": art.ChecksumSHA256,
+		"updated_at":      now,
+	}).Error
+	if err != nil {
+		return wrapErr(err, "upsert task artifact update")
+	}
+	art.ID, art.CreatedAt, art.RunID = existing.ID, existing.CreatedAt, existing.RunID
+	return nil
+}
@@ -0,0 +1,186 @@
+package database
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+// Audit event sources: the audit table each unified audit event comes from.
+const (
+	AuditSourceAuth          = "auth"
+	AuditSourceMcpToolCall   = "mcp_tool_call"
+	AuditSourceAccessControl = "access_control"
+	AuditSourceChat          = "chat"
+	AuditSourcePreference    = "preference"
+)
+
+// AuditSources lists every audit event source in a stable order.
+var AuditSources = []string{
+	AuditSourceAuth, AuditSourceMcpToolCall, AuditSourceAccessControl, AuditSourceChat, AuditSourcePreference,
+}
+
+// MaxAuditEventLimit caps one page of SearchAuditEvents.
+const MaxAuditEventLimit = 500
+
+// AuditEventFilter narrows SearchAuditEvents. Zero fields do not filter. Action matches exactly, or by prefix
+// when it ends in "*" (e.g. "auth.*"). Since is inclusive and Until exclusive.
+type AuditEventFilter struct {
+	EventID      *uuid.UUID
+	Since        *time.Time
+	Until        *time.Time
+	ActorID      *uuid.UUID
+	TaskID       *uuid.UUID
+	ProjectID    *uuid.UUID
+	Source       string
+	Action       string
+	ResourceType string
+	Decision     string
+	Limit        int
+	Cursor       string
+}
+
+// auditEventsUnion normalizes the five audit tables to the models.AuditEvent columns. Auth and chat outcomes map
+// to allow/deny; preference changes are always allow and resolve the actor by handle.
+const auditEventsUnion = `
+SELECT id AS event_id, 'auth'::text AS source, created_at AS ts, user_id AS actor_id, subject_handle AS actor_handle,
+	NULL::uuid AS task_id, NULL::uuid AS project_id, 'auth.' || event_type AS action, 'user'::text AS resource_type,
+	COALESCE(user_id::text, subject_handle, '') AS resource_id,
+	CASE WHEN success THEN 'allow' ELSE 'deny' END AS decision, reason AS details
+FROM auth_audit_log
+UNION ALL
+SELECT id, 'mcp_tool_call', created_at, user_id, NULL, task_id, project_id, 'mcp.tool.invoke', 'mcp.tool', tool_name,
+	decision, NULLIF(CONCAT_WS(': ', status, error_type), '')
+FROM mcp_tool_call_audit_log
+UNION ALL
+SELECT id, 'access_control', created_at, CASE WHEN subject_type = 'user' THEN subject_id END, NULL, task_id, NULL,
+	action, resource_type, resource, decision, reason
+FROM access_control_audit_log
+UNION ALL
+SELECT id, 'chat', created_at, user_id, NULL, NULL, project_id, 'chat.completion', 'chat', COALESCE(request_id, ''),
+	CASE WHEN outcome = 'success' THEN 'allow' ELSE 'deny' END, NULLIF(CONCAT_WS(': ', outcome, error_code), '')
+FROM chat_audit_log
+UNION ALL
+SELECT p.id, 'preference', p.changed_at, u.id, p.changed_by, NULL, NULL, 'preference.change', 'preference_entry',
+	p.entry_id::text, 'allow', p.reason
+FROM preference_audit_log p LEFT JOIN users u ON u.handle = p.changed_by`
+
+// ErrInvalidAuditCursor is returned for a SearchAuditEvents cursor that was not issued by it.
+var ErrInvalidAuditCursor = errors.New("invalid audit cursor")
+
+// AuditCursor returns the opaque cursor that resumes a search after e in (ts, event_id) order.
+func AuditCursor(e *models.AuditEvent) string {
+	raw := e.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + e.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseAuditCursor decodes a cursor from AuditCursor into the timestamp and event id of the last event returned.
+func ParseAuditCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidAuditCursor
+	}
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, uuid.Nil, ErrInvalidAuditCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidAuditCursor
+	}
+	eventID, err := uuid.Parse(id)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidAuditCursor
+	}
+	return t, eventID, nil
+}
+
+// SearchAuditEvents returns audit events across all audit tables that match f, newest first, and the cursor of
+// the next page ("" when there is none). The cursor is a keyset on (ts, event_id), so each page is an index
+// range however deep the export or listing goes. Returns ErrInvalidAuditCursor for a malformed cursor.
+func (db *DB) SearchAuditEvents(ctx context.Context, f AuditEventFilter) ([]*models.AuditEvent, string, error) {
+	limit := auditPageLimit(f)
+	var (
+		where []string
+		args  []interface{}
+	)
+	add := func(cond string, arg ...interface{}) {
+		where = append(where, cond)
+		args = append(args, arg...)
+	}
+	if f.Cursor != "" {
+		ts, id, err := ParseAuditCursor(f.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		add("(ts, event_id) < (?, ?)", ts, id)
+	}
+	if f.EventID != nil {
+		add("event_id = ?", *f.EventID)
+	}
+	if f.Since != nil {
+		add("ts >= ?", *f.Since)
+	}
+	if f.Until != nil {
+		add("ts < ?", *f.Until)
+	}
+	if f.ActorID != nil {
+		add("actor_id = ?", *f.ActorID)
+	}
+	if f.TaskID != nil {
+		add("task_id = ?", *f.TaskID)
+	}
+	if f.ProjectID != nil {
+		add("project_id = ?", *f.ProjectID)
+	}
+	if f.Source != "" {
+		add("source = ?", f.Source)
+	}
+	if prefix, ok := strings.CutSuffix(f.Action, "*"); ok {
+		add(`action LIKE ? ESCAPE '\'`, likeEscape(prefix)+"%")
+	} else if f.Action != "" {
+		add("action = ?", f.Action)
+	}
+	if f.ResourceType != "" {
+		add("resource_type = ?", f.ResourceType)
+	}
+	if f.Decision != "" {
+		add("decision = ?", f.Decision)
+	}
+	query := "SELECT * FROM (" + auditEventsUnion + ") AS audit_events"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY ts DESC, event_id DESC LIMIT ?"
+	args = append(args, limit+1)
+
+	var events []*models.AuditEvent
+	if err := db.db.WithContext(ctx).Raw(query, args...).Scan(&events).Error; err != nil {
+		return nil, "", wrapErr(err, "search audit events")
+	}
+	next := ""
+	if len(events) > limit {
+		events = events[:limit]
+		next = AuditCursor(events[limit-1])
+	}
+	return events, next, nil
+}
+
+// auditPageLimit returns the filter page size clamped to 1..MaxAuditEventLimit.
+func auditPageLimit(f AuditEventFilter) int {
+	if f.Limit <= 0 || f.Limit > MaxAuditEventLimit {
+		return MaxAuditEventLimit
+	}
+	return f.Limit
+}
+
+// likeEscape escapes LIKE wildcards so s matches literally.
+func likeEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -26,7 +26,8 @@ var ErrExists = errors.New("already exists")
 // ErrConflict is returned when an update or delete fails due to version mismatch (expected_version).
 var ErrConflict = errors.New("version conflict")
 
-// ErrLeaseHeld is returned when a task workflow lease is held by another holder (or by same holder with different lease_id).
+// ErrLeaseHeld is returned when a task workflow lease is held by another holder (or by same holder with different lease_id),
+// or when a job lease heartbeat or leased result does not match the job's current lease.
 var ErrLeaseHeld = errors.New("lease held")
 
 func wrapErr(err error, op string) error {
@@ -46,16 +47,40 @@ type Store interface {
 	CreateUser(ctx context.Context, handle string, email *string) (*models.User, error)
 	GetUserByHandle(ctx context.Context, handle string) (*models.User, error)
 	GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	// External (SSO) identities: users keyed by IdP issuer and subject.
+	GetUserByExternalID(ctx context.Context, source, externalID string) (*models.User, error)
+	CreateExternalUser(ctx context.Context, handle string, email *string, source, externalID string) (*models.User, error)
+	// User administration (users.manage).
+	ListUsers(ctx context.Context) ([]*models.User, error)
+	SetUserActive(ctx context.Context, id uuid.UUID, active bool) error
+	DeleteUser(ctx context.Context, id uuid.UUID) error
 
 	// Password credential operations
 	CreatePasswordCredential(ctx context.Context, userID uuid.UUID, passwordHash []byte, hashAlg string) (*models.PasswordCredential, error)
 	GetPasswordCredentialByUserID(ctx context.Context, userID uuid.UUID) (*models.PasswordCredential, error)
+	SetPassword(ctx context.Context, userID uuid.UUID, passwordHash []byte, hashAlg string, mustChange bool) error
+	CreateUserWithPassword(ctx context.Context, handle string, email *string, passwordHash []byte, hashAlg string, mustChange bool) (*models.User, error)
 
 	// Refresh session operations
-	CreateRefreshSession(ctx context.Context, userID uuid.UUID, tokenHash []byte, expiresAt time.Time) (*models.RefreshSession, error)
+	CreateRefreshSession(ctx context.Context, s *models.RefreshSession) error
 	GetActiveRefreshSession(ctx context.Context, tokenHash []byte) (*models.RefreshSession, error)
+	GetRefreshSessionByID(ctx context.Context, id uuid.UUID) (*models.RefreshSession, error)
+	ListActiveRefreshSessions(ctx context.Context, userID uuid.UUID) ([]*models.RefreshSession, error)
+	RotateRefreshSession(ctx context.Context, s *models.RefreshSession, oldTokenHash []byte) error
+	// InvalidateRefreshSession and InvalidateAllUserSessions also put the sessions' access tokens on the deny-list.
 	InvalidateRefreshSession(ctx context.Context, sessionID uuid.UUID) error
 	InvalidateAllUserSessions(ctx context.Context, userID uuid.UUID) error
+	IsAccessSessionDenied(ctx context.Context, sessionID uuid.UUID) (bool, error)
+
+	// Personal access tokens and service accounts (automation clients; stored as SHA-256 hashes).
+	CreateServiceAccount(ctx context.Context, handle string) (*models.User, error)
+	ListServiceAccounts(ctx context.Context) ([]*models.User, error)
+	CreatePersonalAccessToken(ctx context.Context, t *models.PersonalAccessToken) error
+	GetActivePersonalAccessToken(ctx context.Context, tokenHash []byte) (*models.PersonalAccessToken, error)
+	GetPersonalAccessTokenByID(ctx context.Context, id uuid.UUID) (*models.PersonalAccessToken, error)
+	ListPersonalAccessTokens(ctx context.Context, userID uuid.UUID) ([]*models.PersonalAccessToken, error)
+	RevokePersonalAccessToken(ctx context.Context, id uuid.UUID) error
+	TouchPersonalAccessToken(ctx context.Context, id uuid.UUID) error
 
 	// Auth audit log operations (subjectHandle, reason per postgres_schema.md)
 	CreateAuthAuditLog(ctx context.Context, userID *uuid.UUID, eventType string, success bool, ipAddress, userAgent, subjectHandle, reason *string) error
@@ -66,8 +91,10 @@ type Store interface {
 	// GetTaskBySummary looks up the most recently created task matching summary for the given user.
 	// Returns ErrNotFound when no match exists.
 	GetTaskBySummary(ctx context.Context, userID uuid.UUID, summary string) (*models.Task, error)
+	// UpdateTaskStatus never overwrites a terminal status; failed or canceled cascades to open dependent tasks.
 	UpdateTaskStatus(ctx context.Context, taskID uuid.UUID, status string) error
 	UpdateTaskSummary(ctx context.Context, taskID uuid.UUID, summary string) error
+	UpdateTaskType(ctx context.Context, taskID uuid.UUID, taskType string) error
 	ListTasksByUser(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.Task, error)
 	GetJobsByTaskID(ctx context.Context, taskID uuid.UUID) ([]*models.Job, error)
 	CreateJob(ctx context.Context, taskID uuid.UUID, payload string) (*models.Job, error)
@@ -75,14 +102,39 @@ type Store interface {
 	CreateJobCompleted(ctx context.Context, taskID, jobID uuid.UUID, result string) (*models.Job, error)
 	GetJobByID(ctx context.Context, id uuid.UUID) (*models.Job, error)
 	UpdateJobStatus(ctx context.Context, jobID uuid.UUID, status string) error
-	AssignJobToNode(ctx context.Context, jobID, nodeID uuid.UUID) error
+	// AssignJobToNode records the dispatch target and the scheduler's reason for choosing it, for a job still
+	// queued under leaseID; ErrLeaseHeld otherwise.
+	AssignJobToNode(ctx context.Context, jobID, leaseID, nodeID uuid.UUID, reason string) error
 	CompleteJob(ctx context.Context, jobID uuid.UUID, result, status string) error
 	GetNextQueuedJob(ctx context.Context) (*models.Job, error)
+	// ClaimNextQueuedJob claims the oldest queued job with no live lease whose task dependencies are all completed
+	// (FOR UPDATE SKIP LOCKED) and sets its lease.
+	ClaimNextQueuedJob(ctx context.Context, leaseID uuid.UUID, leaseExpiresAt time.Time) (*models.Job, error)
+	// ReleaseJobClaim clears a still-queued job's lease when held by leaseID.
+	ReleaseJobClaim(ctx context.Context, jobID, leaseID uuid.UUID) error
+	// ExtendJobLease moves lease_expires_at forward for a running job held by leaseID; ErrLeaseHeld when the lease no longer matches.
+	ExtendJobLease(ctx context.Context, jobID, leaseID uuid.UUID, expiresAt time.Time) error
+	// CompleteLeasedJob is CompleteJob for a dispatcher result: it applies only while the job is running under leaseID,
+	// so a result arriving after cancel or lease reclaim returns ErrLeaseHeld and is not recorded.
+	CompleteLeasedJob(ctx context.Context, jobID, leaseID uuid.UUID, result, status string) error
+	// RequeueJobForRetry returns a failed job running under leaseID to the queue, claimable from nextAttemptAt.
+	RequeueJobForRetry(ctx context.Context, jobID, leaseID uuid.UUID, result string, nextAttemptAt time.Time) error
+	CreateJobAttempt(ctx context.Context, attempt *models.JobAttempt) error
+	ListJobAttempts(ctx context.Context, jobID uuid.UUID) ([]*models.JobAttempt, error)
+	ListDeadLetteredJobs(ctx context.Context, limit, offset int) ([]*models.Job, error)
+	// RequeueDeadLetteredJob requeues a dead-lettered job with a fresh attempt budget; ErrConflict when it is not dead-lettered.
+	RequeueDeadLetteredJob(ctx context.Context, jobID uuid.UUID) (*models.Job, error)
+	// ListExpiredLeaseJobs returns running jobs whose lease expired before now (node died or stopped heartbeating).
+	ListExpiredLeaseJobs(ctx context.Context, now time.Time) ([]*models.Job, error)
+	// ListRunningJobs returns running jobs assigned to a node (for async result collection).
+	ListRunningJobs(ctx context.Context) ([]*models.Job, error)
 
 	// Node operations
 	CreateNode(ctx context.Context, nodeSlug string) (*models.Node, error)
 	GetNodeBySlug(ctx context.Context, slug string) (*models.Node, error)
 	GetNodeByID(ctx context.Context, id uuid.UUID) (*models.Node, error)
+	// GetNodeByWorkerAPIToken authenticates a node by its Worker API bearer token (worker callbacks).
+	GetNodeByWorkerAPIToken(ctx context.Context, token string) (*models.Node, error)
 	UpdateNodeStatus(ctx context.Context, nodeID uuid.UUID, status string) error
 	UpdateNodeLastSeen(ctx context.Context, nodeID uuid.UUID) error
 	SaveNodeCapabilitySnapshot(ctx context.Context, nodeID uuid.UUID, capJSON string) error
@@ -90,6 +142,10 @@ type Store interface {
 	UpdateNodeCapability(ctx context.Context, nodeID uuid.UUID, capHash string) error
 	ListActiveNodes(ctx context.Context) ([]*models.Node, error)
 	ListDispatchableNodes(ctx context.Context) ([]*models.Node, error)
+	// CountActiveJobsByNode returns the number of running jobs per node (scheduler load input).
+	CountActiveJobsByNode(ctx context.Context) (map[uuid.UUID]int, error)
+	// GetSandboxImageStatusByNode returns node_sandbox_image_availability status per node for an image ref.
+	GetSandboxImageStatusByNode(ctx context.Context, imageRef string) (map[uuid.UUID]string, error)
 	UpdateNodeConfigVersion(ctx context.Context, nodeID uuid.UUID, configVersion string) error
 	UpdateNodeConfigAck(ctx context.Context, nodeID uuid.UUID, configVersion, status string, ackAt time.Time, errMsg *string) error
 	UpdateNodeWorkerAPIConfig(ctx context.Context, nodeID uuid.UUID, targetURL, bearerToken string) error
@@ -109,6 +165,8 @@ type Store interface {
 	GetArtifactByTaskIDAndPath(ctx context.Context, taskID uuid.UUID, path string) (*models.TaskArtifact, error)
 	CreateTaskArtifact(ctx context.Context, taskID uuid.UUID, path, storageRef string, sizeBytes *int64) (*models.TaskArtifact, error)
 	ListArtifactPathsByTaskID(ctx context.Context, taskID uuid.UUID) ([]string, error)
+	ListTaskArtifacts(ctx context.Context, taskID uuid.UUID) ([]*models.TaskArtifact, error)
+	UpsertTaskArtifact(ctx context.Context, art *models.TaskArtifact) error
 	GetOrCreateDefaultProjectForUser(ctx context.Context, userID uuid.UUID) (*models.Project, error)
 
 	// Chat threads and messages (OpenAI-compatible chat API).
@@ -127,9 +185,26 @@ type Store interface {
 	GetSkillByID(ctx context.Context, id uuid.UUID) (*models.Skill, error)
 	ListSkillsForUser(ctx context.Context, userID uuid.UUID, scopeFilter, ownerFilter string) ([]*models.Skill, error)
 	UpdateSkill(ctx context.Context, id uuid.UUID, name, content, scope *string) (*models.Skill, error)
+	// SetSkillGroup sets the group a group-scoped skill is shared with (nil clears it).
+	SetSkillGroup(ctx context.Context, id uuid.UUID, groupID *uuid.UUID) (*models.Skill, error)
 	DeleteSkill(ctx context.Context, id uuid.UUID) error
 	EnsureDefaultSkill(ctx context.Context, content string) error
 
+	// Project plans and task dependency graph (postgres_schema.md project_plans, task_dependencies).
+	CreateProjectPlan(ctx context.Context, projectID uuid.UUID, planName *string, createdBy *uuid.UUID) (*models.ProjectPlan, error)
+	GetProjectPlanByID(ctx context.Context, planID uuid.UUID) (*models.ProjectPlan, error)
+	// AddTaskToPlan sets the task's plan and dependencies; ErrInvalidDependency for deps outside the plan, failed or
+	// canceled deps, or cycles.
+	AddTaskToPlan(ctx context.Context, taskID, planID uuid.UUID, dependsOn []uuid.UUID) error
+	// CreatePlanTask is CreateTask followed by AddTaskToPlan in one transaction.
+	CreatePlanTask(ctx context.Context, createdBy *uuid.UUID, prompt string, taskName *string, projectID *uuid.UUID, planID uuid.UUID, dependsOn []uuid.UUID) (*models.Task, error)
+	ListPlanTasks(ctx context.Context, planID uuid.UUID) ([]*models.Task, error)
+	ListPlanTaskDependencies(ctx context.Context, planID uuid.UUID) ([]*models.TaskDependency, error)
+
+	// Project Git repos, the Git egress allowlist (project_git_repos.md).
+	CreateProjectGitRepo(ctx context.Context, r *models.ProjectGitRepo) error
+	ListProjectGitRepos(ctx context.Context, projectID uuid.UUID) ([]*models.ProjectGitRepo, error)
+
 	// Workflow start gate (REQ-ORCHES-0152, REQ-ORCHES-0153, langgraph_mvp.md WorkflowStartGatePlanApproved).
 	EvaluateWorkflowStartGate(ctx context.Context, task *models.Task, requestedByPMA bool) (denyReason string, err error)
 
@@ -140,11 +215,65 @@ type Store interface {
 	GetWorkflowCheckpoint(ctx context.Context, taskID uuid.UUID) (*models.WorkflowCheckpoint, error)
 	UpsertWorkflowCheckpoint(ctx context.Context, cp *models.WorkflowCheckpoint) error
 
-	// Access control and API egress (REQ-APIEGR-0110--0113, access_control.md).
-	ListAccessControlRulesForApiCall(ctx context.Context, subjectType string, subjectID *uuid.UUID, action, resourceType string) ([]*models.AccessControlRule, error)
+	// Access control and API egress (REQ-APIEGR-0110--0113, access_control.md; evaluation in internal/policy).
+	ListAccessControlRulesForSubjects(ctx context.Context, subjects []AccessSubject, action, resourceType string) ([]*models.AccessControlRule, error)
+	ListAccessControlRules(ctx context.Context, f AccessControlRuleFilter) ([]*models.AccessControlRule, error)
+	GetAccessControlRuleByID(ctx context.Context, id uuid.UUID) (*models.AccessControlRule, error)
+	CreateAccessControlRule(ctx context.Context, r *models.AccessControlRule) error
+	UpdateAccessControlRule(ctx context.Context, r *models.AccessControlRule) error
+	DeleteAccessControlRule(ctx context.Context, id uuid.UUID) error
 	CreateAccessControlAuditLog(ctx context.Context, rec *models.AccessControlAuditLog) error
 	HasActiveApiCredentialForUserAndProvider(ctx context.Context, userID uuid.UUID, provider string) (bool, error)
+	GetActiveApiCredentialForUserAndProvider(ctx context.Context, userID uuid.UUID, provider string) (*models.ApiCredential, error)
+	CreateApiCredential(ctx context.Context, cred *models.ApiCredential) error
+	GetApiCredentialByID(ctx context.Context, id uuid.UUID) (*models.ApiCredential, error)
+	ListApiCredentials(ctx context.Context, f ApiCredentialFilter) ([]*models.ApiCredential, error)
+	RotateApiCredential(ctx context.Context, id uuid.UUID, ciphertext []byte, kid string, expiresAt *time.Time, updatedBy string) (*models.ApiCredential, error)
+	DisableApiCredential(ctx context.Context, id uuid.UUID, updatedBy string) (*models.ApiCredential, error)
+	ListApiCredentialsNotUnderKID(ctx context.Context, kid string, limit int) ([]*models.ApiCredential, error)
+	RewrapApiCredential(ctx context.Context, id uuid.UUID, oldKID string, ciphertext []byte, newKID string) error
 	HasAnyActiveApiCredential(ctx context.Context) (bool, error)
+
+	// API Egress rate limits, concurrency caps, and spend budgets (api_egress_server.md).
+	ListApiEgressLimits(ctx context.Context, provider string) ([]*models.ApiEgressLimit, error)
+	GetApiEgressLimitByID(ctx context.Context, id uuid.UUID) (*models.ApiEgressLimit, error)
+	CreateApiEgressLimit(ctx context.Context, l *models.ApiEgressLimit) error
+	UpdateApiEgressLimit(ctx context.Context, l *models.ApiEgressLimit) error
+	DeleteApiEgressLimit(ctx context.Context, id uuid.UUID) error
+	// AcquireApiEgressQuota returns *ApiEgressLimitExceededError when a limit denies the call; held reports
+	// whether ReleaseApiEgressQuota must be called with c.LeaseID when the call ends.
+	AcquireApiEgressQuota(ctx context.Context, c *ApiEgressCall) (held bool, err error)
+	ReleaseApiEgressQuota(ctx context.Context, leaseID uuid.UUID) error
+
+	// Groups and RBAC (rbac_and_groups.md; permissions resolved in internal/rbac).
+	CreateGroup(ctx context.Context, g *models.Group) error
+	GetGroupByID(ctx context.Context, id uuid.UUID) (*models.Group, error)
+	GetGroupBySlug(ctx context.Context, slug string) (*models.Group, error)
+	ListGroups(ctx context.Context, memberID *uuid.UUID) ([]*models.Group, error)
+	AddGroupMember(ctx context.Context, groupID, userID uuid.UUID, updatedBy string) (*models.GroupMembership, error)
+	RemoveGroupMember(ctx context.Context, groupID, userID uuid.UUID, updatedBy string) error
+	ListGroupMembers(ctx context.Context, groupID uuid.UUID) ([]*models.GroupMembership, error)
+	ListGroupIDsForUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+	// SyncExternalGroupMemberships makes groupIDs the user's externally managed memberships; local ones are kept.
+	SyncExternalGroupMemberships(ctx context.Context, userID uuid.UUID, groupIDs []uuid.UUID, updatedBy string) error
+	ListRoles(ctx context.Context) ([]*models.Role, error)
+	CreateRoleBinding(ctx context.Context, b *models.RoleBinding) error
+	DisableRoleBinding(ctx context.Context, id uuid.UUID, updatedBy string) (*models.RoleBinding, error)
+	ListRoleBindings(ctx context.Context, subjects []AccessSubject) ([]*models.RoleBinding, error)
+	HasAnyRoleBinding(ctx context.Context, subject AccessSubject) (bool, error)
+
+	// Token usage accounting and quotas (token_usage).
+	CreateTokenUsage(ctx context.Context, rec *models.TokenUsage) error
+	// SumTokenUsage sums usage per model in [since, until); a nil userID or projectID does not filter on it.
+	SumTokenUsage(ctx context.Context, userID, projectID *uuid.UUID, since, until time.Time) ([]*models.TokenUsageSummary, error)
+	// CountActiveJobs counts queued and running jobs of tasks created by userID or in projectID (nil does not filter).
+	CountActiveJobs(ctx context.Context, userID, projectID *uuid.UUID) (int, error)
+	// CreateQueuedJobWithinLimits creates a queued job when the task's creator and project are under their
+	// active job limits (0 is unlimited), checked and inserted atomically; returns *ActiveJobLimitError otherwise.
+	CreateQueuedJobWithinLimits(ctx context.Context, taskID, jobID uuid.UUID, payload string, userLimit, projectLimit int) (*models.Job, error)
+
+	// Unified audit search across all audit tables (admin audit API).
+	SearchAuditEvents(ctx context.Context, f AuditEventFilter) ([]*models.AuditEvent, string, error)
 }
 
 // DB wraps GORM database operations.
@@ -283,6 +412,42 @@ func (db *DB) GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, erro
 	return getByID[models.User](db, ctx, id, "get user by id")
 }
 
+// GetUserByExternalID retrieves the user linked to an external identity (e.g. OIDC issuer and subject).
+func (db *DB) GetUserByExternalID(ctx context.Context, source, externalID string) (*models.User, error) {
+	var user models.User
+	err := db.db.WithContext(ctx).Where("external_source = ? AND external_id = ?", source, externalID).First(&user).Error
+	if err != nil {
+		return nil, wrapErr(err, "get user by external id")
+	}
+	return &user, nil
+}
+
+// CreateExternalUser creates an active user linked to an external identity. The email is dropped when another
+// user already has it: accounts are never linked by email.
+func (db *DB) CreateExternalUser(ctx context.Context, handle string, email *string, source, externalID string) (*models.User, error) {
+	if email != nil {
+		var n int64
+		if err := db.db.WithContext(ctx).Model(&models.User{}).Where("email = ?", *email).Count(&n).Error; err != nil {
+			return nil, wrapErr(err, "check user email")
+		}
+		if n > 0 {
+			email = nil
+		}
+	}
+	now := time.Now().UTC()
+	user := &models.User{
+		ID:             uuid.New(),
+		Handle:         handle,
+		Email:          email,
+		IsActive:       true,
+		ExternalSource: &source,
+		ExternalID:     &externalID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	return createReturning(db, ctx, user, "create external user")
+}
+
 // --- Password credential operations ---
 
 // CreatePasswordCredential creates a password credential for a user.
@@ -305,21 +470,14 @@ func (db *DB) GetPasswordCredentialByUserID(ctx context.Context, userID uuid.UUI
 
 // --- Refresh session operations ---
 
-// CreateRefreshSession creates a new refresh session.
-func (db *DB) CreateRefreshSession(ctx context.Context, userID uuid.UUID, tokenHash []byte, expiresAt time.Time) (*models.RefreshSession, error) {
-	session := &models.RefreshSession{
-		ID:               uuid.New(),
-		UserID:           userID,
-		RefreshTokenHash: tokenHash,
-		IsActive:         true,
-		ExpiresAt:        expiresAt,
-		CreatedAt:        time.Now().UTC(),
-		UpdatedAt:        time.Now().UTC(),
-	}
-	if err := db.createRecord(ctx, session, "create refresh session"); err != nil {
-		return nil, err
+// CreateRefreshSession inserts an active session; ID and timestamps are set when zero.
+func (db *DB) CreateRefreshSession(ctx context.Context, s *models.RefreshSession) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
 	}
-	return session, nil
+	now := time.Now().UTC()
+	s.IsActive, s.CreatedAt, s.UpdatedAt = true, now, now
+	return db.createRecord(ctx, s, "create refresh session")
 }
 
 // GetActiveRefreshSession retrieves an active refresh session by token hash.
@@ -334,17 +492,7 @@ func (db *DB) GetActiveRefreshSession(ctx context.Context, tokenHash []byte) (*m
 	return &session, nil
 }
 
-// InvalidateRefreshSession invalidates a refresh session.
-func (db *DB) InvalidateRefreshSession(ctx context.Context, sessionID uuid.UUID) error {
-	return db.updateWhere(ctx, &models.RefreshSession{}, "id", sessionID,
-		map[string]interface{}{"is_active": false}, "invalidate refresh session")
-}
-
-// InvalidateAllUserSessions invalidates all sessions for a user.
-func (db *DB) InvalidateAllUserSessions(ctx context.Context, userID uuid.UUID) error {
-	return db.updateWhere(ctx, &models.RefreshSession{}, "user_id", userID,
-		map[string]interface{}{"is_active": false}, "invalidate all user sessions")
-}
+// Rotation, listing, and revocation with the access token deny-list are in sessions.go.
 
 // --- Auth audit log operations ---
 
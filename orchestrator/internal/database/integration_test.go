@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -125,6 +126,58 @@ func TestIntegration_ListDispatchableNodesAndListTasksByUser(t *testing.T) {
 	_ = tasks
 }
 
+func TestIntegration_SchedulerInputs(t *testing.T) {
+	db, ctx := integrationDB(t)
+	node, err := db.CreateNode(ctx, "inttest-sched-"+uuid.New().String())
+	if err != nil {
+		t.Fatalf("CreateNode: %v", err)
+	}
+	task, err := db.CreateTask(ctx, nil, "sched", nil, nil)
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	job, err := db.CreateJob(ctx, task.ID, `{"command":["true"]}`)
+	if err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	if err := db.AssignJobToNode(ctx, job.ID, leaseJob(t, ctx, db, job.ID), node.ID, "score 1.0"); err != nil {
+		t.Fatalf("AssignJobToNode: %v", err)
+	}
+	if err := db.UpdateJobStatus(ctx, job.ID, models.JobStatusRunning); err != nil {
+		t.Fatalf("UpdateJobStatus: %v", err)
+	}
+	counts, err := db.CountActiveJobsByNode(ctx)
+	if err != nil {
+		t.Fatalf("CountActiveJobsByNode: %v", err)
+	}
+	if counts[node.ID] != 1 {
+		t.Errorf("CountActiveJobsByNode: got %d, want 1", counts[node.ID])
+	}
+	got, _ := db.GetJobByID(ctx, job.ID)
+	if got.SchedulingReason == nil || *got.SchedulingReason != "score 1.0" {
+		t.Errorf("SchedulingReason: got %v", got.SchedulingReason)
+	}
+
+	imageRef := "registry.local/inttest-sched:" + uuid.New().String()
+	img := &models.SandboxImage{ID: uuid.New(), Name: "inttest-sched-" + uuid.New().String()}
+	ver := &models.SandboxImageVersion{ID: uuid.New(), SandboxImageID: img.ID, Version: "1", ImageRef: imageRef, IsAllowed: true}
+	avail := &models.NodeSandboxImageAvailability{
+		ID: uuid.New(), NodeID: node.ID, SandboxImageVersionID: ver.ID, Status: "available", LastCheckedAt: time.Now().UTC(),
+	}
+	for _, rec := range []any{img, ver, avail} {
+		if err := db.GORM().WithContext(ctx).Create(rec).Error; err != nil {
+			t.Fatalf("create %T: %v", rec, err)
+		}
+	}
+	status, err := db.GetSandboxImageStatusByNode(ctx, imageRef)
+	if err != nil {
+		t.Fatalf("GetSandboxImageStatusByNode: %v", err)
+	}
+	if status[node.ID] != "available" {
+		t.Errorf("GetSandboxImageStatusByNode: got %q", status[node.ID])
+	}
+}
+
 func TestIntegration_NodeConfigVersionAndAck(t *testing.T) {
 	db, ctx := integrationDB(t)
 	node, err := db.CreateNode(ctx, "inttest-config-node-"+uuid.New().String())
@@ -170,6 +223,88 @@ func TestIntegration_AuthAuditLog(t *testing.T) {
 	}
 }
 
+func TestIntegration_PersonalAccessTokens(t *testing.T) {
+	db, ctx := integrationDB(t)
+	sa, err := db.CreateServiceAccount(ctx, "inttest-sa-"+uuid.NewString()[:8])
+	if err != nil {
+		t.Fatalf("CreateServiceAccount: %v", err)
+	}
+	if _, err := db.CreateServiceAccount(ctx, sa.Handle); err != ErrExists {
+		t.Errorf("CreateServiceAccount duplicate: expected ErrExists, got %v", err)
+	}
+	hash := []byte("pat-hash-" + sa.ID.String())
+	pat := &models.PersonalAccessToken{UserID: sa.ID, Name: "ci", TokenHash: hash, Scopes: "chat", IsActive: true}
+	if err := db.CreatePersonalAccessToken(ctx, pat); err != nil {
+		t.Fatalf("CreatePersonalAccessToken: %v", err)
+	}
+	if got, err := db.GetActivePersonalAccessToken(ctx, hash); err != nil || got.ID != pat.ID {
+		t.Fatalf("GetActivePersonalAccessToken: %+v, %v", got, err)
+	}
+	if err := db.TouchPersonalAccessToken(ctx, pat.ID); err != nil {
+		t.Fatalf("TouchPersonalAccessToken: %v", err)
+	}
+	if got, _ := db.GetPersonalAccessTokenByID(ctx, pat.ID); got == nil || got.LastUsedAt == nil {
+		t.Error("TouchPersonalAccessToken: last_used_at not set")
+	}
+	if err := db.RevokePersonalAccessToken(ctx, pat.ID); err != nil {
+		t.Fatalf("RevokePersonalAccessToken: %v", err)
+	}
+	if _, err := db.GetActivePersonalAccessToken(ctx, hash); err != ErrNotFound {
+		t.Errorf("GetActivePersonalAccessToken after revoke: expected ErrNotFound, got %v", err)
+	}
+	if list, err := db.ListPersonalAccessTokens(ctx, sa.ID); err != nil || len(list) != 1 {
+		t.Errorf("ListPersonalAccessTokens: %d, %v", len(list), err)
+	}
+}
+
+func TestIntegration_UserAdministration(t *testing.T) {
+	db, ctx := integrationDB(t)
+	user, err := db.CreateUser(ctx, "inttest-admin-"+uuid.NewString()[:8], nil)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if err := db.SetPassword(ctx, user.ID, []byte("h1"), "argon2id", true); err != nil {
+		t.Fatalf("SetPassword (create): %v", err)
+	}
+	if err := db.SetPassword(ctx, user.ID, []byte("h2"), "argon2id", false); err != nil {
+		t.Fatalf("SetPassword (replace): %v", err)
+	}
+	if cred, err := db.GetPasswordCredentialByUserID(ctx, user.ID); err != nil || string(cred.PasswordHash) != "h2" || cred.MustChange {
+		t.Errorf("GetPasswordCredentialByUserID: %+v, %v", cred, err)
+	}
+	withPassword, err := db.CreateUserWithPassword(ctx, "inttest-pw-"+uuid.NewString()[:8], nil, []byte("h3"), "argon2id", true)
+	if err != nil {
+		t.Fatalf("CreateUserWithPassword: %v", err)
+	}
+	if cred, err := db.GetPasswordCredentialByUserID(ctx, withPassword.ID); err != nil || string(cred.PasswordHash) != "h3" || !cred.MustChange {
+		t.Errorf("CreateUserWithPassword credential: %+v, %v", cred, err)
+	}
+	if _, err := db.CreateUserWithPassword(ctx, withPassword.Handle, nil, []byte("h4"), "argon2id", false); err == nil {
+		t.Error("CreateUserWithPassword: duplicate handle accepted")
+	}
+	if err := db.SetUserActive(ctx, user.ID, false); err != nil {
+		t.Fatalf("SetUserActive: %v", err)
+	}
+	if got, _ := db.GetUserByID(ctx, user.ID); got == nil || got.IsActive {
+		t.Error("SetUserActive(false): user still active")
+	}
+	if list, err := db.ListUsers(ctx); err != nil || len(list) == 0 {
+		t.Errorf("ListUsers: %d, %v", len(list), err)
+	}
+	if err := db.DeleteUser(ctx, user.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if _, err := db.GetPasswordCredentialByUserID(ctx, user.ID); err != ErrNotFound {
+		t.Errorf("password credential after DeleteUser: %v", err)
+	}
+	if err := db.DeleteUser(ctx, user.ID); err != ErrNotFound {
+		t.Errorf("DeleteUser twice: expected ErrNotFound, got %v", err)
+	}
+	if err := db.SetUserActive(ctx, user.ID, true); err != ErrNotFound {
+		t.Errorf("SetUserActive on deleted user: expected ErrNotFound, got %v", err)
+	}
+}
+
 func TestIntegration_McpToolCallAuditLog(t *testing.T) {
 	db, ctx := integrationDB(t)
 	rec := &models.McpToolCallAuditLog{
@@ -625,6 +760,38 @@ func TestIntegration_GetArtifactByTaskIDAndPath(t *testing.T) {
 	}
 }
 
+func TestIntegration_UpsertAndListTaskArtifacts(t *testing.T) {
+	db, ctx := integrationDB(t)
+	task, err := db.CreateTask(ctx, nil, "artifact-upsert-task", nil, nil)
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	size := int64(3)
+	art := &models.TaskArtifact{TaskID: task.ID, Path: "b.txt", StorageRef: "fs:tasks/x/b.txt", SizeBytes: &size}
+	if err := db.UpsertTaskArtifact(ctx, art); err != nil {
+		t.Fatalf("UpsertTaskArtifact create: %v", err)
+	}
+	firstID := art.ID
+	size2 := int64(5)
+	again := &models.TaskArtifact{TaskID: task.ID, Path: "b.txt", StorageRef: "fs:tasks/x/b.txt", SizeBytes: &size2}
+	if err := db.UpsertTaskArtifact(ctx, again); err != nil {
+		t.Fatalf("UpsertTaskArtifact update: %v", err)
+	}
+	if again.ID != firstID {
+		t.Errorf("upsert changed id: %s -> %s", firstID, again.ID)
+	}
+	if _, err := db.CreateTaskArtifact(ctx, task.ID, "a.txt", "", nil); err != nil {
+		t.Fatalf("CreateTaskArtifact: %v", err)
+	}
+	list, err := db.ListTaskArtifacts(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("ListTaskArtifacts: %v", err)
+	}
+	if len(list) != 2 || list[0].Path != "a.txt" || list[1].Path != "b.txt" || *list[1].SizeBytes != 5 {
+		t.Errorf("ListTaskArtifacts: got %+v", list)
+	}
+}
+
 func integrationDB(t *testing.T) (*DB, context.Context) {
 	t.Helper()
 	dsn := os.Getenv(integrationEnv)
@@ -646,6 +813,27 @@ func integrationDB(t *testing.T) (*DB, context.Context) {
 	return db, ctx
 }
 
+func TestIntegration_DefaultAccessControlRulesSurviveRunSchema(t *testing.T) {
+	db, ctx := integrationDB(t)
+	seeded := DefaultAccessControlRules()[0]
+	if err := db.DeleteAccessControlRule(ctx, seeded.ID); !errors.Is(err, ErrConflict) {
+		t.Fatalf("DeleteAccessControlRule(default): want ErrConflict, got %v", err)
+	}
+	edited := *seeded
+	edited.Effect = "deny"
+	if err := db.UpdateAccessControlRule(ctx, &edited); err != nil {
+		t.Fatalf("UpdateAccessControlRule: %v", err)
+	}
+	t.Cleanup(func() { _ = db.UpdateAccessControlRule(ctx, seeded) })
+	if err := db.RunSchema(ctx, slog.Default()); err != nil {
+		t.Fatalf("RunSchema: %v", err)
+	}
+	got, err := db.GetAccessControlRuleByID(ctx, seeded.ID)
+	if err != nil || got.Effect != "deny" {
+		t.Errorf("default rule after RunSchema: %+v, %v", got, err)
+	}
+}
+
 func TestIntegration_WorkflowLeaseAndCheckpoint(t *testing.T) {
 	db, ctx := integrationDB(t)
 	task, err := db.CreateTask(ctx, nil, "workflow-lease-test", nil, nil)
@@ -827,6 +1015,256 @@ func TestIntegration_GetNextQueuedJob_ErrNotFound(t *testing.T) {
 	}
 }
 
+func TestIntegration_ClaimNextQueuedJob_Concurrent(t *testing.T) {
+	db, ctx := integrationDB(t)
+	task, err := db.CreateTask(ctx, nil, "claim", nil, nil)
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	const n = 4
+	for i := 0; i < n; i++ {
+		if _, err := db.CreateJob(ctx, task.ID, `{"command":["true"]}`); err != nil {
+			t.Fatalf("CreateJob: %v", err)
+		}
+	}
+	var mu sync.Mutex
+	claimed := map[uuid.UUID]int{}
+	var wg sync.WaitGroup
+	for i := 0; i < n*2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			job, err := db.ClaimNextQueuedJob(ctx, uuid.New(), time.Now().UTC().Add(time.Minute))
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			claimed[job.ID]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	for id, c := range claimed {
+		if c != 1 {
+			t.Errorf("job %s claimed %d times", id, c)
+		}
+	}
+	for id := range claimed {
+		job, _ := db.GetJobByID(ctx, id)
+		if job.LeaseID == nil || job.LeaseExpiresAt == nil {
+			t.Errorf("job %s: lease not set", id)
+			continue
+		}
+		if err := db.ReleaseJobClaim(ctx, id, *job.LeaseID); err != nil {
+			t.Fatalf("ReleaseJobClaim: %v", err)
+		}
+		job, _ = db.GetJobByID(ctx, id)
+		if job.LeaseID != nil {
+			t.Errorf("job %s: lease not released", id)
+		}
+		_ = db.CompleteJob(ctx, id, "", models.JobStatusCanceled)
+	}
+}
+
+func TestIntegration_JobLeaseLifecycle(t *testing.T) {
+	db, ctx := integrationDB(t)
+	task, _ := db.CreateTask(ctx, nil, "lease", nil, nil)
+	job, _ := db.CreateJob(ctx, task.ID, `{"command":["true"]}`)
+	node, _ := db.CreateNode(ctx, "lease-node-"+uuid.New().String()[:8])
+	leaseID := uuid.New()
+	if _, err := db.ClaimNextQueuedJob(ctx, leaseID, time.Now().UTC().Add(time.Minute)); err != nil {
+		t.Fatalf("ClaimNextQueuedJob: %v", err)
+	}
+	if err := db.ExtendJobLease(ctx, job.ID, leaseID, time.Now().UTC().Add(time.Minute)); !errors.Is(err, ErrLeaseHeld) {
+		t.Errorf("ExtendJobLease on queued job: %v", err)
+	}
+	if err := db.AssignJobToNode(ctx, job.ID, leaseID, node.ID, "test"); err != nil {
+		t.Fatalf("AssignJobToNode: %v", err)
+	}
+	if err := db.ExtendJobLease(ctx, job.ID, uuid.New(), time.Now().UTC().Add(time.Minute)); !errors.Is(err, ErrLeaseHeld) {
+		t.Errorf("ExtendJobLease with stale lease: %v", err)
+	}
+	if err := db.ExtendJobLease(ctx, uuid.New(), leaseID, time.Now().UTC()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ExtendJobLease unknown job: %v", err)
+	}
+	running, err := db.ListRunningJobs(ctx)
+	if err != nil || !containsJob(running, job.ID) {
+		t.Fatalf("ListRunningJobs: %v", err)
+	}
+	if err := db.ExtendJobLease(ctx, job.ID, leaseID, time.Now().UTC().Add(-time.Second)); err != nil {
+		t.Fatalf("ExtendJobLease: %v", err)
+	}
+	expired, err := db.ListExpiredLeaseJobs(ctx, time.Now().UTC())
+	if err != nil || !containsJob(expired, job.ID) {
+		t.Fatalf("ListExpiredLeaseJobs: %v", err)
+	}
+	got, _ := db.GetJobByID(ctx, job.ID)
+	if got.Status != models.JobStatusRunning || got.LeaseID == nil || *got.LeaseID != leaseID {
+		t.Errorf("listed job changed: status=%s lease=%v", got.Status, got.LeaseID)
+	}
+	_ = db.CompleteJob(ctx, job.ID, "", models.JobStatusCanceled)
+}
+
+func TestIntegration_CompleteLeasedJob(t *testing.T) {
+	db, ctx := integrationDB(t)
+	task, _ := db.CreateTask(ctx, nil, "leased-result", nil, nil)
+	job, _ := db.CreateJob(ctx, task.ID, `{"command":["true"]}`)
+	leaseID := uuid.New()
+	if _, err := db.ClaimNextQueuedJob(ctx, leaseID, time.Now().UTC().Add(time.Minute)); err != nil {
+		t.Fatalf("ClaimNextQueuedJob: %v", err)
+	}
+	_ = db.UpdateJobStatus(ctx, job.ID, models.JobStatusRunning)
+	_ = db.UpdateJobStatus(ctx, job.ID, models.JobStatusCanceled)
+	if err := db.CompleteLeasedJob(ctx, job.ID, leaseID, `{"status":"completed"}`, models.JobStatusCompleted); !errors.Is(err, ErrLeaseHeld) {
+		t.Errorf("CompleteLeasedJob on canceled job: %v", err)
+	}
+	got, _ := db.GetJobByID(ctx, job.ID)
+	if got.Status != models.JobStatusCanceled {
+		t.Errorf("late result overwrote canceled job: %s", got.Status)
+	}
+	_ = db.UpdateJobStatus(ctx, job.ID, models.JobStatusRunning)
+	if err := db.CompleteLeasedJob(ctx, job.ID, leaseID, `{"status":"completed"}`, models.JobStatusCompleted); err != nil {
+		t.Fatalf("CompleteLeasedJob: %v", err)
+	}
+	if err := db.CompleteLeasedJob(ctx, uuid.New(), leaseID, "{}", models.JobStatusCompleted); !errors.Is(err, ErrNotFound) {
+		t.Errorf("CompleteLeasedJob unknown job: %v", err)
+	}
+}
+
+func TestIntegration_JobRetryAndDeadLetter(t *testing.T) {
+	db, ctx := integrationDB(t)
+	task, _ := db.CreateTask(ctx, nil, "retry-dead-letter", nil, nil)
+	job, _ := db.CreateJob(ctx, task.ID, `{"command":["true"]}`)
+	leaseID := uuid.New()
+	if _, err := db.ClaimNextQueuedJob(ctx, leaseID, time.Now().UTC().Add(time.Minute)); err != nil {
+		t.Fatalf("ClaimNextQueuedJob: %v", err)
+	}
+	_ = db.UpdateJobStatus(ctx, job.ID, models.JobStatusRunning)
+	next := time.Now().UTC().Add(time.Hour)
+	if err := db.RequeueJobForRetry(ctx, job.ID, leaseID, `{"error":"unreachable"}`, next); err != nil {
+		t.Fatalf("RequeueJobForRetry: %v", err)
+	}
+	got, _ := db.GetJobByID(ctx, job.ID)
+	if got.Status != models.JobStatusQueued || got.Attempts != 1 || got.NextAttemptAt == nil || got.LeaseID != nil {
+		t.Errorf("after retry requeue: %+v", got)
+	}
+	if err := db.RequeueJobForRetry(ctx, job.ID, leaseID, "{}", next); !errors.Is(err, ErrLeaseHeld) {
+		t.Errorf("RequeueJobForRetry on queued job: %v", err)
+	}
+	class := "node_unreachable"
+	if err := db.CreateJobAttempt(ctx, &models.JobAttempt{JobID: job.ID, Attempt: 1, Status: models.JobStatusFailed, FailureClass: &class}); err != nil {
+		t.Fatalf("CreateJobAttempt: %v", err)
+	}
+	attempts, err := db.ListJobAttempts(ctx, job.ID)
+	if err != nil || len(attempts) != 1 || *attempts[0].FailureClass != class {
+		t.Fatalf("ListJobAttempts: %v %+v", err, attempts)
+	}
+
+	if _, err := db.RequeueDeadLetteredJob(ctx, job.ID); !errors.Is(err, ErrConflict) {
+		t.Errorf("RequeueDeadLetteredJob on queued job: %v", err)
+	}
+	_ = db.CompleteJob(ctx, job.ID, `{"error":"unreachable"}`, models.JobStatusDeadLettered)
+	_ = db.UpdateTaskStatus(ctx, task.ID, models.TaskStatusFailed)
+	dead, err := db.ListDeadLetteredJobs(ctx, 100, 0)
+	if err != nil || !containsJob(dead, job.ID) {
+		t.Fatalf("ListDeadLetteredJobs: %v", err)
+	}
+	requeued, err := db.RequeueDeadLetteredJob(ctx, job.ID)
+	if err != nil || requeued.Status != models.JobStatusQueued || requeued.Attempts != 0 {
+		t.Fatalf("RequeueDeadLetteredJob: %v %+v", err, requeued)
+	}
+	gotTask, _ := db.GetTaskByID(ctx, task.ID)
+	if gotTask.Status != models.TaskStatusPending || gotTask.Closed {
+		t.Errorf("task after requeue: status=%s closed=%v", gotTask.Status, gotTask.Closed)
+	}
+}
+
+func TestIntegration_TokenUsage(t *testing.T) {
+	db, ctx := integrationDB(t)
+	user, _ := db.CreateUser(ctx, "usage-"+uuid.NewString()[:8], nil)
+	project, _ := db.GetOrCreateDefaultProjectForUser(ctx, user.ID)
+	now := time.Now().UTC()
+	for _, rec := range []*models.TokenUsage{
+		{UserID: &user.ID, ProjectID: &project.ID, Model: "a", Source: models.TokenUsageSourceChat, PromptTokens: 10, CompletionTokens: 5},
+		{UserID: &user.ID, Model: "a", Source: models.TokenUsageSourceTask, PromptTokens: 1, CompletionTokens: 1},
+		{UserID: &user.ID, Model: "b", Source: models.TokenUsageSourceJob, PromptTokens: 2, CompletionTokens: 3},
+		{UserID: &user.ID, Model: "a", OccurredAt: now.Add(-48 * time.Hour), PromptTokens: 100},
+	} {
+		if err := db.CreateTokenUsage(ctx, rec); err != nil {
+			t.Fatalf("CreateTokenUsage: %v", err)
+		}
+	}
+	sums, err := db.SumTokenUsage(ctx, &user.ID, nil, now.Add(-time.Hour), now.Add(time.Hour))
+	if err != nil || len(sums) != 2 || sums[0].Model != "a" || sums[0].TotalTokens != 17 || sums[1].TotalTokens != 5 {
+		t.Fatalf("SumTokenUsage: %v %+v", err, sums)
+	}
+	sums, err = db.SumTokenUsage(ctx, nil, &project.ID, now.Add(-time.Hour), now.Add(time.Hour))
+	if err != nil || len(sums) != 1 || sums[0].PromptTokens != 10 {
+		t.Fatalf("SumTokenUsage by project: %v %+v", err, sums)
+	}
+
+	task, _ := db.CreateTask(ctx, &user.ID, "active-jobs", nil, &project.ID)
+	_, _ = db.CreateJob(ctx, task.ID, "{}")
+	done, _ := db.CreateJob(ctx, task.ID, "{}")
+	_ = db.CompleteJob(ctx, done.ID, "{}", models.JobStatusCompleted)
+	if n, err := db.CountActiveJobs(ctx, &user.ID, nil); err != nil || n != 1 {
+		t.Errorf("CountActiveJobs user: %d %v", n, err)
+	}
+	other := uuid.New()
+	if n, err := db.CountActiveJobs(ctx, nil, &other); err != nil || n != 0 {
+		t.Errorf("CountActiveJobs other project: %d %v", n, err)
+	}
+	if _, err := db.CreateQueuedJobWithinLimits(ctx, task.ID, uuid.New(), "{}", 2, 0); err != nil {
+		t.Fatalf("CreateQueuedJobWithinLimits under limit: %v", err)
+	}
+	var limited *ActiveJobLimitError
+	if _, err := db.CreateQueuedJobWithinLimits(ctx, task.ID, uuid.New(), "{}", 0, 2); !errors.As(err, &limited) || !limited.Project || limited.Used != 2 {
+		t.Errorf("CreateQueuedJobWithinLimits at project limit: %v", err)
+	}
+}
+
+// leaseJob claims jobID as ClaimNextQueuedJob would, without depending on it being the oldest queued job.
+func leaseJob(t *testing.T, ctx context.Context, db *DB, jobID uuid.UUID) uuid.UUID {
+	t.Helper()
+	leaseID := uuid.New()
+	err := db.db.WithContext(ctx).Model(&models.Job{}).Where("id = ?", jobID).
+		Updates(map[string]interface{}{"lease_id": leaseID, "lease_expires_at": time.Now().UTC().Add(time.Minute)}).Error
+	if err != nil {
+		t.Fatalf("lease job: %v", err)
+	}
+	return leaseID
+}
+
+func TestIntegration_AssignJobToNodeAfterCancel(t *testing.T) {
+	db, ctx := integrationDB(t)
+	task, _ := db.CreateTask(ctx, nil, "assign-canceled", nil, nil)
+	job, _ := db.CreateJob(ctx, task.ID, `{"command":["true"]}`)
+	node, _ := db.CreateNode(ctx, "assign-node-"+uuid.New().String()[:8])
+	leaseID := leaseJob(t, ctx, db, job.ID)
+	if err := db.AssignJobToNode(ctx, job.ID, uuid.New(), node.ID, "test"); !errors.Is(err, ErrLeaseHeld) {
+		t.Errorf("AssignJobToNode with another lease: %v", err)
+	}
+	_ = db.UpdateJobStatus(ctx, job.ID, models.JobStatusCanceled)
+	if err := db.AssignJobToNode(ctx, job.ID, leaseID, node.ID, "test"); !errors.Is(err, ErrLeaseHeld) {
+		t.Errorf("AssignJobToNode after cancel: %v", err)
+	}
+	if got, _ := db.GetJobByID(ctx, job.ID); got.Status != models.JobStatusCanceled || got.NodeID != nil {
+		t.Errorf("canceled job: status=%s node=%v", got.Status, got.NodeID)
+	}
+	if err := db.AssignJobToNode(ctx, uuid.New(), leaseID, node.ID, "test"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("AssignJobToNode unknown job: %v", err)
+	}
+}
+
+func containsJob(jobs []*models.Job, id uuid.UUID) bool {
+	for _, j := range jobs {
+		if j.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
 func TestIntegration_CompleteJobRoundTrip(t *testing.T) {
 	db, ctx := integrationDB(t)
 	task, _ := db.CreateTask(ctx, nil, "p", nil, nil)
@@ -878,9 +1316,8 @@ func storeRoundTripCredentials(t *testing.T, db Store, ctx context.Context, user
 
 func storeRoundTripSessions(t *testing.T, db Store, ctx context.Context, userID uuid.UUID) {
 	t.Helper()
-	expires := time.Now().Add(time.Hour)
-	session, err := db.CreateRefreshSession(ctx, userID, []byte("tokenhash"), expires)
-	if err != nil {
+	session := &models.RefreshSession{UserID: userID, RefreshTokenHash: []byte("tokenhash"), ExpiresAt: time.Now().Add(time.Hour)}
+	if err := db.CreateRefreshSession(ctx, session); err != nil {
 		t.Fatalf("CreateRefreshSession: %v", err)
 	}
 	if _, err := db.GetActiveRefreshSession(ctx, []byte("tokenhash")); err != nil {
@@ -929,12 +1366,12 @@ func storeRoundTripTaskJobNode(t *testing.T, db Store, ctx context.Context, user
 	if _, err := db.GetJobsByTaskID(ctx, task.ID); err != nil {
 		t.Fatalf("GetJobsByTaskID: %v", err)
 	}
+	if err := db.AssignJobToNode(ctx, job.ID, leaseJob(t, ctx, db.(*DB), job.ID), node.ID, "integration test"); err != nil {
+		t.Fatalf("AssignJobToNode: %v", err)
+	}
 	if err := db.UpdateJobStatus(ctx, job.ID, models.JobStatusRunning); err != nil {
 		t.Fatalf("UpdateJobStatus: %v", err)
 	}
-	if err := db.AssignJobToNode(ctx, job.ID, node.ID); err != nil {
-		t.Fatalf("AssignJobToNode: %v", err)
-	}
 	_ = db.CompleteJob(ctx, job.ID, "{}", models.JobStatusCompleted)
 }
 
@@ -1169,14 +1606,10 @@ func TestIntegration_CreateRefreshSession_ReturnsSession(t *testing.T) {
 	if err != nil {
 		t.Fatalf("CreateUser: %v", err)
 	}
-	expires := time.Now().UTC().Add(time.Hour)
-	session, err := db.CreateRefreshSession(ctx, user.ID, []byte("tokenhash"), expires)
-	if err != nil {
+	session := &models.RefreshSession{UserID: user.ID, RefreshTokenHash: []byte("tokenhash"), ExpiresAt: time.Now().UTC().Add(time.Hour)}
+	if err := db.CreateRefreshSession(ctx, session); err != nil {
 		t.Fatalf("CreateRefreshSession: %v", err)
 	}
-	if session == nil {
-		t.Fatal("CreateRefreshSession: expected non-nil session")
-	}
 	if session.ID == uuid.Nil || session.UserID != user.ID {
 		t.Errorf("CreateRefreshSession: got id=%s user_id=%s", session.ID, session.UserID)
 	}
@@ -1185,6 +1618,48 @@ func TestIntegration_CreateRefreshSession_ReturnsSession(t *testing.T) {
 	}
 }
 
+func TestIntegration_SessionRotationAndDenyList(t *testing.T) {
+	db, ctx := integrationDB(t)
+	user, err := db.CreateUser(ctx, "sessions-"+uuid.New().String(), nil)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	accessExpires := time.Now().UTC().Add(15 * time.Minute)
+	ua, name := "cynork/1.0", "cynork"
+	session := &models.RefreshSession{
+		UserID: user.ID, RefreshTokenHash: []byte("hash-1"), ExpiresAt: time.Now().UTC().Add(time.Hour),
+		AccessExpiresAt: &accessExpires, UserAgent: &ua, ClientName: &name,
+	}
+	if err := db.CreateRefreshSession(ctx, session); err != nil {
+		t.Fatalf("CreateRefreshSession: %v", err)
+	}
+
+	session.RefreshTokenHash = []byte("hash-2")
+	if err := db.RotateRefreshSession(ctx, session, []byte("hash-1")); err != nil {
+		t.Fatalf("RotateRefreshSession: %v", err)
+	}
+	if err := db.RotateRefreshSession(ctx, session, []byte("hash-1")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("rotating a replayed token: %v", err)
+	}
+	list, err := db.ListActiveRefreshSessions(ctx, user.ID)
+	if err != nil || len(list) != 1 || list[0].ID != session.ID || list[0].LastUsedAt == nil || *list[0].ClientName != "cynork" {
+		t.Fatalf("ListActiveRefreshSessions: %+v, %v", list, err)
+	}
+
+	if denied, err := db.IsAccessSessionDenied(ctx, session.ID); err != nil || denied {
+		t.Fatalf("active session denied: %v, %v", denied, err)
+	}
+	if err := db.InvalidateAllUserSessions(ctx, user.ID); err != nil {
+		t.Fatalf("InvalidateAllUserSessions: %v", err)
+	}
+	if denied, err := db.IsAccessSessionDenied(ctx, session.ID); err != nil || !denied {
+		t.Errorf("revoked session not denied: %v, %v", denied, err)
+	}
+	if _, err := db.GetActiveRefreshSession(ctx, []byte("hash-2")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("revoked session still active: %v", err)
+	}
+}
+
 // workflowGateCreateProjectAndPlan creates a project and plan for workflow gate tests.
 func workflowGateCreateProjectAndPlan(t *testing.T, db *DB, ctx context.Context, now time.Time, state string, archived bool) (*models.Project, uuid.UUID) {
 	t.Helper()
@@ -1348,6 +1823,142 @@ func TestIntegration_WorkflowStartGate_DepTaskNotFound(t *testing.T) {
 	}
 }
 
+func TestIntegration_PlanDependencies(t *testing.T) {
+	db, ctx := integrationDB(t)
+	now := time.Now().UTC()
+	proj, _ := workflowGateCreateProjectAndPlan(t, db, ctx, now, "draft", false)
+	name := "deps"
+	plan, err := db.CreateProjectPlan(ctx, proj.ID, &name, nil)
+	if err != nil || plan.State != models.PlanStateDraft {
+		t.Fatalf("CreateProjectPlan: %v %+v", err, plan)
+	}
+	newTask := func(slug string, deps ...uuid.UUID) *models.Task {
+		task, err := db.CreateTask(ctx, nil, slug, nil, nil)
+		if err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+		if err := db.AddTaskToPlan(ctx, task.ID, plan.ID, deps); err != nil {
+			t.Fatalf("AddTaskToPlan: %v", err)
+		}
+		return task
+	}
+	first := newTask("plan-dep-first")
+	second := newTask("plan-dep-second", first.ID)
+	third := newTask("plan-dep-third", second.ID, first.ID)
+	job, err := db.CreateJob(ctx, third.ID, "{}")
+	if err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+
+	outside, _ := db.CreateTask(ctx, nil, "plan-dep-outside", nil, nil)
+	if err := db.AddTaskToPlan(ctx, third.ID, plan.ID, []uuid.UUID{outside.ID}); !errors.Is(err, ErrInvalidDependency) {
+		t.Errorf("dependency outside plan: got %v", err)
+	}
+	if err := db.AddTaskToPlan(ctx, first.ID, plan.ID, []uuid.UUID{third.ID}); !errors.Is(err, ErrInvalidDependency) {
+		t.Errorf("cycle: got %v", err)
+	}
+
+	tasks, err := db.ListPlanTasks(ctx, plan.ID)
+	if err != nil || len(tasks) != 3 {
+		t.Fatalf("ListPlanTasks: %v len=%d", err, len(tasks))
+	}
+	edges, err := db.ListPlanTaskDependencies(ctx, plan.ID)
+	if err != nil || len(edges) != 3 {
+		t.Fatalf("ListPlanTaskDependencies: %v len=%d", err, len(edges))
+	}
+
+	if err := db.UpdateTaskStatus(ctx, first.ID, models.TaskStatusFailed); err != nil {
+		t.Fatalf("UpdateTaskStatus: %v", err)
+	}
+	for _, id := range []uuid.UUID{second.ID, third.ID} {
+		got, _ := db.GetTaskByID(ctx, id)
+		if got.Status != models.TaskStatusFailed {
+			t.Errorf("dependent %s status = %q, want failed", id, got.Status)
+		}
+	}
+	gotJob, _ := db.GetJobByID(ctx, job.ID)
+	if gotJob.Status != models.JobStatusCanceled {
+		t.Errorf("dependent job status = %q, want canceled", gotJob.Status)
+	}
+
+	// A failed dependency is rejected, and the task created alongside it is rolled back.
+	if _, err := db.CreatePlanTask(ctx, nil, "plan-dep-late", nil, nil, plan.ID, []uuid.UUID{first.ID}); !errors.Is(err, ErrInvalidDependency) {
+		t.Errorf("failed dependency: got %v", err)
+	}
+	if tasks, _ := db.ListPlanTasks(ctx, plan.ID); len(tasks) != 3 {
+		t.Errorf("rejected task left behind: %d plan tasks", len(tasks))
+	}
+	fresh, err := db.CreatePlanTask(ctx, nil, "plan-dep-fresh", nil, nil, plan.ID, nil)
+	if err != nil || fresh.PlanID == nil || *fresh.PlanID != plan.ID {
+		t.Fatalf("CreatePlanTask: %v %+v", err, fresh)
+	}
+}
+
+func TestIntegration_RequeueReopensCascadedDependents(t *testing.T) {
+	db, ctx := integrationDB(t)
+	proj, _ := workflowGateCreateProjectAndPlan(t, db, ctx, time.Now().UTC(), "draft", false)
+	plan, err := db.CreateProjectPlan(ctx, proj.ID, nil, nil)
+	if err != nil {
+		t.Fatalf("CreateProjectPlan: %v", err)
+	}
+	newTask := func(slug string, deps ...uuid.UUID) (*models.Task, *models.Job) {
+		task, err := db.CreatePlanTask(ctx, nil, slug, nil, nil, plan.ID, deps)
+		if err != nil {
+			t.Fatalf("CreatePlanTask %s: %v", slug, err)
+		}
+		job, err := db.CreateJob(ctx, task.ID, "{}")
+		if err != nil {
+			t.Fatalf("CreateJob %s: %v", slug, err)
+		}
+		return task, job
+	}
+	first, firstJob := newTask("requeue-first")
+	second, secondJob := newTask("requeue-second", first.ID)
+	side, sideJob := newTask("requeue-side")
+	third, _ := newTask("requeue-third", second.ID, side.ID)
+
+	_ = db.CompleteJob(ctx, sideJob.ID, "{}", models.JobStatusFailed)
+	_ = db.UpdateTaskStatus(ctx, side.ID, models.TaskStatusFailed)
+	_ = db.CompleteJob(ctx, firstJob.ID, "{}", models.JobStatusDeadLettered)
+	_ = db.UpdateTaskStatus(ctx, first.ID, models.TaskStatusFailed)
+
+	if _, err := db.RequeueDeadLetteredJob(ctx, firstJob.ID); err != nil {
+		t.Fatalf("RequeueDeadLetteredJob: %v", err)
+	}
+	want := map[uuid.UUID]string{
+		first.ID:  models.TaskStatusPending,
+		second.ID: models.TaskStatusPending,
+		side.ID:   models.TaskStatusFailed,
+		third.ID:  models.TaskStatusFailed,
+	}
+	for id, status := range want {
+		if got, _ := db.GetTaskByID(ctx, id); got.Status != status {
+			t.Errorf("task %s status = %q, want %q", id, got.Status, status)
+		}
+	}
+	if got, _ := db.GetJobByID(ctx, secondJob.ID); got.Status != models.JobStatusQueued || got.EndedAt != nil {
+		t.Errorf("reopened dependent job = %+v", got)
+	}
+}
+
+func TestIntegration_ProjectGitRepos(t *testing.T) {
+	db, ctx := integrationDB(t)
+	proj, _ := workflowGateCreateProjectAndPlan(t, db, ctx, time.Now().UTC(), "draft", false)
+	for _, id := range []string{"octo/zeta", "octo/alpha"} {
+		if err := db.CreateProjectGitRepo(ctx, &models.ProjectGitRepo{ProjectID: proj.ID, Provider: "github", RepoIdentifier: id}); err != nil {
+			t.Fatalf("CreateProjectGitRepo %s: %v", id, err)
+		}
+	}
+	dup := &models.ProjectGitRepo{ProjectID: proj.ID, Provider: "github", RepoIdentifier: "octo/alpha"}
+	if err := db.CreateProjectGitRepo(ctx, dup); !errors.Is(err, ErrExists) {
+		t.Errorf("duplicate repo: got %v", err)
+	}
+	repos, err := db.ListProjectGitRepos(ctx, proj.ID)
+	if err != nil || len(repos) != 2 || repos[0].RepoIdentifier != "octo/alpha" {
+		t.Fatalf("ListProjectGitRepos: %v %+v", err, repos)
+	}
+}
+
 func TestIntegration_HasAnyActiveApiCredential_CanceledContext(t *testing.T) {
 	db, _ := integrationDB(t)
 	ctx, cancel := context.WithCancel(context.Background())
@@ -1409,3 +2020,37 @@ func assertListChatMessages(t *testing.T, db *DB, ctx context.Context, threadID
 		t.Errorf("ListChatMessages (limit 1): expected 1, got %d", len(limited))
 	}
 }
+
+func TestIntegration_SearchAuditEventsKeyset(t *testing.T) {
+	db, ctx := integrationDB(t)
+	userID := uuid.New()
+	for range 3 {
+		if err := db.CreateAuthAuditLog(ctx, &userID, "login_success", true, nil, nil, nil, nil); err != nil {
+			t.Fatalf("CreateAuthAuditLog: %v", err)
+		}
+	}
+	f := AuditEventFilter{ActorID: &userID, Limit: 1}
+	seen := map[uuid.UUID]bool{}
+	for page := 0; page < 4; page++ {
+		events, next, err := db.SearchAuditEvents(ctx, f)
+		if err != nil {
+			t.Fatalf("SearchAuditEvents: %v", err)
+		}
+		for _, e := range events {
+			if seen[e.ID] {
+				t.Fatalf("event %s returned twice", e.ID)
+			}
+			seen[e.ID] = true
+		}
+		if next == "" {
+			break
+		}
+		f.Cursor = next
+	}
+	if len(seen) != 3 {
+		t.Errorf("paged through %d events, want 3", len(seen))
+	}
+	if _, _, err := db.SearchAuditEvents(ctx, AuditEventFilter{Cursor: "2"}); !errors.Is(err, ErrInvalidAuditCursor) {
+		t.Errorf("offset cursor: %v", err)
+	}
+}
@@ -20,7 +20,13 @@ func (db *DB) RunSchema(ctx context.Context, logger *slog.Logger) error {
 	if err := db.runAutoMigrate(ctx, logger); err != nil {
 		return err
 	}
-	return db.runDDLBootstrap(ctx, logger)
+	if err := db.runDDLBootstrap(ctx, logger); err != nil {
+		return err
+	}
+	if err := db.seedBuiltinRoles(ctx); err != nil {
+		return err
+	}
+	return db.seedDefaultAccessControlRules(ctx)
 }
 
 // runAutoMigrate runs GORM AutoMigrate for all tables used by the Store.
@@ -30,9 +36,12 @@ func (db *DB) runAutoMigrate(ctx context.Context, logger *slog.Logger) error {
 		&models.User{},
 		&models.PasswordCredential{},
 		&models.RefreshSession{},
+		&models.AccessTokenDenial{},
+		&models.PersonalAccessToken{},
 		&models.AuthAuditLog{},
 		&models.Task{},
 		&models.Job{},
+		&models.JobAttempt{},
 		&models.Node{},
 		&models.NodeCapability{},
 		&models.McpToolCallAuditLog{},
@@ -40,6 +49,7 @@ func (db *DB) runAutoMigrate(ctx context.Context, logger *slog.Logger) error {
 		&models.PreferenceAuditLog{},
 		&models.Project{},
 		&models.ProjectPlan{},
+		&models.ProjectGitRepo{},
 		&models.TaskDependency{},
 		&models.Session{},
 		&models.ChatThread{},
@@ -54,7 +64,15 @@ func (db *DB) runAutoMigrate(ctx context.Context, logger *slog.Logger) error {
 		&models.Skill{},
 		&models.AccessControlRule{},
 		&models.AccessControlAuditLog{},
+		&models.Group{},
+		&models.GroupMembership{},
+		&models.Role{},
+		&models.RoleBinding{},
 		&models.ApiCredential{},
+		&models.ApiEgressLimit{},
+		&models.ApiEgressUsage{},
+		&models.ApiEgressInflight{},
+		&models.TokenUsage{},
 	)
 	if err != nil {
 		return fmt.Errorf("auto migrate: %w", err)
@@ -34,6 +34,15 @@ func (db *DB) GetNodeByID(ctx context.Context, id uuid.UUID) (*models.Node, erro
 	return getByID[models.Node](db, ctx, id, "get node by id")
 }
 
+// GetNodeByWorkerAPIToken retrieves the node whose Worker API bearer token is token.
+// Returns ErrNotFound for an empty or unknown token.
+func (db *DB) GetNodeByWorkerAPIToken(ctx context.Context, token string) (*models.Node, error) {
+	if token == "" {
+		return nil, ErrNotFound
+	}
+	return getWhere[models.Node](db, ctx, "worker_api_bearer_token", token, "get node by worker api token")
+}
+
 // UpdateNodeStatus updates a node's status.
 func (db *DB) UpdateNodeStatus(ctx context.Context, nodeID uuid.UUID, status string) error {
 	return db.updateWhere(ctx, &models.Node{}, "id", nodeID,
@@ -79,6 +88,51 @@ func (db *DB) ListDispatchableNodes(ctx context.Context) ([]*models.Node, error)
 	return nodes, nil
 }
 
+// CountActiveJobsByNode returns the number of running jobs assigned to each node.
+// Nodes with no running jobs are absent from the map.
+func (db *DB) CountActiveJobsByNode(ctx context.Context) (map[uuid.UUID]int, error) {
+	var rows []struct {
+		NodeID uuid.UUID
+		Count  int
+	}
+	err := db.db.WithContext(ctx).Model(&models.Job{}).
+		Select("node_id, count(*) AS count").
+		Where("status = ? AND node_id IS NOT NULL", models.JobStatusRunning).
+		Group("node_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, wrapErr(err, "count active jobs by node")
+	}
+	out := make(map[uuid.UUID]int, len(rows))
+	for _, r := range rows {
+		out[r.NodeID] = r.Count
+	}
+	return out, nil
+}
+
+// GetSandboxImageStatusByNode returns the node_sandbox_image_availability status for each node
+// that has a record for a sandbox image version with the given image_ref.
+func (db *DB) GetSandboxImageStatusByNode(ctx context.Context, imageRef string) (map[uuid.UUID]string, error) {
+	var rows []struct {
+		NodeID uuid.UUID
+		Status string
+	}
+	err := db.db.WithContext(ctx).Table("node_sandbox_image_availability AS a").
+		Select("a.node_id, a.status").
+		Joins("JOIN sandbox_image_versions v ON v.id = a.sandbox_image_version_id").
+		Where("v.image_ref = ?", imageRef).
+		Order("a.last_checked_at ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, wrapErr(err, "get sandbox image status by node")
+	}
+	out := make(map[uuid.UUID]string, len(rows))
+	for _, r := range rows {
+		out[r.NodeID] = r.Status
+	}
+	return out, nil
+}
+
 // SaveNodeCapabilitySnapshot saves a capability snapshot for a node.
 func (db *DB) SaveNodeCapabilitySnapshot(ctx context.Context, nodeID uuid.UUID, snapshot string) error {
 	nodeCap := &models.NodeCapability{
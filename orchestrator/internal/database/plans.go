@@ -0,0 +1,308 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+// ErrInvalidDependency is returned when a task dependency would cross plans, reference the task itself, or form a cycle.
+var ErrInvalidDependency = errors.New("invalid task dependency")
+
+// depsSatisfiedClause restricts a jobs query to jobs whose task has no dependency that is not yet completed
+// (postgres_schema.md task_dependencies: a task is runnable when all its dependencies are completed).
+const depsSatisfiedClause = `NOT EXISTS (SELECT 1 FROM task_dependencies d JOIN tasks dt ON dt.id = d.depends_on_task_id
+	WHERE d.task_id = jobs.task_id AND dt.status <> 'completed')`
+
+// CreateProjectPlan creates a plan in state draft.
+func (db *DB) CreateProjectPlan(ctx context.Context, projectID uuid.UUID, planName *string, createdBy *uuid.UUID) (*models.ProjectPlan, error) {
+	now := time.Now().UTC()
+	plan := &models.ProjectPlan{
+		ID:        uuid.New(),
+		ProjectID: projectID,
+		PlanName:  planName,
+		State:     models.PlanStateDraft,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	return createReturning(db, ctx, plan, "create project plan")
+}
+
+// GetProjectPlanByID returns a plan by id.
+func (db *DB) GetProjectPlanByID(ctx context.Context, planID uuid.UUID) (*models.ProjectPlan, error) {
+	return getByID[models.ProjectPlan](db, ctx, planID, "get plan by id")
+}
+
+// AddTaskToPlan sets the task's plan_id and records that it depends on each of dependsOn.
+// Every dependency must already belong to the plan and must not have failed or been canceled; ErrInvalidDependency
+// otherwise or when an edge would form a cycle.
+func (db *DB) AddTaskToPlan(ctx context.Context, taskID, planID uuid.UUID, dependsOn []uuid.UUID) error {
+	err := db.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return addTaskToPlan(tx, taskID, planID, dependsOn)
+	})
+	if errors.Is(err, ErrInvalidDependency) || errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return wrapErr(err, "add task to plan")
+}
+
+// CreatePlanTask creates a task and adds it to the plan with its dependencies in one transaction, so a rejected
+// dependency leaves no task behind. Errors are those of CreateTask and AddTaskToPlan.
+func (db *DB) CreatePlanTask(ctx context.Context, createdBy *uuid.UUID, prompt string, taskName *string, projectID *uuid.UUID, planID uuid.UUID, dependsOn []uuid.UUID) (*models.Task, error) {
+	var task *models.Task
+	err := db.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if task, err = createTask(tx, createdBy, prompt, taskName, projectID); err != nil {
+			return err
+		}
+		if err := addTaskToPlan(tx, task.ID, planID, dependsOn); err != nil {
+			return err
+		}
+		task.PlanID = &planID
+		return nil
+	})
+	if errors.Is(err, ErrInvalidDependency) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, wrapErr(err, "create plan task")
+	}
+	return task, nil
+}
+
+// addTaskToPlan is AddTaskToPlan within tx. Dependency rows are locked so a dependency failing concurrently either
+// is seen here and rejected or, once this commits, cascades to the new task (cascadeTaskStatus).
+func addTaskToPlan(tx *gorm.DB, taskID, planID uuid.UUID, dependsOn []uuid.UUID) error {
+	if len(dependsOn) > 0 {
+		var deps []*models.Task
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "plan_id", "status").
+			Where("id IN ?", dependsOn).Order("id").Find(&deps).Error; err != nil {
+			return err
+		}
+		if len(deps) != len(uniqueIDs(dependsOn)) {
+			return ErrInvalidDependency
+		}
+		for _, d := range deps {
+			if d.PlanID == nil || *d.PlanID != planID || d.Status == models.TaskStatusFailed || d.Status == models.TaskStatusCanceled {
+				return ErrInvalidDependency
+			}
+		}
+		var edges []*models.TaskDependency
+		if err := tx.Model(&models.TaskDependency{}).
+			Joins("JOIN tasks t ON t.id = task_dependencies.task_id").
+			Where("t.plan_id = ?", planID).Find(&edges).Error; err != nil {
+			return err
+		}
+		if CreatesDependencyCycle(edges, taskID, dependsOn) {
+			return ErrInvalidDependency
+		}
+	}
+	res := tx.Model(&models.Task{}).Where("id = ?", taskID).
+		Updates(map[string]interface{}{"plan_id": planID, "updated_at": time.Now().UTC()})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	for _, dep := range uniqueIDs(dependsOn) {
+		edge := &models.TaskDependency{ID: uuid.New(), TaskID: taskID, DependsOnTaskID: dep}
+		if err := tx.Create(edge).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListPlanTasks returns the plan's tasks, oldest first.
+func (db *DB) ListPlanTasks(ctx context.Context, planID uuid.UUID) ([]*models.Task, error) {
+	var tasks []*models.Task
+	err := db.db.WithContext(ctx).Where("plan_id = ?", planID).Order("created_at ASC").Find(&tasks).Error
+	if err != nil {
+		return nil, wrapErr(err, "list plan tasks")
+	}
+	return tasks, nil
+}
+
+// ListPlanTaskDependencies returns the dependency edges between the plan's tasks.
+func (db *DB) ListPlanTaskDependencies(ctx context.Context, planID uuid.UUID) ([]*models.TaskDependency, error) {
+	var edges []*models.TaskDependency
+	err := db.db.WithContext(ctx).Model(&models.TaskDependency{}).
+		Joins("JOIN tasks t ON t.id = task_dependencies.task_id").
+		Where("t.plan_id = ?", planID).
+		Find(&edges).Error
+	if err != nil {
+		return nil, wrapErr(err, "list plan task dependencies")
+	}
+	return edges, nil
+}
+
+// cascadeTaskStatus sets every open task that depends on taskID, directly or transitively, to status
+// (failed or canceled) and cancels their queued jobs, so dependents of a task that can no longer complete
+// are not held forever (REQ-ORCHES-0154).
+func cascadeTaskStatus(tx *gorm.DB, taskID uuid.UUID, status string, now time.Time) error {
+	frontier := []uuid.UUID{taskID}
+	seen := map[uuid.UUID]bool{taskID: true}
+	var dependents []uuid.UUID
+	for len(frontier) > 0 {
+		var next []uuid.UUID
+		if err := tx.Model(&models.TaskDependency{}).Where("depends_on_task_id IN ?", frontier).
+			Pluck("task_id", &next).Error; err != nil {
+			return err
+		}
+		frontier = frontier[:0]
+		for _, id := range next {
+			if !seen[id] {
+				seen[id] = true
+				dependents = append(dependents, id)
+				frontier = append(frontier, id)
+			}
+		}
+	}
+	if len(dependents) == 0 {
+		return nil
+	}
+	err := tx.Model(&models.Task{}).
+		Where("id IN ? AND status NOT IN ?", dependents, terminalTaskStatuses).
+		Updates(map[string]interface{}{"status": status, "closed": true, "updated_at": now}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Model(&models.Job{}).
+		Where("task_id IN ? AND status = ?", dependents, models.JobStatusQueued).
+		Updates(map[string]interface{}{"status": models.JobStatusCanceled, "ended_at": now, "updated_at": now}).Error
+}
+
+// reopenCascadedDependents undoes cascadeTaskStatus for the dependents of taskID, which has been reopened:
+// a dependent that failed only because of the cascade (no job of its own failed, and no other dependency has
+// failed or been canceled) goes back to pending and the jobs the cascade canceled are queued again.
+func reopenCascadedDependents(tx *gorm.DB, taskID uuid.UUID, now time.Time) error {
+	reopened := map[uuid.UUID]bool{taskID: true}
+	var ids []uuid.UUID
+	frontier := []uuid.UUID{taskID}
+	for len(frontier) > 0 {
+		var next []uuid.UUID
+		if err := tx.Model(&models.TaskDependency{}).Where("depends_on_task_id IN ?", frontier).
+			Distinct().Pluck("task_id", &next).Error; err != nil {
+			return err
+		}
+		frontier = frontier[:0]
+		for _, id := range next {
+			if reopened[id] {
+				continue
+			}
+			ok, err := failedByCascade(tx, id, reopened)
+			if err != nil {
+				return err
+			}
+			if ok {
+				reopened[id] = true
+				ids = append(ids, id)
+				frontier = append(frontier, id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	err := tx.Model(&models.Task{}).
+		Where("id IN ? AND status = ?", ids, models.TaskStatusFailed).
+		Updates(map[string]interface{}{"status": models.TaskStatusPending, "closed": false, "updated_at": now}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Model(&models.Job{}).
+		Where("task_id IN ? AND status = ?", ids, models.JobStatusCanceled).
+		Updates(map[string]interface{}{"status": models.JobStatusQueued, "ended_at": nil, "updated_at": now}).Error
+}
+
+// failedByCascade reports whether taskID is failed without a failed job of its own and every dependency outside
+// reopened is still able to complete.
+func failedByCascade(tx *gorm.DB, taskID uuid.UUID, reopened map[uuid.UUID]bool) (bool, error) {
+	var task models.Task
+	if err := tx.Select("status").Where("id = ?", taskID).First(&task).Error; err != nil {
+		return false, err
+	}
+	if task.Status != models.TaskStatusFailed {
+		return false, nil
+	}
+	var ownFailures int64
+	if err := tx.Model(&models.Job{}).
+		Where("task_id = ? AND status IN ?", taskID, []string{models.JobStatusFailed, models.JobStatusDeadLettered}).
+		Count(&ownFailures).Error; err != nil {
+		return false, err
+	}
+	if ownFailures > 0 {
+		return false, nil
+	}
+	var deps []uuid.UUID
+	if err := tx.Model(&models.TaskDependency{}).Where("task_id = ?", taskID).
+		Pluck("depends_on_task_id", &deps).Error; err != nil {
+		return false, err
+	}
+	var others []uuid.UUID
+	for _, d := range deps {
+		if !reopened[d] {
+			others = append(others, d)
+		}
+	}
+	if len(others) == 0 {
+		return true, nil
+	}
+	var blocked int64
+	err := tx.Model(&models.Task{}).
+		Where("id IN ? AND status IN ?", others, []string{models.TaskStatusFailed, models.TaskStatusCanceled}).
+		Count(&blocked).Error
+	return blocked == 0, err
+}
+
+// CreatesDependencyCycle reports whether adding edges taskID -> dependsOn to edges would form a cycle
+// (or a self-dependency): some dependency already depends, directly or transitively, on taskID.
+func CreatesDependencyCycle(edges []*models.TaskDependency, taskID uuid.UUID, dependsOn []uuid.UUID) bool {
+	for _, dep := range dependsOn {
+		if dep == taskID {
+			return true
+		}
+	}
+	dependentsOf := map[uuid.UUID][]uuid.UUID{}
+	for _, e := range edges {
+		dependentsOf[e.DependsOnTaskID] = append(dependentsOf[e.DependsOnTaskID], e.TaskID)
+	}
+	reachable := map[uuid.UUID]bool{}
+	stack := []uuid.UUID{taskID}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, d := range dependentsOf[id] {
+			if !reachable[d] {
+				reachable[d] = true
+				stack = append(stack, d)
+			}
+		}
+	}
+	for _, dep := range dependsOn {
+		if reachable[dep] {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	out := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
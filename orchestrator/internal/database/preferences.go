@@ -86,6 +86,14 @@ func (db *DB) effectiveScopesForTask(ctx context.Context, taskID uuid.UUID) ([]p
 	}
 	scopes := []prefScope{{scopeType: "system", scopeID: nil}}
 	if task.CreatedBy != nil {
+		// Group scopes apply in ascending group_id order, so a later group wins (user_preferences.md).
+		groupIDs, err := db.ListGroupIDsForUser(ctx, *task.CreatedBy)
+		if err != nil {
+			return nil, fmt.Errorf("list groups: %w", err)
+		}
+		for i := range groupIDs {
+			scopes = append(scopes, prefScope{scopeType: "group", scopeID: &groupIDs[i]})
+		}
 		scopes = append(scopes, prefScope{scopeType: "user", scopeID: task.CreatedBy})
 	}
 	if task.ProjectID != nil {
@@ -95,7 +103,7 @@ func (db *DB) effectiveScopesForTask(ctx context.Context, taskID uuid.UUID) ([]p
 	return scopes, nil
 }
 
-// GetEffectivePreferencesForTask computes effective preferences for a task (task > project > user > system; group skipped when no membership data).
+// GetEffectivePreferencesForTask computes effective preferences for a task (task > project > user > group > system).
 // Returns a map key -> JSON value (parsed). Per user_preferences.md resolution: collect by scope precedence, then fold.
 func (db *DB) GetEffectivePreferencesForTask(ctx context.Context, taskID uuid.UUID) (map[string]interface{}, error) {
 	scopes, err := db.effectiveScopesForTask(ctx, taskID)
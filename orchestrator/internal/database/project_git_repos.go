@@ -0,0 +1,43 @@
+// Package database: project Git repository associations (the Git egress allowlist).
+// See docs/tech_specs/project_git_repos.md and postgres_schema.md (Project Git Repositories Table).
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+// CreateProjectGitRepo inserts r. Returns ErrExists when (project_id, provider, repo_identifier) is taken.
+func (db *DB) CreateProjectGitRepo(ctx context.Context, r *models.ProjectGitRepo) error {
+	var n int64
+	err := db.db.WithContext(ctx).Model(&models.ProjectGitRepo{}).
+		Where("project_id = ? AND provider = ? AND repo_identifier = ?", r.ProjectID, r.Provider, r.RepoIdentifier).
+		Count(&n).Error
+	if err != nil {
+		return wrapErr(err, "check project git repo")
+	}
+	if n > 0 {
+		return ErrExists
+	}
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	r.CreatedAt, r.UpdatedAt = now, now
+	return db.createRecord(ctx, r, "create project git repo")
+}
+
+// ListProjectGitRepos returns the repos associated with projectID ordered by provider and identifier.
+func (db *DB) ListProjectGitRepos(ctx context.Context, projectID uuid.UUID) ([]*models.ProjectGitRepo, error) {
+	var out []*models.ProjectGitRepo
+	err := db.db.WithContext(ctx).Where("project_id = ?", projectID).
+		Order("provider, repo_identifier, id").Find(&out).Error
+	if err != nil {
+		return nil, wrapErr(err, "list project git repos")
+	}
+	return out, nil
+}
@@ -0,0 +1,288 @@
+// Package database: groups, memberships, roles, and role bindings per docs/tech_specs/rbac_and_groups.md.
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+// Built-in role names. RunSchema seeds them with the fixed ids in BuiltinRoles; permissions per role are defined
+// in internal/rbac.
+const (
+	RoleAdmin    = "admin"
+	RoleOperator = "operator"
+	RoleMember   = "member"
+	RoleViewer   = "viewer"
+)
+
+// Role binding scope types. Project bindings carry the project id in scope_id; system bindings have none.
+const (
+	ScopeTypeSystem  = "system"
+	ScopeTypeProject = "project"
+)
+
+// ManagedByLocal marks groups, memberships, and bindings created through the API; ManagedByExternalSync marks
+// memberships derived from an identity provider's group claims, which are replaced on every sync.
+const (
+	ManagedByLocal        = "local"
+	ManagedByExternalSync = "external_sync"
+)
+
+// BuiltinRoles returns the roles seeded by RunSchema.
+func BuiltinRoles() []*models.Role {
+	role := func(id, name, description string) *models.Role {
+		return &models.Role{ID: uuid.MustParse(id), Name: name, Description: &description}
+	}
+	return []*models.Role{
+		role("00000000-0000-4000-8000-00000000b001", RoleAdmin, "Full access to every orchestrator resource"),
+		role("00000000-0000-4000-8000-00000000b002", RoleOperator, "Member access plus job and group operations"),
+		role("00000000-0000-4000-8000-00000000b003", RoleMember, "Create and manage own tasks, chats, skills, and credentials"),
+		role("00000000-0000-4000-8000-00000000b004", RoleViewer, "Read-only access to own tasks, skills, and usage"),
+	}
+}
+
+// seedBuiltinRoles inserts BuiltinRoles that are missing (by id or name).
+func (db *DB) seedBuiltinRoles(ctx context.Context) error {
+	now := time.Now().UTC()
+	for _, r := range BuiltinRoles() {
+		r.CreatedAt, r.UpdatedAt = now, now
+		if err := db.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(r).Error; err != nil {
+			return wrapErr(err, "seed role")
+		}
+	}
+	return nil
+}
+
+// CreateGroup inserts g. Returns ErrExists when the slug is taken.
+func (db *DB) CreateGroup(ctx context.Context, g *models.Group) error {
+	var n int64
+	if err := db.db.WithContext(ctx).Model(&models.Group{}).Where("slug = ?", g.Slug).Count(&n).Error; err != nil {
+		return wrapErr(err, "check group slug")
+	}
+	if n > 0 {
+		return ErrExists
+	}
+	if g.ID == uuid.Nil {
+		g.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	g.CreatedAt, g.UpdatedAt = now, now
+	return db.createRecord(ctx, g, "create group")
+}
+
+// GetGroupByID returns a group by id, or ErrNotFound.
+func (db *DB) GetGroupByID(ctx context.Context, id uuid.UUID) (*models.Group, error) {
+	return getByID[models.Group](db, ctx, id, "get group by id")
+}
+
+// GetGroupBySlug returns a group by slug, or ErrNotFound.
+func (db *DB) GetGroupBySlug(ctx context.Context, slug string) (*models.Group, error) {
+	return getWhere[models.Group](db, ctx, "slug", slug, "get group by slug")
+}
+
+// ListGroups returns groups ordered by slug. When memberID is set, only active groups in which that user has an
+// active membership are returned.
+func (db *DB) ListGroups(ctx context.Context, memberID *uuid.UUID) ([]*models.Group, error) {
+	q := db.db.WithContext(ctx).Model(&models.Group{})
+	if memberID != nil {
+		q = q.Where("id IN (?)", db.activeGroupIDsQuery(ctx, *memberID))
+	}
+	var out []*models.Group
+	if err := q.Order("slug").Find(&out).Error; err != nil {
+		return nil, wrapErr(err, "list groups")
+	}
+	return out, nil
+}
+
+// AddGroupMember makes userID an active member of groupID. Adding an existing member is a no-op; a removed
+// membership is reactivated.
+func (db *DB) AddGroupMember(ctx context.Context, groupID, userID uuid.UUID, updatedBy string) (*models.GroupMembership, error) {
+	var m models.GroupMembership
+	err := db.db.WithContext(ctx).Where("group_id = ? AND user_id = ?", groupID, userID).First(&m).Error
+	if err == nil {
+		if !m.IsActive {
+			m.IsActive, m.UpdatedBy, m.UpdatedAt = true, &updatedBy, time.Now().UTC()
+			if err := db.db.WithContext(ctx).Save(&m).Error; err != nil {
+				return nil, wrapErr(err, "reactivate group membership")
+			}
+		}
+		return &m, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, wrapErr(err, "get group membership")
+	}
+	now := time.Now().UTC()
+	managedBy := ManagedByLocal
+	m = models.GroupMembership{
+		ID: uuid.New(), GroupID: groupID, UserID: userID, IsActive: true, ManagedBy: &managedBy,
+		CreatedAt: now, UpdatedAt: now, UpdatedBy: &updatedBy,
+	}
+	return createReturning(db, ctx, &m, "create group membership")
+}
+
+// RemoveGroupMember deactivates userID's membership in groupID. Returns ErrNotFound when there is no active
+// membership.
+func (db *DB) RemoveGroupMember(ctx context.Context, groupID, userID uuid.UUID, updatedBy string) error {
+	res := db.db.WithContext(ctx).Model(&models.GroupMembership{}).
+		Where("group_id = ? AND user_id = ? AND is_active = ?", groupID, userID, true).
+		Updates(map[string]interface{}{"is_active": false, "updated_by": updatedBy, "updated_at": time.Now().UTC()})
+	if res.Error != nil {
+		return wrapErr(res.Error, "remove group member")
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListGroupMembers returns the active memberships of groupID.
+func (db *DB) ListGroupMembers(ctx context.Context, groupID uuid.UUID) ([]*models.GroupMembership, error) {
+	var out []*models.GroupMembership
+	err := db.db.WithContext(ctx).Where("group_id = ? AND is_active = ?", groupID, true).
+		Order("created_at, id").Find(&out).Error
+	if err != nil {
+		return nil, wrapErr(err, "list group members")
+	}
+	return out, nil
+}
+
+// ListGroupIDsForUser returns the ids of active groups in which userID has an active membership, ascending.
+func (db *DB) ListGroupIDsForUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	if err := db.activeGroupIDsQuery(ctx, userID).Order("group_memberships.group_id").Scan(&ids).Error; err != nil {
+		return nil, wrapErr(err, "list group ids for user")
+	}
+	return ids, nil
+}
+
+// SyncExternalGroupMemberships makes userID an active member of each of groupIDs and deactivates the user's
+// other external_sync memberships. A new or reactivated membership is marked external_sync; an active local
+// membership is left as it is, so removing the IdP group never removes access granted through the API.
+func (db *DB) SyncExternalGroupMemberships(ctx context.Context, userID uuid.UUID, groupIDs []uuid.UUID, updatedBy string) error {
+	return db.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+		managedBy := ManagedByExternalSync
+		stale := tx.Model(&models.GroupMembership{}).
+			Where("user_id = ? AND is_active = ? AND managed_by = ?", userID, true, ManagedByExternalSync)
+		if len(groupIDs) > 0 {
+			stale = stale.Where("group_id NOT IN ?", groupIDs)
+		}
+		if err := stale.Updates(map[string]interface{}{"is_active": false, "updated_by": updatedBy, "updated_at": now}).Error; err != nil {
+			return wrapErr(err, "remove external group memberships")
+		}
+		for _, groupID := range groupIDs {
+			if err := syncExternalMembership(tx, groupID, userID, managedBy, updatedBy, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func syncExternalMembership(tx *gorm.DB, groupID, userID uuid.UUID, managedBy, updatedBy string, now time.Time) error {
+	var m models.GroupMembership
+	err := tx.Where("group_id = ? AND user_id = ?", groupID, userID).First(&m).Error
+	switch {
+	case err == nil && m.IsActive:
+		return nil
+	case err == nil:
+		m.IsActive, m.ManagedBy, m.UpdatedBy, m.UpdatedAt = true, &managedBy, &updatedBy, now
+		return wrapErr(tx.Save(&m).Error, "reactivate external group membership")
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return wrapErr(err, "get group membership")
+	}
+	m = models.GroupMembership{
+		ID: uuid.New(), GroupID: groupID, UserID: userID, IsActive: true, ManagedBy: &managedBy,
+		CreatedAt: now, UpdatedAt: now, UpdatedBy: &updatedBy,
+	}
+	return wrapErr(tx.Create(&m).Error, "create external group membership")
+}
+
+// activeGroupIDsQuery selects the group ids of userID's active memberships in active groups; usable as a subquery.
+func (db *DB) activeGroupIDsQuery(ctx context.Context, userID uuid.UUID) *gorm.DB {
+	return db.db.WithContext(ctx).Model(&models.GroupMembership{}).
+		Select("group_memberships.group_id").
+		Joins("JOIN groups ON groups.id = group_memberships.group_id").
+		Where("group_memberships.user_id = ? AND group_memberships.is_active = ? AND groups.is_active = ?", userID, true, true)
+}
+
+// ListRoles returns all roles ordered by name.
+func (db *DB) ListRoles(ctx context.Context) ([]*models.Role, error) {
+	var out []*models.Role
+	if err := db.db.WithContext(ctx).Order("name").Find(&out).Error; err != nil {
+		return nil, wrapErr(err, "list roles")
+	}
+	return out, nil
+}
+
+// CreateRoleBinding inserts b as active. Returns ErrExists when an identical active binding exists.
+func (db *DB) CreateRoleBinding(ctx context.Context, b *models.RoleBinding) error {
+	q := db.db.WithContext(ctx).Model(&models.RoleBinding{}).
+		Where("subject_type = ? AND subject_id = ? AND role_id = ? AND scope_type = ? AND is_active = ?",
+			b.SubjectType, b.SubjectID, b.RoleID, b.ScopeType, true)
+	if b.ScopeID == nil {
+		q = q.Where("scope_id IS NULL")
+	} else {
+		q = q.Where("scope_id = ?", *b.ScopeID)
+	}
+	var n int64
+	if err := q.Count(&n).Error; err != nil {
+		return wrapErr(err, "check role binding")
+	}
+	if n > 0 {
+		return ErrExists
+	}
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	b.IsActive, b.CreatedAt, b.UpdatedAt = true, now, now
+	return db.createRecord(ctx, b, "create role binding")
+}
+
+// DisableRoleBinding deactivates a binding and returns it, or ErrNotFound.
+func (db *DB) DisableRoleBinding(ctx context.Context, id uuid.UUID, updatedBy string) (*models.RoleBinding, error) {
+	err := db.updateWhere(ctx, &models.RoleBinding{}, "id", id,
+		map[string]interface{}{"is_active": false, "updated_by": updatedBy}, "disable role binding")
+	if err != nil {
+		return nil, err
+	}
+	return getByID[models.RoleBinding](db, ctx, id, "get role binding")
+}
+
+// HasAnyRoleBinding reports whether subject has any role binding, active or disabled.
+func (db *DB) HasAnyRoleBinding(ctx context.Context, subject AccessSubject) (bool, error) {
+	var n int64
+	err := db.db.WithContext(ctx).Model(&models.RoleBinding{}).
+		Where("subject_type = ? AND subject_id = ?", subject.Type, subject.ID).
+		Count(&n).Error
+	if err != nil {
+		return false, wrapErr(err, "has any role binding")
+	}
+	return n > 0, nil
+}
+
+// ListRoleBindings returns active bindings whose subject is one of subjects, or every active binding when
+// subjects is empty.
+func (db *DB) ListRoleBindings(ctx context.Context, subjects []AccessSubject) ([]*models.RoleBinding, error) {
+	q := db.db.WithContext(ctx).Where("is_active = ?", true)
+	if len(subjects) > 0 {
+		match := db.db.Where("subject_type = ? AND subject_id = ?", subjects[0].Type, subjects[0].ID)
+		for _, s := range subjects[1:] {
+			match = match.Or("subject_type = ? AND subject_id = ?", s.Type, s.ID)
+		}
+		q = q.Where(match)
+	}
+	var out []*models.RoleBinding
+	if err := q.Order("created_at, id").Find(&out).Error; err != nil {
+		return nil, wrapErr(err, "list role bindings")
+	}
+	return out, nil
+}
@@ -0,0 +1,102 @@
+// Package database: refresh sessions as the user's signed-in devices, and the access token deny-list.
+// See docs/tech_specs/local_user_accounts.md (Sessions and Revocation).
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+// RotateRefreshSession stores the new refresh token hash, expiries, and client of s, which must still be active
+// and hold oldTokenHash. Returns ErrNotFound otherwise, so a refresh token replayed after rotation is refused.
+func (db *DB) RotateRefreshSession(ctx context.Context, s *models.RefreshSession, oldTokenHash []byte) error {
+	now := time.Now().UTC()
+	res := db.db.WithContext(ctx).Model(&models.RefreshSession{}).
+		Where("id = ? AND refresh_token_hash = ? AND is_active = ?", s.ID, oldTokenHash, true).
+		Updates(map[string]interface{}{
+			"refresh_token_hash": s.RefreshTokenHash,
+			"expires_at":         s.ExpiresAt,
+			"access_expires_at":  s.AccessExpiresAt,
+			"user_agent":         s.UserAgent,
+			"ip_address":         s.IPAddress,
+			"last_used_at":       now,
+			"updated_at":         now,
+		})
+	if res.Error != nil {
+		return wrapErr(res.Error, "rotate refresh session")
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	s.LastUsedAt, s.UpdatedAt = &now, now
+	return nil
+}
+
+// GetRefreshSessionByID returns a session by id, active or not.
+func (db *DB) GetRefreshSessionByID(ctx context.Context, id uuid.UUID) (*models.RefreshSession, error) {
+	return getByID[models.RefreshSession](db, ctx, id, "get refresh session by id")
+}
+
+// ListActiveRefreshSessions returns userID's active, unexpired sessions, newest first.
+func (db *DB) ListActiveRefreshSessions(ctx context.Context, userID uuid.UUID) ([]*models.RefreshSession, error) {
+	var out []*models.RefreshSession
+	err := db.db.WithContext(ctx).
+		Where("user_id = ? AND is_active = ? AND expires_at > ?", userID, true, time.Now().UTC()).
+		Order("created_at DESC").Find(&out).Error
+	return out, wrapErr(err, "list refresh sessions")
+}
+
+// InvalidateRefreshSession revokes a session and denies its access tokens until they expire.
+func (db *DB) InvalidateRefreshSession(ctx context.Context, sessionID uuid.UUID) error {
+	return db.invalidateSessions(ctx, "id = ?", sessionID, "invalidate refresh session")
+}
+
+// InvalidateAllUserSessions revokes all sessions of a user and denies their access tokens until they expire.
+func (db *DB) InvalidateAllUserSessions(ctx context.Context, userID uuid.UUID) error {
+	return db.invalidateSessions(ctx, "user_id = ?", userID, "invalidate all user sessions")
+}
+
+// IsAccessSessionDenied reports whether access tokens of sessionID are on the deny-list.
+func (db *DB) IsAccessSessionDenied(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+	var n int64
+	err := db.db.WithContext(ctx).Model(&models.AccessTokenDenial{}).
+		Where("session_id = ? AND expires_at > ?", sessionID, time.Now().UTC()).Count(&n).Error
+	return n > 0, wrapErr(err, "check access token deny-list")
+}
+
+func (db *DB) invalidateSessions(ctx context.Context, where string, arg interface{}, op string) error {
+	return db.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return wrapErr(invalidateSessionsTx(tx, where, arg), op)
+	})
+}
+
+// invalidateSessionsTx deactivates the active sessions matching where and adds those with unexpired access
+// tokens to the deny-list. Expired deny-list entries are purged on the way.
+func invalidateSessionsTx(tx *gorm.DB, where string, arg interface{}) error {
+	var sessions []*models.RefreshSession
+	if err := tx.Where(where, arg).Where("is_active = ?", true).Find(&sessions).Error; err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	var denials []*models.AccessTokenDenial
+	for _, s := range sessions {
+		if s.AccessExpiresAt != nil && s.AccessExpiresAt.After(now) {
+			denials = append(denials, &models.AccessTokenDenial{SessionID: s.ID, UserID: s.UserID, ExpiresAt: *s.AccessExpiresAt, CreatedAt: now})
+		}
+	}
+	if len(denials) > 0 {
+		if err := tx.Create(&denials).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Where("expires_at <= ?", now).Delete(&models.AccessTokenDenial{}).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.RefreshSession{}).Where(where, arg).Where("is_active = ?", true).
+		Updates(map[string]interface{}{"is_active": false, "updated_at": now}).Error
+}
@@ -14,7 +14,27 @@ import (
 // DefaultSkillID is the reserved UUID for the built-in CyNodeAI interaction skill (REQ-SKILLS-0116).
 var DefaultSkillID = uuid.MustParse("00000000-0000-4000-8000-000000000001")
 
-const scopeUser = "user"
+const (
+	scopeUser  = "user"
+	scopeGroup = "group"
+)
+
+// SkillVisibleTo reports whether a user who belongs to groupIDs may read and change s: the system skill, skills the
+// user owns, and group-scoped skills shared with one of the user's groups.
+func SkillVisibleTo(s *models.Skill, userID uuid.UUID, groupIDs []uuid.UUID) bool {
+	if s.IsSystem || (s.OwnerID != nil && *s.OwnerID == userID) {
+		return true
+	}
+	if s.Scope != scopeGroup || s.GroupID == nil {
+		return false
+	}
+	for _, id := range groupIDs {
+		if id == *s.GroupID {
+			return true
+		}
+	}
+	return false
+}
 
 // CreateSkill stores a new skill and returns it. Scope must be user|group|project|global; default user. OwnerID required for non-system skills.
 func (db *DB) CreateSkill(ctx context.Context, name, content, scope string, ownerID *uuid.UUID, isSystem bool) (*models.Skill, error) {
@@ -47,10 +67,11 @@ func (db *DB) GetSkillByID(ctx context.Context, id uuid.UUID) (*models.Skill, er
 	return &s, nil
 }
 
-// ListSkillsForUser returns skills visible to the user: own skills (owner_id = userID) plus system default. scopeFilter and ownerFilter optional (empty = no filter).
+// ListSkillsForUser returns skills visible to the user: own skills (owner_id = userID), group skills shared with the
+// user's active groups, plus system default. scopeFilter and ownerFilter optional (empty = no filter).
 func (db *DB) ListSkillsForUser(ctx context.Context, userID uuid.UUID, scopeFilter, ownerFilter string) ([]*models.Skill, error) {
 	q := db.db.WithContext(ctx).Model(&models.Skill{}).
-		Where("is_system = ? OR owner_id = ?", true, userID)
+		Where("is_system = ? OR owner_id = ? OR (scope = ? AND group_id IN (?))", true, userID, scopeGroup, db.activeGroupIDsQuery(ctx, userID))
 	if scopeFilter != "" {
 		q = q.Where("scope = ?", scopeFilter)
 	}
@@ -91,6 +112,15 @@ func (db *DB) UpdateSkill(ctx context.Context, id uuid.UUID, name, content, scop
 	return db.GetSkillByID(ctx, id)
 }
 
+// SetSkillGroup sets or clears the group a skill is shared with and returns the skill, or ErrNotFound.
+func (db *DB) SetSkillGroup(ctx context.Context, id uuid.UUID, groupID *uuid.UUID) (*models.Skill, error) {
+	err := db.updateWhere(ctx, &models.Skill{}, "id", id, map[string]interface{}{"group_id": groupID}, "set skill group")
+	if err != nil {
+		return nil, err
+	}
+	return db.GetSkillByID(ctx, id)
+}
+
 // DeleteSkill removes a skill by id. System skill cannot be deleted; returns error.
 func (db *DB) DeleteSkill(ctx context.Context, id uuid.UUID) error {
 	var s models.Skill
@@ -2,12 +2,15 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
 )
@@ -35,6 +38,15 @@ func (db *DB) CreateTask(ctx context.Context, createdBy *uuid.UUID, prompt strin
 	if len(projectID) > 0 {
 		effectiveProjectID = projectID[0]
 	}
+	task, err := createTask(db.db.WithContext(ctx), createdBy, prompt, taskName, effectiveProjectID)
+	if err != nil {
+		return nil, wrapErr(err, "create task")
+	}
+	return task, nil
+}
+
+// createTask inserts a pending task through tx, choosing its summary as described on CreateTask.
+func createTask(tx *gorm.DB, createdBy *uuid.UUID, prompt string, taskName *string, projectID *uuid.UUID) (*models.Task, error) {
 	summary := ""
 	if taskName != nil {
 		summary = normalizeTaskName(*taskName)
@@ -42,7 +54,7 @@ func (db *DB) CreateTask(ctx context.Context, createdBy *uuid.UUID, prompt strin
 	if summary == "" {
 		var count int64
 		if createdBy != nil {
-			_ = db.db.WithContext(ctx).Model(&models.Task{}).Where("created_by = ?", createdBy).Count(&count).Error
+			_ = tx.Model(&models.Task{}).Where("created_by = ?", createdBy).Count(&count).Error
 		}
 		summary = fmt.Sprintf("task_name_%03d", count+1)
 	} else if createdBy != nil {
@@ -50,7 +62,7 @@ func (db *DB) CreateTask(ctx context.Context, createdBy *uuid.UUID, prompt strin
 		base := summary
 		for n := 2; ; n++ {
 			var exists int64
-			_ = db.db.WithContext(ctx).Model(&models.Task{}).Where("created_by = ? AND summary = ?", createdBy, summary).Limit(1).Count(&exists).Error
+			_ = tx.Model(&models.Task{}).Where("created_by = ? AND summary = ?", createdBy, summary).Limit(1).Count(&exists).Error
 			if exists == 0 {
 				break
 			}
@@ -60,14 +72,14 @@ func (db *DB) CreateTask(ctx context.Context, createdBy *uuid.UUID, prompt strin
 	task := &models.Task{
 		ID:        uuid.New(),
 		CreatedBy: createdBy,
-		ProjectID: effectiveProjectID,
+		ProjectID: projectID,
 		Status:    models.TaskStatusPending,
 		Prompt:    &prompt,
 		Summary:   &summary,
 		CreatedAt: time.Now().UTC(),
 		UpdatedAt: time.Now().UTC(),
 	}
-	if err := db.createRecord(ctx, task, "create task"); err != nil {
+	if err := tx.Create(task).Error; err != nil {
 		return nil, err
 	}
 	return task, nil
@@ -118,25 +130,34 @@ func (db *DB) GetTaskBySummary(ctx context.Context, userID uuid.UUID, summary st
 // superseded) are never overwritten; the update is silently skipped if the task is already
 // in a terminal state so that race conditions between the dispatcher and cancel cannot
 // revert a canceled task back to running or failed.
+// Setting failed or canceled cascades to the task's open dependents (cascadeTaskStatus).
 func (db *DB) UpdateTaskStatus(ctx context.Context, taskID uuid.UUID, status string) error {
 	closed := isTerminalTaskStatus(status)
 	now := time.Now().UTC()
-	terminalStatuses := []string{
-		models.TaskStatusCompleted,
-		models.TaskStatusFailed,
-		models.TaskStatusCanceled,
-		models.TaskStatusSuperseded,
-	}
-	return wrapErr(
-		db.db.WithContext(ctx).Model(&models.Task{}).
-			Where("id = ? AND status NOT IN ?", taskID, terminalStatuses).
+	return wrapErr(db.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.Task{}).
+			Where("id = ? AND status NOT IN ?", taskID, terminalTaskStatuses).
 			Updates(map[string]interface{}{
 				"status":     status,
 				"closed":     closed,
 				"updated_at": now,
-			}).Error,
-		"update task status",
-	)
+			})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		if status == models.TaskStatusFailed || status == models.TaskStatusCanceled {
+			return cascadeTaskStatus(tx, taskID, status, now)
+		}
+		return nil
+	}), "update task status")
+}
+
+// terminalTaskStatuses are task statuses that are never overwritten by UpdateTaskStatus.
+var terminalTaskStatuses = []string{
+	models.TaskStatusCompleted,
+	models.TaskStatusFailed,
+	models.TaskStatusCanceled,
+	models.TaskStatusSuperseded,
 }
 
 func isTerminalTaskStatus(status string) bool {
@@ -154,6 +175,12 @@ func (db *DB) UpdateTaskSummary(ctx context.Context, taskID uuid.UUID, summary s
 		map[string]interface{}{"summary": summary}, "update task summary")
 }
 
+// UpdateTaskType records the task type (input mode, or "sba") used by access-control conditions.
+func (db *DB) UpdateTaskType(ctx context.Context, taskID uuid.UUID, taskType string) error {
+	return db.updateWhere(ctx, &models.Task{}, "id", taskID,
+		map[string]interface{}{"task_type": taskType}, "update task type")
+}
+
 // ListTasksByUser lists tasks created by a user.
 func (db *DB) ListTasksByUser(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.Task, error) {
 	var tasks []*models.Task
@@ -245,18 +272,30 @@ func (db *DB) UpdateJobStatus(ctx context.Context, jobID uuid.UUID, status strin
 		map[string]interface{}{"status": status}, "update job status")
 }
 
-// AssignJobToNode assigns a job to a node.
-func (db *DB) AssignJobToNode(ctx context.Context, jobID, nodeID uuid.UUID) error {
+// AssignJobToNode marks a job claimed under leaseID as running on nodeID and records the scheduling reason.
+// Returns ErrNotFound when the job does not exist and ErrLeaseHeld when it is no longer queued under leaseID
+// (canceled after it was claimed), in which case it must not be sent to the node.
+func (db *DB) AssignJobToNode(ctx context.Context, jobID, leaseID, nodeID uuid.UUID, reason string) error {
 	now := time.Now().UTC()
-	err := db.db.WithContext(ctx).Model(&models.Job{}).
-		Where("id = ?", jobID).
+	res := db.db.WithContext(ctx).Model(&models.Job{}).
+		Where("id = ? AND lease_id = ? AND status = ?", jobID, leaseID, models.JobStatusQueued).
 		Updates(map[string]interface{}{
-			"node_id":    nodeID,
-			"status":     models.JobStatusRunning,
-			"started_at": now,
-			"updated_at": now,
-		}).Error
-	return wrapErr(err, "assign job to node")
+			"node_id":           nodeID,
+			"status":            models.JobStatusRunning,
+			"scheduling_reason": reason,
+			"started_at":        now,
+			"updated_at":        now,
+		})
+	if res.Error != nil {
+		return wrapErr(res.Error, "assign job to node")
+	}
+	if res.RowsAffected == 0 {
+		if _, err := db.GetJobByID(ctx, jobID); err != nil {
+			return err
+		}
+		return ErrLeaseHeld
+	}
+	return nil
 }
 
 // CompleteJob marks a job as completed with a result.
@@ -278,6 +317,7 @@ func (db *DB) GetNextQueuedJob(ctx context.Context) (*models.Job, error) {
 	var job models.Job
 	err := db.db.WithContext(ctx).
 		Where("status = ?", models.JobStatusQueued).
+		Where(depsSatisfiedClause).
 		Order("created_at ASC").
 		Limit(1).
 		First(&job).Error
@@ -286,3 +326,240 @@ func (db *DB) GetNextQueuedJob(ctx context.Context) (*models.Job, error) {
 	}
 	return &job, nil
 }
+
+// ClaimNextQueuedJob atomically claims the oldest queued job whose lease is unset or expired.
+// The row is selected with FOR UPDATE SKIP LOCKED and lease_id/lease_expires_at are set in the same
+// transaction, so concurrent dispatchers (goroutines or control-plane replicas) never claim the same job.
+// Returns ErrNotFound when no job is claimable.
+func (db *DB) ClaimNextQueuedJob(ctx context.Context, leaseID uuid.UUID, leaseExpiresAt time.Time) (*models.Job, error) {
+	var job models.Job
+	err := db.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND (lease_expires_at IS NULL OR lease_expires_at < ?) AND (next_attempt_at IS NULL OR next_attempt_at <= ?)",
+				models.JobStatusQueued, now, now).
+			Where(depsSatisfiedClause).
+			Order("created_at ASC").
+			Limit(1).
+			First(&job).Error
+		if err != nil {
+			return err
+		}
+		job.LeaseID = &leaseID
+		job.LeaseExpiresAt = &leaseExpiresAt
+		job.Attempts++
+		job.UpdatedAt = now
+		return tx.Model(&models.Job{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+			"lease_id":         leaseID,
+			"lease_expires_at": leaseExpiresAt,
+			"attempts":         job.Attempts,
+			"updated_at":       now,
+		}).Error
+	})
+	if err != nil {
+		return nil, wrapErr(err, "claim next queued job")
+	}
+	return &job, nil
+}
+
+// ReleaseJobClaim clears the lease on a job that is still queued and held by leaseID,
+// making it immediately claimable again (e.g. when no node was eligible). The claim does not count
+// as a dispatch attempt. No-op otherwise.
+func (db *DB) ReleaseJobClaim(ctx context.Context, jobID, leaseID uuid.UUID) error {
+	err := db.db.WithContext(ctx).Model(&models.Job{}).
+		Where("id = ? AND lease_id = ? AND status = ?", jobID, leaseID, models.JobStatusQueued).
+		Updates(map[string]interface{}{
+			"lease_id":         nil,
+			"lease_expires_at": nil,
+			"attempts":         gorm.Expr("GREATEST(attempts - 1, 0)"),
+			"updated_at":       time.Now().UTC(),
+		}).Error
+	return wrapErr(err, "release job claim")
+}
+
+// ExtendJobLease sets lease_expires_at for a running job whose lease_id matches (worker heartbeat).
+// Returns ErrNotFound when the job does not exist and ErrLeaseHeld when it is no longer running under leaseID.
+func (db *DB) ExtendJobLease(ctx context.Context, jobID, leaseID uuid.UUID, expiresAt time.Time) error {
+	res := db.db.WithContext(ctx).Model(&models.Job{}).
+		Where("id = ? AND lease_id = ? AND status = ?", jobID, leaseID, models.JobStatusRunning).
+		Updates(map[string]interface{}{
+			"lease_expires_at": expiresAt,
+			"updated_at":       time.Now().UTC(),
+		})
+	if res.Error != nil {
+		return wrapErr(res.Error, "extend job lease")
+	}
+	if res.RowsAffected == 0 {
+		if _, err := db.GetJobByID(ctx, jobID); err != nil {
+			return err
+		}
+		return ErrLeaseHeld
+	}
+	return nil
+}
+
+// CompleteLeasedJob records the result of a job that is still running under leaseID.
+// Returns ErrNotFound when the job does not exist and ErrLeaseHeld when it was canceled, reclaimed, or already completed.
+func (db *DB) CompleteLeasedJob(ctx context.Context, jobID, leaseID uuid.UUID, result, status string) error {
+	now := time.Now().UTC()
+	res := db.db.WithContext(ctx).Model(&models.Job{}).
+		Where("id = ? AND lease_id = ? AND status = ?", jobID, leaseID, models.JobStatusRunning).
+		Updates(map[string]interface{}{
+			"result":     models.NewJSONBString(&result),
+			"status":     status,
+			"ended_at":   now,
+			"updated_at": now,
+		})
+	if res.Error != nil {
+		return wrapErr(res.Error, "complete leased job")
+	}
+	if res.RowsAffected == 0 {
+		if _, err := db.GetJobByID(ctx, jobID); err != nil {
+			return err
+		}
+		return ErrLeaseHeld
+	}
+	return nil
+}
+
+// RequeueJobForRetry puts a job that failed while running under leaseID back in the queue, not claimable
+// before nextAttemptAt. result (the failed attempt's result) is kept on the job until the next attempt completes.
+// Returns ErrNotFound when the job does not exist and ErrLeaseHeld when it is no longer running under leaseID.
+func (db *DB) RequeueJobForRetry(ctx context.Context, jobID, leaseID uuid.UUID, result string, nextAttemptAt time.Time) error {
+	res := db.db.WithContext(ctx).Model(&models.Job{}).
+		Where("id = ? AND lease_id = ? AND status = ?", jobID, leaseID, models.JobStatusRunning).
+		Updates(map[string]interface{}{
+			"status":            models.JobStatusQueued,
+			"result":            models.NewJSONBString(&result),
+			"node_id":           nil,
+			"lease_id":          nil,
+			"lease_expires_at":  nil,
+			"scheduling_reason": nil,
+			"started_at":        nil,
+			"next_attempt_at":   nextAttemptAt,
+			"updated_at":        time.Now().UTC(),
+		})
+	if res.Error != nil {
+		return wrapErr(res.Error, "requeue job for retry")
+	}
+	if res.RowsAffected == 0 {
+		if _, err := db.GetJobByID(ctx, jobID); err != nil {
+			return err
+		}
+		return ErrLeaseHeld
+	}
+	return nil
+}
+
+// CreateJobAttempt appends a row to the job's attempt history.
+func (db *DB) CreateJobAttempt(ctx context.Context, attempt *models.JobAttempt) error {
+	if attempt.ID == uuid.Nil {
+		attempt.ID = uuid.New()
+	}
+	if attempt.EndedAt.IsZero() {
+		attempt.EndedAt = time.Now().UTC()
+	}
+	return db.createRecord(ctx, attempt, "create job attempt")
+}
+
+// ListJobAttempts returns a job's attempt history, oldest first.
+func (db *DB) ListJobAttempts(ctx context.Context, jobID uuid.UUID) ([]*models.JobAttempt, error) {
+	var attempts []*models.JobAttempt
+	err := db.db.WithContext(ctx).
+		Where("job_id = ?", jobID).
+		Order("attempt ASC, ended_at ASC").
+		Find(&attempts).Error
+	if err != nil {
+		return nil, wrapErr(err, "list job attempts")
+	}
+	return attempts, nil
+}
+
+// ListDeadLetteredJobs returns dead-lettered jobs, most recently ended first.
+func (db *DB) ListDeadLetteredJobs(ctx context.Context, limit, offset int) ([]*models.Job, error) {
+	var jobs []*models.Job
+	err := db.db.WithContext(ctx).
+		Where("status = ?", models.JobStatusDeadLettered).
+		Order("ended_at DESC").
+		Limit(limit).Offset(offset).
+		Find(&jobs).Error
+	if err != nil {
+		return nil, wrapErr(err, "list dead-lettered jobs")
+	}
+	return jobs, nil
+}
+
+// RequeueDeadLetteredJob returns a dead-lettered job to the queue with a fresh attempt budget and reopens its
+// failed task, along with the dependents that failed only because it did. Returns ErrNotFound when the job does not exist and ErrConflict when it is not dead-lettered.
+func (db *DB) RequeueDeadLetteredJob(ctx context.Context, jobID uuid.UUID) (*models.Job, error) {
+	var job models.Job
+	err := db.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", jobID).First(&job).Error; err != nil {
+			return err
+		}
+		if job.Status != models.JobStatusDeadLettered {
+			return ErrConflict
+		}
+		now := time.Now().UTC()
+		job.Status = models.JobStatusQueued
+		job.Attempts = 0
+		job.NodeID, job.LeaseID, job.LeaseExpiresAt, job.NextAttemptAt = nil, nil, nil, nil
+		job.SchedulingReason, job.StartedAt, job.EndedAt = nil, nil, nil
+		job.UpdatedAt = now
+		err := tx.Model(&models.Job{}).Where("id = ?", jobID).Updates(map[string]interface{}{
+			"status":            job.Status,
+			"attempts":          0,
+			"node_id":           nil,
+			"lease_id":          nil,
+			"lease_expires_at":  nil,
+			"next_attempt_at":   nil,
+			"scheduling_reason": nil,
+			"started_at":        nil,
+			"ended_at":          nil,
+			"updated_at":        now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		res := tx.Model(&models.Task{}).
+			Where("id = ? AND status = ?", job.TaskID, models.TaskStatusFailed).
+			Updates(map[string]interface{}{"status": models.TaskStatusPending, "closed": false, "updated_at": now})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		return reopenCascadedDependents(tx, job.TaskID, now)
+	})
+	if err != nil {
+		if errors.Is(err, ErrConflict) {
+			return nil, err
+		}
+		return nil, wrapErr(err, "requeue dead-lettered job")
+	}
+	return &job, nil
+}
+
+// ListExpiredLeaseJobs returns running jobs whose lease expired before now, oldest lease first. It does not change
+// them: the caller fails each attempt under its lease_id, so a job completed or reclaimed meanwhile is left alone.
+func (db *DB) ListExpiredLeaseJobs(ctx context.Context, now time.Time) ([]*models.Job, error) {
+	var jobs []*models.Job
+	err := db.db.WithContext(ctx).
+		Where("status = ? AND lease_id IS NOT NULL AND lease_expires_at IS NOT NULL AND lease_expires_at < ?", models.JobStatusRunning, now).
+		Order("lease_expires_at ASC").Find(&jobs).Error
+	if err != nil {
+		return nil, wrapErr(err, "list expired lease jobs")
+	}
+	return jobs, nil
+}
+
+// ListRunningJobs returns running jobs that have been assigned to a node, oldest first.
+func (db *DB) ListRunningJobs(ctx context.Context) ([]*models.Job, error) {
+	var jobs []*models.Job
+	err := db.db.WithContext(ctx).
+		Where("status = ? AND node_id IS NOT NULL", models.JobStatusRunning).
+		Order("created_at ASC").
+		Find(&jobs).Error
+	if err != nil {
+		return nil, wrapErr(err, "list running jobs")
+	}
+	return jobs, nil
+}
@@ -3,6 +3,10 @@ package database
 import (
 	"os"
 	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
 )
 
 // Task/Job Store behavior is covered by integration tests.
@@ -60,3 +64,17 @@ func TestIsTerminalTaskStatus(t *testing.T) {
 		}
 	}
 }
+
+func TestCreatesDependencyCycle(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	edges := []*models.TaskDependency{{TaskID: b, DependsOnTaskID: a}, {TaskID: c, DependsOnTaskID: b}}
+	if !CreatesDependencyCycle(edges, a, []uuid.UUID{c}) {
+		t.Error("a -> c closes a cycle")
+	}
+	if !CreatesDependencyCycle(nil, a, []uuid.UUID{a}) {
+		t.Error("self-dependency is a cycle")
+	}
+	if CreatesDependencyCycle(edges, c, []uuid.UUID{a}) {
+		t.Error("c -> a is not a cycle")
+	}
+}
@@ -702,12 +702,19 @@ func TestWithTestcontainers_AccessControlAndApiCredential(t *testing.T) {
 	if err := db.GORM().WithContext(ctx).Create(cred).Error; err != nil {
 		t.Fatalf("create api_credential: %v", err)
 	}
-	rules, err := store.ListAccessControlRulesForApiCall(ctx, "user", &user.ID, ActionApiCall, ResourceTypeProviderOperation)
+	rules, err := store.ListAccessControlRulesForSubjects(ctx, []AccessSubject{{Type: SubjectTypeUser, ID: user.ID}}, ActionApiCall, ResourceTypeProviderOperation)
 	if err != nil {
-		t.Fatalf("ListAccessControlRulesForApiCall: %v", err)
+		t.Fatalf("ListAccessControlRulesForSubjects: %v", err)
 	}
 	if len(rules) < 1 {
-		t.Errorf("ListAccessControlRulesForApiCall: want at least one rule, got %d", len(rules))
+		t.Errorf("ListAccessControlRulesForSubjects: want at least one rule, got %d", len(rules))
+	}
+	if rules, _ := store.ListAccessControlRulesForSubjects(ctx, []AccessSubject{{Type: SubjectTypeUser, ID: uuid.New()}}, ActionApiCall, ResourceTypeProviderOperation); len(rules) != 0 {
+		t.Errorf("ListAccessControlRulesForSubjects(other user): got %d rules", len(rules))
+	}
+	seeded, err := store.ListAccessControlRulesForSubjects(ctx, nil, ActionMcpToolInvoke, ResourceTypeMcpTool)
+	if err != nil || len(seeded) != 1 || seeded[0].SubjectType != SubjectTypeSystem {
+		t.Errorf("default mcp.tool.invoke rule: %v, %v", seeded, err)
 	}
 	hasCred, err := store.HasActiveApiCredentialForUserAndProvider(ctx, user.ID, "openai")
 	if err != nil {
@@ -716,6 +723,13 @@ func TestWithTestcontainers_AccessControlAndApiCredential(t *testing.T) {
 	if !hasCred {
 		t.Error("HasActiveApiCredentialForUserAndProvider: want true")
 	}
+	got, err := store.GetActiveApiCredentialForUserAndProvider(ctx, user.ID, "openai")
+	if err != nil || got.ID != cred.ID {
+		t.Errorf("GetActiveApiCredentialForUserAndProvider: got %v, %v", got, err)
+	}
+	if _, err := store.GetActiveApiCredentialForUserAndProvider(ctx, user.ID, "github"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetActiveApiCredentialForUserAndProvider(github): want ErrNotFound, got %v", err)
+	}
 	tcAssertHasAnyActiveApiCredential(t, store, ctx, true)
 	cancelCtx, cancel := context.WithCancel(ctx)
 	cancel()
@@ -738,3 +752,338 @@ func TestWithTestcontainers_AccessControlAndApiCredential(t *testing.T) {
 		t.Error("CreateAccessControlAuditLog: expected ID and CreatedAt set")
 	}
 }
+
+func TestWithTestcontainers_ApiCredentialLifecycle(t *testing.T) {
+	ctx := context.Background()
+	store := tcOpenDB(t, ctx)
+	user, err := store.CreateUser(ctx, "tc-cred-user", nil)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	kid := "k1"
+	cred := &models.ApiCredential{
+		OwnerType: "user", OwnerID: user.ID, Provider: "openai", CredentialType: "api_key",
+		CredentialName: "default", CredentialCiphertext: []byte("ct1"), CredentialKID: &kid, IsActive: true,
+	}
+	if err := store.CreateApiCredential(ctx, cred); err != nil {
+		t.Fatalf("CreateApiCredential: %v", err)
+	}
+	dup := *cred
+	dup.ID = uuid.Nil
+	if err := store.CreateApiCredential(ctx, &dup); !errors.Is(err, ErrExists) {
+		t.Errorf("duplicate CreateApiCredential: want ErrExists, got %v", err)
+	}
+	list, err := store.ListApiCredentials(ctx, ApiCredentialFilter{OwnerType: "user", OwnerID: &user.ID})
+	if err != nil || len(list) != 1 {
+		t.Fatalf("ListApiCredentials: %d, %v", len(list), err)
+	}
+	rotated, err := store.RotateApiCredential(ctx, cred.ID, []byte("ct2"), "k1", nil, "tc-cred-user")
+	if err != nil || string(rotated.CredentialCiphertext) != "ct2" {
+		t.Fatalf("RotateApiCredential: %v", err)
+	}
+	stale, err := store.ListApiCredentialsNotUnderKID(ctx, "k2", 10)
+	if err != nil || len(stale) != 1 {
+		t.Fatalf("ListApiCredentialsNotUnderKID: %d, %v", len(stale), err)
+	}
+	if err := store.RewrapApiCredential(ctx, cred.ID, "k1", []byte("ct3"), "k2"); err != nil {
+		t.Fatalf("RewrapApiCredential: %v", err)
+	}
+	if err := store.RewrapApiCredential(ctx, cred.ID, "k1", []byte("ct4"), "k2"); !errors.Is(err, ErrConflict) {
+		t.Errorf("second RewrapApiCredential: want ErrConflict, got %v", err)
+	}
+	disabled, err := store.DisableApiCredential(ctx, cred.ID, "tc-cred-user")
+	if err != nil || disabled.IsActive {
+		t.Fatalf("DisableApiCredential: %+v, %v", disabled, err)
+	}
+	if _, err := store.RotateApiCredential(ctx, cred.ID, []byte("ct5"), "k2", nil, "tc-cred-user"); !errors.Is(err, ErrConflict) {
+		t.Errorf("RotateApiCredential on disabled: want ErrConflict, got %v", err)
+	}
+	if _, err := store.GetApiCredentialByID(ctx, uuid.New()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetApiCredentialByID(missing): want ErrNotFound, got %v", err)
+	}
+}
+
+func TestWithTestcontainers_GroupsAndRoleBindings(t *testing.T) {
+	ctx := context.Background()
+	store := tcOpenDB(t, ctx)
+	user, err := store.CreateUser(ctx, "tc-rbac-user", nil)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	group := &models.Group{Slug: "tc-eng", DisplayName: "Eng", IsActive: true}
+	if err := store.CreateGroup(ctx, group); err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+	if err := store.CreateGroup(ctx, &models.Group{Slug: "tc-eng", DisplayName: "dup", IsActive: true}); !errors.Is(err, ErrExists) {
+		t.Errorf("duplicate CreateGroup: want ErrExists, got %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := store.AddGroupMember(ctx, group.ID, user.ID, "admin"); err != nil {
+			t.Fatalf("AddGroupMember #%d: %v", i, err)
+		}
+	}
+	ids, err := store.ListGroupIDsForUser(ctx, user.ID)
+	if err != nil || len(ids) != 1 || ids[0] != group.ID {
+		t.Fatalf("ListGroupIDsForUser: %v, %v", ids, err)
+	}
+	mine, err := store.ListGroups(ctx, &user.ID)
+	if err != nil || len(mine) != 1 {
+		t.Fatalf("ListGroups(member): %d, %v", len(mine), err)
+	}
+	roles, err := store.ListRoles(ctx)
+	if err != nil || len(roles) != len(BuiltinRoles()) {
+		t.Fatalf("ListRoles: %d, %v", len(roles), err)
+	}
+	b := &models.RoleBinding{SubjectType: SubjectTypeGroup, SubjectID: group.ID, RoleID: roles[0].ID, ScopeType: ScopeTypeSystem}
+	if err := store.CreateRoleBinding(ctx, b); err != nil {
+		t.Fatalf("CreateRoleBinding: %v", err)
+	}
+	again := &models.RoleBinding{SubjectType: SubjectTypeGroup, SubjectID: group.ID, RoleID: roles[0].ID, ScopeType: ScopeTypeSystem}
+	if err := store.CreateRoleBinding(ctx, again); !errors.Is(err, ErrExists) {
+		t.Errorf("duplicate CreateRoleBinding: want ErrExists, got %v", err)
+	}
+	bindings, err := store.ListRoleBindings(ctx, []AccessSubject{{Type: SubjectTypeUser, ID: user.ID}, {Type: SubjectTypeGroup, ID: group.ID}})
+	if err != nil || len(bindings) != 1 {
+		t.Fatalf("ListRoleBindings: %d, %v", len(bindings), err)
+	}
+	if disabled, err := store.DisableRoleBinding(ctx, b.ID, "admin"); err != nil || disabled.IsActive {
+		t.Fatalf("DisableRoleBinding: %+v, %v", disabled, err)
+	}
+	if err := store.RemoveGroupMember(ctx, group.ID, user.ID, "admin"); err != nil {
+		t.Fatalf("RemoveGroupMember: %v", err)
+	}
+	if err := store.RemoveGroupMember(ctx, group.ID, user.ID, "admin"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second RemoveGroupMember: want ErrNotFound, got %v", err)
+	}
+	if ids, _ := store.ListGroupIDsForUser(ctx, user.ID); len(ids) != 0 {
+		t.Errorf("ListGroupIDsForUser after removal: %v", ids)
+	}
+}
+
+func TestWithTestcontainers_ExternalUsersAndGroupSync(t *testing.T) {
+	ctx := context.Background()
+	store := tcOpenDB(t, ctx)
+	email := "tc-sso@example.com"
+	if _, err := store.CreateUser(ctx, "tc-sso-local", &email); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	user, err := store.CreateExternalUser(ctx, "tc-sso", &email, "https://idp.example", "sub-1")
+	if err != nil || user.Email != nil {
+		t.Fatalf("CreateExternalUser: %+v, %v (taken email must be dropped)", user, err)
+	}
+	if got, err := store.GetUserByExternalID(ctx, "https://idp.example", "sub-1"); err != nil || got.ID != user.ID {
+		t.Fatalf("GetUserByExternalID: %+v, %v", got, err)
+	}
+	if _, err := store.GetUserByExternalID(ctx, "https://other.example", "sub-1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetUserByExternalID(other issuer): want ErrNotFound, got %v", err)
+	}
+	local, synced := &models.Group{Slug: "tc-sso-local", IsActive: true}, &models.Group{Slug: "tc-sso-synced", IsActive: true}
+	for _, g := range []*models.Group{local, synced} {
+		if err := store.CreateGroup(ctx, g); err != nil {
+			t.Fatalf("CreateGroup: %v", err)
+		}
+	}
+	if g, err := store.GetGroupBySlug(ctx, "tc-sso-synced"); err != nil || g.ID != synced.ID {
+		t.Fatalf("GetGroupBySlug: %+v, %v", g, err)
+	}
+	if _, err := store.AddGroupMember(ctx, local.ID, user.ID, "admin"); err != nil {
+		t.Fatalf("AddGroupMember: %v", err)
+	}
+	if err := store.SyncExternalGroupMemberships(ctx, user.ID, []uuid.UUID{local.ID, synced.ID}, "sso"); err != nil {
+		t.Fatalf("SyncExternalGroupMemberships: %v", err)
+	}
+	if ids, _ := store.ListGroupIDsForUser(ctx, user.ID); len(ids) != 2 {
+		t.Fatalf("after sync: %v", ids)
+	}
+	if err := store.SyncExternalGroupMemberships(ctx, user.ID, nil, "sso"); err != nil {
+		t.Fatalf("SyncExternalGroupMemberships(empty): %v", err)
+	}
+	if ids, _ := store.ListGroupIDsForUser(ctx, user.ID); len(ids) != 1 || ids[0] != local.ID {
+		t.Errorf("after empty sync: %v, want only the local membership", ids)
+	}
+}
+
+func TestWithTestcontainers_AccessControlRuleCRUD(t *testing.T) {
+	ctx := context.Background()
+	store := tcOpenDB(t, ctx)
+	subject := uuid.New()
+	conditions := `{"max_request_bytes": 1024}`
+	rule := &models.AccessControlRule{
+		SubjectType: SubjectTypeUser, SubjectID: &subject, Action: ActionApiCall,
+		ResourceType: ResourceTypeProviderOperation, ResourcePattern: "openai/*", Effect: "allow", Priority: 10,
+		Conditions: &conditions,
+	}
+	if err := store.CreateAccessControlRule(ctx, rule); err != nil {
+		t.Fatalf("CreateAccessControlRule: %v", err)
+	}
+	rules, err := store.ListAccessControlRules(ctx, AccessControlRuleFilter{SubjectType: SubjectTypeUser, SubjectID: &subject})
+	if err != nil || len(rules) != 1 || rules[0].ID != rule.ID {
+		t.Fatalf("ListAccessControlRules: %v, %v", rules, err)
+	}
+	rule.SubjectType, rule.SubjectID, rule.Effect, rule.Conditions = SubjectTypeSystem, nil, "deny", nil
+	if err := store.UpdateAccessControlRule(ctx, rule); err != nil {
+		t.Fatalf("UpdateAccessControlRule: %v", err)
+	}
+	got, err := store.GetAccessControlRuleByID(ctx, rule.ID)
+	if err != nil || got.SubjectID != nil || got.Effect != "deny" || got.Conditions != nil {
+		t.Errorf("GetAccessControlRuleByID after update: %+v, %v", got, err)
+	}
+	if err := store.UpdateAccessControlRule(ctx, &models.AccessControlRule{ID: uuid.New()}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("UpdateAccessControlRule(missing): want ErrNotFound, got %v", err)
+	}
+	if err := store.DeleteAccessControlRule(ctx, rule.ID); err != nil {
+		t.Fatalf("DeleteAccessControlRule: %v", err)
+	}
+	if err := store.DeleteAccessControlRule(ctx, rule.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DeleteAccessControlRule(again): want ErrNotFound, got %v", err)
+	}
+}
+
+func TestWithTestcontainers_SearchAuditEvents(t *testing.T) {
+	ctx := context.Background()
+	store := tcOpenDB(t, ctx)
+	user, err := store.CreateUser(ctx, "audit-"+uuid.NewString()[:8], nil)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	task, project := uuid.New(), uuid.New()
+	reason := "bad password"
+	if err := store.CreateAuthAuditLog(ctx, &user.ID, "login_failure", false, nil, nil, &user.Handle, &reason); err != nil {
+		t.Fatalf("CreateAuthAuditLog: %v", err)
+	}
+	errType := "timeout"
+	if err := store.CreateMcpToolCallAuditLog(ctx, &models.McpToolCallAuditLog{
+		UserID: &user.ID, TaskID: &task, ProjectID: &project, ToolName: "db.preference.get", Decision: "allow",
+		Status: "error", ErrorType: &errType,
+	}); err != nil {
+		t.Fatalf("CreateMcpToolCallAuditLog: %v", err)
+	}
+	if err := store.CreateAccessControlAuditLog(ctx, &models.AccessControlAuditLog{
+		SubjectType: SubjectTypeUser, SubjectID: &user.ID, Action: ActionApiCall, ResourceType: ResourceTypeProviderOperation,
+		Resource: "openai/chat", Decision: "deny", TaskID: &task,
+	}); err != nil {
+		t.Fatalf("CreateAccessControlAuditLog: %v", err)
+	}
+	if err := store.CreateChatAuditLog(ctx, &models.ChatAuditLog{
+		ID: uuid.New(), CreatedAt: time.Now().UTC(), UserID: &user.ID, ProjectID: &project, Outcome: "success",
+	}); err != nil {
+		t.Fatalf("CreateChatAuditLog: %v", err)
+	}
+	pref := &models.PreferenceAuditLog{ID: uuid.New(), EntryID: uuid.New(), ChangedAt: time.Now().UTC(), ChangedBy: &user.Handle}
+	if err := store.(*DB).GORM().WithContext(ctx).Create(pref).Error; err != nil {
+		t.Fatalf("create preference audit log: %v", err)
+	}
+
+	events, next, err := store.SearchAuditEvents(ctx, AuditEventFilter{ActorID: &user.ID})
+	if err != nil || len(events) != 5 || next != "" {
+		t.Fatalf("SearchAuditEvents(actor): %d events, next %q, %v", len(events), next, err)
+	}
+	bySource := map[string]*models.AuditEvent{}
+	for i, e := range events {
+		bySource[e.Source] = e
+		if i > 0 && e.CreatedAt.After(events[i-1].CreatedAt) {
+			t.Errorf("events not newest first at %d", i)
+		}
+	}
+	if e := bySource[AuditSourceAuth]; e == nil || e.Action != "auth.login_failure" || e.Decision != "deny" {
+		t.Errorf("auth event = %+v", e)
+	}
+	if e := bySource[AuditSourceMcpToolCall]; e == nil || e.Details == nil || *e.Details != "error: timeout" {
+		t.Errorf("mcp event = %+v", e)
+	}
+	if e := bySource[AuditSourcePreference]; e == nil || e.ResourceID != pref.EntryID.String() {
+		t.Errorf("preference event = %+v", e)
+	}
+
+	for name, tc := range map[string]struct {
+		f    AuditEventFilter
+		want int
+	}{
+		"task":        {AuditEventFilter{TaskID: &task}, 2},
+		"project":     {AuditEventFilter{ProjectID: &project, ActorID: &user.ID}, 2},
+		"deny":        {AuditEventFilter{ActorID: &user.ID, Decision: "deny"}, 2},
+		"prefix":      {AuditEventFilter{ActorID: &user.ID, Action: "auth.*"}, 1},
+		"source":      {AuditEventFilter{ActorID: &user.ID, Source: AuditSourceChat}, 1},
+		"event":       {AuditEventFilter{EventID: &pref.ID}, 1},
+		"literal_pct": {AuditEventFilter{ActorID: &user.ID, Action: "%*"}, 0},
+	} {
+		got, _, err := store.SearchAuditEvents(ctx, tc.f)
+		if err != nil || len(got) != tc.want {
+			t.Errorf("%s: %d events, want %d (%v)", name, len(got), tc.want, err)
+		}
+	}
+	page, next, err := store.SearchAuditEvents(ctx, AuditEventFilter{ActorID: &user.ID, Limit: 3})
+	if err != nil || len(page) != 3 || next != "3" {
+		t.Fatalf("page 1: %d events, next %q, %v", len(page), next, err)
+	}
+	page, next, err = store.SearchAuditEvents(ctx, AuditEventFilter{ActorID: &user.ID, Limit: 3, Cursor: next})
+	if err != nil || len(page) != 2 || next != "" {
+		t.Errorf("page 2: %d events, next %q, %v", len(page), next, err)
+	}
+}
+
+func TestWithTestcontainers_ApiEgressLimits(t *testing.T) {
+	ctx := context.Background()
+	store := tcOpenDB(t, ctx)
+	provider := "tc-" + uuid.NewString()[:8]
+	rate := &models.ApiEgressLimit{Provider: provider, ScopeType: ApiEgressScopeUser, RequestsPerMinute: 2}
+	slots := &models.ApiEgressLimit{Provider: provider, Operation: "chat", ScopeType: ApiEgressScopeSystem, MaxConcurrent: 1}
+	for _, l := range []*models.ApiEgressLimit{rate, slots} {
+		if err := store.CreateApiEgressLimit(ctx, l); err != nil {
+			t.Fatalf("CreateApiEgressLimit: %v", err)
+		}
+	}
+	call := func(user uuid.UUID, op string) (*ApiEgressCall, bool, error) {
+		c := &ApiEgressCall{Provider: provider, Operation: op, UserID: user, LeaseID: uuid.New(), LeaseExpiresAt: time.Now().Add(time.Minute)}
+		held, err := store.AcquireApiEgressQuota(ctx, c)
+		return c, held, err
+	}
+	alice, bob := uuid.New(), uuid.New()
+	first, held, err := call(alice, "chat")
+	if err != nil || !held {
+		t.Fatalf("first acquire: held=%v err=%v", held, err)
+	}
+	var exceeded *ApiEgressLimitExceededError
+	if _, _, err := call(bob, "chat"); !errors.As(err, &exceeded) || exceeded.Kind != ApiEgressLimitKindConcurrency {
+		t.Fatalf("second concurrent acquire: %v", err)
+	}
+	if err := store.ReleaseApiEgressQuota(ctx, first.LeaseID); err != nil {
+		t.Fatalf("ReleaseApiEgressQuota: %v", err)
+	}
+	if _, held, err := call(alice, "list"); err != nil || held {
+		t.Fatalf("other operation: held=%v err=%v", held, err)
+	}
+	if _, _, err := call(alice, "list"); !errors.As(err, &exceeded) || exceeded.Kind != ApiEgressLimitKindRate ||
+		exceeded.ResetAt == nil || exceeded.RetryAfter(time.Now()) > time.Minute {
+		t.Fatalf("third call in minute: %v", err)
+	}
+	if _, _, err := call(bob, "list"); err != nil { // per-user bucket
+		t.Fatalf("other user: %v", err)
+	}
+
+	rate.RequestsPerMinute, rate.MonthlyBudgetMicroUSD, rate.CostPerCallMicroUSD = 0, 1000, 1000
+	if err := store.UpdateApiEgressLimit(ctx, rate); err != nil {
+		t.Fatalf("UpdateApiEgressLimit: %v", err)
+	}
+	carol := uuid.New()
+	if _, _, err := call(carol, "list"); err != nil {
+		t.Fatalf("within budget: %v", err)
+	}
+	if _, _, err := call(carol, "list"); !errors.As(err, &exceeded) || exceeded.Kind != ApiEgressLimitKindBudget {
+		t.Fatalf("over budget: %v", err)
+	}
+
+	limits, err := store.ListApiEgressLimits(ctx, provider)
+	if err != nil || len(limits) != 2 {
+		t.Fatalf("ListApiEgressLimits: %d %v", len(limits), err)
+	}
+	if err := store.DeleteApiEgressLimit(ctx, rate.ID); err != nil {
+		t.Fatalf("DeleteApiEgressLimit: %v", err)
+	}
+	if err := store.DeleteApiEgressLimit(ctx, rate.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("delete again: %v", err)
+	}
+	if err := store.UpdateApiEgressLimit(ctx, rate); !errors.Is(err, ErrNotFound) {
+		t.Errorf("update deleted: %v", err)
+	}
+}
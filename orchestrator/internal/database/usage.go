@@ -0,0 +1,143 @@
+// Package database: token usage accounting for quotas and GET /v1/usage.
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+// CreateTokenUsage writes one usage record; ID, OccurredAt and TotalTokens are filled in when unset.
+func (db *DB) CreateTokenUsage(ctx context.Context, rec *models.TokenUsage) error {
+	ensureAuditIDAndTime(&rec.ID, &rec.OccurredAt)
+	if rec.TotalTokens == 0 {
+		rec.TotalTokens = rec.PromptTokens + rec.CompletionTokens
+	}
+	return db.createRecord(ctx, rec, "create token usage")
+}
+
+// SumTokenUsage returns usage per model in [since, until), ordered by model.
+func (db *DB) SumTokenUsage(ctx context.Context, userID, projectID *uuid.UUID, since, until time.Time) ([]*models.TokenUsageSummary, error) {
+	q := db.db.WithContext(ctx).Model(&models.TokenUsage{}).
+		Select("model, SUM(prompt_tokens) AS prompt_tokens, SUM(completion_tokens) AS completion_tokens, SUM(total_tokens) AS total_tokens").
+		Where("occurred_at >= ? AND occurred_at < ?", since, until)
+	if userID != nil {
+		q = q.Where("user_id = ?", *userID)
+	}
+	if projectID != nil {
+		q = q.Where("project_id = ?", *projectID)
+	}
+	var out []*models.TokenUsageSummary
+	if err := q.Group("model").Order("model").Scan(&out).Error; err != nil {
+		return nil, wrapErr(err, "sum token usage")
+	}
+	return out, nil
+}
+
+// CountActiveJobs counts queued and running jobs whose task matches userID and projectID.
+func (db *DB) CountActiveJobs(ctx context.Context, userID, projectID *uuid.UUID) (int, error) {
+	n, err := countActiveJobs(db.db.WithContext(ctx), userID, projectID)
+	if err != nil {
+		return 0, wrapErr(err, "count active jobs")
+	}
+	return n, nil
+}
+
+func countActiveJobs(tx *gorm.DB, userID, projectID *uuid.UUID) (int, error) {
+	q := tx.Model(&models.Job{}).
+		Joins("JOIN tasks ON tasks.id = jobs.task_id").
+		Where("jobs.status IN ?", []string{models.JobStatusQueued, models.JobStatusRunning})
+	if userID != nil {
+		q = q.Where("tasks.created_by = ?", *userID)
+	}
+	if projectID != nil {
+		q = q.Where("tasks.project_id = ?", *projectID)
+	}
+	var n int64
+	err := q.Count(&n).Error
+	return int(n), err
+}
+
+// ActiveJobLimitError is returned by CreateQueuedJobWithinLimits when the task's creator, or its project when
+// Project is set, already has Limit queued and running jobs.
+type ActiveJobLimitError struct {
+	Project bool
+	Limit   int
+	Used    int
+}
+
+func (e *ActiveJobLimitError) Error() string {
+	scope := "user"
+	if e.Project {
+		scope = "project"
+	}
+	return fmt.Sprintf("%s active job limit reached (%d of %d)", scope, e.Used, e.Limit)
+}
+
+// CreateQueuedJobWithinLimits creates a queued job like CreateJobWithID when the task's creator has fewer than
+// userLimit and its project fewer than projectLimit queued and running jobs; 0 means unlimited.
+// The creator's user row and the project row are locked for the transaction so concurrent creations cannot
+// both take the last slot. Returns *ActiveJobLimitError when a limit is reached.
+func (db *DB) CreateQueuedJobWithinLimits(ctx context.Context, taskID, jobID uuid.UUID, payload string, userLimit, projectLimit int) (*models.Job, error) {
+	now := time.Now().UTC()
+	job := &models.Job{
+		ID:        jobID,
+		TaskID:    taskID,
+		Status:    models.JobStatusQueued,
+		Payload:   models.NewJSONBString(&payload),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err := db.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var task models.Task
+		if err := tx.Select("id", "created_by", "project_id").First(&task, "id = ?", taskID).Error; err != nil {
+			return err
+		}
+		if userLimit > 0 && task.CreatedBy != nil {
+			if err := checkActiveJobLimit(tx, &models.User{}, task.CreatedBy, nil, userLimit); err != nil {
+				return err
+			}
+		}
+		if projectLimit > 0 && task.ProjectID != nil {
+			if err := checkActiveJobLimit(tx, &models.Project{}, nil, task.ProjectID, projectLimit); err != nil {
+				return err
+			}
+		}
+		return tx.Create(job).Error
+	})
+	var limited *ActiveJobLimitError
+	if errors.As(err, &limited) {
+		return nil, limited
+	}
+	if err != nil {
+		return nil, wrapErr(err, "create queued job within limits")
+	}
+	return job, nil
+}
+
+// checkActiveJobLimit locks the user or project row (owner) and returns *ActiveJobLimitError when the
+// user's or project's queued and running jobs have reached limit.
+func checkActiveJobLimit(tx *gorm.DB, owner interface{}, userID, projectID *uuid.UUID, limit int) error {
+	id := userID
+	if projectID != nil {
+		id = projectID
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(owner, "id = ?", *id).Error; err != nil {
+		return err
+	}
+	n, err := countActiveJobs(tx, userID, projectID)
+	if err != nil {
+		return err
+	}
+	if n >= limit {
+		return &ActiveJobLimitError{Project: projectID != nil, Limit: limit, Used: n}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+// Package database: user administration (list, enable or disable, delete, set password).
+// See docs/tech_specs/local_user_accounts.md (Bootstrap and Administration).
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+// ListUsers returns all users, service accounts included, ordered by handle.
+func (db *DB) ListUsers(ctx context.Context) ([]*models.User, error) {
+	var out []*models.User
+	err := db.db.WithContext(ctx).Order("handle").Find(&out).Error
+	return out, wrapErr(err, "list users")
+}
+
+// SetUserActive enables or disables a user. Returns ErrNotFound when the user does not exist.
+func (db *DB) SetUserActive(ctx context.Context, id uuid.UUID, active bool) error {
+	res := db.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"is_active": active, "updated_at": time.Now().UTC()})
+	if res.Error != nil {
+		return wrapErr(res.Error, "set user active")
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteUser removes a user with their password, sessions, access tokens, group memberships, and role bindings.
+// Access tokens of the sessions stay on the deny-list until they expire.
+// Rows that attribute past activity to the user (tasks, audit logs) are kept. Returns ErrNotFound when the user
+// does not exist.
+func (db *DB) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	return db.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := invalidateSessionsTx(tx, "user_id = ?", id); err != nil {
+			return wrapErr(err, "revoke user sessions")
+		}
+		for _, model := range []interface{}{
+			&models.PasswordCredential{}, &models.RefreshSession{}, &models.PersonalAccessToken{}, &models.GroupMembership{},
+		} {
+			if err := tx.Where("user_id = ?", id).Delete(model).Error; err != nil {
+				return wrapErr(err, "delete user data")
+			}
+		}
+		if err := tx.Where("subject_type = ? AND subject_id = ?", SubjectTypeUser, id).Delete(&models.RoleBinding{}).Error; err != nil {
+			return wrapErr(err, "delete user role bindings")
+		}
+		res := tx.Where("id = ?", id).Delete(&models.User{})
+		if res.Error != nil {
+			return wrapErr(res.Error, "delete user")
+		}
+		if res.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+// CreateUserWithPassword creates an active user with a password credential in one transaction, so a failed
+// credential insert leaves no user without a password behind. mustChange forces a password change at the first login.
+func (db *DB) CreateUserWithPassword(ctx context.Context, handle string, email *string, passwordHash []byte, hashAlg string, mustChange bool) (*models.User, error) {
+	now := time.Now().UTC()
+	user := &models.User{ID: uuid.New(), Handle: handle, Email: email, IsActive: true, CreatedAt: now, UpdatedAt: now}
+	cred := &models.PasswordCredential{
+		ID: uuid.New(), UserID: user.ID, PasswordHash: passwordHash, HashAlg: hashAlg, MustChange: mustChange,
+		CreatedAt: now, UpdatedAt: now,
+	}
+	err := db.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return tx.Create(cred).Error
+	})
+	if err != nil {
+		return nil, wrapErr(err, "create user with password")
+	}
+	return user, nil
+}
+
+// SetPassword replaces userID's password credential, creating it when the user has none. mustChange forces a
+// password change at the next login.
+func (db *DB) SetPassword(ctx context.Context, userID uuid.UUID, passwordHash []byte, hashAlg string, mustChange bool) error {
+	now := time.Now().UTC()
+	res := db.db.WithContext(ctx).Model(&models.PasswordCredential{}).Where("user_id = ?", userID).
+		Updates(map[string]interface{}{"password_hash": passwordHash, "hash_alg": hashAlg, "must_change": mustChange, "updated_at": now})
+	if res.Error != nil {
+		return wrapErr(res.Error, "set password")
+	}
+	if res.RowsAffected > 0 {
+		return nil
+	}
+	cred := &models.PasswordCredential{
+		ID: uuid.New(), UserID: userID, PasswordHash: passwordHash, HashAlg: hashAlg, MustChange: mustChange,
+		CreatedAt: now, UpdatedAt: now,
+	}
+	return db.createRecord(ctx, cred, "create password credential")
+}
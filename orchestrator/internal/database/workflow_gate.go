@@ -9,11 +9,8 @@ import (
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
 )
 
-// Plan state for workflow gate (langgraph_mvp.md, project_plans.state).
-const planStateActive = "active"
-
 func (db *DB) workflowGateCheckPlan(ctx context.Context, task *models.Task, requestedByPMA bool) (denyReason string, err error) {
-	plan, err := db.getPlanByID(ctx, *task.PlanID)
+	plan, err := db.GetProjectPlanByID(ctx, *task.PlanID)
 	if err != nil {
 		if errors.Is(err, ErrNotFound) {
 			return "plan not found", nil
@@ -23,7 +20,7 @@ func (db *DB) workflowGateCheckPlan(ctx context.Context, task *models.Task, requ
 	if plan.Archived {
 		return "plan is archived", nil
 	}
-	if plan.State != planStateActive && !requestedByPMA {
+	if plan.State != models.PlanStateActive && !requestedByPMA {
 		return "plan not active", nil
 	}
 	return "", nil
@@ -62,10 +59,6 @@ func (db *DB) EvaluateWorkflowStartGate(ctx context.Context, task *models.Task,
 	return db.workflowGateCheckDeps(ctx, task.ID)
 }
 
-func (db *DB) getPlanByID(ctx context.Context, planID uuid.UUID) (*models.ProjectPlan, error) {
-	return getByID[models.ProjectPlan](db, ctx, planID, "get plan by id")
-}
-
 func (db *DB) listTaskDependencyIDs(ctx context.Context, taskID uuid.UUID) ([]uuid.UUID, error) {
 	var ids []uuid.UUID
 	err := db.db.WithContext(ctx).Model(&models.TaskDependency{}).
@@ -0,0 +1,201 @@
+// Package dispatcher: async Worker API execution (jobs:submit, result collection, lease reclaim).
+// See docs/tech_specs/worker_api.md Run Job (Asynchronous).
+package dispatcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+// errWorkerResultPending is returned by fetchWorkerResult when the worker has not finished the job (409) or does not know it (404).
+var errWorkerResultPending = errors.New("worker result not available")
+
+// errLeaseExpired is the recorded result of an attempt whose node stopped heartbeating before the lease expired.
+var errLeaseExpired = errors.New("job lease expired: node stopped heartbeating")
+
+// AsyncConfig configures async dispatch.
+// HeartbeatBaseURL is the control-plane base URL reachable from nodes; LeaseTTL is how far each heartbeat extends the lease.
+type AsyncConfig struct {
+	HeartbeatBaseURL string
+	LeaseTTL         time.Duration
+}
+
+// heartbeatInterval is a third of the lease TTL so two missed heartbeats still leave the lease alive.
+func (c *AsyncConfig) heartbeatInterval() int {
+	return max(1, int((c.LeaseTTL / 3).Seconds()))
+}
+
+func (c *AsyncConfig) heartbeatURL(jobID uuid.UUID) string {
+	return strings.TrimSuffix(c.HeartbeatBaseURL, "/") + "/v1/jobs/" + jobID.String() + "/heartbeat"
+}
+
+// submitAsync posts the job to the worker's jobs:submit endpoint and, on 202, shortens the claim lease to LeaseTTL
+// so a dead node is detected by missed heartbeats rather than the long synchronous claim window.
+func submitAsync(ctx context.Context, db database.Store, client *http.Client, job *models.Job, leaseID uuid.UUID,
+	workerURL, workerToken string, runReq *workerapi.RunJobRequest, async *AsyncConfig, nodeSlug string, logger *slog.Logger) error {
+	runReq.Lease = &workerapi.LeaseSpec{
+		LeaseID:                  leaseID.String(),
+		HeartbeatURL:             async.heartbeatURL(job.ID),
+		HeartbeatIntervalSeconds: async.heartbeatInterval(),
+	}
+	handle, err := submitWorkerJob(ctx, client, workerURL, workerToken, runReq)
+	if err != nil {
+		_, err = failJobAttempt(ctx, db, job, leaseID, classifyDispatchError(err), MarshalDispatchError(err), logger)
+		return err
+	}
+	_ = db.ExtendJobLease(ctx, job.ID, leaseID, time.Now().UTC().Add(async.LeaseTTL))
+	if logger != nil {
+		logger.Info("job submitted", "job_id", job.ID, "task_id", job.TaskID, "node_slug", nodeSlug, "worker_status", handle.Status)
+	}
+	return nil
+}
+
+func submitWorkerJob(ctx context.Context, client *http.Client, workerBaseURL, bearerToken string, req *workerapi.RunJobRequest) (*workerapi.SubmitJobResponse, error) {
+	var lastErr error
+	for attempt := 0; attempt < workerAPIMaxAttempts; attempt++ {
+		handle, err := submitWorkerJobOnce(ctx, client, workerBaseURL, bearerToken, req)
+		if err == nil {
+			return handle, nil
+		}
+		lastErr = err
+		if !isTransientWorkerDispatchError(err) || attempt == workerAPIMaxAttempts-1 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+	return nil, lastErr
+}
+
+func submitWorkerJobOnce(ctx context.Context, client *http.Client, workerBaseURL, bearerToken string, req *workerapi.RunJobRequest) (*workerapi.SubmitJobResponse, error) {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(workerBaseURL, "/")+"/v1/worker/jobs:submit", bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+bearerToken)
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusAccepted {
+		return nil, &workerStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	var handle workerapi.SubmitJobResponse
+	if err := json.NewDecoder(resp.Body).Decode(&handle); err != nil {
+		return nil, err
+	}
+	if handle.Version != 1 {
+		return nil, fmt.Errorf("unsupported worker response version: %d", handle.Version)
+	}
+	return &handle, nil
+}
+
+// CollectAsyncResults fetches results for running jobs from their nodes and completes the jobs that have finished.
+// Jobs the worker reports as still running (409) or unknown (404) are left for a later pass or for lease reclaim.
+func CollectAsyncResults(ctx context.Context, db database.Store, client *http.Client, logger *slog.Logger) error {
+	jobs, err := db.ListRunningJobs(ctx)
+	if err != nil {
+		return fmt.Errorf("list running jobs: %w", err)
+	}
+	for _, job := range jobs {
+		if job.LeaseID == nil {
+			continue
+		}
+		node, err := db.GetNodeByID(ctx, *job.NodeID)
+		if err != nil || node.WorkerAPITargetURL == nil || node.WorkerAPIBearerToken == nil {
+			continue
+		}
+		result, err := fetchWorkerResult(ctx, client, *node.WorkerAPITargetURL, *node.WorkerAPIBearerToken, job.ID, *job.LeaseID)
+		if err != nil {
+			if !errors.Is(err, errWorkerResultPending) && logger != nil {
+				logger.Warn("fetch async job result failed", "job_id", job.ID, "node_slug", node.NodeSlug, "error", err)
+			}
+			continue
+		}
+		applied, err := applyJobResult(ctx, db, job, *job.LeaseID, result, logger)
+		if err != nil {
+			return err
+		}
+		logJobResult(logger, job, node.NodeSlug, result.Status, applied)
+	}
+	return nil
+}
+
+// fetchWorkerResult gets the result of the run submitted under leaseID; a retried job's earlier attempt on the
+// same node is not returned.
+func fetchWorkerResult(ctx context.Context, client *http.Client, workerBaseURL, bearerToken string, jobID, leaseID uuid.UUID) (*workerapi.RunJobResponse, error) {
+	u := strings.TrimSuffix(workerBaseURL, "/") + "/v1/worker/jobs/" + url.PathEscape(jobID.String()) + "/result?lease_id=" + url.QueryEscape(leaseID.String())
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+bearerToken)
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusConflict, http.StatusNotFound:
+		return nil, errWorkerResultPending
+	default:
+		return nil, fmt.Errorf("worker api returned %s", resp.Status)
+	}
+	var result workerapi.RunJobResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.Version != 1 {
+		return nil, fmt.Errorf("unsupported worker response version: %d", result.Version)
+	}
+	return &result, nil
+}
+
+// ReclaimExpiredLeases fails the current attempt of each running job whose lease expired (node died or stopped
+// heartbeating) with FailureLeaseExpired, so the job's retry policy decides whether it is requeued with backoff,
+// dead-lettered, or failed. Returns the number of jobs reclaimed.
+func ReclaimExpiredLeases(ctx context.Context, db database.Store, logger *slog.Logger) (int, error) {
+	jobs, err := db.ListExpiredLeaseJobs(ctx, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, job := range jobs {
+		applied, err := failJobAttempt(ctx, db, job, *job.LeaseID, FailureLeaseExpired, MarshalDispatchError(errLeaseExpired), logger)
+		if err != nil {
+			return n, err
+		}
+		if !applied {
+			continue
+		}
+		n++
+		if logger != nil {
+			logger.Warn("job lease expired", "job_id", job.ID, "task_id", job.TaskID, "node_id", job.NodeID)
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,190 @@
+package dispatcher
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
+)
+
+// asyncWorker is a fake Worker API that accepts jobs:submit and serves results once done is set.
+type asyncWorker struct {
+	mu        sync.Mutex
+	submitted *workerapi.RunJobRequest
+	done      bool
+}
+
+func (aw *asyncWorker) handler(w http.ResponseWriter, r *http.Request) {
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/jobs:submit"):
+		var req workerapi.RunJobRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		aw.submitted = &req
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(workerapi.SubmitJobResponse{Version: 1, JobID: req.JobID, Status: workerapi.StatusAccepted})
+	case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/result"):
+		// Like the node, only the run submitted under the requested lease matches.
+		if aw.submitted == nil || aw.submitted.Lease == nil || r.URL.Query().Get("lease_id") != aw.submitted.Lease.LeaseID {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if !aw.done {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(workerapi.RunJobResponse{
+			Version: 1, TaskID: aw.submitted.TaskID, JobID: aw.submitted.JobID, Status: workerapi.StatusCompleted, Stdout: "ok",
+		})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestRunOnceAsync_SubmitCollect(t *testing.T) {
+	aw := &asyncWorker{}
+	server := httptest.NewServer(http.HandlerFunc(aw.handler))
+	defer server.Close()
+
+	mock := testutil.NewMockDB()
+	ctx := context.Background()
+	task, _ := mock.CreateTask(ctx, nil, "prompt", nil, nil)
+	job, _ := mock.CreateJob(ctx, task.ID, testPayload)
+	node, _ := mock.CreateNode(ctx, "node-1")
+	makeDispatchable(t, mock, ctx, node, server.URL, "token")
+
+	async := AsyncConfig{HeartbeatBaseURL: "http://cp:12082/", LeaseTTL: 30 * time.Second}
+	if err := RunOnceAsync(ctx, mock, nil, 10*time.Second, async, nil); err != nil {
+		t.Fatalf("RunOnceAsync: %v", err)
+	}
+	j, _ := mock.GetJobByID(ctx, job.ID)
+	if j.Status != models.JobStatusRunning {
+		t.Fatalf("job status %s, want running", j.Status)
+	}
+	if j.LeaseExpiresAt == nil || time.Until(*j.LeaseExpiresAt) > async.LeaseTTL {
+		t.Errorf("lease should be shortened to TTL: %v", j.LeaseExpiresAt)
+	}
+	aw.mu.Lock()
+	lease := aw.submitted.Lease
+	aw.mu.Unlock()
+	if lease == nil || lease.LeaseID != j.LeaseID.String() || lease.HeartbeatIntervalSeconds != 10 ||
+		lease.HeartbeatURL != "http://cp:12082/v1/jobs/"+job.ID.String()+"/heartbeat" {
+		t.Errorf("lease spec %+v", lease)
+	}
+
+	// Still running on the worker: job stays running.
+	if err := CollectAsyncResults(ctx, mock, &http.Client{}, nil); err != nil {
+		t.Fatalf("CollectAsyncResults: %v", err)
+	}
+	if j, _ = mock.GetJobByID(ctx, job.ID); j.Status != models.JobStatusRunning {
+		t.Fatalf("job status %s while worker pending", j.Status)
+	}
+
+	aw.mu.Lock()
+	aw.done = true
+	aw.mu.Unlock()
+	if err := CollectAsyncResults(ctx, mock, &http.Client{}, nil); err != nil {
+		t.Fatalf("CollectAsyncResults: %v", err)
+	}
+	if j, _ = mock.GetJobByID(ctx, job.ID); j.Status != models.JobStatusCompleted {
+		t.Errorf("job status %s, want completed", j.Status)
+	}
+	tk, _ := mock.GetTaskByID(ctx, task.ID)
+	if tk.Status != models.TaskStatusCompleted {
+		t.Errorf("task status %s", tk.Status)
+	}
+}
+
+func TestRunOnceAsync_SubmitRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+	mock := testutil.NewMockDB()
+	ctx := context.Background()
+	task, _ := mock.CreateTask(ctx, nil, "prompt", nil, nil)
+	job, _ := mock.CreateJob(ctx, task.ID, testPayload)
+	node, _ := mock.CreateNode(ctx, "node-1")
+	makeDispatchable(t, mock, ctx, node, server.URL, "token")
+
+	err := RunOnceAsync(ctx, mock, nil, 5*time.Second, AsyncConfig{HeartbeatBaseURL: "http://cp", LeaseTTL: time.Minute}, nil)
+	if err != nil {
+		t.Fatalf("RunOnceAsync: %v", err)
+	}
+	if j, _ := mock.GetJobByID(ctx, job.ID); j.Status != models.JobStatusFailed {
+		t.Errorf("job status %s, want failed", j.Status)
+	}
+}
+
+func TestReclaimExpiredLeases(t *testing.T) {
+	mock := testutil.NewMockDB()
+	ctx := context.Background()
+	node, _ := mock.CreateNode(ctx, "node-1")
+	// claim leases the oldest queued job, assigns it to node-1, and sets its lease expiry.
+	claim := func(exp time.Time) {
+		leaseID := uuid.New()
+		job, err := mock.ClaimNextQueuedJob(ctx, leaseID, exp)
+		if err != nil {
+			t.Fatalf("ClaimNextQueuedJob: %v", err)
+		}
+		_ = mock.AssignJobToNode(ctx, job.ID, leaseID, node.ID, "test")
+	}
+	past, future := time.Now().Add(-time.Second), time.Now().Add(time.Minute)
+	task, _ := mock.CreateTask(ctx, nil, "prompt", nil, nil)
+	expired, _ := mock.CreateJob(ctx, task.ID, testPayload)
+	claim(past)
+	live, _ := mock.CreateJob(ctx, task.ID, testPayload)
+	claim(future)
+
+	n, err := ReclaimExpiredLeases(ctx, mock, nil)
+	if err != nil || n != 1 {
+		t.Fatalf("ReclaimExpiredLeases: n=%d err=%v", n, err)
+	}
+	j, _ := mock.GetJobByID(ctx, expired.ID)
+	if j.Status != models.JobStatusQueued || j.NodeID != nil || j.LeaseID != nil || j.NextAttemptAt == nil || !j.NextAttemptAt.After(time.Now()) {
+		t.Errorf("expired job not requeued with backoff: %+v", j)
+	}
+	attempts, _ := mock.ListJobAttempts(ctx, expired.ID)
+	if len(attempts) != 1 || attempts[0].FailureClass == nil || *attempts[0].FailureClass != FailureLeaseExpired {
+		t.Errorf("attempts = %+v", attempts)
+	}
+	if j, _ = mock.GetJobByID(ctx, live.ID); j.Status != models.JobStatusRunning {
+		t.Errorf("live job status %s", j.Status)
+	}
+}
+
+func TestReclaimExpiredLeases_DeadLettersAfterLastAttempt(t *testing.T) {
+	mock := testutil.NewMockDB()
+	ctx := context.Background()
+	task, _ := mock.CreateTask(ctx, nil, "prompt", nil, nil)
+	job, _ := mock.CreateJob(ctx, task.ID, `{"command":["true"],"retry":{"max_attempts":1}}`)
+	if _, err := mock.ClaimNextQueuedJob(ctx, uuid.New(), time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("ClaimNextQueuedJob: %v", err)
+	}
+	_ = mock.UpdateJobStatus(ctx, job.ID, models.JobStatusRunning)
+
+	if n, err := ReclaimExpiredLeases(ctx, mock, nil); err != nil || n != 1 {
+		t.Fatalf("ReclaimExpiredLeases: n=%d err=%v", n, err)
+	}
+	if j, _ := mock.GetJobByID(ctx, job.ID); j.Status != models.JobStatusDeadLettered {
+		t.Errorf("job status %s, want dead_lettered", j.Status)
+	}
+	if got, _ := mock.GetTaskByID(ctx, task.ID); got.Status != models.TaskStatusFailed {
+		t.Errorf("task status %s, want failed", got.Status)
+	}
+	if n, _ := ReclaimExpiredLeases(ctx, mock, nil); n != 0 {
+		t.Errorf("dead-lettered job reclaimed again: %d", n)
+	}
+}
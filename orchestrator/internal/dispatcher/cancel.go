@@ -0,0 +1,71 @@
+// Package dispatcher: job cancellation on the assigned node (worker_api.md Cancel Job).
+package dispatcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+// errWorkerJobNotRunning is returned by cancelWorkerJob when the node has no running job with that id.
+var errWorkerJobNotRunning = errors.New("job not running on worker")
+
+// CancelRunningJob stops a job on its assigned node via the Worker API cancel endpoint and records the node's
+// canceled RunJobResponse as the job result. The caller must mark the job canceled first so the result of the
+// in-flight dispatch (or async collection) is treated as late and not applied.
+// Jobs without a node, or that the node no longer runs, are left as they are.
+func CancelRunningJob(ctx context.Context, db database.Store, client *http.Client, job *models.Job) error {
+	if job.NodeID == nil {
+		return nil
+	}
+	node, err := db.GetNodeByID(ctx, *job.NodeID)
+	if err != nil {
+		return fmt.Errorf("get node: %w", err)
+	}
+	if node.WorkerAPITargetURL == nil || node.WorkerAPIBearerToken == nil {
+		return fmt.Errorf("node %s has no worker API URL or token", node.NodeSlug)
+	}
+	result, err := cancelWorkerJob(ctx, client, *node.WorkerAPITargetURL, *node.WorkerAPIBearerToken, job.ID.String())
+	if err != nil {
+		if errors.Is(err, errWorkerJobNotRunning) {
+			return nil
+		}
+		return err
+	}
+	resultJSON, _ := json.Marshal(result)
+	return db.CompleteJob(ctx, job.ID, string(resultJSON), models.JobStatusCanceled)
+}
+
+func cancelWorkerJob(ctx context.Context, client *http.Client, workerBaseURL, bearerToken, jobID string) (*workerapi.RunJobResponse, error) {
+	u := strings.TrimSuffix(workerBaseURL, "/") + "/v1/worker/jobs/" + url.PathEscape(jobID) + "/cancel"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, u, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+bearerToken)
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusConflict:
+		return nil, errWorkerJobNotRunning
+	default:
+		return nil, fmt.Errorf("worker api returned %s", resp.Status)
+	}
+	var result workerapi.RunJobResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
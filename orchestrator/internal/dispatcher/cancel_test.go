@@ -0,0 +1,126 @@
+package dispatcher
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
+)
+
+func setupRunningJobOnNode(t *testing.T, workerURL string) (*testutil.MockDB, *models.Job, uuid.UUID) {
+	t.Helper()
+	mock := testutil.NewMockDB()
+	ctx := context.Background()
+	task, _ := mock.CreateTask(ctx, nil, "prompt", nil, nil)
+	job, _ := mock.CreateJob(ctx, task.ID, testPayload)
+	node, _ := mock.CreateNode(ctx, "node-1")
+	makeDispatchable(t, mock, ctx, node, workerURL, "token")
+	leaseID := uuid.New()
+	if _, err := mock.ClaimNextQueuedJob(ctx, leaseID, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("ClaimNextQueuedJob: %v", err)
+	}
+	_ = mock.AssignJobToNode(ctx, job.ID, leaseID, node.ID, "test")
+	_ = mock.UpdateJobStatus(ctx, job.ID, models.JobStatusRunning)
+	job, _ = mock.GetJobByID(ctx, job.ID)
+	return mock, job, leaseID
+}
+
+func TestCancelRunningJob(t *testing.T) {
+	var gotPath, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth = r.URL.Path, r.Header.Get("Authorization")
+		_ = json.NewEncoder(w).Encode(workerapi.RunJobResponse{Version: 1, Status: workerapi.StatusCanceled, ExitCode: -1, Stdout: "partial"})
+	}))
+	defer server.Close()
+	mock, job, _ := setupRunningJobOnNode(t, server.URL)
+	ctx := context.Background()
+	_ = mock.UpdateJobStatus(ctx, job.ID, models.JobStatusCanceled)
+
+	if err := CancelRunningJob(ctx, mock, server.Client(), job); err != nil {
+		t.Fatalf("CancelRunningJob: %v", err)
+	}
+	if gotPath != "/v1/worker/jobs/"+job.ID.String()+"/cancel" || gotAuth != "Bearer token" {
+		t.Errorf("worker call path=%q auth=%q", gotPath, gotAuth)
+	}
+	got, _ := mock.GetJobByID(ctx, job.ID)
+	if got.Status != models.JobStatusCanceled || got.Result.Ptr() == nil || !strings.Contains(*got.Result.Ptr(), `"canceled"`) {
+		t.Errorf("job after cancel: status=%s result=%v", got.Status, got.Result.Ptr())
+	}
+}
+
+func TestCancelRunningJob_NotRunningOnWorker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	mock, job, _ := setupRunningJobOnNode(t, server.URL)
+	if err := CancelRunningJob(context.Background(), mock, server.Client(), job); err != nil {
+		t.Errorf("404 from worker should be ignored: %v", err)
+	}
+	if err := CancelRunningJob(context.Background(), mock, server.Client(), &models.Job{ID: uuid.New()}); err != nil {
+		t.Errorf("job without node: %v", err)
+	}
+}
+
+func TestCancelRunningJob_WorkerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	mock, job, _ := setupRunningJobOnNode(t, server.URL)
+	if err := CancelRunningJob(context.Background(), mock, server.Client(), job); err == nil {
+		t.Error("expected error on worker 500")
+	}
+}
+
+func TestApplyJobResult_LateResultIgnored(t *testing.T) {
+	mock, job, leaseID := setupRunningJobOnNode(t, "http://unused")
+	ctx := context.Background()
+	_ = mock.UpdateJobStatus(ctx, job.ID, models.JobStatusCanceled)
+	_ = mock.UpdateTaskStatus(ctx, job.TaskID, models.TaskStatusCanceled)
+
+	applied, err := applyJobResult(ctx, mock, job, leaseID, &workerapi.RunJobResponse{Version: 1, Status: workerapi.StatusCompleted}, nil)
+	if err != nil || applied {
+		t.Fatalf("applyJobResult: applied=%v err=%v", applied, err)
+	}
+	got, _ := mock.GetJobByID(ctx, job.ID)
+	if got.Status != models.JobStatusCanceled || got.Result.Ptr() != nil {
+		t.Errorf("late result overwrote canceled job: status=%s", got.Status)
+	}
+}
+
+func TestApplyJobResult_CanceledStatus(t *testing.T) {
+	mock, job, leaseID := setupRunningJobOnNode(t, "http://unused")
+	ctx := context.Background()
+	applied, err := applyJobResult(ctx, mock, job, leaseID, &workerapi.RunJobResponse{Version: 1, Status: workerapi.StatusCanceled}, nil)
+	if err != nil || !applied {
+		t.Fatalf("applyJobResult: applied=%v err=%v", applied, err)
+	}
+	got, _ := mock.GetJobByID(ctx, job.ID)
+	task, _ := mock.GetTaskByID(ctx, job.TaskID)
+	if got.Status != models.JobStatusCanceled || task.Status != models.TaskStatusCanceled {
+		t.Errorf("job=%s task=%s, want canceled", got.Status, task.Status)
+	}
+}
+
+func TestApplyJobResult_RedactsSecrets(t *testing.T) {
+	mock, job, leaseID := setupRunningJobOnNode(t, "http://unused")
+	ctx := context.Background()
+	result := &workerapi.RunJobResponse{Version: 1, Status: workerapi.StatusCompleted, Stdout: "DATABASE_URL=postgres://app:hunter2@db/app\n"}
+	if applied, err := applyJobResult(ctx, mock, job, leaseID, result, nil); err != nil || !applied {
+		t.Fatalf("applyJobResult: applied=%v err=%v", applied, err)
+	}
+	got, _ := mock.GetJobByID(ctx, job.ID)
+	if stored := *got.Result.Ptr(); strings.Contains(stored, "hunter2") || !strings.Contains(stored, "app:SECRET_REDACTED@db") {
+		t.Errorf("stored result = %s", stored)
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/sbajob"
 	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/webegress"
 )
 
 // DefaultSBARunnerImage is the image used for SBA jobs when the payload does not set image (P2-10).
@@ -39,18 +41,47 @@ func ParseSandboxSpec(payload *string) (workerapi.SandboxSpec, error) {
 	if spec.JobSpecJSON != "" && image == "" {
 		image = DefaultSBARunnerImage
 	}
+	networkPolicy := spec.NetworkPolicy
+	if networkPolicy == "" && sbaExtNetAllowed(spec.JobSpecJSON) {
+		networkPolicy = workerapi.NetworkPolicyEgressProxy
+	}
 
 	return workerapi.SandboxSpec{
 		Image:          image,
 		Command:        spec.Command,
 		Env:            spec.Env,
 		TimeoutSeconds: spec.TimeoutSeconds,
-		NetworkPolicy:  spec.NetworkPolicy,
+		NetworkPolicy:  networkPolicy,
 		UseInference:   spec.UseInference,
 		JobSpecJSON:    spec.JobSpecJSON,
 	}, nil
 }
 
+// sbaExtNetAllowed reports whether an SBA job spec allows external network access (constraints.ext_net_allowed).
+// Such jobs default to network policy egress-proxy, so their web access goes through the Web Egress Proxy.
+func sbaExtNetAllowed(jobSpecJSON string) bool {
+	if jobSpecJSON == "" {
+		return false
+	}
+	var spec struct {
+		Constraints sbajob.JobConstraints `json:"constraints"`
+	}
+	return json.Unmarshal([]byte(jobSpecJSON), &spec) == nil && spec.Constraints.ExtNetAllowed
+}
+
+// ApplyEgressProxy issues the job's Web Egress Proxy credential when sandbox uses network policy egress-proxy.
+// It fails when the control-plane has no token secret, since the proxy would refuse every request.
+func ApplyEgressProxy(sandbox *workerapi.SandboxSpec, jobID string, tokenSecret []byte) error {
+	if sandbox.NetworkPolicy != workerapi.NetworkPolicyEgressProxy {
+		return nil
+	}
+	if len(tokenSecret) == 0 {
+		return errors.New("network_policy egress-proxy requires the web egress proxy (WEB_EGRESS_TOKEN_SECRET is not set)")
+	}
+	sandbox.EgressProxyToken = webegress.JobToken(tokenSecret, jobID)
+	return nil
+}
+
 // MarshalDispatchError returns a JSON string for a failed job result.
 func MarshalDispatchError(err error) string {
 	obj := map[string]any{
@@ -2,9 +2,11 @@ package dispatcher
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/webegress"
 )
 
 func TestParseSandboxSpec(t *testing.T) {
@@ -54,6 +56,38 @@ func TestParseSandboxSpec(t *testing.T) {
 	}
 }
 
+func TestParseSandboxSpec_ExtNetAllowedDefaultsToEgressProxy(t *testing.T) {
+	const extNet = `{"job_spec_json":"{\"protocol_version\":\"1.0\",\"job_id\":\"j1\",\"task_id\":\"t1\",\"constraints\":{\"max_runtime_seconds\":60,\"max_output_bytes\":1024,\"ext_net_allowed\":true}}"%s}`
+	spec, err := ParseSandboxSpec(strPtr(fmt.Sprintf(extNet, "")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.NetworkPolicy != workerapi.NetworkPolicyEgressProxy {
+		t.Errorf("ext_net_allowed: network_policy = %q", spec.NetworkPolicy)
+	}
+	spec, err = ParseSandboxSpec(strPtr(fmt.Sprintf(extNet, `,"network_policy":"none"`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.NetworkPolicy != workerapi.NetworkPolicyNone {
+		t.Errorf("explicit network_policy overridden: %q", spec.NetworkPolicy)
+	}
+}
+
+func TestApplyEgressProxy(t *testing.T) {
+	plain := workerapi.SandboxSpec{NetworkPolicy: workerapi.NetworkPolicyNone}
+	if err := ApplyEgressProxy(&plain, "j1", nil); err != nil || plain.EgressProxyToken != "" {
+		t.Errorf("none: err=%v token=%q", err, plain.EgressProxyToken)
+	}
+	egress := workerapi.SandboxSpec{NetworkPolicy: workerapi.NetworkPolicyEgressProxy}
+	if err := ApplyEgressProxy(&egress, "j1", nil); err == nil {
+		t.Error("egress-proxy without secret: expected error")
+	}
+	if err := ApplyEgressProxy(&egress, "j1", []byte("secret")); err != nil || !webegress.VerifyJobToken([]byte("secret"), "j1", egress.EgressProxyToken) {
+		t.Errorf("egress-proxy: err=%v token=%q", err, egress.EgressProxyToken)
+	}
+}
+
 func TestMarshalDispatchError(t *testing.T) {
 	s := MarshalDispatchError(errors.New("test err"))
 	if s == "" {
@@ -0,0 +1,200 @@
+// Package dispatcher: job retry policy, failure classification, attempt history, and dead-lettering.
+// See docs/tech_specs/orchestrator.md Task Scheduler (retries) and postgres_schema.md job_attempts.
+package dispatcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+// Failure classes for RetryPolicy.RetryOn and job_attempts.failure_class.
+const (
+	// FailureNodeUnreachable: the Worker API could not be reached or returned 5xx.
+	FailureNodeUnreachable = "node_unreachable"
+	// FailureDispatchRejected: the Worker API rejected the request (4xx) or returned an unusable response.
+	FailureDispatchRejected = "dispatch_rejected"
+	// FailureTimeout: the job ran past its timeout on the node.
+	FailureTimeout = "timeout"
+	// FailureNonzeroExit: the job ran and failed (non-zero exit or sandbox error).
+	FailureNonzeroExit = "nonzero_exit"
+	// FailureLeaseExpired: the node stopped heartbeating and lease reclaim ended the attempt.
+	FailureLeaseExpired = "lease_expired"
+)
+
+// RetryPolicy is the per-job retry configuration from the job payload's "retry" object.
+// Attempt n (1-based) that fails with a class in RetryOn is retried after
+// BackoffSeconds*2^(n-1) seconds, capped at MaxBackoffSeconds, until MaxAttempts is reached.
+type RetryPolicy struct {
+	MaxAttempts       int      `json:"max_attempts"`
+	BackoffSeconds    int      `json:"backoff_seconds"`
+	MaxBackoffSeconds int      `json:"max_backoff_seconds"`
+	RetryOn           []string `json:"retry_on"`
+}
+
+// DefaultRetryPolicy retries node_unreachable and lease_expired failures, three attempts in total.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:       3,
+		BackoffSeconds:    10,
+		MaxBackoffSeconds: 300,
+		RetryOn:           []string{FailureNodeUnreachable, FailureLeaseExpired},
+	}
+}
+
+// ParseRetryPolicy reads payload.retry; unset or invalid fields take DefaultRetryPolicy values.
+// retry_on set to an empty list disables retries for every class.
+func ParseRetryPolicy(payload *string) RetryPolicy {
+	policy := DefaultRetryPolicy()
+	if payload == nil || *payload == "" {
+		return policy
+	}
+	var spec struct {
+		Retry *struct {
+			MaxAttempts       *int     `json:"max_attempts"`
+			BackoffSeconds    *int     `json:"backoff_seconds"`
+			MaxBackoffSeconds *int     `json:"max_backoff_seconds"`
+			RetryOn           []string `json:"retry_on"`
+		} `json:"retry"`
+	}
+	if json.Unmarshal([]byte(*payload), &spec) != nil || spec.Retry == nil {
+		return policy
+	}
+	r := spec.Retry
+	if r.MaxAttempts != nil && *r.MaxAttempts >= 1 {
+		policy.MaxAttempts = *r.MaxAttempts
+	}
+	if r.BackoffSeconds != nil && *r.BackoffSeconds >= 0 {
+		policy.BackoffSeconds = *r.BackoffSeconds
+	}
+	if r.MaxBackoffSeconds != nil && *r.MaxBackoffSeconds >= 0 {
+		policy.MaxBackoffSeconds = *r.MaxBackoffSeconds
+	}
+	if r.RetryOn != nil {
+		policy.RetryOn = r.RetryOn
+	}
+	return policy
+}
+
+// Retryable reports whether failures of class are retried under the policy.
+func (p RetryPolicy) Retryable(class string) bool {
+	return slices.Contains(p.RetryOn, class)
+}
+
+// Backoff returns the delay before the attempt following failed attempt n (1-based).
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	d := time.Duration(p.BackoffSeconds) * time.Second
+	maxD := time.Duration(p.MaxBackoffSeconds) * time.Second
+	for i := 1; i < attempt && d < maxD; i++ {
+		d *= 2
+	}
+	return min(d, maxD)
+}
+
+// workerStatusError is a non-success HTTP status from the Worker API.
+type workerStatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *workerStatusError) Error() string {
+	return "worker api returned " + e.Status
+}
+
+// classifyDispatchError maps a Worker API call error to a failure class.
+func classifyDispatchError(err error) string {
+	var statusErr *workerStatusError
+	if errors.As(err, &statusErr) {
+		if statusErr.StatusCode >= http.StatusInternalServerError {
+			return FailureNodeUnreachable
+		}
+		return FailureDispatchRejected
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || isTransientWorkerDispatchError(err) {
+		return FailureNodeUnreachable
+	}
+	return FailureDispatchRejected
+}
+
+// classifyResult returns the failure class of a Worker API result, or "" when the job completed or was canceled.
+func classifyResult(result *workerapi.RunJobResponse) string {
+	switch result.Status {
+	case workerapi.StatusCompleted, workerapi.StatusCanceled:
+		return ""
+	case workerapi.StatusTimeout:
+		return FailureTimeout
+	default:
+		return FailureNonzeroExit
+	}
+}
+
+// snapshotAttempt captures the attempt that is ending from the job as it was dispatched; the store may clear
+// the job's node and start time when it is requeued, so the snapshot is taken before any update.
+func snapshotAttempt(job *models.Job) *models.JobAttempt {
+	return &models.JobAttempt{JobID: job.ID, Attempt: job.Attempts, NodeID: job.NodeID, StartedAt: job.StartedAt}
+}
+
+// recordAttempt appends the attempt to job_attempts with its outcome. History is best-effort.
+func recordAttempt(ctx context.Context, db database.Store, a *models.JobAttempt, status, failureClass, result string) {
+	a.Status = status
+	a.EndedAt = time.Now().UTC()
+	if failureClass != "" {
+		a.FailureClass = &failureClass
+	}
+	if result != "" {
+		a.Result = models.NewJSONBString(&result)
+	}
+	_ = db.CreateJobAttempt(ctx, a)
+}
+
+// failJobAttempt handles a failed attempt of a job running under leaseID. A retryable failure is requeued
+// with backoff while attempts remain and dead-lettered once the policy is exhausted; any other failure fails
+// the job and its task. applied is false when the job was no longer running under leaseID (canceled or reclaimed).
+func failJobAttempt(ctx context.Context, db database.Store, job *models.Job, leaseID uuid.UUID, class, result string, logger *slog.Logger) (applied bool, err error) {
+	policy := ParseRetryPolicy(job.Payload.Ptr())
+	attempt := snapshotAttempt(job)
+	if policy.Retryable(class) && job.Attempts < policy.MaxAttempts {
+		next := time.Now().UTC().Add(policy.Backoff(job.Attempts))
+		if err := db.RequeueJobForRetry(ctx, job.ID, leaseID, result, next); err != nil {
+			if errors.Is(err, database.ErrLeaseHeld) {
+				return false, nil
+			}
+			return false, fmt.Errorf("requeue job for retry: %w", err)
+		}
+		recordAttempt(ctx, db, attempt, models.JobStatusFailed, class, result)
+		if logger != nil {
+			logger.Warn("job attempt failed; retry scheduled", "job_id", job.ID, "attempt", job.Attempts,
+				"max_attempts", policy.MaxAttempts, "failure_class", class, "next_attempt_at", next)
+		}
+		return true, nil
+	}
+	status := models.JobStatusFailed
+	if policy.Retryable(class) {
+		status = models.JobStatusDeadLettered
+	}
+	if err := db.CompleteLeasedJob(ctx, job.ID, leaseID, result, status); err != nil {
+		if errors.Is(err, database.ErrLeaseHeld) {
+			return false, nil
+		}
+		return false, fmt.Errorf("complete job: %w", err)
+	}
+	recordAttempt(ctx, db, attempt, status, class, result)
+	_ = db.UpdateTaskStatus(ctx, job.TaskID, models.TaskStatusFailed)
+	if status == models.JobStatusDeadLettered && logger != nil {
+		logger.Warn("job dead-lettered", "job_id", job.ID, "task_id", job.TaskID, "attempts", job.Attempts, "failure_class", class)
+	}
+	return true, nil
+}
@@ -0,0 +1,124 @@
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
+)
+
+func TestParseRetryPolicy(t *testing.T) {
+	def := DefaultRetryPolicy()
+	for _, payload := range []*string{nil, strPtr(""), strPtr("not json"), strPtr(testPayload)} {
+		if got := ParseRetryPolicy(payload); got.MaxAttempts != def.MaxAttempts || !got.Retryable(FailureNodeUnreachable) || got.Retryable(FailureNonzeroExit) {
+			t.Errorf("ParseRetryPolicy(%v) = %+v, want default", payload, got)
+		}
+	}
+	p := ParseRetryPolicy(strPtr(`{"command":["x"],"retry":{"max_attempts":5,"backoff_seconds":1,"retry_on":["nonzero_exit","timeout"]}}`))
+	if p.MaxAttempts != 5 || p.BackoffSeconds != 1 || p.MaxBackoffSeconds != def.MaxBackoffSeconds {
+		t.Errorf("policy = %+v", p)
+	}
+	if !p.Retryable(FailureNonzeroExit) || !p.Retryable(FailureTimeout) || p.Retryable(FailureNodeUnreachable) {
+		t.Errorf("retry_on = %v", p.RetryOn)
+	}
+	if p := ParseRetryPolicy(strPtr(`{"retry":{"max_attempts":0,"retry_on":[]}}`)); p.MaxAttempts != def.MaxAttempts || p.Retryable(FailureNodeUnreachable) {
+		t.Errorf("invalid max_attempts / empty retry_on: %+v", p)
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{BackoffSeconds: 10, MaxBackoffSeconds: 60}
+	for attempt, want := range map[int]time.Duration{1: 10 * time.Second, 2: 20 * time.Second, 3: 40 * time.Second, 4: time.Minute, 10: time.Minute} {
+		if got := p.Backoff(attempt); got != want {
+			t.Errorf("Backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+func TestClassifyDispatchError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{&workerStatusError{StatusCode: 503, Status: "503 Service Unavailable"}, FailureNodeUnreachable},
+		{fmt.Errorf("wrapped: %w", &workerStatusError{StatusCode: 400, Status: "400 Bad Request"}), FailureDispatchRejected},
+		{errors.New("connection refused"), FailureNodeUnreachable},
+		{errors.New("unsupported worker response version: 2"), FailureDispatchRejected},
+	}
+	for _, tt := range tests {
+		if got := classifyDispatchError(tt.err); got != tt.want {
+			t.Errorf("classifyDispatchError(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRunOnce_RetryThenDeadLetter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	mock := testutil.NewMockDB()
+	ctx := context.Background()
+	task, _ := mock.CreateTask(ctx, nil, "p", nil, nil)
+	job, _ := mock.CreateJob(ctx, task.ID, `{"command":["echo"],"retry":{"max_attempts":2,"backoff_seconds":0}}`)
+	node, _ := mock.CreateNode(ctx, "n1")
+	makeDispatchable(t, mock, ctx, node, server.URL, "token")
+
+	for i := 0; i < 2; i++ {
+		if err := RunOnce(ctx, mock, server.Client(), 5*time.Second, nil); err != nil {
+			t.Fatalf("RunOnce %d: %v", i+1, err)
+		}
+	}
+	j, _ := mock.GetJobByID(ctx, job.ID)
+	tk, _ := mock.GetTaskByID(ctx, task.ID)
+	if j.Status != models.JobStatusDeadLettered || j.Attempts != 2 || tk.Status != models.TaskStatusFailed {
+		t.Fatalf("job=%s attempts=%d task=%s", j.Status, j.Attempts, tk.Status)
+	}
+	attempts, _ := mock.ListJobAttempts(ctx, job.ID)
+	if len(attempts) != 2 || attempts[0].Attempt != 1 || attempts[1].Status != models.JobStatusDeadLettered ||
+		attempts[0].NodeID == nil || *attempts[0].FailureClass != FailureNodeUnreachable {
+		t.Errorf("attempts = %+v", attempts)
+	}
+}
+
+func TestApplyJobResult_NonzeroExitNotRetriedByDefault(t *testing.T) {
+	mock, job, leaseID := setupRunningJobOnNode(t, "http://unused")
+	ctx := context.Background()
+	applied, err := applyJobResult(ctx, mock, job, leaseID, &workerapi.RunJobResponse{Version: 1, Status: workerapi.StatusFailed, ExitCode: 2}, nil)
+	if err != nil || !applied {
+		t.Fatalf("applyJobResult: applied=%v err=%v", applied, err)
+	}
+	got, _ := mock.GetJobByID(ctx, job.ID)
+	if got.Status != models.JobStatusFailed {
+		t.Errorf("job status %s, want failed", got.Status)
+	}
+	attempts, _ := mock.ListJobAttempts(ctx, job.ID)
+	if len(attempts) != 1 || *attempts[0].FailureClass != FailureNonzeroExit {
+		t.Errorf("attempts = %+v", attempts)
+	}
+}
+
+func TestApplyJobResult_TimeoutRetriedWhenConfigured(t *testing.T) {
+	mock, job, leaseID := setupRunningJobOnNode(t, "http://unused")
+	ctx := context.Background()
+	payload := `{"command":["echo"],"retry":{"retry_on":["timeout"]}}`
+	job.Payload = models.NewJSONBString(&payload)
+	applied, err := applyJobResult(ctx, mock, job, leaseID, &workerapi.RunJobResponse{Version: 1, Status: workerapi.StatusTimeout}, nil)
+	if err != nil || !applied {
+		t.Fatalf("applyJobResult: applied=%v err=%v", applied, err)
+	}
+	got, _ := mock.GetJobByID(ctx, job.ID)
+	if got.Status != models.JobStatusQueued || got.NextAttemptAt == nil || !got.NextAttemptAt.After(time.Now()) {
+		t.Errorf("job status %s next_attempt_at %v", got.Status, got.NextAttemptAt)
+	}
+	if _, err := mock.ClaimNextQueuedJob(ctx, leaseID, time.Now().Add(time.Minute)); err == nil {
+		t.Error("job claimable before next_attempt_at")
+	}
+}
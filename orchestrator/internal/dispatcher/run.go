@@ -1,4 +1,4 @@
-// Package dispatcher: RunOnce runs a single dispatch iteration (get next job, call worker, complete job).
+// Package dispatcher: RunOnce runs a single dispatch iteration (get next job, schedule a node, call worker, complete job).
 // Used by the control-plane loop and by BDD tests to trigger dispatch without a background ticker.
 package dispatcher
 
@@ -6,6 +6,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -13,34 +14,149 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
+
 	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+	"github.com/cypher0n3/cynodeai/go_shared_libs/redact"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/inference"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/usage"
 )
 
-// RunOnce performs one dispatch iteration: get next queued job, pick a dispatchable node, set job to running, call Worker API, complete job.
+// workerAPIMaxAttempts bounds Worker API run attempts on transient errors.
+const workerAPIMaxAttempts = 3
+
+// claimLeaseGrace is added to the worst-case Worker API call time when sizing a job claim lease.
+const claimLeaseGrace = time.Minute
+
+// claimLeaseDuration returns how long a dispatcher holds a claimed job's lease: long enough for every
+// Worker API attempt to time out, so another dispatcher never reclaims a job that is still in flight.
+func claimLeaseDuration(httpTimeout time.Duration) time.Duration {
+	return workerAPIMaxAttempts*httpTimeout + claimLeaseGrace
+}
+
+// RunOnce performs one dispatch iteration: claim next queued job, schedule it onto the best dispatchable node, set job to running, call Worker API, complete job.
 // Returns nil on success, database.ErrNotFound when no queued job, or another error.
 func RunOnce(ctx context.Context, db database.Store, client *http.Client, httpTimeout time.Duration, logger *slog.Logger) error {
+	return RunOnceWithOptions(ctx, db, client, httpTimeout, Options{}, logger)
+}
+
+// RunOnceAsync is RunOnce for async Worker API execution: the job is submitted with jobs:submit and left running
+// under a heartbeat-extended lease; CollectAsyncResults completes it and ReclaimExpiredLeases fails the attempt if the node dies.
+func RunOnceAsync(ctx context.Context, db database.Store, client *http.Client, httpTimeout time.Duration, async AsyncConfig, logger *slog.Logger) error {
+	return RunOnceWithOptions(ctx, db, client, httpTimeout, Options{Async: &async}, logger)
+}
+
+// Options configures a dispatch iteration.
+type Options struct {
+	// Async, when set, submits jobs with jobs:submit (see RunOnceAsync).
+	Async *AsyncConfig
+	// ArtifactBaseURL, when set, is the control-plane URL nodes upload job artifacts to
+	// (PUT {ArtifactBaseURL}/v1/jobs/{id}/artifacts/{path}).
+	ArtifactBaseURL string
+	// WebEgressTokenSecret signs the proxy credentials of jobs with network policy egress-proxy; it must match
+	// the Web Egress Proxy's WEB_EGRESS_TOKEN_SECRET. Without it such jobs fail.
+	WebEgressTokenSecret []byte
+	// WorkerToken, when set, returns the bearer token for the Worker API call to the selected node in place
+	// of the node's static token: a short-lived orchestrator-signed token the worker verifies against the
+	// orchestrator JWKS, so the call carries no long-lived shared secret.
+	WorkerToken func(node *models.Node) (string, error)
+}
+
+// maxUnschedulableSkips bounds how many queued jobs one dispatch iteration passes over because no node can run them.
+const maxUnschedulableSkips = 16
+
+// claimReleaseTimeout bounds releasing the claims of skipped jobs when an iteration ends.
+const claimReleaseTimeout = 5 * time.Second
+
+// RunOnceWithOptions is RunOnce with optional async submission and artifact upload.
+// A job no dispatchable node can run (ErrNoEligibleNode) does not block the queue: it is skipped, left queued,
+// and the next claimable job is tried. When every claimable job was skipped, the last scheduling error is returned.
+func RunOnceWithOptions(ctx context.Context, db database.Store, client *http.Client, httpTimeout time.Duration, opts Options, logger *slog.Logger) error {
 	if client == nil {
 		client = &http.Client{Timeout: httpTimeout}
 	}
-	job, err := db.GetNextQueuedJob(ctx)
+	leaseID := uuid.New()
+	leaseExpiresAt := time.Now().UTC().Add(claimLeaseDuration(httpTimeout))
+	// Skipped jobs keep their claim until the iteration ends so the next claim moves past them.
+	var skipped []uuid.UUID
+	defer func() { releaseSkippedClaims(ctx, db, skipped, leaseID, logger) }()
+	var schedErr error
+	for {
+		job, err := db.ClaimNextQueuedJob(ctx, leaseID, leaseExpiresAt)
+		if err != nil {
+			if errors.Is(err, database.ErrNotFound) && schedErr != nil {
+				return schedErr
+			}
+			return err
+		}
+		err = dispatchClaimedJob(ctx, db, client, job, leaseID, opts, logger)
+		if !errors.Is(err, ErrNoEligibleNode) {
+			return err
+		}
+		skipped = append(skipped, job.ID)
+		if len(skipped) >= maxUnschedulableSkips {
+			return err
+		}
+		schedErr = err
+		if logger != nil {
+			logger.Info("job not schedulable, trying next queued job", "job_id", job.ID, "error", err)
+		}
+	}
+}
+
+// releaseSkippedClaims releases the claims of jobs skipped under leaseID. It runs even when ctx is done
+// (shutdown, canceled iteration) so the jobs do not stay leased until the lease expires.
+func releaseSkippedClaims(ctx context.Context, db database.Store, skipped []uuid.UUID, leaseID uuid.UUID, logger *slog.Logger) {
+	if len(skipped) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), claimReleaseTimeout)
+	defer cancel()
+	for _, id := range skipped {
+		if err := db.ReleaseJobClaim(ctx, id, leaseID); err != nil && logger != nil {
+			logger.Warn("release skipped job claim failed", "job_id", id, "error", err)
+		}
+	}
+}
+
+// dispatchClaimedJob schedules a job claimed under leaseID onto a node and runs it there.
+// On ErrNoEligibleNode the claim is kept; the caller releases it.
+func dispatchClaimedJob(ctx context.Context, db database.Store, client *http.Client, job *models.Job, leaseID uuid.UUID, opts Options, logger *slog.Logger) error {
+	async := opts.Async
+	sandbox, err := ParseSandboxSpec(job.Payload.Ptr())
+	if err == nil {
+		err = ApplyEgressProxy(&sandbox, job.ID.String(), opts.WebEgressTokenSecret)
+	}
 	if err != nil {
-		return err
+		markJobAndTaskFailed(ctx, db, job, MarshalDispatchError(err))
+		return nil
 	}
-	node, workerURL, workerToken, err := pickNodeAndCredentials(ctx, db)
+	sel, workerURL, workerToken, err := pickNodeAndCredentials(ctx, db, RequirementsFromSandbox(&sandbox), opts.WorkerToken)
 	if err != nil {
+		if !errors.Is(err, ErrNoEligibleNode) {
+			_ = db.ReleaseJobClaim(ctx, job.ID, leaseID)
+		}
 		return err
 	}
-	if err := db.AssignJobToNode(ctx, job.ID, node.ID); err != nil {
+	node := sel.Node
+	if err := db.AssignJobToNode(ctx, job.ID, leaseID, node.ID, sel.Reason); err != nil {
+		_ = db.ReleaseJobClaim(ctx, job.ID, leaseID)
+		if errors.Is(err, database.ErrLeaseHeld) || errors.Is(err, database.ErrNotFound) {
+			// Canceled (or deleted) after it was claimed: it must not reach the node.
+			if logger != nil {
+				logger.Info("job canceled before dispatch", "job_id", job.ID, "task_id", job.TaskID)
+			}
+			return nil
+		}
 		return fmt.Errorf("assign job to node: %w", err)
 	}
-	_ = db.UpdateJobStatus(ctx, job.ID, models.JobStatusRunning)
 	_ = db.UpdateTaskStatus(ctx, job.TaskID, models.TaskStatusRunning)
-	sandbox, err := ParseSandboxSpec(job.Payload.Ptr())
-	if err != nil {
-		markJobAndTaskFailed(ctx, db, job, MarshalDispatchError(err))
-		return nil
+	startedAt := time.Now().UTC()
+	job.NodeID, job.StartedAt = &node.ID, &startedAt
+	if logger != nil {
+		logger.Info("job scheduled", "job_id", job.ID, "node_slug", node.NodeSlug, "reason", sel.Reason)
 	}
 	runReq := workerapi.RunJobRequest{
 		Version: 1,
@@ -48,21 +164,42 @@ func RunOnce(ctx context.Context, db database.Store, client *http.Client, httpTi
 		JobID:   job.ID.String(),
 		Sandbox: sandbox,
 	}
+	if opts.ArtifactBaseURL != "" {
+		runReq.Artifacts = &workerapi.ArtifactUploadSpec{
+			UploadURL: strings.TrimSuffix(opts.ArtifactBaseURL, "/") + "/v1/jobs/" + job.ID.String() + "/artifacts",
+		}
+	}
+	if async != nil {
+		return submitAsync(ctx, db, client, job, leaseID, workerURL, workerToken, &runReq, async, node.NodeSlug, logger)
+	}
 	result, err := callWorkerAPI(ctx, client, workerURL, workerToken, &runReq)
 	if err != nil {
-		markJobAndTaskFailed(ctx, db, job, MarshalDispatchError(err))
-		return nil
-	}
-	if err := applyJobResult(ctx, db, job, result); err != nil {
+		_, err = failJobAttempt(ctx, db, job, leaseID, classifyDispatchError(err), MarshalDispatchError(err), logger)
 		return err
 	}
-	if logger != nil {
-		logger.Info("job dispatched", "job_id", job.ID, "task_id", job.TaskID, "node_slug", node.NodeSlug, "result_status", result.Status)
+	applied, err := applyJobResult(ctx, db, job, leaseID, result, logger)
+	if err != nil {
+		return err
 	}
+	logJobResult(logger, job, node.NodeSlug, result.Status, applied)
 	return nil
 }
 
-func pickNodeAndCredentials(ctx context.Context, db database.Store) (node *models.Node, workerURL, workerToken string, err error) {
+func logJobResult(logger *slog.Logger, job *models.Job, nodeSlug, resultStatus string, applied bool) {
+	if logger == nil {
+		return
+	}
+	if !applied {
+		logger.Info("late job result ignored", "job_id", job.ID, "task_id", job.TaskID, "node_slug", nodeSlug, "result_status", resultStatus)
+		return
+	}
+	logger.Info("job dispatched", "job_id", job.ID, "task_id", job.TaskID, "node_slug", nodeSlug, "result_status", resultStatus)
+}
+
+// pickNodeAndCredentials gathers scheduler inputs for every dispatchable node (latest capability snapshot,
+// running job count, sandbox image availability), selects the best node for req, and returns its Worker API credentials.
+// When signToken is set, the returned token is the one it signs for the node rather than the node's static token.
+func pickNodeAndCredentials(ctx context.Context, db database.Store, req JobRequirements, signToken func(*models.Node) (string, error)) (sel *Selection, workerURL, workerToken string, err error) {
 	nodes, err := db.ListDispatchableNodes(ctx)
 	if err != nil {
 		return nil, "", "", fmt.Errorf("list dispatchable nodes: %w", err)
@@ -70,17 +207,52 @@ func pickNodeAndCredentials(ctx context.Context, db database.Store) (node *model
 	if len(nodes) == 0 {
 		return nil, "", "", fmt.Errorf("no dispatchable nodes (active with config ack and worker API URL/token)")
 	}
-	node = nodes[0]
-	if node.WorkerAPITargetURL != nil {
-		workerURL = *node.WorkerAPITargetURL
+	candidates, err := buildNodeCandidates(ctx, db, nodes, req.Image)
+	if err != nil {
+		return nil, "", "", err
+	}
+	sel, err = SelectNode(req, candidates)
+	if err != nil {
+		return nil, "", "", err
 	}
-	if node.WorkerAPIBearerToken != nil {
-		workerToken = *node.WorkerAPIBearerToken
+	if sel.Node.WorkerAPITargetURL != nil {
+		workerURL = *sel.Node.WorkerAPITargetURL
+	}
+	if sel.Node.WorkerAPIBearerToken != nil {
+		workerToken = *sel.Node.WorkerAPIBearerToken
 	}
 	if workerURL == "" || workerToken == "" {
-		return nil, "", "", fmt.Errorf("node %s has no worker API URL or token", node.NodeSlug)
+		return nil, "", "", fmt.Errorf("node %s has no worker API URL or token", sel.Node.NodeSlug)
+	}
+	if signToken != nil {
+		if workerToken, err = signToken(sel.Node); err != nil {
+			return nil, "", "", fmt.Errorf("sign worker token for node %s: %w", sel.Node.NodeSlug, err)
+		}
+	}
+	return sel, workerURL, workerToken, nil
+}
+
+func buildNodeCandidates(ctx context.Context, db database.Store, nodes []*models.Node, image string) ([]NodeCandidate, error) {
+	active, err := db.CountActiveJobsByNode(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("count active jobs by node: %w", err)
 	}
-	return node, workerURL, workerToken, nil
+	imageStatus := map[uuid.UUID]string{}
+	if image != "" {
+		imageStatus, err = db.GetSandboxImageStatusByNode(ctx, image)
+		if err != nil {
+			return nil, fmt.Errorf("get sandbox image availability: %w", err)
+		}
+	}
+	candidates := make([]NodeCandidate, 0, len(nodes))
+	for _, n := range nodes {
+		c := NodeCandidate{Node: n, ActiveJobs: active[n.ID], ImageStatus: imageStatus[n.ID]}
+		if snap, err := db.GetLatestNodeCapabilitySnapshot(ctx, n.ID); err == nil {
+			c.Capability = ParseCapabilitySnapshot(snap)
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, nil
 }
 
 func markJobAndTaskFailed(ctx context.Context, db database.Store, job *models.Job, result string) {
@@ -88,20 +260,72 @@ func markJobAndTaskFailed(ctx context.Context, db database.Store, job *models.Jo
 	_ = db.UpdateTaskStatus(ctx, job.TaskID, models.TaskStatusFailed)
 }
 
-func applyJobResult(ctx context.Context, db database.Store, job *models.Job, result *workerapi.RunJobResponse) error {
+// applyJobResult records a Worker API result for a job dispatched under leaseID and updates the task status.
+// Failed and timed-out results go through the job's retry policy (failJobAttempt).
+// A result for a job that is no longer running under that lease (canceled or reclaimed while the worker ran)
+// is not authoritative: it is dropped and applied is false.
+// The model token usage the worker reports is recorded against the task's user and project once the result is applied.
+func applyJobResult(ctx context.Context, db database.Store, job *models.Job, leaseID uuid.UUID, result *workerapi.RunJobResponse, logger *slog.Logger) (applied bool, err error) {
+	applied, err = applyJobStatus(ctx, db, job, leaseID, result, logger)
+	if applied {
+		recordJobUsage(ctx, db, job, result.Usage, logger)
+	}
+	return applied, err
+}
+
+func applyJobStatus(ctx context.Context, db database.Store, job *models.Job, leaseID uuid.UUID, result *workerapi.RunJobResponse, logger *slog.Logger) (applied bool, err error) {
 	normalizeSBAResultSurface(result)
-	resultJSON, _ := json.Marshal(result)
-	jobStatus := models.JobStatusCompleted
-	taskStatus := models.TaskStatusCompleted
-	if result.Status != workerapi.StatusCompleted {
-		jobStatus = models.JobStatusFailed
-		taskStatus = models.TaskStatusFailed
+	resultJSON := redactJobResult(job, result, logger)
+	if class := classifyResult(result); class != "" {
+		return failJobAttempt(ctx, db, job, leaseID, class, resultJSON, logger)
 	}
-	if err := db.CompleteJob(ctx, job.ID, string(resultJSON), jobStatus); err != nil {
-		return fmt.Errorf("complete job: %w", err)
+	jobStatus, taskStatus := jobAndTaskStatusForResult(result.Status)
+	attempt := snapshotAttempt(job)
+	if err := db.CompleteLeasedJob(ctx, job.ID, leaseID, resultJSON, jobStatus); err != nil {
+		if errors.Is(err, database.ErrLeaseHeld) {
+			return false, nil
+		}
+		return false, fmt.Errorf("complete job: %w", err)
 	}
+	recordAttempt(ctx, db, attempt, jobStatus, "", resultJSON)
 	_ = db.UpdateTaskStatus(ctx, job.TaskID, taskStatus)
-	return nil
+	return true, nil
+}
+
+// redactJobResult encodes a worker result for storage with secrets in its output (stdout, stderr, SBA result)
+// replaced by redact.Default; the kinds found are logged with the job id.
+func redactJobResult(job *models.Job, result *workerapi.RunJobResponse, logger *slog.Logger) string {
+	b, _ := json.Marshal(result)
+	s, kinds := redact.JSON(string(b))
+	if len(kinds) > 0 && logger != nil {
+		logger.Info("job result redacted", "job_id", job.ID, "redaction_kinds", kinds)
+	}
+	return s
+}
+
+// recordJobUsage writes one token_usage row per model reported by the worker for job.
+func recordJobUsage(ctx context.Context, db database.Store, job *models.Job, reported []workerapi.TokenUsage, logger *slog.Logger) {
+	if len(reported) == 0 {
+		return
+	}
+	a := usage.Attribution{TaskID: &job.TaskID, JobID: &job.ID, Source: models.TokenUsageSourceJob}
+	if task, err := db.GetTaskByID(ctx, job.TaskID); err == nil {
+		a.UserID, a.ProjectID = task.CreatedBy, task.ProjectID
+	}
+	for _, u := range reported {
+		usage.Record(ctx, db, logger, a, inference.Usage{Model: u.Model, PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens})
+	}
+}
+
+func jobAndTaskStatusForResult(status string) (jobStatus, taskStatus string) {
+	switch status {
+	case workerapi.StatusCompleted:
+		return models.JobStatusCompleted, models.TaskStatusCompleted
+	case workerapi.StatusCanceled:
+		return models.JobStatusCanceled, models.TaskStatusCanceled
+	default:
+		return models.JobStatusFailed, models.TaskStatusFailed
+	}
 }
 
 func normalizeSBAResultSurface(result *workerapi.RunJobResponse) {
@@ -119,13 +343,13 @@ func normalizeSBAResultSurface(result *workerapi.RunJobResponse) {
 
 func callWorkerAPI(ctx context.Context, client *http.Client, workerBaseURL, bearerToken string, req *workerapi.RunJobRequest) (*workerapi.RunJobResponse, error) {
 	var lastErr error
-	for attempt := 0; attempt < 3; attempt++ {
+	for attempt := 0; attempt < workerAPIMaxAttempts; attempt++ {
 		runResp, err := callWorkerAPIOnce(ctx, client, workerBaseURL, bearerToken, req)
 		if err == nil {
 			return runResp, nil
 		}
 		lastErr = err
-		if !isTransientWorkerDispatchError(err) || attempt == 2 {
+		if !isTransientWorkerDispatchError(err) || attempt == workerAPIMaxAttempts-1 {
 			break
 		}
 		select {
@@ -156,7 +380,7 @@ func callWorkerAPIOnce(ctx context.Context, client *http.Client, workerBaseURL,
 	}
 	defer func() { _ = resp.Body.Close() }()
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("worker api returned %s", resp.Status)
+		return nil, &workerStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
 	}
 	var runResp workerapi.RunJobResponse
 	if err := json.NewDecoder(resp.Body).Decode(&runResp); err != nil {
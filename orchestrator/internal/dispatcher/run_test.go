@@ -10,6 +10,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
+
 	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/sbajob"
 	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
@@ -55,6 +57,114 @@ func TestRunOnce_Success(t *testing.T) {
 	}
 }
 
+func TestRunOnce_RecordsTokenUsage(t *testing.T) {
+	server := newWorkerServer(t, &workerapi.RunJobResponse{
+		Version: 1, TaskID: "t1", JobID: "j1", Status: workerapi.StatusCompleted,
+		Usage: []workerapi.TokenUsage{{Model: "qwen3:8b", PromptTokens: 12, CompletionTokens: 30}},
+	})
+	defer server.Close()
+
+	mock := testutil.NewMockDB()
+	ctx := context.Background()
+	userID := uuid.New()
+	task, _ := mock.CreateTask(ctx, &userID, "prompt", nil, nil)
+	job, _ := mock.CreateJob(ctx, task.ID, testPayload)
+	node, _ := mock.CreateNode(ctx, "node-1")
+	makeDispatchable(t, mock, ctx, node, server.URL, "token")
+
+	if err := RunOnce(ctx, mock, &http.Client{Timeout: 10 * time.Second}, 10*time.Second, slog.Default()); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if len(mock.TokenUsage) != 1 {
+		t.Fatalf("token usage rows = %d", len(mock.TokenUsage))
+	}
+	u := mock.TokenUsage[0]
+	if u.Source != models.TokenUsageSourceJob || u.TotalTokens != 42 || *u.UserID != userID || *u.JobID != job.ID || *u.TaskID != task.ID {
+		t.Errorf("usage = %+v", u)
+	}
+}
+
+func TestRunOnceWithOptions_SetsArtifactUploadURL(t *testing.T) {
+	var got workerapi.RunJobRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(&workerapi.RunJobResponse{Version: 1, Status: workerapi.StatusCompleted})
+	}))
+	defer server.Close()
+	mock := testutil.NewMockDB()
+	ctx := context.Background()
+	task, _ := mock.CreateTask(ctx, nil, "prompt", nil, nil)
+	job, _ := mock.CreateJob(ctx, task.ID, testPayload)
+	node, _ := mock.CreateNode(ctx, "node-1")
+	makeDispatchable(t, mock, ctx, node, server.URL, "token")
+
+	opts := Options{ArtifactBaseURL: "http://control-plane:12082/"}
+	if err := RunOnceWithOptions(ctx, mock, server.Client(), 5*time.Second, opts, nil); err != nil {
+		t.Fatalf("RunOnceWithOptions: %v", err)
+	}
+	want := "http://control-plane:12082/v1/jobs/" + job.ID.String() + "/artifacts"
+	if got.Artifacts == nil || got.Artifacts.UploadURL != want {
+		t.Errorf("artifacts = %+v, want upload_url %s", got.Artifacts, want)
+	}
+}
+
+func TestRunOnceWithOptions_WorkerTokenReplacesStaticToken(t *testing.T) {
+	var gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(&workerapi.RunJobResponse{Version: 1, Status: workerapi.StatusCompleted})
+	}))
+	defer server.Close()
+	mock := testutil.NewMockDB()
+	ctx := context.Background()
+	task, _ := mock.CreateTask(ctx, nil, "prompt", nil, nil)
+	_, _ = mock.CreateJob(ctx, task.ID, testPayload)
+	node, _ := mock.CreateNode(ctx, "node-1")
+	makeDispatchable(t, mock, ctx, node, server.URL, "static-token")
+
+	opts := Options{WorkerToken: func(n *models.Node) (string, error) { return "signed-for-" + n.NodeSlug, nil }}
+	if err := RunOnceWithOptions(ctx, mock, server.Client(), 5*time.Second, opts, nil); err != nil {
+		t.Fatalf("RunOnceWithOptions: %v", err)
+	}
+	if gotAuth != "Bearer signed-for-node-1" {
+		t.Errorf("Authorization = %q", gotAuth)
+	}
+
+	_, _ = mock.CreateJob(ctx, task.ID, testPayload)
+	opts.WorkerToken = func(*models.Node) (string, error) { return "", errors.New("no key") }
+	if err := RunOnceWithOptions(ctx, mock, server.Client(), 5*time.Second, opts, nil); err == nil {
+		t.Error("dispatch succeeded without a worker token")
+	}
+}
+
+func TestRunOnce_HoldsJobUntilDependenciesComplete(t *testing.T) {
+	server := newWorkerServer(t, &workerapi.RunJobResponse{Version: 1, Status: workerapi.StatusCompleted})
+	defer server.Close()
+	mock := testutil.NewMockDB()
+	ctx := context.Background()
+	plan, _ := mock.CreateProjectPlan(ctx, uuid.New(), nil, nil)
+	first, _ := mock.CreateTask(ctx, nil, "first", nil, nil)
+	second, _ := mock.CreateTask(ctx, nil, "second", nil, nil)
+	_ = mock.AddTaskToPlan(ctx, first.ID, plan.ID, nil)
+	_ = mock.AddTaskToPlan(ctx, second.ID, plan.ID, []uuid.UUID{first.ID})
+	secondJob, _ := mock.CreateJob(ctx, second.ID, testPayload)
+	node, _ := mock.CreateNode(ctx, "n1")
+	makeDispatchable(t, mock, ctx, node, server.URL, "token")
+
+	if err := RunOnce(ctx, mock, server.Client(), 5*time.Second, nil); !errors.Is(err, database.ErrNotFound) {
+		t.Fatalf("dependent job dispatched before its dependency completed: %v", err)
+	}
+	_ = mock.UpdateTaskStatus(ctx, first.ID, models.TaskStatusCompleted)
+	if err := RunOnce(ctx, mock, server.Client(), 5*time.Second, nil); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if j, _ := mock.GetJobByID(ctx, secondJob.ID); j.Status != models.JobStatusCompleted {
+		t.Errorf("dependent job status %s", j.Status)
+	}
+}
+
 func TestRunOnce_ErrNotFound(t *testing.T) {
 	mock := testutil.NewMockDB()
 	ctx := context.Background()
@@ -72,7 +182,7 @@ func TestRunOnce_NoDispatchableNodes(t *testing.T) {
 	mock := testutil.NewMockDB()
 	ctx := context.Background()
 	task, _ := mock.CreateTask(ctx, nil, "p", nil, nil)
-	_, _ = mock.CreateJob(ctx, task.ID, testPayload)
+	job, _ := mock.CreateJob(ctx, task.ID, testPayload)
 	node, _ := mock.CreateNode(ctx, "n1")
 	_ = mock.UpdateNodeStatus(ctx, node.ID, models.NodeStatusActive)
 
@@ -84,6 +194,148 @@ func TestRunOnce_NoDispatchableNodes(t *testing.T) {
 	if errors.Is(err, database.ErrNotFound) {
 		t.Errorf("expected non-ErrNotFound error, got %v", err)
 	}
+	j, _ := mock.GetJobByID(ctx, job.ID)
+	if j.Status != models.JobStatusQueued || j.LeaseID != nil || j.LeaseExpiresAt != nil {
+		t.Errorf("claim should be released: status=%s lease=%v", j.Status, j.LeaseID)
+	}
+}
+
+// TestRunOnce_SkipsUnschedulableJob verifies a queued job no node can run does not block newer jobs behind it.
+func TestRunOnce_SkipsUnschedulableJob(t *testing.T) {
+	server := newWorkerServer(t, &workerapi.RunJobResponse{Version: 1, Status: workerapi.StatusCompleted})
+	defer server.Close()
+
+	mock := testutil.NewMockDB()
+	ctx := context.Background()
+	node, _ := mock.CreateNode(ctx, "no-inference")
+	makeDispatchable(t, mock, ctx, node, server.URL, "token")
+	_ = mock.SaveNodeCapabilitySnapshot(ctx, node.ID, `{"inference":{"supported":false}}`)
+	blockedTask, _ := mock.CreateTask(ctx, nil, "needs inference", nil, nil)
+	blocked, _ := mock.CreateJob(ctx, blockedTask.ID, `{"command":["echo","hi"],"use_inference":true}`)
+	blocked.CreatedAt = blocked.CreatedAt.Add(-time.Minute)
+	task, _ := mock.CreateTask(ctx, nil, "prompt", nil, nil)
+	job, _ := mock.CreateJob(ctx, task.ID, testPayload)
+
+	if err := RunOnce(ctx, mock, nil, 5*time.Second, nil); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if j, _ := mock.GetJobByID(ctx, job.ID); j.Status != models.JobStatusCompleted {
+		t.Errorf("newer job status %s, want completed", j.Status)
+	}
+	b, _ := mock.GetJobByID(ctx, blocked.ID)
+	if b.Status != models.JobStatusQueued || b.LeaseID != nil || b.Attempts != 0 {
+		t.Errorf("skipped job: status=%s lease=%v attempts=%d, want queued and unclaimed", b.Status, b.LeaseID, b.Attempts)
+	}
+	// With nothing else queued the scheduling error is reported and the job stays queued.
+	if err := RunOnce(ctx, mock, nil, 5*time.Second, nil); !errors.Is(err, ErrNoEligibleNode) {
+		t.Errorf("RunOnce with only the unschedulable job: %v, want ErrNoEligibleNode", err)
+	}
+	if b, _ := mock.GetJobByID(ctx, blocked.ID); b.Status != models.JobStatusQueued || b.LeaseID != nil {
+		t.Errorf("skipped job: status=%s lease=%v", b.Status, b.LeaseID)
+	}
+}
+
+// cancelOnSecondClaimStore cancels the iteration's context on the second claim, and fails claim releases
+// made with a done context as the database would.
+type cancelOnSecondClaimStore struct {
+	*testutil.MockDB
+	cancel context.CancelFunc
+	claims int
+}
+
+func (s *cancelOnSecondClaimStore) ClaimNextQueuedJob(ctx context.Context, leaseID uuid.UUID, leaseExpiresAt time.Time) (*models.Job, error) {
+	s.claims++
+	if s.claims > 1 {
+		s.cancel()
+		return nil, context.Canceled
+	}
+	return s.MockDB.ClaimNextQueuedJob(ctx, leaseID, leaseExpiresAt)
+}
+
+func (s *cancelOnSecondClaimStore) ReleaseJobClaim(ctx context.Context, jobID, leaseID uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.MockDB.ReleaseJobClaim(ctx, jobID, leaseID)
+}
+
+func TestRunOnce_ReleasesSkippedClaimsWhenCanceled(t *testing.T) {
+	mock := testutil.NewMockDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	node, _ := mock.CreateNode(ctx, "no-inference")
+	makeDispatchable(t, mock, ctx, node, "http://127.0.0.1:1", "token")
+	_ = mock.SaveNodeCapabilitySnapshot(ctx, node.ID, `{"inference":{"supported":false}}`)
+	task, _ := mock.CreateTask(ctx, nil, "needs inference", nil, nil)
+	job, _ := mock.CreateJob(ctx, task.ID, `{"command":["echo","hi"],"use_inference":true}`)
+
+	store := &cancelOnSecondClaimStore{MockDB: mock, cancel: cancel}
+	if err := RunOnce(ctx, store, nil, 5*time.Second, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("RunOnce: %v, want context.Canceled", err)
+	}
+	if j, _ := mock.GetJobByID(context.Background(), job.ID); j.Status != models.JobStatusQueued || j.LeaseID != nil {
+		t.Errorf("skipped job: status=%s lease=%v, want queued and unclaimed", j.Status, j.LeaseID)
+	}
+}
+
+// cancelAfterClaimStore cancels the job (as the task cancel handler does) once the dispatcher has claimed it
+// and is selecting a node.
+type cancelAfterClaimStore struct {
+	*testutil.MockDB
+	jobID uuid.UUID
+}
+
+func (s *cancelAfterClaimStore) ListDispatchableNodes(ctx context.Context) ([]*models.Node, error) {
+	_ = s.MockDB.UpdateJobStatus(ctx, s.jobID, models.JobStatusCanceled)
+	return s.MockDB.ListDispatchableNodes(ctx)
+}
+
+func TestRunOnce_JobCanceledAfterClaimIsNotDispatched(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	mock := testutil.NewMockDB()
+	ctx := context.Background()
+	node, _ := mock.CreateNode(ctx, "node-1")
+	makeDispatchable(t, mock, ctx, node, server.URL, "token")
+	task, _ := mock.CreateTask(ctx, nil, "prompt", nil, nil)
+	job, _ := mock.CreateJob(ctx, task.ID, testPayload)
+
+	if err := RunOnce(ctx, &cancelAfterClaimStore{MockDB: mock, jobID: job.ID}, nil, 5*time.Second, nil); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if called {
+		t.Error("canceled job was sent to the worker")
+	}
+	got, _ := mock.GetJobByID(ctx, job.ID)
+	if got.Status != models.JobStatusCanceled || got.NodeID != nil {
+		t.Errorf("job: status=%s node=%v, want canceled and unassigned", got.Status, got.NodeID)
+	}
+}
+
+// TestRunOnce_SkipsLeasedJob verifies a job already claimed by another dispatcher is not claimed again.
+func TestRunOnce_SkipsLeasedJob(t *testing.T) {
+	mock := testutil.NewMockDB()
+	ctx := context.Background()
+	task, _ := mock.CreateTask(ctx, nil, "p", nil, nil)
+	_, _ = mock.CreateJob(ctx, task.ID, testPayload)
+	if _, err := mock.ClaimNextQueuedJob(ctx, uuid.New(), time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("ClaimNextQueuedJob: %v", err)
+	}
+	err := RunOnce(ctx, mock, &http.Client{}, 5*time.Second, nil)
+	if !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("expected ErrNotFound while job is leased, got %v", err)
+	}
+}
+
+func TestClaimLeaseDuration(t *testing.T) {
+	if got := claimLeaseDuration(10 * time.Second); got != 30*time.Second+claimLeaseGrace {
+		t.Errorf("claimLeaseDuration: %v", got)
+	}
 }
 
 func TestRunOnce_WorkerReturnsFailed(t *testing.T) {
@@ -152,9 +404,10 @@ func TestRunOnce_WorkerHTTPError(t *testing.T) {
 	if err != nil {
 		t.Fatalf("RunOnce: %v", err)
 	}
+	// 5xx is node_unreachable, retried by the default policy.
 	j, _ := mock.GetJobByID(ctx, job.ID)
-	if j.Status != models.JobStatusFailed {
-		t.Errorf("job status %s", j.Status)
+	if j.Status != models.JobStatusQueued || j.NextAttemptAt == nil {
+		t.Errorf("job status %s next_attempt_at %v", j.Status, j.NextAttemptAt)
 	}
 }
 
@@ -293,3 +546,42 @@ func TestNormalizeSBAResultSurface(t *testing.T) {
 		})
 	}
 }
+
+// TestRunOnce_SchedulesLeastLoadedNode verifies the dispatcher skips a busier node and records its reason on the job.
+func TestRunOnce_SchedulesLeastLoadedNode(t *testing.T) {
+	workerResp := &workerapi.RunJobResponse{
+		Version: 1, TaskID: "t1", JobID: "j1",
+		Status: workerapi.StatusCompleted, ExitCode: 0,
+	}
+	server := newWorkerServer(t, workerResp)
+	defer server.Close()
+
+	mock := testutil.NewMockDB()
+	ctx := context.Background()
+	busy, _ := mock.CreateNode(ctx, "busy")
+	idle, _ := mock.CreateNode(ctx, "idle")
+	makeDispatchable(t, mock, ctx, busy, "http://127.0.0.1:1", "token")
+	makeDispatchable(t, mock, ctx, idle, server.URL, "token")
+	busyTask, _ := mock.CreateTask(ctx, nil, "busy", nil, nil)
+	busyJob, _ := mock.CreateJob(ctx, busyTask.ID, testPayload)
+	busyLease := uuid.New()
+	_, _ = mock.ClaimNextQueuedJob(ctx, busyLease, time.Now().Add(time.Minute))
+	_ = mock.AssignJobToNode(ctx, busyJob.ID, busyLease, busy.ID, "test")
+	_ = mock.UpdateJobStatus(ctx, busyJob.ID, models.JobStatusRunning)
+
+	task, _ := mock.CreateTask(ctx, nil, "prompt", nil, nil)
+	job, _ := mock.CreateJob(ctx, task.ID, testPayload)
+	if err := RunOnce(ctx, mock, nil, 10*time.Second, nil); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	j, _ := mock.GetJobByID(ctx, job.ID)
+	if j.NodeID == nil || *j.NodeID != idle.ID {
+		t.Fatalf("job node %v, want idle node", j.NodeID)
+	}
+	if j.SchedulingReason == nil || *j.SchedulingReason == "" {
+		t.Error("expected scheduling reason to be recorded")
+	}
+	if j.Status != models.JobStatusCompleted {
+		t.Errorf("job status %s", j.Status)
+	}
+}
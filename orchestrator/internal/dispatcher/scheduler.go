@@ -0,0 +1,222 @@
+// Package dispatcher: node scheduler that scores dispatchable nodes for a job.
+// See docs/tech_specs/orchestrator.md Task Scheduler (capability, load, and model availability).
+package dispatcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/nodepayloads"
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/sbajob"
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+// Sandbox image availability statuses (postgres_schema.md node_sandbox_image_availability).
+const (
+	ImageStatusAvailable = "available"
+	ImageStatusPulling   = "pulling"
+	ImageStatusFailed    = "failed"
+	ImageStatusEvicted   = "evicted"
+)
+
+// Score weights. Image and model presence dominate so jobs land where they can start immediately;
+// each running job costs more than any hardware bonus so load spreads across similar nodes.
+const (
+	scoreImageAvailable = 30.0
+	scoreImagePulling   = 5.0
+	scoreModelAvailable = 25.0
+	scorePerActiveJob   = -20.0
+	scorePerCPUCore     = 0.5
+	scorePerRAMGB       = 0.1
+	scorePerVRAMGB      = 0.5
+	maxScoredCPUCores   = 64
+	maxScoredRAMGB      = 256
+	maxScoredVRAMGB     = 96
+)
+
+// ErrNoEligibleNode is returned when no dispatchable node can run the job.
+var ErrNoEligibleNode = errors.New("no eligible node for job")
+
+// JobRequirements is what a job needs from a node, derived from its sandbox spec.
+type JobRequirements struct {
+	Image         string
+	UseInference  bool
+	AllowedModels []string
+}
+
+// RequirementsFromSandbox derives scheduling requirements from a parsed sandbox spec.
+// SBA jobs contribute their allowed inference models (and always need inference).
+func RequirementsFromSandbox(spec *workerapi.SandboxSpec) JobRequirements {
+	req := JobRequirements{Image: spec.Image, UseInference: spec.UseInference}
+	if spec.JobSpecJSON == "" {
+		return req
+	}
+	var js sbajob.JobSpec
+	if json.Unmarshal([]byte(spec.JobSpecJSON), &js) != nil {
+		return req
+	}
+	if js.Inference != nil && len(js.Inference.AllowedModels) > 0 {
+		req.AllowedModels = js.Inference.AllowedModels
+		req.UseInference = true
+	}
+	return req
+}
+
+// NodeCandidate is a dispatchable node with the inputs the scheduler scores.
+// Capability is nil when the node has not reported a snapshot; ImageStatus is empty when unknown.
+type NodeCandidate struct {
+	Node        *models.Node
+	Capability  *nodepayloads.CapabilityReport
+	ActiveJobs  int
+	ImageStatus string
+}
+
+// Selection is the scheduler's choice for a job.
+type Selection struct {
+	Node   *models.Node
+	Score  float64
+	Reason string
+}
+
+type scoredCandidate struct {
+	cand    *NodeCandidate
+	score   float64
+	factors []string
+}
+
+// SelectNode scores candidates for req and returns the best eligible node with a human-readable reason.
+// Ties are broken by fewer active jobs, then node slug, so selection is deterministic.
+// Returns ErrNoEligibleNode (wrapped with per-node rejection reasons) when every candidate is ineligible.
+func SelectNode(req JobRequirements, candidates []NodeCandidate) (*Selection, error) {
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w: no dispatchable nodes", ErrNoEligibleNode)
+	}
+	var scored []scoredCandidate
+	var rejected []string
+	for i := range candidates {
+		c := &candidates[i]
+		if why := ineligibleReason(req, c); why != "" {
+			rejected = append(rejected, c.Node.NodeSlug+": "+why)
+			continue
+		}
+		score, factors := scoreCandidate(req, c)
+		scored = append(scored, scoredCandidate{cand: c, score: score, factors: factors})
+	}
+	if len(scored) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoEligibleNode, strings.Join(rejected, "; "))
+	}
+	sort.SliceStable(scored, func(i, j int) bool {
+		a, b := scored[i], scored[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if a.cand.ActiveJobs != b.cand.ActiveJobs {
+			return a.cand.ActiveJobs < b.cand.ActiveJobs
+		}
+		return a.cand.Node.NodeSlug < b.cand.Node.NodeSlug
+	})
+	best := scored[0]
+	reason := fmt.Sprintf("score %.1f (%s); %d of %d candidates eligible",
+		best.score, strings.Join(best.factors, ", "), len(scored), len(candidates))
+	return &Selection{Node: best.cand.Node, Score: best.score, Reason: reason}, nil
+}
+
+// ineligibleReason returns a non-empty reason when the candidate cannot run the job at all.
+func ineligibleReason(req JobRequirements, c *NodeCandidate) string {
+	if c.ImageStatus == ImageStatusFailed {
+		return "sandbox image pull failed"
+	}
+	capReport := c.Capability
+	if capReport == nil {
+		return ""
+	}
+	if capReport.Sandbox != nil {
+		if !capReport.Sandbox.Supported {
+			return "sandbox not supported"
+		}
+		if limit := capReport.Sandbox.MaxConcurrency; limit > 0 && c.ActiveJobs >= limit {
+			return fmt.Sprintf("at sandbox capacity (%d/%d)", c.ActiveJobs, limit)
+		}
+	}
+	if req.UseInference && capReport.Inference != nil && !capReport.Inference.Supported {
+		return "inference not supported"
+	}
+	return ""
+}
+
+// scoreCandidate returns the candidate's score and the factors that contributed to it.
+func scoreCandidate(req JobRequirements, c *NodeCandidate) (float64, []string) {
+	var score float64
+	var factors []string
+	switch c.ImageStatus {
+	case ImageStatusAvailable:
+		score += scoreImageAvailable
+		factors = append(factors, "sandbox image available")
+	case ImageStatusPulling:
+		score += scoreImagePulling
+		factors = append(factors, "sandbox image pulling")
+	}
+	score += scorePerActiveJob * float64(c.ActiveJobs)
+	factors = append(factors, fmt.Sprintf("%d active jobs", c.ActiveJobs))
+	capReport := c.Capability
+	if capReport == nil {
+		factors = append(factors, "no capability snapshot")
+		return score, factors
+	}
+	if m := firstAvailableModel(req.AllowedModels, capReport.Inference); m != "" {
+		score += scoreModelAvailable
+		factors = append(factors, "model "+m+" available")
+	}
+	cores := min(capReport.Compute.CPUCores, maxScoredCPUCores)
+	ramGB := min(capReport.Compute.RAMMB/1024, maxScoredRAMGB)
+	score += scorePerCPUCore*float64(cores) + scorePerRAMGB*float64(ramGB)
+	factors = append(factors, fmt.Sprintf("%d cpu cores, %d MB RAM", capReport.Compute.CPUCores, capReport.Compute.RAMMB))
+	if req.UseInference {
+		if vram := totalVRAMMB(capReport.GPU); vram > 0 {
+			score += scorePerVRAMGB * float64(min(vram/1024, maxScoredVRAMGB))
+			factors = append(factors, fmt.Sprintf("%d MB GPU VRAM", vram))
+		}
+	}
+	return score, factors
+}
+
+func firstAvailableModel(allowed []string, inf *nodepayloads.InferenceInfo) string {
+	if inf == nil || len(allowed) == 0 {
+		return ""
+	}
+	for _, want := range allowed {
+		for _, have := range inf.AvailableModels {
+			if want == have {
+				return want
+			}
+		}
+	}
+	return ""
+}
+
+func totalVRAMMB(gpu *nodepayloads.GPUInfo) int {
+	if gpu == nil || !gpu.Present {
+		return 0
+	}
+	total := 0
+	for _, d := range gpu.Devices {
+		total += d.VRAMMB
+	}
+	return total
+}
+
+// ParseCapabilitySnapshot decodes a stored capability snapshot; returns nil when empty or invalid.
+func ParseCapabilitySnapshot(snapshot string) *nodepayloads.CapabilityReport {
+	if strings.TrimSpace(snapshot) == "" {
+		return nil
+	}
+	var report nodepayloads.CapabilityReport
+	if json.Unmarshal([]byte(snapshot), &report) != nil {
+		return nil
+	}
+	return &report
+}
@@ -0,0 +1,146 @@
+package dispatcher
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/nodepayloads"
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+func testNode(slug string) *models.Node {
+	return &models.Node{ID: uuid.New(), NodeSlug: slug}
+}
+
+func testCapability(cores, ramMB int, modelNames ...string) *nodepayloads.CapabilityReport {
+	return &nodepayloads.CapabilityReport{
+		Compute:   nodepayloads.Compute{CPUCores: cores, RAMMB: ramMB},
+		Sandbox:   &nodepayloads.SandboxSupport{Supported: true, MaxConcurrency: 4},
+		Inference: &nodepayloads.InferenceInfo{Supported: true, AvailableModels: modelNames},
+	}
+}
+
+func TestSelectNode_PrefersLessLoaded(t *testing.T) {
+	busy, idle := testNode("busy"), testNode("idle")
+	sel, err := SelectNode(JobRequirements{}, []NodeCandidate{
+		{Node: busy, Capability: testCapability(32, 65536), ActiveJobs: 2},
+		{Node: idle, Capability: testCapability(8, 16384)},
+	})
+	if err != nil {
+		t.Fatalf("SelectNode: %v", err)
+	}
+	if sel.Node != idle {
+		t.Errorf("selected %s, want idle", sel.Node.NodeSlug)
+	}
+	if !strings.Contains(sel.Reason, "0 active jobs") || !strings.Contains(sel.Reason, "2 of 2 candidates eligible") {
+		t.Errorf("reason %q", sel.Reason)
+	}
+}
+
+func TestSelectNode_PrefersImageAndModel(t *testing.T) {
+	a, b, c := testNode("a"), testNode("b"), testNode("c")
+	req := JobRequirements{Image: "img", UseInference: true, AllowedModels: []string{"qwen3:8b"}}
+	sel, err := SelectNode(req, []NodeCandidate{
+		{Node: a, Capability: testCapability(64, 262144)},
+		{Node: b, Capability: testCapability(4, 8192), ImageStatus: ImageStatusAvailable},
+		{Node: c, Capability: testCapability(4, 8192, "qwen3:8b"), ImageStatus: ImageStatusAvailable},
+	})
+	if err != nil {
+		t.Fatalf("SelectNode: %v", err)
+	}
+	if sel.Node != c {
+		t.Errorf("selected %s, want c", sel.Node.NodeSlug)
+	}
+	if !strings.Contains(sel.Reason, "model qwen3:8b available") || !strings.Contains(sel.Reason, "sandbox image available") {
+		t.Errorf("reason %q", sel.Reason)
+	}
+}
+
+func TestSelectNode_VRAMOnlyCountsForInference(t *testing.T) {
+	gpu, cpu := testNode("gpu"), testNode("cpu")
+	gpuCap := testCapability(4, 8192)
+	gpuCap.GPU = &nodepayloads.GPUInfo{Present: true, Devices: []nodepayloads.GPUDevice{{VRAMMB: 24576}}}
+	candidates := []NodeCandidate{
+		{Node: gpu, Capability: gpuCap},
+		{Node: cpu, Capability: testCapability(8, 8192)},
+	}
+	sel, err := SelectNode(JobRequirements{}, candidates)
+	if err != nil || sel.Node != cpu {
+		t.Fatalf("no inference: got %v, %v; want cpu", sel, err)
+	}
+	sel, err = SelectNode(JobRequirements{UseInference: true}, candidates)
+	if err != nil || sel.Node != gpu {
+		t.Fatalf("inference: got %v, %v; want gpu", sel, err)
+	}
+}
+
+func TestSelectNode_Ineligible(t *testing.T) {
+	full, noSandbox, pullFailed, noInference := testNode("full"), testNode("nosb"), testNode("failed"), testNode("noinf")
+	noSandboxCap := testCapability(4, 8192)
+	noSandboxCap.Sandbox.Supported = false
+	noInferenceCap := testCapability(4, 8192)
+	noInferenceCap.Inference.Supported = false
+	_, err := SelectNode(JobRequirements{UseInference: true}, []NodeCandidate{
+		{Node: full, Capability: testCapability(4, 8192), ActiveJobs: 4},
+		{Node: noSandbox, Capability: noSandboxCap},
+		{Node: pullFailed, ImageStatus: ImageStatusFailed},
+		{Node: noInference, Capability: noInferenceCap},
+	})
+	if !errors.Is(err, ErrNoEligibleNode) {
+		t.Fatalf("want ErrNoEligibleNode, got %v", err)
+	}
+	for _, want := range []string{"at sandbox capacity (4/4)", "sandbox not supported", "sandbox image pull failed", "inference not supported"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q missing %q", err, want)
+		}
+	}
+	if _, err := SelectNode(JobRequirements{}, nil); !errors.Is(err, ErrNoEligibleNode) {
+		t.Errorf("empty candidates: %v", err)
+	}
+}
+
+func TestSelectNode_TieBreaksBySlug(t *testing.T) {
+	a, b := testNode("a"), testNode("b")
+	sel, err := SelectNode(JobRequirements{}, []NodeCandidate{{Node: b}, {Node: a}})
+	if err != nil {
+		t.Fatalf("SelectNode: %v", err)
+	}
+	if sel.Node != a {
+		t.Errorf("selected %s, want a", sel.Node.NodeSlug)
+	}
+	if !strings.Contains(sel.Reason, "no capability snapshot") {
+		t.Errorf("reason %q", sel.Reason)
+	}
+}
+
+func TestRequirementsFromSandbox(t *testing.T) {
+	req := RequirementsFromSandbox(&workerapi.SandboxSpec{Image: "img"})
+	if req.Image != "img" || req.UseInference || len(req.AllowedModels) != 0 {
+		t.Errorf("plain: %+v", req)
+	}
+	req = RequirementsFromSandbox(&workerapi.SandboxSpec{
+		Image:       "sba",
+		JobSpecJSON: `{"inference":{"allowed_models":["m1","m2"]}}`,
+	})
+	if !req.UseInference || len(req.AllowedModels) != 2 {
+		t.Errorf("sba: %+v", req)
+	}
+	req = RequirementsFromSandbox(&workerapi.SandboxSpec{JobSpecJSON: "not json"})
+	if req.UseInference {
+		t.Errorf("invalid job spec: %+v", req)
+	}
+}
+
+func TestParseCapabilitySnapshot(t *testing.T) {
+	if ParseCapabilitySnapshot("") != nil || ParseCapabilitySnapshot("{bad") != nil {
+		t.Error("expected nil for empty or invalid snapshot")
+	}
+	rep := ParseCapabilitySnapshot(`{"compute":{"cpu_cores":8,"ram_mb":16384}}`)
+	if rep == nil || rep.Compute.CPUCores != 8 {
+		t.Errorf("got %+v", rep)
+	}
+}
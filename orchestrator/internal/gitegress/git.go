@@ -0,0 +1,411 @@
+package gitegress
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Error codes carried by *Error; the MCP gateway records them as the audit error_type.
+const (
+	CodeInvalidArguments = "invalid_arguments"
+	CodeAuthFailed       = "auth_failed"
+	CodeNotFound         = "not_found"
+	CodePushRejected     = "push_rejected"
+	CodeInvalidBundle    = "invalid_bundle"
+	CodeBundleTooLarge   = "bundle_too_large"
+	CodeGitError         = "git_error"
+)
+
+// DefaultMaxBundleBytes bounds bundles in either direction when Client.MaxBundleBytes is zero.
+const DefaultMaxBundleBytes = 64 << 20
+
+// maxErrorMessage bounds the git output quoted in an Error.
+const maxErrorMessage = 512
+
+// objectID matches a full SHA-1 or SHA-256 commit id.
+var objectID = regexp.MustCompile(`^[0-9a-f]{40}([0-9a-f]{24})?$`)
+
+// passEnv are the variables git inherits from the service; everything else (database DSNs, master keys) is dropped.
+var passEnv = []string{"PATH", "HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy"}
+
+// Error is a structured Git egress failure (git_egress_mcp.md Failure Modes and Safety).
+type Error struct {
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string { return e.Code + ": " + e.Message }
+
+func newError(code, format string, args ...any) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// AsError returns err as an *Error, wrapping anything else as CodeGitError.
+func AsError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return &Error{Code: CodeGitError, Message: err.Error()}
+}
+
+// Client runs the git CLI against remote repositories in throwaway bare repositories. The provider token is
+// passed to git only as an HTTP Authorization header through the environment; it never appears in argv, URLs,
+// config files, or results.
+type Client struct {
+	// Git is the git binary; empty means "git" on PATH.
+	Git string
+	// AllowHTTP permits plain-http remotes (local stand-ins only); otherwise only https is used.
+	AllowHTTP bool
+	// MaxBundleBytes bounds produced and accepted bundles; 0 means DefaultMaxBundleBytes.
+	MaxBundleBytes int64
+}
+
+// Bundle is a branch exported for a sandbox. Data is a git bundle holding Branch at Commit, minus the commits
+// the caller already has; it is nil when UpToDate (the caller has every commit).
+type Bundle struct {
+	Branch   string
+	Commit   string
+	Data     []byte
+	SHA256   string
+	UpToDate bool
+}
+
+// Fetch exports branch of repo (the remote's default branch when empty) as a bundle. Commits in have that the
+// remote also has are excluded, so a sandbox holding an earlier bundle gets only what is new.
+func (c *Client) Fetch(ctx context.Context, repo Repo, token []byte, branch string, have []string) (*Bundle, error) {
+	s, err := c.open(repo, token)
+	if err != nil {
+		return nil, err
+	}
+	defer s.close()
+	if branch == "" {
+		if branch, err = s.defaultBranch(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.checkBranch(ctx, branch); err != nil {
+		return nil, err
+	}
+	ref := "refs/heads/" + branch
+	if _, err := s.git(ctx, CodeGitError, "fetch", "--quiet", "--no-tags", s.remote, "+"+ref+":"+ref); err != nil {
+		return nil, err
+	}
+	commit, err := s.git(ctx, CodeGitError, "rev-parse", ref)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := s.knownCommits(ctx, have)
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{Branch: branch, Commit: commit}
+	n, err := s.git(ctx, CodeGitError, append([]string{"rev-list", "--count", ref}, exclude...)...)
+	if err != nil {
+		return nil, err
+	}
+	if n == "0" {
+		b.UpToDate = true
+		return b, nil
+	}
+	file := filepath.Join(s.dir, "out.bundle")
+	if _, err := s.git(ctx, CodeGitError, append([]string{"bundle", "create", "--quiet", file, ref}, exclude...)...); err != nil {
+		return nil, err
+	}
+	if b.Data, err = c.readBundle(file); err != nil {
+		return nil, err
+	}
+	b.SHA256 = BundleSHA256(b.Data)
+	return b, nil
+}
+
+// PushBranch imports branch from bundle and pushes it to repo without force. The bundle may be incremental on
+// top of baseBranch or of branch's current remote tip, which are fetched first. Returns the pushed commit.
+func (c *Client) PushBranch(ctx context.Context, repo Repo, token, bundle []byte, branch, baseBranch string) (string, error) {
+	if int64(len(bundle)) > c.BundleLimit() {
+		return "", newError(CodeBundleTooLarge, "bundle exceeds %d bytes", c.BundleLimit())
+	}
+	s, err := c.open(repo, token)
+	if err != nil {
+		return "", err
+	}
+	defer s.close()
+	if err := s.checkBranch(ctx, branch); err != nil {
+		return "", err
+	}
+	if baseBranch != "" {
+		if err := s.checkBranch(ctx, baseBranch); err != nil {
+			return "", err
+		}
+	}
+	file := filepath.Join(s.dir, "in.bundle")
+	if err := os.WriteFile(file, bundle, 0o600); err != nil {
+		return "", err
+	}
+	head, err := s.bundleHead(ctx, file, branch)
+	if err != nil {
+		return "", err
+	}
+	if err := s.fetchBases(ctx, branch, baseBranch); err != nil {
+		return "", err
+	}
+	ref := "refs/heads/" + branch
+	if _, err := s.git(ctx, CodeInvalidBundle, "fetch", "--quiet", "--no-tags", file, head+":"+ref); err != nil {
+		return "", err
+	}
+	commit, err := s.git(ctx, CodeGitError, "rev-parse", ref)
+	if err != nil {
+		return "", err
+	}
+	if _, err := s.git(ctx, CodeGitError, "push", "--quiet", "--no-verify", s.remote, ref+":"+ref); err != nil {
+		return "", err
+	}
+	return commit, nil
+}
+
+// DefaultBranch returns the branch repo's HEAD points at.
+func (c *Client) DefaultBranch(ctx context.Context, repo Repo, token []byte) (string, error) {
+	s, err := c.open(repo, token)
+	if err != nil {
+		return "", err
+	}
+	defer s.close()
+	return s.defaultBranch(ctx)
+}
+
+// BundleSHA256 returns the hex SHA-256 of bundle bytes (the audited changeset hash).
+func BundleSHA256(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// BundleLimit is the largest bundle the client produces or accepts.
+func (c *Client) BundleLimit() int64 {
+	if c.MaxBundleBytes > 0 {
+		return c.MaxBundleBytes
+	}
+	return DefaultMaxBundleBytes
+}
+
+func (c *Client) readBundle(file string) ([]byte, error) {
+	fi, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() > c.BundleLimit() {
+		return nil, newError(CodeBundleTooLarge, "bundle is %d bytes, limit %d", fi.Size(), c.BundleLimit())
+	}
+	return os.ReadFile(file)
+}
+
+// session is one operation's scratch directory: a bare repository and a HOME with no git config.
+type session struct {
+	bin    string
+	dir    string
+	repo   string
+	env    []string
+	remote string
+}
+
+func (c *Client) open(repo Repo, token []byte) (*session, error) {
+	remote := repo.RemoteURL()
+	if !strings.HasPrefix(remote, "https://") && !(c.AllowHTTP && strings.HasPrefix(remote, "http://")) {
+		return nil, newError(CodeInvalidArguments, "remote %s is not https", remote)
+	}
+	dir, err := os.MkdirTemp("", "cynodeai-git-egress-*")
+	if err != nil {
+		return nil, err
+	}
+	s := &session{bin: c.Git, dir: dir, repo: filepath.Join(dir, "repo"), remote: remote}
+	if s.bin == "" {
+		s.bin = "git"
+	}
+	s.env = gitEnv(dir, repo, token, c.AllowHTTP)
+	if out, err := s.run(context.Background(), "init", "--quiet", "--bare", s.repo); err != nil {
+		s.close()
+		return nil, newError(CodeGitError, "git init: %s", lastLine(out))
+	}
+	return s, nil
+}
+
+func (s *session) close() { _ = os.RemoveAll(s.dir) }
+
+// gitEnv isolates git from host config, disables prompts, redirects, and every transport but https (and http
+// when allowed) and local bundles, and supplies the token as a basic-auth header for the remote.
+func gitEnv(home string, repo Repo, token []byte, allowHTTP bool) []string {
+	env := []string{"HOME=" + home, "GIT_CONFIG_NOSYSTEM=1", "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS="}
+	for _, k := range passEnv {
+		if v, ok := os.LookupEnv(k); ok {
+			env = append(env, k+"="+v)
+		}
+	}
+	user := "x-access-token"
+	if repo.Provider == ProviderGitLab {
+		user = "oauth2"
+	}
+	cfg := [][2]string{
+		{"protocol.allow", "never"},
+		{"protocol.https.allow", "always"},
+		{"protocol.file.allow", "always"},
+		{"credential.helper", ""},
+		{"http.followRedirects", "false"},
+		{"http.extraHeader", "Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+string(token)))},
+	}
+	if allowHTTP {
+		cfg = append(cfg, [2]string{"protocol.http.allow", "always"})
+	}
+	env = append(env, "GIT_CONFIG_COUNT="+strconv.Itoa(len(cfg)))
+	for i, kv := range cfg {
+		n := strconv.Itoa(i)
+		env = append(env, "GIT_CONFIG_KEY_"+n+"="+kv[0], "GIT_CONFIG_VALUE_"+n+"="+kv[1])
+	}
+	return env
+}
+
+// run executes git with args and returns combined output.
+func (s *session) run(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, s.bin, args...)
+	cmd.Env = s.env
+	cmd.Dir = s.dir
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	err := cmd.Run()
+	return strings.TrimSpace(out.String()), err
+}
+
+// git runs a git command in the session repository and returns its trimmed output. Failures are classified
+// from git's output, falling back to code.
+func (s *session) git(ctx context.Context, code string, args ...string) (string, error) {
+	out, err := s.run(ctx, append([]string{"-C", s.repo}, args...)...)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", newError(CodeGitError, "git %s: %v", args[0], ctx.Err())
+		}
+		return "", classify(out, code)
+	}
+	return out, nil
+}
+
+// classify maps git's error output to an Error code.
+func classify(out, fallback string) *Error {
+	code := fallback
+	switch {
+	case containsAny(out, "Authentication failed", "could not read Username", "terminal prompts disabled",
+		"returned error: 401", "returned error: 403"):
+		code = CodeAuthFailed
+	case containsAny(out, "couldn't find remote ref", "not found", "returned error: 404"):
+		code = CodeNotFound
+	case containsAny(out, "[rejected]", "[remote rejected]", "non-fast-forward"):
+		code = CodePushRejected
+	}
+	return &Error{Code: code, Message: lastLine(out)}
+}
+
+func containsAny(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// lastLine returns the last non-empty line of git output (its error summary), truncated.
+func lastLine(out string) string {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	msg := strings.TrimSpace(lines[len(lines)-1])
+	if len(msg) > maxErrorMessage {
+		msg = msg[:maxErrorMessage]
+	}
+	return msg
+}
+
+// checkBranch rejects branch names git would not accept as refs/heads/<name>, and option-like names.
+func (s *session) checkBranch(ctx context.Context, branch string) error {
+	if branch == "" || strings.HasPrefix(branch, "-") {
+		return newError(CodeInvalidArguments, "invalid branch name %q", branch)
+	}
+	if _, err := s.git(ctx, CodeInvalidArguments, "check-ref-format", "refs/heads/"+branch); err != nil {
+		return newError(CodeInvalidArguments, "invalid branch name %q", branch)
+	}
+	return nil
+}
+
+// defaultBranch returns the branch the remote's HEAD points at.
+func (s *session) defaultBranch(ctx context.Context) (string, error) {
+	out, err := s.git(ctx, CodeGitError, "ls-remote", "--symref", s.remote, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if ref, ok := strings.CutPrefix(line, "ref: refs/heads/"); ok {
+			if branch, _, ok := strings.Cut(ref, "\t"); ok {
+				return branch, nil
+			}
+		}
+	}
+	return "", newError(CodeNotFound, "remote has no default branch")
+}
+
+// knownCommits validates have and returns the ^<id> exclusions for those present in the session repository.
+func (s *session) knownCommits(ctx context.Context, have []string) ([]string, error) {
+	var out []string
+	for _, id := range have {
+		if !objectID.MatchString(id) {
+			return nil, newError(CodeInvalidArguments, "have must be full commit ids")
+		}
+		if _, err := s.git(ctx, CodeGitError, "cat-file", "-e", id+"^{commit}"); err == nil {
+			out = append(out, "^"+id)
+		}
+	}
+	return out, nil
+}
+
+// bundleHead returns the bundle ref to import as branch: refs/heads/<branch> when present, else its only head.
+func (s *session) bundleHead(ctx context.Context, file, branch string) (string, error) {
+	out, err := s.git(ctx, CodeInvalidBundle, "bundle", "list-heads", file)
+	if err != nil {
+		return "", err
+	}
+	var heads []string
+	for _, line := range strings.Split(out, "\n") {
+		if _, ref, ok := strings.Cut(strings.TrimSpace(line), " "); ok {
+			if ref == "refs/heads/"+branch {
+				return ref, nil
+			}
+			heads = append(heads, ref)
+		}
+	}
+	if len(heads) != 1 {
+		return "", newError(CodeInvalidBundle, "bundle must contain refs/heads/%s or exactly one ref, has %d", branch, len(heads))
+	}
+	return heads[0], nil
+}
+
+// fetchBases fetches baseBranch (required to exist when set) and branch (when it exists remotely) so that an
+// incremental bundle's prerequisite commits are present.
+func (s *session) fetchBases(ctx context.Context, branch, baseBranch string) error {
+	if baseBranch != "" {
+		ref := "refs/heads/" + baseBranch
+		if _, err := s.git(ctx, CodeGitError, "fetch", "--quiet", "--no-tags", s.remote, "+"+ref+":refs/remotes/base/"+baseBranch); err != nil {
+			return err
+		}
+	}
+	ref := "refs/heads/" + branch
+	_, err := s.git(ctx, CodeGitError, "fetch", "--quiet", "--no-tags", s.remote, "+"+ref+":refs/remotes/base/"+branch)
+	if err != nil && AsError(err).Code != CodeNotFound {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package gitegress
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
+)
+
+const testToken = "ghp_test"
+
+// gitFixture serves octo/hello (main at the returned commit) from a stand-in Git host.
+func gitFixture(t *testing.T) (c *Client, repo Repo, srv *testutil.GitServer, initial string) {
+	t.Helper()
+	srv = testutil.NewGitServer(t, testToken)
+	initial = srv.CreateRepo(t, "octo/hello")
+	repo, err := NormalizeRepo(ProviderGitHub, "octo/hello", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Client{AllowHTTP: true}, repo, srv, initial
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFetchAndPushBranch(t *testing.T) {
+	c, repo, srv, initial := gitFixture(t)
+	ctx := context.Background()
+
+	b, err := c.Fetch(ctx, repo, []byte(testToken), "", nil)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if b.Branch != "main" || b.Commit != initial || b.UpToDate || len(b.Data) == 0 || b.SHA256 != BundleSHA256(b.Data) {
+		t.Fatalf("bundle = %+v", b)
+	}
+	if up, err := c.Fetch(ctx, repo, []byte(testToken), "main", []string{initial}); err != nil || !up.UpToDate || up.Data != nil {
+		t.Fatalf("Fetch with have: %+v, %v", up, err)
+	}
+
+	// The sandbox clones from the bundle, commits on a branch, and exports only its new commit.
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "in.bundle"), b.Data)
+	sandbox := filepath.Join(dir, "work")
+	testutil.Git(t, dir, "clone", "--quiet", "--branch", "main", "in.bundle", sandbox)
+	testutil.Git(t, sandbox, "checkout", "--quiet", "-b", "feature")
+	writeFile(t, filepath.Join(sandbox, "fix.txt"), []byte("fix\n"))
+	testutil.Git(t, sandbox, "add", "fix.txt")
+	testutil.Git(t, sandbox, "commit", "--quiet", "-m", "fix")
+	testutil.Git(t, sandbox, "bundle", "create", "--quiet", "../out.bundle", "feature", "^"+initial)
+	out, _ := os.ReadFile(filepath.Join(dir, "out.bundle"))
+
+	commit, err := c.PushBranch(ctx, repo, []byte(testToken), out, "feature", "main")
+	if err != nil {
+		t.Fatalf("PushBranch: %v", err)
+	}
+	if want := testutil.Git(t, sandbox, "rev-parse", "HEAD"); commit != want {
+		t.Errorf("pushed %s, want %s", commit, want)
+	}
+	if got := testutil.Git(t, filepath.Join(srv.Root, "octo/hello.git"), "rev-parse", "refs/heads/feature"); got != commit {
+		t.Errorf("remote feature = %s, want %s", got, commit)
+	}
+
+	// A bundle that does not descend from the remote branch is not force-pushed.
+	testutil.Git(t, sandbox, "checkout", "--quiet", "-b", "other", "main")
+	writeFile(t, filepath.Join(sandbox, "other.txt"), []byte("other\n"))
+	testutil.Git(t, sandbox, "add", "other.txt")
+	testutil.Git(t, sandbox, "commit", "--quiet", "-m", "other")
+	testutil.Git(t, sandbox, "bundle", "create", "--quiet", "../other.bundle", "other", "^"+initial)
+	other, _ := os.ReadFile(filepath.Join(dir, "other.bundle"))
+	if _, err := c.PushBranch(ctx, repo, []byte(testToken), other, "feature", "main"); AsError(err).Code != CodePushRejected {
+		t.Errorf("diverged push: %v", err)
+	}
+}
+
+func TestGitErrors(t *testing.T) {
+	c, repo, _, _ := gitFixture(t)
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name string
+		run  func() error
+		want string
+	}{
+		{"bad token", func() error { _, err := c.Fetch(ctx, repo, []byte("wrong"), "main", nil); return err }, CodeAuthFailed},
+		{"missing branch", func() error { _, err := c.Fetch(ctx, repo, []byte(testToken), "nope", nil); return err }, CodeNotFound},
+		{"bad branch", func() error { _, err := c.Fetch(ctx, repo, []byte(testToken), "-x", nil); return err }, CodeInvalidArguments},
+		{"bad have", func() error { _, err := c.Fetch(ctx, repo, []byte(testToken), "main", []string{"HEAD"}); return err }, CodeInvalidArguments},
+		{"bad bundle", func() error {
+			_, err := c.PushBranch(ctx, repo, []byte(testToken), []byte("not a bundle"), "feature", "")
+			return err
+		}, CodeInvalidBundle},
+		{"too large", func() error {
+			small := &Client{AllowHTTP: true, MaxBundleBytes: 4}
+			_, err := small.PushBranch(ctx, repo, []byte(testToken), []byte("12345"), "feature", "")
+			return err
+		}, CodeBundleTooLarge},
+		{"http refused", func() error { _, err := (&Client{}).Fetch(ctx, repo, []byte(testToken), "main", nil); return err }, CodeInvalidArguments},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.run(); err == nil || AsError(err).Code != tc.want {
+				t.Errorf("err = %v, want code %s", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeRepo(t *testing.T) {
+	r, err := NormalizeRepo(" GitHub ", "/octo/hello.git/", "")
+	if err != nil || r.Provider != ProviderGitHub || r.Identifier != "octo/hello" || r.RemoteURL() != "https://github.com/octo/hello.git" {
+		t.Fatalf("NormalizeRepo = %+v, %v", r, err)
+	}
+	if !r.Matches("github", "Octo/Hello", "https://github.com/") || r.Matches("github", "octo/other", "") || r.Matches("gitea", "octo/hello", "") {
+		t.Error("Matches")
+	}
+	if r.GitHubAPIURL() != "https://api.github.com" || r.Resource() != "github.com/octo/hello" {
+		t.Errorf("GitHubAPIURL = %s, Resource = %s", r.GitHubAPIURL(), r.Resource())
+	}
+	ghe, _ := NormalizeRepo("github", "octo/hello", "https://ghe.example.com/")
+	if ghe.GitHubAPIURL() != "https://ghe.example.com/api/v3" || ghe.Matches("github", "octo/hello", "") {
+		t.Errorf("enterprise repo = %+v", ghe)
+	}
+	if gl, err := NormalizeRepo("gitlab", "group/sub/project", ""); err != nil || gl.RemoteURL() != "https://gitlab.com/group/sub/project.git" {
+		t.Errorf("gitlab = %+v, %v", gl, err)
+	}
+	for _, bad := range [][3]string{
+		{"github", "octo", ""},
+		{"github", "octo/../x", ""},
+		{"gitea", "org/repo", ""},
+		{"bitbucket", "a/b", ""},
+		{"github", "a/b", "ftp://example.com"},
+		{"github", "a/b", "https://user:pw@example.com"},
+	} {
+		if _, err := NormalizeRepo(bad[0], bad[1], bad[2]); err == nil {
+			t.Errorf("NormalizeRepo%v: expected error", bad)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Package gitegress performs remote Git operations on behalf of sandboxes, which have no network access or Git
+// credentials: it fetches branches into git bundles and pushes branches from bundles with a stored provider
+// token. See docs/tech_specs/git_egress_mcp.md and project_git_repos.md.
+package gitegress
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Supported providers (project_git_repos.md RepoIdentifierFormat).
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+	ProviderGitea  = "gitea"
+)
+
+// defaultBaseURLs are the hosted instances used when a repo has no base_url; Gitea has none.
+var defaultBaseURLs = map[string]string{
+	ProviderGitHub: "https://github.com",
+	ProviderGitLab: "https://gitlab.com",
+}
+
+// repoSegment matches one owner, group, or repository path segment.
+var repoSegment = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,99}$`)
+
+// Repo identifies a repository on a provider. BaseURL is empty for the provider's hosted instance.
+type Repo struct {
+	Provider   string
+	Identifier string
+	BaseURL    string
+}
+
+// NormalizeRepo validates and canonicalizes a provider, repo identifier, and optional base URL: provider is
+// lower-cased, the identifier loses leading and trailing slashes and a ".git" suffix, and the base URL loses its
+// trailing slash. GitHub and Gitea identifiers are owner/repo; GitLab allows subgroups. Gitea requires a base URL.
+func NormalizeRepo(provider, identifier, baseURL string) (Repo, error) {
+	r := trimRepo(provider, identifier, baseURL)
+	segs := strings.Split(r.Identifier, "/")
+	switch r.Provider {
+	case ProviderGitHub, ProviderGitea:
+		if len(segs) != 2 {
+			return Repo{}, errors.New("repo must be owner/repo")
+		}
+	case ProviderGitLab:
+		if len(segs) < 2 {
+			return Repo{}, errors.New("repo must be a namespace/project path")
+		}
+	default:
+		return Repo{}, errors.New("unsupported provider " + r.Provider)
+	}
+	for _, s := range segs {
+		if !repoSegment.MatchString(s) {
+			return Repo{}, errors.New("invalid repo path segment " + s)
+		}
+	}
+	if r.Provider == ProviderGitea && r.BaseURL == "" {
+		return Repo{}, errors.New("base_url is required for gitea")
+	}
+	if r.BaseURL != "" {
+		u, err := url.Parse(r.BaseURL)
+		if err != nil || u.Host == "" || u.User != nil || u.RawQuery != "" || u.Fragment != "" ||
+			(u.Scheme != "https" && u.Scheme != "http") {
+			return Repo{}, errors.New("base_url must be an http(s) URL without credentials")
+		}
+	}
+	return r, nil
+}
+
+// Base returns the repo's base URL, or the provider's hosted instance.
+func (r Repo) Base() string {
+	if r.BaseURL != "" {
+		return r.BaseURL
+	}
+	return defaultBaseURLs[r.Provider]
+}
+
+// RemoteURL returns the HTTPS clone URL, {base}/{identifier}.git.
+func (r Repo) RemoteURL() string {
+	return r.Base() + "/" + r.Identifier + ".git"
+}
+
+// Resource returns the access control resource for the repo, host/identifier (e.g. github.com/octo/hello).
+func (r Repo) Resource() string {
+	host := r.Base()
+	if u, err := url.Parse(host); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	return strings.ToLower(host) + "/" + r.Identifier
+}
+
+// Matches reports whether r is the repo recorded as provider, identifier, and baseURL (a project_git_repos row).
+// Identifiers compare case-insensitively, as the providers resolve them; an empty base URL stands for the
+// provider's hosted instance.
+func (r Repo) Matches(provider, identifier, baseURL string) bool {
+	o := trimRepo(provider, identifier, baseURL)
+	return r.Provider == o.Provider && strings.EqualFold(r.Identifier, o.Identifier) && strings.EqualFold(r.Base(), o.Base())
+}
+
+// GitHubAPIURL returns the REST API root for a GitHub repo: api.github.com for github.com, {base}/api/v3 for
+// GitHub Enterprise Server.
+func (r Repo) GitHubAPIURL() string {
+	if r.BaseURL == "" || strings.EqualFold(r.BaseURL, defaultBaseURLs[ProviderGitHub]) {
+		return "https://api.github.com"
+	}
+	return r.BaseURL + "/api/v3"
+}
+
+// trimRepo applies NormalizeRepo's canonical form without validating.
+func trimRepo(provider, identifier, baseURL string) Repo {
+	return Repo{
+		Provider:   strings.ToLower(strings.TrimSpace(provider)),
+		Identifier: strings.TrimSuffix(strings.Trim(strings.TrimSpace(identifier), "/"), ".git"),
+		BaseURL:    strings.TrimRight(strings.TrimSpace(baseURL), "/"),
+	}
+}
@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/auth"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/rbac"
+)
+
+// Auth audit event types for personal access tokens and service accounts.
+const (
+	EventTokenCreate          = "token_create"
+	EventTokenRevoke          = "token_revoke"
+	EventServiceAccountCreate = "service_account_create"
+)
+
+// maxAccessTokenNameLen bounds a personal access token name.
+const maxAccessTokenNameLen = 128
+
+// AccessTokenHandler serves personal access tokens and service accounts (local_user_accounts.md).
+// Users manage their own tokens under /v1/users/me/tokens; holders of users.manage create service accounts and
+// manage their tokens under /v1/service-accounts. Tokens are stored as SHA-256 hashes and returned only once.
+type AccessTokenHandler struct {
+	db     database.Store
+	logger *slog.Logger
+}
+
+// NewAccessTokenHandler creates an access token handler.
+func NewAccessTokenHandler(db database.Store, logger *slog.Logger) *AccessTokenHandler {
+	return &AccessTokenHandler{db: db, logger: logger}
+}
+
+// tokenOwnerFunc returns the user whose tokens the request manages, or writes an error response and returns nil.
+type tokenOwnerFunc func(http.ResponseWriter, *http.Request) *models.User
+
+// ListMyTokens handles GET /v1/users/me/tokens.
+func (h *AccessTokenHandler) ListMyTokens(w http.ResponseWriter, r *http.Request) {
+	h.listTokens(w, r, h.caller)
+}
+
+// CreateMyToken handles POST /v1/users/me/tokens.
+func (h *AccessTokenHandler) CreateMyToken(w http.ResponseWriter, r *http.Request) {
+	h.createToken(w, r, h.caller)
+}
+
+// RevokeMyToken handles DELETE /v1/users/me/tokens/{token_id}.
+func (h *AccessTokenHandler) RevokeMyToken(w http.ResponseWriter, r *http.Request) {
+	h.revokeToken(w, r, h.caller)
+}
+
+// ListServiceAccountTokens handles GET /v1/service-accounts/{id}/tokens (users.manage).
+func (h *AccessTokenHandler) ListServiceAccountTokens(w http.ResponseWriter, r *http.Request) {
+	h.listTokens(w, r, h.serviceAccount)
+}
+
+// CreateServiceAccountToken handles POST /v1/service-accounts/{id}/tokens (users.manage).
+func (h *AccessTokenHandler) CreateServiceAccountToken(w http.ResponseWriter, r *http.Request) {
+	h.createToken(w, r, h.serviceAccount)
+}
+
+// RevokeServiceAccountToken handles DELETE /v1/service-accounts/{id}/tokens/{token_id} (users.manage).
+func (h *AccessTokenHandler) RevokeServiceAccountToken(w http.ResponseWriter, r *http.Request) {
+	h.revokeToken(w, r, h.serviceAccount)
+}
+
+// CreateServiceAccount handles POST /v1/service-accounts (users.manage). The account has no password and gets
+// the default role until bound to another one.
+func (h *AccessTokenHandler) CreateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	var req userapi.CreateServiceAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteBadRequest(w, "Invalid request body")
+		return
+	}
+	handle := strings.TrimSpace(req.Handle)
+	if !validUserHandle(handle) {
+		WriteBadRequest(w, "handle must be lowercase letters, digits, '.', '_' or '-' (at most 48 characters), and not system")
+		return
+	}
+	ctx := r.Context()
+	user, err := h.db.CreateServiceAccount(ctx, handle)
+	if err != nil {
+		if errors.Is(err, database.ErrExists) {
+			WriteConflict(w, "A user with this handle already exists")
+			return
+		}
+		h.logger.Error("create service account", "error", err)
+		WriteInternalError(w, "Failed to create service account")
+		return
+	}
+	h.audit(r, &user.ID, EventServiceAccountCreate, "service account "+user.Handle)
+	WriteJSON(w, http.StatusCreated, userToResponse(user))
+}
+
+// ListServiceAccounts handles GET /v1/service-accounts (users.manage).
+func (h *AccessTokenHandler) ListServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	list, err := h.db.ListServiceAccounts(r.Context())
+	if err != nil {
+		h.logger.Error("list service accounts", "error", err)
+		WriteInternalError(w, "Failed to list service accounts")
+		return
+	}
+	resp := userapi.ListServiceAccountsResponse{ServiceAccounts: make([]userapi.UserResponse, 0, len(list))}
+	for _, u := range list {
+		resp.ServiceAccounts = append(resp.ServiceAccounts, userToResponse(u))
+	}
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+// listTokens lists the owner's tokens; refused for requests authenticated with a personal access token.
+func (h *AccessTokenHandler) listTokens(w http.ResponseWriter, r *http.Request, owner tokenOwnerFunc) {
+	if refuseAccessTokenAuth(w, r, "list tokens") {
+		return
+	}
+	user := owner(w, r)
+	if user == nil {
+		return
+	}
+	list, err := h.db.ListPersonalAccessTokens(r.Context(), user.ID)
+	if err != nil {
+		h.logger.Error("list personal access tokens", "error", err, "user_id", user.ID)
+		WriteInternalError(w, "Failed to list tokens")
+		return
+	}
+	resp := userapi.ListAccessTokensResponse{Tokens: make([]userapi.AccessTokenResponse, 0, len(list))}
+	for _, t := range list {
+		resp.Tokens = append(resp.Tokens, accessTokenToResponse(t))
+	}
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+// refuseAccessTokenAuth writes 403 and returns true when the request was authenticated with a personal access
+// token. Tokens and sessions are managed only after a password or SSO sign-in, so a narrowly scoped token cannot
+// mint a broader one, and a leaked token can neither enumerate the owner's credentials nor revoke the owner's
+// other tokens and sessions. action completes "Personal access tokens cannot ...".
+func refuseAccessTokenAuth(w http.ResponseWriter, r *http.Request, action string) bool {
+	if GetTokenScopesFromContext(r.Context()) == nil {
+		return false
+	}
+	WriteForbidden(w, "Personal access tokens cannot "+action+"; sign in with a password or SSO")
+	return true
+}
+
+// createToken issues a token for the owner; refused for requests authenticated with a personal access token.
+func (h *AccessTokenHandler) createToken(w http.ResponseWriter, r *http.Request, owner tokenOwnerFunc) {
+	ctx := r.Context()
+	if refuseAccessTokenAuth(w, r, "create tokens") {
+		return
+	}
+	user := owner(w, r)
+	if user == nil {
+		return
+	}
+	var req userapi.CreateAccessTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteBadRequest(w, "Invalid request body")
+		return
+	}
+	pat, detail := accessTokenFromRequest(ctx, user.ID, &req)
+	if pat == nil {
+		WriteBadRequest(w, detail)
+		return
+	}
+	token, prefix, err := auth.GeneratePersonalAccessToken()
+	if err != nil {
+		h.logger.Error("generate personal access token", "error", err)
+		WriteInternalError(w, "Failed to create token")
+		return
+	}
+	pat.TokenHash, pat.TokenPrefix = auth.HashToken(token), prefix
+	if err := h.db.CreatePersonalAccessToken(ctx, pat); err != nil {
+		h.logger.Error("create personal access token", "error", err)
+		WriteInternalError(w, "Failed to create token")
+		return
+	}
+	h.audit(r, &user.ID, EventTokenCreate, "token "+pat.ID.String()+" ("+pat.Scopes+")")
+	WriteJSON(w, http.StatusCreated, userapi.CreateAccessTokenResponse{AccessTokenResponse: accessTokenToResponse(pat), Token: token})
+}
+
+// accessTokenFromRequest validates req and returns the new row without its hash, or nil and a problem detail.
+func accessTokenFromRequest(ctx context.Context, userID uuid.UUID, req *userapi.CreateAccessTokenRequest) (*models.PersonalAccessToken, string) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxAccessTokenNameLen {
+		return nil, "name is required (at most 128 characters)"
+	}
+	var scopes []string
+	for _, s := range req.Scopes {
+		if !rbac.ValidScope(s) {
+			return nil, "unknown scope " + s + "; valid scopes: " + strings.Join(rbac.Scopes(), ", ")
+		}
+		if !slices.Contains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, "at least one scope is required: " + strings.Join(rbac.Scopes(), ", ")
+	}
+	expiresAt, detail := parseCredentialExpiry(req.ExpiresAt)
+	if detail != "" {
+		return nil, detail
+	}
+	return &models.PersonalAccessToken{
+		UserID:    userID,
+		Name:      name,
+		Scopes:    strings.Join(scopes, " "),
+		IsActive:  true,
+		ExpiresAt: expiresAt,
+		CreatedBy: getUserIDFromContext(ctx),
+	}, ""
+}
+
+// revokeToken revokes one of the owner's tokens; refused for requests authenticated with a personal access token.
+func (h *AccessTokenHandler) revokeToken(w http.ResponseWriter, r *http.Request, owner tokenOwnerFunc) {
+	if refuseAccessTokenAuth(w, r, "revoke tokens") {
+		return
+	}
+	user := owner(w, r)
+	if user == nil {
+		return
+	}
+	id, err := uuid.Parse(r.PathValue("token_id"))
+	if err != nil {
+		WriteBadRequest(w, "Invalid token id")
+		return
+	}
+	ctx := r.Context()
+	pat, err := h.db.GetPersonalAccessTokenByID(ctx, id)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		h.logger.Error("get personal access token", "error", err, "token_id", id)
+		WriteInternalError(w, "Failed to get token")
+		return
+	}
+	if pat == nil || pat.UserID != user.ID {
+		WriteNotFound(w, "Token not found")
+		return
+	}
+	if err := h.db.RevokePersonalAccessToken(ctx, id); err != nil {
+		h.logger.Error("revoke personal access token", "error", err, "token_id", id)
+		WriteInternalError(w, "Failed to revoke token")
+		return
+	}
+	h.audit(r, &user.ID, EventTokenRevoke, "token "+id.String())
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// caller resolves the authenticated user as the token owner.
+func (h *AccessTokenHandler) caller(w http.ResponseWriter, r *http.Request) *models.User {
+	ctx := r.Context()
+	userID := getUserIDFromContext(ctx)
+	if userID == nil {
+		WriteUnauthorized(w, "Authentication required")
+		return nil
+	}
+	user, err := h.db.GetUserByID(ctx, *userID)
+	if err != nil {
+		h.logger.Error("get user", "error", err, "user_id", *userID)
+		WriteInternalError(w, "Failed to get user")
+		return nil
+	}
+	return user
+}
+
+// serviceAccount resolves the service account named by {id}; other users are reported as not found.
+func (h *AccessTokenHandler) serviceAccount(w http.ResponseWriter, r *http.Request) *models.User {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		WriteBadRequest(w, "Invalid service account id")
+		return nil
+	}
+	user, err := h.db.GetUserByID(r.Context(), id)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		h.logger.Error("get service account", "error", err, "user_id", id)
+		WriteInternalError(w, "Failed to get service account")
+		return nil
+	}
+	if user == nil || !user.IsServiceAccount {
+		WriteNotFound(w, "Service account not found")
+		return nil
+	}
+	return user
+}
+
+func (h *AccessTokenHandler) audit(r *http.Request, userID *uuid.UUID, eventType, reason string) {
+	writeAdminAudit(h.db, h.logger, r, userID, eventType, reason)
+}
+
+func accessTokenToResponse(t *models.PersonalAccessToken) userapi.AccessTokenResponse {
+	return userapi.AccessTokenResponse{
+		ID:          t.ID.String(),
+		UserID:      t.UserID.String(),
+		Name:        t.Name,
+		TokenPrefix: t.TokenPrefix,
+		Scopes:      strings.Fields(t.Scopes),
+		IsActive:    t.IsActive,
+		ExpiresAt:   formatOptionalTime(t.ExpiresAt),
+		LastUsedAt:  formatOptionalTime(t.LastUsedAt),
+		CreatedAt:   t.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func formatOptionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.UTC().Format(time.RFC3339)
+	return &s
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/auth"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
+)
+
+func TestAccessTokenHandler_CreateListRevoke(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	alice, _ := mockDB.CreateUser(context.Background(), "alice", nil)
+	h := NewAccessTokenHandler(mockDB, newTestLogger())
+
+	req, rec := credRequest(http.MethodPost, "/v1/users/me/tokens",
+		`{"name":"ci","scopes":["tasks:write","chat","chat"],"expires_at":"2999-01-01T00:00:00Z"}`, alice.ID, "alice")
+	h.CreateMyToken(rec, req)
+	assertStatusCode(t, rec, http.StatusCreated)
+	var created userapi.CreateAccessTokenResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if !auth.IsPersonalAccessToken(created.Token) || !strings.HasPrefix(created.Token, created.TokenPrefix) ||
+		strings.Join(created.Scopes, " ") != "tasks:write chat" || created.ExpiresAt == nil {
+		t.Errorf("created = %+v", created)
+	}
+	stored := mockDB.AccessTokens[0]
+	if string(stored.TokenHash) != string(auth.HashToken(created.Token)) || strings.Contains(string(stored.TokenHash), created.Token) {
+		t.Error("token must be stored as its hash only")
+	}
+	if len(mockDB.AuditLogs) != 1 || mockDB.AuditLogs[0].EventType != EventTokenCreate {
+		t.Errorf("audit = %+v", mockDB.AuditLogs)
+	}
+
+	req, rec = credRequest(http.MethodGet, "/v1/users/me/tokens", "", alice.ID, "alice")
+	h.ListMyTokens(rec, req)
+	assertStatusCode(t, rec, http.StatusOK)
+	if strings.Contains(rec.Body.String(), created.Token) || !strings.Contains(rec.Body.String(), created.ID) {
+		t.Errorf("list = %s", rec.Body.String())
+	}
+
+	// Another user cannot revoke alice's token.
+	bob, _ := mockDB.CreateUser(context.Background(), "bob", nil)
+	req, rec = credRequest(http.MethodDelete, "/v1/users/me/tokens/"+created.ID, "", bob.ID, "bob")
+	req.SetPathValue("token_id", created.ID)
+	h.RevokeMyToken(rec, req)
+	assertStatusCode(t, rec, http.StatusNotFound)
+	req, rec = credRequest(http.MethodDelete, "/v1/users/me/tokens/"+created.ID, "", alice.ID, "alice")
+	req.SetPathValue("token_id", created.ID)
+	h.RevokeMyToken(rec, req)
+	assertStatusCode(t, rec, http.StatusNoContent)
+	if stored.IsActive {
+		t.Error("token still active after revoke")
+	}
+}
+
+func TestAccessTokenHandler_CreateValidation(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	alice, _ := mockDB.CreateUser(context.Background(), "alice", nil)
+	h := NewAccessTokenHandler(mockDB, newTestLogger())
+	for name, body := range map[string]string{
+		"no scopes":     `{"name":"ci"}`,
+		"unknown scope": `{"name":"ci","scopes":["tasks.write"]}`,
+		"no name":       `{"scopes":["chat"]}`,
+		"past expiry":   `{"name":"ci","scopes":["chat"],"expires_at":"2000-01-01T00:00:00Z"}`,
+	} {
+		req, rec := credRequest(http.MethodPost, "/v1/users/me/tokens", body, alice.ID, "alice")
+		h.CreateMyToken(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d", name, rec.Code)
+		}
+	}
+
+	// A request authenticated with a personal access token cannot mint, list, or revoke tokens.
+	req, rec := credRequest(http.MethodPost, "/v1/users/me/tokens", `{"name":"ci","scopes":["all"]}`, alice.ID, "alice")
+	req = req.WithContext(SetTokenScopes(req.Context(), []string{"tasks:write"}))
+	h.CreateMyToken(rec, req)
+	assertStatusCode(t, rec, http.StatusForbidden)
+	req, rec = credRequest(http.MethodGet, "/v1/users/me/tokens", "", alice.ID, "alice")
+	req = req.WithContext(SetTokenScopes(req.Context(), []string{"all"}))
+	h.ListMyTokens(rec, req)
+	assertStatusCode(t, rec, http.StatusForbidden)
+	req, rec = credRequest(http.MethodDelete, "/v1/users/me/tokens/"+uuid.NewString(), "", alice.ID, "alice")
+	req = req.WithContext(SetTokenScopes(req.Context(), []string{"all"}))
+	h.RevokeMyToken(rec, req)
+	assertStatusCode(t, rec, http.StatusForbidden)
+}
+
+func TestAccessTokenHandler_ServiceAccounts(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	h := NewAccessTokenHandler(mockDB, newTestLogger())
+	admin := uuid.New()
+
+	req, rec := credRequest(http.MethodPost, "/v1/service-accounts", `{"handle":"ci-bot"}`, admin, "admin")
+	h.CreateServiceAccount(rec, req)
+	assertStatusCode(t, rec, http.StatusCreated)
+	var sa userapi.UserResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &sa); err != nil || !sa.IsServiceAccount {
+		t.Fatalf("service account = %+v, %v", sa, err)
+	}
+	for _, body := range []string{`{"handle":"ci-bot"}`, `{"handle":"system"}`, `{"handle":"CI Bot"}`} {
+		req, rec = credRequest(http.MethodPost, "/v1/service-accounts", body, admin, "admin")
+		h.CreateServiceAccount(rec, req)
+		if rec.Code != http.StatusConflict && rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d", body, rec.Code)
+		}
+	}
+
+	req, rec = credRequest(http.MethodPost, "/v1/service-accounts/"+sa.ID+"/tokens", `{"name":"deploy","scopes":["read-only"]}`, admin, "admin")
+	req.SetPathValue("id", sa.ID)
+	h.CreateServiceAccountToken(rec, req)
+	assertStatusCode(t, rec, http.StatusCreated)
+	if len(mockDB.AccessTokens) != 1 || mockDB.AccessTokens[0].UserID.String() != sa.ID || *mockDB.AccessTokens[0].CreatedBy != admin {
+		t.Errorf("tokens = %+v", mockDB.AccessTokens)
+	}
+
+	// Tokens of human users are not managed through the service account routes.
+	human, _ := mockDB.CreateUser(context.Background(), "alice", nil)
+	req, rec = credRequest(http.MethodGet, "/v1/service-accounts/"+human.ID.String()+"/tokens", "", admin, "admin")
+	req.SetPathValue("id", human.ID.String())
+	h.ListServiceAccountTokens(rec, req)
+	assertStatusCode(t, rec, http.StatusNotFound)
+}
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+// Audit list paging and export bounds.
+const (
+	DefaultAuditListLimit = 50
+	MaxAuditListLimit     = 200
+	MaxAuditExportEvents  = 100000
+)
+
+// Access control audit entry written for each audit export, so bulk reads of the audit log are themselves audited.
+const (
+	ActionAuditExport    = "audit.export"
+	ResourceTypeAuditLog = "audit_log"
+)
+
+// auditCSVHeader is the CSV export column order; it matches the JSON field names of AuditEventResponse.
+var auditCSVHeader = []string{
+	"event_id", "ts", "source", "actor_id", "actor_handle", "task_id", "project_id",
+	"action", "resource_type", "resource_id", "decision", "details",
+}
+
+// AuditHandler serves the unified audit log (auth, MCP tool call, access control, chat, and preference audit
+// tables). Every route requires audit.read, which only admins hold.
+type AuditHandler struct {
+	db     database.Store
+	logger *slog.Logger
+}
+
+// NewAuditHandler creates an audit handler.
+func NewAuditHandler(db database.Store, logger *slog.Logger) *AuditHandler {
+	return &AuditHandler{db: db, logger: logger}
+}
+
+// List handles GET /v1/audit. Filters: since, until (RFC 3339), actor_id, task_id, project_id, source, action
+// (event type; a trailing "*" matches by prefix), resource_type, decision. Paging: limit (1-200, default 50) and
+// cursor. With format=jsonl or format=csv it streams every matching event as a download instead of one page.
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f, detail := auditFilterFromQuery(q)
+	if detail != "" {
+		WriteBadRequest(w, detail)
+		return
+	}
+	switch format := q.Get("format"); format {
+	case "", "json":
+	case userapi.AuditFormatJSONL, userapi.AuditFormatCSV:
+		h.export(w, r, f, format)
+		return
+	default:
+		WriteBadRequest(w, "format must be json, jsonl, or csv")
+		return
+	}
+	events, next, err := h.db.SearchAuditEvents(r.Context(), f)
+	if err != nil {
+		h.logger.Error("search audit events", "error", err)
+		WriteInternalError(w, "Failed to search audit events")
+		return
+	}
+	resp := userapi.ListAuditEventsResponse{Events: make([]userapi.AuditEventResponse, 0, len(events)), NextCursor: next}
+	for _, e := range events {
+		resp.Events = append(resp.Events, auditEventToResponse(e))
+	}
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+// Get handles GET /v1/audit/{id}.
+func (h *AuditHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		WriteBadRequest(w, "Invalid event id")
+		return
+	}
+	events, _, err := h.db.SearchAuditEvents(r.Context(), database.AuditEventFilter{EventID: &id, Limit: 1})
+	if err != nil {
+		h.logger.Error("get audit event", "error", err, "event_id", id)
+		WriteInternalError(w, "Failed to get audit event")
+		return
+	}
+	if len(events) == 0 {
+		WriteNotFound(w, "Audit event not found")
+		return
+	}
+	WriteJSON(w, http.StatusOK, auditEventToResponse(events[0]))
+}
+
+// export streams matching events page by page as JSONL or CSV, up to MaxAuditExportEvents, following the keyset
+// cursor so later pages cost no more than the first. Once the body has started, a failure can only end the
+// stream early, so it is logged.
+func (h *AuditHandler) export(w http.ResponseWriter, r *http.Request, f database.AuditEventFilter, format string) {
+	ctx := r.Context()
+	h.auditExport(r)
+	contentType, emit, flush := auditEncoder(w, format)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="audit.`+format+`"`)
+	w.WriteHeader(http.StatusOK)
+	f.Limit, f.Cursor = database.MaxAuditEventLimit, ""
+	for written := 0; written < MaxAuditExportEvents; {
+		events, next, err := h.db.SearchAuditEvents(ctx, f)
+		if err != nil {
+			h.logger.Error("export audit events", "error", err, "written", written)
+			break
+		}
+		for _, e := range events {
+			if written == MaxAuditExportEvents {
+				break
+			}
+			if err := emit(auditEventToResponse(e)); err != nil {
+				h.logger.Warn("export audit events: write", "error", err)
+				return
+			}
+			written++
+		}
+		if next == "" {
+			break
+		}
+		f.Cursor = next
+	}
+	flush()
+}
+
+// auditEncoder returns the content type and the per-event and final writers for an export format.
+func auditEncoder(w http.ResponseWriter, format string) (contentType string, emit func(userapi.AuditEventResponse) error, flush func()) {
+	if format == userapi.AuditFormatJSONL {
+		enc := json.NewEncoder(w)
+		return "application/x-ndjson", func(e userapi.AuditEventResponse) error { return enc.Encode(e) }, func() {}
+	}
+	cw := csv.NewWriter(w)
+	headerWritten := false
+	emit = func(e userapi.AuditEventResponse) error {
+		if !headerWritten {
+			headerWritten = true
+			if err := cw.Write(auditCSVHeader); err != nil {
+				return err
+			}
+		}
+		return cw.Write([]string{
+			e.EventID, e.Timestamp, e.Source, derefString(e.ActorID), derefString(e.ActorHandle), derefString(e.TaskID),
+			derefString(e.ProjectID), e.Action, e.ResourceType, e.ResourceID, e.Decision, derefString(e.Details),
+		})
+	}
+	flush = func() {
+		if !headerWritten {
+			_ = cw.Write(auditCSVHeader)
+		}
+		cw.Flush()
+	}
+	return "text/csv; charset=utf-8", emit, flush
+}
+
+// auditExport records the export and its query in access_control_audit_log.
+func (h *AuditHandler) auditExport(r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.RawQuery
+	rec := &models.AccessControlAuditLog{
+		SubjectType:  database.SubjectTypeUser,
+		SubjectID:    getUserIDFromContext(ctx),
+		Action:       ActionAuditExport,
+		ResourceType: ResourceTypeAuditLog,
+		Resource:     "/v1/audit",
+		Decision:     "allow",
+		Reason:       &query,
+	}
+	if err := h.db.CreateAccessControlAuditLog(ctx, rec); err != nil {
+		h.logger.Warn("audit export audit log failed", "error", err)
+	}
+}
+
+// auditFilterFromQuery parses the List query parameters; returns a problem detail when one is invalid.
+func auditFilterFromQuery(q url.Values) (database.AuditEventFilter, string) {
+	get := q.Get
+	f := database.AuditEventFilter{
+		Source: get("source"), Action: get("action"), ResourceType: get("resource_type"), Decision: get("decision"),
+		Limit: DefaultAuditListLimit, Cursor: get("cursor"),
+	}
+	if f.Source != "" && !slices.Contains(database.AuditSources, f.Source) {
+		return f, "source must be one of auth, mcp_tool_call, access_control, chat, preference"
+	}
+	if f.Decision != "" && f.Decision != "allow" && f.Decision != "deny" {
+		return f, "decision must be allow or deny"
+	}
+	if s := get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > MaxAuditListLimit {
+			return f, "limit must be between 1 and 200"
+		}
+		f.Limit = n
+	}
+	if s := get("cursor"); s != "" {
+		if _, _, err := database.ParseAuditCursor(s); err != nil {
+			return f, "Invalid cursor"
+		}
+	}
+	for _, t := range []struct {
+		key string
+		dst **time.Time
+	}{{"since", &f.Since}, {"until", &f.Until}} {
+		if s := get(t.key); s != "" {
+			v, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return f, t.key + " must be RFC 3339"
+			}
+			*t.dst = &v
+		}
+	}
+	for _, u := range []struct {
+		key string
+		dst **uuid.UUID
+	}{{"actor_id", &f.ActorID}, {"task_id", &f.TaskID}, {"project_id", &f.ProjectID}} {
+		if s := get(u.key); s != "" {
+			id, err := uuid.Parse(s)
+			if err != nil {
+				return f, "Invalid " + u.key
+			}
+			*u.dst = &id
+		}
+	}
+	return f, ""
+}
+
+func auditEventToResponse(e *models.AuditEvent) userapi.AuditEventResponse {
+	return userapi.AuditEventResponse{
+		EventID:      e.ID.String(),
+		Timestamp:    e.CreatedAt.UTC().Format(time.RFC3339),
+		Source:       e.Source,
+		ActorID:      uuidString(e.ActorID),
+		ActorHandle:  e.ActorHandle,
+		TaskID:       uuidString(e.TaskID),
+		ProjectID:    uuidString(e.ProjectID),
+		Action:       e.Action,
+		ResourceType: e.ResourceType,
+		ResourceID:   e.ResourceID,
+		Decision:     e.Decision,
+		Details:      e.Details,
+	}
+}
+
+// uuidString formats an optional id; nil stays nil.
+func uuidString(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
+)
+
+func seedAuditEvents(mockDB *testutil.MockDB, actor, task uuid.UUID) []*models.AuditEvent {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	reason := "bad password"
+	events := []*models.AuditEvent{
+		{ID: uuid.New(), Source: database.AuditSourceAuth, CreatedAt: base, ActorID: &actor, Action: "auth.login_failure",
+			ResourceType: "user", ResourceID: actor.String(), Decision: "deny", Details: &reason},
+		{ID: uuid.New(), Source: database.AuditSourceMcpToolCall, CreatedAt: base.Add(time.Hour), ActorID: &actor, TaskID: &task,
+			Action: "mcp.tool.invoke", ResourceType: "mcp.tool", ResourceID: "db.preference.get", Decision: "allow"},
+		{ID: uuid.New(), Source: database.AuditSourceAccessControl, CreatedAt: base.Add(2 * time.Hour), TaskID: &task,
+			Action: "api.call", ResourceType: "api.provider_operation", ResourceID: "openai/chat", Decision: "deny"},
+	}
+	mockDB.AuditEvents = append(mockDB.AuditEvents, events...)
+	return events
+}
+
+func listAudit(t *testing.T, h *AuditHandler, query string, want int) userapi.ListAuditEventsResponse {
+	t.Helper()
+	req, rec := credRequest(http.MethodGet, "/v1/audit?"+query, "", uuid.New(), "admin")
+	h.List(rec, req)
+	assertStatusCode(t, rec, want)
+	var resp userapi.ListAuditEventsResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return resp
+}
+
+func TestAuditHandler_ListFiltersAndPaging(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	h := NewAuditHandler(mockDB, newTestLogger())
+	actor, task := uuid.New(), uuid.New()
+	events := seedAuditEvents(mockDB, actor, task)
+
+	all := listAudit(t, h, "", http.StatusOK)
+	if len(all.Events) != 3 || all.Events[0].EventID != events[2].ID.String() || all.NextCursor != "" {
+		t.Errorf("all = %+v", all)
+	}
+	page := listAudit(t, h, "limit=2", http.StatusOK)
+	if len(page.Events) != 2 || page.NextCursor == "" {
+		t.Fatalf("page 1 = %+v", page)
+	}
+	page = listAudit(t, h, "limit=2&cursor="+page.NextCursor, http.StatusOK)
+	if len(page.Events) != 1 || page.Events[0].Action != "auth.login_failure" || page.NextCursor != "" {
+		t.Errorf("page 2 = %+v", page)
+	}
+
+	for query, n := range map[string]int{
+		"actor_id=" + actor.String():                    2,
+		"task_id=" + task.String():                      2,
+		"decision=deny":                                 2,
+		"source=mcp_tool_call":                          1,
+		"action=auth.*":                                 1,
+		"resource_type=api.provider_operation":          1,
+		"since=2026-03-01T13:00:00Z":                    2,
+		"until=2026-03-01T13:00:00Z":                    1,
+		"project_id=" + uuid.NewString():                0,
+		"decision=deny&task_id=" + task.String():        1,
+		"since=2026-03-01T12:30:00Z&action=api.call":    1,
+		"since=2026-03-01T12:30:00Z&decision=allow":     1,
+		"until=2026-03-01T12:00:00Z&source=auth":        0,
+		"actor_id=" + actor.String() + "&decision=deny": 1,
+	} {
+		if got := listAudit(t, h, query, http.StatusOK); len(got.Events) != n {
+			t.Errorf("%s: got %d events, want %d", query, len(got.Events), n)
+		}
+	}
+	for _, query := range []string{
+		"limit=0", "limit=201", "cursor=-1", "decision=maybe", "source=billing", "since=yesterday",
+		"actor_id=nope", "format=xml",
+	} {
+		listAudit(t, h, query, http.StatusBadRequest)
+	}
+}
+
+func TestAuditHandler_Export(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	h := NewAuditHandler(mockDB, newTestLogger())
+	seedAuditEvents(mockDB, uuid.New(), uuid.New())
+
+	req, rec := credRequest(http.MethodGet, "/v1/audit?format=jsonl&decision=deny&limit=1", "", uuid.New(), "admin")
+	h.List(rec, req)
+	assertStatusCode(t, rec, http.StatusOK)
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 2 { // export ignores limit and returns every match
+		t.Fatalf("jsonl = %q", rec.Body.String())
+	}
+	var e userapi.AuditEventResponse
+	if err := json.Unmarshal([]byte(lines[1]), &e); err != nil || e.Details == nil || *e.Details != "bad password" {
+		t.Errorf("line = %q (%v)", lines[1], err)
+	}
+
+	req, rec = credRequest(http.MethodGet, "/v1/audit?format=csv", "", uuid.New(), "admin")
+	h.List(rec, req)
+	assertStatusCode(t, rec, http.StatusOK)
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil || len(rows) != 4 || strings.Join(rows[0], ",") != strings.Join(auditCSVHeader, ",") {
+		t.Fatalf("csv = %v (%v)", rows, err)
+	}
+	if rows[3][7] != "auth.login_failure" || rows[3][11] != "bad password" {
+		t.Errorf("last row = %v", rows[3])
+	}
+
+	req, rec = credRequest(http.MethodGet, "/v1/audit?format=csv&source=chat", "", uuid.New(), "admin")
+	h.List(rec, req)
+	if body := strings.TrimSpace(rec.Body.String()); body != strings.Join(auditCSVHeader, ",") {
+		t.Errorf("empty csv = %q", body)
+	}
+}
+
+// TestAuditHandler_ExportPagesByKeyset exports more than one page of events, many sharing a timestamp, and
+// expects each exactly once in (ts, event_id) order.
+func TestAuditHandler_ExportPagesByKeyset(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	h := NewAuditHandler(mockDB, newTestLogger())
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	const n = 2*database.MaxAuditEventLimit + 7
+	for i := range n {
+		mockDB.AuditEvents = append(mockDB.AuditEvents, &models.AuditEvent{ID: uuid.New(), Source: database.AuditSourceAuth,
+			CreatedAt: base.Add(time.Duration(i/10) * time.Second), Action: "auth.login_success", Decision: "allow"})
+	}
+
+	// A cursor is ignored by the export, as is limit.
+	cursor := database.AuditCursor(mockDB.AuditEvents[n-1])
+	req, rec := credRequest(http.MethodGet, "/v1/audit?format=jsonl&cursor="+cursor, "", uuid.New(), "admin")
+	h.List(rec, req)
+	assertStatusCode(t, rec, http.StatusOK)
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != n {
+		t.Fatalf("exported %d events, want %d", len(lines), n)
+	}
+	seen := make(map[string]bool, n)
+	prev := userapi.AuditEventResponse{Timestamp: "9999"}
+	for _, line := range lines {
+		var e userapi.AuditEventResponse
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("line %q: %v", line, err)
+		}
+		if seen[e.EventID] {
+			t.Fatalf("event %s exported twice", e.EventID)
+		}
+		seen[e.EventID] = true
+		if e.Timestamp > prev.Timestamp || (e.Timestamp == prev.Timestamp && e.EventID > prev.EventID) {
+			t.Fatalf("event %s out of order after %s", e.EventID, prev.EventID)
+		}
+		prev = e
+	}
+}
+
+func TestAuditHandler_Get(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	h := NewAuditHandler(mockDB, newTestLogger())
+	events := seedAuditEvents(mockDB, uuid.New(), uuid.New())
+
+	get := func(id string, want int) userapi.AuditEventResponse {
+		t.Helper()
+		req, rec := credRequest(http.MethodGet, "/v1/audit/"+id, "", uuid.New(), "admin")
+		req.SetPathValue("id", id)
+		h.Get(rec, req)
+		assertStatusCode(t, rec, want)
+		var resp userapi.AuditEventResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		return resp
+	}
+	if got := get(events[1].ID.String(), http.StatusOK); got.Source != database.AuditSourceMcpToolCall || got.TaskID == nil {
+		t.Errorf("event = %+v", got)
+	}
+	get(uuid.NewString(), http.StatusNotFound)
+	get("nope", http.StatusBadRequest)
+}
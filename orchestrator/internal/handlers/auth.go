@@ -10,6 +10,7 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/problem"
 	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/auth"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
@@ -22,6 +23,7 @@ type AuthHandler struct {
 	jwt         *auth.JWTManager
 	rateLimiter *auth.RateLimiter
 	logger      *slog.Logger
+	sso         *SSOConfig
 }
 
 // NewAuthHandler creates a new auth handler.
@@ -37,6 +39,7 @@ func NewAuthHandler(db database.Store, jwt *auth.JWTManager, rateLimiter *auth.R
 // loginResult contains the result of login validation.
 type loginResult struct {
 	user        *models.User
+	cred        *models.PasswordCredential
 	errResponse func()
 }
 
@@ -76,7 +79,7 @@ func (h *AuthHandler) validateLoginCredentials(ctx context.Context, req userapi.
 		return &loginResult{errResponse: func() {}}
 	}
 
-	return &loginResult{user: user}
+	return &loginResult{user: user, cred: cred}
 }
 
 // Login handles POST /v1/auth/login.
@@ -116,45 +119,102 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		WriteUnauthorized(w, "Invalid credentials")
 		return
 	}
+	if !h.applyPasswordChange(w, ctx, &req, result, ipAddr, r.UserAgent()) {
+		return
+	}
 
-	h.completeLogin(w, ctx, result.user, ipAddr, r.UserAgent())
+	h.completeLogin(w, ctx, result.user, ipAddr, r.UserAgent(), req.ClientName, "")
 }
 
-// completeLogin generates tokens and creates session.
-func (h *AuthHandler) completeLogin(w http.ResponseWriter, ctx context.Context, user *models.User, ipAddr, userAgent string) {
-	accessToken, err := h.jwt.GenerateAccessToken(user.ID, user.Handle)
+// applyPasswordChange sets req.NewPassword when given, once the current password is verified. Without one, an
+// account that must change its password gets 403 password_change_required. Returns false when a response was
+// written.
+func (h *AuthHandler) applyPasswordChange(w http.ResponseWriter, ctx context.Context, req *userapi.LoginRequest, result *loginResult, ipAddr, userAgent string) bool {
+	user := result.user
+	if req.NewPassword == "" {
+		if !result.cred.MustChange {
+			return true
+		}
+		h.auditLog(ctx, &user.ID, "login_failure", false, ipAddr, userAgent, "password change required")
+		WriteError(w, http.StatusForbidden, problem.TypePasswordChangeRequired, "Password Change Required",
+			"A new password is required; log in again with new_password")
+		return false
+	}
+	if detail := passwordPolicyError(req.NewPassword); detail != "" {
+		WriteBadRequest(w, "new_password: "+detail)
+		return false
+	}
+	if req.NewPassword == req.Password {
+		WriteBadRequest(w, "new_password must differ from the current password")
+		return false
+	}
+	hash, err := auth.HashPassword(req.NewPassword, nil)
+	if err == nil {
+		err = h.db.SetPassword(ctx, user.ID, hash, passwordHashAlg, false)
+	}
 	if err != nil {
-		h.logger.Error("generate access token", "error", err)
-		WriteInternalError(w, "Failed to generate token")
-		return
+		h.logger.Error("change password", "error", err, "user_id", user.ID)
+		WriteInternalError(w, "Failed to change password")
+		return false
 	}
+	h.auditLog(ctx, &user.ID, EventPasswordChange, true, ipAddr, userAgent, "")
+	return true
+}
 
-	refreshToken, expiresAt, err := h.jwt.GenerateRefreshToken(user.ID)
-	if err != nil {
-		h.logger.Error("generate refresh token", "error", err)
-		WriteInternalError(w, "Failed to generate token")
+// completeLogin starts a session for user and returns its tokens. The session records the client's address,
+// user agent, and self-reported name. details is recorded on the login_success audit event (e.g. "sso" for
+// identity provider sign-ins).
+func (h *AuthHandler) completeLogin(w http.ResponseWriter, ctx context.Context, user *models.User, ipAddr, userAgent, clientName, details string) {
+	session := &models.RefreshSession{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		UserAgent:  optionalString(userAgent),
+		IPAddress:  optionalString(ipAddr),
+		ClientName: optionalString(truncateRunes(strings.TrimSpace(clientName), maxClientNameLen)),
+	}
+	resp := h.issueSessionTokens(w, user, session)
+	if resp == nil {
 		return
 	}
-
-	tokenHash := auth.HashToken(refreshToken)
-	_, err = h.db.CreateRefreshSession(ctx, user.ID, tokenHash, expiresAt)
-	if err != nil {
+	if err := h.db.CreateRefreshSession(ctx, session); err != nil {
 		h.logger.Error("create refresh session", "error", err)
 		WriteInternalError(w, "Failed to create session")
 		return
 	}
 
-	h.auditLog(ctx, &user.ID, "login_success", true, ipAddr, userAgent, "")
+	h.auditLog(ctx, &user.ID, "login_success", true, ipAddr, userAgent, details)
+
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+// issueSessionTokens signs a new access and refresh token for session and records the refresh token hash and both
+// expiries on it; the caller stores the session. Writes an error response and returns nil on failure.
+func (h *AuthHandler) issueSessionTokens(w http.ResponseWriter, user *models.User, session *models.RefreshSession) *userapi.LoginResponse {
+	accessToken, accessExpiresAt, err := h.jwt.GenerateSessionAccessToken(user.ID, user.Handle, session.ID)
+	if err != nil {
+		h.logger.Error("generate access token", "error", err)
+		WriteInternalError(w, "Failed to generate token")
+		return nil
+	}
 
-	WriteJSON(w, http.StatusOK, userapi.LoginResponse{
+	refreshToken, expiresAt, err := h.jwt.GenerateRefreshToken(user.ID)
+	if err != nil {
+		h.logger.Error("generate refresh token", "error", err)
+		WriteInternalError(w, "Failed to generate token")
+		return nil
+	}
+
+	session.RefreshTokenHash, session.ExpiresAt, session.AccessExpiresAt = auth.HashToken(refreshToken), expiresAt, &accessExpiresAt
+	return &userapi.LoginResponse{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
 		TokenType:    "Bearer",
 		ExpiresIn:    900, // 15 minutes
-	})
+	}
 }
 
-// Refresh handles POST /v1/auth/refresh.
+// Refresh handles POST /v1/auth/refresh. The refresh token is rotated within its session, so the session keeps
+// its id; a refresh token that was already rotated is refused.
 func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	ipAddr := getClientIP(r)
@@ -198,13 +258,6 @@ func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Invalidate old session (token rotation)
-	if err := h.db.InvalidateRefreshSession(ctx, session.ID); err != nil {
-		h.logger.Error("invalidate refresh session", "error", err)
-		WriteInternalError(w, "Failed to refresh token")
-		return
-	}
-
 	// Get user
 	user, err := h.db.GetUserByID(ctx, userID)
 	if err != nil {
@@ -218,38 +271,30 @@ func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Generate new tokens
-	accessToken, err := h.jwt.GenerateAccessToken(user.ID, user.Handle)
-	if err != nil {
-		h.logger.Error("generate access token", "error", err)
-		WriteInternalError(w, "Failed to generate token")
+	resp := h.issueSessionTokens(w, user, session)
+	if resp == nil {
 		return
 	}
-
-	newRefreshToken, expiresAt, err := h.jwt.GenerateRefreshToken(user.ID)
-	if err != nil {
-		h.logger.Error("generate refresh token", "error", err)
-		WriteInternalError(w, "Failed to generate token")
-		return
+	if ua := r.UserAgent(); ua != "" {
+		session.UserAgent = &ua
 	}
-
-	// Store new refresh session
-	newTokenHash := auth.HashToken(newRefreshToken)
-	_, err = h.db.CreateRefreshSession(ctx, user.ID, newTokenHash, expiresAt)
-	if err != nil {
-		h.logger.Error("create refresh session", "error", err)
-		WriteInternalError(w, "Failed to create session")
+	if ipAddr != "" {
+		session.IPAddress = &ipAddr
+	}
+	if err := h.db.RotateRefreshSession(ctx, session, tokenHash); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			h.auditLog(ctx, &userID, "refresh_failure", false, ipAddr, r.UserAgent(), "refresh token already rotated")
+			WriteUnauthorized(w, "Invalid refresh token")
+			return
+		}
+		h.logger.Error("rotate refresh session", "error", err)
+		WriteInternalError(w, "Failed to refresh token")
 		return
 	}
 
 	h.auditLog(ctx, &userID, "refresh_success", true, ipAddr, r.UserAgent(), "")
 
-	WriteJSON(w, http.StatusOK, userapi.LoginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: newRefreshToken,
-		TokenType:    "Bearer",
-		ExpiresIn:    900,
-	})
+	WriteJSON(w, http.StatusOK, resp)
 }
 
 // Logout handles POST /v1/auth/logout.
@@ -264,12 +309,18 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// End the session of the refresh token, or else the session of the access token; the session's access
+	// tokens are denied from now on.
+	sessionID := getSessionIDFromContext(ctx)
 	if req.RefreshToken != "" {
-		// Invalidate specific session
 		tokenHash := auth.HashToken(req.RefreshToken)
-		session, err := h.db.GetActiveRefreshSession(ctx, tokenHash)
-		if err == nil {
-			_ = h.db.InvalidateRefreshSession(ctx, session.ID)
+		if session, err := h.db.GetActiveRefreshSession(ctx, tokenHash); err == nil {
+			sessionID = &session.ID
+		}
+	}
+	if sessionID != nil {
+		if err := h.db.InvalidateRefreshSession(ctx, *sessionID); err != nil && h.logger != nil {
+			h.logger.Error("invalidate refresh session", "error", err, "session_id", *sessionID)
 		}
 	}
 
@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/problem"
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/oidc"
+)
+
+// SSOConfig enables OpenID Connect single sign-on on AuthHandler.
+type SSOConfig struct {
+	Provider *oidc.Provider
+	// AutoProvision creates a user on first sign-in. When false only users already linked to the identity
+	// provider (users.external_source and external_id) can sign in.
+	AutoProvision bool
+	// GroupMap maps identity provider group names to group slugs; only mapped names are synced, so an IdP group
+	// cannot grant membership of a CyNodeAI group (e.g. an admin group) just by sharing its slug. When empty,
+	// group memberships are not synced.
+	GroupMap map[string]string
+}
+
+// SetSSO enables the /v1/auth/sso endpoints; without it they return 404.
+func (h *AuthHandler) SetSSO(sso *SSOConfig) {
+	h.sso = sso
+}
+
+const (
+	ssoCookieName   = "cynodeai_sso"
+	ssoCookiePath   = "/v1/auth/sso"
+	ssoCookieTTL    = 10 * time.Minute
+	ssoAuditDetails = "sso"
+	// defaultDeviceInterval is the polling interval when the identity provider does not send one (RFC 8628).
+	defaultDeviceInterval = 5
+)
+
+var errSSONotLinked = errors.New("no user linked to this identity")
+
+// ssoFlow is the per-browser state of an authorization code flow, kept in the SSO cookie.
+type ssoFlow struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+// SSOLogin handles GET /v1/auth/sso/login. It starts an authorization code flow with PKCE by redirecting to the
+// identity provider. State, nonce, and code verifier travel in a short-lived HttpOnly cookie scoped to the SSO
+// endpoints, so any gateway replica can complete the flow.
+func (h *AuthHandler) SSOLogin(w http.ResponseWriter, r *http.Request) {
+	if !h.ssoEnabled(w) {
+		return
+	}
+	flow := ssoFlow{State: oidc.RandomToken(), Nonce: oidc.RandomToken(), Verifier: oidc.RandomToken()}
+	authURL, err := h.sso.Provider.AuthCodeURL(r.Context(), flow.State, flow.Nonce, flow.Verifier)
+	if err != nil {
+		h.logger.Error("sso authorization url", "error", err)
+		writeIdPUnavailable(w)
+		return
+	}
+	raw, _ := json.Marshal(flow)
+	h.setSSOCookie(w, r, base64.RawURLEncoding.EncodeToString(raw), int(ssoCookieTTL.Seconds()))
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// SSOCallback handles GET /v1/auth/sso/callback, the redirect target registered with the identity provider. It
+// checks state against the SSO cookie, redeems the code with the PKCE verifier, and returns gateway tokens.
+func (h *AuthHandler) SSOCallback(w http.ResponseWriter, r *http.Request) {
+	if !h.ssoEnabled(w) {
+		return
+	}
+	ctx, ipAddr := r.Context(), getClientIP(r)
+	if !h.rateLimiter.Allow(ipAddr) {
+		h.auditLog(ctx, nil, "login_failure", false, ipAddr, r.UserAgent(), "rate limited")
+		WriteTooManyRequests(w, "Too many login attempts")
+		return
+	}
+	flow, ok := readSSOFlow(r)
+	h.setSSOCookie(w, r, "", -1)
+	q := r.URL.Query()
+	if !ok || subtle.ConstantTimeCompare([]byte(q.Get("state")), []byte(flow.State)) != 1 {
+		h.auditLog(ctx, nil, "login_failure", false, ipAddr, r.UserAgent(), "sso state mismatch")
+		WriteBadRequest(w, "Sign-in state is missing or expired; start again")
+		return
+	}
+	if providerErr := q.Get("error"); providerErr != "" {
+		h.auditLog(ctx, nil, "login_failure", false, ipAddr, r.UserAgent(), "sso provider error: "+providerErr)
+		WriteUnauthorized(w, "Sign-in was not completed at the identity provider")
+		return
+	}
+	id, err := h.sso.Provider.Exchange(ctx, q.Get("code"), flow.Verifier, flow.Nonce)
+	if err != nil {
+		h.ssoFailure(w, r, err)
+		return
+	}
+	h.completeSSOLogin(w, r, id, "")
+}
+
+// SSODeviceStart handles POST /v1/auth/sso/device. It starts a device authorization grant at the identity
+// provider for terminal clients (cynork auth login --sso).
+func (h *AuthHandler) SSODeviceStart(w http.ResponseWriter, r *http.Request) {
+	if !h.ssoEnabled(w) {
+		return
+	}
+	if !h.rateLimiter.Allow(getClientIP(r)) {
+		WriteTooManyRequests(w, "Too many login attempts")
+		return
+	}
+	da, err := h.sso.Provider.StartDevice(r.Context())
+	if errors.Is(err, oidc.ErrDeviceFlowUnsupported) {
+		WriteError(w, http.StatusNotImplemented, problem.TypeValidation, "Not Implemented", err.Error())
+		return
+	}
+	if err != nil {
+		h.logger.Error("sso device authorization", "error", err)
+		writeIdPUnavailable(w)
+		return
+	}
+	if da.Interval <= 0 {
+		da.Interval = defaultDeviceInterval
+	}
+	WriteJSON(w, http.StatusOK, userapi.SSODeviceAuthorizationResponse{
+		DeviceCode:              da.DeviceCode,
+		UserCode:                da.UserCode,
+		VerificationURI:         da.VerificationURI,
+		VerificationURIComplete: da.VerificationURIComplete,
+		ExpiresIn:               da.ExpiresIn,
+		Interval:                da.Interval,
+	})
+}
+
+// SSODeviceToken handles POST /v1/auth/sso/device/token. It polls the identity provider once: 202 while the user
+// has not finished signing in, gateway tokens once they have.
+func (h *AuthHandler) SSODeviceToken(w http.ResponseWriter, r *http.Request) {
+	if !h.ssoEnabled(w) {
+		return
+	}
+	var req userapi.SSODeviceTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.DeviceCode == "" {
+		WriteBadRequest(w, "device_code is required")
+		return
+	}
+	id, err := h.sso.Provider.PollDevice(r.Context(), req.DeviceCode)
+	switch {
+	case errors.Is(err, oidc.ErrAuthorizationPending):
+		WriteJSON(w, http.StatusAccepted, userapi.SSODevicePendingResponse{Status: userapi.SSODeviceStatusPending})
+	case errors.Is(err, oidc.ErrSlowDown):
+		WriteJSON(w, http.StatusAccepted, userapi.SSODevicePendingResponse{Status: userapi.SSODeviceStatusSlowDown})
+	case errors.Is(err, oidc.ErrAccessDenied), errors.Is(err, oidc.ErrExpiredToken):
+		h.auditLog(r.Context(), nil, "login_failure", false, getClientIP(r), r.UserAgent(), "sso device "+err.Error())
+		WriteUnauthorized(w, "Device sign-in was denied or has expired")
+	case err != nil:
+		h.ssoFailure(w, r, err)
+	default:
+		h.completeSSOLogin(w, r, id, req.ClientName)
+	}
+}
+
+// completeSSOLogin maps a verified identity to a user, syncs group memberships from the groups claim, and issues
+// gateway tokens the same way password login does. clientName labels the session.
+func (h *AuthHandler) completeSSOLogin(w http.ResponseWriter, r *http.Request, id *oidc.Identity, clientName string) {
+	ctx, ipAddr, userAgent := r.Context(), getClientIP(r), r.UserAgent()
+	user, created, err := h.ssoUser(ctx, id)
+	if errors.Is(err, errSSONotLinked) {
+		h.auditLog(ctx, nil, "login_failure", false, ipAddr, userAgent, "sso identity not linked")
+		WriteForbidden(w, "No account is linked to this identity")
+		return
+	}
+	if err != nil {
+		h.logger.Error("sso user", "error", err)
+		WriteInternalError(w, "Failed to authenticate")
+		return
+	}
+	if created {
+		h.auditLog(ctx, &user.ID, "user_created", true, ipAddr, userAgent, ssoAuditDetails)
+	}
+	if !user.IsActive {
+		h.auditLog(ctx, &user.ID, "login_failure", false, ipAddr, userAgent, "user inactive")
+		WriteUnauthorized(w, "Account is disabled")
+		return
+	}
+	if err := h.syncSSOGroups(ctx, user.ID, id.Groups); err != nil {
+		h.logger.Error("sso group sync", "error", err)
+		WriteInternalError(w, "Failed to authenticate")
+		return
+	}
+	h.completeLogin(w, ctx, user, ipAddr, userAgent, clientName, ssoAuditDetails)
+}
+
+// ssoUser returns the user linked to id (issuer and subject), creating one when AutoProvision is set. Accounts
+// are linked only by subject, never by email or handle.
+func (h *AuthHandler) ssoUser(ctx context.Context, id *oidc.Identity) (*models.User, bool, error) {
+	source := h.sso.Provider.Issuer()
+	user, err := h.db.GetUserByExternalID(ctx, source, id.Subject)
+	if !errors.Is(err, database.ErrNotFound) {
+		return user, false, err
+	}
+	if !h.sso.AutoProvision {
+		return nil, false, errSSONotLinked
+	}
+	handle, err := h.freeSSOHandle(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+	var email *string
+	if id.Email != "" && id.EmailVerified {
+		email = &id.Email
+	}
+	user, err = h.db.CreateExternalUser(ctx, handle, email, source, id.Subject)
+	return user, err == nil, err
+}
+
+// reservedSSOHandles are never given to an auto-provisioned user as is; control-plane bootstrap grants the admin
+// role to the local "admin" user.
+var reservedSSOHandles = map[string]bool{"admin": true, "system": true}
+
+// freeSSOHandle picks a handle for a new user from preferred_username or the email local part, adding a suffix
+// derived from the subject when the handle is taken or reserved.
+func (h *AuthHandler) freeSSOHandle(ctx context.Context, id *oidc.Identity) (string, error) {
+	sum := sha256.Sum256([]byte(id.Issuer + "\x00" + id.Subject))
+	suffix := hex.EncodeToString(sum[:])
+	base := ""
+	for _, c := range []string{id.PreferredUsername, strings.SplitN(id.Email, "@", 2)[0]} {
+		if base = sanitizeHandle(c); base != "" {
+			break
+		}
+	}
+	if base == "" {
+		base = "sso-" + suffix[:8]
+	}
+	for _, candidate := range []string{base, base + "-" + suffix[:6], base + "-" + suffix[:12]} {
+		if reservedSSOHandles[candidate] {
+			continue
+		}
+		_, err := h.db.GetUserByHandle(ctx, candidate)
+		if errors.Is(err, database.ErrNotFound) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("no free handle for %q", base)
+}
+
+// sanitizeHandle lowercases s and keeps letters, digits, '.', '_', and '-', up to 48 characters.
+func sanitizeHandle(s string) string {
+	var b strings.Builder
+	for _, c := range strings.ToLower(s) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '.' || c == '_' || c == '-' {
+			b.WriteRune(c)
+		}
+	}
+	out := strings.Trim(b.String(), "._-")
+	if len(out) > 48 {
+		out = out[:48]
+	}
+	return out
+}
+
+// syncSSOGroups replaces the user's externally managed group memberships with the active groups named in the
+// groups claim, mapped through GroupMap. A token without the claim (nil groups) or an empty GroupMap leaves
+// memberships unchanged.
+func (h *AuthHandler) syncSSOGroups(ctx context.Context, userID uuid.UUID, groups []string) error {
+	if groups == nil || len(h.sso.GroupMap) == 0 {
+		return nil
+	}
+	var ids []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, name := range groups {
+		slug, ok := h.sso.GroupMap[name]
+		if !ok {
+			continue
+		}
+		g, err := h.db.GetGroupBySlug(ctx, slug)
+		if errors.Is(err, database.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if g.IsActive && !seen[g.ID] {
+			seen[g.ID] = true
+			ids = append(ids, g.ID)
+		}
+	}
+	return h.db.SyncExternalGroupMemberships(ctx, userID, ids, ssoAuditDetails)
+}
+
+// ssoFailure records a failed exchange or ID token validation.
+func (h *AuthHandler) ssoFailure(w http.ResponseWriter, r *http.Request, err error) {
+	h.logger.Warn("sso sign-in failed", "error", err)
+	h.auditLog(r.Context(), nil, "login_failure", false, getClientIP(r), r.UserAgent(), "sso token exchange failed")
+	WriteUnauthorized(w, "Sign-in with the identity provider failed")
+}
+
+func (h *AuthHandler) ssoEnabled(w http.ResponseWriter) bool {
+	if h.sso == nil || h.db == nil {
+		WriteNotFound(w, "Single sign-on is not configured")
+		return false
+	}
+	return true
+}
+
+func (h *AuthHandler) setSSOCookie(w http.ResponseWriter, r *http.Request, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     ssoCookieName,
+		Value:    value,
+		Path:     ssoCookiePath,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func readSSOFlow(r *http.Request) (ssoFlow, bool) {
+	var flow ssoFlow
+	c, err := r.Cookie(ssoCookieName)
+	if err != nil {
+		return flow, false
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(c.Value)
+	if err != nil || json.Unmarshal(raw, &flow) != nil || flow.State == "" {
+		return flow, false
+	}
+	return flow, true
+}
+
+func writeIdPUnavailable(w http.ResponseWriter) {
+	WriteError(w, http.StatusBadGateway, problem.TypeInternal, "Bad Gateway", "Identity provider is unavailable")
+}
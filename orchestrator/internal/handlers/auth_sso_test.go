@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/auth"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/oidc"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
+)
+
+const ssoTestCallback = "https://gateway.example/v1/auth/sso/callback"
+
+type ssoEnv struct {
+	idp     *testutil.OIDCServer
+	mock    *testutil.MockDB
+	handler *AuthHandler
+}
+
+func newSSOEnv(t *testing.T, claims map[string]any, groupMap map[string]string) *ssoEnv {
+	t.Helper()
+	e := &ssoEnv{idp: testutil.NewOIDCServer(t, "cynodeai", claims), mock: testutil.NewMockDB()}
+	provider, err := oidc.New(oidc.Config{IssuerURL: e.idp.URL, ClientID: "cynodeai", RedirectURL: ssoTestCallback})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwtMgr := auth.NewJWTManager("test-secret-key-1234567890123456", 15*time.Minute, 7*24*time.Hour, 24*time.Hour)
+	e.handler = NewAuthHandler(e.mock, jwtMgr, auth.NewRateLimiter(100, time.Minute), newTestLogger())
+	e.handler.SetSSO(&SSOConfig{Provider: provider, AutoProvision: true, GroupMap: groupMap})
+	return e
+}
+
+func (e *ssoEnv) group(slug string) *models.Group {
+	g := &models.Group{Slug: slug, DisplayName: slug, IsActive: true}
+	_ = e.mock.CreateGroup(context.Background(), g)
+	return g
+}
+
+// browserLogin runs GET /login, follows the redirect through the stub IdP, and calls the callback with the
+// cookie the gateway set.
+func (e *ssoEnv) browserLogin(t *testing.T) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/v1/auth/sso/login", http.NoBody)
+	rec := httptest.NewRecorder()
+	e.handler.SSOLogin(rec, req)
+	if rec.Code != http.StatusFound {
+		t.Fatalf("login: %d %s", rec.Code, rec.Body)
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	back, _ := url.Parse(resp.Header.Get("Location"))
+	callback := httptest.NewRequest(http.MethodGet, "/v1/auth/sso/callback?"+back.RawQuery, http.NoBody)
+	for _, c := range rec.Result().Cookies() {
+		callback.AddCookie(c)
+	}
+	out := httptest.NewRecorder()
+	e.handler.SSOCallback(out, callback)
+	return out
+}
+
+func TestSSO_BrowserLoginProvisionsUserAndSyncsGroups(t *testing.T) {
+	e := newSSOEnv(t, map[string]any{
+		"sub": "idp-123", "preferred_username": "Dev.One", "email": "dev@example.com", "email_verified": true,
+		"groups": []string{"idp-eng", "unmapped"},
+	}, map[string]string{"idp-eng": "eng"})
+	eng, ops := e.group("eng"), e.group("ops")
+	ctx := context.Background()
+
+	rec := e.browserLogin(t)
+	assertStatusCode(t, rec, http.StatusOK)
+	var tokens userapi.LoginResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &tokens)
+	if tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Fatalf("tokens = %+v", tokens)
+	}
+	user, err := e.mock.GetUserByExternalID(ctx, e.idp.URL, "idp-123")
+	if err != nil || user.Handle != "dev.one" || user.Email == nil || *user.Email != "dev@example.com" {
+		t.Fatalf("provisioned user = %+v, %v", user, err)
+	}
+	if ids, _ := e.mock.ListGroupIDsForUser(ctx, user.ID); len(ids) != 1 || ids[0] != eng.ID {
+		t.Errorf("groups = %v, want [eng]", ids)
+	}
+
+	// A second sign-in reuses the account; losing the IdP group removes only the synced membership.
+	if _, err := e.mock.AddGroupMember(ctx, ops.ID, user.ID, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	e.idp.SetClaims(map[string]any{"sub": "idp-123", "preferred_username": "dev.one", "groups": []string{}})
+	assertStatusCode(t, e.browserLogin(t), http.StatusOK)
+	if ids, _ := e.mock.ListGroupIDsForUser(ctx, user.ID); len(ids) != 1 || ids[0] != ops.ID {
+		t.Errorf("groups after resync = %v, want [ops]", ids)
+	}
+	if len(e.mock.Users) != 1 {
+		t.Errorf("users = %d, want 1", len(e.mock.Users))
+	}
+}
+
+func TestSSO_HandleCollisionAndLinking(t *testing.T) {
+	e := newSSOEnv(t, map[string]any{"sub": "s1", "preferred_username": "admin", "email": "admin@example.com"}, nil)
+	ctx := context.Background()
+	email := "admin@example.com"
+	local, _ := e.mock.CreateUser(ctx, "admin", &email)
+
+	assertStatusCode(t, e.browserLogin(t), http.StatusOK)
+	user, err := e.mock.GetUserByExternalID(ctx, e.idp.URL, "s1")
+	if err != nil || user.ID == local.ID || user.Handle == "admin" || user.Email != nil {
+		t.Fatalf("sso user = %+v, %v; must not reuse the local admin or its unverified email", user, err)
+	}
+
+	e.handler.sso.AutoProvision = false
+	e.idp.SetClaims(map[string]any{"sub": "s2"})
+	assertStatusCode(t, e.browserLogin(t), http.StatusForbidden)
+
+	e.idp.SetClaims(map[string]any{"sub": "s1"})
+	user.IsActive = false
+	assertStatusCode(t, e.browserLogin(t), http.StatusUnauthorized)
+}
+
+// TestSSO_ReservedHandles verifies an IdP user never gets a reserved handle, even before the local admin exists.
+func TestSSO_ReservedHandles(t *testing.T) {
+	e := newSSOEnv(t, nil, nil)
+	for sub, name := range map[string]string{"s1": "Admin", "s2": "system"} {
+		e.idp.SetClaims(map[string]any{"sub": sub, "preferred_username": name})
+		assertStatusCode(t, e.browserLogin(t), http.StatusOK)
+		user, err := e.mock.GetUserByExternalID(context.Background(), e.idp.URL, sub)
+		if want := strings.ToLower(name) + "-"; err != nil || !strings.HasPrefix(user.Handle, want) {
+			t.Errorf("%s handle = %+v, %v; want prefix %q", name, user, err, want)
+		}
+	}
+}
+
+func TestSSO_CallbackRejectsBadState(t *testing.T) {
+	e := newSSOEnv(t, map[string]any{"sub": "s1"}, nil)
+	req := httptest.NewRequest(http.MethodGet, "/v1/auth/sso/callback?code=x&state=forged", http.NoBody)
+	rec := httptest.NewRecorder()
+	e.handler.SSOCallback(rec, req)
+	assertStatusCode(t, rec, http.StatusBadRequest)
+	if n := len(e.mock.AuditLogs); n != 1 || e.mock.AuditLogs[0].EventType != "login_failure" {
+		t.Errorf("audit = %d entries", n)
+	}
+}
+
+func TestSSO_NoGroupMapSkipsGroupSync(t *testing.T) {
+	e := newSSOEnv(t, map[string]any{"sub": "idp-9", "preferred_username": "dev", "groups": []string{"admins"}}, nil)
+	e.group("admins")
+	assertStatusCode(t, e.browserLogin(t), http.StatusOK)
+	user, err := e.mock.GetUserByExternalID(context.Background(), e.idp.URL, "idp-9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids, _ := e.mock.ListGroupIDsForUser(context.Background(), user.ID); len(ids) != 0 {
+		t.Errorf("groups = %v; an IdP group must not join a same-named group without OIDC_GROUP_MAP", ids)
+	}
+}
+
+func TestSSO_DeviceFlow(t *testing.T) {
+	e := newSSOEnv(t, map[string]any{"sub": "s1", "email": "ci@example.com", "email_verified": true}, nil)
+	req, rec := recordedRequest(http.MethodPost, "/v1/auth/sso/device", nil)
+	e.handler.SSODeviceStart(rec, req)
+	assertStatusCode(t, rec, http.StatusOK)
+	var da userapi.SSODeviceAuthorizationResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &da)
+	if da.UserCode == "" || da.Interval != 1 {
+		t.Fatalf("device authorization = %+v", da)
+	}
+
+	poll := func() *httptest.ResponseRecorder {
+		req, rec := recordedRequestJSON(http.MethodPost, "/v1/auth/sso/device/token", userapi.SSODeviceTokenRequest{DeviceCode: da.DeviceCode})
+		e.handler.SSODeviceToken(rec, req)
+		return rec
+	}
+	rec = poll()
+	assertStatusCode(t, rec, http.StatusAccepted)
+	var pending userapi.SSODevicePendingResponse
+	if _ = json.Unmarshal(rec.Body.Bytes(), &pending); pending.Status != userapi.SSODeviceStatusPending {
+		t.Errorf("pending = %+v", pending)
+	}
+	e.idp.ApproveDevice(da.UserCode)
+	rec = poll()
+	assertStatusCode(t, rec, http.StatusOK)
+	if user, err := e.mock.GetUserByExternalID(context.Background(), e.idp.URL, "s1"); err != nil || user.Handle != "ci" {
+		t.Errorf("user = %+v, %v", user, err)
+	}
+	assertStatusCode(t, poll(), http.StatusUnauthorized)
+}
+
+func TestSSO_NotConfigured(t *testing.T) {
+	handler := NewAuthHandler(testutil.NewMockDB(), nil, auth.NewRateLimiter(10, time.Minute), newTestLogger())
+	runHandlerTest(t, http.MethodGet, "/v1/auth/sso/login", nil, handler.SSOLogin, http.StatusNotFound)
+	runHandlerTest(t, http.MethodPost, "/v1/auth/sso/device", nil, handler.SSODeviceStart, http.StatusNotFound)
+}
@@ -13,6 +13,8 @@ const (
 	contextKeyHandle   contextKey = "handle"
 	contextKeyNodeID   contextKey = "node_id"
 	contextKeyNodeSlug contextKey = "node_slug"
+	contextKeyScopes   contextKey = "token_scopes"
+	contextKeySession  contextKey = "session_id"
 )
 
 // SetUserContext adds user info to context.
@@ -22,6 +24,35 @@ func SetUserContext(ctx context.Context, userID uuid.UUID, handle string) contex
 	return ctx
 }
 
+// SetTokenScopes marks the request as authenticated with a personal access token limited to scopes.
+func SetTokenScopes(ctx context.Context, scopes []string) context.Context {
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return context.WithValue(ctx, contextKeyScopes, scopes)
+}
+
+// SetSessionContext records the refresh session of the access token that authenticated the request.
+func SetSessionContext(ctx context.Context, sessionID uuid.UUID) context.Context {
+	return context.WithValue(ctx, contextKeySession, sessionID)
+}
+
+// getSessionIDFromContext returns the caller's session id, or nil for personal access tokens and access tokens
+// without a sid claim.
+func getSessionIDFromContext(ctx context.Context) *uuid.UUID {
+	if id, ok := ctx.Value(contextKeySession).(uuid.UUID); ok {
+		return &id
+	}
+	return nil
+}
+
+// GetTokenScopesFromContext returns the personal access token scopes of the request, or nil when the caller
+// authenticated with a session access token.
+func GetTokenScopesFromContext(ctx context.Context) []string {
+	scopes, _ := ctx.Value(contextKeyScopes).([]string)
+	return scopes
+}
+
 // SetNodeContext adds node info to context.
 func SetNodeContext(ctx context.Context, nodeID uuid.UUID, nodeSlug string) context.Context {
 	ctx = context.WithValue(ctx, contextKeyNodeID, nodeID)
@@ -36,8 +67,13 @@ func getUserIDFromContext(ctx context.Context) *uuid.UUID {
 	return nil
 }
 
+// GetUserIDFromContext returns the authenticated user's id from context, or nil.
+// Used by permission middleware to resolve the user's roles.
+func GetUserIDFromContext(ctx context.Context) *uuid.UUID {
+	return getUserIDFromContext(ctx)
+}
+
 // GetHandleFromContext returns the authenticated user's handle from context, or empty string.
-// Used by admin middleware to enforce admin-gated endpoints.
 func GetHandleFromContext(ctx context.Context) string {
 	if h, ok := ctx.Value(contextKeyHandle).(string); ok {
 		return h
@@ -45,6 +81,11 @@ func GetHandleFromContext(ctx context.Context) string {
 	return ""
 }
 
+// GetNodeIDFromContext returns the authenticated node's id from context, or nil.
+func GetNodeIDFromContext(ctx context.Context) *uuid.UUID {
+	return getNodeIDFromContext(ctx)
+}
+
 func getNodeIDFromContext(ctx context.Context) *uuid.UUID {
 	if id, ok := ctx.Value(contextKeyNodeID).(uuid.UUID); ok {
 		return &id
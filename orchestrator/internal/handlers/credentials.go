@@ -0,0 +1,453 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/problem"
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/credcrypt"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/rbac"
+)
+
+// Access control audit actions for credential management (resource type api.credential).
+const (
+	ActionCredentialCreate  = "credential.create"
+	ActionCredentialRotate  = "credential.rotate"
+	ActionCredentialDisable = "credential.disable"
+	ActionCredentialRekey   = "credential.rekey"
+	ResourceTypeCredential  = "api.credential"
+)
+
+// maxCredentialSecretBytes bounds a credential secret.
+const maxCredentialSecretBytes = 16 << 10
+
+// rekeyBatchSize is how many rows POST /v1/credentials/rekey loads at a time.
+const rekeyBatchSize = 100
+
+var (
+	credentialProviderRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+	credentialTypes      = map[string]bool{"api_key": true, "oauth_token": true, "bearer_token": true}
+)
+
+// CredentialHandler serves the API Egress credential admin API (api_egress_server.md Admin API).
+// Users manage their own user-owned credentials and can see the credentials of their groups; holders of
+// credentials.admin manage any credential, including group-owned ones.
+// Secrets are envelope-encrypted with keys before they are stored and are never returned.
+type CredentialHandler struct {
+	db     database.Store
+	keys   *credcrypt.Keyring
+	logger *slog.Logger
+}
+
+// NewCredentialHandler creates a credential handler. keys may be nil, in which case create, rotate, and rekey
+// return 503 while list, get, and disable still work.
+func NewCredentialHandler(db database.Store, keys *credcrypt.Keyring, logger *slog.Logger) *CredentialHandler {
+	return &CredentialHandler{db: db, keys: keys, logger: logger}
+}
+
+// ListCredentials handles GET /v1/credentials. Optional filters: provider, owner_type, owner_id (credentials.admin
+// only; other callers always see their own credentials and those of their groups).
+func (h *CredentialHandler) ListCredentials(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := getUserIDFromContext(ctx)
+	if userID == nil {
+		WriteUnauthorized(w, "Authentication required")
+		return
+	}
+	q := r.URL.Query()
+	f := database.ApiCredentialFilter{Provider: strings.ToLower(strings.TrimSpace(q.Get("provider")))}
+	if rbac.FromContext(ctx).Has(rbac.PermCredentialsAdmin) {
+		f.OwnerType = q.Get("owner_type")
+		if s := q.Get("owner_id"); s != "" {
+			id, err := uuid.Parse(s)
+			if err != nil {
+				WriteBadRequest(w, "Invalid owner_id")
+				return
+			}
+			f.OwnerID = &id
+		}
+	} else {
+		f.Owners = []database.AccessSubject{{Type: userapi.CredentialOwnerUser, ID: *userID}}
+		if a := rbac.FromContext(ctx); a != nil {
+			for _, g := range a.GroupIDs {
+				f.Owners = append(f.Owners, database.AccessSubject{Type: userapi.CredentialOwnerGroup, ID: g})
+			}
+		}
+	}
+	list, err := h.db.ListApiCredentials(ctx, f)
+	if err != nil {
+		h.logger.Error("list credentials", "error", err)
+		WriteInternalError(w, "Failed to list credentials")
+		return
+	}
+	resp := userapi.ListCredentialsResponse{Credentials: make([]userapi.CredentialResponse, 0, len(list))}
+	for _, c := range list {
+		resp.Credentials = append(resp.Credentials, credentialToResponse(c))
+	}
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+// GetCredential handles GET /v1/credentials/{id}; 404 when the caller may not see the credential.
+func (h *CredentialHandler) GetCredential(w http.ResponseWriter, r *http.Request) {
+	cred := h.resolveCredential(w, r, false)
+	if cred == nil {
+		return
+	}
+	WriteJSON(w, http.StatusOK, credentialToResponse(cred))
+}
+
+// CreateCredential handles POST /v1/credentials and returns 201 with the metadata.
+func (h *CredentialHandler) CreateCredential(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := getUserIDFromContext(ctx)
+	if userID == nil {
+		WriteUnauthorized(w, "Authentication required")
+		return
+	}
+	if !h.requireKeys(w) {
+		return
+	}
+	var req userapi.CreateCredentialRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteBadRequest(w, "Invalid request body")
+		return
+	}
+	cred, detail := h.credentialFromRequest(ctx, *userID, &req)
+	if cred == nil {
+		WriteBadRequest(w, detail)
+		return
+	}
+	if !canManageCredential(ctx, cred) {
+		WriteForbidden(w, "Creating credentials for other owners requires "+rbac.PermCredentialsAdmin)
+		return
+	}
+	secret := []byte(req.Secret)
+	ct, kid, err := h.keys.Seal(cred.ID, secret)
+	credcrypt.Zero(secret)
+	if err != nil {
+		h.logger.Error("encrypt credential", "error", err)
+		WriteInternalError(w, "Failed to encrypt credential")
+		return
+	}
+	cred.CredentialCiphertext, cred.CredentialKID = ct, &kid
+	if err := h.db.CreateApiCredential(ctx, cred); err != nil {
+		if errors.Is(err, database.ErrExists) {
+			WriteConflict(w, "A credential with this name already exists for the owner and provider")
+			return
+		}
+		h.logger.Error("create credential", "error", err)
+		WriteInternalError(w, "Failed to create credential")
+		return
+	}
+	h.audit(ctx, ActionCredentialCreate, cred.ID.String())
+	WriteJSON(w, http.StatusCreated, credentialToResponse(cred))
+}
+
+// credentialFromRequest validates req and returns the new (unencrypted) row, or nil and a problem detail.
+func (h *CredentialHandler) credentialFromRequest(ctx context.Context, callerID uuid.UUID, req *userapi.CreateCredentialRequest) (*models.ApiCredential, string) {
+	provider := strings.ToLower(strings.TrimSpace(req.Provider))
+	if !credentialProviderRe.MatchString(provider) {
+		return nil, "provider is required (lowercase letters, digits, '-' or '_')"
+	}
+	name := strings.TrimSpace(req.CredentialName)
+	if name == "" || len(name) > 128 {
+		return nil, "credential_name is required (at most 128 characters)"
+	}
+	credType := req.CredentialType
+	if credType == "" {
+		credType = "api_key"
+	}
+	if !credentialTypes[credType] {
+		return nil, "credential_type must be api_key, oauth_token, or bearer_token"
+	}
+	if detail := validateSecret(req.Secret); detail != "" {
+		return nil, detail
+	}
+	ownerType := req.OwnerType
+	if ownerType == "" {
+		ownerType = userapi.CredentialOwnerUser
+	}
+	if ownerType != userapi.CredentialOwnerUser && ownerType != userapi.CredentialOwnerGroup {
+		return nil, "owner_type must be user or group"
+	}
+	ownerID := callerID
+	if req.OwnerID != "" {
+		id, err := uuid.Parse(req.OwnerID)
+		if err != nil {
+			return nil, "Invalid owner_id"
+		}
+		ownerID = id
+	} else if ownerType == userapi.CredentialOwnerGroup {
+		return nil, "owner_id is required for group credentials"
+	}
+	expiresAt, detail := parseCredentialExpiry(req.ExpiresAt)
+	if detail != "" {
+		return nil, detail
+	}
+	updatedBy := GetHandleFromContext(ctx)
+	return &models.ApiCredential{
+		ID:             uuid.New(),
+		OwnerType:      ownerType,
+		OwnerID:        ownerID,
+		Provider:       provider,
+		CredentialType: credType,
+		CredentialName: name,
+		IsActive:       true,
+		ExpiresAt:      expiresAt,
+		UpdatedBy:      &updatedBy,
+	}, ""
+}
+
+// RotateCredential handles POST /v1/credentials/{id}/rotate: the new secret replaces the old one under the
+// current master key. 409 when the credential is disabled.
+func (h *CredentialHandler) RotateCredential(w http.ResponseWriter, r *http.Request) {
+	if !h.requireKeys(w) {
+		return
+	}
+	cred := h.resolveCredential(w, r, true)
+	if cred == nil {
+		return
+	}
+	var req userapi.RotateCredentialRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteBadRequest(w, "Invalid request body")
+		return
+	}
+	if detail := validateSecret(req.Secret); detail != "" {
+		WriteBadRequest(w, detail)
+		return
+	}
+	expiresAt, detail := parseCredentialExpiry(req.ExpiresAt)
+	if detail != "" {
+		WriteBadRequest(w, detail)
+		return
+	}
+	secret := []byte(req.Secret)
+	ct, kid, err := h.keys.Seal(cred.ID, secret)
+	credcrypt.Zero(secret)
+	if err != nil {
+		h.logger.Error("encrypt credential", "error", err, "credential_id", cred.ID)
+		WriteInternalError(w, "Failed to encrypt credential")
+		return
+	}
+	ctx := r.Context()
+	updated, err := h.db.RotateApiCredential(ctx, cred.ID, ct, kid, expiresAt, GetHandleFromContext(ctx))
+	if err != nil {
+		if errors.Is(err, database.ErrConflict) {
+			WriteConflict(w, "Credential is disabled")
+			return
+		}
+		h.logger.Error("rotate credential", "error", err, "credential_id", cred.ID)
+		WriteInternalError(w, "Failed to rotate credential")
+		return
+	}
+	h.audit(ctx, ActionCredentialRotate, cred.ID.String())
+	WriteJSON(w, http.StatusOK, credentialToResponse(updated))
+}
+
+// DisableCredential handles POST /v1/credentials/{id}/disable (revoke). The row and its ciphertext are kept for
+// audit; API Egress stops selecting it immediately.
+func (h *CredentialHandler) DisableCredential(w http.ResponseWriter, r *http.Request) {
+	cred := h.resolveCredential(w, r, true)
+	if cred == nil {
+		return
+	}
+	ctx := r.Context()
+	updated, err := h.db.DisableApiCredential(ctx, cred.ID, GetHandleFromContext(ctx))
+	if err != nil {
+		h.logger.Error("disable credential", "error", err, "credential_id", cred.ID)
+		WriteInternalError(w, "Failed to disable credential")
+		return
+	}
+	h.audit(ctx, ActionCredentialDisable, cred.ID.String())
+	WriteJSON(w, http.StatusOK, credentialToResponse(updated))
+}
+
+// Rekey handles POST /v1/credentials/rekey (credentials.admin): every credential whose data key is wrapped with a
+// previous master key is re-wrapped with the current one. Secrets are not decrypted. Rows whose key id is not
+// configured are counted as failed and left unchanged.
+func (h *CredentialHandler) Rekey(w http.ResponseWriter, r *http.Request) {
+	if !h.requireKeys(w) {
+		return
+	}
+	ctx := r.Context()
+	current := h.keys.CurrentKID()
+	resp := userapi.RekeyCredentialsResponse{KeyID: current}
+	seen := make(map[uuid.UUID]bool)
+	for {
+		batch, err := h.db.ListApiCredentialsNotUnderKID(ctx, current, len(seen)+rekeyBatchSize)
+		if err != nil {
+			h.logger.Error("list credentials for rekey", "error", err)
+			WriteInternalError(w, "Failed to list credentials")
+			return
+		}
+		progressed := false
+		for _, c := range batch {
+			if seen[c.ID] {
+				continue
+			}
+			seen[c.ID], progressed = true, true
+			if h.rewrap(ctx, c) {
+				resp.Rewrapped++
+			} else {
+				resp.Failed++
+			}
+		}
+		if !progressed {
+			break
+		}
+	}
+	h.audit(ctx, ActionCredentialRekey, current)
+	h.logger.Info("credentials re-wrapped", "credential_kid", current, "rewrapped", resp.Rewrapped, "failed", resp.Failed)
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *CredentialHandler) rewrap(ctx context.Context, c *models.ApiCredential) bool {
+	oldKID := *c.CredentialKID
+	ct, kid, err := h.keys.Rewrap(c.ID, oldKID, c.CredentialCiphertext)
+	if err != nil {
+		h.logger.Warn("rewrap credential", "error", err, "credential_id", c.ID, "credential_kid", oldKID)
+		return false
+	}
+	if err := h.db.RewrapApiCredential(ctx, c.ID, oldKID, ct, kid); err != nil {
+		// ErrConflict: rotated concurrently, so it is already under the current key.
+		if errors.Is(err, database.ErrConflict) {
+			return true
+		}
+		h.logger.Warn("store rewrapped credential", "error", err, "credential_id", c.ID)
+		return false
+	}
+	return true
+}
+
+// resolveCredential loads {id} and checks the caller may see it (and change it when write is set); writes
+// 400/401/403/404/500 and returns nil otherwise.
+func (h *CredentialHandler) resolveCredential(w http.ResponseWriter, r *http.Request, write bool) *models.ApiCredential {
+	ctx := r.Context()
+	if getUserIDFromContext(ctx) == nil {
+		WriteUnauthorized(w, "Authentication required")
+		return nil
+	}
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		WriteBadRequest(w, "Invalid credential id")
+		return nil
+	}
+	cred, err := h.db.GetApiCredentialByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			WriteNotFound(w, "Credential not found")
+			return nil
+		}
+		h.logger.Error("get credential", "error", err, "credential_id", id)
+		WriteInternalError(w, "Failed to get credential")
+		return nil
+	}
+	if !canViewCredential(ctx, cred) {
+		WriteNotFound(w, "Credential not found")
+		return nil
+	}
+	if write && !canManageCredential(ctx, cred) {
+		WriteForbidden(w, "Changing group credentials requires "+rbac.PermCredentialsAdmin)
+		return nil
+	}
+	return cred
+}
+
+func (h *CredentialHandler) requireKeys(w http.ResponseWriter) bool {
+	if h.keys == nil {
+		WriteError(w, http.StatusServiceUnavailable, problem.TypeInternal, "Service Unavailable", "Credential encryption key is not configured")
+		return false
+	}
+	return true
+}
+
+// audit records a credential management action in access_control_audit_log (no secret material).
+func (h *CredentialHandler) audit(ctx context.Context, action, resource string) {
+	rec := &models.AccessControlAuditLog{
+		SubjectType:  "user",
+		SubjectID:    getUserIDFromContext(ctx),
+		Action:       action,
+		ResourceType: ResourceTypeCredential,
+		Resource:     resource,
+		Decision:     "allow",
+	}
+	if err := h.db.CreateAccessControlAuditLog(ctx, rec); err != nil {
+		h.logger.Warn("credential audit log failed", "error", err, "action", action)
+	}
+}
+
+// canManageCredential reports whether the caller may change cred: holders of credentials.admin always, other
+// users only their own user-owned credentials.
+func canManageCredential(ctx context.Context, cred *models.ApiCredential) bool {
+	if rbac.FromContext(ctx).Has(rbac.PermCredentialsAdmin) {
+		return true
+	}
+	userID := getUserIDFromContext(ctx)
+	return userID != nil && cred.OwnerType == userapi.CredentialOwnerUser && cred.OwnerID == *userID
+}
+
+// canViewCredential reports whether the caller may see cred's metadata: anyone who may manage it, and members of
+// the owning group.
+func canViewCredential(ctx context.Context, cred *models.ApiCredential) bool {
+	return canManageCredential(ctx, cred) ||
+		(cred.OwnerType == userapi.CredentialOwnerGroup && rbac.FromContext(ctx).InGroup(cred.OwnerID))
+}
+
+func validateSecret(secret string) string {
+	if strings.TrimSpace(secret) == "" {
+		return "secret is required"
+	}
+	if len(secret) > maxCredentialSecretBytes {
+		return "secret is too large"
+	}
+	return ""
+}
+
+// parseCredentialExpiry parses an optional RFC 3339 expiry that must be in the future.
+func parseCredentialExpiry(s *string) (*time.Time, string) {
+	if s == nil || *s == "" {
+		return nil, ""
+	}
+	t, err := time.Parse(time.RFC3339, *s)
+	if err != nil {
+		return nil, "expires_at must be RFC 3339"
+	}
+	if !t.After(time.Now()) {
+		return nil, "expires_at must be in the future"
+	}
+	t = t.UTC()
+	return &t, ""
+}
+
+func credentialToResponse(c *models.ApiCredential) userapi.CredentialResponse {
+	resp := userapi.CredentialResponse{
+		ID:             c.ID.String(),
+		OwnerType:      c.OwnerType,
+		OwnerID:        c.OwnerID.String(),
+		Provider:       c.Provider,
+		CredentialName: c.CredentialName,
+		CredentialType: c.CredentialType,
+		KeyID:          c.CredentialKID,
+		IsActive:       c.IsActive,
+		CreatedAt:      c.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:      c.UpdatedAt.UTC().Format(time.RFC3339),
+		UpdatedBy:      c.UpdatedBy,
+	}
+	if c.ExpiresAt != nil {
+		s := c.ExpiresAt.UTC().Format(time.RFC3339)
+		resp.ExpiresAt = &s
+	}
+	return resp
+}
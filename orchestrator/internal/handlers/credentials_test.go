@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/credcrypt"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/rbac"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
+)
+
+var (
+	testCredKeyK1 = []byte("0123456789abcdef0123456789abcdef")
+	testCredKeyK2 = []byte("fedcba9876543210fedcba9876543210")
+)
+
+func newTestCredentialHandler(t *testing.T, mockDB *testutil.MockDB) *CredentialHandler {
+	t.Helper()
+	keys, err := credcrypt.New("k1", testCredKeyK1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewCredentialHandler(mockDB, keys, newTestLogger())
+}
+
+// credRequest builds a request authenticated as userID with the given handle. The handle "admin" holds the admin
+// role; everyone else the default member role.
+func credRequest(method, path, body string, userID uuid.UUID, handle string, groupIDs ...uuid.UUID) (*http.Request, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	return req.WithContext(accessContext(userID, handle, groupIDs...)), httptest.NewRecorder()
+}
+
+// accessContext returns a user context carrying the rbac.Access RequirePermission would resolve.
+func accessContext(userID uuid.UUID, handle string, groupIDs ...uuid.UUID) context.Context {
+	roles := database.BuiltinRoles()
+	role := roles[2] // member
+	if handle == "admin" {
+		role = roles[0]
+	}
+	access := &rbac.Access{UserID: userID, GroupIDs: groupIDs, Roles: []*models.Role{role}}
+	return rbac.WithAccess(SetUserContext(context.Background(), userID, handle), access)
+}
+
+func createTestCredential(t *testing.T, h *CredentialHandler, userID uuid.UUID, body string) userapi.CredentialResponse {
+	t.Helper()
+	req, rec := credRequest(http.MethodPost, "/v1/credentials", body, userID, "alice")
+	h.CreateCredential(rec, req)
+	assertStatusCode(t, rec, http.StatusCreated)
+	var resp userapi.CredentialResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestCredentialHandler_CreateEncryptsAndNeverReturnsSecret(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	h := newTestCredentialHandler(t, mockDB)
+	userID := uuid.New()
+	resp := createTestCredential(t, h, userID, `{"provider":"OpenAI","credential_name":"default","secret":"sk-live-123"}`)
+	if resp.OwnerType != "user" || resp.OwnerID != userID.String() || resp.Provider != "openai" ||
+		resp.CredentialType != "api_key" || !resp.IsActive || resp.KeyID == nil || *resp.KeyID != "k1" {
+		t.Errorf("response = %+v", resp)
+	}
+	cred := mockDB.ApiCredentials[0]
+	if strings.Contains(string(cred.CredentialCiphertext), "sk-live-123") {
+		t.Fatal("secret stored in plaintext")
+	}
+	pt, err := h.keys.Open(cred.ID, *cred.CredentialKID, cred.CredentialCiphertext)
+	if err != nil || string(pt) != "sk-live-123" {
+		t.Errorf("decrypt stored secret = %q, %v", pt, err)
+	}
+
+	// Reading it back never includes the secret.
+	req, rec := credRequest(http.MethodGet, "/v1/credentials", "", userID, "alice")
+	h.ListCredentials(rec, req)
+	assertStatusCode(t, rec, http.StatusOK)
+	if strings.Contains(rec.Body.String(), "sk-live") || strings.Contains(rec.Body.String(), "ciphertext") {
+		t.Errorf("list leaks secret: %s", rec.Body.String())
+	}
+
+	req, rec = credRequest(http.MethodPost, "/v1/credentials", `{"provider":"openai","credential_name":"default","secret":"x"}`, userID, "alice")
+	h.CreateCredential(rec, req)
+	assertStatusCode(t, rec, http.StatusConflict)
+}
+
+func TestCredentialHandler_CreateValidation(t *testing.T) {
+	h := newTestCredentialHandler(t, testutil.NewMockDB())
+	userID := uuid.New()
+	for _, body := range []string{
+		`not json`,
+		`{"provider":"","credential_name":"n","secret":"s"}`,
+		`{"provider":"openai","credential_name":"","secret":"s"}`,
+		`{"provider":"openai","credential_name":"n","secret":" "}`,
+		`{"provider":"openai","credential_name":"n","secret":"s","credential_type":"password"}`,
+		`{"provider":"openai","credential_name":"n","secret":"s","owner_type":"team"}`,
+		`{"provider":"openai","credential_name":"n","secret":"s","owner_type":"group"}`,
+		`{"provider":"openai","credential_name":"n","secret":"s","expires_at":"2000-01-01T00:00:00Z"}`,
+	} {
+		req, rec := credRequest(http.MethodPost, "/v1/credentials", body, userID, "alice")
+		h.CreateCredential(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d", body, rec.Code)
+		}
+	}
+	// Only the admin may create credentials for other owners, including groups.
+	groupBody := `{"provider":"github","credential_name":"org","secret":"s","owner_type":"group","owner_id":"` + uuid.NewString() + `"}`
+	req, rec := credRequest(http.MethodPost, "/v1/credentials", groupBody, userID, "alice")
+	h.CreateCredential(rec, req)
+	assertStatusCode(t, rec, http.StatusForbidden)
+	req, rec = credRequest(http.MethodPost, "/v1/credentials", groupBody, uuid.New(), "admin")
+	h.CreateCredential(rec, req)
+	assertStatusCode(t, rec, http.StatusCreated)
+}
+
+func TestCredentialHandler_RotateAndDisable(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	h := newTestCredentialHandler(t, mockDB)
+	owner := uuid.New()
+	created := createTestCredential(t, h, owner, `{"provider":"github","credential_name":"ci","secret":"ghp_old"}`)
+	path := "/v1/credentials/" + created.ID
+
+	// Another user cannot see or change it.
+	req, rec := credRequest(http.MethodPost, path+"/rotate", `{"secret":"x"}`, uuid.New(), "bob")
+	req.SetPathValue("id", created.ID)
+	h.RotateCredential(rec, req)
+	assertStatusCode(t, rec, http.StatusNotFound)
+
+	req, rec = credRequest(http.MethodPost, path+"/rotate", `{"secret":"ghp_new"}`, owner, "alice")
+	req.SetPathValue("id", created.ID)
+	h.RotateCredential(rec, req)
+	assertStatusCode(t, rec, http.StatusOK)
+	cred := mockDB.ApiCredentials[0]
+	pt, err := h.keys.Open(cred.ID, *cred.CredentialKID, cred.CredentialCiphertext)
+	if err != nil || string(pt) != "ghp_new" {
+		t.Errorf("rotated secret = %q, %v", pt, err)
+	}
+
+	req, rec = credRequest(http.MethodPost, path+"/disable", "", owner, "alice")
+	req.SetPathValue("id", created.ID)
+	h.DisableCredential(rec, req)
+	assertStatusCode(t, rec, http.StatusOK)
+	if cred.IsActive || cred.UpdatedBy == nil || *cred.UpdatedBy != "alice" {
+		t.Errorf("disabled credential = %+v", cred)
+	}
+
+	req, rec = credRequest(http.MethodPost, path+"/rotate", `{"secret":"again"}`, owner, "alice")
+	req.SetPathValue("id", created.ID)
+	h.RotateCredential(rec, req)
+	assertStatusCode(t, rec, http.StatusConflict)
+}
+
+func TestCredentialHandler_ListScopesNonAdmin(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	h := newTestCredentialHandler(t, mockDB)
+	alice, bob := uuid.New(), uuid.New()
+	createTestCredential(t, h, alice, `{"provider":"openai","credential_name":"a","secret":"s"}`)
+	createTestCredential(t, h, bob, `{"provider":"openai","credential_name":"b","secret":"s"}`)
+
+	list := func(userID uuid.UUID, handle, query string) userapi.ListCredentialsResponse {
+		req, rec := credRequest(http.MethodGet, "/v1/credentials"+query, "", userID, handle)
+		h.ListCredentials(rec, req)
+		assertStatusCode(t, rec, http.StatusOK)
+		var resp userapi.ListCredentialsResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		return resp
+	}
+	if got := list(alice, "alice", "?owner_id="+bob.String()); len(got.Credentials) != 1 || got.Credentials[0].CredentialName != "a" {
+		t.Errorf("alice sees %+v", got.Credentials)
+	}
+	if got := list(uuid.New(), "admin", ""); len(got.Credentials) != 2 {
+		t.Errorf("admin sees %d", len(got.Credentials))
+	}
+	if got := list(uuid.New(), "admin", "?owner_id="+bob.String()); len(got.Credentials) != 1 {
+		t.Errorf("admin filter sees %d", len(got.Credentials))
+	}
+}
+
+func TestCredentialHandler_GroupCredentialVisibleToMembers(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	h := newTestCredentialHandler(t, mockDB)
+	group, member := uuid.New(), uuid.New()
+	body := `{"provider":"github","credential_name":"org","secret":"s","owner_type":"group","owner_id":"` + group.String() + `"}`
+	req, rec := credRequest(http.MethodPost, "/v1/credentials", body, uuid.New(), "admin")
+	h.CreateCredential(rec, req)
+	assertStatusCode(t, rec, http.StatusCreated)
+	id := mockDB.ApiCredentials[0].ID.String()
+
+	req, rec = credRequest(http.MethodGet, "/v1/credentials", "", member, "carol", group)
+	h.ListCredentials(rec, req)
+	var resp userapi.ListCredentialsResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if len(resp.Credentials) != 1 || resp.Credentials[0].OwnerType != "group" {
+		t.Errorf("member sees %+v", resp.Credentials)
+	}
+	req, rec = credRequest(http.MethodGet, "/v1/credentials/"+id, "", member, "carol", group)
+	req.SetPathValue("id", id)
+	h.GetCredential(rec, req)
+	assertStatusCode(t, rec, http.StatusOK)
+	// Members can use but not change group credentials; outsiders cannot see them.
+	req, rec = credRequest(http.MethodPost, "/v1/credentials/"+id+"/disable", "", member, "carol", group)
+	req.SetPathValue("id", id)
+	h.DisableCredential(rec, req)
+	assertStatusCode(t, rec, http.StatusForbidden)
+	req, rec = credRequest(http.MethodGet, "/v1/credentials/"+id, "", uuid.New(), "dave")
+	req.SetPathValue("id", id)
+	h.GetCredential(rec, req)
+	assertStatusCode(t, rec, http.StatusNotFound)
+}
+
+func TestCredentialHandler_Rekey(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	old := newTestCredentialHandler(t, mockDB)
+	owner := uuid.New()
+	createTestCredential(t, old, owner, `{"provider":"openai","credential_name":"a","secret":"sk-a"}`)
+	createTestCredential(t, old, owner, `{"provider":"github","credential_name":"b","secret":"ghp-b"}`)
+	orphanKID := "k0"
+	mockDB.ApiCredentials = append(mockDB.ApiCredentials, &models.ApiCredential{ID: uuid.New(), CredentialKID: &orphanKID, CredentialCiphertext: []byte("x")})
+
+	keys, _ := credcrypt.New("k2", testCredKeyK2)
+	if err := keys.Add("k1", testCredKeyK1); err != nil {
+		t.Fatal(err)
+	}
+	h := NewCredentialHandler(mockDB, keys, newTestLogger())
+	req, rec := credRequest(http.MethodPost, "/v1/credentials/rekey", "", uuid.New(), "admin")
+	h.Rekey(rec, req)
+	assertStatusCode(t, rec, http.StatusOK)
+	var resp userapi.RekeyCredentialsResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp.KeyID != "k2" || resp.Rewrapped != 2 || resp.Failed != 1 {
+		t.Errorf("rekey = %+v", resp)
+	}
+	current, _ := credcrypt.New("k2", testCredKeyK2)
+	for _, c := range mockDB.ApiCredentials[:2] {
+		if *c.CredentialKID != "k2" {
+			t.Errorf("credential %s still under %s", c.ID, *c.CredentialKID)
+		}
+		if _, err := current.Open(c.ID, *c.CredentialKID, c.CredentialCiphertext); err != nil {
+			t.Errorf("open after rekey: %v", err)
+		}
+	}
+}
+
+func TestCredentialHandler_NoKeys(t *testing.T) {
+	h := NewCredentialHandler(testutil.NewMockDB(), nil, newTestLogger())
+	req, rec := credRequest(http.MethodPost, "/v1/credentials", `{"provider":"openai","credential_name":"n","secret":"s"}`, uuid.New(), "alice")
+	h.CreateCredential(rec, req)
+	assertStatusCode(t, rec, http.StatusServiceUnavailable)
+	req, rec = credRequest(http.MethodGet, "/v1/credentials", "", uuid.New(), "alice")
+	h.ListCredentials(rec, req)
+	assertStatusCode(t, rec, http.StatusOK)
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+// Access control audit actions for API Egress limit management.
+const (
+	ActionEgressLimitCreate = "egress.limit.create"
+	ActionEgressLimitUpdate = "egress.limit.update"
+	ActionEgressLimitDelete = "egress.limit.delete"
+	ResourceTypeEgressLimit = "api_egress_limit"
+)
+
+// microUSD is the number of micro-dollars in a dollar; budgets are stored as integers of micro-dollars.
+const microUSD = 1e6
+
+// EgressLimitHandler serves API Egress rate limit, concurrency cap, and spend budget administration
+// (api_egress_server.md). Reads require policy.read; changes require policy.manage and are audited.
+type EgressLimitHandler struct {
+	db     database.Store
+	logger *slog.Logger
+}
+
+// NewEgressLimitHandler creates an egress limit handler.
+func NewEgressLimitHandler(db database.Store, logger *slog.Logger) *EgressLimitHandler {
+	return &EgressLimitHandler{db: db, logger: logger}
+}
+
+// List handles GET /v1/egress/limits. Optional filter: provider.
+func (h *EgressLimitHandler) List(w http.ResponseWriter, r *http.Request) {
+	limits, err := h.db.ListApiEgressLimits(r.Context(), strings.ToLower(r.URL.Query().Get("provider")))
+	if err != nil {
+		h.logger.Error("list api egress limits", "error", err)
+		WriteInternalError(w, "Failed to list egress limits")
+		return
+	}
+	resp := userapi.ListEgressLimitsResponse{Limits: make([]userapi.EgressLimitResponse, 0, len(limits))}
+	for _, l := range limits {
+		resp.Limits = append(resp.Limits, egressLimitToResponse(l))
+	}
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+// Get handles GET /v1/egress/limits/{id}.
+func (h *EgressLimitHandler) Get(w http.ResponseWriter, r *http.Request) {
+	l := h.resolveLimit(w, r)
+	if l == nil {
+		return
+	}
+	WriteJSON(w, http.StatusOK, egressLimitToResponse(l))
+}
+
+// Create handles POST /v1/egress/limits and returns 201 with the limit.
+func (h *EgressLimitHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	l, ok := decodeEgressLimit(w, r)
+	if !ok {
+		return
+	}
+	updatedBy := GetHandleFromContext(ctx)
+	l.UpdatedBy = &updatedBy
+	if err := h.db.CreateApiEgressLimit(ctx, l); err != nil {
+		h.logger.Error("create api egress limit", "error", err)
+		WriteInternalError(w, "Failed to create egress limit")
+		return
+	}
+	h.audit(ctx, ActionEgressLimitCreate, l)
+	WriteJSON(w, http.StatusCreated, egressLimitToResponse(l))
+}
+
+// Update handles PUT /v1/egress/limits/{id}. The body replaces the whole limit; usage already counted in the
+// current minute and month is kept.
+func (h *EgressLimitHandler) Update(w http.ResponseWriter, r *http.Request) {
+	existing := h.resolveLimit(w, r)
+	if existing == nil {
+		return
+	}
+	ctx := r.Context()
+	l, ok := decodeEgressLimit(w, r)
+	if !ok {
+		return
+	}
+	updatedBy := GetHandleFromContext(ctx)
+	l.ID, l.CreatedAt, l.UpdatedBy = existing.ID, existing.CreatedAt, &updatedBy
+	if err := h.db.UpdateApiEgressLimit(ctx, l); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			WriteNotFound(w, "Egress limit not found")
+			return
+		}
+		h.logger.Error("update api egress limit", "error", err, "limit_id", l.ID)
+		WriteInternalError(w, "Failed to update egress limit")
+		return
+	}
+	h.audit(ctx, ActionEgressLimitUpdate, l)
+	WriteJSON(w, http.StatusOK, egressLimitToResponse(l))
+}
+
+// Delete handles DELETE /v1/egress/limits/{id}.
+func (h *EgressLimitHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	l := h.resolveLimit(w, r)
+	if l == nil {
+		return
+	}
+	ctx := r.Context()
+	if err := h.db.DeleteApiEgressLimit(ctx, l.ID); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			WriteNotFound(w, "Egress limit not found")
+			return
+		}
+		h.logger.Error("delete api egress limit", "error", err, "limit_id", l.ID)
+		WriteInternalError(w, "Failed to delete egress limit")
+		return
+	}
+	h.audit(ctx, ActionEgressLimitDelete, l)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// resolveLimit loads {id}. Writes 400/404/500 and returns nil otherwise.
+func (h *EgressLimitHandler) resolveLimit(w http.ResponseWriter, r *http.Request) *models.ApiEgressLimit {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		WriteBadRequest(w, "Invalid limit id")
+		return nil
+	}
+	l, err := h.db.GetApiEgressLimitByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			WriteNotFound(w, "Egress limit not found")
+			return nil
+		}
+		h.logger.Error("get api egress limit", "error", err, "limit_id", id)
+		WriteInternalError(w, "Failed to get egress limit")
+		return nil
+	}
+	return l
+}
+
+// decodeEgressLimit decodes and validates an EgressLimitRequest body. Writes 400 and returns false when invalid.
+func decodeEgressLimit(w http.ResponseWriter, r *http.Request) (*models.ApiEgressLimit, bool) {
+	var req userapi.EgressLimitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteBadRequest(w, "Invalid request body")
+		return nil, false
+	}
+	l, detail := egressLimitFromRequest(&req)
+	if l == nil {
+		WriteBadRequest(w, detail)
+		return nil, false
+	}
+	return l, true
+}
+
+func egressLimitFromRequest(req *userapi.EgressLimitRequest) (*models.ApiEgressLimit, string) {
+	l := &models.ApiEgressLimit{
+		Provider:              strings.TrimSpace(strings.ToLower(req.Provider)),
+		Operation:             strings.TrimSpace(strings.ToLower(req.Operation)),
+		ScopeType:             req.ScopeType,
+		RequestsPerMinute:     req.RequestsPerMinute,
+		MaxConcurrent:         req.MaxConcurrent,
+		MonthlyBudgetMicroUSD: int64(math.Round(req.MonthlyBudgetUSD * microUSD)),
+		CostPerCallMicroUSD:   int64(math.Round(req.CostPerCallUSD * microUSD)),
+	}
+	if l.Provider == "" {
+		return nil, "provider is required"
+	}
+	hasScopeID := req.ScopeID != nil && *req.ScopeID != ""
+	switch req.ScopeType {
+	case userapi.EgressLimitScopeSystem:
+		if hasScopeID {
+			return nil, "scope_id must be empty for system limits"
+		}
+	case userapi.EgressLimitScopeUser, userapi.EgressLimitScopeProject:
+		if hasScopeID {
+			id, err := uuid.Parse(*req.ScopeID)
+			if err != nil {
+				return nil, "Invalid scope_id"
+			}
+			l.ScopeID = &id
+		}
+	default:
+		return nil, "scope_type must be system, user, or project"
+	}
+	if l.RequestsPerMinute < 0 || l.MaxConcurrent < 0 || l.MonthlyBudgetMicroUSD < 0 || l.CostPerCallMicroUSD < 0 {
+		return nil, "limits must not be negative"
+	}
+	if l.RequestsPerMinute == 0 && l.MaxConcurrent == 0 && l.MonthlyBudgetMicroUSD == 0 {
+		return nil, "set at least one of requests_per_minute, max_concurrent, monthly_budget_usd"
+	}
+	if l.MonthlyBudgetMicroUSD > 0 && l.CostPerCallMicroUSD == 0 {
+		return nil, "cost_per_call_usd is required with monthly_budget_usd"
+	}
+	return l, ""
+}
+
+// audit records a limit change in access_control_audit_log; the reason is the limit as JSON.
+func (h *EgressLimitHandler) audit(ctx context.Context, action string, l *models.ApiEgressLimit) {
+	rec := &models.AccessControlAuditLog{
+		SubjectType:  database.SubjectTypeUser,
+		SubjectID:    getUserIDFromContext(ctx),
+		Action:       action,
+		ResourceType: ResourceTypeEgressLimit,
+		Resource:     l.ID.String(),
+		Decision:     "allow",
+	}
+	if snapshot, err := json.Marshal(egressLimitToResponse(l)); err == nil {
+		s := string(snapshot)
+		rec.Reason = &s
+	}
+	if err := h.db.CreateAccessControlAuditLog(ctx, rec); err != nil {
+		h.logger.Warn("egress limit audit log failed", "error", err, "action", action)
+	}
+}
+
+func egressLimitToResponse(l *models.ApiEgressLimit) userapi.EgressLimitResponse {
+	return userapi.EgressLimitResponse{
+		ID:                l.ID.String(),
+		Provider:          l.Provider,
+		Operation:         l.Operation,
+		ScopeType:         l.ScopeType,
+		ScopeID:           uuidString(l.ScopeID),
+		RequestsPerMinute: l.RequestsPerMinute,
+		MaxConcurrent:     l.MaxConcurrent,
+		MonthlyBudgetUSD:  float64(l.MonthlyBudgetMicroUSD) / microUSD,
+		CostPerCallUSD:    float64(l.CostPerCallMicroUSD) / microUSD,
+		CreatedAt:         l.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:         l.UpdatedAt.UTC().Format(time.RFC3339),
+		UpdatedBy:         l.UpdatedBy,
+	}
+}
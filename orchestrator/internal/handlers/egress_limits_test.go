@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
+)
+
+func TestEgressLimitHandler_CRUD(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	h := NewEgressLimitHandler(mockDB, newTestLogger())
+	project := uuid.NewString()
+
+	create := func(body string, want int) userapi.EgressLimitResponse {
+		t.Helper()
+		req, rec := credRequest(http.MethodPost, "/v1/egress/limits", body, uuid.New(), "admin")
+		h.Create(rec, req)
+		assertStatusCode(t, rec, want)
+		var resp userapi.EgressLimitResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		return resp
+	}
+	limit := create(`{"provider":"OpenAI","operation":"chat_completions","scope_type":"project","scope_id":"`+project+`",`+
+		`"requests_per_minute":60,"monthly_budget_usd":25.5,"cost_per_call_usd":0.002}`, http.StatusCreated)
+	if limit.Provider != "openai" || limit.ScopeID == nil || *limit.ScopeID != project || limit.MonthlyBudgetUSD != 25.5 ||
+		limit.CostPerCallUSD != 0.002 || limit.UpdatedBy == nil || *limit.UpdatedBy != "admin" {
+		t.Errorf("limit = %+v", limit)
+	}
+	if got := mockDB.ApiEgressLimits[0]; got.MonthlyBudgetMicroUSD != 25500000 || got.CostPerCallMicroUSD != 2000 {
+		t.Errorf("stored = %+v", got)
+	}
+	for _, body := range []string{
+		`{"scope_type":"system","requests_per_minute":1}`,
+		`{"provider":"openai","scope_type":"system","scope_id":"` + project + `","requests_per_minute":1}`,
+		`{"provider":"openai","scope_type":"group","requests_per_minute":1}`,
+		`{"provider":"openai","scope_type":"user","scope_id":"nope","requests_per_minute":1}`,
+		`{"provider":"openai","scope_type":"user"}`,
+		`{"provider":"openai","scope_type":"user","max_concurrent":-1}`,
+		`{"provider":"openai","scope_type":"user","monthly_budget_usd":10}`,
+		`not json`,
+	} {
+		create(body, http.StatusBadRequest)
+	}
+	create(`{"provider":"github","scope_type":"user","max_concurrent":2}`, http.StatusCreated)
+
+	req, rec := credRequest(http.MethodGet, "/v1/egress/limits?provider=openai", "", uuid.New(), "admin")
+	h.List(rec, req)
+	var list userapi.ListEgressLimitsResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &list)
+	if len(list.Limits) != 1 || list.Limits[0].ID != limit.ID {
+		t.Errorf("list = %+v", list.Limits)
+	}
+
+	req, rec = credRequest(http.MethodPut, "/v1/egress/limits/"+limit.ID,
+		`{"provider":"openai","scope_type":"system","max_concurrent":4}`, uuid.New(), "admin")
+	req.SetPathValue("id", limit.ID)
+	h.Update(rec, req)
+	assertStatusCode(t, rec, http.StatusOK)
+	stored, _ := mockDB.GetApiEgressLimitByID(context.Background(), uuid.MustParse(limit.ID))
+	if stored.ScopeType != database.ApiEgressScopeSystem || stored.ScopeID != nil || stored.Operation != "" ||
+		stored.RequestsPerMinute != 0 || stored.MaxConcurrent != 4 || stored.MonthlyBudgetMicroUSD != 0 {
+		t.Errorf("updated = %+v", stored)
+	}
+
+	get := func(id string, want int) {
+		t.Helper()
+		req, rec := credRequest(http.MethodGet, "/v1/egress/limits/"+id, "", uuid.New(), "admin")
+		req.SetPathValue("id", id)
+		h.Get(rec, req)
+		assertStatusCode(t, rec, want)
+	}
+	del := func(id string, want int) {
+		t.Helper()
+		req, rec := credRequest(http.MethodDelete, "/v1/egress/limits/"+id, "", uuid.New(), "admin")
+		req.SetPathValue("id", id)
+		h.Delete(rec, req)
+		assertStatusCode(t, rec, want)
+	}
+	get(limit.ID, http.StatusOK)
+	del(limit.ID, http.StatusNoContent)
+	del(limit.ID, http.StatusNotFound)
+	get(limit.ID, http.StatusNotFound)
+	del("not-a-uuid", http.StatusBadRequest)
+	if n := len(mockDB.AccessControlAuditLogs); n != 4 {
+		t.Errorf("audit entries = %d, want 4", n)
+	}
+}
@@ -5,6 +5,8 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/problem"
 )
@@ -53,6 +55,17 @@ func WriteTooManyRequests(w http.ResponseWriter, detail string) {
 	WriteError(w, http.StatusTooManyRequests, problem.TypeRateLimit, "Too Many Requests", detail)
 }
 
+// WriteQuotaExceeded writes a 429 quota_exceeded error with a Retry-After header (seconds).
+func WriteQuotaExceeded(w http.ResponseWriter, retryAfter time.Duration, detail string) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+	WriteError(w, http.StatusTooManyRequests, problem.TypeQuotaExceeded, "Quota Exceeded", detail)
+}
+
+// WritePayloadTooLarge writes a 413 error.
+func WritePayloadTooLarge(w http.ResponseWriter, detail string) {
+	WriteError(w, http.StatusRequestEntityTooLarge, problem.TypeValidation, "Payload Too Large", detail)
+}
+
 // WriteInternalError writes a 500 error.
 func WriteInternalError(w http.ResponseWriter, detail string) {
 	WriteError(w, http.StatusInternalServerError, problem.TypeInternal, "Internal Server Error", detail)
@@ -0,0 +1,500 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/rbac"
+)
+
+// Access control audit actions for group and role binding management.
+const (
+	ActionGroupCreate        = "group.create"
+	ActionGroupMemberAdd     = "group.member.add"
+	ActionGroupMemberRemove  = "group.member.remove"
+	ActionRoleBindingCreate  = "role_binding.create"
+	ActionRoleBindingDisable = "role_binding.disable"
+	ResourceTypeGroup        = "group"
+	ResourceTypeRoleBinding  = "role_binding"
+)
+
+var groupSlugRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// GroupHandler serves groups, memberships, roles, and role bindings (rbac_and_groups.md).
+// Routes are gated by groups.read or groups.manage; without groups.manage a caller only sees groups it belongs to.
+type GroupHandler struct {
+	db     database.Store
+	logger *slog.Logger
+}
+
+// NewGroupHandler creates a group handler.
+func NewGroupHandler(db database.Store, logger *slog.Logger) *GroupHandler {
+	return &GroupHandler{db: db, logger: logger}
+}
+
+// ListGroups handles GET /v1/groups.
+func (h *GroupHandler) ListGroups(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := getUserIDFromContext(ctx)
+	if userID == nil {
+		WriteUnauthorized(w, "Authentication required")
+		return
+	}
+	var memberID *uuid.UUID
+	if !rbac.FromContext(ctx).Has(rbac.PermGroupsManage) {
+		memberID = userID
+	}
+	groups, err := h.db.ListGroups(ctx, memberID)
+	if err != nil {
+		h.logger.Error("list groups", "error", err)
+		WriteInternalError(w, "Failed to list groups")
+		return
+	}
+	resp := userapi.ListGroupsResponse{Groups: make([]userapi.GroupResponse, 0, len(groups))}
+	for _, g := range groups {
+		resp.Groups = append(resp.Groups, groupToResponse(g))
+	}
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+// CreateGroup handles POST /v1/groups and returns 201 with the group.
+func (h *GroupHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req userapi.CreateGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteBadRequest(w, "Invalid request body")
+		return
+	}
+	slug := strings.TrimSpace(req.Slug)
+	if !groupSlugRe.MatchString(slug) {
+		WriteBadRequest(w, "slug is required (lowercase letters, digits, '-' or '_')")
+		return
+	}
+	displayName := strings.TrimSpace(req.DisplayName)
+	if displayName == "" {
+		displayName = slug
+	}
+	updatedBy := GetHandleFromContext(ctx)
+	managedBy := database.ManagedByLocal
+	g := &models.Group{Slug: slug, DisplayName: displayName, IsActive: true, ManagedBy: &managedBy, UpdatedBy: &updatedBy}
+	if err := h.db.CreateGroup(ctx, g); err != nil {
+		if errors.Is(err, database.ErrExists) {
+			WriteConflict(w, "A group with this slug already exists")
+			return
+		}
+		h.logger.Error("create group", "error", err)
+		WriteInternalError(w, "Failed to create group")
+		return
+	}
+	h.audit(ctx, ActionGroupCreate, ResourceTypeGroup, g.ID.String())
+	WriteJSON(w, http.StatusCreated, groupToResponse(g))
+}
+
+// GetGroup handles GET /v1/groups/{id}.
+func (h *GroupHandler) GetGroup(w http.ResponseWriter, r *http.Request) {
+	g := h.resolveGroup(w, r)
+	if g == nil {
+		return
+	}
+	WriteJSON(w, http.StatusOK, groupToResponse(g))
+}
+
+// ListMembers handles GET /v1/groups/{id}/members.
+func (h *GroupHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	g := h.resolveGroup(w, r)
+	if g == nil {
+		return
+	}
+	ctx := r.Context()
+	members, err := h.db.ListGroupMembers(ctx, g.ID)
+	if err != nil {
+		h.logger.Error("list group members", "error", err, "group_id", g.ID)
+		WriteInternalError(w, "Failed to list group members")
+		return
+	}
+	resp := userapi.ListGroupMembersResponse{Members: make([]userapi.GroupMemberResponse, 0, len(members))}
+	for _, m := range members {
+		item := userapi.GroupMemberResponse{UserID: m.UserID.String(), CreatedAt: m.CreatedAt.UTC().Format(time.RFC3339)}
+		if u, err := h.db.GetUserByID(ctx, m.UserID); err == nil {
+			item.Handle = u.Handle
+		}
+		resp.Members = append(resp.Members, item)
+	}
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+// AddMember handles POST /v1/groups/{id}/members. Adding an existing member succeeds without change.
+func (h *GroupHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	g := h.resolveGroup(w, r)
+	if g == nil {
+		return
+	}
+	ctx := r.Context()
+	var req userapi.AddGroupMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteBadRequest(w, "Invalid request body")
+		return
+	}
+	user, detail := h.lookupMember(ctx, &req)
+	if detail != "" {
+		WriteBadRequest(w, detail)
+		return
+	}
+	if user == nil {
+		WriteNotFound(w, "User not found")
+		return
+	}
+	m, err := h.db.AddGroupMember(ctx, g.ID, user.ID, GetHandleFromContext(ctx))
+	if err != nil {
+		h.logger.Error("add group member", "error", err, "group_id", g.ID)
+		WriteInternalError(w, "Failed to add group member")
+		return
+	}
+	h.audit(ctx, ActionGroupMemberAdd, ResourceTypeGroup, g.ID.String()+"/members/"+user.ID.String())
+	WriteJSON(w, http.StatusOK, userapi.GroupMemberResponse{
+		UserID: user.ID.String(), Handle: user.Handle, CreatedAt: m.CreatedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// lookupMember resolves the user named by req; returns a problem detail for a bad request, or a nil user when
+// no such user exists.
+func (h *GroupHandler) lookupMember(ctx context.Context, req *userapi.AddGroupMemberRequest) (*models.User, string) {
+	if (req.UserID == "") == (req.Handle == "") {
+		return nil, "Set exactly one of user_id or handle"
+	}
+	var (
+		user *models.User
+		err  error
+	)
+	if req.UserID != "" {
+		id, perr := uuid.Parse(req.UserID)
+		if perr != nil {
+			return nil, "Invalid user_id"
+		}
+		user, err = h.db.GetUserByID(ctx, id)
+	} else {
+		user, err = h.db.GetUserByHandle(ctx, req.Handle)
+	}
+	if err != nil {
+		return nil, ""
+	}
+	return user, ""
+}
+
+// RemoveMember handles DELETE /v1/groups/{id}/members/{user_id}. The membership is deactivated, not deleted.
+func (h *GroupHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	g := h.resolveGroup(w, r)
+	if g == nil {
+		return
+	}
+	userID, err := uuid.Parse(r.PathValue("user_id"))
+	if err != nil {
+		WriteBadRequest(w, "Invalid user id")
+		return
+	}
+	ctx := r.Context()
+	if err := h.db.RemoveGroupMember(ctx, g.ID, userID, GetHandleFromContext(ctx)); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			WriteNotFound(w, "Membership not found")
+			return
+		}
+		h.logger.Error("remove group member", "error", err, "group_id", g.ID)
+		WriteInternalError(w, "Failed to remove group member")
+		return
+	}
+	h.audit(ctx, ActionGroupMemberRemove, ResourceTypeGroup, g.ID.String()+"/members/"+userID.String())
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListRoles handles GET /v1/roles.
+func (h *GroupHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.db.ListRoles(r.Context())
+	if err != nil {
+		h.logger.Error("list roles", "error", err)
+		WriteInternalError(w, "Failed to list roles")
+		return
+	}
+	resp := userapi.ListRolesResponse{Roles: make([]userapi.RoleResponse, 0, len(roles))}
+	for _, role := range roles {
+		resp.Roles = append(resp.Roles, userapi.RoleResponse{
+			ID: role.ID.String(), Name: role.Name, Description: role.Description, Permissions: rbac.RolePermissions(role.Name),
+		})
+	}
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+// ListRoleBindings handles GET /v1/role-bindings. Optional filters: subject_type and subject_id (together).
+func (h *GroupHandler) ListRoleBindings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	var subjects []database.AccessSubject
+	if st, sid := q.Get("subject_type"), q.Get("subject_id"); st != "" || sid != "" {
+		id, err := uuid.Parse(sid)
+		if err != nil || (st != userapi.RoleBindingSubjectUser && st != userapi.RoleBindingSubjectGroup) {
+			WriteBadRequest(w, "subject_type (user or group) and subject_id must be set together")
+			return
+		}
+		subjects = []database.AccessSubject{{Type: st, ID: id}}
+	}
+	bindings, err := h.db.ListRoleBindings(ctx, subjects)
+	if err != nil {
+		h.logger.Error("list role bindings", "error", err)
+		WriteInternalError(w, "Failed to list role bindings")
+		return
+	}
+	names, ok := h.roleNames(ctx, w)
+	if !ok {
+		return
+	}
+	resp := userapi.ListRoleBindingsResponse{RoleBindings: make([]userapi.RoleBindingResponse, 0, len(bindings))}
+	for _, b := range bindings {
+		resp.RoleBindings = append(resp.RoleBindings, roleBindingToResponse(b, names[b.RoleID]))
+	}
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+// CreateRoleBinding handles POST /v1/role-bindings and returns 201 with the binding.
+func (h *GroupHandler) CreateRoleBinding(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req userapi.CreateRoleBindingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteBadRequest(w, "Invalid request body")
+		return
+	}
+	b, detail := bindingFromRequest(&req)
+	if b == nil {
+		WriteBadRequest(w, detail)
+		return
+	}
+	roles, err := h.db.ListRoles(ctx)
+	if err != nil {
+		h.logger.Error("list roles", "error", err)
+		WriteInternalError(w, "Failed to list roles")
+		return
+	}
+	for _, role := range roles {
+		if role.Name == req.Role {
+			b.RoleID = role.ID
+		}
+	}
+	if b.RoleID == uuid.Nil {
+		WriteBadRequest(w, "Unknown role")
+		return
+	}
+	if !h.subjectExists(ctx, w, b) {
+		return
+	}
+	updatedBy := GetHandleFromContext(ctx)
+	managedBy := database.ManagedByLocal
+	b.UpdatedBy, b.ManagedBy = &updatedBy, &managedBy
+	if err := h.db.CreateRoleBinding(ctx, b); err != nil {
+		if errors.Is(err, database.ErrExists) {
+			WriteConflict(w, "This role binding already exists")
+			return
+		}
+		h.logger.Error("create role binding", "error", err)
+		WriteInternalError(w, "Failed to create role binding")
+		return
+	}
+	h.audit(ctx, ActionRoleBindingCreate, ResourceTypeRoleBinding, b.ID.String())
+	WriteJSON(w, http.StatusCreated, roleBindingToResponse(b, req.Role))
+}
+
+func bindingFromRequest(req *userapi.CreateRoleBindingRequest) (*models.RoleBinding, string) {
+	if req.SubjectType != userapi.RoleBindingSubjectUser && req.SubjectType != userapi.RoleBindingSubjectGroup {
+		return nil, "subject_type must be user or group"
+	}
+	subjectID, err := uuid.Parse(req.SubjectID)
+	if err != nil {
+		return nil, "Invalid subject_id"
+	}
+	b := &models.RoleBinding{SubjectType: req.SubjectType, SubjectID: subjectID, ScopeType: req.ScopeType}
+	if b.ScopeType == "" {
+		b.ScopeType = userapi.RoleBindingScopeSystem
+	}
+	switch b.ScopeType {
+	case userapi.RoleBindingScopeSystem:
+		if req.ScopeID != nil && *req.ScopeID != "" {
+			return nil, "scope_id must be empty for system scope"
+		}
+	case userapi.RoleBindingScopeProject:
+		if req.ScopeID == nil {
+			return nil, "scope_id is required for project scope"
+		}
+		id, err := uuid.Parse(*req.ScopeID)
+		if err != nil {
+			return nil, "Invalid scope_id"
+		}
+		b.ScopeID = &id
+	default:
+		return nil, "scope_type must be system or project"
+	}
+	return b, ""
+}
+
+// subjectExists writes 404 and returns false when the binding's user or group does not exist.
+func (h *GroupHandler) subjectExists(ctx context.Context, w http.ResponseWriter, b *models.RoleBinding) bool {
+	var err error
+	if b.SubjectType == userapi.RoleBindingSubjectUser {
+		_, err = h.db.GetUserByID(ctx, b.SubjectID)
+	} else {
+		_, err = h.db.GetGroupByID(ctx, b.SubjectID)
+	}
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, database.ErrNotFound):
+		WriteNotFound(w, "Subject not found")
+	default:
+		h.logger.Error("get role binding subject", "error", err)
+		WriteInternalError(w, "Failed to get subject")
+	}
+	return false
+}
+
+// DisableRoleBinding handles DELETE /v1/role-bindings/{id}. The binding is deactivated, not deleted. The last
+// active system-scope admin binding cannot be removed (409), so the deployment keeps an administrator.
+func (h *GroupHandler) DisableRoleBinding(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		WriteBadRequest(w, "Invalid role binding id")
+		return
+	}
+	bindings, err := h.db.ListRoleBindings(ctx, nil)
+	if err != nil {
+		h.logger.Error("list role bindings", "error", err)
+		WriteInternalError(w, "Failed to list role bindings")
+		return
+	}
+	names, ok := h.roleNames(ctx, w)
+	if !ok {
+		return
+	}
+	var target *models.RoleBinding
+	admins := 0
+	for _, b := range bindings {
+		if b.ID == id {
+			target = b
+		}
+		if names[b.RoleID] == database.RoleAdmin && b.ScopeType == database.ScopeTypeSystem {
+			admins++
+		}
+	}
+	if target == nil {
+		WriteNotFound(w, "Role binding not found")
+		return
+	}
+	if names[target.RoleID] == database.RoleAdmin && target.ScopeType == database.ScopeTypeSystem && admins == 1 {
+		WriteConflict(w, "Cannot remove the last admin role binding")
+		return
+	}
+	if _, err := h.db.DisableRoleBinding(ctx, id, GetHandleFromContext(ctx)); err != nil {
+		h.logger.Error("disable role binding", "error", err, "role_binding_id", id)
+		WriteInternalError(w, "Failed to disable role binding")
+		return
+	}
+	h.audit(ctx, ActionRoleBindingDisable, ResourceTypeRoleBinding, id.String())
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *GroupHandler) roleNames(ctx context.Context, w http.ResponseWriter) (map[uuid.UUID]string, bool) {
+	roles, err := h.db.ListRoles(ctx)
+	if err != nil {
+		h.logger.Error("list roles", "error", err)
+		WriteInternalError(w, "Failed to list roles")
+		return nil, false
+	}
+	names := make(map[uuid.UUID]string, len(roles))
+	for _, role := range roles {
+		names[role.ID] = role.Name
+	}
+	return names, true
+}
+
+// resolveGroup loads {id}; callers without groups.manage must be members. Writes 400/401/404/500 and returns nil
+// otherwise.
+func (h *GroupHandler) resolveGroup(w http.ResponseWriter, r *http.Request) *models.Group {
+	ctx := r.Context()
+	if getUserIDFromContext(ctx) == nil {
+		WriteUnauthorized(w, "Authentication required")
+		return nil
+	}
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		WriteBadRequest(w, "Invalid group id")
+		return nil
+	}
+	a := rbac.FromContext(ctx)
+	if !a.Has(rbac.PermGroupsManage) && !a.InGroup(id) {
+		WriteNotFound(w, "Group not found")
+		return nil
+	}
+	g, err := h.db.GetGroupByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			WriteNotFound(w, "Group not found")
+			return nil
+		}
+		h.logger.Error("get group", "error", err, "group_id", id)
+		WriteInternalError(w, "Failed to get group")
+		return nil
+	}
+	return g
+}
+
+// audit records a group or role binding change in access_control_audit_log.
+func (h *GroupHandler) audit(ctx context.Context, action, resourceType, resource string) {
+	rec := &models.AccessControlAuditLog{
+		SubjectType:  database.SubjectTypeUser,
+		SubjectID:    getUserIDFromContext(ctx),
+		Action:       action,
+		ResourceType: resourceType,
+		Resource:     resource,
+		Decision:     "allow",
+	}
+	if err := h.db.CreateAccessControlAuditLog(ctx, rec); err != nil {
+		h.logger.Warn("group audit log failed", "error", err, "action", action)
+	}
+}
+
+func groupToResponse(g *models.Group) userapi.GroupResponse {
+	return userapi.GroupResponse{
+		ID:          g.ID.String(),
+		Slug:        g.Slug,
+		DisplayName: g.DisplayName,
+		IsActive:    g.IsActive,
+		ManagedBy:   g.ManagedBy,
+		CreatedAt:   g.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:   g.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func roleBindingToResponse(b *models.RoleBinding, role string) userapi.RoleBindingResponse {
+	resp := userapi.RoleBindingResponse{
+		ID:          b.ID.String(),
+		SubjectType: b.SubjectType,
+		SubjectID:   b.SubjectID.String(),
+		RoleID:      b.RoleID.String(),
+		Role:        role,
+		ScopeType:   b.ScopeType,
+		CreatedAt:   b.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedBy:   b.UpdatedBy,
+	}
+	if b.ScopeID != nil {
+		s := b.ScopeID.String()
+		resp.ScopeID = &s
+	}
+	return resp
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
+)
+
+func createTestGroup(t *testing.T, h *GroupHandler, slug string) userapi.GroupResponse {
+	t.Helper()
+	req, rec := credRequest(http.MethodPost, "/v1/groups", `{"slug":"`+slug+`"}`, uuid.New(), "admin")
+	h.CreateGroup(rec, req)
+	assertStatusCode(t, rec, http.StatusCreated)
+	var g userapi.GroupResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &g); err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestGroupHandler_CreateAndMembership(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	h := NewGroupHandler(mockDB, newTestLogger())
+	alice, _ := mockDB.CreateUser(context.Background(), "alice", nil)
+	mockDB.AddUser(alice)
+
+	g := createTestGroup(t, h, "eng")
+	if g.DisplayName != "eng" || !g.IsActive {
+		t.Errorf("group = %+v", g)
+	}
+	req, rec := credRequest(http.MethodPost, "/v1/groups", `{"slug":"eng"}`, uuid.New(), "admin")
+	h.CreateGroup(rec, req)
+	assertStatusCode(t, rec, http.StatusConflict)
+	req, rec = credRequest(http.MethodPost, "/v1/groups", `{"slug":"Bad Slug"}`, uuid.New(), "admin")
+	h.CreateGroup(rec, req)
+	assertStatusCode(t, rec, http.StatusBadRequest)
+
+	member := func(body string, want int) {
+		t.Helper()
+		req, rec := credRequest(http.MethodPost, "/v1/groups/"+g.ID+"/members", body, uuid.New(), "admin")
+		req.SetPathValue("id", g.ID)
+		h.AddMember(rec, req)
+		assertStatusCode(t, rec, want)
+	}
+	member(`{"handle":"alice"}`, http.StatusOK)
+	member(`{"handle":"alice"}`, http.StatusOK) // idempotent
+	member(`{"handle":"nobody"}`, http.StatusNotFound)
+	member(`{}`, http.StatusBadRequest)
+
+	// A non-manager sees only its own groups and cannot read others.
+	createTestGroup(t, h, "ops")
+	groupID := uuid.MustParse(g.ID)
+	req, rec = credRequest(http.MethodGet, "/v1/groups", "", alice.ID, "alice", groupID)
+	h.ListGroups(rec, req)
+	var list userapi.ListGroupsResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &list)
+	if len(list.Groups) != 1 || list.Groups[0].Slug != "eng" {
+		t.Errorf("alice sees %+v", list.Groups)
+	}
+	req, rec = credRequest(http.MethodGet, "/v1/groups/"+g.ID+"/members", "", alice.ID, "alice", groupID)
+	req.SetPathValue("id", g.ID)
+	h.ListMembers(rec, req)
+	var members userapi.ListGroupMembersResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &members)
+	if len(members.Members) != 1 || members.Members[0].Handle != "alice" {
+		t.Errorf("members = %+v", members.Members)
+	}
+	req, rec = credRequest(http.MethodGet, "/v1/groups/"+g.ID, "", uuid.New(), "bob")
+	req.SetPathValue("id", g.ID)
+	h.GetGroup(rec, req)
+	assertStatusCode(t, rec, http.StatusNotFound)
+
+	req, rec = credRequest(http.MethodDelete, "/v1/groups/"+g.ID+"/members/"+alice.ID.String(), "", uuid.New(), "admin")
+	req.SetPathValue("id", g.ID)
+	req.SetPathValue("user_id", alice.ID.String())
+	h.RemoveMember(rec, req)
+	assertStatusCode(t, rec, http.StatusNoContent)
+	if ids, _ := mockDB.ListGroupIDsForUser(context.Background(), alice.ID); len(ids) != 0 {
+		t.Errorf("groups after removal = %v", ids)
+	}
+}
+
+func TestGroupHandler_RoleBindings(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	h := NewGroupHandler(mockDB, newTestLogger())
+	alice, _ := mockDB.CreateUser(context.Background(), "alice", nil)
+	mockDB.AddUser(alice)
+
+	create := func(body string, want int) userapi.RoleBindingResponse {
+		t.Helper()
+		req, rec := credRequest(http.MethodPost, "/v1/role-bindings", body, uuid.New(), "admin")
+		h.CreateRoleBinding(rec, req)
+		assertStatusCode(t, rec, want)
+		var b userapi.RoleBindingResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &b)
+		return b
+	}
+	admin := create(`{"subject_type":"user","subject_id":"`+alice.ID.String()+`","role":"admin"}`, http.StatusCreated)
+	if admin.Role != database.RoleAdmin || admin.ScopeType != database.ScopeTypeSystem {
+		t.Errorf("binding = %+v", admin)
+	}
+	create(`{"subject_type":"user","subject_id":"`+alice.ID.String()+`","role":"admin"}`, http.StatusConflict)
+	create(`{"subject_type":"user","subject_id":"`+alice.ID.String()+`","role":"root"}`, http.StatusBadRequest)
+	create(`{"subject_type":"user","subject_id":"`+uuid.NewString()+`","role":"viewer"}`, http.StatusNotFound)
+	create(`{"subject_type":"user","subject_id":"`+alice.ID.String()+`","role":"viewer","scope_type":"project"}`, http.StatusBadRequest)
+	project := create(`{"subject_type":"user","subject_id":"`+alice.ID.String()+`","role":"operator","scope_type":"project","scope_id":"`+uuid.NewString()+`"}`, http.StatusCreated)
+	if project.ScopeID == nil {
+		t.Errorf("project binding = %+v", project)
+	}
+
+	req, rec := credRequest(http.MethodGet, "/v1/role-bindings?subject_type=user&subject_id="+alice.ID.String(), "", uuid.New(), "admin")
+	h.ListRoleBindings(rec, req)
+	var list userapi.ListRoleBindingsResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &list)
+	if len(list.RoleBindings) != 2 {
+		t.Errorf("bindings = %+v", list.RoleBindings)
+	}
+
+	disable := func(id string, want int) {
+		t.Helper()
+		req, rec := credRequest(http.MethodDelete, "/v1/role-bindings/"+id, "", uuid.New(), "admin")
+		req.SetPathValue("id", id)
+		h.DisableRoleBinding(rec, req)
+		assertStatusCode(t, rec, want)
+	}
+	disable(admin.ID, http.StatusConflict) // last system admin
+	disable(project.ID, http.StatusNoContent)
+	disable(uuid.NewString(), http.StatusNotFound)
+}
+
+func TestGroupHandler_ListRolesIncludesPermissions(t *testing.T) {
+	h := NewGroupHandler(testutil.NewMockDB(), newTestLogger())
+	req, rec := credRequest(http.MethodGet, "/v1/roles", "", uuid.New(), "alice")
+	h.ListRoles(rec, req)
+	assertStatusCode(t, rec, http.StatusOK)
+	var resp userapi.ListRolesResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if len(resp.Roles) != 4 {
+		t.Fatalf("roles = %+v", resp.Roles)
+	}
+	for _, r := range resp.Roles {
+		if len(r.Permissions) == 0 {
+			t.Errorf("role %s has no permissions", r.Name)
+		}
+	}
+}
+
+func TestSkillsHandler_GroupScope(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	h := NewSkillsHandler(mockDB, newTestLogger())
+	group := &models.Group{Slug: "eng", DisplayName: "eng", IsActive: true}
+	if err := mockDB.CreateGroup(context.Background(), group); err != nil {
+		t.Fatal(err)
+	}
+	author, peer := uuid.New(), uuid.New()
+	for _, id := range []uuid.UUID{author, peer} {
+		if _, err := mockDB.AddGroupMember(context.Background(), group.ID, id, "admin"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	body := `{"content":"# Team skill","name":"Team","scope":"group","group_id":"` + group.ID.String() + `"}`
+	req, rec := credRequest(http.MethodPost, "/v1/skills/load", body, uuid.New(), "outsider")
+	h.Load(rec, req)
+	assertStatusCode(t, rec, http.StatusForbidden)
+	req, rec = credRequest(http.MethodPost, "/v1/skills/load", `{"content":"# x","scope":"group"}`, author, "author", group.ID)
+	h.Load(rec, req)
+	assertStatusCode(t, rec, http.StatusBadRequest)
+	req, rec = credRequest(http.MethodPost, "/v1/skills/load", body, author, "author", group.ID)
+	h.Load(rec, req)
+	assertStatusCode(t, rec, http.StatusCreated)
+	var created map[string]interface{}
+	_ = json.Unmarshal(rec.Body.Bytes(), &created)
+	if created["group_id"] != group.ID.String() {
+		t.Errorf("created = %v", created)
+	}
+	id, _ := created["id"].(string)
+
+	req, rec = credRequest(http.MethodGet, "/v1/skills/"+id, "", peer, "peer", group.ID)
+	req.SetPathValue("id", id)
+	h.Get(rec, req)
+	assertStatusCode(t, rec, http.StatusOK)
+	req, rec = credRequest(http.MethodGet, "/v1/skills/"+id, "", uuid.New(), "outsider")
+	req.SetPathValue("id", id)
+	h.Get(rec, req)
+	assertStatusCode(t, rec, http.StatusNotFound)
+}
+
+func TestSkillsHandler_GroupSkillReadOnlyForMembers(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	h := NewSkillsHandler(mockDB, newTestLogger())
+	author, peer, group := uuid.New(), uuid.New(), uuid.New()
+	skill, _ := mockDB.CreateSkill(context.Background(), "Team", "# Team", "group", &author, false)
+	_, _ = mockDB.SetSkillGroup(context.Background(), skill.ID, &group)
+	id := skill.ID.String()
+
+	req, rec := credRequest(http.MethodPut, "/v1/skills/"+id, `{"name":"Mine"}`, peer, "peer", group)
+	req.SetPathValue("id", id)
+	h.Update(rec, req)
+	assertStatusCode(t, rec, http.StatusForbidden)
+	req, rec = credRequest(http.MethodDelete, "/v1/skills/"+id, "", peer, "peer", group)
+	req.SetPathValue("id", id)
+	h.Delete(rec, req)
+	assertStatusCode(t, rec, http.StatusForbidden)
+	req, rec = credRequest(http.MethodDelete, "/v1/skills/"+id, "", author, "author", group)
+	req.SetPathValue("id", id)
+	h.Delete(rec, req)
+	assertStatusCode(t, rec, http.StatusNoContent)
+}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -18,6 +19,7 @@ import (
 
 	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/nodepayloads"
 	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/auth"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
@@ -189,6 +191,42 @@ func TestAuthHandler_LoginUnauthorizedCases(t *testing.T) {
 	}
 }
 
+func TestAuthHandler_LoginMustChangePassword(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	handler := NewAuthHandler(mockDB, auth.NewJWTManager("test-secret-key-1234567890123456", 15*time.Minute, 7*24*time.Hour, 24*time.Hour), auth.NewRateLimiter(100, time.Minute), newTestLogger())
+	user, _ := mockDB.CreateUser(context.Background(), "newhire", nil)
+	hash, _ := auth.HashPassword("temporary-pass", nil)
+	_ = mockDB.SetPassword(context.Background(), user.ID, hash, "argon2id", true)
+	login := func(body userapi.LoginRequest) *httptest.ResponseRecorder {
+		req, rec := recordedRequestJSON("POST", "/v1/auth/login", body)
+		handler.Login(rec, req)
+		return rec
+	}
+
+	rec := login(userapi.LoginRequest{Handle: "newhire", Password: "temporary-pass"})
+	assertStatusCode(t, rec, http.StatusForbidden)
+	if !strings.Contains(rec.Body.String(), "password_change_required") {
+		t.Errorf("body = %s", rec.Body.String())
+	}
+	for _, np := range []string{"short", "temporary-pass"} {
+		rec = login(userapi.LoginRequest{Handle: "newhire", Password: "temporary-pass", NewPassword: np})
+		assertStatusCode(t, rec, http.StatusBadRequest)
+	}
+	rec = login(userapi.LoginRequest{Handle: "newhire", Password: "wrong-pass", NewPassword: "chosen-pass-1"})
+	assertStatusCode(t, rec, http.StatusUnauthorized)
+
+	rec = login(userapi.LoginRequest{Handle: "newhire", Password: "temporary-pass", NewPassword: "chosen-pass-1"})
+	assertStatusCode(t, rec, http.StatusOK)
+	if mockDB.PasswordCreds[user.ID].MustChange {
+		t.Error("must_change still set after the password was changed")
+	}
+	rec = login(userapi.LoginRequest{Handle: "newhire", Password: "chosen-pass-1"})
+	assertStatusCode(t, rec, http.StatusOK)
+	if !slices.ContainsFunc(mockDB.AuditLogs, func(e *testutil.AuthAuditLog) bool { return e.EventType == EventPasswordChange }) {
+		t.Error("password change not audited")
+	}
+}
+
 func TestAuthHandler_LoginDBError(t *testing.T) {
 	mockDB := testutil.NewMockDB()
 	mockDB.ForceError = errors.New("database error")
@@ -278,8 +316,7 @@ func TestAuthHandler_RefreshInvalidUserIDInToken(t *testing.T) {
 		TokenType: auth.TokenTypeRefresh,
 		UserID:    "not-a-valid-uuid",
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenStr, err := token.SignedString([]byte(secret))
+	tokenStr, err := jwtMgr.Keyring().Sign(claims)
 	if err != nil {
 		t.Fatalf("sign token: %v", err)
 	}
@@ -631,6 +668,43 @@ func TestTaskHandler_CreateTask_InvalidProjectID(t *testing.T) {
 	}
 }
 
+func TestTaskHandler_CreateTask_PolicyDenyAndTaskType(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	handler := NewTaskHandler(mockDB, newTestLogger(), "", "")
+	userID := uuid.New()
+	conditions := `{"task_types":["script"]}`
+	mockDB.AccessControlRules = append(mockDB.AccessControlRules, &models.AccessControlRule{
+		ID: uuid.New(), SubjectType: database.SubjectTypeUser, SubjectID: &userID, Action: database.ActionTaskCreate,
+		ResourceType: database.ResourceTypeProject, ResourcePattern: "projects/*", Effect: "deny", Priority: 10,
+		Conditions: &conditions,
+	})
+	ctx := context.WithValue(context.Background(), contextKeyUserID, userID)
+	post := func(body userapi.CreateTaskRequest) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(body)
+		req := httptest.NewRequest("POST", "/v1/tasks", bytes.NewBuffer(jsonBody)).WithContext(ctx)
+		rec := httptest.NewRecorder()
+		handler.CreateTask(rec, req)
+		return rec
+	}
+	if rec := post(userapi.CreateTaskRequest{Prompt: "echo hi", InputMode: "script"}); rec.Code != http.StatusForbidden {
+		t.Fatalf("script task: expected 403, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec := post(userapi.CreateTaskRequest{Prompt: "hello"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("prompt task: expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp userapi.TaskResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	task, err := mockDB.GetTaskByID(context.Background(), uuid.MustParse(resp.TaskID))
+	if err != nil || task.TaskType == nil || *task.TaskType != InputModePrompt {
+		t.Errorf("task type: %+v, %v", task, err)
+	}
+	mockDB.AccessControlRules = nil
+	if rec := post(userapi.CreateTaskRequest{Prompt: "hello"}); rec.Code != http.StatusForbidden {
+		t.Errorf("no rules (default deny): expected 403, got %d", rec.Code)
+	}
+}
+
 func TestTaskHandler_CreateTaskWithUseInference_StoresUseInferenceInJobPayload(t *testing.T) {
 	mockDB := testutil.NewMockDB()
 	logger := newTestLogger()
@@ -2272,6 +2346,41 @@ func TestTaskHandler_CancelTaskWithJobs(t *testing.T) {
 	}
 }
 
+func TestTaskHandler_CancelTaskStopsRunningJob(t *testing.T) {
+	var canceledPath string
+	worker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		canceledPath = r.URL.Path
+		_ = json.NewEncoder(w).Encode(workerapi.RunJobResponse{Version: 1, Status: workerapi.StatusCanceled, ExitCode: -1})
+	}))
+	defer worker.Close()
+	mockDB := testutil.NewMockDB()
+	handler := NewTaskHandler(mockDB, newTestLogger(), "", "")
+	userID := uuid.New()
+	task := &models.Task{ID: uuid.New(), CreatedBy: &userID, Status: models.TaskStatusRunning, CreatedAt: time.Now().UTC(), UpdatedAt: time.Now().UTC()}
+	mockDB.AddTask(task)
+	token, workerURL := "worker-token", worker.URL
+	node := &models.Node{ID: uuid.New(), NodeSlug: "n1", Status: models.NodeStatusActive, WorkerAPITargetURL: &workerURL, WorkerAPIBearerToken: &token}
+	mockDB.AddNode(node)
+	leaseID := uuid.New()
+	job := &models.Job{ID: uuid.New(), TaskID: task.ID, NodeID: &node.ID, LeaseID: &leaseID, Status: models.JobStatusRunning, CreatedAt: time.Now().UTC(), UpdatedAt: time.Now().UTC()}
+	mockDB.AddJob(job)
+
+	req := httptest.NewRequest("POST", "/v1/tasks/"+task.ID.String()+"/cancel", http.NoBody).WithContext(context.WithValue(context.Background(), contextKeyUserID, userID))
+	req.SetPathValue("id", task.ID.String())
+	rec := httptest.NewRecorder()
+	handler.CancelTask(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if canceledPath != "/v1/worker/jobs/"+job.ID.String()+"/cancel" {
+		t.Errorf("worker cancel path %q", canceledPath)
+	}
+	got, _ := mockDB.GetJobByID(context.Background(), job.ID)
+	if got.Status != models.JobStatusCanceled || got.Result.Ptr() == nil {
+		t.Errorf("job status=%s result=%v", got.Status, got.Result.Ptr())
+	}
+}
+
 func TestTaskHandler_CancelTaskUpdateStatusError(t *testing.T) {
 	mockDB := testutil.NewMockDB()
 	mockDB.ForceError = errors.New("database error")
@@ -3127,11 +3236,11 @@ type sessionErrorMockDB struct {
 	failOnCreateSession bool
 }
 
-func (m *sessionErrorMockDB) CreateRefreshSession(ctx context.Context, _ uuid.UUID, _ []byte, _ time.Time) (*models.RefreshSession, error) {
+func (m *sessionErrorMockDB) CreateRefreshSession(ctx context.Context, s *models.RefreshSession) error {
 	if m.failOnCreateSession {
-		return nil, errors.New("session creation failed")
+		return errors.New("session creation failed")
 	}
-	return m.MockDB.CreateRefreshSession(ctx, uuid.New(), nil, time.Now())
+	return m.MockDB.CreateRefreshSession(ctx, s)
 }
 
 func TestAuthHandler_LoginSessionCreationError(t *testing.T) {
@@ -3443,13 +3552,13 @@ func TestNodeHandler_RegisterNewNode_CreateNodeFails(t *testing.T) {
 	}
 }
 
-// invalidateSessionErrorStore fails only InvalidateRefreshSession (Refresh token rotation).
-type invalidateSessionErrorStore struct {
+// rotateSessionErrorStore fails only RotateRefreshSession (Refresh token rotation).
+type rotateSessionErrorStore struct {
 	*testutil.MockDB
 }
 
-func (m *invalidateSessionErrorStore) InvalidateRefreshSession(_ context.Context, _ uuid.UUID) error {
-	return errors.New("invalidate session error")
+func (m *rotateSessionErrorStore) RotateRefreshSession(_ context.Context, _ *models.RefreshSession, _ []byte) error {
+	return errors.New("rotate session error")
 }
 
 // refreshWithStore runs Refresh with a pre-configured user and session on base, using store as the handler's DB. Asserts rec.Code == expectedCode.
@@ -3473,9 +3582,9 @@ func refreshWithStore(t *testing.T, base *testutil.MockDB, store database.Store,
 	}
 }
 
-func TestAuthHandler_Refresh_InvalidateSessionFails(t *testing.T) {
+func TestAuthHandler_Refresh_RotateSessionFails(t *testing.T) {
 	base := testutil.NewMockDB()
-	refreshWithStore(t, base, &invalidateSessionErrorStore{MockDB: base}, http.StatusInternalServerError)
+	refreshWithStore(t, base, &rotateSessionErrorStore{MockDB: base}, http.StatusInternalServerError)
 }
 
 // getUserByIDErrorStore fails GetUserByID (Refresh path: user not found).
@@ -3526,47 +3635,52 @@ func TestAuthHandler_Refresh_GetActiveRefreshSessionError(t *testing.T) {
 	}
 }
 
-func TestAuthHandler_Refresh_CreateSessionFails(t *testing.T) {
-	mockDB := &sessionErrorMockDB{MockDB: testutil.NewMockDB(), failOnCreateSession: true}
+func TestAuthHandler_Refresh_RotatesSessionInPlace(t *testing.T) {
+	mockDB := testutil.NewMockDB()
 	jwtMgr := auth.NewJWTManager("test-secret-key-1234567890123456", 15*time.Minute, 7*24*time.Hour, 24*time.Hour)
 	handler := NewAuthHandler(mockDB, jwtMgr, auth.NewRateLimiter(100, time.Minute), newTestLogger())
 
 	user := &models.User{ID: uuid.New(), Handle: "u", IsActive: true, CreatedAt: time.Now().UTC(), UpdatedAt: time.Now().UTC()}
 	mockDB.AddUser(user)
 	refreshToken, expiresAt, _ := jwtMgr.GenerateRefreshToken(user.ID)
-	tokenHash := auth.HashToken(refreshToken)
 	session := &models.RefreshSession{
-		ID: uuid.New(), UserID: user.ID, RefreshTokenHash: tokenHash, IsActive: true, ExpiresAt: expiresAt,
+		ID: uuid.New(), UserID: user.ID, RefreshTokenHash: auth.HashToken(refreshToken), IsActive: true, ExpiresAt: expiresAt,
 		CreatedAt: time.Now().UTC(), UpdatedAt: time.Now().UTC(),
 	}
 	mockDB.AddRefreshSession(session)
 
 	req, rec := recordedRequestJSON("POST", "/v1/auth/refresh", userapi.RefreshRequest{RefreshToken: refreshToken})
+	req.Header.Set("User-Agent", "cynork/2")
 	handler.Refresh(rec, req)
-
-	if rec.Code != http.StatusInternalServerError {
-		t.Errorf("expected status 500, got %d", rec.Code)
+	assertStatusCode(t, rec, http.StatusOK)
+	var resp userapi.LoginResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	claims, err := jwtMgr.ValidateAccessToken(resp.AccessToken)
+	if err != nil || claims.SessionID != session.ID.String() {
+		t.Errorf("access token sid = %+v, %v", claims, err)
+	}
+	if len(mockDB.RefreshSessions) != 1 || session.LastUsedAt == nil || session.UserAgent == nil || *session.UserAgent != "cynork/2" ||
+		session.AccessExpiresAt == nil {
+		t.Errorf("session after refresh = %+v", session)
 	}
+
+	// The rotated-out refresh token is refused.
+	req, rec = recordedRequestJSON("POST", "/v1/auth/refresh", userapi.RefreshRequest{RefreshToken: refreshToken})
+	handler.Refresh(rec, req)
+	assertStatusCode(t, rec, http.StatusUnauthorized)
 }
 
-// createJobErrorStore fails only CreateJob (CreateTask handler: task created, job creation fails).
+// createJobErrorStore fails only queued job creation (CreateTask handler: task created, job creation fails).
 type createJobErrorStore struct {
 	*testutil.MockDB
 }
 
-func (m *createJobErrorStore) CreateJob(_ context.Context, _ uuid.UUID, _ string) (*models.Job, error) {
+func (m *createJobErrorStore) CreateQueuedJobWithinLimits(_ context.Context, _, _ uuid.UUID, _ string, _, _ int) (*models.Job, error) {
 	return nil, errors.New("create job error")
 }
 
-// createJobWithIDErrorStore fails only CreateJobWithID (UseSBA path).
-type createJobWithIDErrorStore struct {
-	*testutil.MockDB
-}
-
-func (m *createJobWithIDErrorStore) CreateJobWithID(_ context.Context, _, _ uuid.UUID, _ string) (*models.Job, error) {
-	return nil, errors.New("create job with id error")
-}
-
 // createJobCompletedErrorStore fails only CreateJobCompleted (orchestrator inference path).
 type createJobCompletedErrorStore struct {
 	*testutil.MockDB
@@ -3577,7 +3691,7 @@ func (m *createJobCompletedErrorStore) CreateJobCompleted(_ context.Context, _,
 }
 
 func TestTaskHandler_CreateTask_UseSBA_CreateJobWithIDFails(t *testing.T) {
-	mockDB := &createJobWithIDErrorStore{MockDB: testutil.NewMockDB()}
+	mockDB := &createJobErrorStore{MockDB: testutil.NewMockDB()}
 	handler := NewTaskHandler(mockDB, newTestLogger(), "", "")
 	userID := uuid.New()
 	ctx := context.WithValue(context.Background(), contextKeyUserID, userID)
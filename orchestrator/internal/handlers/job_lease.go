@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+// JobLeaseHandler handles worker heartbeats for async jobs (worker_api.md Run Job (Asynchronous)).
+// The worker authenticates with its Worker API bearer token, which must match the token of the node the job is assigned to.
+type JobLeaseHandler struct {
+	db       database.Store
+	leaseTTL time.Duration
+	logger   *slog.Logger
+}
+
+// NewJobLeaseHandler creates a job lease handler; each accepted heartbeat extends the lease to now+leaseTTL.
+func NewJobLeaseHandler(db database.Store, leaseTTL time.Duration, logger *slog.Logger) *JobLeaseHandler {
+	return &JobLeaseHandler{db: db, leaseTTL: leaseTTL, logger: logger}
+}
+
+// Heartbeat handles POST /v1/jobs/{id}/heartbeat.
+// The node is authenticated before the job is read, and a job that does not exist or is not assigned to the
+// calling node is 404 either way, so callers cannot probe job ids.
+// Returns 409 when the job is no longer running under the given lease (reclaimed, canceled, or completed).
+func (h *JobLeaseHandler) Heartbeat(w http.ResponseWriter, r *http.Request) {
+	node, ok := authenticateWorkerNode(r, h.db)
+	if !ok {
+		WriteUnauthorized(w, "invalid worker token")
+		return
+	}
+	jobID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		WriteBadRequest(w, "invalid job id")
+		return
+	}
+	var req workerapi.HeartbeatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteBadRequest(w, "invalid JSON body")
+		return
+	}
+	leaseID, err := uuid.Parse(req.LeaseID)
+	if err != nil {
+		WriteBadRequest(w, "lease_id required")
+		return
+	}
+	job, err := h.db.GetJobByID(r.Context(), jobID)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		h.logger.Error("get job failed", "error", err, "job_id", jobID)
+		WriteInternalError(w, "failed to get job")
+		return
+	}
+	if err != nil || job.NodeID == nil || *job.NodeID != node.ID {
+		WriteNotFound(w, "job not found")
+		return
+	}
+	expiresAt := time.Now().UTC().Add(h.leaseTTL)
+	if err := h.db.ExtendJobLease(r.Context(), jobID, leaseID, expiresAt); err != nil {
+		if errors.Is(err, database.ErrLeaseHeld) {
+			WriteConflict(w, "job is not running under this lease")
+			return
+		}
+		h.logger.Error("extend job lease failed", "error", err, "job_id", jobID)
+		WriteInternalError(w, "failed to extend lease")
+		return
+	}
+	WriteJSON(w, http.StatusOK, workerapi.HeartbeatResponse{LeaseExpiresAt: expiresAt.Format(time.RFC3339)})
+}
+
+// authenticateWorkerNode returns the node whose Worker API bearer token the request carries.
+func authenticateWorkerNode(r *http.Request, db database.Store) (*models.Node, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return nil, false
+	}
+	node, err := db.GetNodeByWorkerAPIToken(r.Context(), token)
+	if err != nil {
+		return nil, false
+	}
+	return node, true
+}
+
+// authorizedForJobNode checks the request bearer token against the assigned node's Worker API token.
+func authorizedForJobNode(r *http.Request, db database.Store, nodeID *uuid.UUID) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" || nodeID == nil {
+		return false
+	}
+	node, err := db.GetNodeByID(r.Context(), *nodeID)
+	if err != nil || node.WorkerAPIBearerToken == nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(*node.WorkerAPIBearerToken)) == 1
+}
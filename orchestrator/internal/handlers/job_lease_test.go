@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
+)
+
+func setupLeasedJob(t *testing.T) (*testutil.MockDB, *models.Job, uuid.UUID) {
+	t.Helper()
+	mock := testutil.NewMockDB()
+	token := "worker-token"
+	node := &models.Node{ID: uuid.New(), NodeSlug: "n1", Status: models.NodeStatusActive, WorkerAPIBearerToken: &token}
+	mock.AddNode(node)
+	leaseID := uuid.New()
+	exp := time.Now().Add(time.Minute)
+	job := &models.Job{ID: uuid.New(), TaskID: uuid.New(), NodeID: &node.ID, Status: models.JobStatusRunning, LeaseID: &leaseID, LeaseExpiresAt: &exp}
+	mock.AddJob(job)
+	return mock, job, leaseID
+}
+
+func heartbeatRequest(t *testing.T, h *JobLeaseHandler, jobID, leaseID, token string) int {
+	t.Helper()
+	req, rec := recordedRequestJSON(http.MethodPost, "/v1/jobs/"+jobID+"/heartbeat",
+		workerapi.HeartbeatRequest{Version: 1, JobID: jobID, LeaseID: leaseID, Status: workerapi.StatusInProgress})
+	req.SetPathValue("id", jobID)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	h.Heartbeat(rec, req)
+	if rec.Code == http.StatusOK {
+		var resp workerapi.HeartbeatResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.LeaseExpiresAt == "" {
+			t.Errorf("heartbeat response: %v %+v", err, resp)
+		}
+	}
+	return rec.Code
+}
+
+func TestJobLeaseHandler_Heartbeat(t *testing.T) {
+	mock, job, leaseID := setupLeasedJob(t)
+	h := NewJobLeaseHandler(mock, 5*time.Minute, slog.Default())
+	before := *job.LeaseExpiresAt
+
+	if code := heartbeatRequest(t, h, job.ID.String(), leaseID.String(), "worker-token"); code != http.StatusOK {
+		t.Fatalf("heartbeat: %d", code)
+	}
+	got, _ := mock.GetJobByID(t.Context(), job.ID)
+	if !got.LeaseExpiresAt.After(before) {
+		t.Errorf("lease not extended: %v <= %v", got.LeaseExpiresAt, before)
+	}
+}
+
+func TestJobLeaseHandler_HeartbeatErrors(t *testing.T) {
+	mock, job, leaseID := setupLeasedJob(t)
+	h := NewJobLeaseHandler(mock, time.Minute, slog.Default())
+	id, lease := job.ID.String(), leaseID.String()
+
+	if code := heartbeatRequest(t, h, id, lease, ""); code != http.StatusUnauthorized {
+		t.Errorf("missing token: %d", code)
+	}
+	if code := heartbeatRequest(t, h, id, lease, "wrong"); code != http.StatusUnauthorized {
+		t.Errorf("wrong token: %d", code)
+	}
+	if code := heartbeatRequest(t, h, id, uuid.New().String(), "worker-token"); code != http.StatusConflict {
+		t.Errorf("stale lease: %d", code)
+	}
+	if code := heartbeatRequest(t, h, uuid.New().String(), lease, "worker-token"); code != http.StatusNotFound {
+		t.Errorf("unknown job: %d", code)
+	}
+	if code := heartbeatRequest(t, h, uuid.New().String(), lease, "wrong"); code != http.StatusUnauthorized {
+		t.Errorf("unknown job without a valid token: %d, want 401 before the job is looked up", code)
+	}
+	if code := heartbeatRequest(t, h, "not-a-uuid", lease, "worker-token"); code != http.StatusBadRequest {
+		t.Errorf("bad job id: %d", code)
+	}
+	if code := heartbeatRequest(t, h, id, "", "worker-token"); code != http.StatusBadRequest {
+		t.Errorf("missing lease_id: %d", code)
+	}
+	req, rec := recordedRequest(http.MethodPost, "/v1/jobs/"+id+"/heartbeat", []byte("{"))
+	req.SetPathValue("id", id)
+	req.Header.Set("Authorization", "Bearer worker-token")
+	h.Heartbeat(rec, req)
+	assertStatusCode(t, rec, http.StatusBadRequest)
+
+	_ = mock.CompleteJob(t.Context(), job.ID, "{}", models.JobStatusCompleted)
+	if code := heartbeatRequest(t, h, id, lease, "worker-token"); code != http.StatusConflict {
+		t.Errorf("completed job: %d", code)
+	}
+}
+
+// TestJobLeaseHandler_HeartbeatOtherNodesJob verifies a job assigned to another node looks the same as a missing one.
+func TestJobLeaseHandler_HeartbeatOtherNodesJob(t *testing.T) {
+	mock, job, leaseID := setupLeasedJob(t)
+	other := "other-token"
+	mock.AddNode(&models.Node{ID: uuid.New(), NodeSlug: "n2", Status: models.NodeStatusActive, WorkerAPIBearerToken: &other})
+	h := NewJobLeaseHandler(mock, time.Minute, slog.Default())
+	before := *job.LeaseExpiresAt
+
+	if code := heartbeatRequest(t, h, job.ID.String(), leaseID.String(), other); code != http.StatusNotFound {
+		t.Errorf("other node's job: %d, want 404", code)
+	}
+	got, _ := mock.GetJobByID(t.Context(), job.ID)
+	if !got.LeaseExpiresAt.Equal(before) {
+		t.Error("lease extended by another node")
+	}
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+// JobHandler handles admin job endpoints: dead-letter listing, requeue, and attempt history.
+type JobHandler struct {
+	db     database.Store
+	logger *slog.Logger
+}
+
+// NewJobHandler creates a new job handler.
+func NewJobHandler(db database.Store, logger *slog.Logger) *JobHandler {
+	return &JobHandler{db: db, logger: logger}
+}
+
+// ListDeadLettered handles GET /v1/jobs/dead-lettered (admin-gated), most recently dead-lettered first.
+func (h *JobHandler) ListDeadLettered(w http.ResponseWriter, r *http.Request) {
+	limit, offset, _, _, errCode := parseListTasksParams(r)
+	if errCode != 0 {
+		WriteBadRequest(w, "Invalid limit or offset")
+		return
+	}
+	jobs, err := h.db.ListDeadLetteredJobs(r.Context(), limit+1, offset)
+	if err != nil {
+		h.logger.Error("list dead-lettered jobs", "error", err)
+		WriteInternalError(w, "Failed to list jobs")
+		return
+	}
+	hasMore := len(jobs) > limit
+	if hasMore {
+		jobs = jobs[:limit]
+	}
+	resp := userapi.ListJobsResponse{Jobs: make([]userapi.JobResponse, 0, len(jobs))}
+	for _, j := range jobs {
+		resp.Jobs = append(resp.Jobs, adminJobToResponse(j))
+	}
+	if hasMore {
+		next := offset + limit
+		resp.NextOffset = &next
+	}
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+// Requeue handles POST /v1/jobs/{id}/requeue (admin-gated).
+// The job is queued again with a fresh attempt budget and its failed task is reopened; 409 when the job is not dead-lettered.
+func (h *JobHandler) Requeue(w http.ResponseWriter, r *http.Request) {
+	jobID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		WriteBadRequest(w, "invalid job id")
+		return
+	}
+	job, err := h.db.RequeueDeadLetteredJob(r.Context(), jobID)
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrNotFound):
+			WriteNotFound(w, "Job not found")
+		case errors.Is(err, database.ErrConflict):
+			WriteConflict(w, "Job is not dead-lettered")
+		default:
+			h.logger.Error("requeue job", "error", err, "job_id", jobID)
+			WriteInternalError(w, "Failed to requeue job")
+		}
+		return
+	}
+	h.logger.Info("dead-lettered job requeued", "job_id", job.ID, "task_id", job.TaskID)
+	WriteJSON(w, http.StatusOK, adminJobToResponse(job))
+}
+
+// ListAttempts handles GET /v1/jobs/{id}/attempts (admin-gated).
+func (h *JobHandler) ListAttempts(w http.ResponseWriter, r *http.Request) {
+	jobID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		WriteBadRequest(w, "invalid job id")
+		return
+	}
+	ctx := r.Context()
+	if _, err := h.db.GetJobByID(ctx, jobID); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			WriteNotFound(w, "Job not found")
+			return
+		}
+		h.logger.Error("get job", "error", err, "job_id", jobID)
+		WriteInternalError(w, "Failed to get job")
+		return
+	}
+	attempts, err := h.db.ListJobAttempts(ctx, jobID)
+	if err != nil {
+		h.logger.Error("list job attempts", "error", err, "job_id", jobID)
+		WriteInternalError(w, "Failed to list job attempts")
+		return
+	}
+	resp := userapi.ListJobAttemptsResponse{JobID: jobID.String(), Attempts: make([]userapi.JobAttemptResponse, 0, len(attempts))}
+	for _, a := range attempts {
+		resp.Attempts = append(resp.Attempts, jobAttemptToResponse(a))
+	}
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+func adminJobToResponse(job *models.Job) userapi.JobResponse {
+	resp := jobToResponse(job)
+	resp.TaskID = job.TaskID.String()
+	resp.Attempts = job.Attempts
+	return resp
+}
+
+func jobAttemptToResponse(a *models.JobAttempt) userapi.JobAttemptResponse {
+	resp := userapi.JobAttemptResponse{
+		Attempt:      a.Attempt,
+		Status:       a.Status,
+		FailureClass: a.FailureClass,
+		Result:       a.Result.Ptr(),
+		EndedAt:      a.EndedAt.Format(time.RFC3339),
+	}
+	if a.NodeID != nil {
+		s := a.NodeID.String()
+		resp.NodeID = &s
+	}
+	if a.StartedAt != nil {
+		s := a.StartedAt.Format(time.RFC3339)
+		resp.StartedAt = &s
+	}
+	return resp
+}
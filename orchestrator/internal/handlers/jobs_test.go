@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
+)
+
+func setupDeadLetteredJob(t *testing.T) (*testutil.MockDB, *models.Job) {
+	t.Helper()
+	mock := testutil.NewMockDB()
+	task := &models.Task{ID: uuid.New(), Status: models.TaskStatusFailed}
+	mock.AddTask(task)
+	ended := time.Now().UTC()
+	job := &models.Job{ID: uuid.New(), TaskID: task.ID, Status: models.JobStatusDeadLettered, Attempts: 3, EndedAt: &ended}
+	mock.AddJob(job)
+	class := "node_unreachable"
+	_ = mock.CreateJobAttempt(t.Context(), &models.JobAttempt{JobID: job.ID, Attempt: 1, Status: models.JobStatusFailed, FailureClass: &class})
+	return mock, job
+}
+
+func TestJobHandler_ListDeadLettered(t *testing.T) {
+	mock, job := setupDeadLetteredJob(t)
+	mock.AddJob(&models.Job{ID: uuid.New(), TaskID: job.TaskID, Status: models.JobStatusFailed})
+	h := NewJobHandler(mock, slog.Default())
+
+	req, rec := recordedRequest(http.MethodGet, "/v1/jobs/dead-lettered?limit=10", nil)
+	h.ListDeadLettered(rec, req)
+	assertStatusCode(t, rec, http.StatusOK)
+	var resp userapi.ListJobsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Jobs) != 1 || resp.Jobs[0].ID != job.ID.String() || resp.Jobs[0].Attempts != 3 ||
+		resp.Jobs[0].TaskID != job.TaskID.String() || resp.Jobs[0].Status != userapi.StatusDeadLettered || resp.NextOffset != nil {
+		t.Errorf("response = %+v", resp)
+	}
+
+	req, rec = recordedRequest(http.MethodGet, "/v1/jobs/dead-lettered?limit=0", nil)
+	h.ListDeadLettered(rec, req)
+	assertStatusCode(t, rec, http.StatusBadRequest)
+}
+
+func TestJobHandler_Requeue(t *testing.T) {
+	mock, job := setupDeadLetteredJob(t)
+	h := NewJobHandler(mock, slog.Default())
+	requeue := func(id string) int {
+		req, rec := recordedRequest(http.MethodPost, "/v1/jobs/"+id+"/requeue", nil)
+		req.SetPathValue("id", id)
+		h.Requeue(rec, req)
+		return rec.Code
+	}
+
+	if code := requeue(job.ID.String()); code != http.StatusOK {
+		t.Fatalf("requeue: %d", code)
+	}
+	got, _ := mock.GetJobByID(t.Context(), job.ID)
+	task, _ := mock.GetTaskByID(t.Context(), job.TaskID)
+	if got.Status != models.JobStatusQueued || got.Attempts != 0 || task.Status != models.TaskStatusPending {
+		t.Errorf("after requeue: job=%s attempts=%d task=%s", got.Status, got.Attempts, task.Status)
+	}
+	if code := requeue(job.ID.String()); code != http.StatusConflict {
+		t.Errorf("requeue queued job: %d", code)
+	}
+	if code := requeue(uuid.New().String()); code != http.StatusNotFound {
+		t.Errorf("unknown job: %d", code)
+	}
+	if code := requeue("bad"); code != http.StatusBadRequest {
+		t.Errorf("bad id: %d", code)
+	}
+}
+
+func TestJobHandler_ListAttempts(t *testing.T) {
+	mock, job := setupDeadLetteredJob(t)
+	h := NewJobHandler(mock, slog.Default())
+	list := func(id string) (int, userapi.ListJobAttemptsResponse) {
+		req, rec := recordedRequest(http.MethodGet, "/v1/jobs/"+id+"/attempts", nil)
+		req.SetPathValue("id", id)
+		h.ListAttempts(rec, req)
+		var resp userapi.ListJobAttemptsResponse
+		if rec.Code == http.StatusOK {
+			_ = json.NewDecoder(rec.Body).Decode(&resp)
+		}
+		return rec.Code, resp
+	}
+
+	code, resp := list(job.ID.String())
+	if code != http.StatusOK || len(resp.Attempts) != 1 || resp.Attempts[0].Attempt != 1 ||
+		resp.Attempts[0].FailureClass == nil || *resp.Attempts[0].FailureClass != "node_unreachable" {
+		t.Errorf("attempts: code=%d resp=%+v", code, resp)
+	}
+	if code, _ := list(uuid.New().String()); code != http.StatusNotFound {
+		t.Errorf("unknown job: %d", code)
+	}
+	if code, _ := list("bad"); code != http.StatusBadRequest {
+		t.Errorf("bad id: %d", code)
+	}
+}
@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/auth"
+)
+
+// jwksMaxAge is how long clients may cache the JWKS. Verifiers also refetch on an unknown kid, so a key
+// rotated in is picked up before the cache expires.
+const jwksMaxAge = "300"
+
+// JWKS serves GET /.well-known/jwks.json: the public keys orchestrator tokens are signed with, so services
+// can verify tokens without holding signing material (local_user_accounts.md).
+func JWKS(keys *auth.Keyring) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age="+jwksMaxAge)
+		WriteJSON(w, http.StatusOK, keys.JWKS())
+	}
+}
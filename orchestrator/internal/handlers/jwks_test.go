@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/jwks"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/auth"
+)
+
+func TestJWKS_ServesPublicKeysForRemoteVerification(t *testing.T) {
+	_, current, _ := ed25519.GenerateKey(rand.Reader)
+	previous, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	oldRing, err := auth.NewKeyring(previous)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ring, err := auth.NewKeyring(current, previous.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(JWKS(ring))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var set jwks.Set
+	_ = json.NewDecoder(resp.Body).Decode(&set)
+	_ = resp.Body.Close()
+	if len(set.Keys) != 2 || set.Keys[0].Kid != ring.KeyID() || set.Keys[0].Alg != "EdDSA" || set.Keys[1].Alg != "ES256" {
+		t.Fatalf("jwks = %+v", set)
+	}
+	for _, k := range set.Keys {
+		if k.N != "" || k.Kty == "oct" {
+			t.Errorf("unexpected key material in %+v", k)
+		}
+	}
+
+	verifier := auth.NewRemoteVerifier(srv.URL)
+	ctx := context.Background()
+	userID := uuid.New()
+	for name, mgr := range map[string]*auth.JWTManager{
+		"current":  auth.NewJWTManagerWithKeyring(ring, time.Minute, time.Hour, time.Hour),
+		"previous": auth.NewJWTManagerWithKeyring(oldRing, time.Minute, time.Hour, time.Hour),
+	} {
+		token, _ := mgr.GenerateAccessToken(userID, "alice")
+		claims, err := verifier.Validate(ctx, token, auth.TokenTypeAccess)
+		if err != nil || claims.UserID != userID.String() {
+			t.Errorf("%s key: claims = %+v, err = %v", name, claims, err)
+		}
+		if _, err := verifier.Validate(ctx, token, auth.TokenTypeNode); err == nil {
+			t.Errorf("%s key: access token accepted as node token", name)
+		}
+	}
+	foreign, _ := auth.NewJWTManager("other-secret", time.Minute, time.Hour, time.Hour).GenerateAccessToken(userID, "alice")
+	if _, err := verifier.Validate(ctx, foreign, auth.TokenTypeAccess); err == nil {
+		t.Error("token from a key outside the JWKS accepted")
+	}
+}
@@ -8,8 +8,8 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
-	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,10 +17,12 @@ import (
 
 	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/nodepayloads"
 	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/go_shared_libs/redact"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/inference"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/pmaclient"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/usage"
 )
 
 // Max total wait for chat completion (REQ-ORCHES-0131).
@@ -48,16 +50,9 @@ const chatCompletionBackoffBase = 500 * time.Millisecond
 const EffectiveModelPM = "cynodeai.pm"
 const managedServiceTypePMA = "pma"
 
-const secretRedacted = "SECRET_REDACTED"
-
 const completionFailedMsg = "Completion failed"
 const inferenceFailedCode = "orchestrator_inference_failed"
 
-var (
-	apiKeyLikePattern = regexp.MustCompile(`(?i)(api[_-]?key|secret|password|token|bearer)\s*[:=]\s*[a-zA-Z0-9\-._~:/?#\[\]@!$&'()*+,;=%]+`)
-	skPrefixPattern   = regexp.MustCompile(`sk-[a-zA-Z0-9\-._]{20,}`)
-)
-
 // OpenAIChatHandler handles GET /v1/models and POST /v1/chat/completions.
 type OpenAIChatHandler struct {
 	db                   database.Store
@@ -65,6 +60,7 @@ type OpenAIChatHandler struct {
 	inferenceURL         string
 	inferenceModel       string
 	workerAPIBearerToken string
+	quotas               *usage.Enforcer
 }
 
 // NewOpenAIChatHandler creates a handler for the OpenAI-compatible chat surface.
@@ -83,6 +79,28 @@ func NewOpenAIChatHandler(db database.Store, logger *slog.Logger, inferenceURL,
 	}
 }
 
+// SetQuotaEnforcer enables token quota checks on chat completions; nil (the default) disables them.
+func (h *OpenAIChatHandler) SetQuotaEnforcer(quotas *usage.Enforcer) {
+	h.quotas = quotas
+}
+
+// checkChatQuota writes an OpenAI-style 429 quota_exceeded (or 500) error and returns false when the user
+// or project has used up a token quota.
+func (h *OpenAIChatHandler) checkChatQuota(ctx context.Context, w http.ResponseWriter, userID uuid.UUID, projectID *uuid.UUID) bool {
+	err := h.quotas.CheckTokens(ctx, userID, projectID)
+	if err == nil {
+		return true
+	}
+	if exceeded, ok := quotaExceeded(err); ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(exceeded.RetryAfter(time.Now()).Seconds())))
+		writeOpenAIError(w, http.StatusTooManyRequests, "quota_exceeded", exceeded.Error())
+		return false
+	}
+	h.logger.Error("check chat quota", "error", err)
+	writeOpenAIError(w, http.StatusInternalServerError, "internal_error", "Failed to check quota")
+	return false
+}
+
 // ListModels returns GET /v1/models in OpenAI list-models format.
 // Exposes cynodeai.pm and the configured inference model(s) the user is authorized to use.
 func (h *OpenAIChatHandler) ListModels(w http.ResponseWriter, r *http.Request) {
@@ -125,6 +143,9 @@ func (h *OpenAIChatHandler) ChatCompletions(w http.ResponseWriter, r *http.Reque
 		effectiveModel = EffectiveModelPM
 	}
 	projectID := projectIDFromHeader(r)
+	if !h.checkChatQuota(ctx, w, *userID, projectID) {
+		return
+	}
 	redacted, kinds := redactSecrets(req.Messages)
 	thread, err := h.db.GetOrCreateActiveChatThread(ctx, *userID, projectID)
 	if err != nil {
@@ -157,13 +178,37 @@ func (h *OpenAIChatHandler) ChatCompletions(w http.ResponseWriter, r *http.Reque
 	// REQ-ORCHES-0131: enforce maximum total wait duration.
 	timeoutCtx, cancel := context.WithTimeout(ctx, chatCompletionTimeout)
 	defer cancel()
+	timeoutCtx = usage.WithRecorder(timeoutCtx, h.db, h.logger, usage.Attribution{
+		UserID: userID, ProjectID: projectID, Source: models.TokenUsageSourceChat,
+	})
 	start := time.Now()
+	if req.Stream {
+		sw := newChatStreamWriter(w, effectiveModel)
+		content, status, code, msg := h.streamAndComplete(timeoutCtx, effectiveModel, contextMessages, lastUserContent, sw.delta)
+		if status != 0 {
+			if !sw.started {
+				writeOpenAIError(w, status, code, msg)
+				return
+			}
+			sw.fail(code, msg)
+			return
+		}
+		h.recordAssistantReply(ctx, thread.ID, content, userID, projectID, kinds, start)
+		sw.finish()
+		return
+	}
 	content, status, code, msg := h.routeAndComplete(timeoutCtx, effectiveModel, contextMessages, lastUserContent)
 	if status != 0 {
 		writeOpenAIError(w, status, code, msg)
 		return
 	}
-	if _, err := h.db.AppendChatMessage(ctx, thread.ID, "assistant", content, nil); err != nil {
+	h.recordAssistantReply(ctx, thread.ID, content, userID, projectID, kinds, start)
+	writeOpenAIJSON(w, http.StatusOK, buildChatCompletionsResponse(effectiveModel, content)) //nolint:exhaustruct // response struct built inline; exhaustruct wants all fields set
+}
+
+// recordAssistantReply persists the assistant message and the chat audit record for a successful completion.
+func (h *OpenAIChatHandler) recordAssistantReply(ctx context.Context, threadID uuid.UUID, content string, userID, projectID *uuid.UUID, kinds []string, start time.Time) {
+	if _, err := h.db.AppendChatMessage(ctx, threadID, "assistant", content, nil); err != nil {
 		h.logger.Error("append assistant message", "error", err)
 	}
 	durationMs := int(time.Since(start).Milliseconds())
@@ -175,7 +220,6 @@ func (h *OpenAIChatHandler) ChatCompletions(w http.ResponseWriter, r *http.Reque
 		RedactionKinds:   kindsJSON(kinds),
 		DurationMs:       &durationMs,
 	})
-	writeOpenAIJSON(w, http.StatusOK, buildChatCompletionsResponse(effectiveModel, content)) //nolint:exhaustruct // response struct built inline; exhaustruct wants all fields set
 }
 
 func (h *OpenAIChatHandler) decodeAndValidateChatRequest(r *http.Request) (req userapi.ChatCompletionsRequest, status int, errMsg string) {
@@ -202,7 +246,7 @@ func isTransientInferenceError(err error) bool {
 	if err == nil {
 		return false
 	}
-	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) || errors.Is(err, errStreamInterrupted) {
 		return false
 	}
 	var netErr net.Error
@@ -223,12 +267,25 @@ func (h *OpenAIChatHandler) routeAndComplete(ctx context.Context, effectiveModel
 }
 
 func (h *OpenAIChatHandler) completeViaPMA(ctx context.Context, effectiveModel string, redacted []userapi.ChatMessage) (content string, status int, code, msg string) {
+	endpoint, workerToken, msgs, ok := h.pmaHandoff(ctx, redacted)
+	if !ok {
+		return "", http.StatusServiceUnavailable, "model_unavailable", "PM agent is not available"
+	}
+	call := func() (string, error) {
+		return pmaclient.CallChatCompletion(ctx, nil, endpoint, msgs, workerToken)
+	}
+	return h.runCompletionWithRetry(ctx, effectiveModel, "pma", "PMA chat completion failed", call)
+}
+
+// pmaHandoff resolves the PMA endpoint and worker token and converts the messages for the handoff;
+// ok is false when no PMA endpoint is available.
+func (h *OpenAIChatHandler) pmaHandoff(ctx context.Context, redacted []userapi.ChatMessage) (endpoint, workerToken string, msgs []pmaclient.ChatMessage, ok bool) {
 	candidate := h.resolvePMAEndpointCandidate(ctx)
 	if candidate.endpoint == "" {
 		h.logger.Warn("PMA base URL not configured; cannot route to cynodeai.pm")
-		return "", http.StatusServiceUnavailable, "model_unavailable", "PM agent is not available"
+		return "", "", nil, false
 	}
-	workerToken := strings.TrimSpace(candidate.workerAPIBearerToken)
+	workerToken = strings.TrimSpace(candidate.workerAPIBearerToken)
 	tokenSource := "node"
 	if workerToken == "" {
 		workerToken = h.workerAPIBearerToken
@@ -243,14 +300,11 @@ func (h *OpenAIChatHandler) completeViaPMA(ctx context.Context, effectiveModel s
 		"endpoint",
 		candidate.endpoint,
 	)
-	msgs := make([]pmaclient.ChatMessage, 0, len(redacted))
+	msgs = make([]pmaclient.ChatMessage, 0, len(redacted))
 	for _, m := range redacted {
 		msgs = append(msgs, pmaclient.ChatMessage{Role: m.Role, Content: m.Content})
 	}
-	call := func() (string, error) {
-		return pmaclient.CallChatCompletion(ctx, nil, candidate.endpoint, msgs, workerToken)
-	}
-	return h.runCompletionWithRetry(ctx, effectiveModel, "pma", "PMA chat completion failed", call)
+	return candidate.endpoint, workerToken, msgs, true
 }
 
 // resolvePMAEndpoint returns the PMA base URL for chat routing.
@@ -394,23 +448,17 @@ func projectIDFromHeader(r *http.Request) *uuid.UUID {
 	return &id
 }
 
+// redactSecrets replaces secrets in message content with SECRET_REDACTED (redact.Default) and returns the kinds
+// found across all messages.
 func redactSecrets(messages []userapi.ChatMessage) (amended []userapi.ChatMessage, kinds []string) {
 	amended = make([]userapi.ChatMessage, 0, len(messages))
 	seen := make(map[string]bool)
 	for _, m := range messages {
-		content := m.Content
-		if skPrefixPattern.MatchString(content) {
-			content = skPrefixPattern.ReplaceAllString(content, secretRedacted)
-			if !seen["api_key"] {
-				kinds = append(kinds, "api_key")
-				seen["api_key"] = true
-			}
-		}
-		if apiKeyLikePattern.MatchString(content) {
-			content = apiKeyLikePattern.ReplaceAllString(content, "${1}: "+secretRedacted)
-			if !seen["api_key"] {
-				kinds = append(kinds, "api_key")
-				seen["api_key"] = true
+		content, found := redact.String(m.Content)
+		for _, k := range found {
+			if !seen[k] {
+				seen[k] = true
+				kinds = append(kinds, k)
 			}
 		}
 		amended = append(amended, userapi.ChatMessage{Role: m.Role, Content: content})
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/inference"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/pmaclient"
+)
+
+// errStreamInterrupted marks a completion that failed after content was already streamed to the client;
+// it is never retried because the client has seen part of the answer.
+var errStreamInterrupted = errors.New("completion stream interrupted")
+
+// chatStreamWriter writes an OpenAI-compatible streamed completion: chat.completion.chunk server-sent events
+// followed by data: [DONE]. The 200 response is committed on the first event, so errors before any content
+// can still be returned as a normal OpenAI error response.
+type chatStreamWriter struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	id      string
+	model   string
+	created int64
+	started bool
+}
+
+func newChatStreamWriter(w http.ResponseWriter, model string) *chatStreamWriter {
+	return &chatStreamWriter{
+		w:       w,
+		rc:      http.NewResponseController(w),
+		id:      uuid.New().String(),
+		model:   model,
+		created: time.Now().Unix(),
+	}
+}
+
+// start commits the event-stream response and sends the assistant role chunk.
+func (s *chatStreamWriter) start() {
+	s.started = true
+	// The stream is bounded by chatCompletionTimeout, not the gateway WRITE_TIMEOUT.
+	_ = s.rc.SetWriteDeadline(time.Time{})
+	s.w.Header().Set("Content-Type", "text/event-stream")
+	s.w.Header().Set("Cache-Control", "no-cache")
+	s.w.Header().Set("X-Accel-Buffering", "no")
+	s.w.WriteHeader(http.StatusOK)
+	s.chunk(userapi.ChatCompletionsDelta{Role: "assistant"}, nil)
+}
+
+// delta sends one content fragment.
+func (s *chatStreamWriter) delta(content string) {
+	if content == "" {
+		return
+	}
+	if !s.started {
+		s.start()
+	}
+	s.chunk(userapi.ChatCompletionsDelta{Content: content}, nil)
+}
+
+// finish sends the final chunk (finish_reason stop) and data: [DONE].
+func (s *chatStreamWriter) finish() {
+	if !s.started {
+		s.start()
+	}
+	stop := "stop"
+	s.chunk(userapi.ChatCompletionsDelta{}, &stop)
+	s.event(userapi.ChatCompletionsStreamDone)
+}
+
+// fail reports an error after the stream has started, in the OpenAI error shape, then ends the stream.
+func (s *chatStreamWriter) fail(code, message string) {
+	b, _ := json.Marshal(map[string]interface{}{
+		"error": map[string]interface{}{
+			"message": message,
+			"type":    "cynodeai_error",
+			"param":   nil,
+			"code":    code,
+		},
+	})
+	s.event(string(b))
+	s.event(userapi.ChatCompletionsStreamDone)
+}
+
+func (s *chatStreamWriter) chunk(delta userapi.ChatCompletionsDelta, finishReason *string) {
+	b, _ := json.Marshal(userapi.ChatCompletionsChunk{
+		ID:      s.id,
+		Object:  "chat.completion.chunk",
+		Created: s.created,
+		Model:   s.model,
+		Choices: []userapi.ChatCompletionsChunkChoice{{Index: 0, Delta: delta, FinishReason: finishReason}},
+	})
+	s.event(string(b))
+}
+
+func (s *chatStreamWriter) event(data string) {
+	_, _ = fmt.Fprintf(s.w, "data: %s\n\n", data)
+	_ = s.rc.Flush()
+}
+
+// streamAndComplete is routeAndComplete for stream: true; onDelta receives each content fragment as it arrives.
+// Transient failures are retried only while nothing has been streamed yet.
+func (h *OpenAIChatHandler) streamAndComplete(ctx context.Context, effectiveModel string, redacted []userapi.ChatMessage, lastUserContent string, onDelta func(string)) (content string, status int, code, msg string) {
+	streamed := false
+	emit := func(d string) {
+		streamed = true
+		onDelta(d)
+	}
+	interrupted := func(err error) error {
+		if err != nil && streamed {
+			return fmt.Errorf("%w: %v", errStreamInterrupted, err)
+		}
+		return err
+	}
+	if effectiveModel == EffectiveModelPM {
+		endpoint, workerToken, msgs, ok := h.pmaHandoff(ctx, redacted)
+		if !ok {
+			return "", http.StatusServiceUnavailable, "model_unavailable", "PM agent is not available"
+		}
+		call := func() (string, error) {
+			out, err := pmaclient.StreamChatCompletion(ctx, nil, endpoint, msgs, workerToken, emit)
+			return out, interrupted(err)
+		}
+		return h.runCompletionWithRetry(ctx, effectiveModel, "pma_stream", "PMA chat completion stream failed", call)
+	}
+	if h.inferenceURL == "" {
+		return "", http.StatusBadRequest, "invalid_request", "Direct inference not configured for this model"
+	}
+	call := func() (string, error) {
+		out, err := inference.CallGenerateStream(ctx, nil, h.inferenceURL, h.inferenceModel, lastUserContent, emit)
+		return out, interrupted(err)
+	}
+	return h.runCompletionWithRetry(ctx, effectiveModel, "direct_inference_stream", "direct inference stream failed", call)
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
+)
+
+func streamChatRequest(model string) *http.Request {
+	body := []byte(`{"model":"` + model + `","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	return httptest.NewRequest("POST", "/v1/chat/completions", bytes.NewReader(body)).
+		WithContext(context.WithValue(context.Background(), contextKeyUserID, uuid.New()))
+}
+
+// parseChatStream returns the chunks of an SSE chat stream, any error event payload, and whether [DONE] was seen.
+func parseChatStream(t *testing.T, body string) (chunks []userapi.ChatCompletionsChunk, errEvent string, done bool) {
+	t.Helper()
+	for _, line := range strings.Split(body, "\n") {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		if data == userapi.ChatCompletionsStreamDone {
+			done = true
+			continue
+		}
+		if strings.HasPrefix(data, `{"error"`) {
+			errEvent = data
+			continue
+		}
+		var c userapi.ChatCompletionsChunk
+		if err := json.Unmarshal([]byte(data), &c); err != nil {
+			t.Fatalf("decode chunk %q: %v", data, err)
+		}
+		chunks = append(chunks, c)
+	}
+	return chunks, errEvent, done
+}
+
+func TestOpenAIChatHandler_ChatCompletions_StreamPMA(t *testing.T) {
+	mockPMA := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"content\":\"PMA \"}\n\ndata: {\"content\":\"reply.\"}\n\ndata: [DONE]\n\n"))
+	}))
+	defer mockPMA.Close()
+	db := mockDBWithPMAEndpoint(t, mockPMA.URL)
+	h := NewOpenAIChatHandler(db, newTestLogger(), "", "", "")
+	rec := httptest.NewRecorder()
+	h.ChatCompletions(rec, streamChatRequest(EffectiveModelPM))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status %d content-type %q: %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+	chunks, errEvent, done := parseChatStream(t, rec.Body.String())
+	if !done || errEvent != "" || len(chunks) != 4 {
+		t.Fatalf("chunks=%+v err=%q done=%v", chunks, errEvent, done)
+	}
+	if chunks[0].Object != "chat.completion.chunk" || chunks[0].Choices[0].Delta.Role != "assistant" {
+		t.Errorf("first chunk = %+v", chunks[0])
+	}
+	if chunks[1].Choices[0].Delta.Content+chunks[2].Choices[0].Delta.Content != "PMA reply." {
+		t.Errorf("content chunks = %+v %+v", chunks[1], chunks[2])
+	}
+	if fr := chunks[3].Choices[0].FinishReason; fr == nil || *fr != "stop" {
+		t.Errorf("last chunk = %+v", chunks[3])
+	}
+	var persisted []string
+	for _, msgs := range db.ChatMessages {
+		for _, m := range msgs {
+			persisted = append(persisted, m.Role+":"+m.Content)
+		}
+	}
+	if len(persisted) != 2 || persisted[1] != "assistant:PMA reply." {
+		t.Errorf("persisted messages = %v", persisted)
+	}
+}
+
+func TestOpenAIChatHandler_ChatCompletions_StreamDirectInference(t *testing.T) {
+	mockOllama := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"response":"Hel"}` + "\n" + `{"response":"lo"}` + "\n" + `{"done":true}` + "\n"))
+	}))
+	defer mockOllama.Close()
+	h := NewOpenAIChatHandler(testutil.NewMockDB(), newTestLogger(), mockOllama.URL, "qwen3.5:0.8b", "")
+	rec := httptest.NewRecorder()
+	h.ChatCompletions(rec, streamChatRequest("qwen3.5:0.8b"))
+	chunks, _, done := parseChatStream(t, rec.Body.String())
+	if !done || len(chunks) != 4 || chunks[1].Choices[0].Delta.Content != "Hel" || chunks[0].Model != "qwen3.5:0.8b" {
+		t.Errorf("chunks=%+v done=%v", chunks, done)
+	}
+}
+
+func TestOpenAIChatHandler_ChatCompletions_StreamErrors(t *testing.T) {
+	// Failure before any content: a normal OpenAI error response.
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer failing.Close()
+	h := NewOpenAIChatHandler(mockDBWithPMAEndpoint(t, failing.URL), newTestLogger(), "", "", "")
+	rec := httptest.NewRecorder()
+	h.ChatCompletions(rec, streamChatRequest(EffectiveModelPM))
+	if rec.Code != http.StatusBadGateway || !strings.Contains(rec.Body.String(), inferenceFailedCode) {
+		t.Errorf("pre-stream failure: %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Failure mid-stream: an error event, then [DONE]; nothing persisted for the assistant.
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"content\":\"part\"}\n\ndata: {\"error\":\"completion failed\"}\n\n"))
+	}))
+	defer broken.Close()
+	db := mockDBWithPMAEndpoint(t, broken.URL)
+	h = NewOpenAIChatHandler(db, newTestLogger(), "", "", "")
+	rec = httptest.NewRecorder()
+	h.ChatCompletions(rec, streamChatRequest(EffectiveModelPM))
+	chunks, errEvent, done := parseChatStream(t, rec.Body.String())
+	if rec.Code != http.StatusOK || !done || !strings.Contains(errEvent, inferenceFailedCode) || len(chunks) != 2 {
+		t.Errorf("mid-stream failure: chunks=%+v err=%q done=%v", chunks, errEvent, done)
+	}
+	for _, msgs := range db.ChatMessages {
+		for _, m := range msgs {
+			if m.Role == "assistant" {
+				t.Errorf("assistant message persisted after failed stream: %q", m.Content)
+			}
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+// PlanHandler handles project plan endpoints (user_api_gateway.md Project Plan API).
+type PlanHandler struct {
+	db     database.Store
+	logger *slog.Logger
+}
+
+// NewPlanHandler creates a new plan handler.
+func NewPlanHandler(db database.Store, logger *slog.Logger) *PlanHandler {
+	return &PlanHandler{db: db, logger: logger}
+}
+
+// CreatePlan handles POST /v1/projects/{project_id}/plans. The plan starts in state draft and belongs to the
+// caller, who must have access to the project.
+func (h *PlanHandler) CreatePlan(w http.ResponseWriter, r *http.Request) {
+	projectID, err := uuid.Parse(r.PathValue("project_id"))
+	if err != nil {
+		WriteBadRequest(w, "invalid project id")
+		return
+	}
+	ctx := r.Context()
+	userID := getUserIDFromContext(ctx)
+	if userID == nil {
+		WriteUnauthorized(w, "Authentication required")
+		return
+	}
+	ok, err := canAccessProject(ctx, h.db, *userID, projectID)
+	if err != nil {
+		h.logger.Error("check project access", "error", err)
+		WriteInternalError(w, "Failed to create plan")
+		return
+	}
+	if !ok {
+		WriteNotFound(w, "Project not found")
+		return
+	}
+	var req userapi.CreatePlanRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteBadRequest(w, "Invalid request body")
+			return
+		}
+	}
+	plan, err := h.db.CreateProjectPlan(ctx, projectID, req.PlanName, userID)
+	if err != nil {
+		h.logger.Error("create plan", "error", err)
+		WriteInternalError(w, "Failed to create plan")
+		return
+	}
+	WriteJSON(w, http.StatusCreated, planToResponse(plan))
+}
+
+// GetPlanStatus handles GET /v1/plans/{id}/status: the plan's task graph with each task's state. Plans of other
+// users are reported as not found.
+func (h *PlanHandler) GetPlanStatus(w http.ResponseWriter, r *http.Request) {
+	planID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		WriteBadRequest(w, "invalid plan id")
+		return
+	}
+	ctx := r.Context()
+	plan, err := h.db.GetProjectPlanByID(ctx, planID)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		h.logger.Error("get plan", "error", err)
+		WriteInternalError(w, "Failed to get plan")
+		return
+	}
+	if plan == nil || !isCreator(plan.CreatedBy, getUserIDFromContext(ctx)) {
+		WriteNotFound(w, "Plan not found")
+		return
+	}
+	tasks, err := h.db.ListPlanTasks(ctx, planID)
+	if err != nil {
+		h.logger.Error("list plan tasks", "error", err)
+		WriteInternalError(w, "Failed to get plan tasks")
+		return
+	}
+	edges, err := h.db.ListPlanTaskDependencies(ctx, planID)
+	if err != nil {
+		h.logger.Error("list plan dependencies", "error", err)
+		WriteInternalError(w, "Failed to get plan dependencies")
+		return
+	}
+	WriteJSON(w, http.StatusOK, buildPlanStatus(plan, tasks, edges))
+}
+
+// isCreator reports whether userID is the recorded creator of a plan or task; a missing creator or caller never matches.
+func isCreator(createdBy, userID *uuid.UUID) bool {
+	return createdBy != nil && userID != nil && *createdBy == *userID
+}
+
+func planToResponse(plan *models.ProjectPlan) userapi.PlanResponse {
+	return userapi.PlanResponse{
+		PlanID:    plan.ID.String(),
+		ProjectID: plan.ProjectID.String(),
+		PlanName:  plan.PlanName,
+		State:     plan.State,
+		Archived:  plan.Archived,
+		CreatedAt: plan.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: plan.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// buildPlanStatus lists the plan's tasks in dependency order (a task after all its dependencies; ties by creation time)
+// and derives the plan execution status from the task statuses.
+func buildPlanStatus(plan *models.ProjectPlan, tasks []*models.Task, edges []*models.TaskDependency) userapi.PlanStatusResponse {
+	byID := make(map[uuid.UUID]*models.Task, len(tasks))
+	for _, t := range tasks {
+		byID[t.ID] = t
+	}
+	dependsOn := map[uuid.UUID][]uuid.UUID{}
+	dependents := map[uuid.UUID][]uuid.UUID{}
+	for _, e := range edges {
+		dependsOn[e.TaskID] = append(dependsOn[e.TaskID], e.DependsOnTaskID)
+		dependents[e.DependsOnTaskID] = append(dependents[e.DependsOnTaskID], e.TaskID)
+	}
+	resp := userapi.PlanStatusResponse{
+		PlanResponse: planToResponse(plan),
+		Counts:       map[string]int{},
+		Tasks:        make([]userapi.PlanTaskNode, 0, len(tasks)),
+	}
+	for _, t := range topoSortTasks(tasks, dependsOn, dependents) {
+		node := userapi.PlanTaskNode{
+			TaskID:     t.ID.String(),
+			TaskName:   t.Summary,
+			Status:     taskStatusToSpec(t.Status),
+			DependsOn:  idStrings(dependsOn[t.ID]),
+			Dependents: idStrings(dependents[t.ID]),
+		}
+		for _, dep := range dependsOn[t.ID] {
+			if d, ok := byID[dep]; !ok || d.Status != models.TaskStatusCompleted {
+				node.BlockedBy = append(node.BlockedBy, dep.String())
+			}
+		}
+		node.Runnable = t.Status == models.TaskStatusPending && len(node.BlockedBy) == 0
+		resp.Counts[node.Status]++
+		resp.Tasks = append(resp.Tasks, node)
+	}
+	resp.Status = planExecutionStatus(tasks)
+	return resp
+}
+
+// topoSortTasks orders tasks so each comes after its dependencies (Kahn's algorithm over creation order).
+func topoSortTasks(tasks []*models.Task, dependsOn, dependents map[uuid.UUID][]uuid.UUID) []*models.Task {
+	inPlan := make(map[uuid.UUID]bool, len(tasks))
+	for _, t := range tasks {
+		inPlan[t.ID] = true
+	}
+	remaining := make(map[uuid.UUID]int, len(tasks))
+	for _, t := range tasks {
+		for _, dep := range dependsOn[t.ID] {
+			if inPlan[dep] {
+				remaining[t.ID]++
+			}
+		}
+	}
+	out := make([]*models.Task, 0, len(tasks))
+	placed := make(map[uuid.UUID]bool, len(tasks))
+	for len(out) < len(tasks) {
+		progressed := false
+		for _, t := range tasks {
+			if placed[t.ID] || remaining[t.ID] > 0 {
+				continue
+			}
+			placed[t.ID] = true
+			out = append(out, t)
+			progressed = true
+			for _, d := range dependents[t.ID] {
+				remaining[d]--
+			}
+			break
+		}
+		if !progressed {
+			// Cycle (not created through AddTaskToPlan): append the rest in creation order.
+			for _, t := range tasks {
+				if !placed[t.ID] {
+					out = append(out, t)
+				}
+			}
+			break
+		}
+	}
+	return out
+}
+
+// planExecutionStatus is failed when any task failed, canceled when any was canceled and none is still open,
+// completed when every task completed, running when any task is running, and queued otherwise (including no tasks).
+func planExecutionStatus(tasks []*models.Task) string {
+	counts := map[string]int{}
+	for _, t := range tasks {
+		counts[t.Status]++
+	}
+	switch {
+	case counts[models.TaskStatusFailed] > 0:
+		return userapi.StatusFailed
+	case len(tasks) > 0 && counts[models.TaskStatusCompleted] == len(tasks):
+		return userapi.StatusCompleted
+	case counts[models.TaskStatusRunning] > 0:
+		return userapi.StatusRunning
+	case counts[models.TaskStatusCanceled] > 0 && counts[models.TaskStatusPending] == 0:
+		return userapi.StatusCanceled
+	default:
+		return userapi.StatusQueued
+	}
+}
+
+func idStrings(ids []uuid.UUID) []string {
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, id.String())
+	}
+	return out
+}
+
+// taskPlacement is a validated plan_id and depends_on from a create task request.
+type taskPlacement struct {
+	plan      *models.ProjectPlan
+	dependsOn []uuid.UUID
+}
+
+func (p *taskPlacement) hasDependencies() bool {
+	return p != nil && len(p.dependsOn) > 0
+}
+
+// resolveTaskPlacement validates req.PlanID and req.DependsOn: the plan must exist, be created by userID and not be
+// archived, and every dependency must be a task userID created that is already in the plan and has not failed or
+// been canceled. Plans and tasks of other users are reported as unknown. When req.ProjectID is unset it is set to the plan's project.
+// Returns (nil, true) when the request has no plan; writes 400 and returns false on invalid input.
+func (h *TaskHandler) resolveTaskPlacement(ctx context.Context, w http.ResponseWriter, userID *uuid.UUID, req *userapi.CreateTaskRequest) (*taskPlacement, bool) {
+	if req.PlanID == nil || strings.TrimSpace(*req.PlanID) == "" {
+		if len(req.DependsOn) > 0 {
+			WriteBadRequest(w, "depends_on requires plan_id")
+			return nil, false
+		}
+		return nil, true
+	}
+	planID, err := uuid.Parse(strings.TrimSpace(*req.PlanID))
+	if err != nil {
+		WriteBadRequest(w, "Invalid plan_id")
+		return nil, false
+	}
+	plan, err := h.db.GetProjectPlanByID(ctx, planID)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		h.logger.Error("get plan", "error", err)
+		WriteInternalError(w, "Failed to create task")
+		return nil, false
+	}
+	if plan == nil || !isCreator(plan.CreatedBy, userID) {
+		WriteBadRequest(w, "Unknown plan_id")
+		return nil, false
+	}
+	if plan.Archived {
+		WriteBadRequest(w, "Plan is archived")
+		return nil, false
+	}
+	planProject := plan.ProjectID.String()
+	if req.ProjectID == nil || strings.TrimSpace(*req.ProjectID) == "" {
+		req.ProjectID = &planProject
+	} else if strings.TrimSpace(*req.ProjectID) != planProject {
+		WriteBadRequest(w, "project_id does not match the plan's project")
+		return nil, false
+	}
+	placement := &taskPlacement{plan: plan}
+	for _, raw := range req.DependsOn {
+		depID, err := uuid.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			WriteBadRequest(w, "Invalid depends_on task id")
+			return nil, false
+		}
+		dep, err := h.db.GetTaskByID(ctx, depID)
+		if err != nil && !errors.Is(err, database.ErrNotFound) {
+			h.logger.Error("get dependency task", "error", err)
+			WriteInternalError(w, "Failed to create task")
+			return nil, false
+		}
+		if dep == nil || !isCreator(dep.CreatedBy, userID) || dep.PlanID == nil || *dep.PlanID != planID {
+			WriteBadRequest(w, "depends_on task "+depID.String()+" is not in the plan")
+			return nil, false
+		}
+		if dep.Status == models.TaskStatusFailed || dep.Status == models.TaskStatusCanceled {
+			WriteBadRequest(w, "depends_on task "+depID.String()+" is "+dep.Status)
+			return nil, false
+		}
+		placement.dependsOn = append(placement.dependsOn, depID)
+	}
+	return placement, true
+}
+
+// insertTask creates the task, placing it in its plan in the same transaction when p is set; writes an error
+// response and returns false on failure.
+func (h *TaskHandler) insertTask(ctx context.Context, w http.ResponseWriter, userID *uuid.UUID, req *userapi.CreateTaskRequest, projectID *uuid.UUID, p *taskPlacement) (*models.Task, bool) {
+	var task *models.Task
+	var err error
+	if p == nil {
+		task, err = h.db.CreateTask(ctx, userID, req.Prompt, req.TaskName, projectID)
+	} else {
+		task, err = h.db.CreatePlanTask(ctx, userID, req.Prompt, req.TaskName, projectID, p.plan.ID, p.dependsOn)
+	}
+	if errors.Is(err, database.ErrInvalidDependency) {
+		WriteBadRequest(w, "Invalid depends_on")
+		return nil, false
+	}
+	if err != nil {
+		h.logger.Error("create task", "error", err)
+		WriteInternalError(w, "Failed to create task")
+		return nil, false
+	}
+	return task, true
+}
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
+)
+
+func createPlanTask(t *testing.T, h *TaskHandler, userID uuid.UUID, planID string, dependsOn ...string) (int, string) {
+	t.Helper()
+	req, rec := recordedRequestJSON(http.MethodPost, "/v1/tasks",
+		userapi.CreateTaskRequest{Prompt: "step", PlanID: &planID, DependsOn: dependsOn})
+	req = req.WithContext(context.WithValue(req.Context(), contextKeyUserID, userID))
+	h.CreateTask(rec, req)
+	var resp userapi.TaskResponse
+	_ = json.NewDecoder(rec.Body).Decode(&resp)
+	return rec.Code, resp.TaskID
+}
+
+func getPlanStatus(t *testing.T, h *PlanHandler, userID uuid.UUID, planID string) (int, userapi.PlanStatusResponse) {
+	t.Helper()
+	req, rec := recordedRequest(http.MethodGet, "/v1/plans/"+planID+"/status", nil)
+	req = req.WithContext(context.WithValue(req.Context(), contextKeyUserID, userID))
+	req.SetPathValue("id", planID)
+	h.GetPlanStatus(rec, req)
+	var resp userapi.PlanStatusResponse
+	if rec.Code == http.StatusOK {
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode plan status: %v", err)
+		}
+	}
+	return rec.Code, resp
+}
+
+// setupPlan creates a plan in the default project of a new user, returned as the plan's owner.
+func setupPlan(t *testing.T) (*testutil.MockDB, *TaskHandler, *PlanHandler, string, uuid.UUID) {
+	t.Helper()
+	mock := testutil.NewMockDB()
+	planHandler := NewPlanHandler(mock, newTestLogger())
+	userID := uuid.New()
+	project, _ := mock.GetOrCreateDefaultProjectForUser(context.Background(), userID)
+	projectID := project.ID.String()
+	name := "release"
+	req, rec := recordedRequestJSON(http.MethodPost, "/v1/projects/"+projectID+"/plans", userapi.CreatePlanRequest{PlanName: &name})
+	req = req.WithContext(context.WithValue(req.Context(), contextKeyUserID, userID))
+	req.SetPathValue("project_id", projectID)
+	planHandler.CreatePlan(rec, req)
+	assertStatusCode(t, rec, http.StatusCreated)
+	var plan userapi.PlanResponse
+	if err := json.NewDecoder(rec.Body).Decode(&plan); err != nil || plan.State != models.PlanStateDraft || plan.ProjectID != projectID {
+		t.Fatalf("create plan: %v %+v", err, plan)
+	}
+	return mock, NewTaskHandler(mock, newTestLogger(), "", ""), planHandler, plan.PlanID, userID
+}
+
+func TestPlanStatus_DependencyOrder(t *testing.T) {
+	mock, taskHandler, planHandler, planID, userID := setupPlan(t)
+	_, build := createPlanTask(t, taskHandler, userID, planID)
+	_, test := createPlanTask(t, taskHandler, userID, planID, build)
+	code, deploy := createPlanTask(t, taskHandler, userID, planID, test, build)
+	if code != http.StatusCreated {
+		t.Fatalf("create dependent task: %d", code)
+	}
+
+	code, status := getPlanStatus(t, planHandler, userID, planID)
+	if code != http.StatusOK || len(status.Tasks) != 3 || status.Status != userapi.StatusQueued || status.Counts[userapi.StatusQueued] != 3 {
+		t.Fatalf("plan status: code=%d %+v", code, status)
+	}
+	if status.Tasks[0].TaskID != build || status.Tasks[1].TaskID != test || status.Tasks[2].TaskID != deploy {
+		t.Errorf("tasks not in dependency order: %+v", status.Tasks)
+	}
+	if !status.Tasks[0].Runnable || status.Tasks[1].Runnable || len(status.Tasks[2].BlockedBy) != 2 || len(status.Tasks[0].Dependents) != 2 {
+		t.Errorf("runnable/blocked: %+v", status.Tasks)
+	}
+
+	buildID, _ := uuid.Parse(build)
+	_ = mock.UpdateTaskStatus(context.Background(), buildID, models.TaskStatusCompleted)
+	_, status = getPlanStatus(t, planHandler, userID, planID)
+	if !status.Tasks[1].Runnable || status.Tasks[2].Runnable {
+		t.Errorf("after build completed: %+v", status.Tasks)
+	}
+}
+
+func TestPlanStatus_FailureCascades(t *testing.T) {
+	mock, taskHandler, planHandler, planID, userID := setupPlan(t)
+	_, first := createPlanTask(t, taskHandler, userID, planID)
+	_, second := createPlanTask(t, taskHandler, userID, planID, first)
+
+	firstID, _ := uuid.Parse(first)
+	_ = mock.UpdateTaskStatus(context.Background(), firstID, models.TaskStatusFailed)
+	_, status := getPlanStatus(t, planHandler, userID, planID)
+	if status.Status != userapi.StatusFailed || status.Tasks[1].TaskID != second || status.Tasks[1].Status != userapi.StatusFailed {
+		t.Errorf("plan after failure: %+v", status)
+	}
+	secondID, _ := uuid.Parse(second)
+	jobs, _ := mock.GetJobsByTaskID(context.Background(), secondID)
+	if len(jobs) != 1 || jobs[0].Status != models.JobStatusCanceled {
+		t.Errorf("dependent job not canceled: %+v", jobs)
+	}
+
+	// New tasks cannot depend on a task that already failed, which would otherwise never become runnable.
+	tasksBefore := len(mock.Tasks)
+	if code, _ := createPlanTask(t, taskHandler, userID, planID, first); code != http.StatusBadRequest {
+		t.Errorf("dependency on failed task: %d", code)
+	}
+	if len(mock.Tasks) != tasksBefore {
+		t.Errorf("rejected task was created")
+	}
+}
+
+// TestPlanStatus_RequeueReopensCascadedDependents verifies requeueing a dead-lettered step reopens the steps that
+// failed only because of it, while a step that also depends on another failed step stays failed.
+func TestPlanStatus_RequeueReopensCascadedDependents(t *testing.T) {
+	mock, taskHandler, planHandler, planID, userID := setupPlan(t)
+	ctx := context.Background()
+	_, first := createPlanTask(t, taskHandler, userID, planID)
+	_, second := createPlanTask(t, taskHandler, userID, planID, first)
+	_, side := createPlanTask(t, taskHandler, userID, planID)
+	_, third := createPlanTask(t, taskHandler, userID, planID, second, side)
+	fail := func(taskID, jobStatus string) *models.Job {
+		jobs, _ := mock.GetJobsByTaskID(ctx, uuid.MustParse(taskID))
+		_ = mock.CompleteJob(ctx, jobs[0].ID, "{}", jobStatus)
+		_ = mock.UpdateTaskStatus(ctx, uuid.MustParse(taskID), models.TaskStatusFailed)
+		return jobs[0]
+	}
+	fail(side, models.JobStatusFailed)
+	deadLettered := fail(first, models.JobStatusDeadLettered)
+
+	req, rec := recordedRequest(http.MethodPost, "/v1/jobs/"+deadLettered.ID.String()+"/requeue", nil)
+	req.SetPathValue("id", deadLettered.ID.String())
+	NewJobHandler(mock, newTestLogger()).Requeue(rec, req)
+	assertStatusCode(t, rec, http.StatusOK)
+
+	_, status := getPlanStatus(t, planHandler, userID, planID)
+	want := map[string]string{first: userapi.StatusQueued, second: userapi.StatusQueued, side: userapi.StatusFailed, third: userapi.StatusFailed}
+	for _, task := range status.Tasks {
+		if task.Status != want[task.TaskID] {
+			t.Errorf("task %s status %s, want %s", task.TaskID, task.Status, want[task.TaskID])
+		}
+	}
+	jobs, _ := mock.GetJobsByTaskID(ctx, uuid.MustParse(second))
+	if len(jobs) != 1 || jobs[0].Status != models.JobStatusQueued || jobs[0].EndedAt != nil {
+		t.Errorf("reopened dependent's job: %+v", jobs)
+	}
+	jobs, _ = mock.GetJobsByTaskID(ctx, uuid.MustParse(third))
+	if len(jobs) != 1 || jobs[0].Status != models.JobStatusCanceled {
+		t.Errorf("still-blocked dependent's job: %+v", jobs)
+	}
+}
+
+func TestPlanStatus_CancelCascades(t *testing.T) {
+	mock, taskHandler, planHandler, planID, userID := setupPlan(t)
+	_, first := createPlanTask(t, taskHandler, userID, planID)
+	_, second := createPlanTask(t, taskHandler, userID, planID, first)
+
+	_ = mock.UpdateTaskStatus(context.Background(), uuid.MustParse(first), models.TaskStatusCanceled)
+	_, status := getPlanStatus(t, planHandler, userID, planID)
+	if status.Status != userapi.StatusCanceled || status.Tasks[1].TaskID != second || status.Tasks[1].Status != userapi.StatusCanceled {
+		t.Errorf("plan after cancel: %+v", status)
+	}
+	if code, _ := createPlanTask(t, taskHandler, userID, planID, first); code != http.StatusBadRequest {
+		t.Errorf("dependency on canceled task: %d", code)
+	}
+}
+
+func TestCreateTask_PlanPlacementErrors(t *testing.T) {
+	mock, taskHandler, planHandler, planID, userID := setupPlan(t)
+	outside, _ := mock.CreateTask(context.Background(), &userID, "outside", nil)
+
+	if code, _ := createPlanTask(t, taskHandler, userID, planID, outside.ID.String()); code != http.StatusBadRequest {
+		t.Errorf("dependency outside plan: %d", code)
+	}
+	if code, _ := createPlanTask(t, taskHandler, userID, uuid.New().String()); code != http.StatusBadRequest {
+		t.Errorf("unknown plan: %d", code)
+	}
+	if code, _ := createPlanTask(t, taskHandler, userID, planID, "not-a-uuid"); code != http.StatusBadRequest {
+		t.Errorf("invalid dependency id: %d", code)
+	}
+	req, rec := recordedRequestJSON(http.MethodPost, "/v1/tasks", userapi.CreateTaskRequest{Prompt: "p", DependsOn: []string{outside.ID.String()}})
+	taskHandler.CreateTask(rec, req)
+	assertStatusCode(t, rec, http.StatusBadRequest)
+
+	if code, _ := getPlanStatus(t, planHandler, userID, uuid.New().String()); code != http.StatusNotFound {
+		t.Errorf("unknown plan status: %d", code)
+	}
+	if code, _ := getPlanStatus(t, planHandler, userID, "bad"); code != http.StatusBadRequest {
+		t.Errorf("bad plan id: %d", code)
+	}
+}
+
+func TestPlans_ScopedToOwner(t *testing.T) {
+	mock, taskHandler, planHandler, planID, owner := setupPlan(t)
+	other := uuid.New()
+	if code, _ := getPlanStatus(t, planHandler, other, planID); code != http.StatusNotFound {
+		t.Errorf("other user's plan status: %d", code)
+	}
+	_, ownerTask := createPlanTask(t, taskHandler, owner, planID)
+	if code, _ := createPlanTask(t, taskHandler, other, planID); code != http.StatusBadRequest {
+		t.Errorf("task in other user's plan: %d", code)
+	}
+
+	// A task in the plan that another user created (e.g. from before plans had owners) is not a valid dependency.
+	foreign, _ := mock.CreateTask(context.Background(), &other, "x", nil, nil)
+	planUUID := uuid.MustParse(planID)
+	_ = mock.AddTaskToPlan(context.Background(), foreign.ID, planUUID, nil)
+	if code, _ := createPlanTask(t, taskHandler, owner, planID, ownerTask, foreign.ID.String()); code != http.StatusBadRequest {
+		t.Errorf("dependency on other user's task: %d", code)
+	}
+	if code, _ := createPlanTask(t, taskHandler, owner, planID, ownerTask); code != http.StatusCreated {
+		t.Errorf("dependency on own task: %d", code)
+	}
+
+	// A project the caller has no role in cannot get plans; one where they hold a project role can.
+	projectID := uuid.New()
+	create := func() int {
+		req, rec := recordedRequestJSON(http.MethodPost, "/v1/projects/"+projectID.String()+"/plans", userapi.CreatePlanRequest{})
+		req = req.WithContext(context.WithValue(req.Context(), contextKeyUserID, other))
+		req.SetPathValue("project_id", projectID.String())
+		planHandler.CreatePlan(rec, req)
+		return rec.Code
+	}
+	if code := create(); code != http.StatusNotFound {
+		t.Errorf("plan in foreign project: %d", code)
+	}
+	for _, r := range database.BuiltinRoles() {
+		if r.Name == database.RoleMember {
+			_ = mock.CreateRoleBinding(context.Background(), &models.RoleBinding{
+				SubjectType: database.SubjectTypeUser, SubjectID: other, RoleID: r.ID,
+				ScopeType: database.ScopeTypeProject, ScopeID: &projectID,
+			})
+		}
+	}
+	if code := create(); code != http.StatusCreated {
+		t.Errorf("plan in project with a role binding: %d", code)
+	}
+}
@@ -0,0 +1,439 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/policy"
+)
+
+// Access control audit actions for policy rule management.
+const (
+	ActionPolicyRuleCreate = "policy.rule.create"
+	ActionPolicyRuleUpdate = "policy.rule.update"
+	ActionPolicyRuleDelete = "policy.rule.delete"
+	ResourceTypePolicyRule = "access_control_rule"
+)
+
+// PolicyHandler serves access control rule administration and the evaluate dry-run (access_control.md).
+// Reads and evaluate require policy.read; rule changes require policy.manage and are audited.
+type PolicyHandler struct {
+	db     database.Store
+	policy *policy.Engine
+	logger *slog.Logger
+}
+
+// NewPolicyHandler creates a policy handler.
+func NewPolicyHandler(db database.Store, logger *slog.Logger) *PolicyHandler {
+	return &PolicyHandler{db: db, policy: policy.New(db), logger: logger}
+}
+
+// ListRules handles GET /v1/policy/rules. Optional filters: action, resource_type, subject_type, subject_id.
+func (h *PolicyHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := database.AccessControlRuleFilter{
+		Action: q.Get("action"), ResourceType: q.Get("resource_type"), SubjectType: q.Get("subject_type"),
+	}
+	if sid := q.Get("subject_id"); sid != "" {
+		id, err := uuid.Parse(sid)
+		if err != nil {
+			WriteBadRequest(w, "Invalid subject_id")
+			return
+		}
+		f.SubjectID = &id
+	}
+	rules, err := h.db.ListAccessControlRules(r.Context(), f)
+	if err != nil {
+		h.logger.Error("list access control rules", "error", err)
+		WriteInternalError(w, "Failed to list policy rules")
+		return
+	}
+	WriteJSON(w, http.StatusOK, userapi.ListPolicyRulesResponse{Rules: rulesToResponse(rules)})
+}
+
+// GetRule handles GET /v1/policy/rules/{id}.
+func (h *PolicyHandler) GetRule(w http.ResponseWriter, r *http.Request) {
+	rule := h.resolveRule(w, r)
+	if rule == nil {
+		return
+	}
+	WriteJSON(w, http.StatusOK, ruleToResponse(rule))
+}
+
+// CreateRule handles POST /v1/policy/rules and returns 201 with the rule.
+func (h *PolicyHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rule, ok := decodeRule(w, r)
+	if !ok {
+		return
+	}
+	updatedBy := GetHandleFromContext(ctx)
+	rule.UpdatedBy = &updatedBy
+	if err := h.db.CreateAccessControlRule(ctx, rule); err != nil {
+		h.logger.Error("create access control rule", "error", err)
+		WriteInternalError(w, "Failed to create policy rule")
+		return
+	}
+	h.audit(ctx, ActionPolicyRuleCreate, rule)
+	WriteJSON(w, http.StatusCreated, ruleToResponse(rule))
+}
+
+// UpdateRule handles PUT /v1/policy/rules/{id}. The body replaces the whole rule.
+func (h *PolicyHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	existing := h.resolveRule(w, r)
+	if existing == nil {
+		return
+	}
+	ctx := r.Context()
+	rule, ok := decodeRule(w, r)
+	if !ok {
+		return
+	}
+	updatedBy := GetHandleFromContext(ctx)
+	rule.ID, rule.CreatedAt, rule.UpdatedBy = existing.ID, existing.CreatedAt, &updatedBy
+	if err := h.db.UpdateAccessControlRule(ctx, rule); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			WriteNotFound(w, "Policy rule not found")
+			return
+		}
+		h.logger.Error("update access control rule", "error", err, "rule_id", rule.ID)
+		WriteInternalError(w, "Failed to update policy rule")
+		return
+	}
+	h.audit(ctx, ActionPolicyRuleUpdate, rule)
+	WriteJSON(w, http.StatusOK, ruleToResponse(rule))
+}
+
+// DeleteRule handles DELETE /v1/policy/rules/{id}. Default rules are refused with 409 because RunSchema re-seeds them.
+func (h *PolicyHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	rule := h.resolveRule(w, r)
+	if rule == nil {
+		return
+	}
+	ctx := r.Context()
+	if err := h.db.DeleteAccessControlRule(ctx, rule.ID); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			WriteNotFound(w, "Policy rule not found")
+			return
+		}
+		if errors.Is(err, database.ErrConflict) {
+			WriteConflict(w, "Default policy rules cannot be deleted; edit the rule or override it with a higher-priority deny rule")
+			return
+		}
+		h.logger.Error("delete access control rule", "error", err, "rule_id", rule.ID)
+		WriteInternalError(w, "Failed to delete policy rule")
+		return
+	}
+	h.audit(ctx, ActionPolicyRuleDelete, rule)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Evaluate handles POST /v1/policy/evaluate: it decides the request as the policy engine would at an
+// enforcement point, without side effects, and returns the decision and every rule that matched.
+func (h *PolicyHandler) Evaluate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req userapi.PolicyEvaluateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteBadRequest(w, "Invalid request body")
+		return
+	}
+	preq := &policy.Request{Action: strings.TrimSpace(req.Action), ResourceType: req.ResourceType, Resource: req.Resource}
+	if preq.ResourceType == "" {
+		preq.ResourceType = policy.DefaultResourceType(preq.Action)
+	}
+	if preq.Action == "" || preq.ResourceType == "" {
+		WriteBadRequest(w, "action is required, and resource_type unless the action is built in")
+		return
+	}
+	if detail := evaluateContext(req.Context, &preq.Context); detail != "" {
+		WriteBadRequest(w, detail)
+		return
+	}
+	principal, ok := h.evaluatePrincipal(ctx, w, &req)
+	if !ok {
+		return
+	}
+	preq.Principal = principal
+	d, matched, err := h.policy.Explain(ctx, preq)
+	if err != nil {
+		h.logger.Error("policy evaluate", "error", err)
+		WriteInternalError(w, "Failed to evaluate policy")
+		return
+	}
+	resp := userapi.PolicyEvaluateResponse{
+		Allowed:      d.Allowed,
+		Reason:       d.Reason,
+		ResourceType: preq.ResourceType,
+		MatchedRules: rulesToResponse(matched),
+		Principal:    principalToResponse(principal),
+	}
+	if d.Rule != nil {
+		rule := ruleToResponse(d.Rule)
+		resp.Rule = &rule
+	}
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+// evaluatePrincipal resolves the subject of a dry-run. Writes 400/404/500 and returns false on failure.
+func (h *PolicyHandler) evaluatePrincipal(ctx context.Context, w http.ResponseWriter, req *userapi.PolicyEvaluateRequest) (policy.Principal, bool) {
+	switch req.SubjectType {
+	case userapi.PolicySubjectSystem:
+		return policy.Principal{}, true
+	case "", userapi.PolicySubjectUser:
+		return h.userPrincipal(ctx, w, req)
+	case userapi.PolicySubjectGroup:
+		id, err := uuid.Parse(req.SubjectID)
+		if err != nil {
+			WriteBadRequest(w, "Invalid subject_id")
+			return policy.Principal{}, false
+		}
+		if _, err := h.db.GetGroupByID(ctx, id); err != nil {
+			h.writeLookupErr(w, err, "Group not found")
+			return policy.Principal{}, false
+		}
+		return policy.Principal{GroupIDs: []uuid.UUID{id}}, true
+	case userapi.PolicySubjectRole:
+		roles, err := h.db.ListRoles(ctx)
+		if err != nil {
+			h.writeLookupErr(w, err, "")
+			return policy.Principal{}, false
+		}
+		for _, role := range roles {
+			if role.ID.String() == req.SubjectID || (req.Role != "" && role.Name == req.Role) {
+				return policy.Principal{RoleIDs: []uuid.UUID{role.ID}, RoleNames: []string{role.Name}}, true
+			}
+		}
+		WriteNotFound(w, "Role not found")
+		return policy.Principal{}, false
+	}
+	WriteBadRequest(w, "subject_type must be system, user, group, or role")
+	return policy.Principal{}, false
+}
+
+// userPrincipal resolves a user subject (the caller when neither subject_id nor handle is set) with its groups
+// and roles.
+func (h *PolicyHandler) userPrincipal(ctx context.Context, w http.ResponseWriter, req *userapi.PolicyEvaluateRequest) (policy.Principal, bool) {
+	var userID uuid.UUID
+	switch {
+	case req.SubjectID != "":
+		id, err := uuid.Parse(req.SubjectID)
+		if err != nil {
+			WriteBadRequest(w, "Invalid subject_id")
+			return policy.Principal{}, false
+		}
+		if _, err := h.db.GetUserByID(ctx, id); err != nil {
+			h.writeLookupErr(w, err, "User not found")
+			return policy.Principal{}, false
+		}
+		userID = id
+	case req.Handle != "":
+		u, err := h.db.GetUserByHandle(ctx, req.Handle)
+		if err != nil {
+			h.writeLookupErr(w, err, "User not found")
+			return policy.Principal{}, false
+		}
+		userID = u.ID
+	default:
+		caller := getUserIDFromContext(ctx)
+		if caller == nil {
+			WriteUnauthorized(w, "Authentication required")
+			return policy.Principal{}, false
+		}
+		userID = *caller
+	}
+	p, err := h.policy.PrincipalForUser(ctx, userID)
+	if err != nil {
+		h.writeLookupErr(w, err, "")
+		return policy.Principal{}, false
+	}
+	return p, true
+}
+
+// writeLookupErr writes 404 with notFound for ErrNotFound (when notFound is set) and 500 otherwise.
+func (h *PolicyHandler) writeLookupErr(w http.ResponseWriter, err error, notFound string) {
+	if notFound != "" && errors.Is(err, database.ErrNotFound) {
+		WriteNotFound(w, notFound)
+		return
+	}
+	h.logger.Error("policy evaluate subject", "error", err)
+	WriteInternalError(w, "Failed to resolve subject")
+}
+
+// evaluateContext copies the dry-run context into out; returns a problem detail when a field is invalid.
+func evaluateContext(in *userapi.PolicyEvaluateContext, out *policy.Context) string {
+	if in == nil {
+		return ""
+	}
+	if in.ProjectID != nil && *in.ProjectID != "" {
+		id, err := uuid.Parse(*in.ProjectID)
+		if err != nil {
+			return "Invalid context.project_id"
+		}
+		out.ProjectID = &id
+	}
+	if in.Time != nil && *in.Time != "" {
+		t, err := time.Parse(time.RFC3339, *in.Time)
+		if err != nil {
+			return "context.time must be RFC 3339"
+		}
+		out.Time = t
+	}
+	out.TaskType, out.RequestBytes = in.TaskType, in.RequestBytes
+	return ""
+}
+
+// resolveRule loads {id}. Writes 400/404/500 and returns nil otherwise.
+func (h *PolicyHandler) resolveRule(w http.ResponseWriter, r *http.Request) *models.AccessControlRule {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		WriteBadRequest(w, "Invalid rule id")
+		return nil
+	}
+	rule, err := h.db.GetAccessControlRuleByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			WriteNotFound(w, "Policy rule not found")
+			return nil
+		}
+		h.logger.Error("get access control rule", "error", err, "rule_id", id)
+		WriteInternalError(w, "Failed to get policy rule")
+		return nil
+	}
+	return rule
+}
+
+// decodeRule decodes and validates a PolicyRuleRequest body. Writes 400 and returns false when invalid.
+func decodeRule(w http.ResponseWriter, r *http.Request) (*models.AccessControlRule, bool) {
+	var req userapi.PolicyRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteBadRequest(w, "Invalid request body")
+		return nil, false
+	}
+	rule, detail := ruleFromRequest(&req)
+	if rule == nil {
+		WriteBadRequest(w, detail)
+		return nil, false
+	}
+	return rule, true
+}
+
+func ruleFromRequest(req *userapi.PolicyRuleRequest) (*models.AccessControlRule, string) {
+	rule := &models.AccessControlRule{
+		SubjectType:     req.SubjectType,
+		Action:          strings.TrimSpace(req.Action),
+		ResourceType:    strings.TrimSpace(req.ResourceType),
+		ResourcePattern: req.ResourcePattern,
+		Effect:          req.Effect,
+		Priority:        req.Priority,
+	}
+	hasSubjectID := req.SubjectID != nil && *req.SubjectID != ""
+	switch req.SubjectType {
+	case userapi.PolicySubjectSystem:
+		if hasSubjectID {
+			return nil, "subject_id must be empty for system rules"
+		}
+	case userapi.PolicySubjectUser, userapi.PolicySubjectGroup, userapi.PolicySubjectRole:
+		if !hasSubjectID {
+			return nil, "subject_id is required for user, group, and role rules"
+		}
+		id, err := uuid.Parse(*req.SubjectID)
+		if err != nil {
+			return nil, "Invalid subject_id"
+		}
+		rule.SubjectID = &id
+	default:
+		return nil, "subject_type must be system, user, group, or role"
+	}
+	if rule.Action == "" || rule.ResourceType == "" {
+		return nil, "action and resource_type are required"
+	}
+	if err := policy.ValidatePattern(rule.ResourcePattern); err != nil {
+		return nil, err.Error()
+	}
+	if rule.Effect != policy.EffectAllow && rule.Effect != policy.EffectDeny {
+		return nil, "effect must be allow or deny"
+	}
+	if raw := strings.TrimSpace(string(req.Conditions)); raw != "" && raw != "null" {
+		if _, err := policy.ParseConditions(raw); err != nil {
+			return nil, err.Error()
+		}
+		rule.Conditions = &raw
+	}
+	return rule, ""
+}
+
+// audit records a rule change in access_control_audit_log; the reason is the rule as JSON so history can be
+// reconstructed after updates and deletes.
+func (h *PolicyHandler) audit(ctx context.Context, action string, rule *models.AccessControlRule) {
+	rec := &models.AccessControlAuditLog{
+		SubjectType:  database.SubjectTypeUser,
+		SubjectID:    getUserIDFromContext(ctx),
+		Action:       action,
+		ResourceType: ResourceTypePolicyRule,
+		Resource:     rule.ID.String(),
+		Decision:     "allow",
+	}
+	if snapshot, err := json.Marshal(ruleToResponse(rule)); err == nil {
+		s := string(snapshot)
+		rec.Reason = &s
+	}
+	if err := h.db.CreateAccessControlAuditLog(ctx, rec); err != nil {
+		h.logger.Warn("policy audit log failed", "error", err, "action", action)
+	}
+}
+
+func rulesToResponse(rules []*models.AccessControlRule) []userapi.PolicyRuleResponse {
+	out := make([]userapi.PolicyRuleResponse, 0, len(rules))
+	for _, r := range rules {
+		out = append(out, ruleToResponse(r))
+	}
+	return out
+}
+
+func ruleToResponse(r *models.AccessControlRule) userapi.PolicyRuleResponse {
+	resp := userapi.PolicyRuleResponse{
+		ID:              r.ID.String(),
+		SubjectType:     r.SubjectType,
+		Action:          r.Action,
+		ResourceType:    r.ResourceType,
+		ResourcePattern: r.ResourcePattern,
+		Effect:          r.Effect,
+		Priority:        r.Priority,
+		CreatedAt:       r.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:       r.UpdatedAt.UTC().Format(time.RFC3339),
+		UpdatedBy:       r.UpdatedBy,
+	}
+	if r.SubjectID != nil {
+		id := r.SubjectID.String()
+		resp.SubjectID = &id
+	}
+	if r.Conditions != nil && json.Valid([]byte(*r.Conditions)) {
+		resp.Conditions = json.RawMessage(*r.Conditions)
+	}
+	return resp
+}
+
+func principalToResponse(p policy.Principal) userapi.PolicyPrincipal {
+	resp := userapi.PolicyPrincipal{GroupIDs: make([]string, 0, len(p.GroupIDs)), RoleNames: p.RoleNames}
+	if p.UserID != nil {
+		id := p.UserID.String()
+		resp.UserID = &id
+	}
+	for _, id := range p.GroupIDs {
+		resp.GroupIDs = append(resp.GroupIDs, id.String())
+	}
+	if resp.RoleNames == nil {
+		resp.RoleNames = []string{}
+	}
+	return resp
+}
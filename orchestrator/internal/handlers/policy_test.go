@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
+)
+
+func TestPolicyHandler_RuleCRUD(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	h := NewPolicyHandler(mockDB, newTestLogger())
+	user := uuid.NewString()
+
+	create := func(body string, want int) userapi.PolicyRuleResponse {
+		t.Helper()
+		req, rec := credRequest(http.MethodPost, "/v1/policy/rules", body, uuid.New(), "admin")
+		h.CreateRule(rec, req)
+		assertStatusCode(t, rec, want)
+		var resp userapi.PolicyRuleResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		return resp
+	}
+	rule := create(`{"subject_type":"user","subject_id":"`+user+`","action":"api.call","resource_type":"api.provider_operation",`+
+		`"resource_pattern":"openai/*","effect":"allow","priority":10,"conditions":{"max_request_bytes":1024}}`, http.StatusCreated)
+	if rule.UpdatedBy == nil || *rule.UpdatedBy != "admin" || string(rule.Conditions) != `{"max_request_bytes":1024}` {
+		t.Errorf("rule = %+v", rule)
+	}
+	for _, body := range []string{
+		`{"subject_type":"system","subject_id":"` + user + `","action":"a","resource_type":"r","resource_pattern":"*","effect":"allow"}`,
+		`{"subject_type":"user","action":"a","resource_type":"r","resource_pattern":"*","effect":"allow"}`,
+		`{"subject_type":"project","subject_id":"` + user + `","action":"a","resource_type":"r","resource_pattern":"*","effect":"allow"}`,
+		`{"subject_type":"system","action":"a","resource_type":"r","resource_pattern":"[","effect":"allow"}`,
+		`{"subject_type":"system","action":"a","resource_type":"r","resource_pattern":"*","effect":"maybe"}`,
+		`{"subject_type":"system","action":"a","resource_type":"r","resource_pattern":"*","effect":"allow","conditions":{"weekday":1}}`,
+		`{"subject_type":"system","resource_type":"r","resource_pattern":"*","effect":"allow"}`,
+	} {
+		create(body, http.StatusBadRequest)
+	}
+
+	req, rec := credRequest(http.MethodGet, "/v1/policy/rules?action=api.call", "", uuid.New(), "admin")
+	h.ListRules(rec, req)
+	var list userapi.ListPolicyRulesResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &list)
+	if len(list.Rules) != 1 || list.Rules[0].ID != rule.ID {
+		t.Errorf("list = %+v", list.Rules)
+	}
+
+	req, rec = credRequest(http.MethodPut, "/v1/policy/rules/"+rule.ID,
+		`{"subject_type":"system","action":"api.call","resource_type":"api.provider_operation","resource_pattern":"github/**","effect":"deny","priority":5}`,
+		uuid.New(), "admin")
+	req.SetPathValue("id", rule.ID)
+	h.UpdateRule(rec, req)
+	assertStatusCode(t, rec, http.StatusOK)
+	stored, _ := mockDB.GetAccessControlRuleByID(context.Background(), uuid.MustParse(rule.ID))
+	if stored.SubjectType != database.SubjectTypeSystem || stored.SubjectID != nil || stored.Effect != "deny" || stored.Conditions != nil {
+		t.Errorf("updated = %+v", stored)
+	}
+
+	del := func(id string, want int) {
+		t.Helper()
+		req, rec := credRequest(http.MethodDelete, "/v1/policy/rules/"+id, "", uuid.New(), "admin")
+		req.SetPathValue("id", id)
+		h.DeleteRule(rec, req)
+		assertStatusCode(t, rec, want)
+	}
+	del(rule.ID, http.StatusNoContent)
+	del(rule.ID, http.StatusNotFound)
+	del("not-a-uuid", http.StatusBadRequest)
+	for _, r := range database.DefaultAccessControlRules() {
+		del(r.ID.String(), http.StatusConflict)
+	}
+}
+
+func evaluate(t *testing.T, h *PolicyHandler, body string, want int) userapi.PolicyEvaluateResponse {
+	t.Helper()
+	req, rec := credRequest(http.MethodPost, "/v1/policy/evaluate", body, uuid.New(), "admin")
+	h.Evaluate(rec, req)
+	assertStatusCode(t, rec, want)
+	var resp userapi.PolicyEvaluateResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return resp
+}
+
+func TestPolicyHandler_Evaluate(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	h := NewPolicyHandler(mockDB, newTestLogger())
+	ctx := context.Background()
+	alice, _ := mockDB.CreateUser(ctx, "alice", nil)
+	mockDB.AddUser(alice)
+	group := uuid.New()
+	_ = mockDB.CreateAccessControlRule(ctx, ruleFor(database.SubjectTypeUser, alice.ID, "openai/*", "allow", 0))
+	deny := ruleFor(database.SubjectTypeUser, alice.ID, "openai/chat", "deny", 10)
+	_ = mockDB.CreateAccessControlRule(ctx, deny)
+
+	resp := evaluate(t, h, `{"handle":"alice","action":"api.call","resource":"openai/chat"}`, http.StatusOK)
+	if resp.Allowed || resp.Rule == nil || resp.Rule.ID != deny.ID.String() || len(resp.MatchedRules) != 2 ||
+		resp.ResourceType != database.ResourceTypeProviderOperation {
+		t.Errorf("openai/chat = %+v", resp)
+	}
+	if resp.Principal.UserID == nil || *resp.Principal.UserID != alice.ID.String() || len(resp.Principal.RoleNames) != 1 {
+		t.Errorf("principal = %+v", resp.Principal)
+	}
+	resp = evaluate(t, h, `{"subject_id":"`+alice.ID.String()+`","action":"api.call","resource":"openai/embeddings"}`, http.StatusOK)
+	if !resp.Allowed || len(resp.MatchedRules) != 1 {
+		t.Errorf("openai/embeddings = %+v", resp)
+	}
+	// The seeded system rule allows MCP tools for every subject, including the system principal.
+	resp = evaluate(t, h, `{"subject_type":"system","action":"mcp.tool.invoke","resource":"db.preference.get"}`, http.StatusOK)
+	if !resp.Allowed || resp.Principal.UserID != nil {
+		t.Errorf("system mcp = %+v", resp)
+	}
+	resp = evaluate(t, h, `{"subject_type":"role","role":"member","action":"api.call","resource":"openai/chat"}`, http.StatusOK)
+	if resp.Allowed || resp.Rule != nil || resp.Reason == "" {
+		t.Errorf("role = %+v", resp)
+	}
+
+	evaluate(t, h, `{"handle":"nobody","action":"api.call","resource":"x"}`, http.StatusNotFound)
+	evaluate(t, h, `{"subject_type":"group","subject_id":"`+group.String()+`","action":"api.call","resource":"x"}`, http.StatusNotFound)
+	evaluate(t, h, `{"subject_type":"role","role":"root","action":"api.call","resource":"x"}`, http.StatusNotFound)
+	evaluate(t, h, `{"action":"web.fetch","resource":"x"}`, http.StatusBadRequest)
+	evaluate(t, h, `{"subject_type":"agent","action":"api.call","resource":"x"}`, http.StatusBadRequest)
+	evaluate(t, h, `{"action":"api.call","resource":"x","context":{"time":"yesterday"}}`, http.StatusBadRequest)
+}
+
+func TestPolicyHandler_EvaluateConditionsUseContext(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	h := NewPolicyHandler(mockDB, newTestLogger())
+	r := ruleFor(database.SubjectTypeSystem, uuid.Nil, "*", "allow", 0)
+	r.SubjectID = nil
+	cond := `{"time_window":{"start":"09:00","end":"17:00"}}`
+	r.Conditions = &cond
+	_ = mockDB.CreateAccessControlRule(context.Background(), r)
+
+	in := evaluate(t, h, `{"subject_type":"system","action":"api.call","resource":"openai/chat","context":{"time":"2026-03-04T10:00:00Z"}}`, http.StatusOK)
+	out := evaluate(t, h, `{"subject_type":"system","action":"api.call","resource":"openai/chat","context":{"time":"2026-03-04T20:00:00Z"}}`, http.StatusOK)
+	if !in.Allowed || out.Allowed {
+		t.Errorf("in hours = %+v, out of hours = %+v", in, out)
+	}
+}
+
+func ruleFor(subjectType string, subjectID uuid.UUID, pattern, effect string, priority int) *models.AccessControlRule {
+	return &models.AccessControlRule{
+		SubjectType: subjectType, SubjectID: &subjectID, Action: database.ActionApiCall,
+		ResourceType: database.ResourceTypeProviderOperation, ResourcePattern: pattern, Effect: effect, Priority: priority,
+	}
+}
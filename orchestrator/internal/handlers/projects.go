@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/rbac"
+)
+
+// canAccessProject reports whether userID may act in projectID (projects_and_scopes.md): it is the user's default
+// project, or the user or one of their groups holds a role bound in it, or the user is an admin. The access
+// resolved by the permission middleware is used when present.
+func canAccessProject(ctx context.Context, db database.Store, userID, projectID uuid.UUID) (bool, error) {
+	access := rbac.FromContext(ctx)
+	if access == nil || access.UserID != userID {
+		var err error
+		if access, err = rbac.Resolve(ctx, db, userID); err != nil {
+			return false, err
+		}
+	}
+	if access.InProject(projectID) {
+		return true, nil
+	}
+	def, err := db.GetOrCreateDefaultProjectForUser(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return def != nil && def.ID == projectID, nil
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+// maxClientNameLen bounds the self-reported client name stored on a session.
+const maxClientNameLen = 64
+
+// ListMySessions handles GET /v1/users/me/sessions: the caller's active sessions, newest first, with the session
+// of the calling access token marked current.
+func (h *UserHandler) ListMySessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := getUserIDFromContext(ctx)
+	if userID == nil {
+		WriteUnauthorized(w, "Not authenticated")
+		return
+	}
+	if refuseAccessTokenAuth(w, r, "list sessions") {
+		return
+	}
+	list, err := h.db.ListActiveRefreshSessions(ctx, *userID)
+	if err != nil {
+		h.logError("list sessions", "error", err, "user_id", *userID)
+		WriteInternalError(w, "Failed to list sessions")
+		return
+	}
+	current := getSessionIDFromContext(ctx)
+	resp := userapi.ListSessionsResponse{Sessions: make([]userapi.SessionResponse, 0, len(list))}
+	for _, s := range list {
+		resp.Sessions = append(resp.Sessions, sessionToResponse(s, current))
+	}
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+// RevokeMySession handles DELETE /v1/users/me/sessions/{session_id}. The session can no longer be refreshed and
+// its access tokens are denied at once; revoking the current session signs the caller out. Personal access
+// tokens are refused, so a narrowly scoped token cannot sign its owner out.
+func (h *UserHandler) RevokeMySession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := getUserIDFromContext(ctx)
+	if userID == nil {
+		WriteUnauthorized(w, "Not authenticated")
+		return
+	}
+	if refuseAccessTokenAuth(w, r, "revoke sessions") {
+		return
+	}
+	id, err := uuid.Parse(r.PathValue("session_id"))
+	if err != nil {
+		WriteBadRequest(w, "Invalid session id")
+		return
+	}
+	session, err := h.db.GetRefreshSessionByID(ctx, id)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		h.logError("get session", "error", err, "session_id", id)
+		WriteInternalError(w, "Failed to get session")
+		return
+	}
+	if session == nil || session.UserID != *userID || !session.IsActive {
+		WriteNotFound(w, "Session not found")
+		return
+	}
+	if err := h.db.InvalidateRefreshSession(ctx, id); err != nil {
+		h.logError("revoke session", "error", err, "session_id", id)
+		WriteInternalError(w, "Failed to revoke session")
+		return
+	}
+	h.audit(r, userID, EventSessionRevoke, "session "+id.String())
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func sessionToResponse(s *models.RefreshSession, current *uuid.UUID) userapi.SessionResponse {
+	return userapi.SessionResponse{
+		ID:         s.ID.String(),
+		ClientName: s.ClientName,
+		UserAgent:  s.UserAgent,
+		IPAddress:  s.IPAddress,
+		Current:    current != nil && *current == s.ID,
+		CreatedAt:  s.CreatedAt.UTC().Format(time.RFC3339),
+		LastUsedAt: formatOptionalTime(s.LastUsedAt),
+		ExpiresAt:  s.ExpiresAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// optionalString returns nil for "" so empty values are stored as NULL.
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// truncateRunes cuts s to at most n runes.
+func truncateRunes(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
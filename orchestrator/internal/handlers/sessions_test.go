@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/auth"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
+)
+
+func TestUserHandler_MySessions(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	jwtMgr := auth.NewJWTManager("test-secret-key-1234567890123456", 15*time.Minute, 7*24*time.Hour, 24*time.Hour)
+	authHandler := NewAuthHandler(mockDB, jwtMgr, auth.NewRateLimiter(100, time.Minute), newTestLogger())
+	h := NewUserHandler(mockDB, newTestLogger())
+	alice, _ := mockDB.CreateUser(context.Background(), "alice", nil)
+	hash, _ := auth.HashPassword("alice-password", nil)
+	_, _ = mockDB.CreatePasswordCredential(context.Background(), alice.ID, hash, "argon2id")
+
+	// Two sign-ins: a terminal client that names itself and a browser.
+	var sessionIDs []uuid.UUID
+	for _, name := range []string{"cynork", ""} {
+		req, rec := recordedRequestJSON("POST", "/v1/auth/login", userapi.LoginRequest{Handle: "alice", Password: "alice-password", ClientName: name})
+		req.Header.Set("User-Agent", "agent-"+name)
+		authHandler.Login(rec, req)
+		assertStatusCode(t, rec, http.StatusOK)
+		var resp userapi.LoginResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		claims, err := jwtMgr.ValidateAccessToken(resp.AccessToken)
+		if err != nil || claims.SessionID == "" {
+			t.Fatalf("access token claims = %+v, %v", claims, err)
+		}
+		sessionIDs = append(sessionIDs, uuid.MustParse(claims.SessionID))
+	}
+	cli := mockDB.RefreshSessions[sessionIDs[0]]
+	if cli.ClientName == nil || *cli.ClientName != "cynork" || *cli.UserAgent != "agent-cynork" || cli.IPAddress == nil {
+		t.Errorf("session metadata = %+v", cli)
+	}
+
+	req, rec := credRequest(http.MethodGet, "/v1/users/me/sessions", "", alice.ID, "alice")
+	req = req.WithContext(SetSessionContext(req.Context(), sessionIDs[1]))
+	h.ListMySessions(rec, req)
+	assertStatusCode(t, rec, http.StatusOK)
+	var list userapi.ListSessionsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || len(list.Sessions) != 2 {
+		t.Fatalf("list = %+v, %v", list, err)
+	}
+	for _, s := range list.Sessions {
+		if s.Current != (s.ID == sessionIDs[1].String()) {
+			t.Errorf("session %s current=%t", s.ID, s.Current)
+		}
+	}
+	req, rec = credRequest(http.MethodGet, "/v1/users/me/sessions", "", alice.ID, "alice")
+	req = req.WithContext(SetTokenScopes(req.Context(), []string{"all"}))
+	h.ListMySessions(rec, req)
+	assertStatusCode(t, rec, http.StatusForbidden)
+
+	// Another user cannot revoke alice's session.
+	bob, _ := mockDB.CreateUser(context.Background(), "bob", nil)
+	revoke := func(userID uuid.UUID, handle string) int {
+		req, rec := credRequest(http.MethodDelete, "/v1/users/me/sessions/"+sessionIDs[0].String(), "", userID, handle)
+		req.SetPathValue("session_id", sessionIDs[0].String())
+		h.RevokeMySession(rec, req)
+		return rec.Code
+	}
+	if code := revoke(bob.ID, "bob"); code != http.StatusNotFound {
+		t.Errorf("revoke as bob: %d", code)
+	}
+	req, rec = credRequest(http.MethodDelete, "/v1/users/me/sessions/"+sessionIDs[0].String(), "", alice.ID, "alice")
+	req = req.WithContext(SetTokenScopes(req.Context(), []string{"all"}))
+	req.SetPathValue("session_id", sessionIDs[0].String())
+	h.RevokeMySession(rec, req)
+	assertStatusCode(t, rec, http.StatusForbidden)
+	if code := revoke(alice.ID, "alice"); code != http.StatusNoContent {
+		t.Errorf("revoke: %d", code)
+	}
+	if denied, _ := mockDB.IsAccessSessionDenied(context.Background(), sessionIDs[0]); !denied || cli.IsActive {
+		t.Error("revoked session must be inactive with its access tokens denied")
+	}
+	if code := revoke(alice.ID, "alice"); code != http.StatusNotFound {
+		t.Errorf("revoke twice: %d", code)
+	}
+	if last := mockDB.AuditLogs[len(mockDB.AuditLogs)-1]; last.EventType != EventSessionRevoke {
+		t.Errorf("audit = %+v", last)
+	}
+
+	// Logout without a refresh token ends the session of the access token.
+	req, rec = recordedRequestJSON("POST", "/v1/auth/logout", userapi.LogoutRequest{})
+	req = req.WithContext(SetSessionContext(SetUserContext(req.Context(), alice.ID, "alice"), sessionIDs[1]))
+	authHandler.Logout(rec, req)
+	assertStatusCode(t, rec, http.StatusNoContent)
+	if denied, _ := mockDB.IsAccessSessionDenied(context.Background(), sessionIDs[1]); !denied {
+		t.Error("logout must deny the current session's access tokens")
+	}
+}
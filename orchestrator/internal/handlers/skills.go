@@ -11,10 +11,14 @@ import (
 
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/rbac"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/skillscan"
 )
 
-const skillScopeUser = "user"
+const (
+	skillScopeUser  = "user"
+	skillScopeGroup = "group"
+)
 
 // SkillsHandler handles GET/POST /v1/skills and GET/PUT/DELETE /v1/skills/{id}.
 type SkillsHandler struct {
@@ -27,18 +31,20 @@ func NewSkillsHandler(db database.Store, logger *slog.Logger) *SkillsHandler {
 	return &SkillsHandler{db: db, logger: logger}
 }
 
-// SkillLoadRequest is the body for POST /v1/skills/load.
+// SkillLoadRequest is the body for POST /v1/skills/load. GroupID is required when Scope is group.
 type SkillLoadRequest struct {
 	Content string `json:"content"`
 	Name    string `json:"name,omitempty"`
 	Scope   string `json:"scope,omitempty"`
+	GroupID string `json:"group_id,omitempty"`
 }
 
-// SkillUpdateRequest is the body for PUT /v1/skills/{id}.
+// SkillUpdateRequest is the body for PUT /v1/skills/{id}. GroupID is required when Scope changes to group.
 type SkillUpdateRequest struct {
 	Content *string `json:"content,omitempty"`
 	Name    *string `json:"name,omitempty"`
 	Scope   *string `json:"scope,omitempty"`
+	GroupID *string `json:"group_id,omitempty"`
 }
 
 // SkillRejectResponse is returned on audit failure (REQ-SKILLS-0113).
@@ -58,9 +64,54 @@ func skillToMeta(s *models.Skill) map[string]interface{} {
 	if s.OwnerID != nil {
 		m["owner_id"] = s.OwnerID.String()
 	}
+	if s.GroupID != nil {
+		m["group_id"] = s.GroupID.String()
+	}
 	return m
 }
 
+// skillVisible reports whether the caller may read s (own, system, or shared with one of the caller's groups).
+func skillVisible(ctx context.Context, s *models.Skill, userID uuid.UUID) bool {
+	var groupIDs []uuid.UUID
+	if a := rbac.FromContext(ctx); a != nil {
+		groupIDs = a.GroupIDs
+	}
+	return database.SkillVisibleTo(s, userID, groupIDs)
+}
+
+// skillWritable reports whether the caller may change or delete a visible skill: group members may only read a
+// group skill they do not own unless they hold groups.manage.
+func skillWritable(ctx context.Context, s *models.Skill, userID uuid.UUID) bool {
+	return s.IsSystem || (s.OwnerID != nil && *s.OwnerID == userID) || rbac.FromContext(ctx).Has(rbac.PermGroupsManage)
+}
+
+// skillGroup validates the group for a group-scoped skill: groupID must name a group the caller belongs to (or
+// the caller holds groups.manage). Returns nil for other scopes; writes 400/403 and returns ok=false on failure.
+func skillGroup(ctx context.Context, w http.ResponseWriter, scope string, groupID *string) (id *uuid.UUID, ok bool) {
+	if scope != skillScopeGroup {
+		if groupID != nil && *groupID != "" {
+			WriteBadRequest(w, "group_id is only valid with scope group")
+			return nil, false
+		}
+		return nil, true
+	}
+	if groupID == nil || *groupID == "" {
+		WriteBadRequest(w, "group_id required for scope group")
+		return nil, false
+	}
+	parsed, err := uuid.Parse(*groupID)
+	if err != nil {
+		WriteBadRequest(w, "invalid group_id")
+		return nil, false
+	}
+	a := rbac.FromContext(ctx)
+	if !a.InGroup(parsed) && !a.Has(rbac.PermGroupsManage) {
+		WriteForbidden(w, "Group-scoped skills require membership in the group")
+		return nil, false
+	}
+	return &parsed, true
+}
+
 // List handles GET /v1/skills. Optional query: scope, owner.
 func (h *SkillsHandler) List(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -112,8 +163,8 @@ func (h *SkillsHandler) Get(w http.ResponseWriter, r *http.Request) {
 		WriteInternalError(w, "Failed to get skill")
 		return
 	}
-	// Only return if user owns it or it is system default.
-	if !skill.IsSystem && (skill.OwnerID == nil || *skill.OwnerID != *userID) {
+	// Only return if user owns it, it is shared with one of the user's groups, or it is system default.
+	if !skillVisible(ctx, skill, *userID) {
 		WriteNotFound(w, "skill not found")
 		return
 	}
@@ -153,11 +204,18 @@ func (h *SkillsHandler) Load(w http.ResponseWriter, r *http.Request) {
 	if scope == "" {
 		scope = skillScopeUser
 	}
+	groupID, ok := skillGroup(ctx, w, scope, &req.GroupID)
+	if !ok {
+		return
+	}
 	name := req.Name
 	if name == "" {
 		name = "Untitled skill"
 	}
 	skill, err := h.db.CreateSkill(ctx, name, req.Content, scope, userID, false)
+	if err == nil && groupID != nil {
+		skill, err = h.db.SetSkillGroup(ctx, skill.ID, groupID)
+	}
 	if err != nil {
 		h.logger.Error("create skill", "error", err)
 		WriteInternalError(w, "Failed to create skill")
@@ -182,7 +240,17 @@ func (h *SkillsHandler) Update(w http.ResponseWriter, r *http.Request) {
 	if !ok {
 		return
 	}
-	_ = existing
+	var groupID *uuid.UUID
+	changeGroup := req.Scope != nil || req.GroupID != nil
+	if changeGroup {
+		scope := existing.Scope
+		if req.Scope != nil {
+			scope = *req.Scope
+		}
+		if groupID, ok = skillGroup(ctx, w, scope, req.GroupID); !ok {
+			return
+		}
+	}
 	if req.Content != nil {
 		if m := skillscan.ScanContent(*req.Content); m != nil {
 			writeSkillReject(w, m)
@@ -190,6 +258,9 @@ func (h *SkillsHandler) Update(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	skill, err := h.db.UpdateSkill(ctx, id, req.Name, req.Content, req.Scope)
+	if err == nil && changeGroup {
+		skill, err = h.db.SetSkillGroup(ctx, id, groupID)
+	}
 	if err != nil {
 		if err == database.ErrNotFound {
 			WriteNotFound(w, "skill not found")
@@ -231,10 +302,14 @@ func (h *SkillsHandler) getSkillForWrite(ctx context.Context, w http.ResponseWri
 		WriteInternalError(w, "Failed to get skill")
 		return nil, false
 	}
-	if !existing.IsSystem && (existing.OwnerID == nil || *existing.OwnerID != *userID) {
+	if !skillVisible(ctx, existing, *userID) {
 		WriteNotFound(w, "skill not found")
 		return nil, false
 	}
+	if !skillWritable(ctx, existing, *userID) {
+		WriteForbidden(w, "Only the owner may change this skill")
+		return nil, false
+	}
 	return existing, true
 }
 
@@ -276,10 +351,14 @@ func (h *SkillsHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		WriteInternalError(w, "Failed to get skill")
 		return
 	}
-	if !existing.IsSystem && (existing.OwnerID == nil || *existing.OwnerID != *userID) {
+	if !skillVisible(ctx, existing, *userID) {
 		WriteNotFound(w, "skill not found")
 		return
 	}
+	if !skillWritable(ctx, existing, *userID) {
+		WriteForbidden(w, "Only the owner may delete this skill")
+		return
+	}
 	if err := h.db.DeleteSkill(ctx, id); err != nil {
 		if err == database.ErrNotFound {
 			WriteNotFound(w, "skill not found")
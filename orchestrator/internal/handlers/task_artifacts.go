@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/problem"
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/artifacts"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+// SetArtifactStore enables artifact content upload and download; maxBytes bounds one upload (0 means no limit).
+// Without a store, listing still works and content requests return 503.
+func (h *TaskHandler) SetArtifactStore(store artifacts.Store, maxBytes int64) {
+	h.artifactStore = store
+	h.artifactMaxBytes = maxBytes
+}
+
+// ListTaskArtifacts handles GET /v1/tasks/{id}/artifacts.
+func (h *TaskHandler) ListTaskArtifacts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	task := h.resolveTask(ctx, w, getUserIDFromContext(ctx), r.PathValue("id"))
+	if task == nil {
+		return
+	}
+	list, err := h.db.ListTaskArtifacts(ctx, task.ID)
+	if err != nil {
+		h.logger.Error("list task artifacts", "error", err, "task_id", task.ID)
+		WriteInternalError(w, "Failed to list artifacts")
+		return
+	}
+	resp := userapi.ListArtifactsResponse{TaskID: task.ID.String(), Artifacts: make([]userapi.ArtifactResponse, 0, len(list))}
+	for _, art := range list {
+		resp.Artifacts = append(resp.Artifacts, artifactToResponse(art, h.artifactStore))
+	}
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+// GetTaskArtifact handles GET /v1/tasks/{id}/artifacts/{path...}. The content is verified against the recorded
+// checksum before it is sent, and the checksum is returned in X-Checksum-SHA256.
+func (h *TaskHandler) GetTaskArtifact(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	task := h.resolveTask(ctx, w, getUserIDFromContext(ctx), r.PathValue("id"))
+	if task == nil {
+		return
+	}
+	if !h.requireArtifactStore(w) {
+		return
+	}
+	p, err := artifacts.CleanPath(r.PathValue("path"))
+	if err != nil {
+		WriteBadRequest(w, "Invalid artifact path")
+		return
+	}
+	art, err := h.db.GetArtifactByTaskIDAndPath(ctx, task.ID, p)
+	if errors.Is(err, database.ErrNotFound) {
+		WriteNotFound(w, "Artifact not found")
+		return
+	}
+	if err != nil {
+		h.logger.Error("get task artifact", "error", err, "task_id", task.ID)
+		WriteInternalError(w, "Failed to get artifact")
+		return
+	}
+	f, err := artifacts.Open(ctx, h.artifactStore, art)
+	switch {
+	case errors.Is(err, artifacts.ErrNoContent):
+		WriteNotFound(w, "Artifact has no stored content")
+		return
+	case errors.Is(err, artifacts.ErrChecksumMismatch):
+		h.logger.Error("task artifact failed checksum verification", "task_id", task.ID, "path", p)
+		WriteInternalError(w, "Artifact content failed checksum verification")
+		return
+	case err != nil:
+		h.logger.Error("open task artifact", "error", err, "task_id", task.ID, "path", p)
+		WriteInternalError(w, "Failed to read artifact")
+		return
+	}
+	defer func() { _ = f.Close() }()
+	if art.ContentType != nil && *art.ContentType != "" {
+		w.Header().Set("Content-Type", *art.ContentType)
+	} else {
+		w.Header().Set("Content-Type", "application/octet-stream")
+	}
+	if art.ChecksumSHA256 != nil {
+		w.Header().Set(userapi.HeaderChecksumSHA256, *art.ChecksumSHA256)
+	}
+	http.ServeContent(w, r, "", art.UpdatedAt, f)
+}
+
+// PutTaskArtifact handles PUT /v1/tasks/{id}/artifacts/{path...}. The raw body is the content; an optional
+// X-Checksum-SHA256 header is verified before anything is stored. Returns 201 for a new artifact, 200 on replace.
+func (h *TaskHandler) PutTaskArtifact(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	task := h.resolveTask(ctx, w, getUserIDFromContext(ctx), r.PathValue("id"))
+	if task == nil {
+		return
+	}
+	if !h.requireArtifactStore(w) {
+		return
+	}
+	saveArtifact(w, r, h.db, h.artifactStore, h.artifactMaxBytes, h.logger, task.ID)
+}
+
+func (h *TaskHandler) requireArtifactStore(w http.ResponseWriter) bool {
+	if h.artifactStore == nil {
+		WriteError(w, http.StatusServiceUnavailable, problem.TypeInternal, "Service Unavailable", "Artifact storage is not configured")
+		return false
+	}
+	return true
+}
+
+// JobArtifactHandler accepts job output uploads from worker nodes (PUT /v1/jobs/{id}/artifacts/{path...}).
+// Like heartbeats, the worker authenticates with the Worker API bearer token of the node the job is assigned to.
+type JobArtifactHandler struct {
+	db       database.Store
+	store    artifacts.Store
+	maxBytes int64
+	logger   *slog.Logger
+}
+
+// NewJobArtifactHandler creates a job artifact handler; maxBytes bounds one upload (0 means no limit).
+func NewJobArtifactHandler(db database.Store, store artifacts.Store, maxBytes int64, logger *slog.Logger) *JobArtifactHandler {
+	return &JobArtifactHandler{db: db, store: store, maxBytes: maxBytes, logger: logger}
+}
+
+// PutJobArtifact handles PUT /v1/jobs/{id}/artifacts/{path...}; the artifact is stored under the job's task.
+func (h *JobArtifactHandler) PutJobArtifact(w http.ResponseWriter, r *http.Request) {
+	jobID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		WriteBadRequest(w, "invalid job id")
+		return
+	}
+	job, err := h.db.GetJobByID(r.Context(), jobID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			WriteNotFound(w, "job not found")
+			return
+		}
+		h.logger.Error("get job failed", "error", err, "job_id", jobID)
+		WriteInternalError(w, "failed to get job")
+		return
+	}
+	if !authorizedForJobNode(r, h.db, job.NodeID) {
+		WriteUnauthorized(w, "invalid worker token for job node")
+		return
+	}
+	saveArtifact(w, r, h.db, h.store, h.maxBytes, h.logger, job.TaskID)
+}
+
+// saveArtifact stores the request body as the artifact at r.PathValue("path") of taskID and writes the response.
+func saveArtifact(w http.ResponseWriter, r *http.Request, db database.Store, store artifacts.Store, maxBytes int64,
+	logger *slog.Logger, taskID uuid.UUID) {
+	p, err := artifacts.CleanPath(r.PathValue("path"))
+	if err != nil {
+		WriteBadRequest(w, "Invalid artifact path")
+		return
+	}
+	if maxBytes > 0 && r.ContentLength > maxBytes {
+		WritePayloadTooLarge(w, "Artifact exceeds the maximum size")
+		return
+	}
+	art, created, err := artifacts.Save(r.Context(), db, store, &artifacts.Upload{
+		TaskID:         taskID,
+		Path:           p,
+		ContentType:    r.Header.Get("Content-Type"),
+		Body:           r.Body,
+		MaxBytes:       maxBytes,
+		ChecksumSHA256: r.Header.Get(userapi.HeaderChecksumSHA256),
+	})
+	switch {
+	case errors.Is(err, artifacts.ErrTooLarge):
+		WritePayloadTooLarge(w, "Artifact exceeds the maximum size")
+		return
+	case errors.Is(err, artifacts.ErrChecksumMismatch):
+		WriteBadRequest(w, "Content does not match "+userapi.HeaderChecksumSHA256)
+		return
+	case err != nil:
+		logger.Error("save task artifact", "error", err, "task_id", taskID, "path", p)
+		WriteInternalError(w, "Failed to store artifact")
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	WriteJSON(w, status, artifactToResponse(art, store))
+}
+
+func artifactToResponse(art *models.TaskArtifact, store artifacts.Store) userapi.ArtifactResponse {
+	return userapi.ArtifactResponse{
+		Path:           art.Path,
+		SizeBytes:      art.SizeBytes,
+		ContentType:    art.ContentType,
+		ChecksumSHA256: art.ChecksumSHA256,
+		HasContent:     store != nil && artifacts.HasContent(store, art),
+		CreatedAt:      art.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:      art.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/artifacts"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
+)
+
+func setupArtifactTask(t *testing.T) (*testutil.MockDB, *TaskHandler, *models.Task, uuid.UUID) {
+	t.Helper()
+	mock := testutil.NewMockDB()
+	userID := uuid.New()
+	task := &models.Task{ID: uuid.New(), CreatedBy: &userID, Status: models.TaskStatusCompleted}
+	mock.AddTask(task)
+	store, err := artifacts.NewFSStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewTaskHandler(mock, newTestLogger(), "", "")
+	h.SetArtifactStore(store, 16)
+	return mock, h, task, userID
+}
+
+func artifactRequest(method, taskID, path, body string, userID uuid.UUID) (*http.Request, *httptest.ResponseRecorder) {
+	req, rec := requestWithUserContext(method, "/v1/tasks/"+taskID+"/artifacts/"+path, []byte(body), userID)
+	req.SetPathValue("id", taskID)
+	req.SetPathValue("path", path)
+	return req, rec
+}
+
+func TestTaskHandler_PutAndGetArtifact(t *testing.T) {
+	_, h, task, userID := setupArtifactTask(t)
+	id := task.ID.String()
+
+	req, rec := artifactRequest(http.MethodPut, id, "out/report.md", "# hi", userID)
+	sum := sha256.Sum256([]byte("# hi"))
+	req.Header.Set(userapi.HeaderChecksumSHA256, hex.EncodeToString(sum[:]))
+	req.Header.Set("Content-Type", "text/markdown")
+	h.PutTaskArtifact(rec, req)
+	assertStatusCode(t, rec, http.StatusCreated)
+	var created userapi.ArtifactResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &created)
+	if created.Path != "out/report.md" || !created.HasContent || *created.SizeBytes != 4 {
+		t.Errorf("created = %+v", created)
+	}
+
+	req, rec = artifactRequest(http.MethodPut, id, "out/report.md", "# bye", userID)
+	h.PutTaskArtifact(rec, req)
+	assertStatusCode(t, rec, http.StatusOK)
+
+	req, rec = artifactRequest(http.MethodGet, id, "out/report.md", "", userID)
+	h.GetTaskArtifact(rec, req)
+	assertStatusCode(t, rec, http.StatusOK)
+	sum = sha256.Sum256([]byte("# bye"))
+	if rec.Body.String() != "# bye" || rec.Header().Get(userapi.HeaderChecksumSHA256) != hex.EncodeToString(sum[:]) {
+		t.Errorf("body = %q checksum = %q", rec.Body.String(), rec.Header().Get(userapi.HeaderChecksumSHA256))
+	}
+
+	req, rec = requestWithUserContext(http.MethodGet, "/v1/tasks/"+id+"/artifacts", nil, userID)
+	req.SetPathValue("id", id)
+	h.ListTaskArtifacts(rec, req)
+	assertStatusCode(t, rec, http.StatusOK)
+	var list userapi.ListArtifactsResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &list)
+	if len(list.Artifacts) != 1 || list.Artifacts[0].ContentType != nil {
+		t.Errorf("list = %+v", list)
+	}
+}
+
+func TestTaskHandler_ArtifactErrors(t *testing.T) {
+	mock, h, task, userID := setupArtifactTask(t)
+	id := task.ID.String()
+	_, _ = mock.CreateTaskArtifact(t.Context(), task.ID, "attached.txt", "", nil)
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		hdr    string
+		user   uuid.UUID
+		want   int
+	}{
+		{"checksum mismatch", http.MethodPut, "a.txt", "data", "deadbeef", userID, http.StatusBadRequest},
+		{"too large", http.MethodPut, "a.txt", "0123456789abcdefXX", "", userID, http.StatusRequestEntityTooLarge},
+		{"bad path", http.MethodPut, "../a.txt", "data", "", userID, http.StatusBadRequest},
+		{"not owner", http.MethodPut, "a.txt", "data", "", uuid.New(), http.StatusForbidden},
+		{"missing", http.MethodGet, "nope.txt", "", "", userID, http.StatusNotFound},
+		{"path-only ref", http.MethodGet, "attached.txt", "", "", userID, http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		req, rec := artifactRequest(tc.method, id, tc.path, tc.body, tc.user)
+		if tc.hdr != "" {
+			req.Header.Set(userapi.HeaderChecksumSHA256, tc.hdr)
+		}
+		if tc.method == http.MethodPut {
+			h.PutTaskArtifact(rec, req)
+		} else {
+			h.GetTaskArtifact(rec, req)
+		}
+		if rec.Code != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, rec.Code, tc.want)
+		}
+	}
+
+	h.SetArtifactStore(nil, 0)
+	req, rec := artifactRequest(http.MethodGet, id, "a.txt", "", userID)
+	h.GetTaskArtifact(rec, req)
+	assertStatusCode(t, rec, http.StatusServiceUnavailable)
+}
+
+func TestJobArtifactHandler_PutJobArtifact(t *testing.T) {
+	mock, job, _ := setupLeasedJob(t)
+	store, _ := artifacts.NewFSStore(t.TempDir())
+	h := NewJobArtifactHandler(mock, store, 0, slog.Default())
+	put := func(jobID, token string) int {
+		req, rec := recordedRequest(http.MethodPut, "/v1/jobs/"+jobID+"/artifacts/out.txt", []byte("result"))
+		req.SetPathValue("id", jobID)
+		req.SetPathValue("path", "out.txt")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		h.PutJobArtifact(rec, req)
+		return rec.Code
+	}
+	if code := put(job.ID.String(), "wrong"); code != http.StatusUnauthorized {
+		t.Errorf("wrong token: %d", code)
+	}
+	if code := put(uuid.New().String(), "worker-token"); code != http.StatusNotFound {
+		t.Errorf("unknown job: %d", code)
+	}
+	if code := put("nope", "worker-token"); code != http.StatusBadRequest {
+		t.Errorf("bad job id: %d", code)
+	}
+	if code := put(job.ID.String(), "worker-token"); code != http.StatusCreated {
+		t.Fatalf("upload: %d", code)
+	}
+	art, err := mock.GetArtifactByTaskIDAndPath(t.Context(), job.TaskID, "out.txt")
+	if err != nil || art.StorageRef != "fs:tasks/"+job.TaskID.String()+"/out.txt" {
+		t.Errorf("artifact = %+v, %v", art, err)
+	}
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+// Log follow timing: the gateway polls the task and its nodes' telemetry every logFollowInterval and
+// sends an SSE comment at least every logFollowKeepAlive so idle connections are not dropped by proxies.
+const (
+	defaultLogFollowInterval = time.Second
+	logFollowKeepAlive       = 15 * time.Second
+)
+
+// jobAttemptKey identifies one attempt of a job. A retried job runs again under the same id, possibly on the
+// same node, so each attempt is followed from the start of its own output.
+type jobAttemptKey struct {
+	jobID   uuid.UUID
+	attempt int
+}
+
+// jobLogCursor tracks what a follow stream has already sent for one job attempt.
+type jobLogCursor struct {
+	offset   int  // telemetry events consumed (page_token for the next poll)
+	finished bool // output for the job's terminal status has been sent
+}
+
+// taskLogFollower writes GET /v1/tasks/{id}/logs?follow=true as server-sent events.
+type taskLogFollower struct {
+	h       *TaskHandler
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	stream  string
+	cursors map[jobAttemptKey]*jobLogCursor
+	nodes   map[uuid.UUID]*models.Node
+	lastOut time.Time
+}
+
+// followTaskLogs streams the task's job output until the task is terminal or the client disconnects.
+// Running jobs are read from their node's telemetry log API; jobs that finished without being followed
+// (or whose node is unreachable) are replayed from the stored job result.
+func (h *TaskHandler) followTaskLogs(w http.ResponseWriter, r *http.Request, task *models.Task, stream string) {
+	f := &taskLogFollower{
+		h:       h,
+		w:       w,
+		rc:      http.NewResponseController(w),
+		stream:  stream,
+		cursors: map[jobAttemptKey]*jobLogCursor{},
+		nodes:   map[uuid.UUID]*models.Node{},
+	}
+	// A follow lasts as long as the task runs, not the gateway WRITE_TIMEOUT.
+	_ = f.rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	f.comment("following task " + task.ID.String())
+
+	ctx := r.Context()
+	interval := h.logFollowInterval
+	if interval <= 0 {
+		interval = defaultLogFollowInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		current, err := h.db.GetTaskByID(ctx, task.ID)
+		if err != nil {
+			if ctx.Err() == nil {
+				h.logger.Error("follow logs: get task", "task_id", task.ID, "error", err)
+			}
+			return
+		}
+		jobs, err := h.db.GetJobsByTaskID(ctx, task.ID)
+		if err != nil {
+			if ctx.Err() == nil {
+				h.logger.Error("follow logs: get jobs", "task_id", task.ID, "error", err)
+			}
+			return
+		}
+		for _, job := range jobs {
+			f.followJob(ctx, job)
+		}
+		if isTaskTerminal(current.Status) {
+			b, _ := json.Marshal(userapi.TaskLogEnd{TaskID: task.ID.String(), Status: taskStatusToSpec(current.Status)})
+			f.event(userapi.TaskLogEventEnd, b)
+			return
+		}
+		if time.Since(f.lastOut) >= logFollowKeepAlive {
+			f.comment("keep-alive")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (f *taskLogFollower) followJob(ctx context.Context, job *models.Job) {
+	key := jobAttemptKey{jobID: job.ID, attempt: job.Attempts}
+	c := f.cursors[key]
+	if c == nil {
+		c = &jobLogCursor{}
+		f.cursors[key] = c
+	}
+	switch job.Status {
+	case models.JobStatusQueued:
+		return
+	case models.JobStatusRunning:
+		c.finished = false
+		f.pollTelemetry(ctx, job, c)
+		return
+	}
+	if c.finished {
+		return
+	}
+	c.finished = true
+	// Drain what the node recorded after the last poll; without any telemetry, replay the result.
+	if c.offset > 0 && f.pollTelemetry(ctx, job, c) {
+		return
+	}
+	if c.offset == 0 {
+		f.replayResult(job)
+	}
+}
+
+// pollTelemetry sends the job's telemetry log events past the cursor. Returns false when the node
+// could not be queried.
+func (f *taskLogFollower) pollTelemetry(ctx context.Context, job *models.Job, c *jobLogCursor) bool {
+	node := f.node(ctx, job)
+	if node == nil {
+		return false
+	}
+	for {
+		page, err := f.h.telemetry.QueryJobLogs(ctx, *node.WorkerAPITargetURL, *node.WorkerAPIBearerToken, job.ID.String(), strconv.Itoa(c.offset))
+		if err != nil {
+			if ctx.Err() == nil {
+				f.h.logger.Debug("follow logs: query worker telemetry", "job_id", job.ID, "node", node.NodeSlug, "error", err)
+			}
+			return false
+		}
+		for _, e := range page.Events {
+			f.line(job.ID, e.Stream, e.Message, e.OccurredAt)
+		}
+		c.offset += len(page.Events)
+		if page.NextPageToken == "" || len(page.Events) == 0 {
+			return true
+		}
+	}
+}
+
+// node returns the job's node when it has a Worker API URL and token; cached for the stream.
+func (f *taskLogFollower) node(ctx context.Context, job *models.Job) *models.Node {
+	if job.NodeID == nil {
+		return nil
+	}
+	if n, ok := f.nodes[*job.NodeID]; ok {
+		return n
+	}
+	n, err := f.h.db.GetNodeByID(ctx, *job.NodeID)
+	if err != nil || n.WorkerAPITargetURL == nil || n.WorkerAPIBearerToken == nil {
+		n = nil
+	}
+	f.nodes[*job.NodeID] = n
+	return n
+}
+
+// replayResult sends the stdout and stderr stored in the job result, line by line.
+func (f *taskLogFollower) replayResult(job *models.Job) {
+	if job.Result.Ptr() == nil {
+		return
+	}
+	var res workerapi.RunJobResponse
+	if json.Unmarshal([]byte(*job.Result.Ptr()), &res) != nil {
+		return
+	}
+	for _, out := range []struct{ stream, text string }{{"stdout", res.Stdout}, {"stderr", res.Stderr}} {
+		if out.text == "" {
+			continue
+		}
+		for _, l := range strings.Split(strings.TrimSuffix(out.text, "\n"), "\n") {
+			f.line(job.ID, out.stream, strings.TrimSuffix(l, "\r"), res.EndedAt)
+		}
+	}
+}
+
+func (f *taskLogFollower) line(jobID uuid.UUID, stream, text, occurredAt string) {
+	if f.stream != streamParamAll && f.stream != stream {
+		return
+	}
+	b, _ := json.Marshal(userapi.TaskLogEvent{JobID: jobID.String(), Stream: stream, Line: text, OccurredAt: occurredAt})
+	f.event(userapi.TaskLogEventLog, b)
+}
+
+func (f *taskLogFollower) event(name string, data []byte) {
+	_, _ = fmt.Fprintf(f.w, "event: %s\ndata: %s\n\n", name, data)
+	_ = f.rc.Flush()
+	f.lastOut = time.Now()
+}
+
+func (f *taskLogFollower) comment(text string) {
+	_, _ = fmt.Fprintf(f.w, ": %s\n\n", text)
+	_ = f.rc.Flush()
+	f.lastOut = time.Now()
+}
+
+func isTaskTerminal(status string) bool {
+	switch status {
+	case models.TaskStatusCompleted, models.TaskStatusFailed, models.TaskStatusCanceled, models.TaskStatusSuperseded:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
+)
+
+type followedLogs struct {
+	lines []userapi.TaskLogEvent
+	end   *userapi.TaskLogEnd
+}
+
+func parseTaskLogStream(t *testing.T, body string) followedLogs {
+	t.Helper()
+	var out followedLogs
+	for _, block := range strings.Split(body, "\n\n") {
+		var name, data string
+		for _, line := range strings.Split(block, "\n") {
+			if v, ok := strings.CutPrefix(line, "event: "); ok {
+				name = v
+			} else if v, ok := strings.CutPrefix(line, "data: "); ok {
+				data = v
+			}
+		}
+		switch name {
+		case userapi.TaskLogEventLog:
+			var e userapi.TaskLogEvent
+			if err := json.Unmarshal([]byte(data), &e); err != nil {
+				t.Fatalf("decode log event %q: %v", data, err)
+			}
+			out.lines = append(out.lines, e)
+		case userapi.TaskLogEventEnd:
+			out.end = &userapi.TaskLogEnd{}
+			if err := json.Unmarshal([]byte(data), out.end); err != nil {
+				t.Fatalf("decode end event %q: %v", data, err)
+			}
+		}
+	}
+	return out
+}
+
+func followRequest(taskID, userID uuid.UUID, query string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/v1/tasks/"+taskID.String()+"/logs?"+query, http.NoBody).
+		WithContext(context.WithValue(context.Background(), contextKeyUserID, userID))
+	req.SetPathValue("id", taskID.String())
+	return req
+}
+
+func TestTaskHandler_GetTaskLogsFollowRunningJob(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	userID := uuid.New()
+	task := &models.Task{ID: uuid.New(), CreatedBy: &userID, Status: models.TaskStatusRunning}
+	mockDB.AddTask(task)
+	nodeID := uuid.New()
+	job := &models.Job{ID: uuid.New(), TaskID: task.ID, NodeID: &nodeID, Status: models.JobStatusRunning}
+	mockDB.AddJob(job)
+
+	worker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.Header.Get("Authorization") != "Bearer node-token" || q.Get("source_name") != "job-"+job.ID.String() {
+			t.Errorf("unexpected telemetry request: %s", r.URL)
+		}
+		switch q.Get("page_token") {
+		case "0":
+			_, _ = w.Write([]byte(`{"version":1,"events":[{"stream":"stdout","message":"step 1"},{"stream":"stderr","message":"warn"}]}`))
+		case "2":
+			// The job finishes after its last line; the follower drains once more and ends the stream.
+			_, _ = w.Write([]byte(`{"version":1,"events":[{"stream":"stdout","message":"step 2"}]}`))
+			_ = mockDB.CompleteJob(context.Background(), job.ID, `{"stdout":"step 1\nstep 2\n"}`, models.JobStatusCompleted)
+			_ = mockDB.UpdateTaskStatus(context.Background(), task.ID, models.TaskStatusCompleted)
+		default:
+			_, _ = w.Write([]byte(`{"version":1,"events":[]}`))
+		}
+	}))
+	defer worker.Close()
+	workerURL, token := worker.URL, "node-token"
+	mockDB.AddNode(&models.Node{ID: nodeID, NodeSlug: "n1", WorkerAPITargetURL: &workerURL, WorkerAPIBearerToken: &token})
+
+	h := NewTaskHandler(mockDB, newTestLogger(), "", "")
+	h.logFollowInterval = 10 * time.Millisecond
+	rec := httptest.NewRecorder()
+	h.GetTaskLogs(rec, followRequest(task.ID, userID, "follow=true"))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status %d content-type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	got := parseTaskLogStream(t, rec.Body.String())
+	if len(got.lines) != 3 || got.lines[0].Line != "step 1" || got.lines[1].Stream != "stderr" || got.lines[2].Line != "step 2" {
+		t.Errorf("lines = %+v", got.lines)
+	}
+	if got.lines[0].JobID != job.ID.String() {
+		t.Errorf("job id = %q", got.lines[0].JobID)
+	}
+	if got.end == nil || got.end.Status != userapi.StatusCompleted || got.end.TaskID != task.ID.String() {
+		t.Errorf("end = %+v", got.end)
+	}
+}
+
+// TestTaskHandler_GetTaskLogsFollowRetriedJob verifies a retried job's next attempt is followed from the start
+// of its own output, not from the offset the previous attempt reached on another node.
+func TestTaskHandler_GetTaskLogsFollowRetriedJob(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	ctx := context.Background()
+	userID := uuid.New()
+	task := &models.Task{ID: uuid.New(), CreatedBy: &userID, Status: models.TaskStatusRunning}
+	mockDB.AddTask(task)
+	first, second := uuid.New(), uuid.New()
+	lease := uuid.New()
+	job := &models.Job{ID: uuid.New(), TaskID: task.ID, NodeID: &first, Status: models.JobStatusRunning, LeaseID: &lease, Attempts: 1}
+	mockDB.AddJob(job)
+
+	nodeServer := func(handle func(pageToken string) string) string {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(handle(r.URL.Query().Get("page_token"))))
+		}))
+		t.Cleanup(srv.Close)
+		return srv.URL
+	}
+	firstURL := nodeServer(func(pageToken string) string {
+		if pageToken == "0" {
+			return `{"version":1,"events":[{"stream":"stdout","message":"a1"},{"stream":"stdout","message":"a2"}]}`
+		}
+		// The attempt fails; the job is requeued and claimed again onto the second node.
+		_ = mockDB.RequeueJobForRetry(ctx, job.ID, lease, "{}", time.Now())
+		retryLease := uuid.New()
+		_, _ = mockDB.ClaimNextQueuedJob(ctx, retryLease, time.Now().Add(time.Minute))
+		_ = mockDB.AssignJobToNode(ctx, job.ID, retryLease, second, "retry")
+		return `{"version":1,"events":[]}`
+	})
+	secondURL := nodeServer(func(pageToken string) string {
+		_ = mockDB.CompleteJob(ctx, job.ID, `{}`, models.JobStatusCompleted)
+		_ = mockDB.UpdateTaskStatus(ctx, task.ID, models.TaskStatusCompleted)
+		if pageToken == "0" {
+			return `{"version":1,"events":[{"stream":"stdout","message":"b1"}]}`
+		}
+		return `{"version":1,"events":[]}`
+	})
+	token := "node-token"
+	mockDB.AddNode(&models.Node{ID: first, NodeSlug: "n1", WorkerAPITargetURL: &firstURL, WorkerAPIBearerToken: &token})
+	mockDB.AddNode(&models.Node{ID: second, NodeSlug: "n2", WorkerAPITargetURL: &secondURL, WorkerAPIBearerToken: &token})
+
+	h := NewTaskHandler(mockDB, newTestLogger(), "", "")
+	h.logFollowInterval = 10 * time.Millisecond
+	rec := httptest.NewRecorder()
+	h.GetTaskLogs(rec, followRequest(task.ID, userID, "follow=true"))
+	got := parseTaskLogStream(t, rec.Body.String())
+	var lines []string
+	for _, l := range got.lines {
+		lines = append(lines, l.Line)
+	}
+	if strings.Join(lines, ",") != "a1,a2,b1" {
+		t.Errorf("lines = %v, want a1,a2,b1", lines)
+	}
+}
+
+func TestTaskHandler_GetTaskLogsFollowReplaysFinishedJobs(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	userID := uuid.New()
+	task := &models.Task{ID: uuid.New(), CreatedBy: &userID, Status: models.TaskStatusFailed}
+	mockDB.AddTask(task)
+	result := `{"stdout":"out\n","stderr":"e1\r\ne2\n","ended_at":"2026-01-01T00:00:00Z"}`
+	mockDB.AddJob(&models.Job{ID: uuid.New(), TaskID: task.ID, Status: models.JobStatusFailed, Result: models.NewJSONBString(&result)})
+
+	h := NewTaskHandler(mockDB, newTestLogger(), "", "")
+	rec := httptest.NewRecorder()
+	h.GetTaskLogs(rec, followRequest(task.ID, userID, "follow=1&stream=stderr"))
+	got := parseTaskLogStream(t, rec.Body.String())
+	if len(got.lines) != 2 || got.lines[0].Line != "e1" || got.lines[1].Line != "e2" || got.lines[0].OccurredAt != "2026-01-01T00:00:00Z" {
+		t.Errorf("lines = %+v", got.lines)
+	}
+	if got.end == nil || got.end.Status != userapi.StatusFailed {
+		t.Errorf("end = %+v", got.end)
+	}
+
+	rec = httptest.NewRecorder()
+	h.GetTaskLogs(rec, followRequest(task.ID, userID, "follow=maybe"))
+	assertStatusCode(t, rec, http.StatusBadRequest)
+}
+
+func TestTaskHandler_GetTaskLogsFollowClientDisconnect(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	userID := uuid.New()
+	task := &models.Task{ID: uuid.New(), CreatedBy: &userID, Status: models.TaskStatusPending}
+	mockDB.AddTask(task)
+	mockDB.AddJob(&models.Job{ID: uuid.New(), TaskID: task.ID, Status: models.JobStatusQueued})
+
+	h := NewTaskHandler(mockDB, newTestLogger(), "", "")
+	h.logFollowInterval = 10 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), contextKeyUserID, userID), 50*time.Millisecond)
+	defer cancel()
+	req := followRequest(task.ID, userID, "follow=true").WithContext(ctx)
+	rec := httptest.NewRecorder()
+	h.GetTaskLogs(rec, req)
+	got := parseTaskLogStream(t, rec.Body.String())
+	if len(got.lines) != 0 || got.end != nil || !strings.HasPrefix(rec.Body.String(), ": following task") {
+		t.Errorf("body = %q", rec.Body.String())
+	}
+}
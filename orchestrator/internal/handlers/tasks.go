@@ -16,10 +16,14 @@ import (
 	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/sbajob"
 	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
 	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/artifacts"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/dispatcher"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/inference"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/nodetelemetry"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/policy"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/usage"
 )
 
 // TaskHandler handles task endpoints.
@@ -28,8 +32,19 @@ type TaskHandler struct {
 	logger         *slog.Logger
 	inferenceURL   string
 	inferenceModel string
+	workerClient   *http.Client
+	telemetry      *nodetelemetry.Client
+	// logFollowInterval is how often a logs follow stream polls; zero means defaultLogFollowInterval.
+	logFollowInterval time.Duration
+	quotas            *usage.Enforcer
+	artifactStore     artifacts.Store
+	artifactMaxBytes  int64
+	policy            *policy.Engine
 }
 
+// workerCancelTimeout bounds the Worker API cancel call; the node waits up to 30s for the sandbox to stop.
+const workerCancelTimeout = 40 * time.Second
+
 // NewTaskHandler creates a new task handler. inferenceURL and inferenceModel are optional; when set, prompt-mode tasks call the model directly so prompt→model MUST work.
 func NewTaskHandler(db database.Store, logger *slog.Logger, inferenceURL, inferenceModel string) *TaskHandler {
 	if inferenceModel == "" {
@@ -40,7 +55,69 @@ func NewTaskHandler(db database.Store, logger *slog.Logger, inferenceURL, infere
 		logger:         logger,
 		inferenceURL:   inferenceURL,
 		inferenceModel: inferenceModel,
+		workerClient:   &http.Client{Timeout: workerCancelTimeout},
+		telemetry:      nodetelemetry.NewClient(),
+		policy:         policy.New(db),
+	}
+}
+
+// SetQuotaEnforcer enables quota checks on task creation and chat; nil (the default) disables them.
+func (h *TaskHandler) SetQuotaEnforcer(quotas *usage.Enforcer) {
+	h.quotas = quotas
+}
+
+// TaskTypeSBA is the task type recorded for use_sba tasks; other tasks record their input mode.
+const TaskTypeSBA = "sba"
+
+func taskType(req *userapi.CreateTaskRequest) string {
+	if req.UseSBA {
+		return TaskTypeSBA
+	}
+	return normalizeInputMode(req.InputMode)
+}
+
+// checkTaskPolicy writes a 403 (or 500) and returns false when access control denies task.create in the project.
+func (h *TaskHandler) checkTaskPolicy(ctx context.Context, w http.ResponseWriter, userID, projectID *uuid.UUID, req *userapi.CreateTaskRequest) bool {
+	preq := &policy.Request{
+		Action:       database.ActionTaskCreate,
+		ResourceType: database.ResourceTypeProject,
+		Context:      policy.Context{ProjectID: projectID, TaskType: taskType(req), RequestBytes: int64(len(req.Prompt))},
+	}
+	if projectID != nil {
+		preq.Resource = "projects/" + projectID.String()
+	}
+	if userID != nil {
+		principal, err := h.policy.PrincipalForUser(ctx, *userID)
+		if err != nil {
+			h.logger.Error("resolve policy principal", "error", err)
+			WriteInternalError(w, "Failed to evaluate policy")
+			return false
+		}
+		preq.Principal = principal
 	}
+	d, err := h.policy.Decide(ctx, preq)
+	if err != nil {
+		h.logger.Error("evaluate task policy", "error", err)
+		WriteInternalError(w, "Failed to evaluate policy")
+		return false
+	}
+	if !d.Allowed {
+		WriteForbidden(w, "Task creation "+d.Reason)
+		return false
+	}
+	return true
+}
+
+// checkTaskQuota writes a 429 (or 500) and returns false when the user may not create another task.
+func (h *TaskHandler) checkTaskQuota(ctx context.Context, w http.ResponseWriter, userID, projectID *uuid.UUID) bool {
+	if userID == nil {
+		return true
+	}
+	if err := h.quotas.CheckTask(ctx, *userID, projectID); err != nil {
+		writeQuotaCheckError(w, h.logger, err)
+		return false
+	}
+	return true
 }
 
 // InputModePrompt is the default: natural-language prompt goes to the PM model.
@@ -156,7 +233,7 @@ func (h *TaskHandler) tryCompleteWithOrchestratorInference(
 	if inputMode != InputModePrompt || strings.TrimSpace(h.inferenceURL) == "" {
 		return false
 	}
-	job, err := h.createTaskWithOrchestratorInference(ctx, task.ID, prompt)
+	job, err := h.createTaskWithOrchestratorInference(ctx, task, prompt)
 	if err == nil {
 		_ = job // job already completed
 		WriteJSON(w, http.StatusCreated, taskToResponse(task, taskStatusToSpec(models.TaskStatusCompleted), attachmentPaths))
@@ -171,12 +248,24 @@ func (h *TaskHandler) createSandboxJob(ctx context.Context, taskID uuid.UUID, pr
 	if err != nil {
 		return fmt.Errorf("marshal job payload: %w", err)
 	}
-	if _, err := h.db.CreateJob(ctx, taskID, payload); err != nil {
+	if _, err := h.quotas.CreateQueuedJob(ctx, h.db, taskID, uuid.New(), payload); err != nil {
 		return fmt.Errorf("create job: %w", err)
 	}
 	return nil
 }
 
+// writeJobCreateError writes the response for a failed job insert. When a concurrent request took the last
+// concurrent job slot after checkTaskQuota passed, the task is marked failed and 429 is written; otherwise 500.
+func (h *TaskHandler) writeJobCreateError(ctx context.Context, w http.ResponseWriter, taskID uuid.UUID, err error, detail string) {
+	if _, ok := quotaExceeded(err); ok {
+		_ = h.db.UpdateTaskStatus(ctx, taskID, models.TaskStatusFailed)
+		writeQuotaCheckError(w, h.logger, err)
+		return
+	}
+	h.logger.Error("create job", "task_id", taskID, "error", err)
+	WriteInternalError(w, detail)
+}
+
 // CreateTask handles POST /v1/tasks.
 func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -186,6 +275,10 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 	if !ok {
 		return
 	}
+	placement, ok := h.resolveTaskPlacement(ctx, w, userID, &req)
+	if !ok {
+		return
+	}
 
 	projectID, err := h.resolveTaskProjectID(ctx, userID, req.ProjectID)
 	if err != nil {
@@ -197,12 +290,23 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 		WriteInternalError(w, "Failed to create task")
 		return
 	}
-	task, err := h.db.CreateTask(ctx, userID, req.Prompt, req.TaskName, projectID)
-	if err != nil {
-		h.logger.Error("create task", "error", err)
+	if !h.checkTaskPolicy(ctx, w, userID, projectID, &req) {
+		return
+	}
+	if !h.checkTaskQuota(ctx, w, userID, projectID) {
+		return
+	}
+	task, ok := h.insertTask(ctx, w, userID, &req, projectID, placement)
+	if !ok {
+		return
+	}
+	tt := taskType(&req)
+	if err := h.db.UpdateTaskType(ctx, task.ID, tt); err != nil {
+		h.logger.Error("record task type", "task_id", task.ID, "error", err)
 		WriteInternalError(w, "Failed to create task")
 		return
 	}
+	task.TaskType = &tt
 
 	attachmentPaths := h.persistTaskAttachments(ctx, task.ID, req.Attachments)
 
@@ -215,7 +319,8 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Prompt mode: prefer orchestrator-side inference when configured; fall back to sandbox job path on error.
-	if h.tryCompleteWithOrchestratorInference(ctx, w, task, req.Prompt, attachmentPaths, inputMode) {
+	// Tasks with dependencies always take the job path so dispatch waits for the dependencies.
+	if !placement.hasDependencies() && h.tryCompleteWithOrchestratorInference(ctx, w, task, req.Prompt, attachmentPaths, inputMode) {
 		return
 	}
 
@@ -225,8 +330,7 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 		useInference = true
 	}
 	if err := h.createSandboxJob(ctx, task.ID, req.Prompt, useInference, inputMode); err != nil {
-		h.logger.Error("create sandbox job", "error", err)
-		WriteInternalError(w, "Failed to create task job")
+		h.writeJobCreateError(ctx, w, task.ID, err, "Failed to create task job")
 		return
 	}
 
@@ -234,13 +338,18 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 }
 
 // createTaskWithOrchestratorInference calls the PM model with the prompt and stores the result as a completed job.
-// Returns the job and nil on success; on failure returns nil, error (caller may fall back to sandbox path).
-func (h *TaskHandler) createTaskWithOrchestratorInference(ctx context.Context, taskID uuid.UUID, prompt string) (*models.Job, error) {
+// The model's token usage is recorded against the task. Returns the job and nil on success; on failure returns
+// nil, error (caller may fall back to sandbox path).
+func (h *TaskHandler) createTaskWithOrchestratorInference(ctx context.Context, task *models.Task, prompt string) (*models.Job, error) {
+	taskID := task.ID
+	jobID := uuid.New()
+	ctx = usage.WithRecorder(ctx, h.db, h.logger, usage.Attribution{
+		UserID: task.CreatedBy, ProjectID: task.ProjectID, TaskID: &taskID, JobID: &jobID, Source: models.TokenUsageSourceTask,
+	})
 	response, err := inference.CallGenerate(ctx, nil, h.inferenceURL, h.inferenceModel, prompt)
 	if err != nil {
 		return nil, err
 	}
-	jobID := uuid.New()
 	now := time.Now().UTC()
 	nowStr := now.Format(time.RFC3339)
 	result := workerapi.RunJobResponse{
@@ -307,9 +416,8 @@ func (h *TaskHandler) createTaskSBA(ctx context.Context, w http.ResponseWriter,
 		WriteInternalError(w, "Failed to create SBA job")
 		return true
 	}
-	if _, err := h.db.CreateJobWithID(ctx, task.ID, jobID, payload); err != nil {
-		h.logger.Error("create SBA job", "error", err)
-		WriteInternalError(w, "Failed to create task job")
+	if _, err := h.quotas.CreateQueuedJob(ctx, h.db, task.ID, jobID, payload); err != nil {
+		h.writeJobCreateError(ctx, w, task.ID, err, "Failed to create task job")
 		return true
 	}
 	WriteJSON(w, http.StatusCreated, taskToResponse(task, taskStatusToSpec(task.Status), attachmentPaths))
@@ -571,8 +679,16 @@ func (h *TaskHandler) CancelTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	for _, j := range jobs {
-		if j.Status != models.JobStatusCompleted && j.Status != models.JobStatusFailed && j.Status != models.JobStatusCanceled {
-			_ = h.db.UpdateJobStatus(ctx, j.ID, models.JobStatusCanceled)
+		if j.Status == models.JobStatusCompleted || j.Status == models.JobStatusFailed || j.Status == models.JobStatusCanceled {
+			continue
+		}
+		wasRunning := j.Status == models.JobStatusRunning
+		// Mark canceled before stopping the sandbox so the dispatcher treats the job's eventual result as late.
+		_ = h.db.UpdateJobStatus(ctx, j.ID, models.JobStatusCanceled)
+		if wasRunning {
+			if err := dispatcher.CancelRunningJob(ctx, h.db, h.workerClient, j); err != nil {
+				h.logger.Warn("cancel job on node failed", "error", err, "job_id", j.ID)
+			}
 		}
 	}
 	WriteJSON(w, http.StatusOK, userapi.CancelTaskResponse{TaskID: taskID.String(), Canceled: true})
@@ -598,7 +714,8 @@ func aggregateLogsFromJobs(jobs []*models.Job, stream string) (stdout, stderr st
 	return outStd.String(), outErr.String()
 }
 
-// GetTaskLogs handles GET /v1/tasks/{id}/logs.
+// GetTaskLogs handles GET /v1/tasks/{id}/logs. With follow=true the response is a server-sent event
+// stream of live job output that ends when the task is terminal (see followTaskLogs).
 func (h *TaskHandler) GetTaskLogs(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	userID := getUserIDFromContext(ctx)
@@ -610,6 +727,17 @@ func (h *TaskHandler) GetTaskLogs(w http.ResponseWriter, r *http.Request) {
 	if stream == "" {
 		stream = streamParamAll
 	}
+	if follow := r.URL.Query().Get("follow"); follow != "" {
+		on, err := strconv.ParseBool(follow)
+		if err != nil {
+			WriteBadRequest(w, "Invalid follow parameter")
+			return
+		}
+		if on {
+			h.followTaskLogs(w, r, task, stream)
+			return
+		}
+	}
 	jobs, err := h.db.GetJobsByTaskID(ctx, task.ID)
 	if err != nil {
 		h.logger.Error("get jobs", "error", err)
@@ -652,10 +780,7 @@ func (h *TaskHandler) chatPollUntilTerminal(ctx context.Context, taskID uuid.UUI
 			h.logger.Error("chat get task", "error", err)
 			return "", http.StatusInternalServerError
 		}
-		if t.Status == models.TaskStatusCompleted ||
-			t.Status == models.TaskStatusFailed ||
-			t.Status == models.TaskStatusCanceled ||
-			t.Status == models.TaskStatusSuperseded {
+		if isTaskTerminal(t.Status) {
 			jobs, err := h.db.GetJobsByTaskID(ctx, taskID)
 			if err != nil {
 				return "", http.StatusInternalServerError
@@ -697,6 +822,9 @@ func (h *TaskHandler) Chat(w http.ResponseWriter, r *http.Request) {
 		WriteInternalError(w, "Failed to process message")
 		return
 	}
+	if !h.checkTaskQuota(ctx, w, userID, projectID) {
+		return
+	}
 	task, err := h.db.CreateTask(ctx, userID, req.Message, nil, projectID)
 	if err != nil {
 		h.logger.Error("chat create task", "error", err)
@@ -704,7 +832,7 @@ func (h *TaskHandler) Chat(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if h.inferenceURL != "" {
-		job, err := h.createTaskWithOrchestratorInference(ctx, task.ID, req.Message)
+		job, err := h.createTaskWithOrchestratorInference(ctx, task, req.Message)
 		if err == nil {
 			WriteJSON(w, http.StatusOK, ChatResponse{Response: h.chatResponseFromJob(job)})
 			return
@@ -717,9 +845,8 @@ func (h *TaskHandler) Chat(w http.ResponseWriter, r *http.Request) {
 		WriteInternalError(w, "Failed to process message")
 		return
 	}
-	if _, err := h.db.CreateJob(ctx, task.ID, payload); err != nil {
-		h.logger.Error("chat create job", "error", err)
-		WriteInternalError(w, "Failed to process message")
+	if _, err := h.quotas.CreateQueuedJob(ctx, h.db, task.ID, uuid.New(), payload); err != nil {
+		h.writeJobCreateError(ctx, w, task.ID, err, "Failed to process message")
 		return
 	}
 	out, errCode := h.chatPollUntilTerminal(ctx, task.ID)
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/usage"
+)
+
+// UsageHandler handles GET /v1/usage: the caller's token usage and quota status.
+type UsageHandler struct {
+	db     database.Store
+	logger *slog.Logger
+	quotas *usage.Enforcer
+}
+
+// NewUsageHandler creates a new usage handler. quotas may be nil (no quotas configured).
+func NewUsageHandler(db database.Store, logger *slog.Logger, quotas *usage.Enforcer) *UsageHandler {
+	return &UsageHandler{db: db, logger: logger, quotas: quotas}
+}
+
+// GetUsage handles GET /v1/usage?since=&until=&project_id=.
+// since and until are RFC 3339; the window defaults to the current UTC month up to now.
+// project_id must be a project the caller can access; others are reported as not found.
+func (h *UsageHandler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := getUserIDFromContext(ctx)
+	if userID == nil {
+		WriteUnauthorized(w, "Authentication required")
+		return
+	}
+	q := r.URL.Query()
+	now := time.Now().UTC()
+	since := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	until := now
+	var err error
+	if v := q.Get("since"); v != "" {
+		if since, err = time.Parse(time.RFC3339, v); err != nil {
+			WriteBadRequest(w, "Invalid since (RFC 3339 expected)")
+			return
+		}
+	}
+	if v := q.Get("until"); v != "" {
+		if until, err = time.Parse(time.RFC3339, v); err != nil {
+			WriteBadRequest(w, "Invalid until (RFC 3339 expected)")
+			return
+		}
+	}
+	if !until.After(since) {
+		WriteBadRequest(w, "until must be after since")
+		return
+	}
+	var projectID *uuid.UUID
+	if v := strings.TrimSpace(q.Get("project_id")); v != "" {
+		parsed, err := uuid.Parse(v)
+		if err != nil {
+			WriteBadRequest(w, "Invalid project_id")
+			return
+		}
+		projectID = &parsed
+		ok, err := canAccessProject(ctx, h.db, *userID, parsed)
+		if err != nil {
+			h.logger.Error("check project access", "error", err)
+			WriteInternalError(w, "Failed to get usage")
+			return
+		}
+		if !ok {
+			WriteNotFound(w, "Project not found")
+			return
+		}
+	}
+
+	sums, err := h.db.SumTokenUsage(ctx, userID, projectID, since, until)
+	if err != nil {
+		h.logger.Error("sum token usage", "error", err)
+		WriteInternalError(w, "Failed to get usage")
+		return
+	}
+	quotas, err := h.quotas.Status(ctx, *userID, projectID)
+	if err != nil {
+		h.logger.Error("quota status", "error", err)
+		WriteInternalError(w, "Failed to get usage")
+		return
+	}
+	resp := userapi.UsageResponse{
+		UserID:  userID.String(),
+		Since:   since.UTC().Format(time.RFC3339),
+		Until:   until.UTC().Format(time.RFC3339),
+		ByModel: make([]userapi.ModelUsage, 0, len(sums)),
+		Quotas:  quotas,
+	}
+	if projectID != nil {
+		s := projectID.String()
+		resp.ProjectID = &s
+	}
+	for _, m := range sums {
+		t := userapi.UsageTotals{PromptTokens: m.PromptTokens, CompletionTokens: m.CompletionTokens, TotalTokens: m.TotalTokens}
+		resp.ByModel = append(resp.ByModel, userapi.ModelUsage{Model: m.Model, UsageTotals: t})
+		resp.Totals.PromptTokens += t.PromptTokens
+		resp.Totals.CompletionTokens += t.CompletionTokens
+		resp.Totals.TotalTokens += t.TotalTokens
+	}
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+// quotaExceeded returns the quota error wrapped in err, if any.
+func quotaExceeded(err error) (*usage.ExceededError, bool) {
+	var exceeded *usage.ExceededError
+	ok := errors.As(err, &exceeded)
+	return exceeded, ok
+}
+
+// writeQuotaCheckError writes the response for a failed quota check: 429 when a quota is used up, else 500.
+func writeQuotaCheckError(w http.ResponseWriter, logger *slog.Logger, err error) {
+	if exceeded, ok := quotaExceeded(err); ok {
+		WriteQuotaExceeded(w, exceeded.RetryAfter(time.Now()), exceeded.Error())
+		return
+	}
+	logger.Error("check quota", "error", err)
+	WriteInternalError(w, "Failed to check quota")
+}
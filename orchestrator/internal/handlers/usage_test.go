@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/problem"
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/usage"
+)
+
+func addTokenUsage(mockDB *testutil.MockDB, userID uuid.UUID, model string, prompt, completion int64) {
+	_ = mockDB.CreateTokenUsage(context.Background(), &models.TokenUsage{
+		UserID: &userID, Model: model, Source: models.TokenUsageSourceChat, PromptTokens: prompt, CompletionTokens: completion,
+	})
+}
+
+func TestUsageHandler_GetUsage(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	userID := uuid.New()
+	addTokenUsage(mockDB, userID, "qwen3:8b", 10, 20)
+	addTokenUsage(mockDB, userID, "qwen3:8b", 5, 5)
+	addTokenUsage(mockDB, userID, "llama3", 1, 2)
+	addTokenUsage(mockDB, uuid.New(), "qwen3:8b", 100, 100)
+	quotas := usage.NewEnforcer(mockDB, usage.Limits{User: usage.Quotas{DailyTokens: 1000}})
+	h := NewUsageHandler(mockDB, newTestLogger(), quotas)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/usage", http.NoBody).
+		WithContext(context.WithValue(context.Background(), contextKeyUserID, userID))
+	rec := httptest.NewRecorder()
+	h.GetUsage(rec, req)
+	assertStatusCode(t, rec, http.StatusOK)
+	var resp userapi.UsageResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if resp.Totals.TotalTokens != 43 || len(resp.ByModel) != 2 {
+		t.Errorf("totals = %+v by_model = %+v", resp.Totals, resp.ByModel)
+	}
+	if len(resp.Quotas) != 1 || resp.Quotas[0].Kind != usage.KindDailyTokens || resp.Quotas[0].Used != 43 || resp.Quotas[0].ResetsAt == nil {
+		t.Errorf("quotas = %+v", resp.Quotas)
+	}
+}
+
+func TestUsageHandler_GetUsageRequiresProjectAccess(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	ctx := context.Background()
+	owner, _ := mockDB.CreateUser(ctx, "owner", nil)
+	other, _ := mockDB.CreateUser(ctx, "other", nil)
+	project, _ := mockDB.GetOrCreateDefaultProjectForUser(ctx, owner.ID)
+	h := NewUsageHandler(mockDB, newTestLogger(), usage.NewEnforcer(mockDB, usage.Limits{Project: usage.Quotas{DailyTokens: 1000}}))
+
+	get := func(userID uuid.UUID) int {
+		req := httptest.NewRequest(http.MethodGet, "/v1/usage?project_id="+project.ID.String(), http.NoBody).
+			WithContext(context.WithValue(ctx, contextKeyUserID, userID))
+		rec := httptest.NewRecorder()
+		h.GetUsage(rec, req)
+		return rec.Code
+	}
+	if code := get(owner.ID); code != http.StatusOK {
+		t.Errorf("owner: status %d", code)
+	}
+	if code := get(other.ID); code != http.StatusNotFound {
+		t.Errorf("non-member: status %d, want 404", code)
+	}
+}
+
+func TestUsageHandler_GetUsageBadRequest(t *testing.T) {
+	h := NewUsageHandler(testutil.NewMockDB(), newTestLogger(), nil)
+	for _, query := range []string{"since=yesterday", "until=soon", "project_id=nope", "since=2026-02-01T00:00:00Z&until=2026-01-01T00:00:00Z"} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/usage?"+query, http.NoBody).
+			WithContext(context.WithValue(context.Background(), contextKeyUserID, uuid.New()))
+		rec := httptest.NewRecorder()
+		h.GetUsage(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d", query, rec.Code)
+		}
+	}
+	rec := httptest.NewRecorder()
+	h.GetUsage(rec, httptest.NewRequest(http.MethodGet, "/v1/usage", http.NoBody))
+	assertStatusCode(t, rec, http.StatusUnauthorized)
+}
+
+func TestTaskHandler_CreateTaskQuotaExceeded(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	userID := uuid.New()
+	addTokenUsage(mockDB, userID, "qwen3:8b", 60, 40)
+	h := NewTaskHandler(mockDB, newTestLogger(), "", "")
+	h.SetQuotaEnforcer(usage.NewEnforcer(mockDB, usage.Limits{User: usage.Quotas{DailyTokens: 100}}))
+
+	jsonBody, _ := json.Marshal(userapi.CreateTaskRequest{Prompt: "prompt"})
+	req := httptest.NewRequest(http.MethodPost, "/v1/tasks", bytes.NewReader(jsonBody)).
+		WithContext(context.WithValue(context.Background(), contextKeyUserID, userID))
+	rec := httptest.NewRecorder()
+	h.CreateTask(rec, req)
+	assertStatusCode(t, rec, http.StatusTooManyRequests)
+	var p problem.Details
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil || p.Type != problem.TypeQuotaExceeded {
+		t.Errorf("problem = %+v (%v)", p, err)
+	}
+	if secs, err := strconv.Atoi(rec.Header().Get("Retry-After")); err != nil || secs < 1 || secs > int((24*time.Hour).Seconds()) {
+		t.Errorf("Retry-After = %q", rec.Header().Get("Retry-After"))
+	}
+	if len(mockDB.Tasks) != 0 {
+		t.Errorf("task created despite quota")
+	}
+}
+
+func TestTaskHandler_CreateTaskConcurrentJobsQuota(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	userID := uuid.New()
+	running := &models.Task{ID: uuid.New(), CreatedBy: &userID, Status: models.TaskStatusRunning}
+	mockDB.AddTask(running)
+	mockDB.AddJob(&models.Job{ID: uuid.New(), TaskID: running.ID, Status: models.JobStatusRunning})
+	h := NewTaskHandler(mockDB, newTestLogger(), "", "")
+	h.SetQuotaEnforcer(usage.NewEnforcer(mockDB, usage.Limits{User: usage.Quotas{ConcurrentJobs: 1}}))
+
+	jsonBody, _ := json.Marshal(userapi.CreateTaskRequest{Prompt: "prompt"})
+	req := httptest.NewRequest(http.MethodPost, "/v1/tasks", bytes.NewReader(jsonBody)).
+		WithContext(context.WithValue(context.Background(), contextKeyUserID, userID))
+	rec := httptest.NewRecorder()
+	h.CreateTask(rec, req)
+	assertStatusCode(t, rec, http.StatusTooManyRequests)
+	if rec.Header().Get("Retry-After") != "30" {
+		t.Errorf("Retry-After = %q", rec.Header().Get("Retry-After"))
+	}
+}
+
+// staleActiveJobCountStore reports no active jobs to the early quota check, as when a concurrent request
+// queues its job between the check and the insert.
+type staleActiveJobCountStore struct {
+	*testutil.MockDB
+}
+
+func (m *staleActiveJobCountStore) CountActiveJobs(_ context.Context, _, _ *uuid.UUID) (int, error) {
+	return 0, nil
+}
+
+func TestTaskHandler_CreateTaskConcurrentJobsQuotaEnforcedAtInsert(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	userID := uuid.New()
+	running := &models.Task{ID: uuid.New(), CreatedBy: &userID, Status: models.TaskStatusRunning}
+	mockDB.AddTask(running)
+	mockDB.AddJob(&models.Job{ID: uuid.New(), TaskID: running.ID, Status: models.JobStatusRunning})
+	store := &staleActiveJobCountStore{MockDB: mockDB}
+	h := NewTaskHandler(store, newTestLogger(), "", "")
+	h.SetQuotaEnforcer(usage.NewEnforcer(store, usage.Limits{User: usage.Quotas{ConcurrentJobs: 1}}))
+
+	jsonBody, _ := json.Marshal(userapi.CreateTaskRequest{Prompt: "prompt"})
+	req := httptest.NewRequest(http.MethodPost, "/v1/tasks", bytes.NewReader(jsonBody)).
+		WithContext(context.WithValue(context.Background(), contextKeyUserID, userID))
+	rec := httptest.NewRecorder()
+	h.CreateTask(rec, req)
+	assertStatusCode(t, rec, http.StatusTooManyRequests)
+	if n, _ := mockDB.CountActiveJobs(context.Background(), &userID, nil); n != 1 {
+		t.Errorf("active jobs = %d, want 1", n)
+	}
+	for _, task := range mockDB.Tasks {
+		if task.ID != running.ID && task.Status != models.TaskStatusFailed {
+			t.Errorf("task without a job has status %s, want failed", task.Status)
+		}
+	}
+}
+
+func TestOpenAIChatHandler_ChatCompletionsQuotaAndUsage(t *testing.T) {
+	mockOllama := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"response": "hi", "done": true, "prompt_eval_count": 7, "eval_count": 3})
+	}))
+	defer mockOllama.Close()
+	mockDB := testutil.NewMockDB()
+	userID := uuid.New()
+	h := NewOpenAIChatHandler(mockDB, newTestLogger(), mockOllama.URL, "qwen3.5:0.8b", "")
+	h.SetQuotaEnforcer(usage.NewEnforcer(mockDB, usage.Limits{User: usage.Quotas{MonthlyTokens: 10}}))
+
+	chat := func() *httptest.ResponseRecorder {
+		body := []byte(`{"model":"qwen3.5:0.8b","messages":[{"role":"user","content":"hi"}]}`)
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewReader(body)).
+			WithContext(context.WithValue(context.Background(), contextKeyUserID, userID))
+		rec := httptest.NewRecorder()
+		h.ChatCompletions(rec, req)
+		return rec
+	}
+	assertStatusCode(t, chat(), http.StatusOK)
+	if len(mockDB.TokenUsage) != 1 || mockDB.TokenUsage[0].TotalTokens != 10 || mockDB.TokenUsage[0].Source != models.TokenUsageSourceChat {
+		t.Fatalf("token usage = %+v", mockDB.TokenUsage)
+	}
+
+	rec := chat()
+	assertStatusCode(t, rec, http.StatusTooManyRequests)
+	var out struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil || out.Error.Code != "quota_exceeded" {
+		t.Errorf("body = %s", rec.Body.String())
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("missing Retry-After")
+	}
+}
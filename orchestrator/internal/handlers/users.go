@@ -1,14 +1,39 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
 
 	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/auth"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+// Auth audit event types for user administration, password changes, and session revocation.
+const (
+	EventUserCreate     = "user_create"
+	EventUserDisable    = "user_disable"
+	EventUserEnable     = "user_enable"
+	EventUserDelete     = "user_delete"
+	EventPasswordReset  = "password_reset"
+	EventPasswordChange = "password_change"
+	EventSessionsRevoke = "sessions_revoke"
+	EventSessionRevoke  = "session_revoke"
+)
+
+const (
+	// minPasswordLength applies to passwords set through the API; the bootstrap admin password is not checked.
+	minPasswordLength = 8
+	passwordHashAlg   = "argon2id"
+	systemUserHandle  = "system"
 )
 
 // UserHandler handles user endpoints.
@@ -49,49 +74,278 @@ func (h *UserHandler) GetMe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	WriteJSON(w, http.StatusOK, userapi.UserResponse{
-		ID:       user.ID.String(),
-		Handle:   user.Handle,
-		Email:    user.Email,
-		IsActive: user.IsActive,
-	})
+	WriteJSON(w, http.StatusOK, userToResponse(user))
+}
+
+func userToResponse(u *models.User) userapi.UserResponse {
+	return userapi.UserResponse{
+		ID:               u.ID.String(),
+		Handle:           u.Handle,
+		Email:            u.Email,
+		IsActive:         u.IsActive,
+		IsServiceAccount: u.IsServiceAccount,
+	}
+}
+
+// validUserHandle reports whether h is usable as a new user handle: already in the form sanitizeHandle produces
+// and not the reserved system handle.
+func validUserHandle(h string) bool {
+	return h != "" && h != systemUserHandle && sanitizeHandle(h) == h
 }
 
-// RevokeSessions handles POST /v1/users/{id}/revoke_sessions (admin-gated).
+// RevokeSessions handles POST /v1/users/{id}/revoke_sessions (users.manage).
 // Invalidates all refresh sessions for the given user per local_user_accounts.md.
 func (h *UserHandler) RevokeSessions(w http.ResponseWriter, r *http.Request) {
+	user := h.targetUser(w, r)
+	if user == nil {
+		return
+	}
+	if err := h.db.InvalidateAllUserSessions(r.Context(), user.ID); err != nil {
+		h.logError("revoke_sessions invalidate", "error", err)
+		WriteInternalError(w, "Failed to revoke sessions")
+		return
+	}
+	h.audit(r, &user.ID, EventSessionsRevoke, "user "+user.Handle)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListUsers handles GET /v1/users (users.manage). Service accounts are included.
+func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	if h.db == nil {
+		WriteInternalError(w, "Database not available")
+		return
+	}
+	list, err := h.db.ListUsers(r.Context())
+	if err != nil {
+		h.logError("list users", "error", err)
+		WriteInternalError(w, "Failed to list users")
+		return
+	}
+	resp := userapi.ListUsersResponse{Users: make([]userapi.UserResponse, 0, len(list))}
+	for _, u := range list {
+		resp.Users = append(resp.Users, userToResponse(u))
+	}
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+// CreateUser handles POST /v1/users (users.manage): an active local user with a password and the default role.
+func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var req userapi.CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteBadRequest(w, "Invalid request body")
+		return
+	}
+	handle := strings.TrimSpace(req.Handle)
+	if !validUserHandle(handle) {
+		WriteBadRequest(w, "handle must be lowercase letters, digits, '.', '_' or '-' (at most 48 characters), and not system")
+		return
+	}
+	if detail := passwordPolicyError(req.Password); detail != "" {
+		WriteBadRequest(w, "password: "+detail)
+		return
+	}
+	if h.db == nil {
+		WriteInternalError(w, "Database not available")
+		return
+	}
+	ctx := r.Context()
+	if _, err := h.db.GetUserByHandle(ctx, handle); !errors.Is(err, database.ErrNotFound) {
+		if err == nil {
+			WriteConflict(w, "A user with this handle already exists")
+			return
+		}
+		h.logError("create user get handle", "error", err)
+		WriteInternalError(w, "Failed to create user")
+		return
+	}
+	user, err := h.createUserWithPassword(ctx, handle, optionalTrimmed(req.Email), req.Password, req.MustChangePassword)
+	if err != nil {
+		h.logError("create user", "error", err)
+		WriteInternalError(w, "Failed to create user")
+		return
+	}
+	h.audit(r, &user.ID, EventUserCreate, "user "+user.Handle)
+	WriteJSON(w, http.StatusCreated, userToResponse(user))
+}
+
+func (h *UserHandler) createUserWithPassword(ctx context.Context, handle string, email *string, password string, mustChange bool) (*models.User, error) {
+	hash, err := auth.HashPassword(password, nil)
+	if err != nil {
+		return nil, err
+	}
+	return h.db.CreateUserWithPassword(ctx, handle, email, hash, passwordHashAlg, mustChange)
+}
+
+// DisableUser handles POST /v1/users/{id}/disable (users.manage). Login, refresh, and the user's personal access
+// tokens stop working and existing refresh sessions are revoked.
+func (h *UserHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	h.setActive(w, r, false)
+}
+
+// EnableUser handles POST /v1/users/{id}/enable (users.manage).
+func (h *UserHandler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	h.setActive(w, r, true)
+}
+
+func (h *UserHandler) setActive(w http.ResponseWriter, r *http.Request, active bool) {
+	user := h.targetUser(w, r)
+	if user == nil || !h.changeableUser(w, r, user) {
+		return
+	}
 	ctx := r.Context()
+	err := h.db.SetUserActive(ctx, user.ID, active)
+	if err == nil && !active {
+		err = h.db.InvalidateAllUserSessions(ctx, user.ID)
+	}
+	if err != nil {
+		h.logError("set user active", "error", err, "user_id", user.ID)
+		WriteInternalError(w, "Failed to update user")
+		return
+	}
+	event := EventUserEnable
+	if !active {
+		event = EventUserDisable
+	}
+	h.audit(r, &user.ID, event, "user "+user.Handle)
+	user.IsActive = active
+	WriteJSON(w, http.StatusOK, userToResponse(user))
+}
+
+// DeleteUser handles DELETE /v1/users/{id} (users.manage). The user's credentials, sessions, tokens,
+// memberships, and role bindings are removed; audit history keeps the user id.
+func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	user := h.targetUser(w, r)
+	if user == nil || !h.changeableUser(w, r, user) {
+		return
+	}
+	if err := h.db.DeleteUser(r.Context(), user.ID); err != nil {
+		h.logError("delete user", "error", err, "user_id", user.ID)
+		WriteInternalError(w, "Failed to delete user")
+		return
+	}
+	h.audit(r, &user.ID, EventUserDelete, "user "+user.Handle)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ResetPassword handles POST /v1/users/{id}/reset_password (users.manage). The user's refresh sessions are
+// revoked; with must_change_password the next login must set a new password.
+func (h *UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	user := h.targetUser(w, r)
+	if user == nil {
+		return
+	}
+	var req userapi.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteBadRequest(w, "Invalid request body")
+		return
+	}
+	if user.IsServiceAccount || user.Handle == systemUserHandle {
+		WriteBadRequest(w, "This account cannot have a password")
+		return
+	}
+	if detail := passwordPolicyError(req.Password); detail != "" {
+		WriteBadRequest(w, "password: "+detail)
+		return
+	}
+	ctx := r.Context()
+	hash, err := auth.HashPassword(req.Password, nil)
+	if err == nil {
+		err = h.db.SetPassword(ctx, user.ID, hash, passwordHashAlg, req.MustChangePassword)
+	}
+	if err == nil {
+		err = h.db.InvalidateAllUserSessions(ctx, user.ID)
+	}
+	if err != nil {
+		h.logError("reset password", "error", err, "user_id", user.ID)
+		WriteInternalError(w, "Failed to reset password")
+		return
+	}
+	reason := "user " + user.Handle
+	if req.MustChangePassword {
+		reason += "; must change at next login"
+	}
+	h.audit(r, &user.ID, EventPasswordReset, reason)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// targetUser resolves the user named by {id}, or writes an error response and returns nil.
+func (h *UserHandler) targetUser(w http.ResponseWriter, r *http.Request) *models.User {
 	idStr := r.PathValue("id")
 	if idStr == "" {
 		WriteBadRequest(w, "user id required")
-		return
+		return nil
 	}
 	userID, err := uuid.Parse(idStr)
 	if err != nil {
 		WriteBadRequest(w, "invalid user id")
-		return
+		return nil
 	}
 	if h.db == nil {
 		WriteInternalError(w, "Database not available")
-		return
+		return nil
 	}
-	if _, err := h.db.GetUserByID(ctx, userID); err != nil {
+	user, err := h.db.GetUserByID(r.Context(), userID)
+	if err != nil {
 		if errors.Is(err, database.ErrNotFound) {
 			WriteNotFound(w, "User not found")
-			return
-		}
-		if h.logger != nil {
-			h.logger.Error("revoke_sessions get user", "error", err)
+			return nil
 		}
+		h.logError("get user", "error", err)
 		WriteInternalError(w, "Failed to get user")
-		return
+		return nil
 	}
-	if err := h.db.InvalidateAllUserSessions(ctx, userID); err != nil {
-		if h.logger != nil {
-			h.logger.Error("revoke_sessions invalidate", "error", err)
-		}
-		WriteInternalError(w, "Failed to revoke sessions")
-		return
+	return user
+}
+
+// changeableUser refuses to disable or delete the caller's own account or the system user, so an admin cannot
+// lock themselves out by accident.
+func (h *UserHandler) changeableUser(w http.ResponseWriter, r *http.Request, user *models.User) bool {
+	if user.Handle == systemUserHandle {
+		WriteBadRequest(w, "The system user cannot be changed")
+		return false
 	}
-	w.WriteHeader(http.StatusNoContent)
+	if caller := getUserIDFromContext(r.Context()); caller != nil && *caller == user.ID {
+		WriteBadRequest(w, "You cannot disable or delete your own account")
+		return false
+	}
+	return true
+}
+
+func (h *UserHandler) audit(r *http.Request, userID *uuid.UUID, eventType, reason string) {
+	writeAdminAudit(h.db, h.logger, r, userID, eventType, reason)
+}
+
+func (h *UserHandler) logError(msg string, args ...any) {
+	if h.logger != nil {
+		h.logger.Error(msg, args...)
+	}
+}
+
+// writeAdminAudit records an administrative action in auth_audit_log: userID is the affected account and the
+// subject handle is the caller.
+func writeAdminAudit(db database.Store, logger *slog.Logger, r *http.Request, userID *uuid.UUID, eventType, reason string) {
+	ctx := r.Context()
+	ip, ua, actor := getClientIP(r), r.UserAgent(), GetHandleFromContext(ctx)
+	if err := db.CreateAuthAuditLog(ctx, userID, eventType, true, &ip, &ua, &actor, &reason); err != nil && logger != nil {
+		logger.Warn("auth audit log failed", "error", err, "event_type", eventType)
+	}
+}
+
+// passwordPolicyError returns why p is not acceptable as a new password, or "".
+func passwordPolicyError(p string) string {
+	if len(p) < minPasswordLength {
+		return "must be at least " + strconv.Itoa(minPasswordLength) + " characters"
+	}
+	return ""
+}
+
+func optionalTrimmed(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	t := strings.TrimSpace(*s)
+	if t == "" {
+		return nil
+	}
+	return &t
 }
@@ -2,14 +2,19 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/auth"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
 )
 
@@ -161,3 +166,101 @@ func TestUserResponseIsInactive(t *testing.T) {
 		t.Error("expected IsActive to be false")
 	}
 }
+
+func adminUserRequest(h http.HandlerFunc, method, path, id, body string, caller uuid.UUID) *httptest.ResponseRecorder {
+	req, rec := credRequest(method, path, body, caller, "admin")
+	req.SetPathValue("id", id)
+	h(rec, req)
+	return rec
+}
+
+// createUserErrorStore fails user creation as a failed password credential insert would.
+type createUserErrorStore struct {
+	*testutil.MockDB
+}
+
+func (m *createUserErrorStore) CreateUserWithPassword(_ context.Context, _ string, _ *string, _ []byte, _ string, _ bool) (*models.User, error) {
+	return nil, errors.New("create password credential error")
+}
+
+func TestUserHandler_AdminLifecycle(t *testing.T) {
+	mockDB := testutil.NewMockDB()
+	h := NewUserHandler(mockDB, newTestLogger())
+	admin, _ := mockDB.CreateUser(context.Background(), "admin", nil)
+
+	rec := adminUserRequest(h.CreateUser, http.MethodPost, "/v1/users", "",
+		`{"handle":"bob","email":" bob@example.com ","password":"initial-pass","must_change_password":true}`, admin.ID)
+	assertStatusCode(t, rec, http.StatusCreated)
+	var bob userapi.UserResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &bob); err != nil || !bob.IsActive || bob.Email == nil || *bob.Email != "bob@example.com" {
+		t.Fatalf("created = %+v, %v", bob, err)
+	}
+	bobID := uuid.MustParse(bob.ID)
+	if cred := mockDB.PasswordCreds[bobID]; cred == nil || !cred.MustChange {
+		t.Errorf("password credential = %+v", cred)
+	}
+	failing := NewUserHandler(&createUserErrorStore{MockDB: mockDB}, newTestLogger())
+	rec = adminUserRequest(failing.CreateUser, http.MethodPost, "/v1/users", "", `{"handle":"dave","password":"initial-pass"}`, admin.ID)
+	assertStatusCode(t, rec, http.StatusInternalServerError)
+	if _, err := mockDB.GetUserByHandle(context.Background(), "dave"); err == nil {
+		t.Error("failed create must not leave a user without a password")
+	}
+	for body, want := range map[string]int{
+		`{"handle":"bob","password":"another-pass"}`:      http.StatusConflict,
+		`{"handle":"Bob Smith","password":"x-long-pass"}`: http.StatusBadRequest,
+		`{"handle":"carol","password":"short"}`:           http.StatusBadRequest,
+	} {
+		if rec := adminUserRequest(h.CreateUser, http.MethodPost, "/v1/users", "", body, admin.ID); rec.Code != want {
+			t.Errorf("%s: status %d, want %d", body, rec.Code, want)
+		}
+	}
+
+	rec = adminUserRequest(h.ListUsers, http.MethodGet, "/v1/users", "", "", admin.ID)
+	assertStatusCode(t, rec, http.StatusOK)
+	var list userapi.ListUsersResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || len(list.Users) != 2 || list.Users[0].Handle != "admin" {
+		t.Errorf("list = %+v, %v", list, err)
+	}
+
+	_ = mockDB.CreateRefreshSession(context.Background(), &models.RefreshSession{UserID: bobID, RefreshTokenHash: []byte("h"), ExpiresAt: time.Now().Add(time.Hour)})
+	rec = adminUserRequest(h.DisableUser, http.MethodPost, "/v1/users/"+bob.ID+"/disable", bob.ID, "", admin.ID)
+	assertStatusCode(t, rec, http.StatusOK)
+	if mockDB.Users[bobID].IsActive || mockDB.SessionsByHash["h"].IsActive {
+		t.Error("disable must deactivate the user and revoke their sessions")
+	}
+	rec = adminUserRequest(h.EnableUser, http.MethodPost, "/v1/users/"+bob.ID+"/enable", bob.ID, "", admin.ID)
+	assertStatusCode(t, rec, http.StatusOK)
+	if !mockDB.Users[bobID].IsActive {
+		t.Error("enable must reactivate the user")
+	}
+
+	rec = adminUserRequest(h.ResetPassword, http.MethodPost, "/v1/users/"+bob.ID+"/reset_password", bob.ID, `{"password":"reset-pass-1"}`, admin.ID)
+	assertStatusCode(t, rec, http.StatusNoContent)
+	if ok, _ := auth.VerifyPassword("reset-pass-1", mockDB.PasswordCreds[bobID].PasswordHash); !ok || mockDB.PasswordCreds[bobID].MustChange {
+		t.Error("reset_password did not replace the password")
+	}
+
+	// An admin cannot disable or delete their own account or the system user.
+	system, _ := mockDB.CreateUser(context.Background(), "system", nil)
+	for _, id := range []string{admin.ID.String(), system.ID.String()} {
+		if rec := adminUserRequest(h.DeleteUser, http.MethodDelete, "/v1/users/"+id, id, "", admin.ID); rec.Code != http.StatusBadRequest {
+			t.Errorf("delete %s: status %d", id, rec.Code)
+		}
+	}
+	rec = adminUserRequest(h.DeleteUser, http.MethodDelete, "/v1/users/"+bob.ID, bob.ID, "", admin.ID)
+	assertStatusCode(t, rec, http.StatusNoContent)
+	if _, ok := mockDB.Users[bobID]; ok || mockDB.PasswordCreds[bobID] != nil {
+		t.Error("delete left the user or their password behind")
+	}
+	rec = adminUserRequest(h.DisableUser, http.MethodPost, "/v1/users/"+bob.ID+"/disable", bob.ID, "", admin.ID)
+	assertStatusCode(t, rec, http.StatusNotFound)
+
+	var events []string
+	for _, e := range mockDB.AuditLogs {
+		events = append(events, e.EventType)
+	}
+	want := []string{EventUserCreate, EventUserDisable, EventUserEnable, EventPasswordReset, EventUserDelete}
+	if strings.Join(events, ",") != strings.Join(want, ",") {
+		t.Errorf("audit events = %v, want %v", events, want)
+	}
+}
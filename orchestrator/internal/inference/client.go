@@ -3,6 +3,7 @@
 package inference
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -22,10 +23,47 @@ type GenerateRequest struct {
 }
 
 // GenerateChunk is one chunk of the response (single JSON object or NDJSON line).
+// Ollama sets the token counts on the final (done) chunk.
 type GenerateChunk struct {
-	Response string `json:"response"`
-	Error    string `json:"error"`
-	Done     bool   `json:"done"`
+	Model           string `json:"model"`
+	Response        string `json:"response"`
+	Error           string `json:"error"`
+	Done            bool   `json:"done"`
+	PromptEvalCount int64  `json:"prompt_eval_count"`
+	EvalCount       int64  `json:"eval_count"`
+}
+
+// Usage is the token usage of one inference call.
+type Usage struct {
+	Model            string
+	PromptTokens     int64
+	CompletionTokens int64
+}
+
+type usageObserverKey struct{}
+
+// WithUsageObserver returns a context whose inference calls report their token usage to fn.
+// Callers use it to attribute usage (user, project, task) without threading it through every call.
+func WithUsageObserver(ctx context.Context, fn func(Usage)) context.Context {
+	return context.WithValue(ctx, usageObserverKey{}, fn)
+}
+
+// ObserveUsage reports u to the observer set on ctx by WithUsageObserver, if any.
+// Calls that report no tokens are ignored.
+func ObserveUsage(ctx context.Context, u Usage) {
+	if u.PromptTokens <= 0 && u.CompletionTokens <= 0 {
+		return
+	}
+	if fn, ok := ctx.Value(usageObserverKey{}).(func(Usage)); ok && fn != nil {
+		fn(u)
+	}
+}
+
+func (c *GenerateChunk) usage(model string) Usage {
+	if c.Model != "" {
+		model = c.Model
+	}
+	return Usage{Model: model, PromptTokens: c.PromptEvalCount, CompletionTokens: c.EvalCount}
 }
 
 // CallGenerate calls baseURL/api/generate with the given model and prompt.
@@ -60,28 +98,94 @@ func CallGenerate(ctx context.Context, client *http.Client, baseURL, model, prom
 	if _, err := buf.ReadFrom(resp.Body); err != nil {
 		return "", err
 	}
-	return parseGenerateResponse(buf.String())
+	out, usage, err := parseGenerateResponse(buf.String(), model)
+	if err != nil {
+		return "", err
+	}
+	ObserveUsage(ctx, usage)
+	return out, nil
+}
+
+// CallGenerateStream calls baseURL/api/generate with stream enabled and calls onDelta with each non-empty
+// response fragment as it arrives. Returns the concatenated response or an error message.
+func CallGenerateStream(ctx context.Context, client *http.Client, baseURL, model, prompt string, onDelta func(string)) (string, error) {
+	if baseURL == "" {
+		return "", fmt.Errorf("inference base URL is required")
+	}
+	if client == nil {
+		// No overall client timeout: a stream may legitimately run longer; ctx bounds the call.
+		client = &http.Client{}
+	}
+	url := strings.TrimSuffix(baseURL, "/") + "/api/generate"
+	b, err := json.Marshal(GenerateRequest{Model: model, Prompt: prompt, Stream: true})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("inference API returned %s", resp.Status)
+	}
+	var out strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var chunk GenerateChunk
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			continue
+		}
+		if chunk.Error != "" {
+			return "", fmt.Errorf("inference error: %s", chunk.Error)
+		}
+		if chunk.Response != "" {
+			out.WriteString(chunk.Response)
+			if onDelta != nil {
+				onDelta(chunk.Response)
+			}
+		}
+		if chunk.Done {
+			ObserveUsage(ctx, chunk.usage(model))
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return out.String(), nil
 }
 
-func parseGenerateResponse(raw string) (string, error) {
+func parseGenerateResponse(raw, model string) (string, Usage, error) {
 	var chunk GenerateChunk
 	if err := json.Unmarshal([]byte(raw), &chunk); err == nil {
 		if chunk.Error != "" {
-			return "", fmt.Errorf("inference error: %s", chunk.Error)
+			return "", Usage{}, fmt.Errorf("inference error: %s", chunk.Error)
 		}
-		return chunk.Response, nil
+		return chunk.Response, chunk.usage(model), nil
 	}
-	return parseGenerateResponseNDJSON(raw)
+	return parseGenerateResponseNDJSON(raw, model)
 }
 
-func parseGenerateResponseNDJSON(raw string) (string, error) {
+func parseGenerateResponseNDJSON(raw, model string) (string, Usage, error) {
 	var out, errMsg strings.Builder
-	var chunk GenerateChunk
+	usage := Usage{Model: model}
 	for _, line := range strings.Split(raw, "\n") {
 		line = strings.TrimSpace(line)
 		if line == "" {
 			continue
 		}
+		var chunk GenerateChunk
 		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
 			continue
 		}
@@ -89,9 +193,12 @@ func parseGenerateResponseNDJSON(raw string) (string, error) {
 			errMsg.WriteString(chunk.Error)
 		}
 		out.WriteString(chunk.Response)
+		if chunk.Done {
+			usage = chunk.usage(model)
+		}
 	}
 	if errMsg.Len() > 0 {
-		return "", fmt.Errorf("inference error: %s", errMsg.String())
+		return "", Usage{}, fmt.Errorf("inference error: %s", errMsg.String())
 	}
-	return out.String(), nil
+	return out.String(), usage, nil
 }
@@ -82,7 +82,7 @@ func TestCallGenerate_NonOK(t *testing.T) {
 }
 
 func Test_parseGenerateResponseNDJSON_ErrorInChunk(t *testing.T) {
-	_, err := parseGenerateResponseNDJSON(`{"error":"model not found"}`)
+	_, _, err := parseGenerateResponseNDJSON(`{"error":"model not found"}`, "m")
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -98,3 +98,67 @@ func TestCallGenerate_ClientDoError(t *testing.T) {
 		t.Fatal("expected error")
 	}
 }
+
+func TestCallGenerateStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GenerateRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if !req.Stream {
+			t.Error("stream not requested")
+		}
+		enc := json.NewEncoder(w)
+		_ = enc.Encode(GenerateChunk{Response: "Hel"})
+		_ = enc.Encode(GenerateChunk{Response: "lo"})
+		_ = enc.Encode(GenerateChunk{Done: true})
+	}))
+	defer server.Close()
+	var deltas []string
+	out, err := CallGenerateStream(context.Background(), nil, server.URL, "m", "Hi", func(d string) { deltas = append(deltas, d) })
+	if err != nil || out != "Hello" || len(deltas) != 2 {
+		t.Fatalf("got %q %v deltas=%v", out, err, deltas)
+	}
+}
+
+func TestCallGenerateStream_ErrorChunk(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(GenerateChunk{Error: "model not found"})
+	}))
+	defer server.Close()
+	if _, err := CallGenerateStream(context.Background(), nil, server.URL, "m", "Hi", nil); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := CallGenerateStream(context.Background(), nil, "", "m", "Hi", nil); err == nil {
+		t.Fatal("expected error for empty base URL")
+	}
+}
+
+func TestCallGenerate_ReportsUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GenerateRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Stream {
+			_, _ = w.Write([]byte(`{"response":"a"}` + "\n" + `{"done":true,"prompt_eval_count":4,"eval_count":2}` + "\n"))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(GenerateChunk{Model: "served", Response: "b", Done: true, PromptEvalCount: 3, EvalCount: 1})
+	}))
+	defer server.Close()
+	var got []Usage
+	ctx := WithUsageObserver(context.Background(), func(u Usage) { got = append(got, u) })
+	if _, err := CallGenerate(ctx, nil, server.URL, "m", "Hi"); err != nil {
+		t.Fatalf("CallGenerate: %v", err)
+	}
+	if _, err := CallGenerateStream(ctx, nil, server.URL, "m", "Hi", nil); err != nil {
+		t.Fatalf("CallGenerateStream: %v", err)
+	}
+	if len(got) != 2 || got[0] != (Usage{Model: "served", PromptTokens: 3, CompletionTokens: 1}) ||
+		got[1] != (Usage{Model: "m", PromptTokens: 4, CompletionTokens: 2}) {
+		t.Errorf("usage = %+v", got)
+	}
+	// Without an observer, and for calls without counts, nothing is reported.
+	ObserveUsage(context.Background(), Usage{PromptTokens: 1})
+	ObserveUsage(ctx, Usage{Model: "m"})
+	if len(got) != 2 {
+		t.Errorf("unexpected usage report: %+v", got)
+	}
+}
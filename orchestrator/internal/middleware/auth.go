@@ -3,6 +3,7 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 	"strings"
@@ -11,22 +12,44 @@ import (
 
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/auth"
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/handlers"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/rbac"
 )
 
-// AuthMiddleware provides JWT authentication middleware.
+// AuthMiddleware provides JWT authentication and RBAC permission middleware.
 type AuthMiddleware struct {
 	jwt    *auth.JWTManager
+	store  Store
 	logger *slog.Logger
+	access *rbac.Cache
 }
 
-// NewAuthMiddleware creates a new auth middleware.
-func NewAuthMiddleware(jwt *auth.JWTManager, logger *slog.Logger) *AuthMiddleware {
+// Store is what AuthMiddleware reads: groups and roles for RequirePermission, and personal access tokens, their
+// owners, and the access token deny-list for RequireUserAuth. database.Store satisfies it.
+type Store interface {
+	rbac.Store
+	GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	GetActivePersonalAccessToken(ctx context.Context, tokenHash []byte) (*models.PersonalAccessToken, error)
+	TouchPersonalAccessToken(ctx context.Context, id uuid.UUID) error
+	IsAccessSessionDenied(ctx context.Context, sessionID uuid.UUID) (bool, error)
+}
+
+// NewAuthMiddleware creates a new auth middleware. store may be nil when only JWT checks are used, in which case
+// personal access tokens are rejected, the deny-list is not checked, and RequirePermission denies every request.
+func NewAuthMiddleware(jwt *auth.JWTManager, store Store, logger *slog.Logger) *AuthMiddleware {
 	return &AuthMiddleware{
 		jwt:    jwt,
+		store:  store,
 		logger: logger,
 	}
 }
 
+// SetAccessCache makes RequirePermission resolve access through c instead of loading it on every request;
+// nil (the default) disables caching.
+func (m *AuthMiddleware) SetAccessCache(c *rbac.Cache) {
+	m.access = c
+}
+
 // setContextFunc derives a new context from the request context; used so contextcheck sees inheritance.
 type setContextFunc func(context.Context) context.Context
 
@@ -61,7 +84,7 @@ func (m *AuthMiddleware) tokenToContext(
 	}
 }
 
-// parseTokenIDName runs getIDName(tokenStr) and parses the id string to uuid; shared by user and node auth.
+// parseTokenIDName runs getIDName(tokenStr) and parses the id string to uuid.
 func parseTokenIDName(tokenStr string, getIDName func(string) (idStr, name string, err error)) (uuid.UUID, string, error) {
 	idStr, name, err := getIDName(tokenStr)
 	if err != nil {
@@ -74,14 +97,6 @@ func parseTokenIDName(tokenStr string, getIDName func(string) (idStr, name strin
 	return id, name, nil
 }
 
-func (m *AuthMiddleware) userIDName(tokenStr string) (idStr, name string, err error) {
-	claims, err := m.jwt.ValidateAccessToken(tokenStr)
-	if err != nil {
-		return "", "", err
-	}
-	return claims.UserID, claims.Handle, nil
-}
-
 func (m *AuthMiddleware) nodeIDName(tokenStr string) (idStr, name string, err error) {
 	claims, err := m.jwt.ValidateNodeToken(tokenStr)
 	if err != nil {
@@ -90,11 +105,88 @@ func (m *AuthMiddleware) nodeIDName(tokenStr string) (idStr, name string, err er
 	return claims.NodeID, claims.NodeSlug, nil
 }
 
-// RequireUserAuth middleware requires a valid user access token.
+// RequireUserAuth middleware requires a valid user access token or personal access token.
 func (m *AuthMiddleware) RequireUserAuth(next http.Handler) http.Handler {
-	validate := func(t string) (uuid.UUID, string, error) { return parseTokenIDName(t, m.userIDName) }
-	getCtx := m.tokenToContext(validate, handlers.SetUserContext)
-	return m.requireAuth(next, getCtx)
+	return m.requireAuth(next, func(r *http.Request, tokenStr string) (setContextFunc, error) {
+		if auth.IsPersonalAccessToken(tokenStr) {
+			return m.accessTokenContext(r, tokenStr)
+		}
+		return m.sessionTokenContext(r, tokenStr)
+	})
+}
+
+// sessionTokenContext validates a user access JWT. A token whose session (sid claim) is on the access token
+// deny-list is refused, so revoking a session takes effect before its access tokens expire.
+func (m *AuthMiddleware) sessionTokenContext(r *http.Request, tokenStr string) (setContextFunc, error) {
+	claims, err := m.jwt.ValidateAccessToken(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if claims.SessionID == "" {
+		return func(c context.Context) context.Context { return handlers.SetUserContext(c, userID, claims.Handle) }, nil
+	}
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAccessSession(r.Context(), m.store, sessionID); err != nil {
+		if !errors.Is(err, auth.ErrInvalidToken) && m.logger != nil {
+			m.logger.Error("check access token deny-list", "error", err, "session_id", sessionID)
+		}
+		return nil, err
+	}
+	return func(c context.Context) context.Context {
+		return handlers.SetSessionContext(handlers.SetUserContext(c, userID, claims.Handle), sessionID)
+	}, nil
+}
+
+// SessionDenyList is the access token deny-list lookup; database.Store satisfies it.
+type SessionDenyList interface {
+	IsAccessSessionDenied(ctx context.Context, sessionID uuid.UUID) (bool, error)
+}
+
+// checkAccessSession returns auth.ErrInvalidToken when sessionID is on the deny-list, or the lookup error.
+// A nil denyList skips the check.
+func checkAccessSession(ctx context.Context, denyList SessionDenyList, sessionID uuid.UUID) error {
+	if denyList == nil {
+		return nil
+	}
+	denied, err := denyList.IsAccessSessionDenied(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if denied {
+		return auth.ErrInvalidToken
+	}
+	return nil
+}
+
+// accessTokenContext validates a personal access token: it must be active, unexpired, and owned by an active
+// user. Its use is recorded and its scopes are set in the context so RequirePermission narrows the owner's roles.
+func (m *AuthMiddleware) accessTokenContext(r *http.Request, tokenStr string) (setContextFunc, error) {
+	if m.store == nil {
+		return nil, auth.ErrInvalidToken
+	}
+	ctx := r.Context()
+	pat, err := m.store.GetActivePersonalAccessToken(ctx, auth.HashToken(tokenStr))
+	if err != nil {
+		return nil, err
+	}
+	user, err := m.store.GetUserByID(ctx, pat.UserID)
+	if err != nil || !user.IsActive {
+		return nil, auth.ErrInvalidToken
+	}
+	if err := m.store.TouchPersonalAccessToken(ctx, pat.ID); err != nil && m.logger != nil {
+		m.logger.Warn("record personal access token use", "error", err, "token_id", pat.ID)
+	}
+	scopes := strings.Fields(pat.Scopes)
+	return func(c context.Context) context.Context {
+		return handlers.SetTokenScopes(handlers.SetUserContext(c, user.ID, user.Handle), scopes)
+	}, nil
 }
 
 // RequireNodeAuth middleware requires a valid node token.
@@ -104,18 +196,42 @@ func (m *AuthMiddleware) RequireNodeAuth(next http.Handler) http.Handler {
 		handlers.SetNodeContext))
 }
 
-// RequireAdminAuth middleware requires a valid user access token and the user to have handle "admin".
-// Used for admin-gated endpoints per local_user_accounts.md (e.g. POST /users/{id}/revoke_sessions).
-func (m *AuthMiddleware) RequireAdminAuth(next http.Handler) http.Handler {
+// RequirePermission middleware requires a valid user access token and a role granting perm (rbac_and_groups.md).
+// For personal access tokens the token scopes must also allow perm.
+// The resolved rbac.Access is stored in the request context for handler-level checks (e.g. group membership);
+// with SetAccessCache it may be up to the cache TTL old.
+func (m *AuthMiddleware) RequirePermission(perm string, next http.Handler) http.Handler {
 	return m.RequireUserAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if handlers.GetHandleFromContext(r.Context()) != "admin" {
-			handlers.WriteForbidden(w, "Admin required")
+		ctx := r.Context()
+		userID := handlers.GetUserIDFromContext(ctx)
+		if m.store == nil || userID == nil {
+			handlers.WriteForbidden(w, "Permission required: "+perm)
+			return
+		}
+		access, err := m.resolveAccess(ctx, *userID)
+		if err != nil {
+			if m.logger != nil {
+				m.logger.Error("resolve user access", "error", err, "user_id", *userID)
+			}
+			handlers.WriteInternalError(w, "Failed to resolve permissions")
+			return
+		}
+		access.Scopes = handlers.GetTokenScopesFromContext(ctx)
+		if !access.Has(perm) {
+			handlers.WriteForbidden(w, "Permission required: "+perm)
 			return
 		}
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, r.WithContext(rbac.WithAccess(ctx, access)))
 	}))
 }
 
+func (m *AuthMiddleware) resolveAccess(ctx context.Context, userID uuid.UUID) (*rbac.Access, error) {
+	if m.access != nil {
+		return m.access.Resolve(ctx, userID)
+	}
+	return rbac.Resolve(ctx, m.store, userID)
+}
+
 func extractBearerToken(r *http.Request) string {
 	authHeader := r.Header.Get("Authorization")
 	if authHeader == "" {
@@ -148,3 +264,40 @@ func RequireWorkflowRunnerAuth(token string) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// RequireSignedToken returns a middleware for services that verify orchestrator tokens without holding signing
+// material: the bearer token must verify against the orchestrator JWKS and have one of types. A user access token
+// whose session (sid claim) is on denyList is refused as in RequireUserAuth; denyList may be nil only for services
+// that have no database and therefore act on no user's data. The caller's user or node id and name, and the
+// session id, are set in the request context.
+func RequireSignedToken(v *auth.RemoteVerifier, denyList SessionDenyList, types ...auth.TokenType) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenStr := extractBearerToken(r)
+			if tokenStr == "" {
+				handlers.WriteUnauthorized(w, "Missing authorization header")
+				return
+			}
+			claims, err := v.Validate(r.Context(), tokenStr, types...)
+			if err != nil {
+				handlers.WriteUnauthorized(w, "Invalid or expired token")
+				return
+			}
+			ctx := r.Context()
+			if claims.SessionID != "" {
+				sessionID, err := uuid.Parse(claims.SessionID)
+				if err != nil || checkAccessSession(ctx, denyList, sessionID) != nil {
+					handlers.WriteUnauthorized(w, "Invalid or expired token")
+					return
+				}
+				ctx = handlers.SetSessionContext(ctx, sessionID)
+			}
+			if id, err := uuid.Parse(claims.UserID); err == nil {
+				ctx = handlers.SetUserContext(ctx, id, claims.Handle)
+			} else if id, err := uuid.Parse(claims.NodeID); err == nil {
+				ctx = handlers.SetNodeContext(ctx, id, claims.NodeSlug)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -11,12 +12,17 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/cypher0n3/cynodeai/orchestrator/internal/auth"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/handlers"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/rbac"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
 )
 
 func TestAuthMiddleware_RequireUserAuth_Valid(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	jwt := auth.NewJWTManager("test-secret", 15*time.Minute, 7*24*time.Hour, 24*time.Hour)
-	mw := NewAuthMiddleware(jwt, logger)
+	mw := NewAuthMiddleware(jwt, nil, logger)
 
 	userID := uuid.New()
 	token, _ := jwt.GenerateAccessToken(userID, "testuser")
@@ -45,7 +51,7 @@ func TestAuthMiddleware_RequireUserAuth_Valid(t *testing.T) {
 func TestAuthMiddleware_RequireUserAuth_Missing(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	jwt := auth.NewJWTManager("test-secret", 15*time.Minute, 7*24*time.Hour, 24*time.Hour)
-	mw := NewAuthMiddleware(jwt, logger)
+	mw := NewAuthMiddleware(jwt, nil, logger)
 
 	handler := mw.RequireUserAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		t.Error("handler should not be called")
@@ -64,7 +70,7 @@ func TestAuthMiddleware_RequireUserAuth_Missing(t *testing.T) {
 func TestAuthMiddleware_RequireUserAuth_Invalid(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	jwt := auth.NewJWTManager("test-secret", 15*time.Minute, 7*24*time.Hour, 24*time.Hour)
-	mw := NewAuthMiddleware(jwt, logger)
+	mw := NewAuthMiddleware(jwt, nil, logger)
 
 	handler := mw.RequireUserAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		t.Error("handler should not be called")
@@ -84,7 +90,7 @@ func TestAuthMiddleware_RequireUserAuth_Invalid(t *testing.T) {
 func TestAuthMiddleware_RequireNodeAuth_Valid(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	jwt := auth.NewJWTManager("test-secret", 15*time.Minute, 7*24*time.Hour, 24*time.Hour)
-	mw := NewAuthMiddleware(jwt, logger)
+	mw := NewAuthMiddleware(jwt, nil, logger)
 
 	nodeID := uuid.New()
 	token, _, _ := jwt.GenerateNodeToken(nodeID, "test-node")
@@ -113,7 +119,7 @@ func TestAuthMiddleware_RequireNodeAuth_Valid(t *testing.T) {
 func TestAuthMiddleware_RequireNodeAuth_WrongTokenType(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	jwt := auth.NewJWTManager("test-secret", 15*time.Minute, 7*24*time.Hour, 24*time.Hour)
-	mw := NewAuthMiddleware(jwt, logger)
+	mw := NewAuthMiddleware(jwt, nil, logger)
 
 	// Use user access token instead of node token
 	userID := uuid.New()
@@ -134,37 +140,117 @@ func TestAuthMiddleware_RequireNodeAuth_WrongTokenType(t *testing.T) {
 	}
 }
 
-func TestAuthMiddleware_RequireAdminAuth(t *testing.T) {
+func TestAuthMiddleware_RequirePermission(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	jwt := auth.NewJWTManager("test-secret", 15*time.Minute, 7*24*time.Hour, 24*time.Hour)
-	mw := NewAuthMiddleware(jwt, logger)
-
-	t.Run("admin_passes", func(t *testing.T) {
-		tok, _ := jwt.GenerateAccessToken(uuid.New(), "admin")
-		called := false
-		h := mw.RequireAdminAuth(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { called = true; w.WriteHeader(http.StatusOK) }))
+	db := testutil.NewMockDB()
+	mw := NewAuthMiddleware(jwt, db, logger)
+	adminID, memberID := uuid.New(), uuid.New()
+	db.RoleBindings = append(db.RoleBindings, &models.RoleBinding{
+		ID: uuid.New(), SubjectType: database.SubjectTypeUser, SubjectID: adminID,
+		RoleID: database.BuiltinRoles()[0].ID, ScopeType: database.ScopeTypeSystem, IsActive: true,
+	})
+	serve := func(h http.Handler, userID uuid.UUID) int {
+		tok, _ := jwt.GenerateAccessToken(userID, "someone")
 		req := httptest.NewRequest("GET", "/test", http.NoBody)
 		req.Header.Set("Authorization", "Bearer "+tok)
 		w := httptest.NewRecorder()
 		h.ServeHTTP(w, req)
-		if w.Code != http.StatusOK || !called {
-			t.Errorf("code=%d called=%v", w.Code, called)
+		return w.Code
+	}
+
+	t.Run("admin_role_passes", func(t *testing.T) {
+		var access *rbac.Access
+		h := mw.RequirePermission(rbac.PermJobsManage, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			access = rbac.FromContext(r.Context())
+			w.WriteHeader(http.StatusOK)
+		}))
+		if code := serve(h, adminID); code != http.StatusOK || access == nil || access.UserID != adminID {
+			t.Errorf("code=%d access=%+v", code, access)
 		}
 	})
 
-	t.Run("non_admin_forbidden", func(t *testing.T) {
-		tok, _ := jwt.GenerateAccessToken(uuid.New(), "otheruser")
-		h := mw.RequireAdminAuth(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { t.Error("should not run") }))
-		req := httptest.NewRequest("GET", "/test", http.NoBody)
-		req.Header.Set("Authorization", "Bearer "+tok)
-		w := httptest.NewRecorder()
-		h.ServeHTTP(w, req)
-		if w.Code != http.StatusForbidden {
-			t.Errorf("code=%d", w.Code)
+	t.Run("default_member_role", func(t *testing.T) {
+		ok := mw.RequirePermission(rbac.PermTasksWrite, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }))
+		if code := serve(ok, memberID); code != http.StatusOK {
+			t.Errorf("tasks.write: code=%d", code)
+		}
+		denied := mw.RequirePermission(rbac.PermJobsManage, http.HandlerFunc(func(http.ResponseWriter, *http.Request) { t.Error("should not run") }))
+		if code := serve(denied, memberID); code != http.StatusForbidden {
+			t.Errorf("jobs.manage: code=%d", code)
+		}
+	})
+
+	t.Run("no_store_forbidden", func(t *testing.T) {
+		h := NewAuthMiddleware(jwt, nil, logger).RequirePermission(rbac.PermTasksRead, http.HandlerFunc(func(http.ResponseWriter, *http.Request) { t.Error("should not run") }))
+		if code := serve(h, adminID); code != http.StatusForbidden {
+			t.Errorf("code=%d", code)
 		}
 	})
 }
 
+func TestAuthMiddleware_PersonalAccessToken(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	jwt := auth.NewJWTManager("test-secret", 15*time.Minute, 7*24*time.Hour, 24*time.Hour)
+	db := testutil.NewMockDB()
+	mw := NewAuthMiddleware(jwt, db, logger)
+	ctx := context.Background()
+	user, _ := db.CreateUser(ctx, "ci-bot", nil)
+	issue := func(scopes string, expiresAt *time.Time) (string, *models.PersonalAccessToken) {
+		token, prefix, err := auth.GeneratePersonalAccessToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		pat := &models.PersonalAccessToken{UserID: user.ID, Name: "ci", TokenHash: auth.HashToken(token), TokenPrefix: prefix,
+			Scopes: scopes, IsActive: true, ExpiresAt: expiresAt}
+		_ = db.CreatePersonalAccessToken(ctx, pat)
+		return token, pat
+	}
+	serve := func(perm, token string) int {
+		h := mw.RequirePermission(perm, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if handlers.GetHandleFromContext(r.Context()) != "ci-bot" {
+				t.Error("user not set from token owner")
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/test", http.NoBody)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	token, pat := issue("tasks:write", nil)
+	if code := serve(rbac.PermTasksWrite, token); code != http.StatusOK {
+		t.Errorf("tasks.write with tasks:write token: code=%d", code)
+	}
+	if pat.LastUsedAt == nil {
+		t.Error("last_used_at not recorded")
+	}
+	if code := serve(rbac.PermChatUse, token); code != http.StatusForbidden {
+		t.Errorf("chat.use with tasks:write token: code=%d", code)
+	}
+	readOnly, _ := issue("read-only", nil)
+	if code := serve(rbac.PermTasksWrite, readOnly); code != http.StatusForbidden {
+		t.Errorf("tasks.write with read-only token: code=%d", code)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	expired, _ := issue("all", &past)
+	if code := serve(rbac.PermTasksRead, expired); code != http.StatusUnauthorized {
+		t.Errorf("expired token: code=%d", code)
+	}
+	_ = db.RevokePersonalAccessToken(ctx, pat.ID)
+	if code := serve(rbac.PermTasksWrite, token); code != http.StatusUnauthorized {
+		t.Errorf("revoked token: code=%d", code)
+	}
+	active, _ := issue("all", nil)
+	user.IsActive = false
+	if code := serve(rbac.PermTasksRead, active); code != http.StatusUnauthorized {
+		t.Errorf("disabled owner: code=%d", code)
+	}
+}
+
 func TestRequireWorkflowRunnerAuth(t *testing.T) {
 	t.Run("empty_token_allows", func(t *testing.T) {
 		called := false
@@ -235,3 +321,113 @@ func TestExtractBearerToken(t *testing.T) {
 		})
 	}
 }
+
+func TestRequireSignedToken(t *testing.T) {
+	jwt := auth.NewJWTManager("test-secret", 15*time.Minute, 7*24*time.Hour, 24*time.Hour)
+	jwksSrv := httptest.NewServer(handlers.JWKS(jwt.Keyring()))
+	defer jwksSrv.Close()
+	mw := RequireSignedToken(auth.NewRemoteVerifier(jwksSrv.URL), nil, auth.TokenTypeAccess, auth.TokenTypeNode)
+
+	var gotUser *uuid.UUID
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser = handlers.GetUserIDFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+	userID := uuid.New()
+	access, _ := jwt.GenerateAccessToken(userID, "alice")
+	node, _, _ := jwt.GenerateNodeToken(uuid.New(), "node-1")
+	refresh, _, _ := jwt.GenerateRefreshToken(userID)
+	foreign, _ := auth.NewJWTManager("other-secret", time.Minute, time.Hour, time.Hour).GenerateAccessToken(userID, "alice")
+	for _, tc := range []struct {
+		name  string
+		token string
+		code  int
+	}{
+		{"access", access, http.StatusOK},
+		{"node", node, http.StatusOK},
+		{"refresh token type", refresh, http.StatusUnauthorized},
+		{"foreign key", foreign, http.StatusUnauthorized},
+		{"missing", "", http.StatusUnauthorized},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/v1/call", http.NoBody)
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != tc.code {
+				t.Fatalf("status = %d, want %d", w.Code, tc.code)
+			}
+		})
+	}
+	req := httptest.NewRequest("POST", "/v1/call", http.NoBody)
+	req.Header.Set("Authorization", "Bearer "+access)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if gotUser == nil || *gotUser != userID {
+		t.Errorf("user in context = %v, want %v", gotUser, userID)
+	}
+}
+
+func TestAuthMiddleware_SessionDenyList(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	jwt := auth.NewJWTManager("test-secret", 15*time.Minute, 7*24*time.Hour, 24*time.Hour)
+	db := testutil.NewMockDB()
+	mw := NewAuthMiddleware(jwt, db, logger)
+	ctx := context.Background()
+	user, _ := db.CreateUser(ctx, "alice", nil)
+	session := &models.RefreshSession{UserID: user.ID, RefreshTokenHash: []byte("h"), ExpiresAt: time.Now().Add(time.Hour)}
+	_ = db.CreateRefreshSession(ctx, session)
+	token, accessExpiresAt, _ := jwt.GenerateSessionAccessToken(user.ID, "alice", session.ID)
+	session.AccessExpiresAt = &accessExpiresAt
+
+	h := mw.RequireUserAuth(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }))
+	serve := func() int {
+		req := httptest.NewRequest("GET", "/test", http.NoBody)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := serve(); code != http.StatusOK {
+		t.Fatalf("active session: code=%d", code)
+	}
+	if err := db.InvalidateRefreshSession(ctx, session.ID); err != nil {
+		t.Fatal(err)
+	}
+	if code := serve(); code != http.StatusUnauthorized {
+		t.Errorf("revoked session: code=%d, want 401 before the access token expires", code)
+	}
+}
+
+func TestRequireSignedToken_SessionDenyList(t *testing.T) {
+	jwt := auth.NewJWTManager("test-secret", 15*time.Minute, 7*24*time.Hour, 24*time.Hour)
+	jwksSrv := httptest.NewServer(handlers.JWKS(jwt.Keyring()))
+	defer jwksSrv.Close()
+	db := testutil.NewMockDB()
+	ctx := context.Background()
+	user, _ := db.CreateUser(ctx, "alice", nil)
+	session := &models.RefreshSession{UserID: user.ID, RefreshTokenHash: []byte("h"), ExpiresAt: time.Now().Add(time.Hour)}
+	_ = db.CreateRefreshSession(ctx, session)
+	token, accessExpiresAt, _ := jwt.GenerateSessionAccessToken(user.ID, "alice", session.ID)
+	session.AccessExpiresAt = &accessExpiresAt
+
+	h := RequireSignedToken(auth.NewRemoteVerifier(jwksSrv.URL), db, auth.TokenTypeAccess)(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }))
+	serve := func() int {
+		req := httptest.NewRequest("POST", "/v1/call", http.NoBody)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := serve(); code != http.StatusOK {
+		t.Fatalf("active session: code=%d", code)
+	}
+	if err := db.InvalidateRefreshSession(ctx, session.ID); err != nil {
+		t.Fatal(err)
+	}
+	if code := serve(); code != http.StatusUnauthorized {
+		t.Errorf("revoked session: code=%d, want 401 before the access token expires", code)
+	}
+}
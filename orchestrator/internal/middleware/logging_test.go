@@ -46,3 +46,12 @@ func TestResponseWriter_WriteHeader(t *testing.T) {
 		t.Errorf("status = %d, want %d", rw.status, http.StatusNotFound)
 	}
 }
+
+func TestLogging_Flush(t *testing.T) {
+	handler := Logging(slog.New(slog.NewTextHandler(os.Stdout, nil)))(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("Flush through logging writer: %v", err)
+		}
+	}))
+	runWrapTest(t, handler, http.StatusOK)
+}
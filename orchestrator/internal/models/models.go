@@ -74,33 +74,40 @@ func (j *JSONBString) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-// User represents a system user.
+// User represents a system user. IsServiceAccount marks a non-human user for automation: it has no password and
+// authenticates only with personal access tokens issued by an admin.
 type User struct {
-	ID             uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
-	Handle         string    `gorm:"column:handle;uniqueIndex" json:"handle"`
-	Email          *string   `gorm:"column:email;uniqueIndex" json:"email,omitempty"`
-	IsActive       bool      `gorm:"column:is_active;index" json:"is_active"`
-	ExternalSource *string   `gorm:"column:external_source" json:"external_source,omitempty"`
-	ExternalID     *string   `gorm:"column:external_id" json:"external_id,omitempty"`
-	CreatedAt      time.Time `gorm:"column:created_at" json:"created_at"`
-	UpdatedAt      time.Time `gorm:"column:updated_at" json:"updated_at"`
+	ID               uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	Handle           string    `gorm:"column:handle;uniqueIndex" json:"handle"`
+	Email            *string   `gorm:"column:email;uniqueIndex" json:"email,omitempty"`
+	IsActive         bool      `gorm:"column:is_active;index" json:"is_active"`
+	ExternalSource   *string   `gorm:"column:external_source;uniqueIndex:uix_users_external,priority:1" json:"external_source,omitempty"`
+	ExternalID       *string   `gorm:"column:external_id;uniqueIndex:uix_users_external,priority:2" json:"external_id,omitempty"`
+	IsServiceAccount bool      `gorm:"column:is_service_account;not null;default:false" json:"is_service_account"`
+	CreatedAt        time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt        time.Time `gorm:"column:updated_at" json:"updated_at"`
 }
 
 func (User) TableName() string { return "users" }
 
-// PasswordCredential stores hashed password for a user.
+// PasswordCredential stores hashed password for a user. MustChange is set when an admin creates or resets the
+// password; login then requires a new password before issuing tokens.
 type PasswordCredential struct {
 	ID           uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
 	UserID       uuid.UUID `gorm:"column:user_id;uniqueIndex" json:"user_id"`
 	PasswordHash []byte    `gorm:"column:password_hash;type:bytea" json:"-"`
 	HashAlg      string    `gorm:"column:hash_alg" json:"hash_alg"`
+	MustChange   bool      `gorm:"column:must_change;not null;default:false" json:"must_change"`
 	CreatedAt    time.Time `gorm:"column:created_at" json:"created_at"`
 	UpdatedAt    time.Time `gorm:"column:updated_at" json:"updated_at"`
 }
 
 func (PasswordCredential) TableName() string { return "password_credentials" }
 
-// RefreshSession represents an active refresh token session.
+// RefreshSession represents a signed-in session. The row keeps its id across refresh token rotation, and access
+// tokens carry it as their sid claim. AccessExpiresAt is when the newest access token issued for the session
+// expires; a revoked session's access tokens stay on the deny-list until then. UserAgent and IPAddress are as
+// last seen at login or refresh.
 type RefreshSession struct {
 	ID               uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
 	UserID           uuid.UUID  `gorm:"column:user_id;index" json:"user_id"`
@@ -108,6 +115,10 @@ type RefreshSession struct {
 	RefreshTokenKID  *string    `gorm:"column:refresh_token_kid" json:"-"`
 	IsActive         bool       `gorm:"column:is_active" json:"is_active"`
 	ExpiresAt        time.Time  `gorm:"column:expires_at" json:"expires_at"`
+	AccessExpiresAt  *time.Time `gorm:"column:access_expires_at" json:"-"`
+	UserAgent        *string    `gorm:"column:user_agent" json:"user_agent,omitempty"`
+	IPAddress        *string    `gorm:"column:ip_address" json:"ip_address,omitempty"`
+	ClientName       *string    `gorm:"column:client_name" json:"client_name,omitempty"`
 	LastUsedAt       *time.Time `gorm:"column:last_used_at" json:"last_used_at,omitempty"`
 	CreatedAt        time.Time  `gorm:"column:created_at" json:"created_at"`
 	UpdatedAt        time.Time  `gorm:"column:updated_at" json:"updated_at"`
@@ -115,6 +126,37 @@ type RefreshSession struct {
 
 func (RefreshSession) TableName() string { return "refresh_sessions" }
 
+// AccessTokenDenial is a deny-list entry: access tokens whose sid is SessionID are rejected until ExpiresAt, when
+// the last of them has expired and the entry may be purged.
+type AccessTokenDenial struct {
+	SessionID uuid.UUID `gorm:"type:uuid;primaryKey" json:"session_id"`
+	UserID    uuid.UUID `gorm:"column:user_id;index" json:"user_id"`
+	ExpiresAt time.Time `gorm:"column:expires_at;index" json:"expires_at"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (AccessTokenDenial) TableName() string { return "access_token_denylist" }
+
+// PersonalAccessToken is a long-lived bearer token for automation, owned by a user or service account.
+// Only the SHA-256 hash is stored; TokenPrefix is the leading part of the token shown in listings.
+// Scopes is a space-separated list of token scopes that narrow what the owner's roles allow.
+type PersonalAccessToken struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID      uuid.UUID  `gorm:"column:user_id;index" json:"user_id"`
+	Name        string     `gorm:"column:name" json:"name"`
+	TokenHash   []byte     `gorm:"column:token_hash;type:bytea;uniqueIndex" json:"-"`
+	TokenPrefix string     `gorm:"column:token_prefix" json:"token_prefix"`
+	Scopes      string     `gorm:"column:scopes" json:"scopes"`
+	IsActive    bool       `gorm:"column:is_active;index" json:"is_active"`
+	ExpiresAt   *time.Time `gorm:"column:expires_at" json:"expires_at,omitempty"`
+	LastUsedAt  *time.Time `gorm:"column:last_used_at" json:"last_used_at,omitempty"`
+	CreatedBy   *uuid.UUID `gorm:"column:created_by" json:"created_by,omitempty"`
+	CreatedAt   time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (PersonalAccessToken) TableName() string { return "personal_access_tokens" }
+
 // AuthAuditLog records authentication events.
 // Schema: subject_handle, reason, ip_address (inet); no details column.
 type AuthAuditLog struct {
@@ -168,6 +210,7 @@ type Task struct {
 	CreatedBy          *uuid.UUID `gorm:"column:created_by;index" json:"created_by,omitempty"`
 	ProjectID          *uuid.UUID `gorm:"column:project_id;index" json:"project_id,omitempty"`
 	PlanID             *uuid.UUID `gorm:"column:plan_id;index" json:"plan_id,omitempty"`
+	TaskType           *string    `gorm:"column:task_type" json:"task_type,omitempty"`
 	Status             string     `gorm:"column:status;index" json:"status"`
 	Closed             bool       `gorm:"column:closed;index" json:"closed"`
 	Prompt             *string    `gorm:"column:prompt" json:"prompt,omitempty"`
@@ -180,16 +223,29 @@ type Task struct {
 
 func (Task) TableName() string { return "tasks" }
 
-// ProjectPlan represents a project plan (postgres_schema.md project_plans). Used by workflow start gate.
+// ProjectPlan represents a project plan (postgres_schema.md project_plans). Used by workflow start gate
+// and plan execution (tasks with plan_id run in task_dependencies order).
 type ProjectPlan struct {
-	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
-	ProjectID uuid.UUID `gorm:"column:project_id;index" json:"project_id"`
-	State     string    `gorm:"column:state;index" json:"state"`
-	Archived  bool      `gorm:"column:archived;index" json:"archived"`
-	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
-	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	ProjectID uuid.UUID  `gorm:"column:project_id;index" json:"project_id"`
+	PlanName  *string    `gorm:"column:plan_name" json:"plan_name,omitempty"`
+	State     string     `gorm:"column:state;index" json:"state"`
+	Archived  bool       `gorm:"column:archived;index" json:"archived"`
+	CreatedBy *uuid.UUID `gorm:"column:created_by" json:"created_by,omitempty"`
+	CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time  `gorm:"column:updated_at" json:"updated_at"`
 }
 
+// PlanState constants (postgres_schema.md project_plans.state).
+const (
+	PlanStateDraft     = "draft"
+	PlanStateReady     = "ready"
+	PlanStateActive    = "active"
+	PlanStateSuspended = "suspended"
+	PlanStateCompleted = "completed"
+	PlanStateCanceled  = "canceled"
+)
+
 func (ProjectPlan) TableName() string { return "project_plans" }
 
 // TaskDependency represents task_dependencies (task_id depends on depends_on_task_id).
@@ -203,23 +259,44 @@ func (TaskDependency) TableName() string { return "task_dependencies" }
 
 // Job represents a unit of work dispatched to a node.
 // Payload and Result are stored as jsonb via JSONBString.
+// SchedulingReason records why the dispatcher picked NodeID (score and deciding factors).
+// Attempts counts dispatch attempts; a job requeued for retry is not claimable before NextAttemptAt.
 type Job struct {
-	ID             uuid.UUID   `gorm:"type:uuid;primaryKey" json:"id"`
-	TaskID         uuid.UUID   `gorm:"column:task_id;index" json:"task_id"`
-	NodeID         *uuid.UUID  `gorm:"column:node_id;index" json:"node_id,omitempty"`
-	Status         string      `gorm:"column:status;index" json:"status"`
-	Payload        JSONBString `gorm:"column:payload;type:jsonb" json:"payload,omitempty"`
-	Result         JSONBString `gorm:"column:result;type:jsonb" json:"result,omitempty"`
-	LeaseID        *uuid.UUID  `gorm:"column:lease_id" json:"lease_id,omitempty"`
-	LeaseExpiresAt *time.Time  `gorm:"column:lease_expires_at" json:"lease_expires_at,omitempty"`
-	StartedAt      *time.Time  `gorm:"column:started_at" json:"started_at,omitempty"`
-	EndedAt        *time.Time  `gorm:"column:ended_at" json:"ended_at,omitempty"`
-	CreatedAt      time.Time   `gorm:"column:created_at" json:"created_at"`
-	UpdatedAt      time.Time   `gorm:"column:updated_at" json:"updated_at"`
+	ID               uuid.UUID   `gorm:"type:uuid;primaryKey" json:"id"`
+	TaskID           uuid.UUID   `gorm:"column:task_id;index" json:"task_id"`
+	NodeID           *uuid.UUID  `gorm:"column:node_id;index" json:"node_id,omitempty"`
+	Status           string      `gorm:"column:status;index" json:"status"`
+	Payload          JSONBString `gorm:"column:payload;type:jsonb" json:"payload,omitempty"`
+	Result           JSONBString `gorm:"column:result;type:jsonb" json:"result,omitempty"`
+	LeaseID          *uuid.UUID  `gorm:"column:lease_id" json:"lease_id,omitempty"`
+	LeaseExpiresAt   *time.Time  `gorm:"column:lease_expires_at" json:"lease_expires_at,omitempty"`
+	SchedulingReason *string     `gorm:"column:scheduling_reason" json:"scheduling_reason,omitempty"`
+	Attempts         int         `gorm:"column:attempts;default:0" json:"attempts"`
+	NextAttemptAt    *time.Time  `gorm:"column:next_attempt_at" json:"next_attempt_at,omitempty"`
+	StartedAt        *time.Time  `gorm:"column:started_at" json:"started_at,omitempty"`
+	EndedAt          *time.Time  `gorm:"column:ended_at" json:"ended_at,omitempty"`
+	CreatedAt        time.Time   `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt        time.Time   `gorm:"column:updated_at" json:"updated_at"`
 }
 
 func (Job) TableName() string { return "jobs" }
 
+// JobAttempt records one dispatch attempt of a job (job_attempts): the node it ran on, its outcome,
+// and, for failures, the failure class used by the retry policy.
+type JobAttempt struct {
+	ID           uuid.UUID   `gorm:"type:uuid;primaryKey" json:"id"`
+	JobID        uuid.UUID   `gorm:"column:job_id;index" json:"job_id"`
+	Attempt      int         `gorm:"column:attempt" json:"attempt"`
+	NodeID       *uuid.UUID  `gorm:"column:node_id" json:"node_id,omitempty"`
+	Status       string      `gorm:"column:status" json:"status"`
+	FailureClass *string     `gorm:"column:failure_class" json:"failure_class,omitempty"`
+	Result       JSONBString `gorm:"column:result;type:jsonb" json:"result,omitempty"`
+	StartedAt    *time.Time  `gorm:"column:started_at" json:"started_at,omitempty"`
+	EndedAt      time.Time   `gorm:"column:ended_at" json:"ended_at"`
+}
+
+func (JobAttempt) TableName() string { return "job_attempts" }
+
 // TaskStatus constants.
 const (
 	TaskStatusPending    = "pending"
@@ -238,6 +315,8 @@ const (
 	JobStatusFailed       = "failed"
 	JobStatusCanceled     = "canceled"
 	JobStatusLeaseExpired = "lease_expired"
+	// JobStatusDeadLettered is terminal: the job exhausted its retry policy and waits for an operator requeue.
+	JobStatusDeadLettered = "dead_lettered"
 )
 
 // NodeStatus constants.
@@ -267,6 +346,10 @@ type McpToolCallAuditLog struct {
 	Status      string     `gorm:"column:status" json:"status"`     // success or error
 	DurationMs  *int       `gorm:"column:duration_ms" json:"duration_ms,omitempty"`
 	ErrorType   *string    `gorm:"column:error_type" json:"error_type,omitempty"`
+	// Arguments is the tool arguments with secrets redacted and large strings omitted; RedactionKinds lists
+	// what was redacted.
+	Arguments      *string `gorm:"column:arguments;type:jsonb" json:"arguments,omitempty"`
+	RedactionKinds *string `gorm:"column:redaction_kinds;type:jsonb" json:"redaction_kinds,omitempty"`
 }
 
 func (McpToolCallAuditLog) TableName() string { return "mcp_tool_call_audit_log" }
@@ -315,6 +398,25 @@ type Project struct {
 
 func (Project) TableName() string { return "projects" }
 
+// ProjectGitRepo associates a Git repository with a project (postgres_schema.md project_git_repos). A project's
+// rows are the Git egress allowlist for its tasks (project_git_repos.md). RepoIdentifier is owner/repo (GitHub,
+// Gitea) or the full project path (GitLab); BaseURL is set for self-hosted instances.
+type ProjectGitRepo struct {
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	ProjectID      uuid.UUID `gorm:"column:project_id;uniqueIndex:idx_project_git_repos_repo" json:"project_id"`
+	Provider       string    `gorm:"column:provider;uniqueIndex:idx_project_git_repos_repo" json:"provider"`
+	RepoIdentifier string    `gorm:"column:repo_identifier;uniqueIndex:idx_project_git_repos_repo" json:"repo_identifier"`
+	BaseURL        *string   `gorm:"column:base_url" json:"base_url,omitempty"`
+	DisplayName    *string   `gorm:"column:display_name" json:"display_name,omitempty"`
+	Description    *string   `gorm:"column:description" json:"description,omitempty"`
+	Tags           *string   `gorm:"column:tags;type:jsonb" json:"tags,omitempty"`
+	Metadata       *string   `gorm:"column:metadata;type:jsonb" json:"metadata,omitempty"`
+	CreatedAt      time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (ProjectGitRepo) TableName() string { return "project_git_repos" }
+
 // Session represents a user session (see postgres_schema.md Sessions).
 type Session struct {
 	ID              uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
@@ -458,6 +560,7 @@ type Skill struct {
 	Content   string     `gorm:"column:content;type:text" json:"content"`
 	Scope     string     `gorm:"column:scope;index" json:"scope"` // user, group, project, global
 	OwnerID   *uuid.UUID `gorm:"column:owner_id;index" json:"owner_id,omitempty"`
+	GroupID   *uuid.UUID `gorm:"column:group_id;index" json:"group_id,omitempty"` // set when scope is group
 	IsSystem  bool       `gorm:"column:is_system;index" json:"is_system"`
 	CreatedAt time.Time  `gorm:"column:created_at;index" json:"created_at"`
 	UpdatedAt time.Time  `gorm:"column:updated_at;index" json:"updated_at"`
@@ -499,6 +602,87 @@ type AccessControlAuditLog struct {
 
 func (AccessControlAuditLog) TableName() string { return "access_control_audit_log" }
 
+// AuditEvent is one row of the unified, read-only audit view over the auth, MCP tool call, access control,
+// chat, and preference audit logs (see database.SearchAuditEvents). It has no table of its own.
+type AuditEvent struct {
+	ID           uuid.UUID  `gorm:"column:event_id" json:"event_id"`
+	Source       string     `gorm:"column:source" json:"source"`
+	CreatedAt    time.Time  `gorm:"column:ts" json:"ts"`
+	ActorID      *uuid.UUID `gorm:"column:actor_id" json:"actor_id,omitempty"`
+	ActorHandle  *string    `gorm:"column:actor_handle" json:"actor_handle,omitempty"`
+	TaskID       *uuid.UUID `gorm:"column:task_id" json:"task_id,omitempty"`
+	ProjectID    *uuid.UUID `gorm:"column:project_id" json:"project_id,omitempty"`
+	Action       string     `gorm:"column:action" json:"action"`
+	ResourceType string     `gorm:"column:resource_type" json:"resource_type"`
+	ResourceID   string     `gorm:"column:resource_id" json:"resource_id"`
+	Decision     string     `gorm:"column:decision" json:"decision"` // allow | deny
+	Details      *string    `gorm:"column:details" json:"details,omitempty"`
+}
+
+// Group is a set of users. Per docs/tech_specs/rbac_and_groups.md (Groups Table).
+type Group struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	Slug           string     `gorm:"column:slug;uniqueIndex" json:"slug"`
+	DisplayName    string     `gorm:"column:display_name" json:"display_name"`
+	IsActive       bool       `gorm:"column:is_active;index" json:"is_active"`
+	ExternalSource *string    `gorm:"column:external_source" json:"external_source,omitempty"`
+	ExternalID     *string    `gorm:"column:external_id" json:"external_id,omitempty"`
+	ManagedBy      *string    `gorm:"column:managed_by" json:"managed_by,omitempty"` // local | external_sync
+	LastSyncedAt   *time.Time `gorm:"column:last_synced_at" json:"last_synced_at,omitempty"`
+	CreatedAt      time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"column:updated_at" json:"updated_at"`
+	UpdatedBy      *string    `gorm:"column:updated_by" json:"updated_by,omitempty"`
+}
+
+func (Group) TableName() string { return "groups" }
+
+// GroupMembership links a user to a group. Removal sets IsActive false so history is kept.
+// Unique on (group_id, user_id).
+type GroupMembership struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	GroupID        uuid.UUID  `gorm:"column:group_id;uniqueIndex:uix_group_membership,priority:1;index" json:"group_id"`
+	UserID         uuid.UUID  `gorm:"column:user_id;uniqueIndex:uix_group_membership,priority:2;index" json:"user_id"`
+	IsActive       bool       `gorm:"column:is_active;index" json:"is_active"`
+	ExternalSource *string    `gorm:"column:external_source" json:"external_source,omitempty"`
+	ExternalID     *string    `gorm:"column:external_id" json:"external_id,omitempty"`
+	ManagedBy      *string    `gorm:"column:managed_by" json:"managed_by,omitempty"`
+	LastSyncedAt   *time.Time `gorm:"column:last_synced_at" json:"last_synced_at,omitempty"`
+	CreatedAt      time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"column:updated_at" json:"updated_at"`
+	UpdatedBy      *string    `gorm:"column:updated_by" json:"updated_by,omitempty"`
+}
+
+func (GroupMembership) TableName() string { return "group_memberships" }
+
+// Role is a named set of permissions. Per docs/tech_specs/rbac_and_groups.md (Roles Table).
+type Role struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	Name        string    `gorm:"column:name;uniqueIndex" json:"name"`
+	Description *string   `gorm:"column:description" json:"description,omitempty"`
+	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (Role) TableName() string { return "roles" }
+
+// RoleBinding assigns a role to a user or group within a scope (system, or project with ScopeID set).
+type RoleBinding struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	SubjectType  string     `gorm:"column:subject_type;index:idx_role_bindings_subject" json:"subject_type"` // user | group
+	SubjectID    uuid.UUID  `gorm:"column:subject_id;index:idx_role_bindings_subject" json:"subject_id"`
+	RoleID       uuid.UUID  `gorm:"column:role_id;index" json:"role_id"`
+	ScopeType    string     `gorm:"column:scope_type;index:idx_role_bindings_scope" json:"scope_type"` // system | project
+	ScopeID      *uuid.UUID `gorm:"column:scope_id;index:idx_role_bindings_scope" json:"scope_id,omitempty"`
+	IsActive     bool       `gorm:"column:is_active;index" json:"is_active"`
+	ManagedBy    *string    `gorm:"column:managed_by" json:"managed_by,omitempty"`
+	LastSyncedAt *time.Time `gorm:"column:last_synced_at" json:"last_synced_at,omitempty"`
+	CreatedAt    time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"column:updated_at" json:"updated_at"`
+	UpdatedBy    *string    `gorm:"column:updated_by" json:"updated_by,omitempty"`
+}
+
+func (RoleBinding) TableName() string { return "role_bindings" }
+
 // ApiCredential stores an encrypted API credential for egress. Per docs/tech_specs/postgres_schema.md (API Credentials Table).
 type ApiCredential struct {
 	ID                   uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
@@ -517,3 +701,81 @@ type ApiCredential struct {
 }
 
 func (ApiCredential) TableName() string { return "api_credentials" }
+
+// ApiEgressLimit throttles API Egress calls to a provider (and optionally one operation; "" is every operation).
+// ScopeType system with no ScopeID is one shared bucket; user or project with ScopeID applies to that subject only;
+// user or project without ScopeID gives every user or project its own bucket. Zero limits are unlimited.
+// Spend is an estimate: CostPerCallMicroUSD is charged per allowed call against MonthlyBudgetMicroUSD.
+type ApiEgressLimit struct {
+	ID                    uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	Provider              string     `gorm:"column:provider;index:idx_api_egress_limits_target" json:"provider"`
+	Operation             string     `gorm:"column:operation;index:idx_api_egress_limits_target" json:"operation"`
+	ScopeType             string     `gorm:"column:scope_type" json:"scope_type"` // system | user | project
+	ScopeID               *uuid.UUID `gorm:"column:scope_id" json:"scope_id,omitempty"`
+	RequestsPerMinute     int        `gorm:"column:requests_per_minute" json:"requests_per_minute"`
+	MaxConcurrent         int        `gorm:"column:max_concurrent" json:"max_concurrent"`
+	MonthlyBudgetMicroUSD int64      `gorm:"column:monthly_budget_micro_usd" json:"monthly_budget_micro_usd"`
+	CostPerCallMicroUSD   int64      `gorm:"column:cost_per_call_micro_usd" json:"cost_per_call_micro_usd"`
+	CreatedAt             time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt             time.Time  `gorm:"column:updated_at" json:"updated_at"`
+	UpdatedBy             *string    `gorm:"column:updated_by" json:"updated_by,omitempty"`
+}
+
+func (ApiEgressLimit) TableName() string { return "api_egress_limits" }
+
+// ApiEgressUsage counts the calls and estimated spend of one limit bucket in one period (minute or month).
+// Bucket is "" for shared buckets, else the user or project id.
+type ApiEgressUsage struct {
+	LimitID       uuid.UUID `gorm:"type:uuid;primaryKey;column:limit_id" json:"limit_id"`
+	Bucket        string    `gorm:"primaryKey;column:bucket" json:"bucket"`
+	Period        string    `gorm:"primaryKey;column:period" json:"period"` // minute | month
+	PeriodStart   time.Time `gorm:"primaryKey;column:period_start" json:"period_start"`
+	Calls         int64     `gorm:"column:calls" json:"calls"`
+	SpendMicroUSD int64     `gorm:"column:spend_micro_usd" json:"spend_micro_usd"`
+}
+
+func (ApiEgressUsage) TableName() string { return "api_egress_usage" }
+
+// ApiEgressInflight holds one concurrency slot of a limit bucket for a call in progress. Slots are released when
+// the call ends; ExpiresAt bounds a slot whose holder died.
+type ApiEgressInflight struct {
+	LeaseID   uuid.UUID `gorm:"type:uuid;primaryKey;column:lease_id" json:"lease_id"`
+	LimitID   uuid.UUID `gorm:"type:uuid;primaryKey;column:limit_id;index:idx_api_egress_inflight_bucket" json:"limit_id"`
+	Bucket    string    `gorm:"column:bucket;index:idx_api_egress_inflight_bucket" json:"bucket"`
+	ExpiresAt time.Time `gorm:"column:expires_at;index" json:"expires_at"`
+}
+
+func (ApiEgressInflight) TableName() string { return "api_egress_inflight" }
+
+// TokenUsage records the model tokens one inference consumer used (token_usage): a chat turn, a PMA turn,
+// a prompt-mode task, or a sandbox job. Written by the gateway and dispatcher; summed for quotas and GET /v1/usage.
+type TokenUsage struct {
+	ID               uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	OccurredAt       time.Time  `gorm:"column:occurred_at;index" json:"occurred_at"`
+	UserID           *uuid.UUID `gorm:"column:user_id;index" json:"user_id,omitempty"`
+	ProjectID        *uuid.UUID `gorm:"column:project_id;index" json:"project_id,omitempty"`
+	TaskID           *uuid.UUID `gorm:"column:task_id;index" json:"task_id,omitempty"`
+	JobID            *uuid.UUID `gorm:"column:job_id" json:"job_id,omitempty"`
+	Model            string     `gorm:"column:model" json:"model"`
+	Source           string     `gorm:"column:source" json:"source"` // TokenUsageSource*
+	PromptTokens     int64      `gorm:"column:prompt_tokens" json:"prompt_tokens"`
+	CompletionTokens int64      `gorm:"column:completion_tokens" json:"completion_tokens"`
+	TotalTokens      int64      `gorm:"column:total_tokens" json:"total_tokens"`
+}
+
+func (TokenUsage) TableName() string { return "token_usage" }
+
+// TokenUsage source constants.
+const (
+	TokenUsageSourceChat = "chat" // OpenAI-compatible chat, direct inference or PMA
+	TokenUsageSourceTask = "task" // prompt-mode task answered by orchestrator inference
+	TokenUsageSourceJob  = "job"  // sandbox or SBA job, counted by the worker inference proxy
+)
+
+// TokenUsageSummary is the token usage of one model summed over a time window (not a table).
+type TokenUsageSummary struct {
+	Model            string `json:"model"`
+	PromptTokens     int64  `json:"prompt_tokens"`
+	CompletionTokens int64  `json:"completion_tokens"`
+	TotalTokens      int64  `json:"total_tokens"`
+}
@@ -31,6 +31,7 @@ func TestJobStatusConstants(t *testing.T) {
 		JobStatusFailed,
 		JobStatusCanceled,
 		JobStatusLeaseExpired,
+		JobStatusDeadLettered,
 	}
 
 	for _, s := range statuses {
@@ -224,6 +225,7 @@ func TestTableNames(t *testing.T) {
 		{"NodeCapability", (NodeCapability{}).TableName(), "node_capabilities"},
 		{"Task", (Task{}).TableName(), "tasks"},
 		{"Job", (Job{}).TableName(), "jobs"},
+		{"JobAttempt", (JobAttempt{}).TableName(), "job_attempts"},
 		{"Project", (Project{}).TableName(), "projects"},
 		{"Session", (Session{}).TableName(), "sessions"},
 		{"ChatThread", (ChatThread{}).TableName(), "chat_threads"},
@@ -241,6 +243,7 @@ func TestTableNames(t *testing.T) {
 		{"AccessControlRule", (AccessControlRule{}).TableName(), "access_control_rules"},
 		{"AccessControlAuditLog", (AccessControlAuditLog{}).TableName(), "access_control_audit_log"},
 		{"ApiCredential", (ApiCredential{}).TableName(), "api_credentials"},
+		{"TokenUsage", (TokenUsage{}).TableName(), "token_usage"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -5,9 +5,11 @@ package nodetelemetry
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
@@ -37,6 +39,43 @@ func (c *Client) PullNodeStats(ctx context.Context, baseURL, bearer string) ([]b
 	return c.get(ctx, baseURL, "/v1/worker/telemetry/node:stats", bearer)
 }
 
+// JobLogSourcePrefix prefixes the log_event source_name under which a worker records a job's container output.
+const JobLogSourcePrefix = "job-"
+
+// LogEvent is one event from GET /v1/worker/telemetry/logs.
+type LogEvent struct {
+	OccurredAt string            `json:"occurred_at"`
+	Stream     string            `json:"stream,omitempty"`
+	Message    string            `json:"message"`
+	Fields     map[string]string `json:"fields"`
+}
+
+// LogsPage is one page of GET /v1/worker/telemetry/logs.
+type LogsPage struct {
+	Events        []LogEvent `json:"events"`
+	NextPageToken string     `json:"next_page_token,omitempty"`
+}
+
+// QueryJobLogs GETs the container output a worker recorded for jobID, starting at pageToken
+// (an event offset; empty for the first event).
+func (c *Client) QueryJobLogs(ctx context.Context, baseURL, bearer, jobID, pageToken string) (*LogsPage, error) {
+	q := url.Values{}
+	q.Set("source_kind", "container")
+	q.Set("source_name", JobLogSourcePrefix+jobID)
+	if pageToken != "" {
+		q.Set("page_token", pageToken)
+	}
+	body, err := c.get(ctx, baseURL, "/v1/worker/telemetry/logs?"+q.Encode(), bearer)
+	if err != nil {
+		return nil, err
+	}
+	var page LogsPage
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, fmt.Errorf("telemetry logs decode: %w", err)
+	}
+	return &page, nil
+}
+
 func (c *Client) get(ctx context.Context, baseURL, path, bearer string) ([]byte, error) {
 	baseURL = strings.TrimSuffix(baseURL, "/")
 	url := baseURL + path
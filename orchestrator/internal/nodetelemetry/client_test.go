@@ -140,3 +140,22 @@ func TestPullNodeInfo_BaseURLWithTrailingSlash(t *testing.T) {
 		t.Fatalf("PullNodeInfo with trailing slash: %v", err)
 	}
 }
+
+func TestQueryJobLogs(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/v1/worker/telemetry/logs" || q.Get("source_kind") != "container" || q.Get("source_name") != "job-j1" || q.Get("page_token") != "2" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+		_, _ = w.Write([]byte(`{"version":1,"events":[{"occurred_at":"2026-01-01T00:00:00Z","stream":"stderr","message":"boom","fields":{}}],"next_page_token":"3"}`))
+	}))
+	defer srv.Close()
+
+	page, err := NewClient().QueryJobLogs(context.Background(), srv.URL, "tok", "j1", "2")
+	if err != nil {
+		t.Fatalf("QueryJobLogs: %v", err)
+	}
+	if len(page.Events) != 1 || page.Events[0].Stream != "stderr" || page.Events[0].Message != "boom" || page.NextPageToken != "3" {
+		t.Errorf("page = %+v", page)
+	}
+}
@@ -0,0 +1,116 @@
+package oidc
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidIDToken wraps every ID token validation failure.
+var ErrInvalidIDToken = errors.New("invalid id token")
+
+// clockSkew is the leeway allowed on exp, iat, and nbf.
+const clockSkew = time.Minute
+
+// signingMethods are the asymmetric algorithms accepted on ID tokens. HMAC is excluded: a token signed with
+// the client secret proves nothing the gateway could not forge itself.
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// Identity is the signed-in user as asserted by a validated ID token.
+type Identity struct {
+	Issuer            string
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+	// Groups lists the values of the configured groups claim; nil when the token does not carry the claim,
+	// which callers treat as "unknown" rather than "no groups".
+	Groups []string
+}
+
+// Verify validates an ID token: signature against the provider's JWKS, issuer, audience (and azp when
+// present), expiry, and, when nonce is not empty, the nonce claim. It returns the asserted identity.
+func (p *Provider) Verify(ctx context.Context, raw, nonce string) (*Identity, error) {
+	meta, err := p.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	keys := p.keys
+	p.mu.Unlock()
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockSkew),
+	)
+	claims := jwt.MapClaims{}
+	_, err = parser.ParseWithClaims(raw, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return keys.Key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if err := p.checkClaims(claims, nonce); err != nil {
+		return nil, err
+	}
+	return p.identity(claims), nil
+}
+
+func (p *Provider) checkClaims(claims jwt.MapClaims, nonce string) error {
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return fmt.Errorf("%w: missing sub", ErrInvalidIDToken)
+	}
+	if azp, ok := claims["azp"].(string); ok && azp != p.cfg.ClientID {
+		return fmt.Errorf("%w: azp %q is not this client", ErrInvalidIDToken, azp)
+	}
+	if nonce != "" {
+		got, _ := claims["nonce"].(string)
+		if subtle.ConstantTimeCompare([]byte(got), []byte(nonce)) != 1 {
+			return fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+		}
+	}
+	return nil
+}
+
+func (p *Provider) identity(claims jwt.MapClaims) *Identity {
+	str := func(name string) string {
+		s, _ := claims[name].(string)
+		return s
+	}
+	verified, _ := claims["email_verified"].(bool)
+	return &Identity{
+		Issuer:            str("iss"),
+		Subject:           str("sub"),
+		Email:             str("email"),
+		EmailVerified:     verified,
+		Name:              str("name"),
+		PreferredUsername: str("preferred_username"),
+		Groups:            stringList(claims[p.cfg.GroupsClaim]),
+	}
+}
+
+// stringList reads a claim that is either a list of strings or a single string; other values are ignored.
+func stringList(v any) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		out := make([]string, 0, len(t))
+		for _, item := range t {
+			if s, ok := item.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
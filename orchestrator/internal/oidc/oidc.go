@@ -0,0 +1,290 @@
+// Package oidc is the OpenID Connect relying party behind single sign-on at the User API Gateway: provider
+// discovery, ID token validation against the provider's JWKS, the authorization code flow with PKCE, and the
+// device authorization grant (RFC 8628) used by terminal clients.
+// See docs/tech_specs/local_user_accounts.md#spec-cynai-identy-externalidpsso.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/jwks"
+)
+
+// Defaults applied by New.
+const (
+	DefaultGroupsClaim = "groups"
+	defaultTimeout     = 15 * time.Second
+	maxResponseBytes   = 1 << 20
+)
+
+// DefaultScopes are requested when Config.Scopes is empty.
+var DefaultScopes = []string{"openid", "profile", "email"}
+
+// Device flow outcomes reported by the token endpoint (RFC 8628 section 3.5).
+var (
+	ErrAuthorizationPending = errors.New("authorization pending")
+	ErrSlowDown             = errors.New("slow down")
+	ErrAccessDenied         = errors.New("access denied")
+	ErrExpiredToken         = errors.New("device code expired")
+)
+
+// ErrDeviceFlowUnsupported is returned when the provider does not advertise a device authorization endpoint.
+var ErrDeviceFlowUnsupported = errors.New("identity provider does not support the device authorization grant")
+
+// Config identifies the provider and this client's registration with it.
+type Config struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string // empty for a public client
+	RedirectURL  string // the gateway's authorization code callback; unused by the device flow
+	Scopes       []string
+	GroupsClaim  string // ID token claim listing the user's groups
+	HTTPClient   *http.Client
+}
+
+// Metadata is the subset of the provider's discovery document the relying party uses.
+type Metadata struct {
+	Issuer                           string   `json:"issuer"`
+	AuthorizationEndpoint            string   `json:"authorization_endpoint"`
+	TokenEndpoint                    string   `json:"token_endpoint"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	DeviceAuthorizationEndpoint      string   `json:"device_authorization_endpoint,omitempty"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported,omitempty"`
+}
+
+// DeviceAuthorization is the provider's answer to a device authorization request.
+type DeviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval,omitempty"`
+}
+
+// Provider talks to one OpenID provider. Discovery runs on first use and is retried until it succeeds, so the
+// gateway starts even when the provider is briefly unreachable. Safe for concurrent use.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu   sync.Mutex
+	meta *Metadata
+	keys *jwks.KeySet
+}
+
+// New returns a Provider for cfg. IssuerURL and ClientID are required.
+func New(cfg Config) (*Provider, error) {
+	if cfg.IssuerURL == "" || cfg.ClientID == "" {
+		return nil, errors.New("oidc: issuer URL and client id are required")
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = DefaultScopes
+	}
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = DefaultGroupsClaim
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+	return &Provider{cfg: cfg, client: client}, nil
+}
+
+// Issuer returns the configured issuer URL, which identifies linked accounts (users.external_source).
+func (p *Provider) Issuer() string { return p.cfg.IssuerURL }
+
+// Metadata returns the discovery document, fetching it from the issuer's
+// /.well-known/openid-configuration on first use.
+func (p *Provider) Metadata(ctx context.Context) (*Metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.meta != nil {
+		return p.meta, nil
+	}
+	discoveryURL := strings.TrimSuffix(p.cfg.IssuerURL, "/") + "/.well-known/openid-configuration"
+	var meta Metadata
+	if err := p.getJSON(ctx, discoveryURL, &meta); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if meta.Issuer != p.cfg.IssuerURL {
+		return nil, fmt.Errorf("oidc discovery: issuer %q does not match configured %q", meta.Issuer, p.cfg.IssuerURL)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("oidc discovery: authorization, token, or jwks endpoint missing")
+	}
+	p.meta = &meta
+	p.keys = jwks.NewKeySet(meta.JWKSURI, p.getJSON)
+	return p.meta, nil
+}
+
+// AuthCodeURL returns the provider URL that starts an authorization code flow bound to state and nonce, with
+// the PKCE S256 challenge for verifier.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	if p.cfg.RedirectURL == "" {
+		return "", errors.New("oidc: redirect URL is not configured")
+	}
+	meta, err := p.Metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(meta.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("oidc: authorization endpoint: %w", err)
+	}
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", p.cfg.RedirectURL)
+	q.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", Challenge(verifier))
+	q.Set("code_challenge_method", "S256")
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// Exchange redeems an authorization code and returns the identity from the validated ID token, whose nonce
+// must match.
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Identity, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {verifier},
+	}
+	idToken, err := p.token(ctx, form)
+	if err != nil {
+		return nil, err
+	}
+	return p.Verify(ctx, idToken, nonce)
+}
+
+// StartDevice requests a device and user code for a terminal sign-in.
+func (p *Provider) StartDevice(ctx context.Context) (*DeviceAuthorization, error) {
+	meta, err := p.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if meta.DeviceAuthorizationEndpoint == "" {
+		return nil, ErrDeviceFlowUnsupported
+	}
+	var out DeviceAuthorization
+	form := url.Values{"scope": {strings.Join(p.cfg.Scopes, " ")}}
+	if err := p.postForm(ctx, meta.DeviceAuthorizationEndpoint, form, &out); err != nil {
+		return nil, fmt.Errorf("oidc device authorization: %w", err)
+	}
+	if out.DeviceCode == "" || out.UserCode == "" || out.VerificationURI == "" {
+		return nil, errors.New("oidc device authorization: incomplete response")
+	}
+	return &out, nil
+}
+
+// PollDevice redeems a device code. Until the user finishes signing in it returns ErrAuthorizationPending or
+// ErrSlowDown; ErrAccessDenied and ErrExpiredToken end the flow.
+func (p *Provider) PollDevice(ctx context.Context, deviceCode string) (*Identity, error) {
+	form := url.Values{
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		"device_code": {deviceCode},
+	}
+	idToken, err := p.token(ctx, form)
+	if err != nil {
+		return nil, err
+	}
+	return p.Verify(ctx, idToken, "")
+}
+
+// tokenResponse is a token endpoint response; error responses share the shape (RFC 6749 section 5.2).
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+var deviceErrors = map[string]error{
+	"authorization_pending": ErrAuthorizationPending,
+	"slow_down":             ErrSlowDown,
+	"access_denied":         ErrAccessDenied,
+	"expired_token":         ErrExpiredToken,
+}
+
+// token calls the token endpoint and returns the ID token.
+func (p *Provider) token(ctx context.Context, form url.Values) (string, error) {
+	meta, err := p.Metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+	var out tokenResponse
+	err = p.postForm(ctx, meta.TokenEndpoint, form, &out)
+	if known, ok := deviceErrors[out.Error]; ok {
+		return "", known
+	}
+	if out.Error != "" {
+		return "", fmt.Errorf("oidc token: %s: %s", out.Error, out.ErrorDescription)
+	}
+	if err != nil {
+		return "", fmt.Errorf("oidc token: %w", err)
+	}
+	if out.IDToken == "" {
+		return "", errors.New("oidc token: response has no id_token")
+	}
+	return out.IDToken, nil
+}
+
+// postForm posts form with the client's credentials (client_secret_basic, or client_id alone for a public
+// client) and decodes the JSON response into out, including error bodies.
+func (p *Provider) postForm(ctx context.Context, endpoint string, form url.Values, out any) error {
+	if p.cfg.ClientSecret == "" {
+		form.Set("client_id", p.cfg.ClientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+	return p.do(req, out)
+}
+
+func (p *Provider) getJSON(ctx context.Context, endpoint string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	return p.do(req, out)
+}
+
+// do sends req and decodes the body into out. A non-2xx status is an error even when the body decoded.
+func (p *Provider) do(req *http.Request, out any) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return err
+	}
+	decodeErr := json.Unmarshal(body, out)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s: %s", req.URL.Redacted(), resp.Status)
+	}
+	if decodeErr != nil {
+		return fmt.Errorf("decode %s: %w", req.URL.Redacted(), decodeErr)
+	}
+	return nil
+}
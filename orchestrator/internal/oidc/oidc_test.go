@@ -0,0 +1,157 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
+)
+
+const testClientID = "cynodeai"
+
+func newTestProvider(t *testing.T, claims map[string]any) (*Provider, *testutil.OIDCServer) {
+	t.Helper()
+	idp := testutil.NewOIDCServer(t, testClientID, claims)
+	p, err := New(Config{IssuerURL: idp.URL, ClientID: testClientID, RedirectURL: "https://gateway.example/callback"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, idp
+}
+
+func TestVerify(t *testing.T) {
+	p, idp := newTestProvider(t, nil)
+	ctx := context.Background()
+	past := time.Now().Add(-time.Hour).Unix()
+	for _, tc := range []struct {
+		name   string
+		claims map[string]any
+		nonce  string
+		ok     bool
+	}{
+		{"valid", map[string]any{"sub": "u1", "nonce": "n1"}, "n1", true},
+		{"nonce not checked", map[string]any{"sub": "u1"}, "", true},
+		{"nonce mismatch", map[string]any{"sub": "u1", "nonce": "other"}, "n1", false},
+		{"wrong audience", map[string]any{"sub": "u1", "aud": "someone-else"}, "", false},
+		{"wrong issuer", map[string]any{"sub": "u1", "iss": "https://evil.example"}, "", false},
+		{"expired", map[string]any{"sub": "u1", "iat": past, "exp": past}, "", false},
+		{"no subject", map[string]any{}, "", false},
+		{"foreign azp", map[string]any{"sub": "u1", "aud": []string{testClientID, "x"}, "azp": "x"}, "", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := p.Verify(ctx, idp.SignIDToken(tc.claims), tc.nonce)
+			if tc.ok != (err == nil) {
+				t.Fatalf("Verify err = %v, want ok=%v", err, tc.ok)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidIDToken) {
+				t.Errorf("err = %v, want ErrInvalidIDToken", err)
+			}
+		})
+	}
+}
+
+func TestVerify_RejectsHMACAndPicksUpRotatedKeys(t *testing.T) {
+	p, idp := newTestProvider(t, nil)
+	ctx := context.Background()
+	hs := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss": idp.URL, "aud": testClientID, "sub": "u1", "iat": time.Now().Unix(), "exp": time.Now().Add(time.Minute).Unix(),
+	})
+	signed, _ := hs.SignedString([]byte(testClientID))
+	if _, err := p.Verify(ctx, signed, ""); err == nil {
+		t.Fatal("HS256 id token accepted")
+	}
+
+	if _, err := p.Verify(ctx, idp.SignIDToken(map[string]any{"sub": "u1"}), ""); err != nil {
+		t.Fatal(err)
+	}
+	idp.RotateKey()
+	rotated := idp.SignIDToken(map[string]any{"sub": "u1"})
+	if _, err := p.Verify(ctx, rotated, ""); err == nil {
+		t.Fatal("unknown kid accepted before the refresh interval elapsed")
+	}
+	p.keys.MinRefresh = 0
+	if _, err := p.Verify(ctx, rotated, ""); err != nil {
+		t.Fatalf("rotated key: %v", err)
+	}
+}
+
+func TestAuthCodeFlow(t *testing.T) {
+	p, _ := newTestProvider(t, map[string]any{
+		"sub": "u1", "email": "dev@example.com", "email_verified": true, "preferred_username": "dev", "groups": []string{"eng", "ops"},
+	})
+	ctx := context.Background()
+	verifier := RandomToken()
+	authURL, err := p.AuthCodeURL(ctx, "s1", "n1", verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	back, _ := url.Parse(resp.Header.Get("Location"))
+	if back.Query().Get("state") != "s1" {
+		t.Fatalf("redirect = %s", back)
+	}
+	if _, err := p.Exchange(ctx, back.Query().Get("code"), "wrong-verifier", "n1"); err == nil {
+		t.Fatal("exchange with wrong PKCE verifier succeeded")
+	}
+
+	resp, _ = client.Get(authURL)
+	_ = resp.Body.Close()
+	back, _ = url.Parse(resp.Header.Get("Location"))
+	id, err := p.Exchange(ctx, back.Query().Get("code"), verifier, "n1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Identity{Issuer: p.Issuer(), Subject: "u1", Email: "dev@example.com", EmailVerified: true, PreferredUsername: "dev", Groups: []string{"eng", "ops"}}
+	if !reflect.DeepEqual(id, want) {
+		t.Errorf("identity = %+v, want %+v", id, want)
+	}
+}
+
+func TestDeviceFlow(t *testing.T) {
+	p, idp := newTestProvider(t, map[string]any{"sub": "u1"})
+	ctx := context.Background()
+	da, err := p.StartDevice(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.PollDevice(ctx, da.DeviceCode); !errors.Is(err, ErrAuthorizationPending) {
+		t.Fatalf("poll before approval: %v", err)
+	}
+	if !idp.ApproveDevice(da.UserCode) {
+		t.Fatal("user code not found")
+	}
+	id, err := p.PollDevice(ctx, da.DeviceCode)
+	if err != nil || id.Subject != "u1" || id.Groups != nil {
+		t.Fatalf("poll after approval: %+v, %v", id, err)
+	}
+	if _, err := p.PollDevice(ctx, da.DeviceCode); !errors.Is(err, ErrExpiredToken) {
+		t.Errorf("reused device code: %v", err)
+	}
+}
+
+func TestDiscovery_IssuerMismatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"issuer":"https://other.example","authorization_endpoint":"a","token_endpoint":"t","jwks_uri":"j"}`))
+	}))
+	defer srv.Close()
+	p, _ := New(Config{IssuerURL: srv.URL, ClientID: testClientID})
+	if _, err := p.Metadata(context.Background()); err == nil {
+		t.Fatal("issuer mismatch accepted")
+	}
+	if _, err := New(Config{ClientID: testClientID}); err == nil {
+		t.Error("New without issuer succeeded")
+	}
+}
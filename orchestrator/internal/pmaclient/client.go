@@ -11,6 +11,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/inference"
 )
 
 const defaultTimeout = 120 * time.Second
@@ -24,11 +26,39 @@ type ChatMessage struct {
 // CompletionRequest is the body sent to cynode-pma internal chat completion endpoint.
 type CompletionRequest struct {
 	Messages []ChatMessage `json:"messages"`
+	Stream   bool          `json:"stream,omitempty"`
+}
+
+// StreamEvent is the data payload of one server-sent event from a streamed cynode-pma completion.
+// Usage arrives in its own event after the last fragment.
+type StreamEvent struct {
+	Content string `json:"content,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Usage   *Usage `json:"usage,omitempty"`
 }
 
+// streamDone is the data payload of the final server-sent event.
+const streamDone = "[DONE]"
+
 // CompletionResponse is the response from cynode-pma.
 type CompletionResponse struct {
 	Content string `json:"content"`
+	Usage   *Usage `json:"usage,omitempty"`
+}
+
+// Usage is the model token usage cynode-pma reports for a completion.
+type Usage struct {
+	Model            string `json:"model"`
+	PromptTokens     int64  `json:"prompt_tokens"`
+	CompletionTokens int64  `json:"completion_tokens"`
+}
+
+// observe reports u to the caller's inference usage observer (inference.WithUsageObserver), if any.
+func (u *Usage) observe(ctx context.Context) {
+	if u == nil {
+		return
+	}
+	inference.ObserveUsage(ctx, inference.Usage{Model: u.Model, PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens})
 }
 
 type managedProxyRequest struct {
@@ -37,6 +67,7 @@ type managedProxyRequest struct {
 	Path    string              `json:"path"`
 	Headers map[string][]string `json:"headers,omitempty"`
 	BodyB64 string              `json:"body_b64,omitempty"`
+	Stream  bool                `json:"stream,omitempty"`
 }
 
 type managedProxyResponse struct {
@@ -83,6 +114,7 @@ func CallChatCompletion(ctx context.Context, client *http.Client, baseURL string
 	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
 		return "", err
 	}
+	out.Usage.observe(ctx)
 	return out.Content, nil
 }
 
@@ -137,5 +169,6 @@ func callViaManagedProxy(ctx context.Context, client *http.Client, proxyURL stri
 	if err := json.Unmarshal(rawCompletion, &out); err != nil {
 		return "", err
 	}
+	out.Usage.observe(ctx)
 	return out.Content, nil
 }
@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/inference"
 )
 
 func TestCallChatCompletion_EmptyURL(t *testing.T) {
@@ -105,7 +107,7 @@ func TestCallChatCompletion_ManagedProxySuccess(t *testing.T) {
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
-		completion := CompletionResponse{Content: "proxied-ok"}
+		completion := CompletionResponse{Content: "proxied-ok", Usage: &Usage{Model: "m", PromptTokens: 3, CompletionTokens: 1}}
 		completionRaw, _ := json.Marshal(completion)
 		_ = json.NewEncoder(w).Encode(managedProxyResponse{
 			Version: 1,
@@ -115,13 +117,18 @@ func TestCallChatCompletion_ManagedProxySuccess(t *testing.T) {
 	}))
 	defer server.Close()
 	url := server.URL + "/v1/worker/managed-services/pma-main/proxy:http"
-	content, err := CallChatCompletion(context.Background(), nil, url, []ChatMessage{{Role: "user", Content: "hi"}}, "")
+	var usage []inference.Usage
+	ctx := inference.WithUsageObserver(context.Background(), func(u inference.Usage) { usage = append(usage, u) })
+	content, err := CallChatCompletion(ctx, nil, url, []ChatMessage{{Role: "user", Content: "hi"}}, "")
 	if err != nil {
 		t.Fatalf("CallChatCompletion via proxy: %v", err)
 	}
 	if content != "proxied-ok" {
 		t.Errorf("content want proxied-ok, got %q", content)
 	}
+	if len(usage) != 1 || usage[0].PromptTokens != 3 || usage[0].CompletionTokens != 1 {
+		t.Errorf("usage = %+v", usage)
+	}
 }
 
 func TestCallChatCompletion_ManagedProxyUpstreamError(t *testing.T) {
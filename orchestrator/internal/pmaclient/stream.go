@@ -0,0 +1,144 @@
+package pmaclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// StreamChatCompletion is like CallChatCompletion but asks cynode-pma for a streamed completion and calls onDelta
+// with each content fragment as it arrives. Returns the concatenated content.
+// When the upstream answers with a plain JSON completion instead of an event stream, its content is passed to
+// onDelta as a single fragment.
+func StreamChatCompletion(ctx context.Context, client *http.Client, baseURL string, messages []ChatMessage, workerBearerToken string, onDelta func(string)) (string, error) {
+	if baseURL == "" {
+		return "", fmt.Errorf("PMA base URL is required")
+	}
+	if client == nil {
+		// No overall client timeout: a stream may legitimately run longer; ctx bounds the call.
+		client = &http.Client{}
+	}
+	baseURL = strings.TrimSpace(baseURL)
+	b, err := json.Marshal(CompletionRequest{Messages: messages, Stream: true})
+	if err != nil {
+		return "", err
+	}
+	proxied := looksLikeManagedProxyEndpoint(baseURL)
+	var req *http.Request
+	if proxied {
+		rawReq, err := json.Marshal(managedProxyRequest{
+			Version: 1,
+			Method:  http.MethodPost,
+			Path:    "/internal/chat/completion",
+			Headers: map[string][]string{"Content-Type": {"application/json"}},
+			BodyB64: base64.StdEncoding.EncodeToString(b),
+			Stream:  true,
+		})
+		if err != nil {
+			return "", err
+		}
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, baseURL, bytes.NewReader(rawReq))
+		if err != nil {
+			return "", err
+		}
+		if workerBearerToken != "" {
+			req.Header.Set("Authorization", "Bearer "+workerBearerToken)
+		}
+	} else {
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(baseURL, "/")+"/internal/chat/completion", bytes.NewReader(b))
+		if err != nil {
+			return "", err
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("PMA chat completion returned %s", resp.Status)
+	}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return readStream(ctx, resp.Body, onDelta)
+	}
+	out, err := readNonStreamed(resp.Body, proxied)
+	if err != nil {
+		return "", err
+	}
+	out.Usage.observe(ctx)
+	if out.Content != "" && onDelta != nil {
+		onDelta(out.Content)
+	}
+	return out.Content, nil
+}
+
+// readStream reads data: events until data: [DONE]; an error event or a stream without [DONE] is an error.
+// Usage is reported to the context's observer only once the stream completed.
+func readStream(ctx context.Context, body io.Reader, onDelta func(string)) (string, error) {
+	var usage *Usage
+	var out strings.Builder
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if data == streamDone {
+			usage.observe(ctx)
+			return out.String(), nil
+		}
+		var ev StreamEvent
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			return "", fmt.Errorf("decode PMA stream event: %w", err)
+		}
+		if ev.Error != "" {
+			return "", fmt.Errorf("PMA stream error: %s", ev.Error)
+		}
+		if ev.Usage != nil {
+			usage = ev.Usage
+		}
+		if ev.Content != "" {
+			out.WriteString(ev.Content)
+			if onDelta != nil {
+				onDelta(ev.Content)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.New("PMA stream ended before completion")
+}
+
+func readNonStreamed(body io.Reader, proxied bool) (*CompletionResponse, error) {
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if proxied {
+		var proxyResp managedProxyResponse
+		if err := json.Unmarshal(raw, &proxyResp); err != nil {
+			return nil, err
+		}
+		if proxyResp.Status != http.StatusOK {
+			return nil, fmt.Errorf("PMA proxy upstream returned %d", proxyResp.Status)
+		}
+		if raw, err = base64.StdEncoding.DecodeString(proxyResp.BodyB64); err != nil {
+			return nil, err
+		}
+	}
+	var out CompletionResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
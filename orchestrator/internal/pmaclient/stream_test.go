@@ -0,0 +1,77 @@
+package pmaclient
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/inference"
+)
+
+func TestStreamChatCompletion_ManagedProxyStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req managedProxyRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		body, _ := base64.StdEncoding.DecodeString(req.BodyB64)
+		var completion CompletionRequest
+		_ = json.Unmarshal(body, &completion)
+		if !req.Stream || !completion.Stream || r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"content\":\"hel\"}\n\ndata: {\"content\":\"lo\"}\n\n" +
+			"data: {\"usage\":{\"model\":\"m\",\"prompt_tokens\":5,\"completion_tokens\":2}}\n\ndata: [DONE]\n\n"))
+	}))
+	defer server.Close()
+	var deltas []string
+	var usage []inference.Usage
+	ctx := inference.WithUsageObserver(context.Background(), func(u inference.Usage) { usage = append(usage, u) })
+	url := server.URL + "/v1/worker/managed-services/pma-main/proxy:http"
+	content, err := StreamChatCompletion(ctx, nil, url, []ChatMessage{{Role: "user", Content: "hi"}}, "tok",
+		func(d string) { deltas = append(deltas, d) })
+	if err != nil || content != "hello" || len(deltas) != 2 {
+		t.Fatalf("content=%q err=%v deltas=%v", content, err, deltas)
+	}
+	if len(usage) != 1 || usage[0] != (inference.Usage{Model: "m", PromptTokens: 5, CompletionTokens: 2}) {
+		t.Errorf("usage = %+v", usage)
+	}
+}
+
+func TestStreamChatCompletion_NonStreamedFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(CompletionResponse{Content: "whole"})
+	}))
+	defer server.Close()
+	var deltas []string
+	content, err := StreamChatCompletion(context.Background(), nil, server.URL, nil, "", func(d string) { deltas = append(deltas, d) })
+	if err != nil || content != "whole" || len(deltas) != 1 {
+		t.Fatalf("content=%q err=%v deltas=%v", content, err, deltas)
+	}
+}
+
+func TestStreamChatCompletion_Errors(t *testing.T) {
+	cases := map[string]string{
+		"error event":   "data: {\"content\":\"a\"}\n\ndata: {\"error\":\"completion failed\"}\n\ndata: [DONE]\n\n",
+		"no done":       "data: {\"content\":\"a\"}\n\n",
+		"invalid event": "data: {\n\n",
+	}
+	for name, body := range cases {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				_, _ = w.Write([]byte(body))
+			}))
+			defer server.Close()
+			if _, err := StreamChatCompletion(context.Background(), nil, server.URL, nil, "", nil); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+	if _, err := StreamChatCompletion(context.Background(), nil, "", nil, "", nil); err == nil {
+		t.Error("expected error for empty base URL")
+	}
+}
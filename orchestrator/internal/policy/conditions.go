@@ -0,0 +1,142 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Conditions is the JSON form of access_control_rules.conditions. Every present field must hold for the rule to
+// match; an absent field is not checked.
+type Conditions struct {
+	TimeWindow      *TimeWindow `json:"time_window,omitempty"`
+	ProjectIDs      []string    `json:"project_ids,omitempty"`
+	TaskTypes       []string    `json:"task_types,omitempty"`
+	MaxRequestBytes *int64      `json:"max_request_bytes,omitempty"`
+}
+
+// TimeWindow limits a rule to a daily window. Start and End are "HH:MM" in Timezone (IANA, default UTC); End
+// before Start wraps past midnight. Days, when set, lists the days ("mon".."sun") on which the window opens.
+type TimeWindow struct {
+	Start    string   `json:"start"`
+	End      string   `json:"end"`
+	Days     []string `json:"days,omitempty"`
+	Timezone string   `json:"timezone,omitempty"`
+}
+
+var dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ParseConditions decodes and validates raw conditions JSON. Unknown fields are rejected.
+func ParseConditions(raw string) (*Conditions, error) {
+	dec := json.NewDecoder(bytes.NewReader([]byte(raw)))
+	dec.DisallowUnknownFields()
+	var c Conditions
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("conditions: %w", err)
+	}
+	if c.MaxRequestBytes != nil && *c.MaxRequestBytes < 0 {
+		return nil, errors.New("conditions: max_request_bytes must not be negative")
+	}
+	if c.TimeWindow != nil {
+		if _, _, _, err := c.TimeWindow.parse(); err != nil {
+			return nil, fmt.Errorf("conditions: time_window: %w", err)
+		}
+	}
+	return &c, nil
+}
+
+// conditionsHold reports whether raw conditions hold for ctx. Nil or empty conditions always hold.
+func conditionsHold(raw *string, ctx *Context) (bool, error) {
+	if raw == nil || strings.TrimSpace(*raw) == "" || strings.TrimSpace(*raw) == "null" {
+		return true, nil
+	}
+	c, err := ParseConditions(*raw)
+	if err != nil {
+		return false, err
+	}
+	return c.Hold(ctx), nil
+}
+
+// Hold reports whether every condition holds for ctx.
+func (c *Conditions) Hold(ctx *Context) bool {
+	if c.MaxRequestBytes != nil && ctx.RequestBytes > *c.MaxRequestBytes {
+		return false
+	}
+	if len(c.ProjectIDs) > 0 && (ctx.ProjectID == nil || !slices.Contains(c.ProjectIDs, ctx.ProjectID.String())) {
+		return false
+	}
+	if len(c.TaskTypes) > 0 && !slices.Contains(c.TaskTypes, ctx.TaskType) {
+		return false
+	}
+	if c.TimeWindow != nil && !c.TimeWindow.contains(ctx.Time) {
+		return false
+	}
+	return true
+}
+
+func (w *TimeWindow) parse() (start, end int, loc *time.Location, err error) {
+	if start, err = parseClock(w.Start); err != nil {
+		return 0, 0, nil, err
+	}
+	if end, err = parseClock(w.End); err != nil {
+		return 0, 0, nil, err
+	}
+	loc = time.UTC
+	if w.Timezone != "" {
+		if loc, err = time.LoadLocation(w.Timezone); err != nil {
+			return 0, 0, nil, err
+		}
+	}
+	for _, d := range w.Days {
+		if !slices.Contains(dayNames, strings.ToLower(d)) {
+			return 0, 0, nil, fmt.Errorf("unknown day %q", d)
+		}
+	}
+	return start, end, loc, nil
+}
+
+func (w *TimeWindow) contains(t time.Time) bool {
+	start, end, loc, err := w.parse()
+	if err != nil {
+		return false
+	}
+	t = t.In(loc)
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	var inWindow bool
+	switch {
+	case start <= end:
+		inWindow = minute >= start && minute < end
+	case minute >= start:
+		inWindow = true
+	case minute < end:
+		// Past midnight: the window opened the previous day.
+		inWindow = true
+		day = (day + 6) % 7
+	}
+	return inWindow && w.dayAllowed(day)
+}
+
+func (w *TimeWindow) dayAllowed(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if strings.EqualFold(d, dayNames[day]) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (want HH:MM)", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
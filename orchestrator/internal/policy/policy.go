@@ -0,0 +1,239 @@
+// Package policy evaluates access_control_rules for the MCP gateway, API Egress, and the User API Gateway.
+// Rules are default deny: a request is allowed only when an allow rule matches at the highest priority that has
+// any matching rule, and no deny rule matches at that priority. See docs/tech_specs/access_control.md.
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/rbac"
+)
+
+// Rule effects.
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+// Principal is who is acting: a user (nil for system callers such as agents without task context) and the
+// groups and system-scope roles resolved for that user. System rules apply to every principal. RoleNames is
+// carried for audit records.
+type Principal struct {
+	UserID    *uuid.UUID
+	GroupIDs  []uuid.UUID
+	RoleIDs   []uuid.UUID
+	RoleNames []string
+}
+
+// Subjects returns the non-system subjects the principal acts as.
+func (p Principal) Subjects() []database.AccessSubject {
+	var out []database.AccessSubject
+	if p.UserID != nil {
+		out = append(out, database.AccessSubject{Type: database.SubjectTypeUser, ID: *p.UserID})
+	}
+	for _, id := range p.GroupIDs {
+		out = append(out, database.AccessSubject{Type: database.SubjectTypeGroup, ID: id})
+	}
+	for _, id := range p.RoleIDs {
+		out = append(out, database.AccessSubject{Type: database.SubjectTypeRole, ID: id})
+	}
+	return out
+}
+
+// Context is request metadata checked by rule conditions. A zero Time means now.
+type Context struct {
+	Time         time.Time
+	ProjectID    *uuid.UUID
+	TaskType     string
+	RequestBytes int64
+}
+
+// Request is one access decision to make.
+type Request struct {
+	Principal    Principal
+	Action       string
+	ResourceType string
+	Resource     string
+	Context      Context
+}
+
+// Decision is the outcome of evaluating a Request. Rule is the deciding rule; it is nil on default deny.
+type Decision struct {
+	Allowed bool
+	Reason  string
+	Rule    *models.AccessControlRule
+}
+
+// Store loads candidate rules and the principal's groups and roles; database.Store satisfies it.
+type Store interface {
+	rbac.Store
+	ListAccessControlRulesForSubjects(ctx context.Context, subjects []database.AccessSubject, action, resourceType string) ([]*models.AccessControlRule, error)
+}
+
+// Engine loads rules for a request's subjects and evaluates them.
+type Engine struct {
+	store Store
+}
+
+// New returns an Engine backed by store.
+func New(store Store) *Engine {
+	return &Engine{store: store}
+}
+
+// Decide loads the rules that apply to req and evaluates them. On a store error the decision is deny and the
+// error is returned for logging.
+func (e *Engine) Decide(ctx context.Context, req *Request) (Decision, error) {
+	d, _, err := e.Explain(ctx, req)
+	return d, err
+}
+
+// Explain is Decide plus the rules that matched req (see MatchingRules); used by the policy dry-run endpoint.
+func (e *Engine) Explain(ctx context.Context, req *Request) (Decision, []*models.AccessControlRule, error) {
+	rules, err := e.store.ListAccessControlRulesForSubjects(ctx, req.Principal.Subjects(), req.Action, req.ResourceType)
+	if err != nil {
+		return Decision{Reason: "failed to load policy"}, nil, err
+	}
+	matched := MatchingRules(rules, req)
+	return decide(matched), matched, nil
+}
+
+// PrincipalForUser resolves the subjects userID acts as: the user, the user's active groups, and the user's
+// system-scope roles (see rbac.Resolve).
+func (e *Engine) PrincipalForUser(ctx context.Context, userID uuid.UUID) (Principal, error) {
+	a, err := rbac.Resolve(ctx, e.store, userID)
+	if err != nil {
+		return Principal{}, err
+	}
+	return PrincipalForAccess(a), nil
+}
+
+// PrincipalForAccess builds the principal for an already resolved rbac.Access.
+func PrincipalForAccess(a *rbac.Access) Principal {
+	userID := a.UserID
+	return Principal{UserID: &userID, GroupIDs: a.GroupIDs, RoleIDs: a.RoleIDs(), RoleNames: a.RoleNames()}
+}
+
+// Evaluate decides req against rules. Rules for other actions, resource types, or subjects are ignored, so
+// callers may pass a superset. Among matching rules the highest priority wins; at that priority deny overrides
+// allow. With no matching rule the request is denied.
+func Evaluate(rules []*models.AccessControlRule, req *Request) Decision {
+	return decide(MatchingRules(rules, req))
+}
+
+// MatchingRules returns the rules that apply to req, highest priority first: subject, action, resource type,
+// resource pattern, and conditions all match. A rule with malformed conditions matches only if it is a deny rule.
+func MatchingRules(rules []*models.AccessControlRule, req *Request) []*models.AccessControlRule {
+	ctx := req.Context
+	if ctx.Time.IsZero() {
+		ctx.Time = time.Now()
+	}
+	subjects := req.Principal.Subjects()
+	var matched []*models.AccessControlRule
+	for _, r := range rules {
+		if r == nil || !ruleApplies(r, req, subjects) || !MatchResource(r.ResourcePattern, req.Resource) {
+			continue
+		}
+		ok, err := conditionsHold(r.Conditions, &ctx)
+		if err != nil {
+			// Malformed conditions fail closed: a deny rule still applies, an allow rule does not.
+			ok = r.Effect == EffectDeny
+		}
+		if ok {
+			matched = append(matched, r)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].Priority > matched[j].Priority })
+	return matched
+}
+
+// decide takes the decision from matched rules ordered by priority desc.
+func decide(matched []*models.AccessControlRule) Decision {
+	var allow *models.AccessControlRule
+	for _, r := range matched {
+		if allow != nil && r.Priority < allow.Priority {
+			break
+		}
+		switch r.Effect {
+		case EffectDeny:
+			return Decision{Reason: "denied by policy", Rule: r}
+		case EffectAllow:
+			if allow == nil {
+				allow = r
+			}
+		}
+	}
+	if allow != nil {
+		return Decision{Allowed: true, Rule: allow}
+	}
+	return Decision{Reason: "not allowed by policy"}
+}
+
+// DefaultResourceType returns the resource type each enforcement point uses for action, or "" for other actions.
+func DefaultResourceType(action string) string {
+	switch action {
+	case database.ActionApiCall:
+		return database.ResourceTypeProviderOperation
+	case database.ActionMcpToolInvoke:
+		return database.ResourceTypeMcpTool
+	case database.ActionTaskCreate:
+		return database.ResourceTypeProject
+	}
+	return ""
+}
+
+func ruleApplies(r *models.AccessControlRule, req *Request, subjects []database.AccessSubject) bool {
+	if r.Action != req.Action || r.ResourceType != req.ResourceType {
+		return false
+	}
+	if r.SubjectType == database.SubjectTypeSystem {
+		return r.SubjectID == nil
+	}
+	if r.SubjectID == nil {
+		return false
+	}
+	for _, s := range subjects {
+		if s.Type == r.SubjectType && s.ID == *r.SubjectID {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchResource reports whether resource matches pattern. "*" and "**" match everything; a pattern ending in "**"
+// matches by prefix; any other pattern is a glob (path.Match: "*" does not cross "/"). Exact strings match themselves.
+func MatchResource(pattern, resource string) bool {
+	switch {
+	case pattern == "*" || pattern == "**":
+		return true
+	case strings.HasSuffix(pattern, "**"):
+		return strings.HasPrefix(resource, strings.TrimSuffix(pattern, "**"))
+	case !strings.ContainsAny(pattern, "*?["):
+		return pattern == resource
+	}
+	ok, err := path.Match(pattern, resource)
+	return err == nil && ok
+}
+
+// ValidatePattern reports whether pattern can be used as a rule resource_pattern (see MatchResource).
+func ValidatePattern(pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return errors.New("resource_pattern is required")
+	}
+	if pattern == "*" || strings.HasSuffix(pattern, "**") || !strings.ContainsAny(pattern, "*?[") {
+		return nil
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("resource_pattern: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+const (
+	testAction       = "api.call"
+	testResourceType = "api.provider_operation"
+)
+
+func rule(subjectType string, subjectID *uuid.UUID, pattern, effect string, priority int, conditions string) *models.AccessControlRule {
+	r := &models.AccessControlRule{
+		ID: uuid.New(), SubjectType: subjectType, SubjectID: subjectID, Action: testAction,
+		ResourceType: testResourceType, ResourcePattern: pattern, Effect: effect, Priority: priority,
+	}
+	if conditions != "" {
+		r.Conditions = &conditions
+	}
+	return r
+}
+
+func request(userID uuid.UUID, resource string) *Request {
+	return &Request{
+		Principal: Principal{UserID: &userID}, Action: testAction, ResourceType: testResourceType, Resource: resource,
+		Context: Context{Time: time.Date(2026, 3, 4, 10, 30, 0, 0, time.UTC)}, // Wednesday
+	}
+}
+
+func TestMatchResource(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, resource string
+		want              bool
+	}{
+		{"openai/chat", "openai/chat", true},
+		{"openai/chat", "openai/chatx", false},
+		{"*", "github/get_repo", true},
+		{"openai/*", "openai/embeddings", true},
+		{"openai/*", "github/get_repo", false},
+		{"*/get_*", "github/get_issue", true},
+		{"https://example.com/docs/**", "https://example.com/docs/a/b", true},
+		{"https://example.com/docs/**", "https://example.com/blog", false},
+		{"*.example.com", "api.example.com", true},
+		{"a[*", "a[b", false},
+	} {
+		if got := MatchResource(tc.pattern, tc.resource); got != tc.want {
+			t.Errorf("MatchResource(%q, %q) = %v, want %v", tc.pattern, tc.resource, got, tc.want)
+		}
+	}
+}
+
+func TestEvaluate_DefaultDeny(t *testing.T) {
+	d := Evaluate(nil, request(uuid.New(), "openai/chat"))
+	if d.Allowed || d.Rule != nil || d.Reason == "" {
+		t.Errorf("decision = %+v", d)
+	}
+}
+
+func TestEvaluate_PriorityAndDenyOverrides(t *testing.T) {
+	user := uuid.New()
+	other := uuid.New()
+	allowAll := rule(database.SubjectTypeSystem, nil, "*", EffectAllow, 0, "")
+	denyGithub := rule(database.SubjectTypeUser, &user, "github/*", EffectDeny, 10, "")
+	allowRepo := rule(database.SubjectTypeUser, &user, "github/get_repo", EffectAllow, 20, "")
+	sameLevelDeny := rule(database.SubjectTypeUser, &user, "openai/**", EffectDeny, 0, "")
+	otherUserAllow := rule(database.SubjectTypeUser, &other, "*", EffectAllow, 100, "")
+	rules := []*models.AccessControlRule{allowAll, denyGithub, allowRepo, sameLevelDeny, otherUserAllow}
+
+	for _, tc := range []struct {
+		resource string
+		allowed  bool
+		rule     *models.AccessControlRule
+	}{
+		{"github/get_repo", true, allowRepo},
+		{"github/create_issue", false, denyGithub},
+		{"openai/chat_completions", false, sameLevelDeny},
+		{"anthropic/messages", true, allowAll},
+	} {
+		d := Evaluate(rules, request(user, tc.resource))
+		if d.Allowed != tc.allowed || d.Rule != tc.rule {
+			t.Errorf("%s: decision = %+v", tc.resource, d)
+		}
+	}
+}
+
+func TestMatchingRules_OrderAndConditions(t *testing.T) {
+	user := uuid.New()
+	low := rule(database.SubjectTypeSystem, nil, "*", EffectAllow, 0, "")
+	high := rule(database.SubjectTypeUser, &user, "openai/*", EffectDeny, 10, "")
+	offHours := rule(database.SubjectTypeUser, &user, "*", EffectAllow, 20, `{"time_window":{"start":"22:00","end":"23:00"}}`)
+	otherResource := rule(database.SubjectTypeUser, &user, "github/*", EffectAllow, 30, "")
+	matched := MatchingRules([]*models.AccessControlRule{low, offHours, high, otherResource}, request(user, "openai/chat"))
+	if len(matched) != 2 || matched[0] != high || matched[1] != low {
+		t.Errorf("matched = %+v", matched)
+	}
+}
+
+func TestValidatePattern(t *testing.T) {
+	for _, p := range []string{"*", "**", "openai/*", "https://example.com/**", "exact", "*.example.com"} {
+		if err := ValidatePattern(p); err != nil {
+			t.Errorf("%q: %v", p, err)
+		}
+	}
+	for _, p := range []string{"", "  ", "openai/[a-"} {
+		if ValidatePattern(p) == nil {
+			t.Errorf("%q: want error", p)
+		}
+	}
+}
+
+func TestEvaluate_GroupAndRoleSubjects(t *testing.T) {
+	user, group, role := uuid.New(), uuid.New(), uuid.New()
+	rules := []*models.AccessControlRule{
+		rule(database.SubjectTypeGroup, &group, "openai/*", EffectAllow, 0, ""),
+		rule(database.SubjectTypeRole, &role, "openai/embeddings", EffectDeny, 5, ""),
+		rule(database.SubjectTypeSystem, &user, "*", EffectAllow, 0, ""), // system rules must not carry a subject id
+	}
+	req := request(user, "openai/chat_completions")
+	if d := Evaluate(rules, req); d.Allowed {
+		t.Errorf("without group membership: %+v", d)
+	}
+	req.Principal.GroupIDs = []uuid.UUID{group}
+	if d := Evaluate(rules, req); !d.Allowed {
+		t.Errorf("with group membership: %+v", d)
+	}
+	req.Resource = "openai/embeddings"
+	req.Principal.RoleIDs = []uuid.UUID{role}
+	if d := Evaluate(rules, req); d.Allowed {
+		t.Errorf("role deny: %+v", d)
+	}
+}
+
+func TestEvaluate_Conditions(t *testing.T) {
+	user := uuid.New()
+	project := uuid.New()
+	for _, tc := range []struct {
+		name       string
+		conditions string
+		mutate     func(*Context)
+		want       bool
+	}{
+		{"window open", `{"time_window":{"start":"09:00","end":"17:00","days":["mon","wed"]}}`, nil, true},
+		{"window closed", `{"time_window":{"start":"11:00","end":"17:00"}}`, nil, false},
+		{"wrong day", `{"time_window":{"start":"09:00","end":"17:00","days":["sat"]}}`, nil, false},
+		{"timezone", `{"time_window":{"start":"09:00","end":"17:00","timezone":"America/New_York"}}`, nil, false},
+		{"overnight after midnight", `{"time_window":{"start":"22:00","end":"11:00","days":["tue"]}}`, nil, true},
+		{"project match", `{"project_ids":["` + project.String() + `"]}`, func(c *Context) { c.ProjectID = &project }, true},
+		{"project missing", `{"project_ids":["` + project.String() + `"]}`, nil, false},
+		{"task type", `{"task_types":["prompt","sba"]}`, func(c *Context) { c.TaskType = "sba" }, true},
+		{"task type mismatch", `{"task_types":["prompt"]}`, func(c *Context) { c.TaskType = "script" }, false},
+		{"size ok", `{"max_request_bytes":100}`, func(c *Context) { c.RequestBytes = 100 }, true},
+		{"size exceeded", `{"max_request_bytes":100}`, func(c *Context) { c.RequestBytes = 101 }, false},
+		{"unknown field", `{"max_chars":10}`, nil, false},
+		{"malformed", `{`, nil, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := request(user, "openai/chat")
+			if tc.mutate != nil {
+				tc.mutate(&req.Context)
+			}
+			rules := []*models.AccessControlRule{rule(database.SubjectTypeUser, &user, "openai/chat", EffectAllow, 0, tc.conditions)}
+			if d := Evaluate(rules, req); d.Allowed != tc.want {
+				t.Errorf("allowed = %v, want %v (%s)", d.Allowed, tc.want, d.Reason)
+			}
+		})
+	}
+}
+
+func TestEvaluate_MalformedDenyConditionsFailClosed(t *testing.T) {
+	user := uuid.New()
+	rules := []*models.AccessControlRule{
+		rule(database.SubjectTypeSystem, nil, "*", EffectAllow, 0, ""),
+		rule(database.SubjectTypeUser, &user, "*", EffectDeny, 0, `{"bogus":true}`),
+	}
+	if d := Evaluate(rules, request(user, "openai/chat")); d.Allowed {
+		t.Errorf("malformed deny should still deny: %+v", d)
+	}
+}
+
+type fakeStore struct {
+	rules    []*models.AccessControlRule
+	err      error
+	subjects []database.AccessSubject
+	groupIDs []uuid.UUID
+	bindings []*models.RoleBinding
+}
+
+func (f *fakeStore) ListGroupIDsForUser(context.Context, uuid.UUID) ([]uuid.UUID, error) {
+	return f.groupIDs, nil
+}
+
+func (f *fakeStore) ListRoleBindings(context.Context, []database.AccessSubject) ([]*models.RoleBinding, error) {
+	return f.bindings, nil
+}
+
+func (f *fakeStore) ListRoles(context.Context) ([]*models.Role, error) {
+	return database.BuiltinRoles(), nil
+}
+
+func (f *fakeStore) ListAccessControlRulesForSubjects(_ context.Context, subjects []database.AccessSubject, _, _ string) ([]*models.AccessControlRule, error) {
+	f.subjects = subjects
+	return f.rules, f.err
+}
+
+func TestEngine_Decide(t *testing.T) {
+	user := uuid.New()
+	store := &fakeStore{rules: []*models.AccessControlRule{rule(database.SubjectTypeUser, &user, "openai/*", EffectAllow, 0, "")}}
+	e := New(store)
+	p, err := e.PrincipalForUser(context.Background(), user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := request(user, "openai/chat")
+	req.Principal = p
+	d, err := e.Decide(context.Background(), req)
+	if err != nil || !d.Allowed {
+		t.Errorf("Decide: %+v, %v", d, err)
+	}
+	// user + default member role
+	if len(store.subjects) != 2 || store.subjects[0].ID != user || store.subjects[1].Type != database.SubjectTypeRole {
+		t.Errorf("subjects = %+v", store.subjects)
+	}
+	store.err = errors.New("db down")
+	if d, err := e.Decide(context.Background(), req); err == nil || d.Allowed {
+		t.Errorf("store error: %+v, %v", d, err)
+	}
+}
+
+func TestEngine_Explain(t *testing.T) {
+	user := uuid.New()
+	allow := rule(database.SubjectTypeUser, &user, "openai/*", EffectAllow, 0, "")
+	e := New(&fakeStore{rules: []*models.AccessControlRule{allow, rule(database.SubjectTypeUser, &user, "github/*", EffectAllow, 0, "")}})
+	d, matched, err := e.Explain(context.Background(), request(user, "openai/chat"))
+	if err != nil || !d.Allowed || d.Rule != allow || len(matched) != 1 {
+		t.Errorf("Explain: %+v, %v, %v", d, matched, err)
+	}
+	if DefaultResourceType(database.ActionApiCall) != database.ResourceTypeProviderOperation || DefaultResourceType("web.fetch") != "" {
+		t.Error("DefaultResourceType")
+	}
+}
+
+func TestParseConditions_Validation(t *testing.T) {
+	for _, raw := range []string{
+		`{"max_request_bytes":-1}`,
+		`{"time_window":{"start":"9am","end":"17:00"}}`,
+		`{"time_window":{"start":"09:00","end":"17:00","days":["funday"]}}`,
+		`{"time_window":{"start":"09:00","end":"17:00","timezone":"Mars/Olympus"}}`,
+	} {
+		if _, err := ParseConditions(raw); err == nil {
+			t.Errorf("ParseConditions(%s): expected error", raw)
+		}
+	}
+}
+
+func TestEngine_PrincipalForUser_GroupsAndRoles(t *testing.T) {
+	user, group := uuid.New(), uuid.New()
+	admin := database.BuiltinRoles()[0]
+	store := &fakeStore{
+		groupIDs: []uuid.UUID{group},
+		bindings: []*models.RoleBinding{{SubjectType: database.SubjectTypeGroup, SubjectID: group, RoleID: admin.ID, ScopeType: database.ScopeTypeSystem, IsActive: true}},
+		rules:    []*models.AccessControlRule{rule(database.SubjectTypeRole, &admin.ID, "*", EffectAllow, 0, "")},
+	}
+	e := New(store)
+	p, err := e.PrincipalForUser(context.Background(), user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.GroupIDs) != 1 || p.GroupIDs[0] != group || len(p.RoleNames) != 1 || p.RoleNames[0] != database.RoleAdmin {
+		t.Fatalf("principal = %+v", p)
+	}
+	req := request(user, "github/get_repo")
+	req.Principal = p
+	if d, err := e.Decide(context.Background(), req); err != nil || !d.Allowed {
+		t.Errorf("role rule: %+v, %v", d, err)
+	}
+}
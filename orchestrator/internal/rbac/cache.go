@@ -0,0 +1,167 @@
+package rbac
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+// DefaultCacheTTL bounds how long a resolved Access is reused. Changes made through another gateway replica,
+// which cannot invalidate this process's entries, take effect within it.
+const DefaultCacheTTL = 5 * time.Second
+
+// cacheSweepSize is the entry count above which expired entries are dropped on insert.
+const cacheSweepSize = 1024
+
+// Cache memoizes Resolve per user for a short TTL so permission checks do not load groups and role bindings on
+// every request. Entries are dropped when memberships or role bindings change through a store returned by
+// InvalidatingStore.
+type Cache struct {
+	store Store
+	ttl   time.Duration
+	now   func() time.Time
+
+	mu         sync.Mutex
+	entries    map[uuid.UUID]cacheEntry
+	generation uint64
+}
+
+type cacheEntry struct {
+	access  *Access
+	expires time.Time
+}
+
+// NewCache returns a Cache that resolves through store and keeps results for ttl.
+func NewCache(store Store, ttl time.Duration) *Cache {
+	return &Cache{store: store, ttl: ttl, now: time.Now, entries: make(map[uuid.UUID]cacheEntry)}
+}
+
+// Resolve is Resolve served from the cache when a live entry exists. The returned Access is the caller's to
+// modify (e.g. to set Scopes); its roles and groups are shared and must not be changed.
+func (c *Cache) Resolve(ctx context.Context, userID uuid.UUID) (*Access, error) {
+	c.mu.Lock()
+	e, ok := c.entries[userID]
+	gen := c.generation
+	c.mu.Unlock()
+	if ok && c.now().Before(e.expires) {
+		return e.access.clone(), nil
+	}
+	a, err := Resolve(ctx, c.store, userID)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	// A change invalidated while Resolve ran may not be reflected in a; do not cache it.
+	if gen == c.generation {
+		if len(c.entries) >= cacheSweepSize {
+			c.sweepLocked()
+		}
+		c.entries[userID] = cacheEntry{access: a, expires: c.now().Add(c.ttl)}
+	}
+	c.mu.Unlock()
+	return a.clone(), nil
+}
+
+// Invalidate drops userID's entry.
+func (c *Cache) Invalidate(userID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	delete(c.entries, userID)
+}
+
+// InvalidateAll drops every entry, e.g. when a group's role bindings change and its members are not known.
+func (c *Cache) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	clear(c.entries)
+}
+
+func (c *Cache) sweepLocked() {
+	now := c.now()
+	for id, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, id)
+		}
+	}
+}
+
+// invalidateSubject drops the entries a role binding for subject can affect.
+func (c *Cache) invalidateSubject(subjectType string, subjectID uuid.UUID) {
+	if subjectType == database.SubjectTypeUser {
+		c.Invalidate(subjectID)
+		return
+	}
+	c.InvalidateAll()
+}
+
+func (a *Access) clone() *Access {
+	out := *a
+	out.Scopes = nil
+	return &out
+}
+
+// InvalidatingStore returns store with the methods that change group memberships, role bindings, or users
+// dropping the affected cache entries after they succeed.
+func (c *Cache) InvalidatingStore(store database.Store) database.Store {
+	return &invalidatingStore{Store: store, cache: c}
+}
+
+type invalidatingStore struct {
+	database.Store
+	cache *Cache
+}
+
+func (s *invalidatingStore) AddGroupMember(ctx context.Context, groupID, userID uuid.UUID, updatedBy string) (*models.GroupMembership, error) {
+	m, err := s.Store.AddGroupMember(ctx, groupID, userID, updatedBy)
+	if err == nil {
+		s.cache.Invalidate(userID)
+	}
+	return m, err
+}
+
+func (s *invalidatingStore) RemoveGroupMember(ctx context.Context, groupID, userID uuid.UUID, updatedBy string) error {
+	err := s.Store.RemoveGroupMember(ctx, groupID, userID, updatedBy)
+	if err == nil {
+		s.cache.Invalidate(userID)
+	}
+	return err
+}
+
+func (s *invalidatingStore) SyncExternalGroupMemberships(ctx context.Context, userID uuid.UUID, groupIDs []uuid.UUID, updatedBy string) error {
+	err := s.Store.SyncExternalGroupMemberships(ctx, userID, groupIDs, updatedBy)
+	if err == nil {
+		s.cache.Invalidate(userID)
+	}
+	return err
+}
+
+func (s *invalidatingStore) CreateRoleBinding(ctx context.Context, b *models.RoleBinding) error {
+	err := s.Store.CreateRoleBinding(ctx, b)
+	if err == nil {
+		s.cache.invalidateSubject(b.SubjectType, b.SubjectID)
+	}
+	return err
+}
+
+func (s *invalidatingStore) DisableRoleBinding(ctx context.Context, id uuid.UUID, updatedBy string) (*models.RoleBinding, error) {
+	b, err := s.Store.DisableRoleBinding(ctx, id, updatedBy)
+	if err == nil {
+		s.cache.invalidateSubject(b.SubjectType, b.SubjectID)
+	}
+	return b, err
+}
+
+func (s *invalidatingStore) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	err := s.Store.DeleteUser(ctx, id)
+	if err == nil {
+		s.cache.Invalidate(id)
+	}
+	return err
+}
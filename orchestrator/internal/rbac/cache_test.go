@@ -0,0 +1,102 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
+)
+
+// countingStore counts Resolve loads (one ListRoles call each).
+type countingStore struct {
+	*testutil.MockDB
+	loads int
+}
+
+func (s *countingStore) ListRoles(ctx context.Context) ([]*models.Role, error) {
+	s.loads++
+	return s.MockDB.ListRoles(ctx)
+}
+
+func builtinRoleID(name string) uuid.UUID {
+	for _, r := range database.BuiltinRoles() {
+		if r.Name == name {
+			return r.ID
+		}
+	}
+	return uuid.Nil
+}
+
+func TestCache_ReusesUntilExpiry(t *testing.T) {
+	ctx := context.Background()
+	store := &countingStore{MockDB: testutil.NewMockDB()}
+	c := NewCache(store, time.Minute)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	userID := uuid.New()
+
+	a, err := c.Resolve(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Scopes = []string{ScopeReadOnly}
+	b, _ := c.Resolve(ctx, userID)
+	if store.loads != 1 {
+		t.Errorf("loads = %d, want 1", store.loads)
+	}
+	if b.Scopes != nil {
+		t.Error("scopes set on one request leaked into the cached access")
+	}
+	now = now.Add(time.Minute)
+	_, _ = c.Resolve(ctx, userID)
+	if store.loads != 2 {
+		t.Errorf("loads after expiry = %d, want 2", store.loads)
+	}
+}
+
+func TestCache_InvalidatingStore(t *testing.T) {
+	ctx := context.Background()
+	mock := testutil.NewMockDB()
+	c := NewCache(mock, time.Hour)
+	store := c.InvalidatingStore(mock)
+	userID := uuid.New()
+	group := &models.Group{Slug: "ops", DisplayName: "Ops", IsActive: true}
+	_ = store.CreateGroup(ctx, group)
+
+	if a, _ := c.Resolve(ctx, userID); a.Has(PermJobsManage) {
+		t.Fatal("member must not manage jobs")
+	}
+	// A binding for the user takes effect at once.
+	binding := &models.RoleBinding{SubjectType: database.SubjectTypeUser, SubjectID: userID, RoleID: builtinRoleID(database.RoleOperator), ScopeType: database.ScopeTypeSystem}
+	if err := store.CreateRoleBinding(ctx, binding); err != nil {
+		t.Fatal(err)
+	}
+	if a, _ := c.Resolve(ctx, userID); !a.Has(PermJobsManage) {
+		t.Error("operator binding not visible after CreateRoleBinding")
+	}
+	if _, err := store.DisableRoleBinding(ctx, binding.ID, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if a, _ := c.Resolve(ctx, userID); a.Has(PermJobsManage) {
+		t.Error("operator binding still visible after DisableRoleBinding")
+	}
+	// So do a binding for a group and joining it.
+	_ = store.CreateRoleBinding(ctx, &models.RoleBinding{SubjectType: database.SubjectTypeGroup, SubjectID: group.ID, RoleID: builtinRoleID(database.RoleAdmin), ScopeType: database.ScopeTypeSystem})
+	if _, err := store.AddGroupMember(ctx, group.ID, userID, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if a, _ := c.Resolve(ctx, userID); !a.Has(PermUsersManage) {
+		t.Error("group admin binding not visible after AddGroupMember")
+	}
+	if err := store.RemoveGroupMember(ctx, group.ID, userID, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if a, _ := c.Resolve(ctx, userID); a.Has(PermUsersManage) {
+		t.Error("group admin binding still visible after RemoveGroupMember")
+	}
+}
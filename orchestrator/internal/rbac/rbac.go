@@ -0,0 +1,202 @@
+// Package rbac resolves the groups, roles, and permissions a user acts with and maps built-in roles to the
+// permissions the User API Gateway checks per route. See docs/tech_specs/rbac_and_groups.md.
+package rbac
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+// Permissions checked by the User API Gateway. PermAll grants every permission.
+const (
+	PermAll               = "*"
+	PermTasksRead         = "tasks.read"
+	PermTasksWrite        = "tasks.write"
+	PermChatUse           = "chat.use"
+	PermSkillsRead        = "skills.read"
+	PermSkillsWrite       = "skills.write"
+	PermCredentialsManage = "credentials.manage" // own credentials
+	PermCredentialsAdmin  = "credentials.admin"  // any owner's credentials, group credentials, and rekey
+	PermUsageRead         = "usage.read"
+	PermJobsRead          = "jobs.read"
+	PermJobsManage        = "jobs.manage"
+	PermGroupsRead        = "groups.read"
+	PermGroupsManage      = "groups.manage" // groups, memberships, and role bindings
+	PermUsersManage       = "users.manage"
+	PermPolicyRead        = "policy.read"   // list rules and run the evaluate dry-run
+	PermPolicyManage      = "policy.manage" // create, update, and delete rules
+	PermAuditRead         = "audit.read"    // search and export the unified audit log; admin only
+)
+
+// DefaultRole is applied to users with no active system-scope role binding, directly or through a group.
+const DefaultRole = database.RoleMember
+
+var viewerPermissions = []string{PermTasksRead, PermSkillsRead, PermUsageRead, PermGroupsRead}
+
+var memberPermissions = append(slices.Clone(viewerPermissions),
+	PermTasksWrite, PermChatUse, PermSkillsWrite, PermCredentialsManage)
+
+var rolePermissions = map[string][]string{
+	database.RoleAdmin:    {PermAll},
+	database.RoleOperator: append(slices.Clone(memberPermissions), PermJobsRead, PermJobsManage, PermPolicyRead),
+	database.RoleMember:   memberPermissions,
+	database.RoleViewer:   viewerPermissions,
+}
+
+// RolePermissions returns the permissions granted by a role name; unknown roles grant none.
+func RolePermissions(role string) []string {
+	return slices.Clone(rolePermissions[role])
+}
+
+// Store loads membership and role data; database.Store satisfies it.
+type Store interface {
+	ListGroupIDsForUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+	ListRoleBindings(ctx context.Context, subjects []database.AccessSubject) ([]*models.RoleBinding, error)
+	ListRoles(ctx context.Context) ([]*models.Role, error)
+}
+
+// Access is what a user acts with: active groups (ascending), system-scope roles (sorted by name), and roles held
+// only within specific projects. Scopes is set for requests authenticated with a personal access token and
+// narrows what the roles grant (see ScopeAll and friends); nil means no narrowing.
+type Access struct {
+	UserID       uuid.UUID
+	GroupIDs     []uuid.UUID
+	Roles        []*models.Role
+	ProjectRoles map[uuid.UUID][]*models.Role
+	Scopes       []string
+}
+
+// Resolve loads userID's groups and the roles bound to the user or those groups. A user with no system-scope
+// binding gets DefaultRole.
+func Resolve(ctx context.Context, store Store, userID uuid.UUID) (*Access, error) {
+	groupIDs, err := store.ListGroupIDsForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	subjects := []database.AccessSubject{{Type: database.SubjectTypeUser, ID: userID}}
+	for _, id := range groupIDs {
+		subjects = append(subjects, database.AccessSubject{Type: database.SubjectTypeGroup, ID: id})
+	}
+	bindings, err := store.ListRoleBindings(ctx, subjects)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := store.ListRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*models.Role, len(roles))
+	for _, r := range roles {
+		byID[r.ID] = r
+	}
+	a := &Access{UserID: userID, GroupIDs: groupIDs, ProjectRoles: make(map[uuid.UUID][]*models.Role)}
+	for _, b := range bindings {
+		r := byID[b.RoleID]
+		switch {
+		case r == nil:
+			continue
+		case b.ScopeType == database.ScopeTypeSystem:
+			a.Roles = appendRole(a.Roles, r)
+		case b.ScopeType == database.ScopeTypeProject && b.ScopeID != nil:
+			a.ProjectRoles[*b.ScopeID] = appendRole(a.ProjectRoles[*b.ScopeID], r)
+		}
+	}
+	if len(a.Roles) == 0 {
+		for _, r := range roles {
+			if r.Name == DefaultRole {
+				a.Roles = []*models.Role{r}
+			}
+		}
+	}
+	sort.Slice(a.Roles, func(i, j int) bool { return a.Roles[i].Name < a.Roles[j].Name })
+	return a, nil
+}
+
+func appendRole(roles []*models.Role, r *models.Role) []*models.Role {
+	for _, existing := range roles {
+		if existing.ID == r.ID {
+			return roles
+		}
+	}
+	return append(roles, r)
+}
+
+// Has reports whether a system-scope role grants perm and the token scopes, if any, allow it. A nil Access has
+// no permissions.
+func (a *Access) Has(perm string) bool {
+	return a != nil && rolesGrant(a.Roles, perm) && a.scopesAllow(perm)
+}
+
+// HasInProject reports whether perm is granted system-wide or by a role bound in projectID.
+func (a *Access) HasInProject(perm string, projectID uuid.UUID) bool {
+	return a.Has(perm) || (a != nil && rolesGrant(a.ProjectRoles[projectID], perm) && a.scopesAllow(perm))
+}
+
+// InProject reports whether the user holds a role bound in projectID or a system role granting every permission.
+// A user's default project is not bound through a role; callers check it separately.
+func (a *Access) InProject(projectID uuid.UUID) bool {
+	return a != nil && (len(a.ProjectRoles[projectID]) > 0 || rolesGrant(a.Roles, PermAll))
+}
+
+func (a *Access) scopesAllow(perm string) bool {
+	return a.Scopes == nil || ScopesAllow(a.Scopes, perm)
+}
+
+// InGroup reports whether the user is an active member of groupID.
+func (a *Access) InGroup(groupID uuid.UUID) bool {
+	return a != nil && slices.Contains(a.GroupIDs, groupID)
+}
+
+// RoleNames returns the system-scope role names.
+func (a *Access) RoleNames() []string {
+	if a == nil {
+		return nil
+	}
+	names := make([]string, 0, len(a.Roles))
+	for _, r := range a.Roles {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+// RoleIDs returns the system-scope role ids.
+func (a *Access) RoleIDs() []uuid.UUID {
+	if a == nil {
+		return nil
+	}
+	ids := make([]uuid.UUID, 0, len(a.Roles))
+	for _, r := range a.Roles {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+func rolesGrant(roles []*models.Role, perm string) bool {
+	for _, r := range roles {
+		for _, p := range rolePermissions[r.Name] {
+			if p == PermAll || p == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type contextKey struct{}
+
+// WithAccess returns ctx carrying a.
+func WithAccess(ctx context.Context, a *Access) context.Context {
+	return context.WithValue(ctx, contextKey{}, a)
+}
+
+// FromContext returns the Access stored by WithAccess, or nil.
+func FromContext(ctx context.Context) *Access {
+	a, _ := ctx.Value(contextKey{}).(*Access)
+	return a
+}
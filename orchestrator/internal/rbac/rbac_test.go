@@ -0,0 +1,128 @@
+package rbac
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
+)
+
+func bind(db *testutil.MockDB, subjectType string, subjectID uuid.UUID, role string, projectID *uuid.UUID) {
+	b := &models.RoleBinding{SubjectType: subjectType, SubjectID: subjectID, ScopeType: database.ScopeTypeSystem, ScopeID: projectID}
+	if projectID != nil {
+		b.ScopeType = database.ScopeTypeProject
+	}
+	for _, r := range database.BuiltinRoles() {
+		if r.Name == role {
+			b.RoleID = r.ID
+		}
+	}
+	_ = db.CreateRoleBinding(context.Background(), b)
+}
+
+func TestResolve_DefaultRole(t *testing.T) {
+	a, err := Resolve(context.Background(), testutil.NewMockDB(), uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := a.RoleNames(); len(names) != 1 || names[0] != DefaultRole {
+		t.Fatalf("roles = %v", names)
+	}
+	if !a.Has(PermTasksWrite) || !a.Has(PermCredentialsManage) || a.Has(PermJobsManage) || a.Has(PermGroupsManage) {
+		t.Errorf("member permissions wrong")
+	}
+}
+
+func TestResolve_GroupRoleReplacesDefault(t *testing.T) {
+	db := testutil.NewMockDB()
+	ctx := context.Background()
+	user := uuid.New()
+	group := &models.Group{Slug: "ops", DisplayName: "ops", IsActive: true}
+	_ = db.CreateGroup(ctx, group)
+	_, _ = db.AddGroupMember(ctx, group.ID, user, "admin")
+	bind(db, database.SubjectTypeGroup, group.ID, database.RoleViewer, nil)
+
+	a, err := Resolve(ctx, db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := a.RoleNames(); len(names) != 1 || names[0] != database.RoleViewer || !a.InGroup(group.ID) {
+		t.Fatalf("access = %+v", a)
+	}
+	if a.Has(PermTasksWrite) || !a.Has(PermTasksRead) {
+		t.Errorf("viewer permissions wrong")
+	}
+
+	bind(db, database.SubjectTypeUser, user, database.RoleAdmin, nil)
+	if a, _ = Resolve(ctx, db, user); !a.Has(PermUsersManage) || len(a.RoleIDs()) != 2 {
+		t.Errorf("admin not applied: %v", a.RoleNames())
+	}
+}
+
+func TestAccess_HasInProject(t *testing.T) {
+	db := testutil.NewMockDB()
+	user, project := uuid.New(), uuid.New()
+	bind(db, database.SubjectTypeUser, user, database.RoleOperator, &project)
+	a, err := Resolve(context.Background(), db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A project-only binding leaves the default system role in place.
+	if a.Has(PermJobsManage) || !a.HasInProject(PermJobsManage, project) || a.HasInProject(PermJobsManage, uuid.New()) {
+		t.Errorf("project scoping wrong: %+v", a)
+	}
+	var none *Access
+	if none.Has(PermTasksRead) || none.InGroup(project) || none.RoleNames() != nil {
+		t.Error("nil access grants")
+	}
+}
+
+func TestRolePermissions(t *testing.T) {
+	if got := RolePermissions(database.RoleAdmin); len(got) != 1 || got[0] != PermAll {
+		t.Errorf("admin = %v", got)
+	}
+	if got := RolePermissions("unknown"); len(got) != 0 {
+		t.Errorf("unknown = %v", got)
+	}
+	for _, role := range []string{database.RoleOperator, database.RoleMember, database.RoleViewer} {
+		if slices.Contains(RolePermissions(role), PermAuditRead) {
+			t.Errorf("%s must not read the audit log", role)
+		}
+	}
+}
+
+func TestAccess_ScopesNarrowRoles(t *testing.T) {
+	db := testutil.NewMockDB()
+	admin := uuid.New()
+	bind(db, database.SubjectTypeUser, admin, database.RoleAdmin, nil)
+	a, err := Resolve(context.Background(), db, admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Scopes = []string{ScopeReadOnly}
+	if !a.Has(PermTasksRead) || !a.Has(PermAuditRead) || a.Has(PermTasksWrite) || a.Has(PermUsersManage) {
+		t.Error("read-only scope should allow reads only")
+	}
+	a.Scopes = []string{ScopeTasksWrite, ScopeChat}
+	if !a.Has(PermTasksWrite) || !a.Has(PermChatUse) || a.Has(PermSkillsWrite) {
+		t.Error("tasks:write and chat scopes wrong")
+	}
+	a.Scopes = []string{}
+	if a.Has(PermTasksRead) {
+		t.Error("empty scope list should allow nothing")
+	}
+
+	member, _ := Resolve(context.Background(), db, uuid.New())
+	member.Scopes = []string{ScopeAll}
+	if !member.Has(PermTasksWrite) || member.Has(PermUsersManage) {
+		t.Error("all scope must not add to the member role")
+	}
+	if !ValidScope(ScopeTasksWrite) || ValidScope("tasks.write") {
+		t.Error("ValidScope")
+	}
+}
@@ -0,0 +1,54 @@
+package rbac
+
+import (
+	"slices"
+	"sort"
+)
+
+// Personal access token scopes. A token's scopes narrow what its owner's roles grant and never add to them, so a
+// read-only token held by an admin cannot change anything.
+const (
+	ScopeAll         = "all"
+	ScopeReadOnly    = "read-only"
+	ScopeTasksRead   = "tasks:read"
+	ScopeTasksWrite  = "tasks:write"
+	ScopeChat        = "chat"
+	ScopeSkillsWrite = "skills:write"
+)
+
+var scopePermissions = map[string][]string{
+	ScopeAll: {PermAll},
+	ScopeReadOnly: {PermTasksRead, PermSkillsRead, PermUsageRead, PermJobsRead, PermGroupsRead, PermPolicyRead,
+		PermAuditRead},
+	ScopeTasksRead:   {PermTasksRead},
+	ScopeTasksWrite:  {PermTasksRead, PermTasksWrite},
+	ScopeChat:        {PermChatUse},
+	ScopeSkillsWrite: {PermSkillsRead, PermSkillsWrite},
+}
+
+// ValidScope reports whether s is a known token scope.
+func ValidScope(s string) bool {
+	_, ok := scopePermissions[s]
+	return ok
+}
+
+// Scopes returns the known token scopes, sorted.
+func Scopes() []string {
+	out := make([]string, 0, len(scopePermissions))
+	for s := range scopePermissions {
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ScopesAllow reports whether any of scopes allows perm.
+func ScopesAllow(scopes []string, perm string) bool {
+	for _, s := range scopes {
+		perms := scopePermissions[s]
+		if slices.Contains(perms, PermAll) || slices.Contains(perms, perm) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package testutil
+
+import (
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// GitServer is a stand-in Git host: git http-backend over plain http serving bare repositories under Root.
+// Requests must authenticate with basic auth whose password is Token (the way Git egress sends provider tokens).
+type GitServer struct {
+	URL   string
+	Root  string
+	Token string
+}
+
+// NewGitServer starts a GitServer for the test, or skips the test when git or git-http-backend is unavailable.
+func NewGitServer(t testing.TB, token string) *GitServer {
+	t.Helper()
+	out, err := exec.Command("git", "--exec-path").Output()
+	if err != nil {
+		t.Skip("git not available")
+	}
+	backend := filepath.Join(strings.TrimSpace(string(out)), "git-http-backend")
+	if _, err := os.Stat(backend); err != nil {
+		t.Skip("git-http-backend not available")
+	}
+	s := &GitServer{Root: t.TempDir(), Token: token}
+	h := &cgi.Handler{Path: backend, Env: []string{"GIT_PROJECT_ROOT=" + s.Root, "GIT_HTTP_EXPORT_ALL=1"}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pass, ok := r.BasicAuth(); !ok || pass != s.Token {
+			w.Header().Set("WWW-Authenticate", `Basic realm="git"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	s.URL = srv.URL
+	return s
+}
+
+// CreateRepo creates a pushable bare repository at Root/<identifier>.git whose main branch has one commit, and
+// returns that commit.
+func (s *GitServer) CreateRepo(t testing.TB, identifier string) string {
+	t.Helper()
+	bare := filepath.Join(s.Root, identifier+".git")
+	Git(t, "", "init", "--quiet", "--bare", "--initial-branch=main", bare)
+	Git(t, bare, "config", "http.receivepack", "true")
+	work := t.TempDir()
+	Git(t, work, "init", "--quiet", "--initial-branch=main")
+	if err := os.WriteFile(filepath.Join(work, "README.md"), []byte("# "+identifier+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	Git(t, work, "add", "README.md")
+	Git(t, work, "commit", "--quiet", "-m", "initial")
+	Git(t, work, "push", "--quiet", bare, "main")
+	return Git(t, work, "rev-parse", "HEAD")
+}
+
+// Git runs git in dir (the current directory when empty) with a fixed identity and no user config, fails the
+// test on error, and returns trimmed output.
+func Git(t testing.TB, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "HOME="+os.TempDir(), "GIT_CONFIG_NOSYSTEM=1",
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -4,6 +4,8 @@ package testutil
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -27,6 +29,7 @@ type MockDB struct {
 	PasswordCreds         map[uuid.UUID]*models.PasswordCredential
 	RefreshSessions       map[uuid.UUID]*models.RefreshSession
 	SessionsByHash        map[string]*models.RefreshSession
+	AccessTokenDenials    map[uuid.UUID]*models.AccessTokenDenial
 	Nodes                 map[uuid.UUID]*models.Node
 	NodesBySlug           map[string]*models.Node
 	Projects              map[uuid.UUID]*models.Project
@@ -34,6 +37,10 @@ type MockDB struct {
 	Tasks                 map[uuid.UUID]*models.Task
 	Jobs                  map[uuid.UUID]*models.Job
 	JobsByTask            map[uuid.UUID][]*models.Job
+	JobAttempts           map[uuid.UUID][]*models.JobAttempt
+	ProjectPlans          map[uuid.UUID]*models.ProjectPlan
+	TaskDependencies      []*models.TaskDependency
+	ProjectGitRepos       []*models.ProjectGitRepo
 	CapabilityHistory     []*NodeCapabilitySnapshot
 	AuditLogs             []*AuthAuditLog
 	ChatThreads           map[uuid.UUID]*models.ChatThread
@@ -43,11 +50,33 @@ type MockDB struct {
 	Skills                map[uuid.UUID]*models.Skill
 	TaskWorkflowLeases    map[uuid.UUID]*models.TaskWorkflowLease
 	WorkflowCheckpoints   map[uuid.UUID]*models.WorkflowCheckpoint
+	TokenUsage            []*models.TokenUsage
+	AccessTokens          []*models.PersonalAccessToken
+
+	// SandboxImageStatus maps image ref -> node ID -> availability status (scheduler tests).
+	SandboxImageStatus map[string]map[uuid.UUID]string
 
 	// Access control and API egress (for handler tests).
 	AccessControlRules              []*models.AccessControlRule
 	HasActiveApiCredential          bool
+	ApiCredentials                  []*models.ApiCredential
 	HasAnyActiveApiCredentialResult bool // for control-plane inference-path readiness (external key)
+	AccessControlAuditLogs          []*models.AccessControlAuditLog
+	McpToolCallAuditLogs            []*models.McpToolCallAuditLog
+
+	// API Egress limits and their counters (api-egress tests).
+	ApiEgressLimits   []*models.ApiEgressLimit
+	ApiEgressUsage    []*models.ApiEgressUsage
+	ApiEgressInflight []*models.ApiEgressInflight
+
+	// Groups and RBAC. Roles is seeded with database.BuiltinRoles.
+	Groups           map[uuid.UUID]*models.Group
+	GroupMemberships []*models.GroupMembership
+	Roles            []*models.Role
+	RoleBindings     []*models.RoleBinding
+
+	// AuditEvents backs SearchAuditEvents; tests seed it directly since the Create*AuditLog methods do not store.
+	AuditEvents []*models.AuditEvent
 
 	// Error injection
 	ForceError error
@@ -102,6 +131,7 @@ func NewMockDB() *MockDB {
 		PasswordCreds:         make(map[uuid.UUID]*models.PasswordCredential),
 		RefreshSessions:       make(map[uuid.UUID]*models.RefreshSession),
 		SessionsByHash:        make(map[string]*models.RefreshSession),
+		AccessTokenDenials:    make(map[uuid.UUID]*models.AccessTokenDenial),
 		Nodes:                 make(map[uuid.UUID]*models.Node),
 		NodesBySlug:           make(map[string]*models.Node),
 		Projects:              make(map[uuid.UUID]*models.Project),
@@ -109,11 +139,16 @@ func NewMockDB() *MockDB {
 		Tasks:                 make(map[uuid.UUID]*models.Task),
 		Jobs:                  make(map[uuid.UUID]*models.Job),
 		JobsByTask:            make(map[uuid.UUID][]*models.Job),
+		JobAttempts:           make(map[uuid.UUID][]*models.JobAttempt),
+		ProjectPlans:          make(map[uuid.UUID]*models.ProjectPlan),
 		ChatThreads:           make(map[uuid.UUID]*models.ChatThread),
 		ChatMessages:          make(map[uuid.UUID][]*models.ChatMessage),
 		Skills:                make(map[uuid.UUID]*models.Skill),
 		TaskWorkflowLeases:    make(map[uuid.UUID]*models.TaskWorkflowLease),
 		WorkflowCheckpoints:   make(map[uuid.UUID]*models.WorkflowCheckpoint),
+		AccessControlRules:    database.DefaultAccessControlRules(),
+		Groups:                make(map[uuid.UUID]*models.Group),
+		Roles:                 database.BuiltinRoles(),
 	}
 }
 
@@ -168,23 +203,11 @@ func isTerminalTaskStatus(status string) bool {
 	}
 }
 
-func (m *MockDB) setStatusAndUpdatedAt(id uuid.UUID, status string, forTask bool) error {
+func (m *MockDB) setJobStatus(id uuid.UUID, status string) error {
 	return runWithWLockErr(m, func() error {
-		now := time.Now().UTC()
-		if forTask {
-			if t, ok := m.Tasks[id]; ok {
-				// Mirror DB guard: do not overwrite a terminal status.
-				if isTerminalTaskStatus(t.Status) {
-					return nil
-				}
-				t.Status = status
-				t.UpdatedAt = now
-			}
-		} else {
-			if j, ok := m.Jobs[id]; ok {
-				j.Status = status
-				j.UpdatedAt = now
-			}
+		if j, ok := m.Jobs[id]; ok {
+			j.Status = status
+			j.UpdatedAt = time.Now().UTC()
 		}
 		return nil
 	})
@@ -217,6 +240,40 @@ func (m *MockDB) GetUserByID(_ context.Context, id uuid.UUID) (*models.User, err
 	return getByKeyLocked(m, m.Users, id)
 }
 
+// GetUserByExternalID returns the user linked to source and externalID.
+func (m *MockDB) GetUserByExternalID(_ context.Context, source, externalID string) (*models.User, error) {
+	return runWithLock(m, false, func() (*models.User, error) {
+		for _, u := range m.Users {
+			if u.ExternalSource != nil && *u.ExternalSource == source && u.ExternalID != nil && *u.ExternalID == externalID {
+				return u, nil
+			}
+		}
+		return nil, database.ErrNotFound
+	})
+}
+
+// CreateExternalUser creates a user linked to an external identity; a taken email is dropped.
+func (m *MockDB) CreateExternalUser(_ context.Context, handle string, email *string, source, externalID string) (*models.User, error) {
+	return runWithLock(m, true, func() (*models.User, error) {
+		if _, ok := m.UsersByHandle[handle]; ok {
+			return nil, database.ErrExists
+		}
+		for _, u := range m.Users {
+			if email != nil && u.Email != nil && *u.Email == *email {
+				email = nil
+			}
+		}
+		now := time.Now().UTC()
+		user := &models.User{
+			ID: uuid.New(), Handle: handle, Email: email, IsActive: true,
+			ExternalSource: &source, ExternalID: &externalID, CreatedAt: now, UpdatedAt: now,
+		}
+		m.Users[user.ID] = user
+		m.UsersByHandle[handle] = user
+		return user, nil
+	})
+}
+
 // CreatePasswordCredential creates a password credential.
 func (m *MockDB) CreatePasswordCredential(_ context.Context, userID uuid.UUID, passwordHash []byte, hashAlg string) (*models.PasswordCredential, error) {
 	return runWithLock(m, true, func() (*models.PasswordCredential, error) {
@@ -238,21 +295,61 @@ func (m *MockDB) GetPasswordCredentialByUserID(_ context.Context, userID uuid.UU
 	return getByKeyLocked(m, m.PasswordCreds, userID)
 }
 
-// CreateRefreshSession creates a refresh session.
-func (m *MockDB) CreateRefreshSession(_ context.Context, userID uuid.UUID, tokenHash []byte, expiresAt time.Time) (*models.RefreshSession, error) {
-	return runWithLock(m, true, func() (*models.RefreshSession, error) {
-		session := &models.RefreshSession{
-			ID:               uuid.New(),
-			UserID:           userID,
-			RefreshTokenHash: tokenHash,
-			IsActive:         true,
-			ExpiresAt:        expiresAt,
-			CreatedAt:        time.Now().UTC(),
-			UpdatedAt:        time.Now().UTC(),
-		}
-		m.RefreshSessions[session.ID] = session
-		m.SessionsByHash[string(tokenHash)] = session
-		return session, nil
+// CreateRefreshSession creates a refresh session; ID and timestamps are set when zero.
+func (m *MockDB) CreateRefreshSession(_ context.Context, s *models.RefreshSession) error {
+	return runWithWLockErr(m, func() error {
+		if s.ID == uuid.Nil {
+			s.ID = uuid.New()
+		}
+		now := time.Now().UTC()
+		s.IsActive, s.CreatedAt, s.UpdatedAt = true, now, now
+		m.RefreshSessions[s.ID] = s
+		m.SessionsByHash[string(s.RefreshTokenHash)] = s
+		return nil
+	})
+}
+
+// GetRefreshSessionByID returns a session by id, active or not.
+func (m *MockDB) GetRefreshSessionByID(_ context.Context, id uuid.UUID) (*models.RefreshSession, error) {
+	return getByKeyLocked(m, m.RefreshSessions, id)
+}
+
+// ListActiveRefreshSessions returns userID's active, unexpired sessions, newest first.
+func (m *MockDB) ListActiveRefreshSessions(_ context.Context, userID uuid.UUID) ([]*models.RefreshSession, error) {
+	return runWithLock(m, false, func() ([]*models.RefreshSession, error) {
+		var out []*models.RefreshSession
+		now := time.Now()
+		for _, s := range m.RefreshSessions {
+			if s.UserID == userID && s.IsActive && s.ExpiresAt.After(now) {
+				out = append(out, s)
+			}
+		}
+		slices.SortFunc(out, func(a, b *models.RefreshSession) int { return b.CreatedAt.Compare(a.CreatedAt) })
+		return out, nil
+	})
+}
+
+// RotateRefreshSession stores s's new token hash, expiries, and client when the session still holds oldTokenHash.
+func (m *MockDB) RotateRefreshSession(_ context.Context, s *models.RefreshSession, oldTokenHash []byte) error {
+	return runWithWLockErr(m, func() error {
+		cur, ok := m.SessionsByHash[string(oldTokenHash)]
+		if !ok || cur.ID != s.ID || !cur.IsActive {
+			return database.ErrNotFound
+		}
+		now := time.Now().UTC()
+		delete(m.SessionsByHash, string(oldTokenHash))
+		cur.RefreshTokenHash, cur.ExpiresAt, cur.AccessExpiresAt = s.RefreshTokenHash, s.ExpiresAt, s.AccessExpiresAt
+		cur.UserAgent, cur.IPAddress, cur.LastUsedAt, cur.UpdatedAt = s.UserAgent, s.IPAddress, &now, now
+		m.SessionsByHash[string(cur.RefreshTokenHash)] = cur
+		return nil
+	})
+}
+
+// IsAccessSessionDenied reports whether access tokens of sessionID are on the deny-list.
+func (m *MockDB) IsAccessSessionDenied(_ context.Context, sessionID uuid.UUID) (bool, error) {
+	return runWithLock(m, false, func() (bool, error) {
+		d, ok := m.AccessTokenDenials[sessionID]
+		return ok && d.ExpiresAt.After(time.Now()), nil
 	})
 }
 
@@ -267,14 +364,21 @@ func (m *MockDB) GetActiveRefreshSession(_ context.Context, tokenHash []byte) (*
 	})
 }
 
-// invalidateSessionsWhere marks sessions matching pred as inactive. Caller must hold m.mu.
+// invalidateSessionsWhere marks active sessions matching pred as inactive and denies their unexpired access
+// tokens. Caller must hold m.mu.
 func (m *MockDB) invalidateSessionsWhere(pred func(*models.RefreshSession) bool) {
 	now := time.Now().UTC()
 	for _, session := range m.RefreshSessions {
-		if pred(session) {
-			session.IsActive = false
-			session.UpdatedAt = now
+		if !session.IsActive || !pred(session) {
+			continue
+		}
+		if session.AccessExpiresAt != nil && session.AccessExpiresAt.After(now) {
+			m.AccessTokenDenials[session.ID] = &models.AccessTokenDenial{
+				SessionID: session.ID, UserID: session.UserID, ExpiresAt: *session.AccessExpiresAt, CreatedAt: now,
+			}
 		}
+		session.IsActive = false
+		session.UpdatedAt = now
 	}
 }
 
@@ -296,6 +400,192 @@ func (m *MockDB) InvalidateAllUserSessions(_ context.Context, userID uuid.UUID)
 	return m.invalidateSessionsWithPred(func(s *models.RefreshSession) bool { return s.UserID == userID })
 }
 
+// ListUsers returns all users ordered by handle.
+func (m *MockDB) ListUsers(_ context.Context) ([]*models.User, error) {
+	return runWithLock(m, false, func() ([]*models.User, error) {
+		out := make([]*models.User, 0, len(m.Users))
+		for _, u := range m.Users {
+			out = append(out, u)
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Handle < out[j].Handle })
+		return out, nil
+	})
+}
+
+// SetUserActive enables or disables a user; ErrNotFound when missing.
+func (m *MockDB) SetUserActive(_ context.Context, id uuid.UUID, active bool) error {
+	return runWithWLockErr(m, func() error {
+		u, ok := m.Users[id]
+		if !ok {
+			return database.ErrNotFound
+		}
+		u.IsActive, u.UpdatedAt = active, time.Now().UTC()
+		return nil
+	})
+}
+
+// DeleteUser removes a user with their password, sessions, access tokens, memberships, and role bindings.
+func (m *MockDB) DeleteUser(_ context.Context, id uuid.UUID) error {
+	return runWithWLockErr(m, func() error {
+		u, ok := m.Users[id]
+		if !ok {
+			return database.ErrNotFound
+		}
+		m.invalidateSessionsWhere(func(s *models.RefreshSession) bool { return s.UserID == id })
+		delete(m.Users, id)
+		delete(m.UsersByHandle, u.Handle)
+		delete(m.PasswordCreds, id)
+		for k, s := range m.RefreshSessions {
+			if s.UserID == id {
+				delete(m.RefreshSessions, k)
+				delete(m.SessionsByHash, string(s.RefreshTokenHash))
+			}
+		}
+		m.AccessTokens = slices.DeleteFunc(m.AccessTokens, func(t *models.PersonalAccessToken) bool { return t.UserID == id })
+		m.GroupMemberships = slices.DeleteFunc(m.GroupMemberships, func(gm *models.GroupMembership) bool { return gm.UserID == id })
+		m.RoleBindings = slices.DeleteFunc(m.RoleBindings, func(b *models.RoleBinding) bool {
+			return b.SubjectType == database.SubjectTypeUser && b.SubjectID == id
+		})
+		return nil
+	})
+}
+
+// SetPassword replaces or creates userID's password credential.
+func (m *MockDB) SetPassword(_ context.Context, userID uuid.UUID, passwordHash []byte, hashAlg string, mustChange bool) error {
+	return runWithWLockErr(m, func() error {
+		now := time.Now().UTC()
+		cred, ok := m.PasswordCreds[userID]
+		if !ok {
+			cred = &models.PasswordCredential{ID: uuid.New(), UserID: userID, CreatedAt: now}
+			m.PasswordCreds[userID] = cred
+		}
+		cred.PasswordHash, cred.HashAlg, cred.MustChange, cred.UpdatedAt = passwordHash, hashAlg, mustChange, now
+		return nil
+	})
+}
+
+// CreateUserWithPassword creates an active user with a password credential.
+func (m *MockDB) CreateUserWithPassword(_ context.Context, handle string, email *string, passwordHash []byte, hashAlg string, mustChange bool) (*models.User, error) {
+	return runWithLock(m, true, func() (*models.User, error) {
+		now := time.Now().UTC()
+		user := &models.User{ID: uuid.New(), Handle: handle, Email: email, IsActive: true, CreatedAt: now, UpdatedAt: now}
+		m.Users[user.ID] = user
+		m.UsersByHandle[handle] = user
+		m.PasswordCreds[user.ID] = &models.PasswordCredential{
+			ID: uuid.New(), UserID: user.ID, PasswordHash: passwordHash, HashAlg: hashAlg, MustChange: mustChange,
+			CreatedAt: now, UpdatedAt: now,
+		}
+		return user, nil
+	})
+}
+
+// CreateServiceAccount creates a service-account user; ErrExists when the handle is taken.
+func (m *MockDB) CreateServiceAccount(_ context.Context, handle string) (*models.User, error) {
+	return runWithLock(m, true, func() (*models.User, error) {
+		if _, ok := m.UsersByHandle[handle]; ok {
+			return nil, database.ErrExists
+		}
+		now := time.Now().UTC()
+		user := &models.User{ID: uuid.New(), Handle: handle, IsActive: true, IsServiceAccount: true, CreatedAt: now, UpdatedAt: now}
+		m.Users[user.ID] = user
+		m.UsersByHandle[handle] = user
+		return user, nil
+	})
+}
+
+// ListServiceAccounts returns service-account users ordered by handle.
+func (m *MockDB) ListServiceAccounts(_ context.Context) ([]*models.User, error) {
+	return runWithLock(m, false, func() ([]*models.User, error) {
+		var out []*models.User
+		for _, u := range m.Users {
+			if u.IsServiceAccount {
+				out = append(out, u)
+			}
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Handle < out[j].Handle })
+		return out, nil
+	})
+}
+
+// CreatePersonalAccessToken stores t.
+func (m *MockDB) CreatePersonalAccessToken(_ context.Context, t *models.PersonalAccessToken) error {
+	return runWithWLockErr(m, func() error {
+		if t.ID == uuid.Nil {
+			t.ID = uuid.New()
+		}
+		now := time.Now().UTC()
+		t.CreatedAt, t.UpdatedAt = now, now
+		m.AccessTokens = append(m.AccessTokens, t)
+		return nil
+	})
+}
+
+// GetActivePersonalAccessToken returns the unrevoked, unexpired token with tokenHash.
+func (m *MockDB) GetActivePersonalAccessToken(_ context.Context, tokenHash []byte) (*models.PersonalAccessToken, error) {
+	return runWithLock(m, false, func() (*models.PersonalAccessToken, error) {
+		for _, t := range m.AccessTokens {
+			if string(t.TokenHash) == string(tokenHash) && t.IsActive && (t.ExpiresAt == nil || t.ExpiresAt.After(time.Now())) {
+				return t, nil
+			}
+		}
+		return nil, database.ErrNotFound
+	})
+}
+
+// GetPersonalAccessTokenByID returns a token by id.
+func (m *MockDB) GetPersonalAccessTokenByID(_ context.Context, id uuid.UUID) (*models.PersonalAccessToken, error) {
+	return runWithLock(m, false, func() (*models.PersonalAccessToken, error) { return m.findAccessToken(id) })
+}
+
+func (m *MockDB) findAccessToken(id uuid.UUID) (*models.PersonalAccessToken, error) {
+	for _, t := range m.AccessTokens {
+		if t.ID == id {
+			return t, nil
+		}
+	}
+	return nil, database.ErrNotFound
+}
+
+// ListPersonalAccessTokens returns userID's tokens, newest first.
+func (m *MockDB) ListPersonalAccessTokens(_ context.Context, userID uuid.UUID) ([]*models.PersonalAccessToken, error) {
+	return runWithLock(m, false, func() ([]*models.PersonalAccessToken, error) {
+		var out []*models.PersonalAccessToken
+		for i := len(m.AccessTokens) - 1; i >= 0; i-- {
+			if m.AccessTokens[i].UserID == userID {
+				out = append(out, m.AccessTokens[i])
+			}
+		}
+		return out, nil
+	})
+}
+
+// RevokePersonalAccessToken deactivates a token.
+func (m *MockDB) RevokePersonalAccessToken(_ context.Context, id uuid.UUID) error {
+	return runWithWLockErr(m, func() error {
+		t, err := m.findAccessToken(id)
+		if err != nil {
+			return err
+		}
+		t.IsActive, t.UpdatedAt = false, time.Now().UTC()
+		return nil
+	})
+}
+
+// TouchPersonalAccessToken sets last_used_at at most once per database.PersonalAccessTokenTouchInterval.
+func (m *MockDB) TouchPersonalAccessToken(_ context.Context, id uuid.UUID) error {
+	return runWithWLockErr(m, func() error {
+		t, err := m.findAccessToken(id)
+		if err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		if t.LastUsedAt == nil || t.LastUsedAt.Before(now.Add(-database.PersonalAccessTokenTouchInterval)) {
+			t.LastUsedAt = &now
+		}
+		return nil
+	})
+}
+
 // CreateAuthAuditLog creates an auth audit log entry (subjectHandle, reason per Store).
 func (m *MockDB) CreateAuthAuditLog(_ context.Context, userID *uuid.UUID, eventType string, success bool, ipAddr, userAgent, subjectHandle, reason *string) error {
 	return runWithWLockErr(m, func() error {
@@ -319,6 +609,18 @@ func (m *MockDB) GetNodeByID(_ context.Context, id uuid.UUID) (*models.Node, err
 	return getByKeyLocked(m, m.Nodes, id)
 }
 
+// GetNodeByWorkerAPIToken returns the node whose Worker API bearer token is token.
+func (m *MockDB) GetNodeByWorkerAPIToken(_ context.Context, token string) (*models.Node, error) {
+	return runWithLock(m, false, func() (*models.Node, error) {
+		for _, n := range m.Nodes {
+			if token != "" && n.WorkerAPIBearerToken != nil && *n.WorkerAPIBearerToken == token {
+				return n, nil
+			}
+		}
+		return nil, database.ErrNotFound
+	})
+}
+
 // ListActiveNodes lists all active nodes.
 func (m *MockDB) ListActiveNodes(_ context.Context) ([]*models.Node, error) {
 	return runWithLock(m, false, func() ([]*models.Node, error) {
@@ -361,6 +663,30 @@ func (m *MockDB) ListDispatchableNodes(_ context.Context) ([]*models.Node, error
 	})
 }
 
+// CountActiveJobsByNode returns the number of running jobs per node.
+func (m *MockDB) CountActiveJobsByNode(_ context.Context) (map[uuid.UUID]int, error) {
+	return runWithLock(m, false, func() (map[uuid.UUID]int, error) {
+		out := make(map[uuid.UUID]int)
+		for _, j := range m.Jobs {
+			if j.Status == models.JobStatusRunning && j.NodeID != nil {
+				out[*j.NodeID]++
+			}
+		}
+		return out, nil
+	})
+}
+
+// GetSandboxImageStatusByNode returns availability status per node from SandboxImageStatus.
+func (m *MockDB) GetSandboxImageStatusByNode(_ context.Context, imageRef string) (map[uuid.UUID]string, error) {
+	return runWithLock(m, false, func() (map[uuid.UUID]string, error) {
+		out := make(map[uuid.UUID]string)
+		for nodeID, status := range m.SandboxImageStatus[imageRef] {
+			out[nodeID] = status
+		}
+		return out, nil
+	})
+}
+
 // CreateTask creates a new task. When taskName is set, mock sets Summary to it for response tests.
 func (m *MockDB) CreateTask(_ context.Context, createdBy *uuid.UUID, prompt string, taskName *string, projectID ...*uuid.UUID) (*models.Task, error) {
 	var effectiveProjectID *uuid.UUID
@@ -368,27 +694,32 @@ func (m *MockDB) CreateTask(_ context.Context, createdBy *uuid.UUID, prompt stri
 		effectiveProjectID = projectID[0]
 	}
 	return runWithLock(m, true, func() (*models.Task, error) {
-		var summary string
-		if taskName != nil {
-			summary = *taskName
-		} else {
-			summary = fmt.Sprintf("task_name_%03d", len(m.Tasks)+1)
-		}
-		task := &models.Task{
-			ID:        uuid.New(),
-			CreatedBy: createdBy,
-			ProjectID: effectiveProjectID,
-			Status:    models.TaskStatusPending,
-			Prompt:    &prompt,
-			Summary:   &summary,
-			CreatedAt: time.Now().UTC(),
-			UpdatedAt: time.Now().UTC(),
-		}
-		m.Tasks[task.ID] = task
-		return task, nil
+		return m.createTaskLocked(createdBy, prompt, taskName, effectiveProjectID), nil
 	})
 }
 
+// createTaskLocked adds a pending task. Caller holds m.mu for writing.
+func (m *MockDB) createTaskLocked(createdBy *uuid.UUID, prompt string, taskName *string, projectID *uuid.UUID) *models.Task {
+	var summary string
+	if taskName != nil {
+		summary = *taskName
+	} else {
+		summary = fmt.Sprintf("task_name_%03d", len(m.Tasks)+1)
+	}
+	task := &models.Task{
+		ID:        uuid.New(),
+		CreatedBy: createdBy,
+		ProjectID: projectID,
+		Status:    models.TaskStatusPending,
+		Prompt:    &prompt,
+		Summary:   &summary,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+	m.Tasks[task.ID] = task
+	return task
+}
+
 // GetOrCreateDefaultProjectForUser returns a deterministic per-user default project from the mock.
 func (m *MockDB) GetOrCreateDefaultProjectForUser(_ context.Context, userID uuid.UUID) (*models.Project, error) {
 	return runWithLock(m, true, func() (*models.Project, error) {
@@ -448,8 +779,113 @@ func taskMatchesUserAndSummary(t *models.Task, userID uuid.UUID, summary string)
 }
 
 // UpdateTaskStatus updates a task's status.
+// Setting failed or canceled cascades to open dependent tasks and cancels their queued jobs.
 func (m *MockDB) UpdateTaskStatus(_ context.Context, taskID uuid.UUID, status string) error {
-	return m.setStatusAndUpdatedAt(taskID, status, true)
+	return runWithWLockErr(m, func() error {
+		t, ok := m.Tasks[taskID]
+		// Mirror DB guard: do not overwrite a terminal status.
+		if !ok || isTerminalTaskStatus(t.Status) {
+			return nil
+		}
+		now := time.Now().UTC()
+		t.Status, t.Closed, t.UpdatedAt = status, isTerminalTaskStatus(status), now
+		if status != models.TaskStatusFailed && status != models.TaskStatusCanceled {
+			return nil
+		}
+		for _, id := range m.dependentsLocked(taskID) {
+			dep, ok := m.Tasks[id]
+			if !ok || isTerminalTaskStatus(dep.Status) {
+				continue
+			}
+			dep.Status, dep.Closed, dep.UpdatedAt = status, true, now
+			for _, j := range m.JobsByTask[id] {
+				if j.Status == models.JobStatusQueued {
+					j.Status, j.EndedAt, j.UpdatedAt = models.JobStatusCanceled, &now, now
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// dependentsLocked returns the tasks that depend on taskID directly or transitively. Caller holds m.mu.
+func (m *MockDB) dependentsLocked(taskID uuid.UUID) []uuid.UUID {
+	seen := map[uuid.UUID]bool{taskID: true}
+	var out []uuid.UUID
+	frontier := []uuid.UUID{taskID}
+	for len(frontier) > 0 {
+		id := frontier[0]
+		frontier = frontier[1:]
+		for _, e := range m.TaskDependencies {
+			if e.DependsOnTaskID == id && !seen[e.TaskID] {
+				seen[e.TaskID] = true
+				out = append(out, e.TaskID)
+				frontier = append(frontier, e.TaskID)
+			}
+		}
+	}
+	return out
+}
+
+// reopenCascadedDependentsLocked reopens the dependents of taskID that failed only because of the cascade
+// (no failed job of their own, no other failed or canceled dependency) and queues their canceled jobs.
+// Caller holds m.mu.
+func (m *MockDB) reopenCascadedDependentsLocked(taskID uuid.UUID, now time.Time) {
+	reopened := map[uuid.UUID]bool{taskID: true}
+	frontier := []uuid.UUID{taskID}
+	for len(frontier) > 0 {
+		id := frontier[0]
+		frontier = frontier[1:]
+		for _, e := range m.TaskDependencies {
+			if e.DependsOnTaskID != id || reopened[e.TaskID] || !m.failedByCascadeLocked(e.TaskID, reopened) {
+				continue
+			}
+			reopened[e.TaskID] = true
+			frontier = append(frontier, e.TaskID)
+			dep := m.Tasks[e.TaskID]
+			dep.Status, dep.Closed, dep.UpdatedAt = models.TaskStatusPending, false, now
+			for _, j := range m.JobsByTask[e.TaskID] {
+				if j.Status == models.JobStatusCanceled {
+					j.Status, j.EndedAt, j.UpdatedAt = models.JobStatusQueued, nil, now
+				}
+			}
+		}
+	}
+}
+
+// failedByCascadeLocked mirrors the DB check for reopenCascadedDependentsLocked. Caller holds m.mu.
+func (m *MockDB) failedByCascadeLocked(taskID uuid.UUID, reopened map[uuid.UUID]bool) bool {
+	task, ok := m.Tasks[taskID]
+	if !ok || task.Status != models.TaskStatusFailed {
+		return false
+	}
+	for _, j := range m.JobsByTask[taskID] {
+		if j.Status == models.JobStatusFailed || j.Status == models.JobStatusDeadLettered {
+			return false
+		}
+	}
+	for _, e := range m.TaskDependencies {
+		if e.TaskID != taskID || reopened[e.DependsOnTaskID] {
+			continue
+		}
+		if d, ok := m.Tasks[e.DependsOnTaskID]; ok && (d.Status == models.TaskStatusFailed || d.Status == models.TaskStatusCanceled) {
+			return false
+		}
+	}
+	return true
+}
+
+// depsSatisfiedLocked reports whether every dependency of taskID is completed. Caller holds m.mu.
+func (m *MockDB) depsSatisfiedLocked(taskID uuid.UUID) bool {
+	for _, e := range m.TaskDependencies {
+		if e.TaskID != taskID {
+			continue
+		}
+		if dep, ok := m.Tasks[e.DependsOnTaskID]; !ok || dep.Status != models.TaskStatusCompleted {
+			return false
+		}
+	}
+	return true
 }
 
 // UpdateTaskSummary updates a task's summary.
@@ -463,6 +899,15 @@ func (m *MockDB) UpdateTaskSummary(_ context.Context, taskID uuid.UUID, summary
 	})
 }
 
+func (m *MockDB) UpdateTaskType(_ context.Context, taskID uuid.UUID, taskType string) error {
+	return runWithWLockErr(m, func() error {
+		if task, ok := m.Tasks[taskID]; ok {
+			task.TaskType = &taskType
+		}
+		return nil
+	})
+}
+
 // ListTasksByUser lists tasks created by a user.
 func (m *MockDB) ListTasksByUser(_ context.Context, userID uuid.UUID, limit, offset int) ([]*models.Task, error) {
 	return runWithLock(m, false, func() ([]*models.Task, error) {
@@ -560,19 +1005,25 @@ func (m *MockDB) GetJobByID(_ context.Context, id uuid.UUID) (*models.Job, error
 
 // UpdateJobStatus updates a job's status.
 func (m *MockDB) UpdateJobStatus(_ context.Context, jobID uuid.UUID, status string) error {
-	return m.setStatusAndUpdatedAt(jobID, status, false)
+	return m.setJobStatus(jobID, status)
 }
 
-// AssignJobToNode assigns a job to a node.
-func (m *MockDB) AssignJobToNode(_ context.Context, jobID, nodeID uuid.UUID) error {
+// AssignJobToNode marks a job queued under leaseID as running on nodeID and records the scheduling reason.
+func (m *MockDB) AssignJobToNode(_ context.Context, jobID, leaseID, nodeID uuid.UUID, reason string) error {
 	return runWithWLockErr(m, func() error {
-		if job, ok := m.Jobs[jobID]; ok {
-			now := time.Now().UTC()
-			job.NodeID = &nodeID
-			job.Status = models.JobStatusRunning
-			job.StartedAt = &now
-			job.UpdatedAt = now
+		job, ok := m.Jobs[jobID]
+		if !ok {
+			return database.ErrNotFound
+		}
+		if job.Status != models.JobStatusQueued || job.LeaseID == nil || *job.LeaseID != leaseID {
+			return database.ErrLeaseHeld
 		}
+		now := time.Now().UTC()
+		job.NodeID = &nodeID
+		job.Status = models.JobStatusRunning
+		job.SchedulingReason = &reason
+		job.StartedAt = &now
+		job.UpdatedAt = now
 		return nil
 	})
 }
@@ -595,7 +1046,7 @@ func (m *MockDB) CompleteJob(_ context.Context, jobID uuid.UUID, result, status
 func (m *MockDB) GetNextQueuedJob(_ context.Context) (*models.Job, error) {
 	return runWithLock(m, false, func() (*models.Job, error) {
 		for _, job := range m.Jobs {
-			if job.Status == models.JobStatusQueued {
+			if job.Status == models.JobStatusQueued && m.depsSatisfiedLocked(job.TaskID) {
 				return job, nil
 			}
 		}
@@ -603,18 +1054,208 @@ func (m *MockDB) GetNextQueuedJob(_ context.Context) (*models.Job, error) {
 	})
 }
 
-// CreateNode creates a new node.
-func (m *MockDB) CreateNode(_ context.Context, nodeSlug string) (*models.Node, error) {
-	return runWithLock(m, true, func() (*models.Node, error) {
-		node := &models.Node{
-			ID:        uuid.New(),
-			NodeSlug:  nodeSlug,
-			Status:    models.NodeStatusRegistered,
-			CreatedAt: time.Now().UTC(),
-			UpdatedAt: time.Now().UTC(),
+// ClaimNextQueuedJob claims the oldest queued job whose lease is unset or expired and sets its lease.
+func (m *MockDB) ClaimNextQueuedJob(_ context.Context, leaseID uuid.UUID, leaseExpiresAt time.Time) (*models.Job, error) {
+	return runWithLock(m, true, func() (*models.Job, error) {
+		now := time.Now().UTC()
+		var next *models.Job
+		for _, job := range m.Jobs {
+			if job.Status != models.JobStatusQueued || (job.LeaseExpiresAt != nil && !job.LeaseExpiresAt.Before(now)) ||
+				(job.NextAttemptAt != nil && job.NextAttemptAt.After(now)) || !m.depsSatisfiedLocked(job.TaskID) {
+				continue
+			}
+			if next == nil || job.CreatedAt.Before(next.CreatedAt) {
+				next = job
+			}
 		}
-		m.Nodes[node.ID] = node
-		m.NodesBySlug[nodeSlug] = node
+		if next == nil {
+			return nil, database.ErrNotFound
+		}
+		id, exp := leaseID, leaseExpiresAt
+		next.LeaseID = &id
+		next.LeaseExpiresAt = &exp
+		next.Attempts++
+		next.UpdatedAt = now
+		return next, nil
+	})
+}
+
+// ReleaseJobClaim clears the lease on a still-queued job held by leaseID.
+func (m *MockDB) ReleaseJobClaim(_ context.Context, jobID, leaseID uuid.UUID) error {
+	return runWithWLockErr(m, func() error {
+		job, ok := m.Jobs[jobID]
+		if ok && job.Status == models.JobStatusQueued && job.LeaseID != nil && *job.LeaseID == leaseID {
+			job.LeaseID = nil
+			job.LeaseExpiresAt = nil
+			job.Attempts = max(job.Attempts-1, 0)
+			job.UpdatedAt = time.Now().UTC()
+		}
+		return nil
+	})
+}
+
+// ExtendJobLease sets lease_expires_at for a running job whose lease matches.
+func (m *MockDB) ExtendJobLease(_ context.Context, jobID, leaseID uuid.UUID, expiresAt time.Time) error {
+	return runWithWLockErr(m, func() error {
+		job, ok := m.Jobs[jobID]
+		if !ok {
+			return database.ErrNotFound
+		}
+		if job.Status != models.JobStatusRunning || job.LeaseID == nil || *job.LeaseID != leaseID {
+			return database.ErrLeaseHeld
+		}
+		exp := expiresAt
+		job.LeaseExpiresAt = &exp
+		job.UpdatedAt = time.Now().UTC()
+		return nil
+	})
+}
+
+// CompleteLeasedJob records a result only while the job is running under leaseID.
+func (m *MockDB) CompleteLeasedJob(_ context.Context, jobID, leaseID uuid.UUID, result, status string) error {
+	return runWithWLockErr(m, func() error {
+		job, ok := m.Jobs[jobID]
+		if !ok {
+			return database.ErrNotFound
+		}
+		if job.Status != models.JobStatusRunning || job.LeaseID == nil || *job.LeaseID != leaseID {
+			return database.ErrLeaseHeld
+		}
+		now := time.Now().UTC()
+		job.Result = models.NewJSONBString(&result)
+		job.Status = status
+		job.EndedAt = &now
+		job.UpdatedAt = now
+		return nil
+	})
+}
+
+// RequeueJobForRetry requeues a job running under leaseID, claimable from nextAttemptAt.
+func (m *MockDB) RequeueJobForRetry(_ context.Context, jobID, leaseID uuid.UUID, result string, nextAttemptAt time.Time) error {
+	return runWithWLockErr(m, func() error {
+		job, ok := m.Jobs[jobID]
+		if !ok {
+			return database.ErrNotFound
+		}
+		if job.Status != models.JobStatusRunning || job.LeaseID == nil || *job.LeaseID != leaseID {
+			return database.ErrLeaseHeld
+		}
+		next := nextAttemptAt
+		job.Status = models.JobStatusQueued
+		job.Result = models.NewJSONBString(&result)
+		job.NodeID, job.LeaseID, job.LeaseExpiresAt, job.SchedulingReason, job.StartedAt = nil, nil, nil, nil, nil
+		job.NextAttemptAt = &next
+		job.UpdatedAt = time.Now().UTC()
+		return nil
+	})
+}
+
+// CreateJobAttempt appends to the job's attempt history.
+func (m *MockDB) CreateJobAttempt(_ context.Context, attempt *models.JobAttempt) error {
+	return runWithWLockErr(m, func() error {
+		if attempt.ID == uuid.Nil {
+			attempt.ID = uuid.New()
+		}
+		if attempt.EndedAt.IsZero() {
+			attempt.EndedAt = time.Now().UTC()
+		}
+		m.JobAttempts[attempt.JobID] = append(m.JobAttempts[attempt.JobID], attempt)
+		return nil
+	})
+}
+
+// ListJobAttempts returns the job's attempt history in insertion order.
+func (m *MockDB) ListJobAttempts(_ context.Context, jobID uuid.UUID) ([]*models.JobAttempt, error) {
+	return runWithLock(m, false, func() ([]*models.JobAttempt, error) {
+		return append([]*models.JobAttempt(nil), m.JobAttempts[jobID]...), nil
+	})
+}
+
+// ListDeadLetteredJobs returns dead-lettered jobs (unordered in the mock).
+func (m *MockDB) ListDeadLetteredJobs(_ context.Context, limit, offset int) ([]*models.Job, error) {
+	return runWithLock(m, false, func() ([]*models.Job, error) {
+		var out []*models.Job
+		for _, job := range m.Jobs {
+			if job.Status == models.JobStatusDeadLettered {
+				out = append(out, job)
+			}
+		}
+		if offset > len(out) {
+			return nil, nil
+		}
+		out = out[offset:]
+		if limit < len(out) {
+			out = out[:limit]
+		}
+		return out, nil
+	})
+}
+
+// RequeueDeadLetteredJob requeues a dead-lettered job with a fresh attempt budget and reopens its failed task.
+func (m *MockDB) RequeueDeadLetteredJob(_ context.Context, jobID uuid.UUID) (*models.Job, error) {
+	return runWithLock(m, true, func() (*models.Job, error) {
+		job, ok := m.Jobs[jobID]
+		if !ok {
+			return nil, database.ErrNotFound
+		}
+		if job.Status != models.JobStatusDeadLettered {
+			return nil, database.ErrConflict
+		}
+		now := time.Now().UTC()
+		job.Status = models.JobStatusQueued
+		job.Attempts = 0
+		job.NodeID, job.LeaseID, job.LeaseExpiresAt, job.NextAttemptAt = nil, nil, nil, nil
+		job.SchedulingReason, job.StartedAt, job.EndedAt = nil, nil, nil
+		job.UpdatedAt = now
+		if task, ok := m.Tasks[job.TaskID]; ok && task.Status == models.TaskStatusFailed {
+			task.Status, task.Closed = models.TaskStatusPending, false
+			task.UpdatedAt = now
+			m.reopenCascadedDependentsLocked(task.ID, now)
+		}
+		return job, nil
+	})
+}
+
+// ListExpiredLeaseJobs returns running jobs whose lease expired before now.
+func (m *MockDB) ListExpiredLeaseJobs(_ context.Context, now time.Time) ([]*models.Job, error) {
+	return runWithLock(m, false, func() ([]*models.Job, error) {
+		var out []*models.Job
+		for _, job := range m.Jobs {
+			if job.Status == models.JobStatusRunning && job.LeaseID != nil && job.LeaseExpiresAt != nil && job.LeaseExpiresAt.Before(now) {
+				expired := *job
+				out = append(out, &expired)
+			}
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].LeaseExpiresAt.Before(*out[j].LeaseExpiresAt) })
+		return out, nil
+	})
+}
+
+// ListRunningJobs returns running jobs that have been assigned to a node.
+func (m *MockDB) ListRunningJobs(_ context.Context) ([]*models.Job, error) {
+	return runWithLock(m, false, func() ([]*models.Job, error) {
+		var out []*models.Job
+		for _, job := range m.Jobs {
+			if job.Status == models.JobStatusRunning && job.NodeID != nil {
+				out = append(out, job)
+			}
+		}
+		return out, nil
+	})
+}
+
+// CreateNode creates a new node.
+func (m *MockDB) CreateNode(_ context.Context, nodeSlug string) (*models.Node, error) {
+	return runWithLock(m, true, func() (*models.Node, error) {
+		node := &models.Node{
+			ID:        uuid.New(),
+			NodeSlug:  nodeSlug,
+			Status:    models.NodeStatusRegistered,
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+		}
+		m.Nodes[node.ID] = node
+		m.NodesBySlug[nodeSlug] = node
 		return node, nil
 	})
 }
@@ -731,9 +1372,12 @@ func (m *MockDB) UpdateNodeWorkerAPIConfig(_ context.Context, nodeID uuid.UUID,
 	})
 }
 
-// CreateMcpToolCallAuditLog is a no-op for the mock unless ForceError is set; satisfies database.Store.
-func (m *MockDB) CreateMcpToolCallAuditLog(_ context.Context, _ *models.McpToolCallAuditLog) error {
-	return runWithWLockErr(m, func() error { return m.ForceError })
+// CreateMcpToolCallAuditLog appends rec to McpToolCallAuditLogs unless ForceError is set.
+func (m *MockDB) CreateMcpToolCallAuditLog(_ context.Context, rec *models.McpToolCallAuditLog) error {
+	return runWithWLockErr(m, func() error {
+		m.McpToolCallAuditLogs = append(m.McpToolCallAuditLogs, rec)
+		return nil
+	})
 }
 
 func matchPreferenceGet(e *models.PreferenceEntry, scopeType string, scopeID *uuid.UUID, key string) bool {
@@ -822,7 +1466,7 @@ func parseInt(s string) (int, error) {
 	return n, err
 }
 
-// GetEffectivePreferencesForTask merges preferences by scope precedence (task > project > user > system); group skipped in mock.
+// GetEffectivePreferencesForTask merges preferences by scope precedence (task > project > user > group > system).
 func (m *MockDB) GetEffectivePreferencesForTask(ctx context.Context, taskID uuid.UUID) (map[string]interface{}, error) {
 	if m.GetEffectivePreferencesForTaskErr != nil {
 		return nil, m.GetEffectivePreferencesForTaskErr
@@ -836,6 +1480,16 @@ func (m *MockDB) GetEffectivePreferencesForTask(ctx context.Context, taskID uuid
 		id *uuid.UUID
 	}{{"system", nil}}
 	if task.CreatedBy != nil {
+		groupIDs, err := m.ListGroupIDsForUser(ctx, *task.CreatedBy)
+		if err != nil {
+			return nil, err
+		}
+		for i := range groupIDs {
+			scopes = append(scopes, struct {
+				t  string
+				id *uuid.UUID
+			}{"group", &groupIDs[i]})
+		}
 		scopes = append(scopes, struct {
 			t  string
 			id *uuid.UUID
@@ -992,6 +1646,43 @@ func (m *MockDB) ListArtifactPathsByTaskID(_ context.Context, taskID uuid.UUID)
 	})
 }
 
+// ListTaskArtifacts returns the task's artifacts ordered by path.
+func (m *MockDB) ListTaskArtifacts(_ context.Context, taskID uuid.UUID) ([]*models.TaskArtifact, error) {
+	return runWithLock(m, false, func() ([]*models.TaskArtifact, error) {
+		var out []*models.TaskArtifact
+		for _, a := range m.TaskArtifacts {
+			if a.TaskID == taskID {
+				out = append(out, a)
+			}
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+		return out, nil
+	})
+}
+
+// UpsertTaskArtifact inserts or replaces the artifact for (task_id, path).
+func (m *MockDB) UpsertTaskArtifact(_ context.Context, art *models.TaskArtifact) error {
+	return runWithWLockErr(m, func() error {
+		now := time.Now().UTC()
+		art.UpdatedAt = now
+		for _, a := range m.TaskArtifacts {
+			if a.TaskID == art.TaskID && a.Path == art.Path {
+				a.StorageRef, a.SizeBytes, a.ContentType, a.ChecksumSHA256, a.UpdatedAt =
+					art.StorageRef, art.SizeBytes, art.ContentType, art.ChecksumSHA256, now
+				art.ID, art.CreatedAt, art.RunID = a.ID, a.CreatedAt, a.RunID
+				return nil
+			}
+		}
+		if art.ID == uuid.Nil {
+			art.ID = uuid.New()
+		}
+		art.CreatedAt = now
+		stored := *art
+		m.TaskArtifacts = append(m.TaskArtifacts, &stored)
+		return nil
+	})
+}
+
 // findActiveThread scans m.ChatThreads for the most recent thread matching (userID, projectID)
 // that was updated within cutoff. Returns nil if none found. Must be called with lock held.
 func (m *MockDB) findActiveThread(userID uuid.UUID, projectID *uuid.UUID, cutoff time.Time) *models.ChatThread {
@@ -1109,12 +1800,13 @@ func (m *MockDB) GetSkillByID(_ context.Context, id uuid.UUID) (*models.Skill, e
 	return getByKeyLocked(m, m.Skills, id)
 }
 
-// ListSkillsForUser returns skills visible to user (owner_id = userID or is_system).
+// ListSkillsForUser returns skills visible to user (owner_id = userID, group skills of the user's groups, or is_system).
 func (m *MockDB) ListSkillsForUser(_ context.Context, userID uuid.UUID, scopeFilter, ownerFilter string) ([]*models.Skill, error) {
 	return runWithLock(m, false, func() ([]*models.Skill, error) {
+		groupIDs := m.groupIDsForUserLocked(userID)
 		var out []*models.Skill
 		for _, s := range m.Skills {
-			if mockSkillVisible(s, userID, scopeFilter) {
+			if (scopeFilter == "" || s.Scope == scopeFilter) && database.SkillVisibleTo(s, userID, groupIDs) {
 				out = append(out, s)
 			}
 		}
@@ -1122,14 +1814,17 @@ func (m *MockDB) ListSkillsForUser(_ context.Context, userID uuid.UUID, scopeFil
 	})
 }
 
-func mockSkillVisible(s *models.Skill, userID uuid.UUID, scopeFilter string) bool {
-	if scopeFilter != "" && s.Scope != scopeFilter {
-		return false
-	}
-	if s.IsSystem {
-		return true
-	}
-	return s.OwnerID != nil && *s.OwnerID == userID
+// SetSkillGroup sets the group a skill is shared with in the mock.
+func (m *MockDB) SetSkillGroup(_ context.Context, id uuid.UUID, groupID *uuid.UUID) (*models.Skill, error) {
+	return runWithLock(m, true, func() (*models.Skill, error) {
+		s, ok := m.Skills[id]
+		if !ok {
+			return nil, database.ErrNotFound
+		}
+		s.GroupID = groupID
+		s.UpdatedAt = time.Now().UTC()
+		return s, nil
+	})
 }
 
 // UpdateSkill updates a skill in the mock.
@@ -1192,6 +1887,133 @@ func (m *MockDB) EnsureDefaultSkill(_ context.Context, content string) error {
 	})
 }
 
+// CreateProjectPlan creates a plan in state draft.
+func (m *MockDB) CreateProjectPlan(_ context.Context, projectID uuid.UUID, planName *string, createdBy *uuid.UUID) (*models.ProjectPlan, error) {
+	return runWithLock(m, true, func() (*models.ProjectPlan, error) {
+		now := time.Now().UTC()
+		plan := &models.ProjectPlan{
+			ID: uuid.New(), ProjectID: projectID, PlanName: planName, State: models.PlanStateDraft,
+			CreatedBy: createdBy, CreatedAt: now, UpdatedAt: now,
+		}
+		m.ProjectPlans[plan.ID] = plan
+		return plan, nil
+	})
+}
+
+// GetProjectPlanByID returns a plan by id.
+func (m *MockDB) GetProjectPlanByID(_ context.Context, planID uuid.UUID) (*models.ProjectPlan, error) {
+	return runWithLock(m, false, func() (*models.ProjectPlan, error) {
+		if plan, ok := m.ProjectPlans[planID]; ok {
+			return plan, nil
+		}
+		return nil, database.ErrNotFound
+	})
+}
+
+// AddTaskToPlan sets the task's plan and dependency edges.
+func (m *MockDB) AddTaskToPlan(_ context.Context, taskID, planID uuid.UUID, dependsOn []uuid.UUID) error {
+	return runWithWLockErr(m, func() error {
+		return m.addTaskToPlanLocked(taskID, planID, dependsOn)
+	})
+}
+
+// CreatePlanTask creates a task and adds it to the plan; on an invalid dependency no task is left behind.
+func (m *MockDB) CreatePlanTask(_ context.Context, createdBy *uuid.UUID, prompt string, taskName *string, projectID *uuid.UUID, planID uuid.UUID, dependsOn []uuid.UUID) (*models.Task, error) {
+	return runWithLock(m, true, func() (*models.Task, error) {
+		task := m.createTaskLocked(createdBy, prompt, taskName, projectID)
+		if err := m.addTaskToPlanLocked(task.ID, planID, dependsOn); err != nil {
+			delete(m.Tasks, task.ID)
+			return nil, err
+		}
+		return task, nil
+	})
+}
+
+// addTaskToPlanLocked implements AddTaskToPlan. Caller holds m.mu for writing.
+func (m *MockDB) addTaskToPlanLocked(taskID, planID uuid.UUID, dependsOn []uuid.UUID) error {
+	task, ok := m.Tasks[taskID]
+	if !ok {
+		return database.ErrNotFound
+	}
+	var planEdges []*models.TaskDependency
+	for _, e := range m.TaskDependencies {
+		if t, ok := m.Tasks[e.TaskID]; ok && t.PlanID != nil && *t.PlanID == planID {
+			planEdges = append(planEdges, e)
+		}
+	}
+	for _, dep := range dependsOn {
+		t, ok := m.Tasks[dep]
+		if !ok || t.PlanID == nil || *t.PlanID != planID ||
+			t.Status == models.TaskStatusFailed || t.Status == models.TaskStatusCanceled {
+			return database.ErrInvalidDependency
+		}
+	}
+	if database.CreatesDependencyCycle(planEdges, taskID, dependsOn) {
+		return database.ErrInvalidDependency
+	}
+	task.PlanID = &planID
+	for _, dep := range dependsOn {
+		m.TaskDependencies = append(m.TaskDependencies, &models.TaskDependency{ID: uuid.New(), TaskID: taskID, DependsOnTaskID: dep})
+	}
+	return nil
+}
+
+// ListPlanTasks returns the plan's tasks, oldest first.
+func (m *MockDB) ListPlanTasks(_ context.Context, planID uuid.UUID) ([]*models.Task, error) {
+	return runWithLock(m, false, func() ([]*models.Task, error) {
+		var out []*models.Task
+		for _, t := range m.Tasks {
+			if t.PlanID != nil && *t.PlanID == planID {
+				out = append(out, t)
+			}
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+		return out, nil
+	})
+}
+
+// ListPlanTaskDependencies returns the dependency edges between the plan's tasks.
+func (m *MockDB) ListPlanTaskDependencies(_ context.Context, planID uuid.UUID) ([]*models.TaskDependency, error) {
+	return runWithLock(m, false, func() ([]*models.TaskDependency, error) {
+		var out []*models.TaskDependency
+		for _, e := range m.TaskDependencies {
+			if t, ok := m.Tasks[e.TaskID]; ok && t.PlanID != nil && *t.PlanID == planID {
+				out = append(out, e)
+			}
+		}
+		return out, nil
+	})
+}
+
+func (m *MockDB) CreateProjectGitRepo(_ context.Context, r *models.ProjectGitRepo) error {
+	return runWithWLockErr(m, func() error {
+		for _, e := range m.ProjectGitRepos {
+			if e.ProjectID == r.ProjectID && e.Provider == r.Provider && e.RepoIdentifier == r.RepoIdentifier {
+				return database.ErrExists
+			}
+		}
+		if r.ID == uuid.Nil {
+			r.ID = uuid.New()
+		}
+		now := time.Now().UTC()
+		r.CreatedAt, r.UpdatedAt = now, now
+		m.ProjectGitRepos = append(m.ProjectGitRepos, r)
+		return nil
+	})
+}
+
+func (m *MockDB) ListProjectGitRepos(_ context.Context, projectID uuid.UUID) ([]*models.ProjectGitRepo, error) {
+	return runWithLock(m, false, func() ([]*models.ProjectGitRepo, error) {
+		var out []*models.ProjectGitRepo
+		for _, r := range m.ProjectGitRepos {
+			if r.ProjectID == projectID {
+				out = append(out, r)
+			}
+		}
+		return out, nil
+	})
+}
+
 func (m *MockDB) EvaluateWorkflowStartGate(_ context.Context, _ *models.Task, _ bool) (string, error) {
 	if m.EvaluateWorkflowStartGateErr != nil {
 		return "", m.EvaluateWorkflowStartGateErr
@@ -1270,50 +2092,509 @@ func (m *MockDB) UpsertWorkflowCheckpoint(_ context.Context, cp *models.Workflow
 	})
 }
 
-func (m *MockDB) ListAccessControlRulesForApiCall(_ context.Context, subjectType string, subjectID *uuid.UUID, action, resourceType string) ([]*models.AccessControlRule, error) {
+func (m *MockDB) ListAccessControlRulesForSubjects(_ context.Context, subjects []database.AccessSubject, action, resourceType string) ([]*models.AccessControlRule, error) {
 	return runWithLock(m, false, func() ([]*models.AccessControlRule, error) {
-		if m.AccessControlRules == nil {
-			return nil, nil
-		}
 		out := make([]*models.AccessControlRule, 0, len(m.AccessControlRules))
 		for _, r := range m.AccessControlRules {
-			if r != nil && r.Action == action && r.ResourceType == resourceType {
+			if r != nil && r.Action == action && r.ResourceType == resourceType && ruleAppliesToSubjects(r, subjects) {
 				out = append(out, r)
 			}
 		}
+		sort.SliceStable(out, func(i, j int) bool { return out[i].Priority > out[j].Priority })
 		return out, nil
 	})
 }
 
-func (m *MockDB) CreateAccessControlAuditLog(_ context.Context, _ *models.AccessControlAuditLog) error {
-	return nil
+func ruleAppliesToSubjects(r *models.AccessControlRule, subjects []database.AccessSubject) bool {
+	if r.SubjectType == database.SubjectTypeSystem && r.SubjectID == nil {
+		return true
+	}
+	for _, s := range subjects {
+		if r.SubjectType == s.Type && r.SubjectID != nil && *r.SubjectID == s.ID {
+			return true
+		}
+	}
+	return false
 }
 
-func (m *MockDB) HasActiveApiCredentialForUserAndProvider(_ context.Context, _ uuid.UUID, _ string) (bool, error) {
-	return runWithLock(m, false, func() (bool, error) {
-		return m.HasActiveApiCredential, nil
+// ListAccessControlRules returns rules matching f, ordered by action, resource type, then priority desc.
+func (m *MockDB) ListAccessControlRules(_ context.Context, f database.AccessControlRuleFilter) ([]*models.AccessControlRule, error) {
+	return runWithLock(m, false, func() ([]*models.AccessControlRule, error) {
+		out := make([]*models.AccessControlRule, 0, len(m.AccessControlRules))
+		for _, r := range m.AccessControlRules {
+			if (f.Action != "" && r.Action != f.Action) || (f.ResourceType != "" && r.ResourceType != f.ResourceType) ||
+				(f.SubjectType != "" && r.SubjectType != f.SubjectType) ||
+				(f.SubjectID != nil && (r.SubjectID == nil || *r.SubjectID != *f.SubjectID)) {
+				continue
+			}
+			out = append(out, r)
+		}
+		sort.SliceStable(out, func(i, j int) bool {
+			if out[i].Action != out[j].Action {
+				return out[i].Action < out[j].Action
+			}
+			if out[i].ResourceType != out[j].ResourceType {
+				return out[i].ResourceType < out[j].ResourceType
+			}
+			return out[i].Priority > out[j].Priority
+		})
+		return out, nil
 	})
 }
 
-func (m *MockDB) HasAnyActiveApiCredential(_ context.Context) (bool, error) {
-	return runWithLock(m, false, func() (bool, error) {
-		return m.HasAnyActiveApiCredentialResult, nil
+func (m *MockDB) GetAccessControlRuleByID(_ context.Context, id uuid.UUID) (*models.AccessControlRule, error) {
+	return runWithLock(m, false, func() (*models.AccessControlRule, error) {
+		for _, r := range m.AccessControlRules {
+			if r.ID == id {
+				return r, nil
+			}
+		}
+		return nil, database.ErrNotFound
 	})
 }
 
-// AddUser adds a pre-created user to the mock database.
-func (m *MockDB) AddUser(user *models.User) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	m.Users[user.ID] = user
-	m.UsersByHandle[user.Handle] = user
+func (m *MockDB) CreateAccessControlRule(_ context.Context, r *models.AccessControlRule) error {
+	return runWithWLockErr(m, func() error {
+		if r.ID == uuid.Nil {
+			r.ID = uuid.New()
+		}
+		now := time.Now().UTC()
+		r.CreatedAt, r.UpdatedAt = now, now
+		m.AccessControlRules = append(m.AccessControlRules, r)
+		return nil
+	})
 }
 
-// AddPasswordCredential adds a pre-created credential to the mock database.
-func (m *MockDB) AddPasswordCredential(cred *models.PasswordCredential) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+func (m *MockDB) UpdateAccessControlRule(_ context.Context, r *models.AccessControlRule) error {
+	return runWithWLockErr(m, func() error {
+		for i, existing := range m.AccessControlRules {
+			if existing.ID == r.ID {
+				r.CreatedAt, r.UpdatedAt = existing.CreatedAt, time.Now().UTC()
+				m.AccessControlRules[i] = r
+				return nil
+			}
+		}
+		return database.ErrNotFound
+	})
+}
+
+func (m *MockDB) DeleteAccessControlRule(_ context.Context, id uuid.UUID) error {
+	return runWithWLockErr(m, func() error {
+		if database.IsDefaultAccessControlRule(id) {
+			return database.ErrConflict
+		}
+		for i, r := range m.AccessControlRules {
+			if r.ID == id {
+				m.AccessControlRules = append(m.AccessControlRules[:i], m.AccessControlRules[i+1:]...)
+				return nil
+			}
+		}
+		return database.ErrNotFound
+	})
+}
+
+// CreateAccessControlAuditLog appends rec to AccessControlAuditLogs.
+func (m *MockDB) CreateAccessControlAuditLog(_ context.Context, rec *models.AccessControlAuditLog) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.AccessControlAuditLogs = append(m.AccessControlAuditLogs, rec)
+	return nil
+}
+
+func (m *MockDB) HasActiveApiCredentialForUserAndProvider(_ context.Context, _ uuid.UUID, _ string) (bool, error) {
+	return runWithLock(m, false, func() (bool, error) {
+		return m.HasActiveApiCredential, nil
+	})
+}
+
+// GetActiveApiCredentialForUserAndProvider prefers the user's own credentials over those of the user's groups.
+func (m *MockDB) GetActiveApiCredentialForUserAndProvider(_ context.Context, userID uuid.UUID, provider string) (*models.ApiCredential, error) {
+	return runWithLock(m, false, func() (*models.ApiCredential, error) {
+		now := time.Now().UTC()
+		owners := []database.AccessSubject{{Type: database.SubjectTypeUser, ID: userID}}
+		for _, g := range m.groupIDsForUserLocked(userID) {
+			owners = append(owners, database.AccessSubject{Type: database.SubjectTypeGroup, ID: g})
+		}
+		var best *models.ApiCredential
+		for _, c := range m.ApiCredentials {
+			if !credentialOwnedByAny(c, owners) || c.Provider != provider || !c.IsActive {
+				continue
+			}
+			if c.ExpiresAt != nil && !c.ExpiresAt.After(now) {
+				continue
+			}
+			if best == nil || (best.OwnerType != database.SubjectTypeUser && c.OwnerType == database.SubjectTypeUser) ||
+				(best.OwnerType == c.OwnerType && c.UpdatedAt.After(best.UpdatedAt)) {
+				best = c
+			}
+		}
+		if best == nil {
+			return nil, database.ErrNotFound
+		}
+		return best, nil
+	})
+}
+
+// CreateApiCredential stores cred; ErrExists when owner, provider, and name are taken.
+func (m *MockDB) CreateApiCredential(_ context.Context, cred *models.ApiCredential) error {
+	return runWithWLockErr(m, func() error {
+		for _, c := range m.ApiCredentials {
+			if c.OwnerType == cred.OwnerType && c.OwnerID == cred.OwnerID && c.Provider == cred.Provider && c.CredentialName == cred.CredentialName {
+				return database.ErrExists
+			}
+		}
+		if cred.ID == uuid.Nil {
+			cred.ID = uuid.New()
+		}
+		now := time.Now().UTC()
+		cred.CreatedAt, cred.UpdatedAt = now, now
+		m.ApiCredentials = append(m.ApiCredentials, cred)
+		return nil
+	})
+}
+
+func (m *MockDB) findApiCredential(id uuid.UUID) (*models.ApiCredential, error) {
+	for _, c := range m.ApiCredentials {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return nil, database.ErrNotFound
+}
+
+func (m *MockDB) GetApiCredentialByID(_ context.Context, id uuid.UUID) (*models.ApiCredential, error) {
+	return runWithLock(m, false, func() (*models.ApiCredential, error) {
+		return m.findApiCredential(id)
+	})
+}
+
+func (m *MockDB) ListApiCredentials(_ context.Context, f database.ApiCredentialFilter) ([]*models.ApiCredential, error) {
+	return runWithLock(m, false, func() ([]*models.ApiCredential, error) {
+		var out []*models.ApiCredential
+		for _, c := range m.ApiCredentials {
+			if (f.OwnerType != "" && c.OwnerType != f.OwnerType) || (f.OwnerID != nil && c.OwnerID != *f.OwnerID) ||
+				(f.Provider != "" && c.Provider != f.Provider) || (len(f.Owners) > 0 && !credentialOwnedByAny(c, f.Owners)) {
+				continue
+			}
+			out = append(out, c)
+		}
+		return out, nil
+	})
+}
+
+func (m *MockDB) RotateApiCredential(_ context.Context, id uuid.UUID, ciphertext []byte, kid string, expiresAt *time.Time, updatedBy string) (*models.ApiCredential, error) {
+	return runWithLock(m, true, func() (*models.ApiCredential, error) {
+		c, err := m.findApiCredential(id)
+		if err != nil {
+			return nil, err
+		}
+		if !c.IsActive {
+			return nil, database.ErrConflict
+		}
+		c.CredentialCiphertext, c.CredentialKID, c.UpdatedBy = ciphertext, &kid, &updatedBy
+		if expiresAt != nil {
+			c.ExpiresAt = expiresAt
+		}
+		c.UpdatedAt = time.Now().UTC()
+		return c, nil
+	})
+}
+
+func (m *MockDB) DisableApiCredential(_ context.Context, id uuid.UUID, updatedBy string) (*models.ApiCredential, error) {
+	return runWithLock(m, true, func() (*models.ApiCredential, error) {
+		c, err := m.findApiCredential(id)
+		if err != nil {
+			return nil, err
+		}
+		c.IsActive, c.UpdatedBy, c.UpdatedAt = false, &updatedBy, time.Now().UTC()
+		return c, nil
+	})
+}
+
+func (m *MockDB) ListApiCredentialsNotUnderKID(_ context.Context, kid string, limit int) ([]*models.ApiCredential, error) {
+	return runWithLock(m, false, func() ([]*models.ApiCredential, error) {
+		var out []*models.ApiCredential
+		for _, c := range m.ApiCredentials {
+			if c.CredentialKID != nil && *c.CredentialKID != kid && len(out) < limit {
+				out = append(out, c)
+			}
+		}
+		return out, nil
+	})
+}
+
+func (m *MockDB) RewrapApiCredential(_ context.Context, id uuid.UUID, oldKID string, ciphertext []byte, newKID string) error {
+	return runWithWLockErr(m, func() error {
+		c, err := m.findApiCredential(id)
+		if err != nil {
+			return err
+		}
+		if c.CredentialKID == nil || *c.CredentialKID != oldKID {
+			return database.ErrConflict
+		}
+		c.CredentialCiphertext, c.CredentialKID = ciphertext, &newKID
+		return nil
+	})
+}
+
+func (m *MockDB) HasAnyActiveApiCredential(_ context.Context) (bool, error) {
+	return runWithLock(m, false, func() (bool, error) {
+		return m.HasAnyActiveApiCredentialResult, nil
+	})
+}
+
+func (m *MockDB) ListApiEgressLimits(_ context.Context, provider string) ([]*models.ApiEgressLimit, error) {
+	return runWithLock(m, false, func() ([]*models.ApiEgressLimit, error) {
+		var out []*models.ApiEgressLimit
+		for _, l := range m.ApiEgressLimits {
+			if provider == "" || l.Provider == provider {
+				out = append(out, l)
+			}
+		}
+		return out, nil
+	})
+}
+
+func (m *MockDB) findApiEgressLimit(id uuid.UUID) (int, error) {
+	for i, l := range m.ApiEgressLimits {
+		if l.ID == id {
+			return i, nil
+		}
+	}
+	return -1, database.ErrNotFound
+}
+
+func (m *MockDB) GetApiEgressLimitByID(_ context.Context, id uuid.UUID) (*models.ApiEgressLimit, error) {
+	return runWithLock(m, false, func() (*models.ApiEgressLimit, error) {
+		i, err := m.findApiEgressLimit(id)
+		if err != nil {
+			return nil, err
+		}
+		return m.ApiEgressLimits[i], nil
+	})
+}
+
+func (m *MockDB) CreateApiEgressLimit(_ context.Context, l *models.ApiEgressLimit) error {
+	return runWithWLockErr(m, func() error {
+		if l.ID == uuid.Nil {
+			l.ID = uuid.New()
+		}
+		now := time.Now().UTC()
+		l.CreatedAt, l.UpdatedAt = now, now
+		m.ApiEgressLimits = append(m.ApiEgressLimits, l)
+		return nil
+	})
+}
+
+func (m *MockDB) UpdateApiEgressLimit(_ context.Context, l *models.ApiEgressLimit) error {
+	return runWithWLockErr(m, func() error {
+		i, err := m.findApiEgressLimit(l.ID)
+		if err != nil {
+			return err
+		}
+		l.UpdatedAt = time.Now().UTC()
+		m.ApiEgressLimits[i] = l
+		return nil
+	})
+}
+
+func (m *MockDB) DeleteApiEgressLimit(_ context.Context, id uuid.UUID) error {
+	return runWithWLockErr(m, func() error {
+		i, err := m.findApiEgressLimit(id)
+		if err != nil {
+			return err
+		}
+		m.ApiEgressLimits = append(m.ApiEgressLimits[:i], m.ApiEgressLimits[i+1:]...)
+		m.ApiEgressUsage = slices.DeleteFunc(m.ApiEgressUsage, func(u *models.ApiEgressUsage) bool { return u.LimitID == id })
+		m.ApiEgressInflight = slices.DeleteFunc(m.ApiEgressInflight, func(s *models.ApiEgressInflight) bool { return s.LimitID == id })
+		return nil
+	})
+}
+
+// AcquireApiEgressQuota mirrors the database: every applicable limit is checked before any counter is charged.
+func (m *MockDB) AcquireApiEgressQuota(_ context.Context, c *database.ApiEgressCall) (bool, error) {
+	return runWithLock(m, true, func() (bool, error) {
+		now := time.Now().UTC()
+		minute, month := database.ApiEgressPeriodStarts(now)
+		var limits []*models.ApiEgressLimit
+		for _, l := range m.ApiEgressLimits {
+			if !database.ApiEgressLimitApplies(l, c) {
+				continue
+			}
+			limits = append(limits, l)
+			u := m.apiEgressBucketUsageLocked(l.ID, database.ApiEgressBucket(l, c), minute, month, now)
+			if exceeded := database.CheckApiEgressLimit(l, u, now); exceeded != nil {
+				return false, exceeded
+			}
+		}
+		held := false
+		for _, l := range limits {
+			bucket := database.ApiEgressBucket(l, c)
+			if l.RequestsPerMinute > 0 {
+				m.addApiEgressUsageLocked(l.ID, bucket, database.ApiEgressPeriodMinute, minute, 0)
+			}
+			m.addApiEgressUsageLocked(l.ID, bucket, database.ApiEgressPeriodMonth, month, l.CostPerCallMicroUSD)
+			if l.MaxConcurrent > 0 {
+				m.ApiEgressInflight = append(m.ApiEgressInflight, &models.ApiEgressInflight{
+					LeaseID: c.LeaseID, LimitID: l.ID, Bucket: bucket, ExpiresAt: c.LeaseExpiresAt,
+				})
+				held = true
+			}
+		}
+		return held, nil
+	})
+}
+
+func (m *MockDB) apiEgressBucketUsageLocked(limitID uuid.UUID, bucket string, minute, month, now time.Time) *database.ApiEgressBucketUsage {
+	var u database.ApiEgressBucketUsage
+	for _, r := range m.ApiEgressUsage {
+		if r.LimitID != limitID || r.Bucket != bucket {
+			continue
+		}
+		switch {
+		case r.Period == database.ApiEgressPeriodMinute && r.PeriodStart.Equal(minute):
+			u.MinuteCalls += r.Calls
+		case r.Period == database.ApiEgressPeriodMonth && r.PeriodStart.Equal(month):
+			u.MonthSpendMicroUSD += r.SpendMicroUSD
+		}
+	}
+	for _, s := range m.ApiEgressInflight {
+		if s.LimitID == limitID && s.Bucket == bucket && s.ExpiresAt.After(now) {
+			u.Inflight++
+		}
+	}
+	return &u
+}
+
+func (m *MockDB) addApiEgressUsageLocked(limitID uuid.UUID, bucket, period string, start time.Time, spend int64) {
+	for _, r := range m.ApiEgressUsage {
+		if r.LimitID == limitID && r.Bucket == bucket && r.Period == period && r.PeriodStart.Equal(start) {
+			r.Calls++
+			r.SpendMicroUSD += spend
+			return
+		}
+	}
+	m.ApiEgressUsage = append(m.ApiEgressUsage, &models.ApiEgressUsage{
+		LimitID: limitID, Bucket: bucket, Period: period, PeriodStart: start, Calls: 1, SpendMicroUSD: spend,
+	})
+}
+
+func (m *MockDB) ReleaseApiEgressQuota(_ context.Context, leaseID uuid.UUID) error {
+	return runWithWLockErr(m, func() error {
+		m.ApiEgressInflight = slices.DeleteFunc(m.ApiEgressInflight, func(s *models.ApiEgressInflight) bool { return s.LeaseID == leaseID })
+		return nil
+	})
+}
+
+// CreateTokenUsage appends a usage record.
+func (m *MockDB) CreateTokenUsage(_ context.Context, rec *models.TokenUsage) error {
+	return runWithWLockErr(m, func() error {
+		if rec.ID == uuid.Nil {
+			rec.ID = uuid.New()
+		}
+		if rec.OccurredAt.IsZero() {
+			rec.OccurredAt = time.Now().UTC()
+		}
+		if rec.TotalTokens == 0 {
+			rec.TotalTokens = rec.PromptTokens + rec.CompletionTokens
+		}
+		m.TokenUsage = append(m.TokenUsage, rec)
+		return nil
+	})
+}
+
+// SumTokenUsage sums usage per model in [since, until), ordered by model.
+func (m *MockDB) SumTokenUsage(_ context.Context, userID, projectID *uuid.UUID, since, until time.Time) ([]*models.TokenUsageSummary, error) {
+	return runWithLock(m, false, func() ([]*models.TokenUsageSummary, error) {
+		byModel := map[string]*models.TokenUsageSummary{}
+		for _, r := range m.TokenUsage {
+			if r.OccurredAt.Before(since) || !r.OccurredAt.Before(until) ||
+				!uuidPtrMatches(userID, r.UserID) || !uuidPtrMatches(projectID, r.ProjectID) {
+				continue
+			}
+			s := byModel[r.Model]
+			if s == nil {
+				s = &models.TokenUsageSummary{Model: r.Model}
+				byModel[r.Model] = s
+			}
+			s.PromptTokens += r.PromptTokens
+			s.CompletionTokens += r.CompletionTokens
+			s.TotalTokens += r.TotalTokens
+		}
+		out := make([]*models.TokenUsageSummary, 0, len(byModel))
+		for _, s := range byModel {
+			out = append(out, s)
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Model < out[j].Model })
+		return out, nil
+	})
+}
+
+// CountActiveJobs counts queued and running jobs whose task matches userID and projectID.
+func (m *MockDB) CountActiveJobs(_ context.Context, userID, projectID *uuid.UUID) (int, error) {
+	return runWithLock(m, false, func() (int, error) {
+		return m.countActiveJobsLocked(userID, projectID), nil
+	})
+}
+
+func (m *MockDB) countActiveJobsLocked(userID, projectID *uuid.UUID) int {
+	n := 0
+	for _, j := range m.Jobs {
+		if j.Status != models.JobStatusQueued && j.Status != models.JobStatusRunning {
+			continue
+		}
+		t := m.Tasks[j.TaskID]
+		if t == nil || !uuidPtrMatches(userID, t.CreatedBy) || !uuidPtrMatches(projectID, t.ProjectID) {
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+// CreateQueuedJobWithinLimits creates a queued job when the task's creator and project are under their active job limits.
+func (m *MockDB) CreateQueuedJobWithinLimits(_ context.Context, taskID, jobID uuid.UUID, payload string, userLimit, projectLimit int) (*models.Job, error) {
+	return runWithLock(m, true, func() (*models.Job, error) {
+		t := m.Tasks[taskID]
+		if t == nil {
+			return nil, database.ErrNotFound
+		}
+		if userLimit > 0 && t.CreatedBy != nil {
+			if n := m.countActiveJobsLocked(t.CreatedBy, nil); n >= userLimit {
+				return nil, &database.ActiveJobLimitError{Limit: userLimit, Used: n}
+			}
+		}
+		if projectLimit > 0 && t.ProjectID != nil {
+			if n := m.countActiveJobsLocked(nil, t.ProjectID); n >= projectLimit {
+				return nil, &database.ActiveJobLimitError{Project: true, Limit: projectLimit, Used: n}
+			}
+		}
+		now := time.Now().UTC()
+		job := &models.Job{ID: jobID, TaskID: taskID, Status: models.JobStatusQueued, Payload: models.NewJSONBString(&payload), CreatedAt: now, UpdatedAt: now}
+		m.Jobs[job.ID] = job
+		m.JobsByTask[taskID] = append(m.JobsByTask[taskID], job)
+		return job, nil
+	})
+}
+
+// uuidPtrMatches reports whether got satisfies the optional filter want (nil matches anything).
+func uuidPtrMatches(want, got *uuid.UUID) bool {
+	return want == nil || (got != nil && *got == *want)
+}
+
+// AddUser adds a pre-created user to the mock database.
+func (m *MockDB) AddUser(user *models.User) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Users[user.ID] = user
+	m.UsersByHandle[user.Handle] = user
+}
+
+// AddPasswordCredential adds a pre-created credential to the mock database.
+func (m *MockDB) AddPasswordCredential(cred *models.PasswordCredential) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
 	m.PasswordCreds[cred.UserID] = cred
 }
@@ -1352,3 +2633,303 @@ func (m *MockDB) AddRefreshSession(session *models.RefreshSession) {
 	m.RefreshSessions[session.ID] = session
 	m.SessionsByHash[string(session.RefreshTokenHash)] = session
 }
+
+func credentialOwnedByAny(c *models.ApiCredential, owners []database.AccessSubject) bool {
+	for _, o := range owners {
+		if c.OwnerType == o.Type && c.OwnerID == o.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateGroup stores g; ErrExists when the slug is taken.
+func (m *MockDB) CreateGroup(_ context.Context, g *models.Group) error {
+	return runWithWLockErr(m, func() error {
+		for _, existing := range m.Groups {
+			if existing.Slug == g.Slug {
+				return database.ErrExists
+			}
+		}
+		if g.ID == uuid.Nil {
+			g.ID = uuid.New()
+		}
+		now := time.Now().UTC()
+		g.CreatedAt, g.UpdatedAt = now, now
+		m.Groups[g.ID] = g
+		return nil
+	})
+}
+
+func (m *MockDB) GetGroupByID(_ context.Context, id uuid.UUID) (*models.Group, error) {
+	return getByKeyLocked(m, m.Groups, id)
+}
+
+// GetGroupBySlug returns the group with slug.
+func (m *MockDB) GetGroupBySlug(_ context.Context, slug string) (*models.Group, error) {
+	return runWithLock(m, false, func() (*models.Group, error) {
+		for _, g := range m.Groups {
+			if g.Slug == slug {
+				return g, nil
+			}
+		}
+		return nil, database.ErrNotFound
+	})
+}
+
+// ListGroups returns groups by slug; with memberID, only active groups the user actively belongs to.
+func (m *MockDB) ListGroups(_ context.Context, memberID *uuid.UUID) ([]*models.Group, error) {
+	return runWithLock(m, false, func() ([]*models.Group, error) {
+		var member map[uuid.UUID]bool
+		if memberID != nil {
+			member = make(map[uuid.UUID]bool)
+			for _, id := range m.groupIDsForUserLocked(*memberID) {
+				member[id] = true
+			}
+		}
+		out := make([]*models.Group, 0, len(m.Groups))
+		for _, g := range m.Groups {
+			if member == nil || member[g.ID] {
+				out = append(out, g)
+			}
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Slug < out[j].Slug })
+		return out, nil
+	})
+}
+
+// AddGroupMember adds or reactivates a membership.
+func (m *MockDB) AddGroupMember(_ context.Context, groupID, userID uuid.UUID, updatedBy string) (*models.GroupMembership, error) {
+	return runWithLock(m, true, func() (*models.GroupMembership, error) {
+		now := time.Now().UTC()
+		for _, gm := range m.GroupMemberships {
+			if gm.GroupID == groupID && gm.UserID == userID {
+				if !gm.IsActive {
+					gm.IsActive, gm.UpdatedBy, gm.UpdatedAt = true, &updatedBy, now
+				}
+				return gm, nil
+			}
+		}
+		managedBy := database.ManagedByLocal
+		gm := &models.GroupMembership{
+			ID: uuid.New(), GroupID: groupID, UserID: userID, IsActive: true, ManagedBy: &managedBy,
+			CreatedAt: now, UpdatedAt: now, UpdatedBy: &updatedBy,
+		}
+		m.GroupMemberships = append(m.GroupMemberships, gm)
+		return gm, nil
+	})
+}
+
+// RemoveGroupMember deactivates an active membership; ErrNotFound otherwise.
+func (m *MockDB) RemoveGroupMember(_ context.Context, groupID, userID uuid.UUID, updatedBy string) error {
+	return runWithWLockErr(m, func() error {
+		for _, gm := range m.GroupMemberships {
+			if gm.GroupID == groupID && gm.UserID == userID && gm.IsActive {
+				gm.IsActive, gm.UpdatedBy, gm.UpdatedAt = false, &updatedBy, time.Now().UTC()
+				return nil
+			}
+		}
+		return database.ErrNotFound
+	})
+}
+
+// SyncExternalGroupMemberships adds or reactivates external_sync memberships in groupIDs and deactivates the
+// user's other external_sync memberships; active local memberships are untouched.
+func (m *MockDB) SyncExternalGroupMemberships(_ context.Context, userID uuid.UUID, groupIDs []uuid.UUID, updatedBy string) error {
+	return runWithWLockErr(m, func() error {
+		now := time.Now().UTC()
+		managedBy := database.ManagedByExternalSync
+		want := make(map[uuid.UUID]bool, len(groupIDs))
+		for _, id := range groupIDs {
+			want[id] = true
+		}
+		for _, gm := range m.GroupMemberships {
+			if gm.UserID != userID {
+				continue
+			}
+			external := gm.ManagedBy != nil && *gm.ManagedBy == database.ManagedByExternalSync
+			switch {
+			case want[gm.GroupID] && !gm.IsActive:
+				gm.IsActive, gm.ManagedBy, gm.UpdatedBy, gm.UpdatedAt = true, &managedBy, &updatedBy, now
+			case !want[gm.GroupID] && gm.IsActive && external:
+				gm.IsActive, gm.UpdatedBy, gm.UpdatedAt = false, &updatedBy, now
+			}
+			delete(want, gm.GroupID)
+		}
+		for _, id := range groupIDs {
+			if want[id] {
+				m.GroupMemberships = append(m.GroupMemberships, &models.GroupMembership{
+					ID: uuid.New(), GroupID: id, UserID: userID, IsActive: true, ManagedBy: &managedBy,
+					CreatedAt: now, UpdatedAt: now, UpdatedBy: &updatedBy,
+				})
+			}
+		}
+		return nil
+	})
+}
+
+func (m *MockDB) ListGroupMembers(_ context.Context, groupID uuid.UUID) ([]*models.GroupMembership, error) {
+	return runWithLock(m, false, func() ([]*models.GroupMembership, error) {
+		var out []*models.GroupMembership
+		for _, gm := range m.GroupMemberships {
+			if gm.GroupID == groupID && gm.IsActive {
+				out = append(out, gm)
+			}
+		}
+		return out, nil
+	})
+}
+
+func (m *MockDB) ListGroupIDsForUser(_ context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	return runWithLock(m, false, func() ([]uuid.UUID, error) {
+		return m.groupIDsForUserLocked(userID), nil
+	})
+}
+
+// groupIDsForUserLocked returns the user's active groups in ascending id order; caller holds m.mu.
+func (m *MockDB) groupIDsForUserLocked(userID uuid.UUID) []uuid.UUID {
+	var out []uuid.UUID
+	for _, gm := range m.GroupMemberships {
+		if g, ok := m.Groups[gm.GroupID]; ok && g.IsActive && gm.UserID == userID && gm.IsActive {
+			out = append(out, gm.GroupID)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].String() < out[j].String() })
+	return out
+}
+
+func (m *MockDB) ListRoles(_ context.Context) ([]*models.Role, error) {
+	return runWithLock(m, false, func() ([]*models.Role, error) {
+		out := append([]*models.Role(nil), m.Roles...)
+		sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+		return out, nil
+	})
+}
+
+// CreateRoleBinding stores b as active; ErrExists when an identical active binding exists.
+func (m *MockDB) CreateRoleBinding(_ context.Context, b *models.RoleBinding) error {
+	return runWithWLockErr(m, func() error {
+		for _, rb := range m.RoleBindings {
+			if rb.IsActive && rb.SubjectType == b.SubjectType && rb.SubjectID == b.SubjectID && rb.RoleID == b.RoleID &&
+				rb.ScopeType == b.ScopeType && equalUUIDPtr(rb.ScopeID, b.ScopeID) {
+				return database.ErrExists
+			}
+		}
+		if b.ID == uuid.Nil {
+			b.ID = uuid.New()
+		}
+		now := time.Now().UTC()
+		b.IsActive, b.CreatedAt, b.UpdatedAt = true, now, now
+		m.RoleBindings = append(m.RoleBindings, b)
+		return nil
+	})
+}
+
+func (m *MockDB) DisableRoleBinding(_ context.Context, id uuid.UUID, updatedBy string) (*models.RoleBinding, error) {
+	return runWithLock(m, true, func() (*models.RoleBinding, error) {
+		for _, rb := range m.RoleBindings {
+			if rb.ID == id {
+				rb.IsActive, rb.UpdatedBy, rb.UpdatedAt = false, &updatedBy, time.Now().UTC()
+				return rb, nil
+			}
+		}
+		return nil, database.ErrNotFound
+	})
+}
+
+// ListRoleBindings returns active bindings for subjects (all active bindings when subjects is empty).
+func (m *MockDB) ListRoleBindings(_ context.Context, subjects []database.AccessSubject) ([]*models.RoleBinding, error) {
+	return runWithLock(m, false, func() ([]*models.RoleBinding, error) {
+		var out []*models.RoleBinding
+		for _, rb := range m.RoleBindings {
+			if !rb.IsActive {
+				continue
+			}
+			match := len(subjects) == 0
+			for _, s := range subjects {
+				if rb.SubjectType == s.Type && rb.SubjectID == s.ID {
+					match = true
+					break
+				}
+			}
+			if match {
+				out = append(out, rb)
+			}
+		}
+		return out, nil
+	})
+}
+
+func (m *MockDB) HasAnyRoleBinding(_ context.Context, subject database.AccessSubject) (bool, error) {
+	return runWithLock(m, false, func() (bool, error) {
+		for _, rb := range m.RoleBindings {
+			if rb.SubjectType == subject.Type && rb.SubjectID == subject.ID {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+func equalUUIDPtr(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// SearchAuditEvents filters AuditEvents like the database view, newest first, with offset cursors.
+func (m *MockDB) SearchAuditEvents(_ context.Context, f database.AuditEventFilter) ([]*models.AuditEvent, string, error) {
+	type page struct {
+		events []*models.AuditEvent
+		next   string
+	}
+	p, err := runWithLock(m, false, func() (page, error) {
+		out := make([]*models.AuditEvent, 0, len(m.AuditEvents))
+		for _, e := range m.AuditEvents {
+			if auditEventMatches(e, &f) {
+				out = append(out, e)
+			}
+		}
+		sort.Slice(out, func(i, j int) bool { return auditEventBefore(out[j], out[i]) })
+		if f.Cursor != "" {
+			ts, id, err := database.ParseAuditCursor(f.Cursor)
+			if err != nil {
+				return page{}, err
+			}
+			last := &models.AuditEvent{ID: id, CreatedAt: ts}
+			n := sort.Search(len(out), func(i int) bool { return auditEventBefore(out[i], last) })
+			out = out[n:]
+		}
+		limit := f.Limit
+		if limit <= 0 || limit > database.MaxAuditEventLimit {
+			limit = database.MaxAuditEventLimit
+		}
+		if len(out) > limit {
+			return page{out[:limit], database.AuditCursor(out[limit-1])}, nil
+		}
+		return page{out, ""}, nil
+	})
+	return p.events, p.next, err
+}
+
+// auditEventBefore reports whether a sorts below b in (ts, event_id) order.
+func auditEventBefore(a, b *models.AuditEvent) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID.String() < b.ID.String()
+}
+
+func auditEventMatches(e *models.AuditEvent, f *database.AuditEventFilter) bool {
+	uuidMatches := func(want, got *uuid.UUID) bool { return want == nil || (got != nil && *got == *want) }
+	action := e.Action == f.Action
+	if prefix, ok := strings.CutSuffix(f.Action, "*"); ok {
+		action = strings.HasPrefix(e.Action, prefix)
+	}
+	return (f.EventID == nil || e.ID == *f.EventID) &&
+		(f.Since == nil || !e.CreatedAt.Before(*f.Since)) && (f.Until == nil || e.CreatedAt.Before(*f.Until)) &&
+		uuidMatches(f.ActorID, e.ActorID) && uuidMatches(f.TaskID, e.TaskID) && uuidMatches(f.ProjectID, e.ProjectID) &&
+		(f.Source == "" || e.Source == f.Source) && (f.Action == "" || action) &&
+		(f.ResourceType == "" || e.ResourceType == f.ResourceType) && (f.Decision == "" || e.Decision == f.Decision)
+}
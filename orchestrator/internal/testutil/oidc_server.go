@@ -0,0 +1,251 @@
+package testutil
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OIDCServer is a stub OpenID provider for SSO tests. It serves discovery, a JWKS, an authorization endpoint
+// that signs the user in at once, device authorization, and a token endpoint for the authorization code (PKCE
+// S256 required) and device code grants. ID tokens carry Claims (which should include sub) and are signed with
+// an RSA key that RotateKey replaces.
+type OIDCServer struct {
+	URL      string
+	ClientID string
+
+	mu      sync.Mutex
+	claims  map[string]any
+	key     *rsa.PrivateKey
+	kid     string
+	codes   map[string]oidcCode
+	devices map[string]*oidcDevice
+}
+
+type oidcCode struct {
+	redirectURI string
+	challenge   string
+	nonce       string
+}
+
+type oidcDevice struct {
+	userCode string
+	approved bool
+}
+
+// NewOIDCServer starts an OIDCServer for the test that accepts clientID as a public or confidential client.
+func NewOIDCServer(t testing.TB, clientID string, claims map[string]any) *OIDCServer {
+	t.Helper()
+	s := &OIDCServer{ClientID: clientID, claims: claims, codes: map[string]oidcCode{}, devices: map[string]*oidcDevice{}}
+	s.RotateKey()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("GET /jwks", s.jwks)
+	mux.HandleFunc("GET /authorize", s.authorize)
+	mux.HandleFunc("POST /device", s.deviceAuthorization)
+	mux.HandleFunc("POST /token", s.token)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	s.URL = srv.URL
+	return s
+}
+
+// SetClaims replaces the claims put in ID tokens issued from now on.
+func (s *OIDCServer) SetClaims(claims map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.claims = claims
+}
+
+// RotateKey replaces the signing key; the JWKS serves only the new key.
+func (s *OIDCServer) RotateKey() {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.key, s.kid = key, randomCode()
+}
+
+// ApproveDevice completes the sign-in for a pending device authorization, as the user would in a browser.
+func (s *OIDCServer) ApproveDevice(userCode string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range s.devices {
+		if d.userCode == userCode {
+			d.approved = true
+			return true
+		}
+	}
+	return false
+}
+
+// SignIDToken signs claims with the current key, adding iss, aud, iat, and exp when they are not set.
+func (s *OIDCServer) SignIDToken(claims map[string]any) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.signLocked(claims)
+}
+
+func (s *OIDCServer) signLocked(claims map[string]any) string {
+	c := jwt.MapClaims{"iss": s.URL, "aud": s.ClientID, "iat": time.Now().Unix(), "exp": time.Now().Add(5 * time.Minute).Unix()}
+	for k, v := range claims {
+		c[k] = v
+	}
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, c)
+	tok.Header["kid"] = s.kid
+	signed, err := tok.SignedString(s.key)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
+
+func (s *OIDCServer) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeOIDCJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                s.URL,
+		"authorization_endpoint":                s.URL + "/authorize",
+		"token_endpoint":                        s.URL + "/token",
+		"jwks_uri":                              s.URL + "/jwks",
+		"device_authorization_endpoint":         s.URL + "/device",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (s *OIDCServer) jwks(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	pub := s.key.PublicKey
+	kid := s.kid
+	s.mu.Unlock()
+	writeOIDCJSON(w, http.StatusOK, map[string]any{"keys": []map[string]string{{
+		"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+		"n": base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}}})
+}
+
+func (s *OIDCServer) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if q.Get("client_id") != s.ClientID || q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" ||
+		q.Get("code_challenge") == "" || err != nil || !redirect.IsAbs() {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+	code := randomCode()
+	s.mu.Lock()
+	s.codes[code] = oidcCode{redirectURI: q.Get("redirect_uri"), challenge: q.Get("code_challenge"), nonce: q.Get("nonce")}
+	s.mu.Unlock()
+	back := redirect.Query()
+	back.Set("code", code)
+	back.Set("state", q.Get("state"))
+	redirect.RawQuery = back.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *OIDCServer) deviceAuthorization(w http.ResponseWriter, r *http.Request) {
+	if s.clientID(r) != s.ClientID {
+		writeOIDCJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	deviceCode, userCode := randomCode(), strings.ToUpper(randomCode()[:8])
+	s.mu.Lock()
+	s.devices[deviceCode] = &oidcDevice{userCode: userCode}
+	s.mu.Unlock()
+	writeOIDCJSON(w, http.StatusOK, map[string]any{
+		"device_code": deviceCode, "user_code": userCode, "verification_uri": s.URL + "/activate",
+		"verification_uri_complete": s.URL + "/activate?user_code=" + userCode, "expires_in": 600, "interval": 1,
+	})
+}
+
+func (s *OIDCServer) token(w http.ResponseWriter, r *http.Request) {
+	if s.clientID(r) != s.ClientID {
+		writeOIDCJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var nonce, oauthErr string
+	switch r.PostFormValue("grant_type") {
+	case "authorization_code":
+		nonce, oauthErr = s.redeemCodeLocked(r)
+	case "urn:ietf:params:oauth:grant-type:device_code":
+		oauthErr = s.redeemDeviceLocked(r.PostFormValue("device_code"))
+	default:
+		oauthErr = "unsupported_grant_type"
+	}
+	if oauthErr != "" {
+		writeOIDCJSON(w, http.StatusBadRequest, map[string]string{"error": oauthErr})
+		return
+	}
+	claims := map[string]any{}
+	for k, v := range s.claims {
+		claims[k] = v
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	writeOIDCJSON(w, http.StatusOK, map[string]any{
+		"access_token": randomCode(), "token_type": "Bearer", "expires_in": 300, "id_token": s.signLocked(claims),
+	})
+}
+
+// redeemCodeLocked checks and consumes an authorization code; it returns the code's nonce or an OAuth error.
+func (s *OIDCServer) redeemCodeLocked(r *http.Request) (nonce, oauthErr string) {
+	code, ok := s.codes[r.PostFormValue("code")]
+	delete(s.codes, r.PostFormValue("code"))
+	if !ok || code.redirectURI != r.PostFormValue("redirect_uri") {
+		return "", "invalid_grant"
+	}
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != code.challenge {
+		return "", "invalid_grant"
+	}
+	return code.nonce, ""
+}
+
+func (s *OIDCServer) redeemDeviceLocked(deviceCode string) string {
+	d, ok := s.devices[deviceCode]
+	switch {
+	case !ok:
+		return "expired_token"
+	case !d.approved:
+		return "authorization_pending"
+	}
+	delete(s.devices, deviceCode)
+	return ""
+}
+
+// clientID returns the client id from basic auth or, for public clients, the form.
+func (s *OIDCServer) clientID(r *http.Request) string {
+	if id, _, ok := r.BasicAuth(); ok {
+		id, _ = url.QueryUnescape(id)
+		return id
+	}
+	return r.PostFormValue("client_id")
+}
+
+func randomCode() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func writeOIDCJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,281 @@
+// Package usage records model token usage and enforces per-user and per-project quotas.
+// Usage rows are written to token_usage by the gateway (chat, PMA turns, prompt-mode tasks) and the
+// dispatcher (job results); quotas are checked at task creation and chat time.
+// See docs/tech_specs/orchestrator.md (Token Usage and Quotas).
+package usage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/userapi"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/config"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/database"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/inference"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+)
+
+// Quota scopes and kinds (userapi.QuotaStatus Scope and Kind).
+const (
+	ScopeUser    = "user"
+	ScopeProject = "project"
+
+	KindDailyTokens    = "daily_tokens"
+	KindMonthlyTokens  = "monthly_tokens"
+	KindConcurrentJobs = "concurrent_jobs"
+)
+
+// concurrentJobsRetryAfter is the Retry-After suggested when the concurrent job quota is exhausted;
+// there is no fixed reset, a slot frees when a job finishes.
+const concurrentJobsRetryAfter = 30 * time.Second
+
+// Quotas are the limits for one scope; 0 means unlimited.
+type Quotas struct {
+	DailyTokens    int64
+	MonthlyTokens  int64
+	ConcurrentJobs int
+}
+
+// Limits are the quotas applied to every user and every project.
+type Limits struct {
+	User    Quotas
+	Project Quotas
+}
+
+// LimitsFromConfig returns the QUOTA_* limits from cfg.
+func LimitsFromConfig(cfg *config.OrchestratorConfig) Limits {
+	return Limits{
+		User: Quotas{
+			DailyTokens:    int64(cfg.QuotaUserDailyTokens),
+			MonthlyTokens:  int64(cfg.QuotaUserMonthlyTokens),
+			ConcurrentJobs: cfg.QuotaUserConcurrentJobs,
+		},
+		Project: Quotas{
+			DailyTokens:    int64(cfg.QuotaProjectDailyTokens),
+			MonthlyTokens:  int64(cfg.QuotaProjectMonthlyTokens),
+			ConcurrentJobs: cfg.QuotaProjectConcurrentJobs,
+		},
+	}
+}
+
+// ExceededError is returned when a quota is used up. ResetAt is nil for the concurrent job quota.
+type ExceededError struct {
+	Scope   string
+	Kind    string
+	Limit   int64
+	Used    int64
+	ResetAt *time.Time
+}
+
+func (e *ExceededError) Error() string {
+	msg := fmt.Sprintf("%s %s quota exceeded (%d of %d used)", e.Scope, e.Kind, e.Used, e.Limit)
+	if e.ResetAt != nil {
+		msg += "; resets at " + e.ResetAt.Format(time.RFC3339)
+	}
+	return msg
+}
+
+// RetryAfter returns how long a client should wait before retrying, at least one second.
+func (e *ExceededError) RetryAfter(now time.Time) time.Duration {
+	if e.ResetAt == nil {
+		return concurrentJobsRetryAfter
+	}
+	if d := e.ResetAt.Sub(now).Truncate(time.Second); d >= time.Second {
+		return d
+	}
+	return time.Second
+}
+
+// Enforcer checks quotas against recorded usage. A nil *Enforcer enforces nothing.
+type Enforcer struct {
+	db     database.Store
+	limits Limits
+	now    func() time.Time
+}
+
+// NewEnforcer returns an Enforcer for limits backed by db.
+func NewEnforcer(db database.Store, limits Limits) *Enforcer {
+	return &Enforcer{db: db, limits: limits, now: time.Now}
+}
+
+// window is one quota window with its start and the time it resets.
+type window struct {
+	kind  string
+	limit int64
+	start time.Time
+	reset time.Time
+}
+
+func tokenWindows(q Quotas, now time.Time) []window {
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	var out []window
+	if q.DailyTokens > 0 {
+		out = append(out, window{KindDailyTokens, q.DailyTokens, day, day.AddDate(0, 0, 1)})
+	}
+	if q.MonthlyTokens > 0 {
+		out = append(out, window{KindMonthlyTokens, q.MonthlyTokens, month, month.AddDate(0, 1, 0)})
+	}
+	return out
+}
+
+// scope is a quota subject: the user, or the project when one is given.
+type scope struct {
+	name      string
+	quotas    Quotas
+	userID    *uuid.UUID
+	projectID *uuid.UUID
+}
+
+func (e *Enforcer) scopes(userID uuid.UUID, projectID *uuid.UUID) []scope {
+	out := []scope{{name: ScopeUser, quotas: e.limits.User, userID: &userID}}
+	if projectID != nil {
+		out = append(out, scope{name: ScopeProject, quotas: e.limits.Project, projectID: projectID})
+	}
+	return out
+}
+
+// CheckTokens returns an *ExceededError when the user's or project's token usage has reached a daily or
+// monthly quota. Usage is only known after a call completes, so the call that crosses a quota is allowed.
+func (e *Enforcer) CheckTokens(ctx context.Context, userID uuid.UUID, projectID *uuid.UUID) error {
+	if e == nil {
+		return nil
+	}
+	now := e.now()
+	for _, s := range e.scopes(userID, projectID) {
+		for _, w := range tokenWindows(s.quotas, now) {
+			used, err := e.sumTokens(ctx, s, w.start, now)
+			if err != nil {
+				return err
+			}
+			if used >= w.limit {
+				reset := w.reset
+				return &ExceededError{Scope: s.name, Kind: w.kind, Limit: w.limit, Used: used, ResetAt: &reset}
+			}
+		}
+	}
+	return nil
+}
+
+// CheckTask is CheckTokens plus the concurrent job quota, for creating a task that queues a job.
+// The concurrent job check here is early rejection only; CreateQueuedJob enforces it atomically.
+func (e *Enforcer) CheckTask(ctx context.Context, userID uuid.UUID, projectID *uuid.UUID) error {
+	if e == nil {
+		return nil
+	}
+	if err := e.CheckTokens(ctx, userID, projectID); err != nil {
+		return err
+	}
+	for _, s := range e.scopes(userID, projectID) {
+		if s.quotas.ConcurrentJobs <= 0 {
+			continue
+		}
+		n, err := e.db.CountActiveJobs(ctx, s.userID, s.projectID)
+		if err != nil {
+			return err
+		}
+		if n >= s.quotas.ConcurrentJobs {
+			return &ExceededError{Scope: s.name, Kind: KindConcurrentJobs, Limit: int64(s.quotas.ConcurrentJobs), Used: int64(n)}
+		}
+	}
+	return nil
+}
+
+// CreateQueuedJob creates a queued job for taskID, enforcing the concurrent job quota in the same transaction as
+// the insert, so concurrent task creations cannot overshoot it the way CheckTask alone can.
+// Returns an *ExceededError when the quota is used up. A nil Enforcer enforces nothing.
+func (e *Enforcer) CreateQueuedJob(ctx context.Context, db database.Store, taskID, jobID uuid.UUID, payload string) (*models.Job, error) {
+	var userLimit, projectLimit int
+	if e != nil {
+		userLimit, projectLimit = e.limits.User.ConcurrentJobs, e.limits.Project.ConcurrentJobs
+	}
+	job, err := db.CreateQueuedJobWithinLimits(ctx, taskID, jobID, payload, userLimit, projectLimit)
+	var limited *database.ActiveJobLimitError
+	if errors.As(err, &limited) {
+		scope := ScopeUser
+		if limited.Project {
+			scope = ScopeProject
+		}
+		return nil, &ExceededError{Scope: scope, Kind: KindConcurrentJobs, Limit: int64(limited.Limit), Used: int64(limited.Used)}
+	}
+	return job, err
+}
+
+// Status returns every configured quota for the user (and project) with its current use.
+func (e *Enforcer) Status(ctx context.Context, userID uuid.UUID, projectID *uuid.UUID) ([]userapi.QuotaStatus, error) {
+	out := []userapi.QuotaStatus{}
+	if e == nil {
+		return out, nil
+	}
+	now := e.now()
+	for _, s := range e.scopes(userID, projectID) {
+		for _, w := range tokenWindows(s.quotas, now) {
+			used, err := e.sumTokens(ctx, s, w.start, now)
+			if err != nil {
+				return nil, err
+			}
+			reset := w.reset.Format(time.RFC3339)
+			out = append(out, userapi.QuotaStatus{Scope: s.name, Kind: w.kind, Limit: w.limit, Used: used, ResetsAt: &reset})
+		}
+		if s.quotas.ConcurrentJobs > 0 {
+			n, err := e.db.CountActiveJobs(ctx, s.userID, s.projectID)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, userapi.QuotaStatus{Scope: s.name, Kind: KindConcurrentJobs, Limit: int64(s.quotas.ConcurrentJobs), Used: int64(n)})
+		}
+	}
+	return out, nil
+}
+
+func (e *Enforcer) sumTokens(ctx context.Context, s scope, since, now time.Time) (int64, error) {
+	sums, err := e.db.SumTokenUsage(ctx, s.userID, s.projectID, since, now.Add(time.Second))
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, m := range sums {
+		total += m.TotalTokens
+	}
+	return total, nil
+}
+
+// Attribution identifies who and what a usage record is charged to.
+type Attribution struct {
+	UserID    *uuid.UUID
+	ProjectID *uuid.UUID
+	TaskID    *uuid.UUID
+	JobID     *uuid.UUID
+	Source    string // models.TokenUsageSource*
+}
+
+// Record writes one usage row for u. Failures are logged, not returned: accounting must not fail the request.
+func Record(ctx context.Context, db database.Store, logger *slog.Logger, a Attribution, u inference.Usage) {
+	if u.PromptTokens <= 0 && u.CompletionTokens <= 0 {
+		return
+	}
+	rec := &models.TokenUsage{
+		UserID:           a.UserID,
+		ProjectID:        a.ProjectID,
+		TaskID:           a.TaskID,
+		JobID:            a.JobID,
+		Model:            u.Model,
+		Source:           a.Source,
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+	}
+	if err := db.CreateTokenUsage(context.WithoutCancel(ctx), rec); err != nil && logger != nil {
+		logger.Error("record token usage", "source", a.Source, "model", u.Model, "error", err)
+	}
+}
+
+// WithRecorder returns ctx with an inference usage observer that records each call's usage for a.
+func WithRecorder(ctx context.Context, db database.Store, logger *slog.Logger, a Attribution) context.Context {
+	return inference.WithUsageObserver(ctx, func(u inference.Usage) { Record(ctx, db, logger, a, u) })
+}
@@ -0,0 +1,141 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/config"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/inference"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/models"
+	"github.com/cypher0n3/cynodeai/orchestrator/internal/testutil"
+)
+
+func TestLimitsFromConfig(t *testing.T) {
+	cfg := &config.OrchestratorConfig{QuotaUserDailyTokens: 1, QuotaUserMonthlyTokens: 2, QuotaUserConcurrentJobs: 3,
+		QuotaProjectDailyTokens: 4, QuotaProjectMonthlyTokens: 5, QuotaProjectConcurrentJobs: 6}
+	got := LimitsFromConfig(cfg)
+	want := Limits{User: Quotas{1, 2, 3}, Project: Quotas{4, 5, 6}}
+	if got != want {
+		t.Errorf("limits = %+v", got)
+	}
+}
+
+func TestEnforcer_NilEnforcesNothing(t *testing.T) {
+	var e *Enforcer
+	if err := e.CheckTask(context.Background(), uuid.New(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := e.Status(context.Background(), uuid.New(), nil); err != nil || len(s) != 0 {
+		t.Errorf("status = %v, %v", s, err)
+	}
+}
+
+func TestEnforcer_CheckTokens(t *testing.T) {
+	db := testutil.NewMockDB()
+	userID, projectID := uuid.New(), uuid.New()
+	ctx := WithRecorder(context.Background(), db, nil, Attribution{UserID: &userID, ProjectID: &projectID, Source: models.TokenUsageSourceChat})
+	inference.ObserveUsage(ctx, inference.Usage{Model: "m", PromptTokens: 40, CompletionTokens: 10})
+
+	e := NewEnforcer(db, Limits{User: Quotas{DailyTokens: 100}, Project: Quotas{MonthlyTokens: 50}})
+	now := time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)
+	e.now = func() time.Time { return now }
+	db.TokenUsage[0].OccurredAt = now.Add(-time.Hour)
+
+	if err := e.CheckTokens(ctx, userID, nil); err != nil {
+		t.Fatalf("user under quota: %v", err)
+	}
+	err := e.CheckTokens(ctx, userID, &projectID)
+	var exceeded *ExceededError
+	if !errors.As(err, &exceeded) {
+		t.Fatalf("err = %v", err)
+	}
+	if exceeded.Scope != ScopeProject || exceeded.Kind != KindMonthlyTokens || exceeded.Used != 50 || exceeded.Limit != 50 {
+		t.Errorf("exceeded = %+v", exceeded)
+	}
+	if !exceeded.ResetAt.Equal(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("reset = %v", exceeded.ResetAt)
+	}
+
+	// Usage from yesterday does not count against today's quota.
+	db.TokenUsage[0].OccurredAt = now.AddDate(0, 0, -1)
+	e.limits = Limits{User: Quotas{DailyTokens: 50}}
+	if err := e.CheckTokens(ctx, userID, &projectID); err != nil {
+		t.Errorf("yesterday's usage counted: %v", err)
+	}
+}
+
+func TestEnforcer_CheckTaskConcurrentJobs(t *testing.T) {
+	db := testutil.NewMockDB()
+	userID := uuid.New()
+	task := &models.Task{ID: uuid.New(), CreatedBy: &userID}
+	db.AddTask(task)
+	db.AddJob(&models.Job{ID: uuid.New(), TaskID: task.ID, Status: models.JobStatusQueued})
+	db.AddJob(&models.Job{ID: uuid.New(), TaskID: task.ID, Status: models.JobStatusCompleted})
+
+	e := NewEnforcer(db, Limits{User: Quotas{ConcurrentJobs: 2}})
+	if err := e.CheckTask(context.Background(), userID, nil); err != nil {
+		t.Fatalf("under quota: %v", err)
+	}
+	e.limits.User.ConcurrentJobs = 1
+	var exceeded *ExceededError
+	if err := e.CheckTask(context.Background(), userID, nil); !errors.As(err, &exceeded) || exceeded.Kind != KindConcurrentJobs {
+		t.Fatalf("err = %v", err)
+	}
+	if exceeded.RetryAfter(time.Now()) != concurrentJobsRetryAfter {
+		t.Errorf("retry after = %v", exceeded.RetryAfter(time.Now()))
+	}
+	status, err := e.Status(context.Background(), userID, nil)
+	if err != nil || len(status) != 1 || status[0].Used != 1 || status[0].ResetsAt != nil {
+		t.Errorf("status = %+v, %v", status, err)
+	}
+}
+
+func TestEnforcer_CreateQueuedJob(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewMockDB()
+	userID, projectID := uuid.New(), uuid.New()
+	task := &models.Task{ID: uuid.New(), CreatedBy: &userID, ProjectID: &projectID}
+	db.AddTask(task)
+
+	var nilEnforcer *Enforcer
+	if _, err := nilEnforcer.CreateQueuedJob(ctx, db, task.ID, uuid.New(), "{}"); err != nil {
+		t.Fatalf("nil enforcer: %v", err)
+	}
+	e := NewEnforcer(db, Limits{User: Quotas{ConcurrentJobs: 3}, Project: Quotas{ConcurrentJobs: 2}})
+	if _, err := e.CreateQueuedJob(ctx, db, task.ID, uuid.New(), "{}"); err != nil {
+		t.Fatalf("under quota: %v", err)
+	}
+	var exceeded *ExceededError
+	_, err := e.CreateQueuedJob(ctx, db, task.ID, uuid.New(), "{}")
+	if !errors.As(err, &exceeded) || exceeded.Scope != ScopeProject || exceeded.Kind != KindConcurrentJobs || exceeded.Used != 2 {
+		t.Fatalf("err = %v", err)
+	}
+	if n, _ := db.CountActiveJobs(ctx, &userID, nil); n != 2 {
+		t.Errorf("active jobs = %d, want 2", n)
+	}
+}
+
+func TestExceededError_RetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 14, 23, 59, 59, 500, time.UTC)
+	reset := now.Add(300 * time.Millisecond)
+	e := &ExceededError{Scope: ScopeUser, Kind: KindDailyTokens, Limit: 1, Used: 2, ResetAt: &reset}
+	if got := e.RetryAfter(now); got != time.Second {
+		t.Errorf("retry after = %v", got)
+	}
+	reset = now.Add(90 * time.Minute)
+	if got := e.RetryAfter(now); got != 90*time.Minute {
+		t.Errorf("retry after = %v", got)
+	}
+}
+
+func TestRecord_SkipsEmptyUsage(t *testing.T) {
+	db := testutil.NewMockDB()
+	Record(context.Background(), db, nil, Attribution{Source: models.TokenUsageSourceTask}, inference.Usage{Model: "m"})
+	if len(db.TokenUsage) != 0 {
+		t.Errorf("rows = %d", len(db.TokenUsage))
+	}
+}
@@ -0,0 +1,404 @@
+// Package webegress implements the Web Egress Proxy: a default-deny HTTP forward proxy for sandboxes.
+// Plain http:// requests arrive in absolute form and are forwarded; https:// is tunneled with CONNECT.
+// Every request carries its job's credentials as Basic Proxy-Authorization, is decided by an Authorizer,
+// is refused when the destination resolves to a private or reserved address, and is audited with its byte
+// counts. See docs/tech_specs/web_egress_proxy.md.
+package webegress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Reason codes sent to the sandbox in denials (HeaderDenyReason) and recorded in audit records.
+const (
+	ReasonAllowed            = "allowed"
+	ReasonAuthRequired       = "proxy_auth_required"
+	ReasonInvalidCredentials = "invalid_credentials"
+	ReasonJobNotRunning      = "job_not_running"
+	ReasonInvalidDestination = "invalid_destination"
+	ReasonIPLiteral          = "ip_literal"
+	ReasonNotAllowlisted     = "not_allowlisted"
+	ReasonPolicyDenied       = "policy_denied"
+	ReasonPolicyError        = "policy_error"
+	ReasonPrivateAddress     = "private_address"
+	ReasonDNSFailure         = "dns_failure"
+	ReasonUpstreamError      = "upstream_error"
+)
+
+// Audit decisions.
+const (
+	DecisionAllow = "allow"
+	DecisionDeny  = "deny"
+)
+
+// HeaderDenyReason carries the reason code on every response the proxy refuses.
+const HeaderDenyReason = "X-Cynodeai-Egress-Reason"
+
+// defaultDialTimeout bounds connecting to a destination when Proxy.DialTimeout is zero.
+const defaultDialTimeout = 10 * time.Second
+
+// Destination is a normalized request target.
+type Destination struct {
+	Scheme string // http, or https for CONNECT
+	Host   string // lowercase DNS name; never an IP literal
+	Port   int
+}
+
+// Resource is the policy resource for d: host:port.
+func (d Destination) Resource() string {
+	return net.JoinHostPort(d.Host, strconv.Itoa(d.Port))
+}
+
+// Caller is the job a request is made for, as resolved from its proxy credentials.
+type Caller struct {
+	JobID     uuid.UUID
+	TaskID    uuid.UUID
+	UserID    *uuid.UUID
+	ProjectID *uuid.UUID
+	TaskType  string
+}
+
+// Denial refuses a request: Status is sent to the sandbox and Code is one of the Reason constants.
+type Denial struct {
+	Status int
+	Code   string
+	Detail string
+}
+
+func (d *Denial) Error() string {
+	return d.Code + ": " + d.Detail
+}
+
+// Deny returns a Denial.
+func Deny(status int, code, detail string) *Denial {
+	return &Denial{Status: status, Code: code, Detail: detail}
+}
+
+// Authorizer resolves job credentials and decides destinations. An error that is not a *Denial denies the
+// request as ReasonPolicyError.
+type Authorizer interface {
+	Authenticate(ctx context.Context, jobID, token string) (*Caller, error)
+	Authorize(ctx context.Context, c *Caller, dest Destination) error
+}
+
+// AuditRecord is one proxied request and its outcome. Caller is nil when authentication failed; Dest is zero
+// when the target could not be parsed. Byte counts are request and response bodies for http, tunnel
+// payload for CONNECT.
+type AuditRecord struct {
+	Caller        *Caller
+	Dest          Destination
+	Decision      string
+	Code          string
+	BytesSent     int64
+	BytesReceived int64
+}
+
+// Proxy is the Web Egress Proxy handler.
+type Proxy struct {
+	Auth Authorizer
+	// Audit receives a record when each request ends; it must not block for long.
+	Audit func(ctx context.Context, rec *AuditRecord)
+	// LookupIP resolves destination hosts; nil uses net.DefaultResolver.
+	LookupIP func(ctx context.Context, host string) ([]net.IP, error)
+	// PermitIP reports whether a resolved address may be dialed; nil permits only PublicIP addresses.
+	PermitIP    func(ip net.IP) bool
+	DialTimeout time.Duration
+
+	transportOnce sync.Once
+	transport     *http.Transport
+}
+
+// New returns a Proxy that decides requests with auth and reports them to audit.
+func New(auth Authorizer, audit func(ctx context.Context, rec *AuditRecord)) *Proxy {
+	return &Proxy{Auth: auth, Audit: audit}
+}
+
+// ServeHTTP proxies one request: CONNECT opens a tunnel, an absolute-form http:// request is forwarded.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec := &AuditRecord{Decision: DecisionDeny}
+	if p.Audit != nil {
+		defer func() { p.Audit(context.WithoutCancel(r.Context()), rec) }()
+	}
+	ips, err := p.admit(r, rec)
+	if err != nil {
+		d := asDenial(err)
+		rec.Code = d.Code
+		writeDenial(w, d)
+		return
+	}
+	rec.Decision, rec.Code = DecisionAllow, ReasonAllowed
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r, rec, ips)
+		return
+	}
+	p.forward(w, r, rec, ips)
+}
+
+// admit parses the destination, authenticates the caller, asks the Authorizer, and resolves the host to
+// addresses that may be dialed. rec is filled in as each step succeeds.
+func (p *Proxy) admit(r *http.Request, rec *AuditRecord) ([]net.IP, error) {
+	ctx := r.Context()
+	dest, err := destinationOf(r)
+	if err != nil {
+		return nil, err
+	}
+	rec.Dest = dest
+	jobID, token, ok := proxyBasicAuth(r)
+	if !ok {
+		return nil, Deny(http.StatusProxyAuthRequired, ReasonAuthRequired, "proxy credentials required")
+	}
+	caller, err := p.Auth.Authenticate(ctx, jobID, token)
+	if err != nil {
+		return nil, err
+	}
+	rec.Caller = caller
+	if err := p.Auth.Authorize(ctx, caller, dest); err != nil {
+		return nil, err
+	}
+	return p.resolve(ctx, dest.Host)
+}
+
+// destinationOf normalizes r's target. Only CONNECT (https) and absolute-form http:// requests are proxied;
+// IP-literal hosts are refused so every destination goes through the allowlist by name.
+func destinationOf(r *http.Request) (Destination, error) {
+	var d Destination
+	switch {
+	case r.Method == http.MethodConnect:
+		d.Scheme = "https"
+	case r.URL.IsAbs() && strings.EqualFold(r.URL.Scheme, "http"):
+		d.Scheme = "http"
+	default:
+		return d, Deny(http.StatusBadRequest, ReasonInvalidDestination, "only CONNECT and absolute http:// requests are proxied")
+	}
+	port := r.URL.Port()
+	if port == "" && d.Scheme == "http" {
+		port = "80"
+	}
+	n, err := strconv.Atoi(port)
+	host := strings.TrimSuffix(strings.ToLower(r.URL.Hostname()), ".")
+	if err != nil || n < 1 || n > 65535 || host == "" {
+		return d, Deny(http.StatusBadRequest, ReasonInvalidDestination, "destination must be host:port")
+	}
+	d.Host, d.Port = host, n
+	if net.ParseIP(host) != nil {
+		return d, Deny(http.StatusForbidden, ReasonIPLiteral, "IP address destinations are not allowed; use a host name")
+	}
+	return d, nil
+}
+
+// proxyBasicAuth returns the job id and token from Proxy-Authorization (Basic).
+func proxyBasicAuth(r *http.Request) (jobID, token string, ok bool) {
+	h := r.Header.Get("Proxy-Authorization")
+	if h == "" {
+		return "", "", false
+	}
+	req := http.Request{Header: http.Header{"Authorization": {h}}}
+	return req.BasicAuth()
+}
+
+// resolve looks up host and refuses it when any address is not permitted, so a name with one public and
+// one private record cannot be used to reach the private one.
+func (p *Proxy) resolve(ctx context.Context, host string) ([]net.IP, error) {
+	lookup := p.LookupIP
+	if lookup == nil {
+		lookup = func(ctx context.Context, host string) ([]net.IP, error) {
+			return net.DefaultResolver.LookupIP(ctx, "ip", host)
+		}
+	}
+	ips, err := lookup(ctx, host)
+	if err != nil || len(ips) == 0 {
+		return nil, Deny(http.StatusBadGateway, ReasonDNSFailure, "cannot resolve "+host)
+	}
+	permit := p.PermitIP
+	if permit == nil {
+		permit = PublicIP
+	}
+	for _, ip := range ips {
+		if !permit(ip) {
+			return nil, Deny(http.StatusForbidden, ReasonPrivateAddress, host+" resolves to a private or reserved address")
+		}
+	}
+	return ips, nil
+}
+
+// dial connects to the first reachable address in ips. Dialing the addresses that were checked, rather than
+// the name, keeps a second DNS answer from redirecting the connection.
+func (p *Proxy) dial(ctx context.Context, ips []net.IP, port int) (net.Conn, error) {
+	timeout := p.DialTimeout
+	if timeout <= 0 {
+		timeout = defaultDialTimeout
+	}
+	d := net.Dialer{Timeout: timeout}
+	var lastErr error
+	for _, ip := range ips {
+		c, err := d.DialContext(ctx, "tcp", net.JoinHostPort(ip.String(), strconv.Itoa(port)))
+		if err == nil {
+			return c, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// tunnel answers CONNECT and copies bytes both ways until either side closes.
+func (p *Proxy) tunnel(w http.ResponseWriter, r *http.Request, rec *AuditRecord, ips []net.IP) {
+	upstream, err := p.dial(r.Context(), ips, rec.Dest.Port)
+	if err != nil {
+		rec.Code = ReasonUpstreamError
+		writeDenial(w, Deny(http.StatusBadGateway, ReasonUpstreamError, "cannot connect to "+rec.Dest.Resource()))
+		return
+	}
+	defer func() { _ = upstream.Close() }()
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		rec.Code = ReasonUpstreamError
+		writeDenial(w, Deny(http.StatusInternalServerError, ReasonUpstreamError, "tunneling not supported"))
+		return
+	}
+	client, buf, err := hj.Hijack()
+	if err != nil {
+		rec.Code = ReasonUpstreamError
+		return
+	}
+	defer func() { _ = client.Close() }()
+	if _, err := io.WriteString(client, "HTTP/1.1 200 Connection Established\r\n\r\n"); err != nil {
+		return
+	}
+	// Bytes the server read ahead of the hijack belong to the tunnel.
+	var src io.Reader = client
+	if n := buf.Reader.Buffered(); n > 0 {
+		src = io.MultiReader(io.LimitReader(buf.Reader, int64(n)), client)
+	}
+	sent := make(chan int64, 1)
+	go func() {
+		n, _ := io.Copy(upstream, src)
+		closeWrite(upstream)
+		sent <- n
+	}()
+	rec.BytesReceived, _ = io.Copy(client, upstream)
+	_ = client.Close()
+	rec.BytesSent = <-sent
+}
+
+func closeWrite(c net.Conn) {
+	if cw, ok := c.(interface{ CloseWrite() error }); ok {
+		_ = cw.CloseWrite()
+	}
+}
+
+type dialTargetKey struct{}
+
+// forward sends an absolute-form http:// request upstream and streams the response back. Redirects are
+// returned to the sandbox, whose follow-up request is decided on its own.
+func (p *Proxy) forward(w http.ResponseWriter, r *http.Request, rec *AuditRecord, ips []net.IP) {
+	body := &countingReader{r: r.Body}
+	out := r.Clone(context.WithValue(r.Context(), dialTargetKey{}, ips))
+	out.RequestURI = ""
+	out.Body = body
+	if r.ContentLength == 0 {
+		out.Body = nil
+	}
+	removeHopHeaders(out.Header)
+	resp, err := p.roundTripper().RoundTrip(out)
+	rec.BytesSent = body.n
+	if err != nil {
+		rec.Code = ReasonUpstreamError
+		writeDenial(w, Deny(http.StatusBadGateway, ReasonUpstreamError, "request to "+rec.Dest.Resource()+" failed"))
+		return
+	}
+	defer func() { _ = resp.Body.Close() }()
+	removeHopHeaders(resp.Header)
+	for k, vs := range resp.Header {
+		w.Header()[k] = vs
+	}
+	w.WriteHeader(resp.StatusCode)
+	rec.BytesReceived, _ = io.Copy(w, resp.Body)
+}
+
+// roundTripper returns the shared upstream transport; it dials only the addresses admit checked.
+func (p *Proxy) roundTripper() *http.Transport {
+	p.transportOnce.Do(func() {
+		p.transport = &http.Transport{
+			Proxy: nil,
+			DialContext: func(ctx context.Context, _, addr string) (net.Conn, error) {
+				ips, _ := ctx.Value(dialTargetKey{}).([]net.IP)
+				_, port, err := net.SplitHostPort(addr)
+				if err != nil {
+					return nil, err
+				}
+				n, err := strconv.Atoi(port)
+				if err != nil || len(ips) == 0 {
+					return nil, errors.New("no checked address for " + addr)
+				}
+				return p.dial(ctx, ips, n)
+			},
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			ResponseHeaderTimeout: time.Minute,
+		}
+	})
+	return p.transport
+}
+
+// hopHeaders are connection-level headers a proxy must not forward (RFC 9110 section 7.6.1), plus the
+// proxy's own credentials.
+var hopHeaders = []string{
+	"Connection", "Proxy-Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+	"Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+func removeHopHeaders(h http.Header) {
+	for _, v := range h.Values("Connection") {
+		for _, name := range strings.Split(v, ",") {
+			h.Del(strings.TrimSpace(name))
+		}
+	}
+	for _, name := range hopHeaders {
+		h.Del(name)
+	}
+}
+
+type countingReader struct {
+	r io.ReadCloser
+	n int64
+}
+
+func (c *countingReader) Read(b []byte) (int, error) {
+	n, err := c.r.Read(b)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingReader) Close() error {
+	return c.r.Close()
+}
+
+func asDenial(err error) *Denial {
+	var d *Denial
+	if errors.As(err, &d) {
+		return d
+	}
+	return Deny(http.StatusServiceUnavailable, ReasonPolicyError, "cannot evaluate egress policy")
+}
+
+// writeDenial tells the sandbox which destination was refused and why.
+func writeDenial(w http.ResponseWriter, d *Denial) {
+	if d.Status == http.StatusProxyAuthRequired {
+		w.Header().Set("Proxy-Authenticate", `Basic realm="cynodeai-web-egress"`)
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set(HeaderDenyReason, d.Code)
+	w.WriteHeader(d.Status)
+	_, _ = fmt.Fprintf(w, "web egress denied (%s): %s\n", d.Code, d.Detail)
+}
@@ -0,0 +1,201 @@
+package webegress
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeAuth accepts job "job-1" with token "secret" and allows the resources in allowed.
+type fakeAuth struct {
+	allowed map[string]bool
+}
+
+func (a *fakeAuth) Authenticate(_ context.Context, jobID, token string) (*Caller, error) {
+	if jobID != "job-1" || token != "secret" {
+		return nil, Deny(http.StatusProxyAuthRequired, ReasonInvalidCredentials, "invalid job credentials")
+	}
+	return &Caller{JobID: uuid.New(), TaskID: uuid.New()}, nil
+}
+
+func (a *fakeAuth) Authorize(_ context.Context, _ *Caller, dest Destination) error {
+	if !a.allowed[dest.Resource()] {
+		return Deny(http.StatusForbidden, ReasonNotAllowlisted, dest.Resource()+" is not allowlisted")
+	}
+	return nil
+}
+
+// proxyFixture runs a Proxy that resolves every host to loopback. Audit records arrive on the returned channel.
+func proxyFixture(t *testing.T, allowed ...string) (proxyURL *url.URL, p *Proxy, audits chan *AuditRecord) {
+	t.Helper()
+	audits = make(chan *AuditRecord, 8)
+	auth := &fakeAuth{allowed: map[string]bool{}}
+	for _, r := range allowed {
+		auth.allowed[r] = true
+	}
+	p = New(auth, func(_ context.Context, rec *AuditRecord) { audits <- rec })
+	p.LookupIP = func(context.Context, string) ([]net.IP, error) { return []net.IP{net.ParseIP("127.0.0.1")}, nil }
+	p.PermitIP = func(net.IP) bool { return true }
+	srv := httptest.NewServer(p)
+	t.Cleanup(srv.Close)
+	proxyURL, _ = url.Parse(srv.URL)
+	return proxyURL, p, audits
+}
+
+func proxyClient(proxyURL *url.URL, user *url.Userinfo, tlsConfig *tls.Config) *http.Client {
+	u := *proxyURL
+	u.User = user
+	return &http.Client{
+		Transport: &http.Transport{Proxy: http.ProxyURL(&u), TLSClientConfig: tlsConfig},
+		Timeout:   10 * time.Second,
+	}
+}
+
+func nextAudit(t *testing.T, audits chan *AuditRecord) *AuditRecord {
+	t.Helper()
+	select {
+	case rec := <-audits:
+		return rec
+	case <-time.After(5 * time.Second):
+		t.Fatal("no audit record")
+		return nil
+	}
+}
+
+func TestProxy_ForwardHTTP(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Authorization") != "" {
+			t.Error("proxy credentials forwarded upstream")
+		}
+		b, _ := io.ReadAll(r.Body)
+		_, _ = w.Write([]byte("echo:" + string(b)))
+	}))
+	defer upstream.Close()
+	port := upstream.URL[strings.LastIndex(upstream.URL, ":")+1:]
+	proxyURL, _, audits := proxyFixture(t, "pkgs.example:"+port)
+
+	c := proxyClient(proxyURL, url.UserPassword("job-1", "secret"), nil)
+	resp, err := c.Post("http://pkgs.example:"+port+"/upload", "text/plain", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "echo:hello" {
+		t.Fatalf("status=%d body=%q", resp.StatusCode, body)
+	}
+	rec := nextAudit(t, audits)
+	if rec.Decision != DecisionAllow || rec.Code != ReasonAllowed || rec.Caller == nil {
+		t.Errorf("audit = %+v", rec)
+	}
+	if rec.Dest.Scheme != "http" || rec.Dest.Host != "pkgs.example" || rec.BytesSent != 5 || rec.BytesReceived != 10 {
+		t.Errorf("audit dest/bytes = %+v", rec)
+	}
+}
+
+func TestProxy_ConnectTunnel(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("secure"))
+	}))
+	defer upstream.Close()
+	port := upstream.URL[strings.LastIndex(upstream.URL, ":")+1:]
+	proxyURL, _, audits := proxyFixture(t, "example.com:"+port)
+	tlsConfig := upstream.Client().Transport.(*http.Transport).TLSClientConfig
+
+	c := proxyClient(proxyURL, url.UserPassword("job-1", "secret"), tlsConfig)
+	resp, err := c.Get("https://example.com:" + port + "/")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	c.CloseIdleConnections() // ends the tunnel, which is audited when it closes
+	if string(body) != "secure" {
+		t.Fatalf("body = %q", body)
+	}
+	rec := nextAudit(t, audits)
+	if rec.Decision != DecisionAllow || rec.Dest.Scheme != "https" || rec.BytesSent == 0 || rec.BytesReceived == 0 {
+		t.Errorf("audit = %+v", rec)
+	}
+}
+
+func TestProxy_Denials(t *testing.T) {
+	proxyURL, p, audits := proxyFixture(t, "allowed.example:80")
+	good := url.UserPassword("job-1", "secret")
+	for _, tc := range []struct {
+		name      string
+		user      *url.Userinfo
+		target    string
+		setup     func()
+		wantCode  int
+		wantCause string
+	}{
+		{"no credentials", nil, "http://allowed.example/", nil, http.StatusProxyAuthRequired, ReasonAuthRequired},
+		{"bad credentials", url.UserPassword("job-1", "wrong"), "http://allowed.example/", nil, http.StatusProxyAuthRequired, ReasonInvalidCredentials},
+		{"not allowlisted", good, "http://other.example/", nil, http.StatusForbidden, ReasonNotAllowlisted},
+		{"other port", good, "http://allowed.example:8080/", nil, http.StatusForbidden, ReasonNotAllowlisted},
+		{"ip literal", good, "http://10.0.0.1/", nil, http.StatusForbidden, ReasonIPLiteral},
+		{"private address", good, "http://allowed.example/", func() { p.PermitIP = nil }, http.StatusForbidden, ReasonPrivateAddress},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.setup != nil {
+				tc.setup()
+			}
+			resp, err := proxyClient(proxyURL, tc.user, nil).Get(tc.target)
+			if err != nil {
+				t.Fatalf("GET: %v", err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != tc.wantCode || resp.Header.Get(HeaderDenyReason) != tc.wantCause {
+				t.Errorf("status=%d reason=%q, want %d %q", resp.StatusCode, resp.Header.Get(HeaderDenyReason), tc.wantCode, tc.wantCause)
+			}
+			if rec := nextAudit(t, audits); rec.Decision != DecisionDeny || rec.Code != tc.wantCause {
+				t.Errorf("audit = %+v", rec)
+			}
+		})
+	}
+}
+
+func TestProxy_ConnectDenied(t *testing.T) {
+	proxyURL, _, audits := proxyFixture(t)
+	_, err := proxyClient(proxyURL, url.UserPassword("job-1", "secret"), nil).Get("https://blocked.example/")
+	if err == nil || !strings.Contains(err.Error(), "Forbidden") {
+		t.Fatalf("err = %v, want CONNECT refused", err)
+	}
+	if rec := nextAudit(t, audits); rec.Code != ReasonNotAllowlisted || rec.Dest.Resource() != "blocked.example:443" {
+		t.Errorf("audit = %+v", rec)
+	}
+}
+
+func TestPublicIP(t *testing.T) {
+	for ip, want := range map[string]bool{
+		"93.184.215.14": true, "2606:4700::1111": true,
+		"127.0.0.1": false, "10.1.2.3": false, "172.16.0.1": false, "192.168.1.1": false, "169.254.169.254": false,
+		"100.64.0.1": false, "0.0.0.0": false, "224.0.0.1": false, "::1": false, "fd00::1": false, "fe80::1": false,
+		"::ffff:10.0.0.1": false, "64:ff9b::a00:1": false,
+	} {
+		if got := PublicIP(net.ParseIP(ip)); got != want {
+			t.Errorf("PublicIP(%s) = %v, want %v", ip, got, want)
+		}
+	}
+}
+
+func TestJobToken(t *testing.T) {
+	secret := []byte("s3cret")
+	tok := JobToken(secret, "job-1")
+	if !VerifyJobToken(secret, "job-1", tok) {
+		t.Error("token does not verify")
+	}
+	if VerifyJobToken(secret, "job-2", tok) || VerifyJobToken([]byte("other"), "job-1", tok) || VerifyJobToken(nil, "job-1", JobToken(nil, "job-1")) {
+		t.Error("token verified for another job, secret, or an empty secret")
+	}
+}
@@ -0,0 +1,26 @@
+package webegress
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// tokenContext separates job token MACs from any other use of the secret.
+const tokenContext = "cynodeai-web-egress-job:"
+
+// JobToken returns the proxy credential for jobID: an HMAC-SHA256 of the job id under secret. The control-plane
+// hands it to the node with the job; the proxy accepts it only while the job is running.
+func JobToken(secret []byte, jobID string) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte(tokenContext + jobID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyJobToken reports whether token is JobToken(secret, jobID). An empty secret verifies nothing.
+func VerifyJobToken(secret []byte, jobID, token string) bool {
+	if len(secret) == 0 || token == "" {
+		return false
+	}
+	return hmac.Equal([]byte(JobToken(secret, jobID)), []byte(token))
+}
@@ -38,7 +38,7 @@ OLLAMA_CONTAINER_NAME = os.environ.get("OLLAMA_CONTAINER_NAME", "cynodeai-ollama
 OLLAMA_IMAGE = os.environ.get("OLLAMA_IMAGE", "ollama/ollama:rocm")
 PMA_CONTAINER_NAME = os.environ.get("PMA_CONTAINER_NAME", "cynodeai-cynode-pma")
 PMA_PORT = os.environ.get("PMA_PORT", "8090")
-JWT_SECRET = os.environ.get("JWT_SECRET", "dev-jwt-secret-change-in-production")
+JWT_SECRET = os.environ.get("JWT_SECRET", "cynodeai-dev-jwt-secret")
 NODE_PSK = os.environ.get("NODE_PSK", "dev-node-psk-secret")
 ADMIN_PASSWORD = os.environ.get("ADMIN_PASSWORD", "admin123")
 WORKER_PORT = os.environ.get("WORKER_PORT", "12090")
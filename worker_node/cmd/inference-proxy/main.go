@@ -33,6 +33,9 @@ func runMain(ctx context.Context, args []string) int {
 	if healthURL := parseHealthcheckURL(args); healthURL != "" {
 		return runHealthcheck(ctx, healthURL)
 	}
+	if usageURL := parseUsageURL(args); usageURL != "" {
+		return runUsage(ctx, usageURL, os.Stdout)
+	}
 	// REQ-WORKER-0260: prefer UDS when INFERENCE_PROXY_SOCKET is set.
 	if sockPath := os.Getenv("INFERENCE_PROXY_SOCKET"); sockPath != "" {
 		return runUDS(ctx, sockPath)
@@ -67,7 +70,9 @@ func run(ctx context.Context, listener net.Listener, listenAddrOverride string)
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("ok"))
 	})
-	mux.Handle("/", inferenceproxy.NewProxy(u))
+	usage := inferenceproxy.NewUsageTracker()
+	mux.Handle("GET /usage", usage)
+	mux.Handle("/", inferenceproxy.NewProxyWithUsage(u, usage))
 
 	srv := &http.Server{
 		Handler:      mux,
@@ -120,6 +125,42 @@ func parseHealthcheckURL(args []string) string {
 	return healthURL
 }
 
+// parseUsageURL returns the --usage-url flag: fetch the proxy's GET /usage once, print it and exit
+// (used by the worker to collect a job's token usage with runtime exec).
+func parseUsageURL(args []string) string {
+	fs := flag.NewFlagSet("inference-proxy", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var usageURL string
+	fs.StringVar(&usageURL, "usage-url", "", "print the proxy token usage as JSON and exit")
+	_ = fs.Parse(args)
+	return usageURL
+}
+
+func runUsage(ctx context.Context, rawURL string, out io.Writer) int {
+	reqCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, rawURL, http.NoBody)
+	if err != nil {
+		slog.Error("build usage request failed", "error", err)
+		return 1
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		slog.Error("usage request failed", "error", err)
+		return 1
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		slog.Error("usage status not ok", "status", resp.StatusCode)
+		return 1
+	}
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		slog.Error("write usage failed", "error", err)
+		return 1
+	}
+	return 0
+}
+
 func runHealthcheck(ctx context.Context, rawURL string) int {
 	u, err := url.Parse(rawURL)
 	if err != nil || u.Scheme == "" || u.Host == "" {
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"net"
 	"net/http"
@@ -91,6 +92,23 @@ func TestParseHealthcheckURL(t *testing.T) {
 	}
 }
 
+func TestRunUsage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"usage":[]}`))
+	}))
+	defer srv.Close()
+	if got := parseUsageURL([]string{"--usage-url", srv.URL}); got != srv.URL {
+		t.Fatalf("usage-url=%q", got)
+	}
+	var out bytes.Buffer
+	if code := runUsage(context.Background(), srv.URL, &out); code != 0 || out.String() != `{"usage":[]}` {
+		t.Errorf("runUsage=%d out=%q", code, out.String())
+	}
+	if code := runUsage(context.Background(), "http://127.0.0.1:1/usage", &out); code != 1 {
+		t.Errorf("unreachable runUsage=%d, want 1", code)
+	}
+}
+
 func TestRunHealthcheck_Cases(t *testing.T) {
 	okSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
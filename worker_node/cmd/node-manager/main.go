@@ -267,6 +267,7 @@ func startWorkerAPIBinary(bearerToken string) error {
 	}
 	env := os.Environ()
 	env = append(env, "WORKER_API_BEARER_TOKEN="+bearerToken)
+	env = append(env, workerAPIAuthEnv()...)
 	for _, key := range []string{"INFERENCE_PROXY_IMAGE", "OLLAMA_UPSTREAM_URL", "CONTAINER_RUNTIME", "WORKER_API_STATE_DIR"} {
 		if v := os.Getenv(key); v != "" {
 			env = append(env, key+"="+v)
@@ -302,6 +303,9 @@ func startWorkerAPIContainer(image, bearerToken string) error {
 			args = append(args, "-e", key+"="+v)
 		}
 	}
+	for _, kv := range workerAPIAuthEnv() {
+		args = append(args, "-e", kv)
+	}
 	args = append(args, image)
 	if out, err := runner.CombinedOutput(rt, args...); err != nil {
 		return fmt.Errorf("worker-api container: %w: %s", err, strings.TrimSpace(string(out)))
@@ -309,6 +313,14 @@ func startWorkerAPIContainer(image, bearerToken string) error {
 	return nil
 }
 
+// workerAPIAuthEnv returns the env that lets worker-api also accept orchestrator-signed worker tokens: the
+// control-plane JWKS (WORKER_API_JWKS_URL, default ORCHESTRATOR_URL/.well-known/jwks.json) and the node slug
+// those tokens are addressed to.
+func workerAPIAuthEnv() []string {
+	jwksURL := getEnv("WORKER_API_JWKS_URL", strings.TrimSuffix(getEnv("ORCHESTRATOR_URL", "http://localhost:12082"), "/")+"/.well-known/jwks.json")
+	return []string{"WORKER_API_JWKS_URL=" + jwksURL, "NODE_SLUG=" + getEnv("NODE_SLUG", "node-01")}
+}
+
 // startOllama starts the Phase 1 inference container (Ollama). image/variant from config or env. Fail-fast on error.
 // If a container named cynodeai-ollama already exists (e.g. from orchestrator compose), start it if stopped and return.
 // containerNameExact reports whether psOutput (from podman ps --format {{.Names}}) contains
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+	"github.com/cypher0n3/cynodeai/worker_node/cmd/worker-api/executor"
+)
+
+// artifactUploadTimeout bounds the upload of one artifact file.
+const artifactUploadTimeout = 5 * time.Minute
+
+// jobArtifactUploader pushes SBA job outputs to the orchestrator (worker_api.md Job Artifacts).
+// Each regular file under the job's artifacts directory is sent as PUT {upload_url}/{relative path},
+// authenticated with the Worker API bearer token and carrying its SHA-256 for verification.
+type jobArtifactUploader struct {
+	client      *http.Client
+	bearerToken string
+	logger      *slog.Logger
+}
+
+func newJobArtifactUploader(bearerToken string, logger *slog.Logger) *jobArtifactUploader {
+	return &jobArtifactUploader{client: &http.Client{Timeout: artifactUploadTimeout}, bearerToken: bearerToken, logger: logger}
+}
+
+// uploader returns the executor.ArtifactUploader that uploads every file under dir. Failures are logged per file.
+func (u *jobArtifactUploader) uploader() executor.ArtifactUploader {
+	return func(ctx context.Context, req *workerapi.RunJobRequest, dir string) {
+		_ = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return nil
+			}
+			rel, err := filepath.Rel(dir, p)
+			if err != nil {
+				return nil
+			}
+			rel = filepath.ToSlash(rel)
+			if err := u.uploadFile(ctx, req.Artifacts.UploadURL, rel, p); err != nil {
+				u.logger.Warn("artifact upload failed", "error", err, "task_id", req.TaskID, "job_id", req.JobID, "path", rel)
+				return nil
+			}
+			u.logger.Info("artifact uploaded", "task_id", req.TaskID, "job_id", req.JobID, "path", rel)
+			return nil
+		})
+	}
+}
+
+func (u *jobArtifactUploader) uploadFile(ctx context.Context, uploadURL, rel, file string) error {
+	sum, size, err := fileSHA256(file)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	segments := strings.Split(rel, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, strings.TrimSuffix(uploadURL, "/")+"/"+strings.Join(segments, "/"), f)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Authorization", "Bearer "+u.bearerToken)
+	req.Header.Set(workerapi.HeaderChecksumSHA256, sum)
+	if ct := mime.TypeByExtension(path.Ext(rel)); ct != "" {
+		req.Header.Set("Content-Type", ct)
+	} else {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("upload rejected: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+func fileSHA256(file string) (sum string, size int64, err error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	size, err = io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
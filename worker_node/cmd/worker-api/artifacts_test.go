@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+)
+
+func TestJobArtifactUploader_UploadsFiles(t *testing.T) {
+	var mu sync.Mutex
+	got := map[string]*http.Request{}
+	bodies := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		got[r.URL.EscapedPath()] = r
+		bodies[r.URL.EscapedPath()] = string(b)
+		mu.Unlock()
+		if r.URL.Path == "/v1/jobs/j1/artifacts/reject.bin" {
+			http.Error(w, "nope", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(dir, "sub dir"), 0o700)
+	_ = os.WriteFile(filepath.Join(dir, "report.json"), []byte(`{"ok":true}`), 0o600)
+	_ = os.WriteFile(filepath.Join(dir, "sub dir", "a.txt"), []byte("hello"), 0o600)
+	_ = os.WriteFile(filepath.Join(dir, "reject.bin"), []byte("x"), 0o600)
+
+	req := &workerapi.RunJobRequest{TaskID: "t1", JobID: "j1",
+		Artifacts: &workerapi.ArtifactUploadSpec{UploadURL: srv.URL + "/v1/jobs/j1/artifacts/"}}
+	newJobArtifactUploader("worker-token", slog.Default()).uploader()(context.Background(), req, dir)
+
+	if len(got) != 3 {
+		t.Fatalf("uploads = %v", got)
+	}
+	r := got["/v1/jobs/j1/artifacts/sub%20dir/a.txt"]
+	if r == nil || bodies["/v1/jobs/j1/artifacts/sub%20dir/a.txt"] != "hello" {
+		t.Fatalf("nested upload missing: %v", got)
+	}
+	if r.Header.Get("Authorization") != "Bearer worker-token" ||
+		r.Header.Get(workerapi.HeaderChecksumSHA256) != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Errorf("headers = %v", r.Header)
+	}
+	if ct := got["/v1/jobs/j1/artifacts/report.json"].Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("content type = %q", ct)
+	}
+}
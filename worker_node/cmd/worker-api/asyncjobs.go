@@ -0,0 +1,358 @@
+// Async job execution: POST /v1/worker/jobs:submit returns 202 with a job handle; the job runs in the
+// background, heartbeats to the orchestrator to extend its lease, and its result is retained for
+// GET /v1/worker/jobs/{job_id}/result. See docs/tech_specs/worker_api.md#run-job-asynchronous.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/problem"
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+	"github.com/cypher0n3/cynodeai/worker_node/cmd/worker-api/executor"
+)
+
+// defaultAsyncResultRetention is how long a finished async job result is kept for the orchestrator to collect.
+const defaultAsyncResultRetention = time.Hour
+
+// defaultHeartbeatInterval is used when the lease spec does not set an interval.
+const defaultHeartbeatInterval = 20 * time.Second
+
+type asyncJob struct {
+	req        *workerapi.RunJobRequest
+	status     string
+	startedAt  time.Time
+	endedAt    time.Time
+	result     *workerapi.RunJobResponse
+	superseded bool               // a submission under a newer lease replaced this run
+	cancel     context.CancelFunc // stops the run
+	done       chan struct{}      // closed once the run has finished and its workspace is removed
+}
+
+// errPreviousRunStopping is returned by submit when a run under an older lease did not stop in time.
+var errPreviousRunStopping = errors.New("previous run of the job is still stopping")
+
+// leaseIDOf returns the request's lease_id, or "" for a submission without a lease.
+func leaseIDOf(req *workerapi.RunJobRequest) string {
+	if req.Lease == nil {
+		return ""
+	}
+	return req.Lease.LeaseID
+}
+
+// asyncJobRegistry tracks async jobs by job_id, one run (the latest lease) per job.
+// Results are retained until retention elapses after completion.
+type asyncJobRegistry struct {
+	exec          *executor.Executor
+	bearerToken   string
+	workspaceRoot string
+	logger        *slog.Logger
+	client        *http.Client
+	retention     time.Duration
+	running       *runningJobs
+
+	mu   sync.Mutex
+	jobs map[string]*asyncJob
+}
+
+func newAsyncJobRegistry(exec *executor.Executor, bearerToken, workspaceRoot string, running *runningJobs, logger *slog.Logger) *asyncJobRegistry {
+	return &asyncJobRegistry{
+		exec:          exec,
+		bearerToken:   bearerToken,
+		workspaceRoot: workspaceRoot,
+		logger:        logger,
+		client:        &http.Client{Timeout: 10 * time.Second},
+		retention:     time.Duration(getEnvInt("ASYNC_RESULT_RETENTION_SECONDS", int(defaultAsyncResultRetention/time.Second))) * time.Second,
+		running:       running,
+		jobs:          map[string]*asyncJob{},
+	}
+}
+
+// submit registers and starts the job. Re-submitting a known job_id under the same lease is idempotent and
+// returns the existing job. A different lease is a new attempt (the orchestrator requeued the job after a failed
+// one): the previous run is stopped if it is still running, its result is dropped, and the job runs again.
+func (reg *asyncJobRegistry) submit(req *workerapi.RunJobRequest) (*asyncJob, error) {
+	for {
+		reg.mu.Lock()
+		reg.pruneLocked(time.Now())
+		prev, ok := reg.jobs[req.JobID]
+		if ok && leaseIDOf(prev.req) == leaseIDOf(req) {
+			reg.mu.Unlock()
+			return prev, nil
+		}
+		if ok && !isDone(prev) {
+			prev.superseded = true
+			reg.mu.Unlock()
+			if err := reg.stop(prev); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		workspaceDir, cleanup, err := prepareWorkspace(reg.workspaceRoot, req.JobID)
+		if err != nil {
+			reg.mu.Unlock()
+			return nil, err
+		}
+		job := &asyncJob{req: req, status: workerapi.StatusAccepted, startedAt: time.Now().UTC(), done: make(chan struct{})}
+		reg.jobs[req.JobID] = job
+		runCtx, finish := reg.running.start(context.Background(), req)
+		runCtx, job.cancel = context.WithCancel(runCtx)
+		reg.mu.Unlock()
+
+		go reg.run(runCtx, finish, job, workspaceDir, cleanup)
+		return job, nil
+	}
+}
+
+// stop cancels a run, removes its containers, and waits for it to finish so a new run of the same job does not
+// share its workspace or container names.
+func (reg *asyncJobRegistry) stop(job *asyncJob) error {
+	job.cancel()
+	if err := reg.exec.CancelJob(context.Background(), job.req.JobID); err != nil {
+		reg.logger.Warn("remove stopped job containers failed", "error", err, "job_id", job.req.JobID)
+	}
+	select {
+	case <-job.done:
+		return nil
+	case <-time.After(cancelWaitTimeout):
+		return errPreviousRunStopping
+	}
+}
+
+func isDone(job *asyncJob) bool {
+	select {
+	case <-job.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (reg *asyncJobRegistry) run(ctx context.Context, finish func(*workerapi.RunJobResponse), job *asyncJob, workspaceDir string, cleanup func()) {
+	defer close(job.done)
+	defer job.cancel()
+	if cleanup != nil {
+		defer cleanup()
+	}
+	reg.setStatus(job, workerapi.StatusInProgress)
+	heartbeats := job.req.Lease != nil && job.req.Lease.HeartbeatURL != ""
+	hbCtx, stopHeartbeats := context.WithCancel(context.Background())
+	hbDone := make(chan struct{})
+	if heartbeats {
+		go func() {
+			defer close(hbDone)
+			reg.heartbeatLoop(hbCtx, job)
+		}()
+	} else {
+		close(hbDone)
+	}
+	resp, err := reg.exec.RunJob(ctx, job.req, workspaceDir)
+	stopHeartbeats()
+	<-hbDone
+	if err != nil {
+		reg.logger.Error("async job execution error", "error", err, "job_id", job.req.JobID)
+		now := time.Now().UTC()
+		resp = &workerapi.RunJobResponse{
+			Version: 1, TaskID: job.req.TaskID, JobID: job.req.JobID,
+			Status:    workerapi.StatusFailed,
+			Stderr:    err.Error(),
+			StartedAt: job.startedAt.Format(time.RFC3339),
+			EndedAt:   now.Format(time.RFC3339),
+		}
+	}
+	reg.mu.Lock()
+	job.result = resp
+	job.status = resp.Status
+	job.endedAt = time.Now().UTC()
+	superseded := job.superseded
+	reg.mu.Unlock()
+	finish(resp)
+	if heartbeats && !superseded {
+		// Final heartbeat so the orchestrator learns the job is terminal without waiting for its next poll.
+		reg.sendHeartbeat(context.Background(), job)
+	}
+}
+
+func (reg *asyncJobRegistry) setStatus(job *asyncJob, status string) {
+	reg.mu.Lock()
+	job.status = status
+	reg.mu.Unlock()
+}
+
+// heartbeatLoop posts heartbeats at the lease interval until ctx is done (job finished).
+func (reg *asyncJobRegistry) heartbeatLoop(ctx context.Context, job *asyncJob) {
+	interval := time.Duration(job.req.Lease.HeartbeatIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = defaultHeartbeatInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	reg.sendHeartbeat(ctx, job)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reg.sendHeartbeat(ctx, job)
+		}
+	}
+}
+
+func (reg *asyncJobRegistry) sendHeartbeat(ctx context.Context, job *asyncJob) {
+	status, _ := reg.snapshot(job)
+	body, _ := json.Marshal(workerapi.HeartbeatRequest{
+		Version: 1,
+		JobID:   job.req.JobID,
+		LeaseID: job.req.Lease.LeaseID,
+		Status:  status.Status,
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.req.Lease.HeartbeatURL, bytes.NewReader(body))
+	if err != nil {
+		reg.logger.Warn("heartbeat request build failed", "error", err, "job_id", job.req.JobID)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+reg.bearerToken)
+	resp, err := reg.client.Do(req)
+	if err != nil {
+		reg.logger.Warn("heartbeat failed", "error", err, "job_id", job.req.JobID)
+		return
+	}
+	_ = resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusConflict) && !workerapi.IsTerminalStatus(status.Status):
+		// The orchestrator no longer runs the job under this lease (canceled, reclaimed, or retried elsewhere);
+		// its result would be discarded, so stop it instead of heartbeating until it ends.
+		reg.logger.Warn("job lease lost; stopping job", "status", resp.StatusCode, "job_id", job.req.JobID)
+		job.cancel()
+		if err := reg.exec.CancelJob(context.WithoutCancel(ctx), job.req.JobID); err != nil {
+			reg.logger.Warn("remove stopped job containers failed", "error", err, "job_id", job.req.JobID)
+		}
+	default:
+		reg.logger.Warn("heartbeat rejected", "status", resp.StatusCode, "job_id", job.req.JobID)
+	}
+}
+
+// snapshot returns the job's current status and, when terminal, its result.
+func (reg *asyncJobRegistry) snapshot(job *asyncJob) (workerapi.JobStatusResponse, *workerapi.RunJobResponse) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	st := workerapi.JobStatusResponse{
+		Version:   1,
+		TaskID:    job.req.TaskID,
+		JobID:     job.req.JobID,
+		Status:    job.status,
+		StartedAt: job.startedAt.Format(time.RFC3339),
+	}
+	if !job.endedAt.IsZero() {
+		st.EndedAt = job.endedAt.Format(time.RFC3339)
+	}
+	return st, job.result
+}
+
+func (reg *asyncJobRegistry) get(jobID string) (*asyncJob, bool) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.pruneLocked(time.Now())
+	job, ok := reg.jobs[jobID]
+	return job, ok
+}
+
+// pruneLocked drops finished jobs older than the retention window. Caller holds reg.mu.
+func (reg *asyncJobRegistry) pruneLocked(now time.Time) {
+	for id, job := range reg.jobs {
+		if !job.endedAt.IsZero() && now.Sub(job.endedAt) > reg.retention {
+			delete(reg.jobs, id)
+		}
+	}
+}
+
+func handleSubmitJob(reg *asyncJobRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireBearerToken(r, reg.bearerToken) {
+			writeProblem(w, http.StatusUnauthorized, problem.TypeAuthentication, "Unauthorized", "Invalid or missing bearer token")
+			return
+		}
+		req, ok := decodeRunJobRequest(w, r, 10*1024*1024)
+		if !ok {
+			return
+		}
+		if err := validateRunJobRequest(req); err != nil {
+			writeProblem(w, http.StatusBadRequest, problem.TypeValidation, "Bad Request", err.Error())
+			return
+		}
+		job, err := reg.submit(req)
+		if errors.Is(err, errPreviousRunStopping) {
+			writeProblem(w, http.StatusServiceUnavailable, problem.TypeInternal, "Service Unavailable", err.Error())
+			return
+		}
+		if err != nil {
+			reg.logger.Error("workspace creation failed", "error", err, "job_id", req.JobID)
+			writeProblem(w, http.StatusInternalServerError, problem.TypeInternal, "Internal Server Error", "Workspace creation failed")
+			return
+		}
+		st, _ := reg.snapshot(job)
+		base := "/v1/worker/jobs/" + url.PathEscape(req.JobID)
+		writeJSON(w, http.StatusAccepted, workerapi.SubmitJobResponse{
+			Version:   1,
+			TaskID:    st.TaskID,
+			JobID:     st.JobID,
+			Status:    st.Status,
+			StatusURL: base,
+			ResultURL: base + "/result",
+		})
+	}
+}
+
+func handleGetJobStatus(reg *asyncJobRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		job, ok := asyncJobFromRequest(w, r, reg)
+		if !ok {
+			return
+		}
+		st, _ := reg.snapshot(job)
+		writeJSON(w, http.StatusOK, st)
+	}
+}
+
+// handleGetJobResult returns 200 with the RunJobResponse when terminal, or 409 while the job is still running.
+func handleGetJobResult(reg *asyncJobRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		job, ok := asyncJobFromRequest(w, r, reg)
+		if !ok {
+			return
+		}
+		st, result := reg.snapshot(job)
+		if result == nil {
+			writeProblem(w, http.StatusConflict, problem.TypeConflict, "Conflict", fmt.Sprintf("job is %s", st.Status))
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+func asyncJobFromRequest(w http.ResponseWriter, r *http.Request, reg *asyncJobRegistry) (*asyncJob, bool) {
+	if !requireBearerToken(r, reg.bearerToken) {
+		writeProblem(w, http.StatusUnauthorized, problem.TypeAuthentication, "Unauthorized", "Invalid or missing bearer token")
+		return nil, false
+	}
+	job, ok := reg.get(r.PathValue("job_id"))
+	// With lease_id, only the run submitted under that lease matches, never an earlier attempt's.
+	if lease := r.URL.Query().Get("lease_id"); ok && lease != "" && lease != leaseIDOf(job.req) {
+		ok = false
+	}
+	if !ok {
+		writeProblem(w, http.StatusNotFound, problem.TypeNotFound, "Not Found", "Unknown job_id")
+		return nil, false
+	}
+	return job, true
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+	"github.com/cypher0n3/cynodeai/worker_node/cmd/worker-api/executor"
+)
+
+func submitAsyncJob(t *testing.T, mux *http.ServeMux, req *workerapi.RunJobRequest) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(req)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/v1/worker/jobs:submit", bytes.NewReader(body))
+	r.Header.Set("Authorization", "Bearer test-bearer")
+	mux.ServeHTTP(w, r)
+	return w
+}
+
+func getAsync(t *testing.T, mux *http.ServeMux, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+	r.Header.Set("Authorization", "Bearer test-bearer")
+	mux.ServeHTTP(w, r)
+	return w
+}
+
+func waitForAsyncResult(t *testing.T, mux *http.ServeMux, jobID string) workerapi.RunJobResponse {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		w := getAsync(t, mux, "/v1/worker/jobs/"+jobID+"/result")
+		if w.Code == http.StatusOK {
+			var resp workerapi.RunJobResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode result: %v", err)
+			}
+			return resp
+		}
+		if w.Code != http.StatusConflict {
+			t.Fatalf("result status %d: %s", w.Code, w.Body.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for async result")
+	return workerapi.RunJobResponse{}
+}
+
+func TestAsyncJob_SubmitStatusResult(t *testing.T) {
+	mux := newMux(executor.New("direct", 5*time.Second, 1024, "", "", nil), "test-bearer", t.TempDir(), nil, slog.Default())
+	req := &workerapi.RunJobRequest{Version: 1, TaskID: "task-1", JobID: "job-async", Sandbox: workerapi.SandboxSpec{Command: runJobCmd()}}
+
+	w := submitAsyncJob(t, mux, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("submit status %d: %s", w.Code, w.Body.String())
+	}
+	var handle workerapi.SubmitJobResponse
+	if err := json.NewDecoder(w.Body).Decode(&handle); err != nil {
+		t.Fatalf("decode handle: %v", err)
+	}
+	if handle.JobID != "job-async" || handle.StatusURL != "/v1/worker/jobs/job-async" || handle.ResultURL != "/v1/worker/jobs/job-async/result" {
+		t.Errorf("handle %+v", handle)
+	}
+
+	resp := waitForAsyncResult(t, mux, "job-async")
+	if resp.Status != workerapi.StatusCompleted {
+		t.Errorf("result status %s", resp.Status)
+	}
+	w = getAsync(t, mux, handle.StatusURL)
+	var st workerapi.JobStatusResponse
+	_ = json.NewDecoder(w.Body).Decode(&st)
+	if w.Code != http.StatusOK || st.Status != workerapi.StatusCompleted || st.EndedAt == "" {
+		t.Errorf("status %d %+v", w.Code, st)
+	}
+
+	// Re-submitting the same job_id returns the existing job rather than running it again.
+	w = submitAsyncJob(t, mux, req)
+	_ = json.NewDecoder(w.Body).Decode(&handle)
+	if w.Code != http.StatusAccepted || handle.Status != workerapi.StatusCompleted {
+		t.Errorf("resubmit: %d %+v", w.Code, handle)
+	}
+}
+
+func TestAsyncJob_Errors(t *testing.T) {
+	mux := newMux(executor.New("direct", 5*time.Second, 1024, "", "", nil), "test-bearer", "", nil, slog.Default())
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/worker/jobs:submit", http.NoBody))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("unauthenticated submit: %d", w.Code)
+	}
+	w = submitAsyncJob(t, mux, &workerapi.RunJobRequest{Version: 1, TaskID: "t", JobID: "j"})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid submit: %d", w.Code)
+	}
+	if w = getAsync(t, mux, "/v1/worker/jobs/unknown"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown status: %d", w.Code)
+	}
+	if w = getAsync(t, mux, "/v1/worker/jobs/unknown/result"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown result: %d", w.Code)
+	}
+}
+
+func TestAsyncJob_Heartbeats(t *testing.T) {
+	var mu sync.Mutex
+	var beats []workerapi.HeartbeatRequest
+	var auth string
+	orch := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var hb workerapi.HeartbeatRequest
+		_ = json.NewDecoder(r.Body).Decode(&hb)
+		mu.Lock()
+		beats = append(beats, hb)
+		auth = r.Header.Get("Authorization")
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer orch.Close()
+
+	mux := newMux(executor.New("direct", 5*time.Second, 1024, "", "", nil), "test-bearer", "", nil, slog.Default())
+	req := &workerapi.RunJobRequest{
+		Version: 1, TaskID: "task-1", JobID: "job-hb",
+		Sandbox: workerapi.SandboxSpec{Command: runJobCmd()},
+		Lease:   &workerapi.LeaseSpec{LeaseID: "lease-1", HeartbeatURL: orch.URL, HeartbeatIntervalSeconds: 1},
+	}
+	if w := submitAsyncJob(t, mux, req); w.Code != http.StatusAccepted {
+		t.Fatalf("submit: %d", w.Code)
+	}
+	waitForAsyncResult(t, mux, "job-hb")
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		done := len(beats) > 0 && workerapi.IsTerminalStatus(beats[len(beats)-1].Status)
+		mu.Unlock()
+		if done {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(beats) == 0 {
+		t.Fatal("expected at least one heartbeat")
+	}
+	last := beats[len(beats)-1]
+	if last.LeaseID != "lease-1" || last.JobID != "job-hb" || last.Status != workerapi.StatusCompleted {
+		t.Errorf("final heartbeat %+v", last)
+	}
+	if auth != "Bearer test-bearer" {
+		t.Errorf("heartbeat auth %q", auth)
+	}
+}
+
+// TestAsyncJob_RetryOnSameNode submits a job that fails, then re-submits it under a new lease as the
+// orchestrator does after requeueing it: the job runs again rather than returning the failed attempt.
+func TestAsyncJob_RetryOnSameNode(t *testing.T) {
+	ctx, store, _ := telemetryMuxWithStore(t)
+	exec := executor.New("direct", 5*time.Second, 1024, "", "", nil)
+	exec.SetLogSink(newJobLogRecorder(store, nil).sink())
+	mux := newMux(exec, "test-bearer", t.TempDir(), store, slog.Default())
+	marker := filepath.Join(t.TempDir(), "attempted")
+	attempt := func(leaseID string) *workerapi.RunJobRequest {
+		return &workerapi.RunJobRequest{
+			Version: 1, TaskID: "task-1", JobID: "job-retry",
+			Sandbox: workerapi.SandboxSpec{Command: []string{"sh", "-c", "if [ -f " + marker + " ]; then echo second; else touch " + marker + "; echo first; exit 3; fi"}},
+			Lease:   &workerapi.LeaseSpec{LeaseID: leaseID},
+		}
+	}
+
+	if w := submitAsyncJob(t, mux, attempt("lease-1")); w.Code != http.StatusAccepted {
+		t.Fatalf("submit attempt 1: %d", w.Code)
+	}
+	if got := waitForAsyncResult(t, mux, "job-retry"); got.Status != workerapi.StatusFailed {
+		t.Fatalf("attempt 1 status %s", got.Status)
+	}
+	if w := submitAsyncJob(t, mux, attempt("lease-2")); w.Code != http.StatusAccepted {
+		t.Fatalf("submit attempt 2: %d", w.Code)
+	}
+	if got := waitForAsyncResult(t, mux, "job-retry"); got.Status != workerapi.StatusCompleted || got.Stdout != "second\n" {
+		t.Errorf("attempt 2 result %+v", got)
+	}
+	if w := getAsync(t, mux, "/v1/worker/jobs/job-retry/result?lease_id=lease-1"); w.Code != http.StatusNotFound {
+		t.Errorf("attempt 1 result after retry: %d, want 404", w.Code)
+	}
+	if w := getAsync(t, mux, "/v1/worker/jobs/job-retry/result?lease_id=lease-2"); w.Code != http.StatusOK {
+		t.Errorf("attempt 2 result by lease: %d", w.Code)
+	}
+	events, _, _, err := store.QueryLogs(ctx, "container", jobLogSourcePrefix+"job-retry", "", "", "", "", "", 0)
+	if err != nil || len(events) != 1 || events[0].Message != "second" {
+		t.Errorf("recorded output after retry = %+v (%v), want only attempt 2", events, err)
+	}
+}
+
+// TestAsyncJob_NewLeaseStopsRunningAttempt verifies a re-submission under a new lease stops an attempt
+// that is still running (the orchestrator reclaimed it) and runs the job again.
+func TestAsyncJob_NewLeaseStopsRunningAttempt(t *testing.T) {
+	mux := newMux(executor.New("direct", time.Minute, 1024, "", "", nil), "test-bearer", t.TempDir(), nil, slog.Default())
+	stale := sleepJob("job-reclaimed")
+	stale.Lease = &workerapi.LeaseSpec{LeaseID: "lease-1"}
+	if w := submitAsyncJob(t, mux, stale); w.Code != http.StatusAccepted {
+		t.Fatalf("submit attempt 1: %d", w.Code)
+	}
+	retry := &workerapi.RunJobRequest{Version: 1, TaskID: "task-1", JobID: "job-reclaimed",
+		Sandbox: workerapi.SandboxSpec{Command: runJobCmd()}, Lease: &workerapi.LeaseSpec{LeaseID: "lease-2"}}
+	if w := submitAsyncJob(t, mux, retry); w.Code != http.StatusAccepted {
+		t.Fatalf("submit attempt 2: %d", w.Code)
+	}
+	if got := waitForAsyncResult(t, mux, "job-reclaimed"); got.Status != workerapi.StatusCompleted {
+		t.Errorf("attempt 2 status %s", got.Status)
+	}
+}
+
+// TestAsyncJob_StopsWhenLeaseLost verifies a job whose heartbeat is refused (409) is stopped.
+func TestAsyncJob_StopsWhenLeaseLost(t *testing.T) {
+	orch := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	}))
+	defer orch.Close()
+	mux := newMux(executor.New("direct", time.Minute, 1024, "", "", nil), "test-bearer", t.TempDir(), nil, slog.Default())
+	req := sleepJob("job-lost")
+	req.Lease = &workerapi.LeaseSpec{LeaseID: "lease-1", HeartbeatURL: orch.URL, HeartbeatIntervalSeconds: 1}
+	if w := submitAsyncJob(t, mux, req); w.Code != http.StatusAccepted {
+		t.Fatalf("submit: %d", w.Code)
+	}
+	if got := waitForAsyncResult(t, mux, "job-lost"); got.Status == workerapi.StatusCompleted {
+		t.Errorf("job kept running after its lease was lost: %+v", got)
+	}
+}
+
+func TestAsyncJobRegistry_Prune(t *testing.T) {
+	reg := newAsyncJobRegistry(executor.New("direct", time.Second, 1024, "", "", nil), "t", "", newRunningJobs(), slog.Default())
+	reg.retention = time.Minute
+	now := time.Now()
+	reg.jobs["old"] = &asyncJob{endedAt: now.Add(-2 * time.Minute)}
+	reg.jobs["recent"] = &asyncJob{endedAt: now}
+	reg.jobs["running"] = &asyncJob{}
+	reg.pruneLocked(now)
+	if _, ok := reg.jobs["old"]; ok {
+		t.Error("old job should be pruned")
+	}
+	if len(reg.jobs) != 2 {
+		t.Errorf("jobs left: %d", len(reg.jobs))
+	}
+}
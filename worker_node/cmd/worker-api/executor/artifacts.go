@@ -0,0 +1,36 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+)
+
+// artifactsDirname is the job directory subdirectory where SBA jobs write output files (/job/artifacts).
+const artifactsDirname = "artifacts"
+
+// ArtifactUploader pushes the files under dir (a finished job's artifacts directory) to
+// req.Artifacts.UploadURL. It runs before the job directory is removed; failures must be handled
+// (logged) by the uploader and never fail the job.
+type ArtifactUploader func(ctx context.Context, req *workerapi.RunJobRequest, dir string)
+
+// SetArtifactUploader sets the uploader for job outputs; nil disables uploads. Only jobs whose request
+// carries an artifacts upload URL are uploaded.
+func (e *Executor) SetArtifactUploader(u ArtifactUploader) {
+	e.artifactUploader = u
+}
+
+// uploadArtifacts hands jobDir/artifacts to the uploader when the job asked for uploads and produced outputs.
+// The upload outlives job cancellation so outputs of timed-out jobs are still kept.
+func (e *Executor) uploadArtifacts(ctx context.Context, req *workerapi.RunJobRequest, jobDir string) {
+	if e.artifactUploader == nil || req.Artifacts == nil || req.Artifacts.UploadURL == "" {
+		return
+	}
+	dir := filepath.Join(jobDir, artifactsDirname)
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		return
+	}
+	e.artifactUploader(context.WithoutCancel(ctx), req, dir)
+}
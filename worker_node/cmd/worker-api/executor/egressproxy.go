@@ -0,0 +1,78 @@
+package executor
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+)
+
+// egressProxyNoProxy keeps in-pod services (inference proxy) off the egress proxy.
+const egressProxyNoProxy = "localhost,127.0.0.1,::1"
+
+// egressProxyEnvKeys are set for network policy egress-proxy; lower-case forms are what curl and pip read.
+var egressProxyEnvKeys = []string{"HTTP_PROXY", "HTTPS_PROXY", "http_proxy", "https_proxy"}
+
+// SetWebEgressProxy enables network policy egress-proxy (web_egress_proxy.md). Such sandboxes join network,
+// which the operator provisions so that its only route out is the Web Egress Proxy at proxyURL (for example an
+// internal podman network the proxy is also attached to), and get proxy env vars carrying the job's credential.
+// With either value empty, egress-proxy jobs fail instead of running with network access.
+func (e *Executor) SetWebEgressProxy(network, proxyURL string) {
+	e.egressNetwork = strings.TrimSpace(network)
+	e.egressProxyURL = strings.TrimSpace(proxyURL)
+}
+
+func usesEgressProxy(req *workerapi.RunJobRequest) bool {
+	return strings.EqualFold(strings.TrimSpace(req.Sandbox.NetworkPolicy), workerapi.NetworkPolicyEgressProxy)
+}
+
+// checkEgressProxy returns why an egress-proxy job cannot run on this node, or nil.
+func (e *Executor) checkEgressProxy(req *workerapi.RunJobRequest) error {
+	switch {
+	case !usesEgressProxy(req):
+		return nil
+	case e.runtime == "direct":
+		return errors.New("network_policy egress-proxy requires a container runtime")
+	case e.egressNetwork == "" || e.egressProxyURL == "":
+		return errors.New("network_policy egress-proxy is not enabled on this node")
+	case req.Sandbox.EgressProxyToken == "":
+		return errors.New("network_policy egress-proxy requires egress_proxy_token")
+	}
+	return nil
+}
+
+// networkArgs returns the container network flags for req. Every policy but egress-proxy denies all
+// network access (none and restricted are the same in Phase 1; unknown values fall back to none).
+func (e *Executor) networkArgs(req *workerapi.RunJobRequest) []string {
+	if usesEgressProxy(req) && e.egressNetwork != "" {
+		return []string{"--network=" + e.egressNetwork}
+	}
+	return []string{"--network=none"}
+}
+
+// podNetworkArgs returns pod create flags: egress-proxy pods join the egress network; others keep the default.
+func (e *Executor) podNetworkArgs(req *workerapi.RunJobRequest) []string {
+	if usesEgressProxy(req) && e.egressNetwork != "" {
+		return []string{"--network=" + e.egressNetwork}
+	}
+	return nil
+}
+
+// addEgressProxyEnv points the sandbox's HTTP tooling at the egress proxy with the job id and token as proxy
+// credentials. They are overwritten even if the request set them.
+func (e *Executor) addEgressProxyEnv(env map[string]string, req *workerapi.RunJobRequest) {
+	if !usesEgressProxy(req) || e.egressProxyURL == "" {
+		return
+	}
+	u, err := url.Parse(e.egressProxyURL)
+	if err != nil {
+		return
+	}
+	u.User = url.UserPassword(req.JobID, req.Sandbox.EgressProxyToken)
+	for _, k := range egressProxyEnvKeys {
+		env[k] = u.String()
+	}
+	env["NO_PROXY"] = egressProxyNoProxy
+	env["no_proxy"] = egressProxyNoProxy
+}
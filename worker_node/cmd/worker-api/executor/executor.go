@@ -2,9 +2,9 @@
 package executor
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
@@ -43,7 +43,11 @@ type Executor struct {
 	maxOutputBytes        int
 	ollamaUpstreamURL     string // when set with inferenceProxyImage, jobs with UseInference run in pod with proxy
 	inferenceProxyImage   string
-	inferenceProxyCommand []string // optional; when set, appended to proxy container run (e.g. ["sleep","60"] for tests)
+	inferenceProxyCommand []string         // optional; when set, appended to proxy container run (e.g. ["sleep","60"] for tests)
+	logSink               LogSink          // optional; receives live job output (see SetLogSink)
+	artifactUploader      ArtifactUploader // optional; pushes SBA job outputs (see SetArtifactUploader)
+	egressNetwork         string           // optional; network for egress-proxy jobs (see SetWebEgressProxy)
+	egressProxyURL        string           // optional; Web Egress Proxy URL reachable on egressNetwork
 }
 
 // New creates a new job executor. ollamaUpstreamURL and inferenceProxyImage are optional;
@@ -101,6 +105,11 @@ func (e *Executor) RunJob(ctx context.Context, req *workerapi.RunJobRequest, wor
 		image = workerapi.DefaultImage
 	}
 
+	if err := e.checkEgressProxy(req); err != nil {
+		setSBAError(resp, err.Error())
+		return resp, nil
+	}
+
 	// "direct" executes the command in-process (inside the worker-api container).
 	// This is useful for containerized dev environments where running podman-in-podman
 	// is undesirable. Production deployments SHOULD use a real container runtime.
@@ -121,13 +130,7 @@ func (e *Executor) RunJob(ctx context.Context, req *workerapi.RunJobRequest, wor
 	// Build container run command.
 	args := []string{"run", "--rm"}
 
-	// Phase 1: none and restricted both mean deny-all (worker_api.md).
-	switch strings.ToLower(strings.TrimSpace(req.Sandbox.NetworkPolicy)) {
-	case "none", "restricted", "":
-		args = append(args, "--network=none")
-	default:
-		args = append(args, "--network=none")
-	}
+	args = append(args, e.networkArgs(req)...)
 
 	if workspaceDir != "" {
 		args = append(args, "-v", fmt.Sprintf("%s:%s", workspaceDir, workspaceMount), "-w", workspaceMount)
@@ -148,11 +151,10 @@ func (e *Executor) RunJob(ctx context.Context, req *workerapi.RunJobRequest, wor
 
 	cmd := exec.CommandContext(ctx, e.runtime, args...)
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	stdout, stderr, flushLogs := e.captureOutput(cmd, req)
 
 	runErr := cmd.Run()
+	flushLogs()
 	endedAt := time.Now().UTC()
 	resp.EndedAt = endedAt.Format(time.RFC3339)
 
@@ -167,9 +169,7 @@ func (e *Executor) RunJob(ctx context.Context, req *workerapi.RunJobRequest, wor
 	resp.Stdout = stdoutStr
 	resp.Stderr = stderrStr
 
-	if ctx.Err() == context.DeadlineExceeded {
-		resp.Status = workerapi.StatusTimeout
-		resp.ExitCode = -1
+	if setContextDoneStatus(ctx, resp) {
 		return resp, nil
 	}
 
@@ -187,7 +187,7 @@ func (e *Executor) RunJob(ctx context.Context, req *workerapi.RunJobRequest, wor
 // REQ-SANDBX-0131: sandbox gets INFERENCE_PROXY_URL=http+unix://... (UDS), not TCP OLLAMA_BASE_URL.
 func (e *Executor) runJobWithPodInference(ctx context.Context, req *workerapi.RunJobRequest, resp *workerapi.RunJobResponse, workspaceDir string) (*workerapi.RunJobResponse, error) {
 	podName := "cynodeai-job-" + sanitizePodName(req.JobID)
-	createPod := exec.CommandContext(ctx, e.runtime, "pod", "create", "--name", podName)
+	createPod := exec.CommandContext(ctx, e.runtime, append([]string{"pod", "create", "--name", podName}, e.podNetworkArgs(req)...)...)
 	if out, err := createPod.CombinedOutput(); err != nil {
 		setPodInferenceError(resp, "pod create", out)
 		return resp, nil
@@ -230,12 +230,14 @@ func (e *Executor) runJobWithPodInference(ctx context.Context, req *workerapi.Ru
 	sandboxArgs := buildSandboxRunArgsForPod(req, podName, workspaceDir, env, image)
 
 	cmd := exec.CommandContext(ctx, e.runtime, sandboxArgs...)
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	stdout, stderr, flushLogs := e.captureOutput(cmd, req)
 	runErr := cmd.Run()
+	flushLogs()
 	endedAt := time.Now().UTC()
 	resp.EndedAt = endedAt.Format(time.RFC3339)
+	if useHealthProbe {
+		resp.Usage = collectProxyUsage(ctx, e.runtime, proxyContainerID)
+	}
 
 	stdoutStr := stdout.String()
 	stderrStr := stderr.String()
@@ -246,9 +248,7 @@ func (e *Executor) runJobWithPodInference(ctx context.Context, req *workerapi.Ru
 	resp.Stdout = stdoutStr
 	resp.Stderr = stderrStr
 
-	if ctx.Err() == context.DeadlineExceeded {
-		resp.Status = workerapi.StatusTimeout
-		resp.ExitCode = -1
+	if setContextDoneStatus(ctx, resp) {
 		return resp, nil
 	}
 	if runErr != nil {
@@ -262,6 +262,7 @@ func (e *Executor) runJobWithPodInference(ctx context.Context, req *workerapi.Ru
 
 var probeProxyHealthFunc = probeProxyHealthOnce
 var probeProxyRunningFunc = probeProxyRunningOnce
+var fetchProxyUsageFunc = fetchProxyUsageOnce
 
 func waitForProxyReady(ctx context.Context, runtime, proxyContainerID string, timeout time.Duration, useHealthProbe bool) error {
 	if timeout <= 0 {
@@ -305,6 +306,41 @@ func probeProxyHealthOnce(ctx context.Context, runtime, proxyContainerID string)
 	return nil
 }
 
+// collectProxyUsage returns the token usage counted by the job's inference proxy (GET /usage).
+// Usage is best-effort: nil when it cannot be read. Only the stock proxy image supports --usage-url.
+func collectProxyUsage(ctx context.Context, runtime, proxyContainerID string) []workerapi.TokenUsage {
+	usageCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	usage, err := fetchProxyUsageFunc(usageCtx, runtime, proxyContainerID)
+	if err != nil {
+		return nil
+	}
+	return usage
+}
+
+func fetchProxyUsageOnce(ctx context.Context, runtime, proxyContainerID string) ([]workerapi.TokenUsage, error) {
+	usageCmd := exec.CommandContext(
+		ctx,
+		runtime,
+		"exec",
+		proxyContainerID,
+		"/inference-proxy",
+		"--usage-url",
+		"http://127.0.0.1:11434/usage",
+	)
+	out, err := usageCmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("exec proxy usage: %w", err)
+	}
+	var body struct {
+		Usage []workerapi.TokenUsage `json:"usage"`
+	}
+	if err := json.Unmarshal(out, &body); err != nil {
+		return nil, fmt.Errorf("decode proxy usage: %w", err)
+	}
+	return body.Usage, nil
+}
+
 func probeProxyRunningOnce(ctx context.Context, runtime, proxyContainerID string) error {
 	inspectCmd := exec.CommandContext(ctx, runtime, "inspect", "-f", "{{.State.Running}}", proxyContainerID)
 	out, err := inspectCmd.CombinedOutput()
@@ -404,10 +440,10 @@ func (e *Executor) runJobSBA(ctx context.Context, req *workerapi.RunJobRequest,
 		ContainerStarted: false,
 	}
 	cmd := exec.CommandContext(ctx, e.runtime, args...)
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	stdout, stderr, flushLogs := e.captureOutput(cmd, req)
 	runErr := cmd.Run()
+	flushLogs()
+	e.uploadArtifacts(ctx, req, jobDir)
 	endedAt := time.Now().UTC()
 	resp.EndedAt = endedAt.Format(time.RFC3339)
 	// Container started if the runtime process ran (success or exit code); false if e.g. executable not found.
@@ -419,9 +455,7 @@ func (e *Executor) runJobSBA(ctx context.Context, req *workerapi.RunJobRequest,
 	resp.Stdout = truncateUTF8(stdout.String(), e.maxOutputBytes)
 	resp.Stderr = truncateUTF8(stderr.String(), e.maxOutputBytes)
 
-	if ctx.Err() == context.DeadlineExceeded {
-		resp.Status = workerapi.StatusTimeout
-		resp.ExitCode = -1
+	if setContextDoneStatus(ctx, resp) {
 		return resp, nil
 	}
 	if runErr != nil {
@@ -447,7 +481,7 @@ func (e *Executor) runJobSBAWithPodInference(
 	executionMode string,
 ) (*workerapi.RunJobResponse, error) {
 	podName := "cynodeai-job-" + sanitizePodName(req.JobID)
-	if out, err := e.createOrReplacePod(ctx, podName); err != nil {
+	if out, err := e.createOrReplacePod(ctx, podName, e.podNetworkArgs(req)...); err != nil {
 		setPodInferenceError(resp, "pod create", out)
 		return resp, nil
 	}
@@ -492,20 +526,21 @@ func (e *Executor) runJobSBAWithPodInference(
 		ContainerStarted: false,
 	}
 	cmd := exec.CommandContext(ctx, e.runtime, args...)
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	stdout, stderr, flushLogs := e.captureOutput(cmd, req)
 	runErr := cmd.Run()
+	flushLogs()
+	e.uploadArtifacts(ctx, req, jobDir)
 	resp.EndedAt = time.Now().UTC().Format(time.RFC3339)
 	resp.RunDiagnostics.ContainerStarted = runErr == nil || isExitError(runErr)
+	if useHealthProbe {
+		resp.Usage = collectProxyUsage(ctx, e.runtime, proxyContainerID)
+	}
 
 	resp.Truncated.Stdout = len(stdout.String()) > e.maxOutputBytes
 	resp.Truncated.Stderr = len(stderr.String()) > e.maxOutputBytes
 	resp.Stdout = truncateUTF8(stdout.String(), e.maxOutputBytes)
 	resp.Stderr = truncateUTF8(stderr.String(), e.maxOutputBytes)
-	if ctx.Err() == context.DeadlineExceeded {
-		resp.Status = workerapi.StatusTimeout
-		resp.ExitCode = -1
+	if setContextDoneStatus(ctx, resp) {
 		return resp, nil
 	}
 	if runErr != nil {
@@ -515,8 +550,9 @@ func (e *Executor) runJobSBAWithPodInference(
 	return resp, nil
 }
 
-func (e *Executor) createOrReplacePod(ctx context.Context, podName string) ([]byte, error) {
-	create := exec.CommandContext(ctx, e.runtime, "pod", "create", "--name", podName)
+func (e *Executor) createOrReplacePod(ctx context.Context, podName string, networkArgs ...string) ([]byte, error) {
+	createArgs := append([]string{"pod", "create", "--name", podName}, networkArgs...)
+	create := exec.CommandContext(ctx, e.runtime, createArgs...)
 	out, err := create.CombinedOutput()
 	if err == nil {
 		return out, nil
@@ -525,7 +561,7 @@ func (e *Executor) createOrReplacePod(ctx context.Context, podName string) ([]by
 		return out, err
 	}
 	_ = exec.CommandContext(context.WithoutCancel(ctx), e.runtime, "pod", "rm", "-f", podName).Run()
-	retry := exec.CommandContext(ctx, e.runtime, "pod", "create", "--name", podName)
+	retry := exec.CommandContext(ctx, e.runtime, createArgs...)
 	return retry.CombinedOutput()
 }
 
@@ -549,9 +585,9 @@ func inferenceProxyUDSURL(sockPath string) string {
 
 func buildSBARunArgs(req *workerapi.RunJobRequest, jobDir, workspaceDir string, e *Executor, executionMode string) []string {
 	args := []string{"run", "--rm"}
-	// REQ-WORKER-0174: SBA containers always run with --network=none.
-	// Inference is accessed via UDS socket mount, not via network.
-	args = append(args, "--network=none")
+	// REQ-WORKER-0174: SBA containers run with --network=none unless the job uses egress-proxy, whose
+	// network only reaches the Web Egress Proxy. Inference is accessed via UDS socket mount, not via network.
+	args = append(args, e.networkArgs(req)...)
 	// Rootless podman: keep host UID so the container can write to the bind-mounted jobDir (result.json).
 	if e.runtime == runtimePodman {
 		args = append(args, "--userns=keep-id")
@@ -724,9 +760,53 @@ func (e *Executor) buildTaskEnv(req *workerapi.RunJobRequest, workspaceDirValue
 		}
 		out[k] = v
 	}
+	e.addEgressProxyEnv(out, req)
 	return out
 }
 
+// setContextDoneStatus marks resp timeout when the job deadline passed or canceled when the job was canceled,
+// and reports whether it did. The runtime's own exit error is not meaningful in either case.
+func setContextDoneStatus(ctx context.Context, resp *workerapi.RunJobResponse) bool {
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		resp.Status = workerapi.StatusTimeout
+	case context.Canceled:
+		resp.Status = workerapi.StatusCanceled
+	default:
+		return false
+	}
+	resp.ExitCode = -1
+	return true
+}
+
+// CancelJob force-removes the containers (and Podman pod, including the inference proxy sidecar) of a running job.
+// Killing the runtime client alone does not stop a detached or signal-ignoring container, so callers cancel the
+// job context and call CancelJob. The direct runtime has no containers; canceling the context kills the process.
+func (e *Executor) CancelJob(ctx context.Context, jobID string) error {
+	if e.runtime == "direct" {
+		return nil
+	}
+	var errs []error
+	// Pods are Podman-only; "pod exists" fails on other runtimes and when the job ran without a pod.
+	podName := "cynodeai-job-" + sanitizePodName(jobID)
+	if exec.CommandContext(ctx, e.runtime, "pod", "exists", podName).Run() == nil {
+		if out, err := exec.CommandContext(ctx, e.runtime, "pod", "rm", "-f", podName).CombinedOutput(); err != nil {
+			errs = append(errs, fmt.Errorf("pod rm %s: %w: %s", podName, err, strings.TrimSpace(string(out))))
+		}
+	}
+	out, err := exec.CommandContext(ctx, e.runtime, "ps", "-aq", "--filter", "label=cynodeai.job_id="+jobID).Output()
+	if err != nil {
+		return errors.Join(append(errs, fmt.Errorf("list job containers: %w", err))...)
+	}
+	if ids := strings.Fields(string(out)); len(ids) > 0 {
+		rmArgs := append([]string{"rm", "-f"}, ids...)
+		if out, err := exec.CommandContext(ctx, e.runtime, rmArgs...).CombinedOutput(); err != nil {
+			errs = append(errs, fmt.Errorf("rm job containers: %w: %s", err, strings.TrimSpace(string(out))))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // setRunError sets resp status/exit/stderr from an execution error.
 // Preserves existing resp.Stderr (container/runtime output) when appending the error.
 func (e *Executor) setRunError(resp *workerapi.RunJobResponse, err error) {
@@ -776,11 +856,10 @@ func (e *Executor) runDirect(ctx context.Context, req *workerapi.RunJobRequest,
 	}
 	cmd.Env = append(os.Environ(), envSlice...)
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	stdout, stderr, flushLogs := e.captureOutput(cmd, req)
 
 	err := cmd.Run()
+	flushLogs()
 	endedAt := time.Now().UTC()
 	resp.EndedAt = endedAt.Format(time.RFC3339)
 
@@ -795,9 +874,7 @@ func (e *Executor) runDirect(ctx context.Context, req *workerapi.RunJobRequest,
 	resp.Stdout = stdoutStr
 	resp.Stderr = stderrStr
 
-	if ctx.Err() == context.DeadlineExceeded {
-		resp.Status = workerapi.StatusTimeout
-		resp.ExitCode = -1
+	if setContextDoneStatus(ctx, resp) {
 		return resp, nil
 	}
 
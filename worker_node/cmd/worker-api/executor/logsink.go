@@ -0,0 +1,88 @@
+package executor
+
+import (
+	"bytes"
+	"io"
+	"os/exec"
+	"sync"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+)
+
+// Output stream names passed to a LogSink (match telemetry log_event.stream).
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// maxLogLineBytes caps a single line handed to the sink; longer lines are split.
+const maxLogLineBytes = 16 * 1024
+
+// LogSink receives job container output line by line while the job runs (worker_telemetry_api.md
+// container logs). It is called from the goroutines copying stdout and stderr, so it must be safe for
+// concurrent use. Lines do not include the trailing newline.
+type LogSink func(taskID, jobID, stream, line string)
+
+// SetLogSink sets the sink for live job output; nil disables it. The full output is still buffered
+// and returned in the job result.
+func (e *Executor) SetLogSink(sink LogSink) {
+	e.logSink = sink
+}
+
+// captureOutput wires cmd's stdout and stderr to buffers for the job result and, when a sink is set,
+// tees them line by line to the sink. Call flush after cmd.Run to emit any final partial line.
+func (e *Executor) captureOutput(cmd *exec.Cmd, req *workerapi.RunJobRequest) (stdout, stderr *bytes.Buffer, flush func()) {
+	stdout, stderr = &bytes.Buffer{}, &bytes.Buffer{}
+	if e.logSink == nil {
+		cmd.Stdout, cmd.Stderr = stdout, stderr
+		return stdout, stderr, func() {}
+	}
+	outLines := newLineWriter(func(line string) { e.logSink(req.TaskID, req.JobID, StreamStdout, line) })
+	errLines := newLineWriter(func(line string) { e.logSink(req.TaskID, req.JobID, StreamStderr, line) })
+	cmd.Stdout = io.MultiWriter(stdout, outLines)
+	cmd.Stderr = io.MultiWriter(stderr, errLines)
+	return stdout, stderr, func() {
+		outLines.flush()
+		errLines.flush()
+	}
+}
+
+// lineWriter splits written bytes into lines and calls emit for each complete line.
+type lineWriter struct {
+	mu   sync.Mutex
+	buf  []byte
+	emit func(string)
+}
+
+func newLineWriter(emit func(string)) *lineWriter {
+	return &lineWriter{emit: emit}
+}
+
+func (lw *lineWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	lw.buf = append(lw.buf, p...)
+	for {
+		i := bytes.IndexByte(lw.buf, '\n')
+		if i < 0 {
+			break
+		}
+		lw.emit(string(bytes.TrimSuffix(lw.buf[:i], []byte("\r"))))
+		lw.buf = lw.buf[i+1:]
+	}
+	for len(lw.buf) >= maxLogLineBytes {
+		lw.emit(string(lw.buf[:maxLogLineBytes]))
+		lw.buf = lw.buf[maxLogLineBytes:]
+	}
+	return len(p), nil
+}
+
+// flush emits any buffered partial line.
+func (lw *lineWriter) flush() {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	if len(lw.buf) > 0 {
+		lw.emit(string(lw.buf))
+		lw.buf = nil
+	}
+}
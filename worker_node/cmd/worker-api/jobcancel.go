@@ -0,0 +1,130 @@
+// Job cancellation: POST /v1/worker/jobs/{job_id}/cancel stops a running job (sync or async), force-removes its
+// containers or pod, and returns the job's RunJobResponse with status canceled.
+// See docs/tech_specs/worker_api.md#cancel-job.
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/problem"
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+	"github.com/cypher0n3/cynodeai/worker_node/cmd/worker-api/executor"
+)
+
+// cancelWaitTimeout bounds how long the cancel endpoint waits for the job to stop and report its result.
+const cancelWaitTimeout = 30 * time.Second
+
+// runningJobs tracks in-flight jobs from jobs:run and jobs:submit by job_id so they can be canceled.
+type runningJobs struct {
+	mu   sync.Mutex
+	jobs map[string]*runningJob
+	// onStart, when set, is called with the job_id before each run starts.
+	onStart func(jobID string)
+}
+
+type runningJob struct {
+	taskID    string
+	startedAt time.Time
+	cancel    context.CancelFunc
+	done      chan struct{}
+	result    *workerapi.RunJobResponse
+}
+
+func newRunningJobs() *runningJobs {
+	return &runningJobs{jobs: map[string]*runningJob{}}
+}
+
+// start registers the job and returns its run context and a finish func that records the result and unregisters it.
+func (rj *runningJobs) start(parent context.Context, req *workerapi.RunJobRequest) (context.Context, func(*workerapi.RunJobResponse)) {
+	if rj.onStart != nil {
+		rj.onStart(req.JobID)
+	}
+	ctx, cancel := context.WithCancel(parent)
+	job := &runningJob{taskID: req.TaskID, startedAt: time.Now().UTC(), cancel: cancel, done: make(chan struct{})}
+	rj.mu.Lock()
+	rj.jobs[req.JobID] = job
+	rj.mu.Unlock()
+	return ctx, func(resp *workerapi.RunJobResponse) {
+		rj.mu.Lock()
+		job.result = resp
+		if rj.jobs[req.JobID] == job {
+			delete(rj.jobs, req.JobID)
+		}
+		rj.mu.Unlock()
+		cancel()
+		close(job.done)
+	}
+}
+
+func (rj *runningJobs) get(jobID string) (*runningJob, bool) {
+	rj.mu.Lock()
+	defer rj.mu.Unlock()
+	job, ok := rj.jobs[jobID]
+	return job, ok
+}
+
+// waitResult waits for the job to finish and returns its result, or nil when it did not finish in time.
+func (rj *runningJobs) waitResult(ctx context.Context, job *runningJob) *workerapi.RunJobResponse {
+	select {
+	case <-job.done:
+	case <-ctx.Done():
+		return nil
+	}
+	rj.mu.Lock()
+	defer rj.mu.Unlock()
+	return job.result
+}
+
+// handleCancelJob cancels a running job. Finished async jobs return their stored result when it is canceled, 409 otherwise.
+func handleCancelJob(exec *executor.Executor, running *runningJobs, asyncJobs *asyncJobRegistry, bearerToken string, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireBearerToken(r, bearerToken) {
+			writeProblem(w, http.StatusUnauthorized, problem.TypeAuthentication, "Unauthorized", "Invalid or missing bearer token")
+			return
+		}
+		jobID := r.PathValue("job_id")
+		job, ok := running.get(jobID)
+		if !ok {
+			writeFinishedJobCancel(w, asyncJobs, jobID)
+			return
+		}
+		job.cancel()
+		if err := exec.CancelJob(context.WithoutCancel(r.Context()), jobID); err != nil {
+			logger.Warn("remove canceled job containers failed", "error", err, "job_id", jobID)
+		}
+		waitCtx, stop := context.WithTimeout(r.Context(), cancelWaitTimeout)
+		defer stop()
+		resp := running.waitResult(waitCtx, job)
+		if resp == nil {
+			resp = &workerapi.RunJobResponse{
+				Version:   1,
+				TaskID:    job.taskID,
+				JobID:     jobID,
+				Status:    workerapi.StatusCanceled,
+				ExitCode:  -1,
+				StartedAt: job.startedAt.Format(time.RFC3339),
+				EndedAt:   time.Now().UTC().Format(time.RFC3339),
+			}
+		}
+		logger.Info("job canceled", "job_id", jobID, "status", resp.Status)
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func writeFinishedJobCancel(w http.ResponseWriter, asyncJobs *asyncJobRegistry, jobID string) {
+	job, ok := asyncJobs.get(jobID)
+	if !ok {
+		writeProblem(w, http.StatusNotFound, problem.TypeNotFound, "Not Found", "Unknown or finished job_id")
+		return
+	}
+	st, result := asyncJobs.snapshot(job)
+	if result == nil || result.Status != workerapi.StatusCanceled {
+		writeProblem(w, http.StatusConflict, problem.TypeConflict, "Conflict", "job is "+st.Status)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+	"github.com/cypher0n3/cynodeai/worker_node/cmd/worker-api/executor"
+)
+
+func cancelJobRequest(t *testing.T, mux *http.ServeMux, jobID, token string) (int, workerapi.RunJobResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/v1/worker/jobs/"+jobID+"/cancel", http.NoBody)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	mux.ServeHTTP(w, r)
+	var resp workerapi.RunJobResponse
+	if w.Code == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode cancel response: %v", err)
+		}
+	}
+	return w.Code, resp
+}
+
+func sleepJob(jobID string) *workerapi.RunJobRequest {
+	return &workerapi.RunJobRequest{Version: 1, TaskID: "task-1", JobID: jobID, Sandbox: workerapi.SandboxSpec{Command: []string{"sleep", "30"}}}
+}
+
+func TestCancelJob_Async(t *testing.T) {
+	mux := newMux(executor.New("direct", time.Minute, 1024, "", "", nil), "test-bearer", t.TempDir(), nil, slog.Default())
+	if w := submitAsyncJob(t, mux, sleepJob("job-cancel")); w.Code != http.StatusAccepted {
+		t.Fatalf("submit status %d", w.Code)
+	}
+	code, resp := cancelJobRequest(t, mux, "job-cancel", "test-bearer")
+	if code != http.StatusOK || resp.Status != workerapi.StatusCanceled || resp.JobID != "job-cancel" {
+		t.Fatalf("cancel: code=%d resp=%+v", code, resp)
+	}
+	if got := waitForAsyncResult(t, mux, "job-cancel"); got.Status != workerapi.StatusCanceled {
+		t.Errorf("result status %s", got.Status)
+	}
+	// Canceling again is idempotent and returns the stored result.
+	if code, resp = cancelJobRequest(t, mux, "job-cancel", "test-bearer"); code != http.StatusOK || resp.Status != workerapi.StatusCanceled {
+		t.Errorf("second cancel: code=%d status=%s", code, resp.Status)
+	}
+}
+
+func TestCancelJob_Sync(t *testing.T) {
+	running := newRunningJobs()
+	exec := executor.New("direct", time.Minute, 1024, "", "", nil)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/worker/jobs:run", handleRunJob(exec, "test-bearer", t.TempDir(), running, slog.Default()))
+	mux.HandleFunc("POST /v1/worker/jobs/{job_id}/cancel",
+		handleCancelJob(exec, running, newAsyncJobRegistry(exec, "test-bearer", "", running, slog.Default()), "test-bearer", slog.Default()))
+
+	body, _ := json.Marshal(sleepJob("job-sync"))
+	runDone := make(chan *httptest.ResponseRecorder)
+	go func() {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/worker/jobs:run", bytes.NewReader(body))
+		r.Header.Set("Authorization", "Bearer test-bearer")
+		mux.ServeHTTP(w, r)
+		runDone <- w
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, ok := running.get("job-sync"); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("sync job never registered")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	code, resp := cancelJobRequest(t, mux, "job-sync", "test-bearer")
+	if code != http.StatusOK || resp.Status != workerapi.StatusCanceled {
+		t.Fatalf("cancel: code=%d resp=%+v", code, resp)
+	}
+	w := <-runDone
+	var runResp workerapi.RunJobResponse
+	if err := json.NewDecoder(w.Body).Decode(&runResp); err != nil || runResp.Status != workerapi.StatusCanceled {
+		t.Errorf("jobs:run response: err=%v status=%s", err, runResp.Status)
+	}
+}
+
+func TestCancelJob_Errors(t *testing.T) {
+	mux := newMux(executor.New("direct", 5*time.Second, 1024, "", "", nil), "test-bearer", t.TempDir(), nil, slog.Default())
+	if code, _ := cancelJobRequest(t, mux, "job-x", ""); code != http.StatusUnauthorized {
+		t.Errorf("missing token: %d", code)
+	}
+	if code, _ := cancelJobRequest(t, mux, "job-x", "test-bearer"); code != http.StatusNotFound {
+		t.Errorf("unknown job: %d", code)
+	}
+	req := &workerapi.RunJobRequest{Version: 1, TaskID: "task-1", JobID: "job-done", Sandbox: workerapi.SandboxSpec{Command: runJobCmd()}}
+	submitAsyncJob(t, mux, req)
+	waitForAsyncResult(t, mux, "job-done")
+	if code, _ := cancelJobRequest(t, mux, "job-done", "test-bearer"); code != http.StatusConflict {
+		t.Errorf("completed job: %d", code)
+	}
+}
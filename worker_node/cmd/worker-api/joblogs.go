@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/cypher0n3/cynodeai/worker_node/cmd/worker-api/executor"
+	"github.com/cypher0n3/cynodeai/worker_node/internal/telemetry"
+)
+
+// jobLogSourcePrefix prefixes source_name for job container output in log_event, so the orchestrator can
+// follow a job with GET /v1/worker/telemetry/logs?source_kind=container&source_name=job-<job_id>.
+const jobLogSourcePrefix = "job-"
+
+// jobLogRecorder records live job output as container log events in the telemetry store.
+// Inserts are serialized so offsets (page_token) into a job's events stay stable while it runs:
+// rows order by (occurred_at, log_id) and log_id carries a process-wide increasing sequence.
+type jobLogRecorder struct {
+	store  *telemetry.Store
+	logger *slog.Logger
+	epoch  int64 // process start; keeps log_id unique when a job is re-run after a worker restart
+	mu     sync.Mutex
+	seq    int64
+}
+
+func newJobLogRecorder(store *telemetry.Store, logger *slog.Logger) *jobLogRecorder {
+	return &jobLogRecorder{store: store, logger: logger, epoch: time.Now().UTC().UnixNano()}
+}
+
+// sink returns the executor.LogSink that writes to the store.
+func (r *jobLogRecorder) sink() executor.LogSink {
+	return func(taskID, jobID, stream, line string) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.seq++
+		in := telemetry.LogEventInput{
+			LogID:      fmt.Sprintf("%s-%d-%012d", jobID, r.epoch, r.seq),
+			OccurredAt: time.Now().UTC().Format(time.RFC3339),
+			SourceKind: "container",
+			SourceName: jobLogSourcePrefix + jobID,
+			Stream:     stream,
+			Message:    line,
+			Fields:     map[string]string{"task_id": taskID, "job_id": jobID},
+		}
+		if err := r.store.InsertLogEvent(context.Background(), &in); err != nil && r.logger != nil {
+			r.logger.Warn("telemetry job log insert failed", "job_id", jobID, "error", err)
+		}
+	}
+}
+
+// clearJobLogs drops the output earlier runs of jobID recorded, so a new attempt's events start at offset 0
+// for log followers instead of after (or mixed with) the previous attempt's.
+func clearJobLogs(store *telemetry.Store, jobID string, logger *slog.Logger) {
+	if err := store.DeleteLogEvents(context.Background(), "container", jobLogSourcePrefix+jobID); err != nil && logger != nil {
+		logger.Warn("telemetry job log clear failed", "job_id", jobID, "error", err)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+	"github.com/cypher0n3/cynodeai/worker_node/cmd/worker-api/executor"
+	"github.com/cypher0n3/cynodeai/worker_node/internal/telemetry"
+)
+
+func TestJobLogRecorder_FollowableViaTelemetryLogs(t *testing.T) {
+	ctx, store, mux := telemetryMuxWithStore(t)
+	exec := executor.New("direct", 5*time.Second, 1024, "", "", nil)
+	exec.SetLogSink(newJobLogRecorder(store, nil).sink())
+	req := &workerapi.RunJobRequest{
+		Version: 1, TaskID: "task-1", JobID: "job-1",
+		Sandbox: workerapi.SandboxSpec{Command: []string{"sh", "-c", "for i in 1 2 3; do echo line$i; done"}},
+	}
+	if _, err := exec.RunJob(ctx, req, ""); err != nil {
+		t.Fatalf("RunJob: %v", err)
+	}
+
+	query := func(pageToken string) (events []telemetry.LogEventRow) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/v1/worker/telemetry/logs?source_kind=container&source_name=job-job-1&page_token="+pageToken, http.NoBody)
+		r.Header.Set("Authorization", "Bearer telemetry-token")
+		mux.ServeHTTP(w, r)
+		var resp struct {
+			Events []telemetry.LogEventRow `json:"events"`
+		}
+		if w.Code != http.StatusOK || json.NewDecoder(w.Body).Decode(&resp) != nil {
+			t.Fatalf("logs: %d %s", w.Code, w.Body.String())
+		}
+		return resp.Events
+	}
+	events := query("")
+	if len(events) != 3 {
+		t.Fatalf("events = %+v", events)
+	}
+	for i, e := range events {
+		if e.Message != "line"+string(rune('1'+i)) || e.Stream != telemetry.StreamStdout || e.Fields["task_id"] != "task-1" {
+			t.Errorf("event %d = %+v", i, e)
+		}
+	}
+	if rest := query("2"); len(rest) != 1 || rest[0].Message != "line3" {
+		t.Errorf("offset 2 = %+v", rest)
+	}
+	if other := query("0"); len(other) != 3 {
+		t.Errorf("offset 0 = %d events", len(other))
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/nodepayloads"
 	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/problem"
 	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+	"github.com/cypher0n3/cynodeai/go_shared_libs/jwks"
 	"github.com/cypher0n3/cynodeai/worker_node/cmd/worker-api/executor"
 	"github.com/cypher0n3/cynodeai/worker_node/internal/inferenceproxy"
 	"github.com/cypher0n3/cynodeai/worker_node/internal/nodeagent"
@@ -51,6 +52,8 @@ type managedServiceProxyRequest struct {
 	Path    string              `json:"path"`
 	Headers map[string][]string `json:"headers,omitempty"`
 	BodyB64 string              `json:"body_b64,omitempty"`
+	// Stream relays a 200 text/event-stream upstream response as-is instead of the JSON envelope.
+	Stream bool `json:"stream,omitempty"`
 }
 
 type managedServiceProxyResponse struct {
@@ -85,6 +88,7 @@ func runMain(ctx context.Context) int {
 		logger.Error("WORKER_API_BEARER_TOKEN must be set")
 		return 1
 	}
+	signedTokens = signedTokenVerifierFromEnv()
 
 	exec := executor.New(
 		getEnv("CONTAINER_RUNTIME", "podman"),
@@ -94,6 +98,7 @@ func runMain(ctx context.Context) int {
 		getEnv("INFERENCE_PROXY_IMAGE", ""),
 		nil,
 	)
+	exec.SetWebEgressProxy(getEnv("SANDBOX_EGRESS_NETWORK", ""), getEnv("WEB_EGRESS_PROXY_URL", ""))
 	stateDir := getEnv("WORKER_API_STATE_DIR", filepath.Join(os.TempDir(), "cynode", "state"))
 	workspaceRoot := getEnv("WORKSPACE_ROOT", filepath.Join(os.TempDir(), "cynodeai-workspaces"))
 	telemetryStore, cfg := setupWorkerStateAndProxyConfig(ctx, stateDir, logger)
@@ -109,7 +114,9 @@ func runMain(ctx context.Context) int {
 			Source: "worker_api",
 		})
 		slog.SetDefault(logger)
+		exec.SetLogSink(newJobLogRecorder(telemetryStore, logger).sink())
 	}
+	exec.SetArtifactUploader(newJobArtifactUploader(bearerToken, logger).uploader())
 	mux := newMux(exec, bearerToken, workspaceRoot, telemetryStore, logger, cfg.ManagedServiceTargets)
 	internalMux := newInternalMux(cfg.InternalProxy, logger)
 	srv := newServer(mux)
@@ -232,7 +239,17 @@ func newMux(exec *executor.Executor, bearerToken, workspaceRoot string, telemetr
 	})
 	// REQ-WORKER-0140, REQ-WORKER-0142: unauthenticated GET /readyz
 	mux.HandleFunc("GET /readyz", readyzHandler(exec))
-	mux.HandleFunc("POST /v1/worker/jobs:run", handleRunJob(exec, bearerToken, workspaceRoot, logger))
+	running := newRunningJobs()
+	if telemetryStore != nil {
+		// A job that runs again (retry after a failed attempt) starts its recorded output from scratch.
+		running.onStart = func(jobID string) { clearJobLogs(telemetryStore, jobID, logger) }
+	}
+	mux.HandleFunc("POST /v1/worker/jobs:run", handleRunJob(exec, bearerToken, workspaceRoot, running, logger))
+	asyncJobs := newAsyncJobRegistry(exec, bearerToken, workspaceRoot, running, logger)
+	mux.HandleFunc("POST /v1/worker/jobs:submit", handleSubmitJob(asyncJobs))
+	mux.HandleFunc("GET /v1/worker/jobs/{job_id}", handleGetJobStatus(asyncJobs))
+	mux.HandleFunc("GET /v1/worker/jobs/{job_id}/result", handleGetJobResult(asyncJobs))
+	mux.HandleFunc("POST /v1/worker/jobs/{job_id}/cancel", handleCancelJob(exec, running, asyncJobs, bearerToken, logger))
 	mux.HandleFunc("POST /v1/worker/managed-services/{service_id}/proxy:http", handleManagedServiceProxy(bearerToken, targets, logger))
 	// REQ-WORKER-0200--0243: Worker Telemetry API.
 	mux.HandleFunc("GET /v1/worker/telemetry/node:info", telemetryAuth(bearerToken, handleNodeInfo(logger)))
@@ -550,6 +567,10 @@ func handleManagedServiceProxy(bearerToken string, targets map[string]managedSer
 		if !ok {
 			return
 		}
+		if reqPayload.Stream {
+			streamManagedProxyRequest(r.Context(), w, target, reqPayload, reqBody, logger)
+			return
+		}
 		start := time.Now()
 		respPayload, status, detail := forwardManagedProxyRequest(r.Context(), target, reqPayload, reqBody)
 		if status != 0 {
@@ -675,6 +696,27 @@ func forwardManagedProxyRequest(
 	req *managedServiceProxyRequest,
 	body []byte,
 ) (resp *managedServiceProxyResponse, statusCode int, detail string) {
+	timeoutSec := getEnvInt("WORKER_MANAGED_PROXY_UPSTREAM_TIMEOUT_SEC", 30)
+	if timeoutSec < 1 {
+		timeoutSec = 30
+	}
+	httpResp, statusCode, detail := doManagedProxyUpstream(ctx, target, req, body, time.Duration(timeoutSec)*time.Second)
+	if statusCode != 0 {
+		return nil, statusCode, detail
+	}
+	defer func() { _ = httpResp.Body.Close() }()
+	return readManagedProxyResponse(httpResp)
+}
+
+// doManagedProxyUpstream sends the proxied request to the managed service; timeout zero leaves the bound to ctx.
+// The caller closes the response body.
+func doManagedProxyUpstream(
+	ctx context.Context,
+	target managedServiceTarget,
+	req *managedServiceProxyRequest,
+	body []byte,
+	timeout time.Duration,
+) (resp *http.Response, statusCode int, detail string) {
 	upstreamURL, transport, ok := resolveUpstreamURLAndTransport(target.BaseURL, req.Path)
 	if !ok {
 		return nil, http.StatusBadRequest, "failed to build upstream request"
@@ -691,16 +733,16 @@ func forwardManagedProxyRequest(
 			httpReq.Header.Add(name, v)
 		}
 	}
-	timeoutSec := getEnvInt("WORKER_MANAGED_PROXY_UPSTREAM_TIMEOUT_SEC", 30)
-	if timeoutSec < 1 {
-		timeoutSec = 30
-	}
-	client := &http.Client{Timeout: time.Duration(timeoutSec) * time.Second, Transport: transport}
+	client := &http.Client{Timeout: timeout, Transport: transport}
 	httpResp, err := client.Do(httpReq)
 	if err != nil {
 		return nil, http.StatusBadGateway, "upstream request failed"
 	}
-	defer func() { _ = httpResp.Body.Close() }()
+	return httpResp, 0, ""
+}
+
+// readManagedProxyResponse reads a bounded upstream response into the JSON envelope.
+func readManagedProxyResponse(httpResp *http.Response) (resp *managedServiceProxyResponse, statusCode int, detail string) {
 	limited := io.LimitReader(httpResp.Body, maxManagedProxyBodyBytes+1)
 	respBody, err := io.ReadAll(limited)
 	if err != nil {
@@ -725,6 +767,59 @@ func forwardManagedProxyRequest(
 	}, 0, ""
 }
 
+// streamManagedProxyRequest relays a 200 text/event-stream upstream response to the caller as it arrives
+// (no size cap, no upstream timeout beyond ctx). Any other upstream response is returned in the JSON envelope
+// as for a non-streaming call, so callers handle upstream errors the same way.
+func streamManagedProxyRequest(
+	ctx context.Context,
+	w http.ResponseWriter,
+	target managedServiceTarget,
+	req *managedServiceProxyRequest,
+	body []byte,
+	logger *slog.Logger,
+) {
+	start := time.Now()
+	httpResp, status, detail := doManagedProxyUpstream(ctx, target, req, body, 0)
+	if status != 0 {
+		writeProblem(w, status, problem.TypeValidation, http.StatusText(status), detail)
+		return
+	}
+	defer func() { _ = httpResp.Body.Close() }()
+	if httpResp.StatusCode != http.StatusOK || !strings.HasPrefix(httpResp.Header.Get("Content-Type"), "text/event-stream") {
+		respPayload, status, detail := readManagedProxyResponse(httpResp)
+		if status != 0 {
+			writeProblem(w, status, problem.TypeValidation, http.StatusText(status), detail)
+			return
+		}
+		writeJSON(w, http.StatusOK, respPayload)
+		return
+	}
+	w.Header().Set("Content-Type", httpResp.Header.Get("Content-Type"))
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	buf := make([]byte, 4096)
+	for {
+		n, err := httpResp.Body.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				break
+			}
+			_ = rc.Flush()
+		}
+		if err != nil {
+			break
+		}
+	}
+	if logger != nil {
+		logger.Info("managed service proxy stream",
+			"service_type", target.ServiceType,
+			"path", req.Path,
+			"duration_ms", int(time.Since(start).Milliseconds()),
+		)
+	}
+}
+
 func isAllowedProxyMethod(method string) bool {
 	switch method {
 	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
@@ -968,7 +1063,7 @@ func decodeRunJobRequest(w http.ResponseWriter, r *http.Request, maxBytes int64)
 	return &req, true
 }
 
-func handleRunJob(exec *executor.Executor, bearerToken, workspaceRoot string, logger *slog.Logger) http.HandlerFunc {
+func handleRunJob(exec *executor.Executor, bearerToken, workspaceRoot string, running *runningJobs, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if !requireBearerToken(r, bearerToken) {
 			writeProblem(w, http.StatusUnauthorized, problem.TypeAuthentication, "Unauthorized", "Invalid or missing bearer token")
@@ -991,7 +1086,9 @@ func handleRunJob(exec *executor.Executor, bearerToken, workspaceRoot string, lo
 		if cleanup != nil {
 			defer cleanup()
 		}
-		resp, err := exec.RunJob(r.Context(), req, workspaceDir)
+		runCtx, finish := running.start(r.Context(), req)
+		resp, err := exec.RunJob(runCtx, req, workspaceDir)
+		finish(resp)
 		if err != nil {
 			logger.Error("job execution error", "error", err)
 			writeProblem(w, http.StatusInternalServerError, problem.TypeInternal, "Internal Server Error", "Job execution failed")
@@ -1070,13 +1167,41 @@ func getEnvInt(key string, def int) int {
 	return def
 }
 
+// signedTokens, when set from WORKER_API_JWKS_URL, lets callers authenticate with a short-lived orchestrator-signed
+// worker token addressed to this node as well as with the static bearer token. Worker API holds only the
+// orchestrator's public keys.
+var signedTokens *jwks.Verifier
+
+// signedTokenVerifierFromEnv returns the verifier for WORKER_API_JWKS_URL, or nil when it is unset. Tokens must be
+// issued by the orchestrator for NODE_SLUG.
+func signedTokenVerifierFromEnv() *jwks.Verifier {
+	jwksURL := getEnv("WORKER_API_JWKS_URL", "")
+	if jwksURL == "" {
+		return nil
+	}
+	keys := jwks.NewRemoteKeySet(jwksURL, &http.Client{Timeout: 10 * time.Second})
+	return &jwks.Verifier{Keys: keys, Issuer: "cynodeai", Audience: getEnv("NODE_SLUG", "default")}
+}
+
 func requireBearerToken(r *http.Request, expected string) bool {
 	authz := r.Header.Get("Authorization")
 	const prefix = "Bearer "
 	if len(authz) <= len(prefix) || authz[:len(prefix)] != prefix {
 		return false
 	}
-	return authz[len(prefix):] == expected
+	token := authz[len(prefix):]
+	if token == expected {
+		return true
+	}
+	return signedTokens != nil && validWorkerToken(r.Context(), token)
+}
+
+// validWorkerToken reports whether token is an orchestrator-signed worker token for this node.
+func validWorkerToken(ctx context.Context, token string) bool {
+	var claims struct {
+		TokenType string `json:"token_type"`
+	}
+	return signedTokens.Verify(ctx, token, &claims) == nil && claims.TokenType == "worker"
 }
 
 func writeProblem(w http.ResponseWriter, status int, typ, title, detail string) {
@@ -3,6 +3,8 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -20,6 +22,7 @@ import (
 
 	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/nodepayloads"
 	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+	"github.com/cypher0n3/cynodeai/go_shared_libs/jwks"
 	"github.com/cypher0n3/cynodeai/worker_node/cmd/worker-api/executor"
 	"github.com/cypher0n3/cynodeai/worker_node/internal/securestore"
 	"github.com/cypher0n3/cynodeai/worker_node/internal/telemetry"
@@ -84,6 +87,54 @@ func TestRequireBearerToken(t *testing.T) {
 	}
 }
 
+func TestRequireBearerToken_SignedWorkerToken(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	jwk, err := jwks.FromPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(jwks.Set{Keys: []jwks.JWK{jwk}})
+	}))
+	defer srv.Close()
+	t.Setenv("WORKER_API_JWKS_URL", srv.URL)
+	t.Setenv("NODE_SLUG", "node-1")
+	signedTokens = signedTokenVerifierFromEnv()
+	defer func() { signedTokens = nil }()
+
+	sign := func(claims map[string]any) string {
+		header, _ := json.Marshal(map[string]string{"alg": "EdDSA", "kid": jwk.Kid, "typ": "JWT"})
+		payload, _ := json.Marshal(claims)
+		input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+		return input + "." + base64.RawURLEncoding.EncodeToString(ed25519.Sign(key, []byte(input)))
+	}
+	exp := time.Now().Add(time.Minute).Unix()
+	tests := []struct {
+		name   string
+		claims map[string]any
+		want   bool
+	}{
+		{"worker token for this node", map[string]any{"iss": "cynodeai", "aud": "node-1", "exp": exp, "token_type": "worker"}, true},
+		{"other node", map[string]any{"iss": "cynodeai", "aud": "node-2", "exp": exp, "token_type": "worker"}, false},
+		{"access token", map[string]any{"iss": "cynodeai", "aud": "node-1", "exp": exp, "token_type": "access"}, false},
+		{"expired", map[string]any{"iss": "cynodeai", "aud": "node-1", "exp": exp - 3600, "token_type": "worker"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/v1/worker/jobs:run", http.NoBody)
+			r.Header.Set("Authorization", "Bearer "+sign(tt.claims))
+			if got := requireBearerToken(r, "static"); got != tt.want {
+				t.Errorf("requireBearerToken = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	r := httptest.NewRequest(http.MethodPost, "/v1/worker/jobs:run", http.NoBody)
+	r.Header.Set("Authorization", "Bearer static")
+	if !requireBearerToken(r, "static") {
+		t.Error("static token rejected while signed tokens are enabled")
+	}
+}
+
 func TestWriteProblem(t *testing.T) {
 	w := httptest.NewRecorder()
 	writeProblem(w, http.StatusBadRequest, "urn:test", "Bad", "detail here")
@@ -1542,3 +1593,42 @@ func TestStartManagedServiceInferenceProxies_SkipsNonPMA(t *testing.T) {
 		t.Error("inference.sock should NOT be created for non-PMA service types")
 	}
 }
+
+func TestManagedServiceProxy_Stream(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"content\":\"hi\"}\n\n"))
+		w.(http.Flusher).Flush()
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer upstream.Close()
+	rawTargets, _ := json.Marshal(map[string]managedServiceTarget{"pma-main": {ServiceType: "pma", BaseURL: upstream.URL}})
+	t.Setenv("WORKER_MANAGED_SERVICE_TARGETS_JSON", string(rawTargets))
+	mux := newMux(executor.New("direct", time.Second, 1024, "", "", nil), "token", "", nil, slog.Default())
+	call := func(path string) *httptest.ResponseRecorder {
+		rawReq, _ := json.Marshal(managedServiceProxyRequest{Version: 1, Method: http.MethodPost, Path: path, Stream: true})
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/worker/managed-services/pma-main/proxy:http", bytes.NewReader(rawReq))
+		r.Header.Set("Authorization", "Bearer token")
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	w := call("/internal/chat/completion")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status %d content-type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if w.Body.String() != "data: {\"content\":\"hi\"}\n\ndata: [DONE]\n\n" {
+		t.Errorf("relayed body = %q", w.Body.String())
+	}
+
+	w = call("/fail")
+	var resp managedServiceProxyResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || resp.Status != http.StatusInternalServerError {
+		t.Errorf("non-stream upstream response not enveloped: %v %+v", err, resp)
+	}
+}
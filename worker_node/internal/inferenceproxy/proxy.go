@@ -57,7 +57,9 @@ func RunUDSWithUpstream(ctx context.Context, sockPath, upstream string) int {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("ok"))
 	})
-	mux.Handle("/", NewProxy(u))
+	usage := NewUsageTracker()
+	mux.Handle("GET /usage", usage)
+	mux.Handle("/", NewProxyWithUsage(u, usage))
 	srv := &http.Server{
 		Handler:      mux,
 		ReadTimeout:  PerRequestTimeout + 10*time.Second,
@@ -87,6 +89,12 @@ func RunUDSWithUpstream(ctx context.Context, sockPath, upstream string) int {
 
 // NewProxy returns an http.Handler that forwards requests to upstream with size and timeout limits.
 func NewProxy(upstream *url.URL) http.Handler {
+	return NewProxyWithUsage(upstream, nil)
+}
+
+// NewProxyWithUsage is NewProxy that also records the token usage of upstream responses in usage
+// (served on GET /usage so the worker can attach it to the job result). A nil usage records nothing.
+func NewProxyWithUsage(upstream *url.URL, usage *UsageTracker) http.Handler {
 	rp := &httputil.ReverseProxy{
 		Transport: &http.Transport{ResponseHeaderTimeout: PerRequestTimeout},
 		Rewrite: func(req *httputil.ProxyRequest) {
@@ -94,6 +102,9 @@ func NewProxy(upstream *url.URL) http.Handler {
 			req.Out.Host = upstream.Host
 		},
 	}
+	if usage != nil {
+		rp.ModifyResponse = usage.observeResponse
+	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		body, err := io.ReadAll(io.LimitReader(r.Body, MaxRequestBodyBytes+1))
 		if err != nil {
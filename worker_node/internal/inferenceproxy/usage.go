@@ -0,0 +1,161 @@
+package inferenceproxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/contracts/workerapi"
+)
+
+// maxUsageLineBytes bounds the response line buffered for usage parsing; longer lines are skipped.
+const maxUsageLineBytes = 1024 * 1024
+
+// UsageResponse is the body of GET /usage: token usage per model since the proxy started.
+type UsageResponse struct {
+	Usage []workerapi.TokenUsage `json:"usage"`
+}
+
+// UsageTracker accumulates token usage reported in upstream responses, per model.
+// It understands Ollama (prompt_eval_count / eval_count on the final chunk) and OpenAI-compatible
+// (usage.prompt_tokens / usage.completion_tokens) bodies, streamed as NDJSON or SSE or returned whole.
+type UsageTracker struct {
+	mu      sync.Mutex
+	byModel map[string]*workerapi.TokenUsage
+}
+
+// NewUsageTracker returns an empty tracker.
+func NewUsageTracker() *UsageTracker {
+	return &UsageTracker{byModel: map[string]*workerapi.TokenUsage{}}
+}
+
+// Snapshot returns the usage recorded so far, sorted by model.
+func (t *UsageTracker) Snapshot() []workerapi.TokenUsage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]workerapi.TokenUsage, 0, len(t.byModel))
+	for _, u := range t.byModel {
+		out = append(out, *u)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Model < out[j].Model })
+	return out
+}
+
+// ServeHTTP serves GET /usage.
+func (t *UsageTracker) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(UsageResponse{Usage: t.Snapshot()})
+}
+
+func (t *UsageTracker) add(model string, prompt, completion int64) {
+	if prompt <= 0 && completion <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	u := t.byModel[model]
+	if u == nil {
+		u = &workerapi.TokenUsage{Model: model}
+		t.byModel[model] = u
+	}
+	u.PromptTokens += prompt
+	u.CompletionTokens += completion
+}
+
+// observeLine records the usage carried by one response line, if any.
+func (t *UsageTracker) observeLine(line []byte) {
+	line = bytes.TrimSpace(line)
+	line = bytes.TrimPrefix(line, []byte("data:"))
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] != '{' {
+		return
+	}
+	if !bytes.Contains(line, []byte(`"eval_count"`)) && !bytes.Contains(line, []byte(`"usage"`)) {
+		return
+	}
+	var chunk struct {
+		Model           string `json:"model"`
+		PromptEvalCount int64  `json:"prompt_eval_count"`
+		EvalCount       int64  `json:"eval_count"`
+		Usage           *struct {
+			PromptTokens     int64 `json:"prompt_tokens"`
+			CompletionTokens int64 `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+	if json.Unmarshal(line, &chunk) != nil {
+		return
+	}
+	if chunk.Usage != nil {
+		t.add(chunk.Model, chunk.Usage.PromptTokens, chunk.Usage.CompletionTokens)
+		return
+	}
+	t.add(chunk.Model, chunk.PromptEvalCount, chunk.EvalCount)
+}
+
+// observeResponse wraps a JSON, NDJSON or SSE response body so usage is recorded as the client reads it.
+func (t *UsageTracker) observeResponse(resp *http.Response) error {
+	ct := resp.Header.Get("Content-Type")
+	if !strings.Contains(ct, "json") && !strings.Contains(ct, "event-stream") {
+		return nil
+	}
+	resp.Body = &usageBody{ReadCloser: resp.Body, tracker: t}
+	return nil
+}
+
+// usageBody splits the bytes read through it into lines and hands each complete line to the tracker.
+type usageBody struct {
+	io.ReadCloser
+	tracker  *UsageTracker
+	buf      []byte
+	skipping bool // current line exceeded maxUsageLineBytes; drop until the next newline
+	closed   bool
+}
+
+func (b *usageBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	data := p[:n]
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			b.buffer(data)
+			break
+		}
+		b.buffer(data[:i])
+		if !b.skipping {
+			b.tracker.observeLine(b.buf)
+		}
+		b.buf, b.skipping = b.buf[:0], false
+		data = data[i+1:]
+	}
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *usageBody) buffer(data []byte) {
+	if b.skipping {
+		return
+	}
+	if len(b.buf)+len(data) > maxUsageLineBytes {
+		b.buf, b.skipping = b.buf[:0], true
+		return
+	}
+	b.buf = append(b.buf, data...)
+}
+
+// finish records a final line without a trailing newline (non-streamed JSON bodies).
+func (b *usageBody) finish() {
+	if b.closed {
+		return
+	}
+	b.closed = true
+	if !b.skipping && len(b.buf) > 0 {
+		b.tracker.observeLine(b.buf)
+	}
+	b.buf = nil
+}
@@ -0,0 +1,76 @@
+package inferenceproxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestNewProxyWithUsage_CountsTokens(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/chat":
+			// Ollama NDJSON stream: counts on the final chunk only.
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			_, _ = w.Write([]byte(`{"model":"qwen","message":{"content":"hi"}}` + "\n" +
+				`{"model":"qwen","done":true,"prompt_eval_count":10,"eval_count":5}` + "\n"))
+		case "/api/generate":
+			// Non-streamed JSON without a trailing newline.
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"model":"qwen","response":"x","done":true,"prompt_eval_count":2,"eval_count":1}`))
+		case "/v1/chat/completions":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: {\"model\":\"gpt\",\"choices\":[]}\n\n" +
+				"data: {\"model\":\"gpt\",\"usage\":{\"prompt_tokens\":7,\"completion_tokens\":8}}\n\ndata: [DONE]\n\n"))
+		default:
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte(`{"model":"x","prompt_eval_count":100}`))
+		}
+	}))
+	defer backend.Close()
+	u, _ := url.Parse(backend.URL)
+	tracker := NewUsageTracker()
+	proxy := NewProxyWithUsage(u, tracker)
+	for _, path := range []string{"/api/chat", "/api/generate", "/v1/chat/completions", "/other"} {
+		rec := httptest.NewRecorder()
+		proxy.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "http://localhost:11434"+path, strings.NewReader("{}")))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d", path, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	tracker.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/usage", http.NoBody))
+	var got UsageResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode usage: %v", err)
+	}
+	if len(got.Usage) != 2 {
+		t.Fatalf("usage = %+v", got.Usage)
+	}
+	if g := got.Usage[0]; g.Model != "gpt" || g.PromptTokens != 7 || g.CompletionTokens != 8 {
+		t.Errorf("gpt usage = %+v", g)
+	}
+	if q := got.Usage[1]; q.Model != "qwen" || q.PromptTokens != 12 || q.CompletionTokens != 6 {
+		t.Errorf("qwen usage = %+v", q)
+	}
+}
+
+func TestUsageBody_SkipsOversizedLines(t *testing.T) {
+	tracker := NewUsageTracker()
+	long := `{"model":"m","eval_count":1,"pad":"` + strings.Repeat("x", maxUsageLineBytes) + `"}`
+	body := &usageBody{
+		ReadCloser: io.NopCloser(strings.NewReader(long + "\n" + `{"model":"m","eval_count":2}` + "\n")),
+		tracker:    tracker,
+	}
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		t.Fatal(err)
+	}
+	if got := tracker.Snapshot(); len(got) != 1 || got[0].CompletionTokens != 2 {
+		t.Errorf("usage = %+v", got)
+	}
+}
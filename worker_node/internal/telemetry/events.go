@@ -5,7 +5,11 @@ package telemetry
 import (
 	"context"
 	"encoding/json"
+	"slices"
+	"strings"
 	"time"
+
+	"github.com/cypher0n3/cynodeai/go_shared_libs/redact"
 )
 
 // InsertContainerEvent inserts one container_event row (lifecycle transition).
@@ -60,9 +64,10 @@ func (s *Store) InsertLogEvent(ctx context.Context, in *LogEventInput) error {
 	if stream != StreamStdout && stream != StreamStderr {
 		stream = StreamStdout // sentinel for service-origin events (schema CHECK allows only stdout/stderr)
 	}
+	message, fields := redactLogEvent(in.Message, in.Fields)
 	fieldsJSON := "{}"
-	if in.Fields != nil {
-		b, _ := json.Marshal(in.Fields)
+	if fields != nil {
+		b, _ := json.Marshal(fields)
 		fieldsJSON = string(b)
 	}
 	return s.db.WithContext(ctx).Create(&LogEvent{
@@ -73,7 +78,41 @@ func (s *Store) InsertLogEvent(ctx context.Context, in *LogEventInput) error {
 		ContainerID: in.ContainerID,
 		Stream:      stream,
 		Level:       in.Level,
-		Message:     in.Message,
+		Message:     message,
 		FieldsJSON:  fieldsJSON,
 	}).Error
 }
+
+// FieldRedactionKinds is the log_event field listing the kinds of secrets redacted from the event, comma-separated.
+const FieldRedactionKinds = "redaction_kinds"
+
+// redactLogEvent replaces secrets in a log message and its field values (redact.Default) and, when any were
+// found, records their kinds in the FieldRedactionKinds field. The caller's fields map is not modified.
+func redactLogEvent(message string, fields map[string]string) (string, map[string]string) {
+	message, kinds := redact.String(message)
+	var out map[string]string
+	if fields != nil {
+		out = make(map[string]string, len(fields)+1)
+	}
+	for k, v := range fields {
+		var found []string
+		out[k], found = redact.String(v)
+		kinds = mergeKinds(kinds, found)
+	}
+	if len(kinds) > 0 {
+		if out == nil {
+			out = make(map[string]string, 1)
+		}
+		out[FieldRedactionKinds] = strings.Join(kinds, ",")
+	}
+	return message, out
+}
+
+func mergeKinds(kinds, more []string) []string {
+	for _, k := range more {
+		if !slices.Contains(kinds, k) {
+			kinds = append(kinds, k)
+		}
+	}
+	return kinds
+}
@@ -49,6 +49,11 @@ func (s *Store) QueryLogs(ctx context.Context, sourceKind, sourceName, container
 	return list, truncated, nextToken, nil
 }
 
+// DeleteLogEvents deletes every log event of one source (e.g. an earlier run of a job that runs again).
+func (s *Store) DeleteLogEvents(ctx context.Context, sourceKind, sourceName string) error {
+	return s.db.WithContext(ctx).Where("source_kind = ? AND source_name = ?", sourceKind, sourceName).Delete(&LogEvent{}).Error
+}
+
 func parseLogPageToken(pageToken string) int {
 	var offset int
 	if n, _ := fmt.Sscanf(pageToken, "%d", &offset); n == 1 && offset >= 0 {